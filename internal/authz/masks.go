@@ -0,0 +1,172 @@
+package authz
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/formula"
+)
+
+// Mask rules of a field scope. A token reads a masked field as its masked value, and cannot
+// filter, sort or search records by it, so the value cannot be inferred either.
+const (
+	MaskRedact = "redact" // "****"
+	MaskLast4  = "last4"  // the last 4 characters, such as "****1234"
+	MaskHash   = "hash"   // the SHA-256 of the value, such as "sha256:9f86d0…"
+	MaskYear   = "year"   // the year of a date or datetime, such as "1990"
+)
+
+const maskedText = "****"
+
+func validMaskRule(rule string) bool {
+	switch rule {
+	case MaskRedact, MaskLast4, MaskHash, MaskYear:
+		return true
+	}
+	return false
+}
+
+// FieldMasks returns the mask rules of the token on the fields of a table, keyed by field name,
+// or nil when it reads every field unmasked. A rule keyed by field ID wins over one keyed by name.
+func (a *Authorizer) FieldMasks(tableID string, fields []models.Field) map[string]string {
+	if a.IsMaster() {
+		return nil
+	}
+	scope, ok := a.scopes.Tables[tableID]
+	if !ok || len(scope.Masks) == 0 {
+		return nil
+	}
+	masks := make(map[string]string)
+	for _, field := range fields {
+		if rule, ok := scope.Masks[field.ID]; ok {
+			masks[field.Name] = rule
+		} else if rule, ok := scope.Masks[field.Name]; ok {
+			masks[field.Name] = rule
+		}
+	}
+	if len(masks) == 0 {
+		return nil
+	}
+	// A formula reveals the fields it reads, so one reading a masked field is redacted.
+	for name, reads := range FormulaReads(fields) {
+		if _, ok := masks[name]; ok {
+			continue
+		}
+		for _, read := range reads {
+			if _, ok := masks[read]; ok {
+				masks[name] = MaskRedact
+				break
+			}
+		}
+	}
+	return masks
+}
+
+// FormulaReads returns the fields each formula field of a table reads, directly or through the
+// formulas it references, keyed by formula field name. Formulas that do not parse read nothing.
+func FormulaReads(fields []models.Field) map[string][]string {
+	refs := make(map[string][]string)
+	for _, field := range fields {
+		if field.Type != "formula" {
+			continue
+		}
+		var config struct {
+			Formula string `json:"formula"`
+		}
+		_ = json.Unmarshal([]byte(field.Options), &config)
+		expr, err := formula.Parse(config.Formula)
+		if err != nil {
+			continue
+		}
+		refs[field.Name] = expr.References()
+	}
+
+	reads := make(map[string][]string, len(refs))
+	for name := range refs {
+		seen := map[string]bool{name: true}
+		var visit func(string)
+		visit = func(current string) {
+			for _, ref := range refs[current] {
+				if seen[ref] {
+					continue
+				}
+				seen[ref] = true
+				reads[name] = append(reads[name], ref)
+				visit(ref)
+			}
+		}
+		visit(name)
+	}
+	return reads
+}
+
+// MaskedTableIDs returns the IDs of the tables the token reads masked fields of.
+func (a *Authorizer) MaskedTableIDs() []string {
+	if a.IsMaster() {
+		return nil
+	}
+	var ids []string
+	for tableID, scope := range a.scopes.Tables {
+		if len(scope.Masks) > 0 {
+			ids = append(ids, tableID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// MaskValue returns a field value masked by a rule. Empty values stay empty, so masking does not
+// hide whether a field is set.
+func MaskValue(rule string, value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	text := maskInput(value)
+	if text == "" {
+		return ""
+	}
+	switch rule {
+	case MaskLast4:
+		runes := []rune(text)
+		if len(runes) <= 4 {
+			return maskedText
+		}
+		return maskedText + string(runes[len(runes)-4:])
+	case MaskHash:
+		sum := sha256.Sum256([]byte(text))
+		return "sha256:" + hex.EncodeToString(sum[:])
+	case MaskYear:
+		for _, layout := range []string{"2006-01-02", time.RFC3339, time.RFC3339Nano, "2006-01-02 15:04:05"} {
+			if t, err := time.Parse(layout, text); err == nil {
+				return strconv.Itoa(t.Year())
+			}
+		}
+		return maskedText
+	default:
+		return maskedText
+	}
+}
+
+// maskInput returns the text a mask rule applies to: strings as they are, numbers without an
+// exponent, and other values as JSON.
+func maskInput(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(encoded)
+}
@@ -92,14 +92,24 @@ const (
 	ActionManage = "manage"
 )
 
+// SubjectPlaceholder is the row filter value standing for the subject of the token.
+const SubjectPlaceholder = "$subject"
+
 type TableScope struct {
 	Role   string              `json:"role"`
 	Fields map[string][]string `json:"fields,omitempty"`
+	// Rows limits the records of the table the token can access to those whose fields, by
+	// name or ID, equal the given values. A SubjectPlaceholder value stands for the subject.
+	Rows map[string]interface{} `json:"rows,omitempty"`
+	// Masks maps readable fields, by name or ID, to the mask rule their values are read with.
+	Masks map[string]string `json:"masks,omitempty"`
 }
 
 type ScopeConfig struct {
 	Databases map[string]string     `json:"databases"`
 	Tables    map[string]TableScope `json:"tables"`
+	// Subject identifies who the token acts for, such as an email address, for row filters.
+	Subject string `json:"subject,omitempty"`
 }
 
 func NewAuthorizer(db *gorm.DB, tokenID string) (*Authorizer, error) {
@@ -191,9 +201,83 @@ func parseScopes(raw string) (ScopeConfig, error) {
 	if scopes.Tables == nil {
 		scopes.Tables = map[string]TableScope{}
 	}
+	for tableID, scope := range scopes.Tables {
+		if err := validateRowFilter(scope.Rows, scopes.Subject); err != nil {
+			return ScopeConfig{}, fmt.Errorf("invalid row filter of table %s: %w", tableID, err)
+		}
+		for field, rule := range scope.Masks {
+			if !validMaskRule(rule) {
+				return ScopeConfig{}, fmt.Errorf("invalid mask of field %s in table %s: unknown rule '%s'", field, tableID, rule)
+			}
+		}
+	}
 	return scopes, nil
 }
 
+// validateRowFilter checks that a row filter compares fields to strings, numbers or booleans,
+// and that a filter on the subject has one to compare to.
+func validateRowFilter(rows map[string]interface{}, subject string) error {
+	for field, value := range rows {
+		if strings.TrimSpace(field) == "" {
+			return errors.New("field name cannot be empty")
+		}
+		switch v := value.(type) {
+		case string:
+			if v == SubjectPlaceholder && strings.TrimSpace(subject) == "" {
+				return fmt.Errorf("field '%s' is compared to %s, but the token has no subject", field, SubjectPlaceholder)
+			}
+		case float64, bool:
+		default:
+			return fmt.Errorf("field '%s' must equal a string, number or boolean", field)
+		}
+	}
+	return nil
+}
+
+// RenameScopedField moves the field scope, row filter and mask entries of a table from oldName
+// to newName in raw token scopes. Entries keyed by field ID are left alone. It reports false
+// when raw has no entry for oldName.
+func RenameScopedField(raw, tableID, oldName, newName string) (string, bool, error) {
+	scopes, err := parseScopes(raw)
+	if err != nil {
+		return raw, false, err
+	}
+	scope, ok := scopes.Tables[tableID]
+	if !ok {
+		return raw, false, nil
+	}
+	actions, hasField := scope.Fields[oldName]
+	value, hasRow := scope.Rows[oldName]
+	rule, hasMask := scope.Masks[oldName]
+	if !hasField && !hasRow && !hasMask {
+		return raw, false, nil
+	}
+	if hasField {
+		delete(scope.Fields, oldName)
+		for _, action := range scope.Fields[newName] {
+			if !containsAction(actions, action) {
+				actions = append(actions, action)
+			}
+		}
+		scope.Fields[newName] = actions
+	}
+	if hasRow {
+		delete(scope.Rows, oldName)
+		scope.Rows[newName] = value
+	}
+	if hasMask {
+		delete(scope.Masks, oldName)
+		scope.Masks[newName] = rule
+	}
+	scopes.Tables[tableID] = scope
+
+	encoded, err := json.Marshal(scopes)
+	if err != nil {
+		return raw, false, fmt.Errorf("failed to encode token scopes: %w", err)
+	}
+	return string(encoded), true, nil
+}
+
 func (a *Authorizer) IsMaster() bool {
 	return a != nil && a.token.IsMaster
 }
@@ -338,6 +422,61 @@ func (a *Authorizer) CanAccessFields(fieldIDs []string, action string) map[strin
 	return results
 }
 
+// RowFilter returns the row filter of the token on a table, with the subject in place of
+// SubjectPlaceholder, or nil when the token can access every record of the table.
+func (a *Authorizer) RowFilter(tableID string) map[string]interface{} {
+	if a.IsMaster() {
+		return nil
+	}
+	scope, ok := a.scopes.Tables[tableID]
+	if !ok || len(scope.Rows) == 0 {
+		return nil
+	}
+	filter := make(map[string]interface{}, len(scope.Rows))
+	for field, value := range scope.Rows {
+		if value == SubjectPlaceholder {
+			value = a.scopes.Subject
+		}
+		filter[field] = value
+	}
+	return filter
+}
+
+// RowFilterMatches reports whether the data of a record, keyed by field name, passes a row
+// filter. Filter keys name a field or hold its ID; a key matching none of fields matches no record.
+func RowFilterMatches(filter map[string]interface{}, fields []models.Field, data map[string]interface{}) bool {
+	for key, expected := range filter {
+		name := ""
+		for _, field := range fields {
+			if field.Name == key || field.ID == key {
+				name = field.Name
+				break
+			}
+		}
+		if name == "" {
+			return false
+		}
+		actual, exists := data[name]
+		if !exists || !rowValuesEqual(actual, expected) {
+			return false
+		}
+	}
+	return true
+}
+
+// rowValuesEqual compares values by their JSON encoding, as stored record data is compared.
+func rowValuesEqual(actual, expected interface{}) bool {
+	actualJSON, err := json.Marshal(actual)
+	if err != nil {
+		return false
+	}
+	expectedJSON, err := json.Marshal(expected)
+	if err != nil {
+		return false
+	}
+	return string(actualJSON) == string(expectedJSON)
+}
+
 func containsAction(actions []string, action string) bool {
 	for _, candidate := range actions {
 		if strings.EqualFold(strings.TrimSpace(candidate), action) {
@@ -433,12 +572,59 @@ func (a *Authorizer) AccessibleRecordIDs() ([]string, error) {
 		return []string{}, nil
 	}
 
-	var ids []string
-	if err := a.db.Model(&models.Record{}).
-		Where("deleted_at IS NULL AND table_id IN ?", tableIDs).
-		Pluck("id", &ids).Error; err != nil {
+	// Tables with a row filter are read record by record; the others by table.
+	var unfiltered, filtered []string
+	for _, tableID := range tableIDs {
+		if a.RowFilter(tableID) != nil {
+			filtered = append(filtered, tableID)
+		} else {
+			unfiltered = append(unfiltered, tableID)
+		}
+	}
+
+	ids := []string{}
+	if len(unfiltered) > 0 {
+		if err := a.db.Model(&models.Record{}).
+			Where("deleted_at IS NULL AND table_id IN ?", unfiltered).
+			Pluck("id", &ids).Error; err != nil {
+			return nil, err
+		}
+	}
+	for _, tableID := range filtered {
+		matched, err := a.rowFilteredRecordIDs(tableID)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, matched...)
+	}
+	return ids, nil
+}
+
+// rowFilteredRecordIDs returns the IDs of the live records of a table passing the row filter
+// of the token on it.
+func (a *Authorizer) rowFilteredRecordIDs(tableID string) ([]string, error) {
+	var fields []models.Field
+	if err := a.db.Where("table_id = ? AND deleted_at IS NULL", tableID).Find(&fields).Error; err != nil {
+		return nil, err
+	}
+	var records []models.Record
+	if err := a.db.Select("id", "data").
+		Where("table_id = ? AND deleted_at IS NULL", tableID).
+		Find(&records).Error; err != nil {
 		return nil, err
 	}
+
+	filter := a.RowFilter(tableID)
+	ids := make([]string, 0, len(records))
+	for _, record := range records {
+		var data map[string]interface{}
+		if err := json.Unmarshal([]byte(record.Data), &data); err != nil {
+			continue
+		}
+		if RowFilterMatches(filter, fields, data) {
+			ids = append(ids, record.ID)
+		}
+	}
 	return ids, nil
 }
 
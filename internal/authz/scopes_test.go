@@ -685,3 +685,163 @@ func TestContainsAction(t *testing.T) {
 	assert.False(t, containsAction(nil, "read"))
 	assert.False(t, containsAction([]string{}, "read"))
 }
+
+func TestRenameScopedField(t *testing.T) {
+	raw := `{"tables":{"tbl_1":{"role":"viewer","fields":{"price":["read"],"cost":["write"],"fld_1":["read"]}},"tbl_2":{"role":"viewer","fields":{"price":["read"]}}}}`
+
+	renamed, changed, err := RenameScopedField(raw, "tbl_1", "price", "cost")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	scopes, err := parseScopes(renamed)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{"cost": {"read", "write"}, "fld_1": {"read"}}, scopes.Tables["tbl_1"].Fields)
+	assert.Equal(t, []string{"read"}, scopes.Tables["tbl_2"].Fields["price"])
+
+	filtered := `{"tables":{"tbl_1":{"role":"viewer","rows":{"price":1}}}}`
+	renamed, changed, err = RenameScopedField(filtered, "tbl_1", "price", "cost")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	scopes, err = parseScopes(renamed)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"cost": float64(1)}, scopes.Tables["tbl_1"].Rows)
+
+	masked := `{"tables":{"tbl_1":{"role":"viewer","masks":{"price":"redact"}}}}`
+	renamed, changed, err = RenameScopedField(masked, "tbl_1", "price", "cost")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	scopes, err = parseScopes(renamed)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"cost": MaskRedact}, scopes.Tables["tbl_1"].Masks)
+
+	unchanged, changed, err := RenameScopedField(raw, "tbl_3", "price", "cost")
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, raw, unchanged)
+}
+
+func TestParseScopes_RowFilter(t *testing.T) {
+	scopes, err := parseScopes(`{"subject":"alice","tables":{"tbl_1":{"role":"viewer","rows":{"owner":"$subject","open":true}}}}`)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", scopes.Subject)
+	assert.Equal(t, map[string]interface{}{"owner": "$subject", "open": true}, scopes.Tables["tbl_1"].Rows)
+
+	_, err = parseScopes(`{"tables":{"tbl_1":{"role":"viewer","rows":{"owner":"$subject"}}}}`)
+	assert.ErrorContains(t, err, "no subject")
+	_, err = parseScopes(`{"tables":{"tbl_1":{"role":"viewer","rows":{"owner":["a","b"]}}}}`)
+	assert.ErrorContains(t, err, "must equal a string, number or boolean")
+	_, err = parseScopes(`{"tables":{"tbl_1":{"role":"viewer","rows":{" ":"a"}}}}`)
+	assert.ErrorContains(t, err, "field name cannot be empty")
+}
+
+func TestRowFilter(t *testing.T) {
+	d := setupDB(t)
+	_, tbl1, fields := createTestData(t, d)
+	scopes := `{"subject":"alice","tables":{"` + tbl1.ID + `":{"role":"viewer","rows":{"f1":"$subject","` + fields[1].ID + `":2}}}}`
+	worker := createNonMasterToken(t, d, scopes)
+	master := createMasterToken(t, d)
+	ClearTokenCache()
+
+	wa, err := NewAuthorizer(d, worker.ID)
+	require.NoError(t, err)
+	filter := wa.RowFilter(tbl1.ID)
+	assert.Equal(t, map[string]interface{}{"f1": "alice", fields[1].ID: float64(2)}, filter)
+	assert.Nil(t, wa.RowFilter("tbl_other"))
+
+	ma, err := NewAuthorizer(d, master.ID)
+	require.NoError(t, err)
+	assert.Nil(t, ma.RowFilter(tbl1.ID))
+
+	tableFields := []models.Field{*fields[0], *fields[1]}
+	assert.True(t, RowFilterMatches(filter, tableFields, map[string]interface{}{"f1": "alice", "f2": 2}))
+	assert.False(t, RowFilterMatches(filter, tableFields, map[string]interface{}{"f1": "bob", "f2": 2}))
+	assert.False(t, RowFilterMatches(filter, tableFields, map[string]interface{}{"f1": "alice"}))
+	assert.False(t, RowFilterMatches(map[string]interface{}{"missing": "x"}, tableFields, map[string]interface{}{"missing": "x"}))
+}
+
+func TestAccessibleRecordIDs_RowFilter(t *testing.T) {
+	d := setupDB(t)
+	_, tbl1, _ := createTestData(t, d)
+	mine := &models.Record{TableID: tbl1.ID, Data: `{"f1":"alice"}`, Version: 1}
+	theirs := &models.Record{TableID: tbl1.ID, Data: `{"f1":"bob"}`, Version: 1}
+	require.NoError(t, d.Create(mine).Error)
+	require.NoError(t, d.Create(theirs).Error)
+	worker := createNonMasterToken(t, d, `{"subject":"alice","tables":{"`+tbl1.ID+`":{"role":"viewer","rows":{"f1":"$subject"}}}}`)
+	ClearTokenCache()
+
+	wa, _ := NewAuthorizer(d, worker.ID)
+	ids, err := wa.AccessibleRecordIDs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{mine.ID}, ids)
+}
+
+func TestParseScopes_Masks(t *testing.T) {
+	scopes, err := parseScopes(`{"tables":{"tbl_1":{"role":"viewer","masks":{"card":"last4","email":"hash"}}}}`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"card": MaskLast4, "email": MaskHash}, scopes.Tables["tbl_1"].Masks)
+
+	_, err = parseScopes(`{"tables":{"tbl_1":{"role":"viewer","masks":{"card":"first4"}}}}`)
+	assert.ErrorContains(t, err, "unknown rule 'first4'")
+}
+
+func TestFieldMasks(t *testing.T) {
+	d := setupDB(t)
+	_, tbl1, fields := createTestData(t, d)
+	worker := createNonMasterToken(t, d, `{"tables":{"`+tbl1.ID+`":{"role":"viewer","masks":{"f1":"redact","`+fields[1].ID+`":"last4"}}}}`)
+	master := createMasterToken(t, d)
+	ClearTokenCache()
+
+	tableFields := []models.Field{*fields[0], *fields[1]}
+	wa, err := NewAuthorizer(d, worker.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"f1": MaskRedact, "f2": MaskLast4}, wa.FieldMasks(tbl1.ID, tableFields))
+	assert.Nil(t, wa.FieldMasks("tbl_other", tableFields))
+	assert.Equal(t, []string{tbl1.ID}, wa.MaskedTableIDs())
+
+	ma, err := NewAuthorizer(d, master.ID)
+	require.NoError(t, err)
+	assert.Nil(t, ma.FieldMasks(tbl1.ID, tableFields))
+	assert.Nil(t, ma.MaskedTableIDs())
+
+	formulas := append(tableFields,
+		models.Field{TableID: tbl1.ID, Name: "upper", Type: "formula", Options: `{"formula":"f1 & \"!\""}`},
+		models.Field{TableID: tbl1.ID, Name: "nested", Type: "formula", Options: `{"formula":"upper"}`},
+		models.Field{TableID: tbl1.ID, Name: "constant", Type: "formula", Options: `{"formula":"1 + 1"}`},
+	)
+	assert.Equal(t, map[string]string{"f1": MaskRedact, "f2": MaskLast4, "upper": MaskRedact, "nested": MaskRedact}, wa.FieldMasks(tbl1.ID, formulas))
+}
+
+func TestFormulaReads(t *testing.T) {
+	reads := FormulaReads([]models.Field{
+		{Name: "price", Type: "number"},
+		{Name: "quantity", Type: "number"},
+		{Name: "total", Type: "formula", Options: `{"formula":"price * quantity"}`},
+		{Name: "taxed", Type: "formula", Options: `{"formula":"total * 1.2 + price"}`},
+		{Name: "broken", Type: "formula", Options: `{"formula":"price *"}`},
+	})
+	assert.Equal(t, map[string][]string{
+		"total": {"price", "quantity"},
+		"taxed": {"total", "price", "quantity"},
+	}, reads)
+}
+
+func TestMaskValue(t *testing.T) {
+	tests := []struct {
+		rule  string
+		value interface{}
+		want  interface{}
+	}{
+		{MaskRedact, "secret", "****"},
+		{MaskLast4, "4111 1111 1111 1234", "****1234"},
+		{MaskLast4, float64(4111111111111234), "****1234"},
+		{MaskLast4, "123", "****"},
+		{MaskHash, "test", "sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"},
+		{MaskYear, "1990-05-17", "1990"},
+		{MaskYear, "1990-05-17T08:00:00Z", "1990"},
+		{MaskYear, "not a date", "****"},
+		{MaskHash, nil, nil},
+		{MaskLast4, "", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, MaskValue(tt.rule, tt.value), "%s(%v)", tt.rule, tt.value)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -12,6 +13,7 @@ import (
 	"github.com/jiangfire/cornerstone/internal/services"
 	pkgdb "github.com/jiangfire/cornerstone/pkg/db"
 	"github.com/jiangfire/cornerstone/pkg/dto"
+	"github.com/jiangfire/cornerstone/pkg/xlsx"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -311,6 +313,29 @@ func TestDBDeleteCmd_Success(t *testing.T) {
 	assert.Contains(t, out, "deleted")
 }
 
+func TestDBExportCmd_Success(t *testing.T) {
+	setupCLIEnv(t)
+	dbSvc := services.NewDatabaseService(pkgdb.DB())
+	createdDB, err := dbSvc.CreateDatabase(dto.DatabaseCreateRequest{Name: "exportdb"}, "cs_test_master_token")
+	require.NoError(t, err)
+	tblSvc := services.NewTableService(pkgdb.DB())
+	for _, name := range []string{"first", "second"} {
+		_, err := tblSvc.CreateTable(dto.TableCreateRequest{DatabaseID: createdDB.ID, Name: name}, "cs_test_master_token")
+		require.NoError(t, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "export.xlsx")
+	require.NoError(t, dbExportCmd.Flags().Set("output", path))
+	defer func() { _ = dbExportCmd.Flags().Set("output", "") }()
+	require.NoError(t, dbExportCmd.RunE(dbExportCmd, []string{createdDB.ID}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	workbook, err := xlsx.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"first", "second"}, workbook.SheetNames())
+}
+
 func TestDBListCmd_NoMasterToken(t *testing.T) {
 	setupCLIEnv(t)
 	t.Setenv("MASTER_TOKEN", "")
@@ -509,6 +534,49 @@ func TestRecordListCmd_Success(t *testing.T) {
 	assert.Contains(t, out, "records")
 }
 
+func TestRecordExportCmd_Success(t *testing.T) {
+	setupCLIEnv(t)
+	dbSvc := services.NewDatabaseService(pkgdb.DB())
+	createdDB, err := dbSvc.CreateDatabase(dto.DatabaseCreateRequest{Name: "recexportdb"}, "cs_test_master_token")
+	require.NoError(t, err)
+	tblSvc := services.NewTableService(pkgdb.DB())
+	createdTbl, err := tblSvc.CreateTable(dto.TableCreateRequest{
+		DatabaseID: createdDB.ID,
+		Name:       "rectbl",
+	}, "cs_test_master_token")
+	require.NoError(t, err)
+
+	out := captureOutput(t, func() {
+		err := recordExportCmd.RunE(recordExportCmd, []string{createdTbl.ID})
+		require.NoError(t, err)
+	})
+	assert.Equal(t, "id,version\n", out)
+}
+
+func TestRecordImportCmd_Success(t *testing.T) {
+	setupCLIEnv(t)
+	dbSvc := services.NewDatabaseService(pkgdb.DB())
+	createdDB, err := dbSvc.CreateDatabase(dto.DatabaseCreateRequest{Name: "recimportdb"}, "cs_test_master_token")
+	require.NoError(t, err)
+	tblSvc := services.NewTableService(pkgdb.DB())
+	createdTbl, err := tblSvc.CreateTable(dto.TableCreateRequest{
+		DatabaseID: createdDB.ID,
+		Name:       "rectbl",
+	}, "cs_test_master_token")
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "rows.csv")
+	require.NoError(t, os.WriteFile(path, []byte("title,count\na,1\nb,x\n"), 0o600))
+	require.NoError(t, recordImportCmd.Flags().Set("create-fields", "true"))
+	defer func() { _ = recordImportCmd.Flags().Set("create-fields", "false") }()
+
+	out := captureOutput(t, func() {
+		err := recordImportCmd.RunE(recordImportCmd, []string{createdTbl.ID, path})
+		require.NoError(t, err)
+	})
+	assert.Contains(t, out, "imported 2 of 2 rows, 0 failed")
+}
+
 func TestRecordCreateCmd_InvalidJSON(t *testing.T) {
 	setupCLIEnv(t)
 	err := recordCreateCmd.RunE(recordCreateCmd, []string{"tbl_x", "not-json"})
@@ -133,7 +133,7 @@ func printJSON(v interface{}) error {
 var dbCmd = &cobra.Command{
 	Use:   "db",
 	Short: "database management",
-	Long:  `Manage Cornerstone database resources. Supports list, create, get, update, delete subcommands.`,
+	Long:  `Manage Cornerstone database resources. Supports list, create, get, update, delete, import, export subcommands.`,
 }
 
 var dbListCmd = &cobra.Command{
@@ -294,6 +294,45 @@ var dbImportCmd = &cobra.Command{
 	},
 }
 
+var dbExportCmd = &cobra.Command{
+	Use:   "export [id-or-name]",
+	Short: "export every table of a database as an xlsx workbook",
+	Long: `Export the records of every table of a database to stdout, or to --output, as an xlsx
+workbook with a sheet per table.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ensureDB(); err != nil {
+			return err
+		}
+		defer func() { _ = appdb.CloseDB() }()
+
+		format, _ := cmd.Flags().GetString("format")
+		output, _ := cmd.Flags().GetString("output")
+		token, err := getAuthTokenID()
+		if err != nil {
+			return err
+		}
+		svc := services.NewRecordService(db.DB())
+		export, err := svc.ExportDatabase(args[0], format, token)
+		if err != nil {
+			return err
+		}
+
+		if output == "" || output == "-" {
+			return export.Stream(os.Stdout)
+		}
+		file, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		if err := export.Stream(file); err != nil {
+			_ = file.Close()
+			return err
+		}
+		return file.Close()
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(dbCmd)
 	dbCmd.AddCommand(dbListCmd)
@@ -302,9 +341,12 @@ func init() {
 	dbCmd.AddCommand(dbUpdateCmd)
 	dbCmd.AddCommand(dbDeleteCmd)
 	dbCmd.AddCommand(dbImportCmd)
+	dbCmd.AddCommand(dbExportCmd)
 
 	dbCreateCmd.Flags().StringP("description", "d", "", "database description")
 	dbUpdateCmd.Flags().StringP("name", "n", "", "new name")
 	dbUpdateCmd.Flags().StringP("description", "d", "", "new description")
 	dbImportCmd.Flags().StringP("file", "f", "", "path to YAML file")
+	dbExportCmd.Flags().String("format", "xlsx", "export format: xlsx")
+	dbExportCmd.Flags().StringP("output", "o", "", "output file, stdout by default")
 }
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 
 	appdb "github.com/jiangfire/cornerstone/internal/db"
@@ -43,7 +44,19 @@ var fieldCreateCmd = &cobra.Command{
 	Use:   "create [table-id-or-name] [name] [type]",
 	Short: "create a field in a table",
 	Long: `Create a field in a table. Supported types:
-  string, text, number, boolean, date, datetime, file, json, list`,
+  string, text, number, boolean, date, datetime, file, json, list, link, formula, autonumber
+
+Type-specific settings are passed as JSON via --config, e.g. a link to another table:
+  cornerstone field create orders customer link --config '{"link_table_id":"customers","on_delete":"restrict"}'
+a formula computed from other fields of the table:
+  cornerstone field create orders total formula --config '{"formula":"price * quantity"}'
+a sequential number such as INV-000123:
+  cornerstone field create orders number autonumber --config '{"prefix":"INV-","padding":6,"start":1}'
+a field whose values must be unique:
+  cornerstone field create customers email string --config '{"unique":true}'
+or a default filled in when a record is created without the field (static, or one of now, today, token_name, uuid):
+  cornerstone field create orders status string --config '{"default":"new"}'
+  cornerstone field create orders created_on datetime --config '{"default_func":"now"}'`,
 	Args: cobra.ExactArgs(3),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if err := ensureDB(); err != nil {
@@ -54,6 +67,10 @@ var fieldCreateCmd = &cobra.Command{
 		desc, _ := cmd.Flags().GetString("description")
 		required, _ := cmd.Flags().GetBool("required")
 		options, _ := cmd.Flags().GetString("options")
+		config, err := parseFieldConfigFlag(cmd)
+		if err != nil {
+			return err
+		}
 		token, err := getAuthTokenID()
 		if err != nil {
 			return err
@@ -66,6 +83,7 @@ var fieldCreateCmd = &cobra.Command{
 			Description: desc,
 			Required:    required,
 			Options:     options,
+			Config:      config,
 		}, token)
 		if err != nil {
 			return err
@@ -100,6 +118,9 @@ var fieldGetCmd = &cobra.Command{
 var fieldUpdateCmd = &cobra.Command{
 	Use:   "update [id]",
 	Short: "update a field",
+	Long: `Update a field. Changing the type converts the values of existing records, e.g.:
+  cornerstone field update fld_x --name price --type number --dry-run
+  cornerstone field update fld_x --name due --type date --date-format 02/01/2006 --on-failure null`,
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if err := ensureDB(); err != nil {
@@ -112,21 +133,37 @@ var fieldUpdateCmd = &cobra.Command{
 		desc, _ := cmd.Flags().GetString("description")
 		required, _ := cmd.Flags().GetBool("required")
 		options, _ := cmd.Flags().GetString("options")
+		config, err := parseFieldConfigFlag(cmd)
+		if err != nil {
+			return err
+		}
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		onFailure, _ := cmd.Flags().GetString("on-failure")
+		dateFormat, _ := cmd.Flags().GetString("date-format")
 		token, err := getAuthTokenID()
 		if err != nil {
 			return err
 		}
 		svc := services.NewFieldService(db.DB())
-		field, err := svc.UpdateField(args[0], dto.FieldUpdateRequest{
+		field, report, err := svc.UpdateFieldWithReport(args[0], dto.FieldUpdateRequest{
 			Name:        name,
 			Type:        fieldType,
 			Description: desc,
 			Required:    required,
 			Options:     options,
+			Config:      config,
+			Conversion: &dto.FieldConversion{
+				DryRun:     dryRun,
+				OnFailure:  onFailure,
+				DateFormat: dateFormat,
+			},
 		}, token)
 		if err != nil {
 			return err
 		}
+		if dryRun {
+			return printJSON(report)
+		}
 		return printJSON(field)
 	},
 }
@@ -157,6 +194,19 @@ var fieldDeleteCmd = &cobra.Command{
 	},
 }
 
+// parseFieldConfigFlag decodes the --config JSON flag into a field config.
+func parseFieldConfigFlag(cmd *cobra.Command) (dto.FieldConfig, error) {
+	var config dto.FieldConfig
+	raw, _ := cmd.Flags().GetString("config")
+	if raw == "" {
+		return config, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &config); err != nil {
+		return config, fmt.Errorf("invalid --config JSON: %w", err)
+	}
+	return config, nil
+}
+
 func init() {
 	rootCmd.AddCommand(fieldCmd)
 	fieldCmd.AddCommand(fieldListCmd)
@@ -168,10 +218,15 @@ func init() {
 	fieldCreateCmd.Flags().StringP("description", "d", "", "field description")
 	fieldCreateCmd.Flags().BoolP("required", "r", false, "mark field as required")
 	fieldCreateCmd.Flags().StringP("options", "o", "", "options (comma-separated)")
+	fieldCreateCmd.Flags().String("config", "", "field config as JSON, e.g. '{\"link_table_id\":\"tbl_x\"}'")
 
 	fieldUpdateCmd.Flags().StringP("name", "n", "", "new name")
 	fieldUpdateCmd.Flags().StringP("type", "t", "", "new type")
 	fieldUpdateCmd.Flags().StringP("description", "d", "", "new description")
 	fieldUpdateCmd.Flags().BoolP("required", "r", false, "mark field as required")
 	fieldUpdateCmd.Flags().StringP("options", "o", "", "options (comma-separated)")
+	fieldUpdateCmd.Flags().String("config", "", "field config as JSON, e.g. '{\"link_table_id\":\"tbl_x\"}'")
+	fieldUpdateCmd.Flags().Bool("dry-run", false, "report how existing values would convert to the new type without saving")
+	fieldUpdateCmd.Flags().String("on-failure", "abort", "values that cannot be converted: abort, null or keep")
+	fieldUpdateCmd.Flags().String("date-format", "", "Go time layout for converting strings to dates, e.g. 02/01/2006")
 }
@@ -3,6 +3,8 @@ package cli
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"strconv"
 
 	appdb "github.com/jiangfire/cornerstone/internal/db"
@@ -16,7 +18,7 @@ import (
 var recordCmd = &cobra.Command{
 	Use:   "record",
 	Short: "record management",
-	Long:  `Manage Cornerstone record resources. Supports list, create, get, update, delete, batch subcommands.`,
+	Long:  `Manage Cornerstone record resources. Supports list, create, get, update, delete, batch, bulk, upsert, export, import subcommands.`,
 }
 
 func recordForJSON(record *models.Record) (map[string]interface{}, error) {
@@ -67,7 +69,11 @@ var recordListCmd = &cobra.Command{
 
 		limit, _ := cmd.Flags().GetInt("limit")
 		offset, _ := cmd.Flags().GetInt("offset")
+		after, _ := cmd.Flags().GetString("after")
+		before, _ := cmd.Flags().GetString("before")
 		filter, _ := cmd.Flags().GetString("filter")
+		sort, _ := cmd.Flags().GetString("sort")
+		q, _ := cmd.Flags().GetString("q")
 		token, err := getAuthTokenID()
 		if err != nil {
 			return err
@@ -77,7 +83,11 @@ var recordListCmd = &cobra.Command{
 			TableID: args[0],
 			Limit:   limit,
 			Offset:  offset,
+			After:   after,
+			Before:  before,
 			Filter:  filter,
+			Sort:    sort,
+			Q:       q,
 		}, token)
 		if err != nil {
 			return err
@@ -239,6 +249,225 @@ var recordBatchCmd = &cobra.Command{
 	},
 }
 
+var recordBulkCmd = &cobra.Command{
+	Use:   "bulk [table-id] --file records.json",
+	Short: "bulk insert records from a JSON array",
+	Long: `Insert one record per object of a JSON array read from --file ("-" reads stdin).
+
+With --mode atomic (default) all records are inserted or none; with --mode best_effort
+every valid record is inserted and the failing ones are reported.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ensureDB(); err != nil {
+			return err
+		}
+		defer func() { _ = appdb.CloseDB() }()
+
+		filePath, _ := cmd.Flags().GetString("file")
+		records, err := readRecordArrayFile(filePath)
+		if err != nil {
+			return err
+		}
+		mode, _ := cmd.Flags().GetString("mode")
+
+		token, err := getAuthTokenID()
+		if err != nil {
+			return err
+		}
+		svc := services.NewRecordService(db.DB())
+		result, err := svc.BulkInsertRecords(dto.RecordBulkInsertRequest{
+			TableID: args[0],
+			Records: records,
+			Mode:    mode,
+		}, token)
+		if err != nil {
+			return err
+		}
+		if !jsonOutput {
+			fmt.Printf("inserted %d of %d records, %d failed\n", result.Inserted, result.Total, result.Failed)
+		}
+		return printJSON(result)
+	},
+}
+
+var recordUpsertCmd = &cobra.Command{
+	Use:   "upsert [table-id] --key external_id --file records.json",
+	Short: "insert or update records by key fields",
+	Long: `Upsert one record per object of a JSON array read from --file ("-" reads stdin).
+
+The --key fields (repeatable) identify the record of each object: a matching record is
+updated, otherwise a new one is created. With --mode merge (default) the given fields are
+set and the others kept; with --mode replace the other writable fields are cleared.
+Each object is written on its own and the failing ones are reported.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ensureDB(); err != nil {
+			return err
+		}
+		defer func() { _ = appdb.CloseDB() }()
+
+		filePath, _ := cmd.Flags().GetString("file")
+		records, err := readRecordArrayFile(filePath)
+		if err != nil {
+			return err
+		}
+		keys, _ := cmd.Flags().GetStringSlice("key")
+		mode, _ := cmd.Flags().GetString("mode")
+		items := make([]dto.RecordUpsertItem, len(records))
+		for i, data := range records {
+			items[i] = dto.RecordUpsertItem{Data: data}
+		}
+
+		token, err := getAuthTokenID()
+		if err != nil {
+			return err
+		}
+		svc := services.NewRecordService(db.DB())
+		result, err := svc.UpsertRecords(dto.RecordUpsertRequest{
+			TableID:   args[0],
+			KeyFields: keys,
+			Records:   items,
+			Mode:      mode,
+		}, token)
+		if err != nil {
+			return err
+		}
+		if !jsonOutput {
+			fmt.Printf("inserted %d, updated %d of %d records, %d failed\n", result.Inserted, result.Updated, result.Total, result.Failed)
+		}
+		return printJSON(result)
+	},
+}
+
+var recordExportCmd = &cobra.Command{
+	Use:   "export [table-id]",
+	Short: "export records as csv, json, ndjson or xlsx",
+	Long: `Export the records of a table to stdout, or to --output. Records are streamed as they
+are read, so large tables export in constant memory. --filter, --fields and --sort work like
+record list.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ensureDB(); err != nil {
+			return err
+		}
+		defer func() { _ = appdb.CloseDB() }()
+
+		format, _ := cmd.Flags().GetString("format")
+		filter, _ := cmd.Flags().GetString("filter")
+		fields, _ := cmd.Flags().GetString("fields")
+		sort, _ := cmd.Flags().GetString("sort")
+		output, _ := cmd.Flags().GetString("output")
+		token, err := getAuthTokenID()
+		if err != nil {
+			return err
+		}
+		svc := services.NewRecordService(db.DB())
+		export, err := svc.ExportRecords(dto.RecordExportRequest{
+			TableID: args[0],
+			Format:  format,
+			Filter:  filter,
+			Fields:  fields,
+			Sort:    sort,
+		}, token)
+		if err != nil {
+			return err
+		}
+
+		if output == "" || output == "-" {
+			return export.Stream(os.Stdout)
+		}
+		file, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		if err := export.Stream(file); err != nil {
+			_ = file.Close()
+			return err
+		}
+		return file.Close()
+	},
+}
+
+var recordImportCmd = &cobra.Command{
+	Use:   "import [table-id] [file]",
+	Short: "import records from csv, json, ndjson or xlsx",
+	Long: `Import a record per row of a CSV file, JSON array, NDJSON file or the first sheet of an XLSX
+workbook ("-" reads stdin). The format is inferred from the file extension unless --format is
+set. Columns map to fields by --mapping, else by field name; with --create-fields, unmatched
+columns get new fields typed by their values. The file is streamed, and the rows that fail are reported by line.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ensureDB(); err != nil {
+			return err
+		}
+		defer func() { _ = appdb.CloseDB() }()
+
+		format, _ := cmd.Flags().GetString("format")
+		createFields, _ := cmd.Flags().GetBool("create-fields")
+		mappingJSON, _ := cmd.Flags().GetString("mapping")
+		var mapping map[string]string
+		if mappingJSON != "" {
+			if err := json.Unmarshal([]byte(mappingJSON), &mapping); err != nil {
+				return fmt.Errorf("invalid --mapping, expected a JSON object of column names to field names: %w", err)
+			}
+		}
+		if format == "" {
+			format = services.InferImportFormat("", args[1])
+		}
+
+		input := io.Reader(os.Stdin)
+		if args[1] != "-" {
+			file, err := os.Open(args[1])
+			if err != nil {
+				return fmt.Errorf("failed to open file: %w", err)
+			}
+			defer func() { _ = file.Close() }()
+			input = file
+		}
+
+		token, err := getAuthTokenID()
+		if err != nil {
+			return err
+		}
+		svc := services.NewRecordService(db.DB())
+		result, err := svc.ImportRecords(dto.RecordImportRequest{
+			TableID:      args[0],
+			Format:       format,
+			Mapping:      mapping,
+			CreateFields: createFields,
+		}, input, token)
+		if err != nil {
+			return err
+		}
+		if !jsonOutput {
+			fmt.Printf("imported %d of %d rows, %d failed\n", result.Inserted, result.Total, result.Failed)
+		}
+		return printJSON(result)
+	},
+}
+
+// readRecordArrayFile reads a JSON array of record objects from path, or from stdin for "-".
+func readRecordArrayFile(path string) ([]map[string]interface{}, error) {
+	if path == "" {
+		return nil, fmt.Errorf("--file is required")
+	}
+	var raw []byte
+	var err error
+	if path == "-" {
+		raw, err = io.ReadAll(os.Stdin)
+	} else {
+		raw, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	var records []map[string]interface{}
+	if err := json.Unmarshal(raw, &records); err != nil {
+		return nil, fmt.Errorf("invalid JSON array of records: %w", err)
+	}
+	return records, nil
+}
+
 func init() {
 	rootCmd.AddCommand(recordCmd)
 	recordCmd.AddCommand(recordListCmd)
@@ -247,10 +476,35 @@ func init() {
 	recordCmd.AddCommand(recordUpdateCmd)
 	recordCmd.AddCommand(recordDeleteCmd)
 	recordCmd.AddCommand(recordBatchCmd)
+	recordCmd.AddCommand(recordBulkCmd)
+	recordCmd.AddCommand(recordUpsertCmd)
+	recordCmd.AddCommand(recordExportCmd)
+	recordCmd.AddCommand(recordImportCmd)
 
 	recordListCmd.Flags().IntP("limit", "l", 20, "page size")
 	recordListCmd.Flags().IntP("offset", "o", 0, "offset")
+	recordListCmd.Flags().String("after", "", "list records after this cursor (next_cursor of a page)")
+	recordListCmd.Flags().String("before", "", "list records before this cursor (prev_cursor of a page)")
 	recordListCmd.Flags().StringP("filter", "f", "", "filter condition (JSON)")
+	recordListCmd.Flags().StringP("sort", "s", "", "sort fields, e.g. due_date,-priority:nulls_first")
+	recordListCmd.Flags().StringP("q", "q", "", "full-text search over string and text fields")
 
 	recordUpdateCmd.Flags().IntP("version", "v", 0, "optimistic lock version")
+
+	recordExportCmd.Flags().String("format", "csv", "export format: csv, json, ndjson or xlsx")
+	recordExportCmd.Flags().StringP("filter", "f", "", "filter condition (JSON) or keyword")
+	recordExportCmd.Flags().String("fields", "", "comma-separated field names to export")
+	recordExportCmd.Flags().StringP("sort", "s", "", "sort fields, e.g. due_date,-priority:nulls_first")
+	recordExportCmd.Flags().StringP("output", "o", "", "output file, stdout by default")
+
+	recordImportCmd.Flags().String("format", "", "file format: csv, json, ndjson or xlsx, inferred from the extension by default")
+	recordImportCmd.Flags().String("mapping", "", `JSON object mapping columns to field names, e.g. {"Full Name":"name"}`)
+	recordImportCmd.Flags().Bool("create-fields", false, "create fields for unmatched columns")
+
+	recordBulkCmd.Flags().StringP("file", "f", "", "JSON file with an array of record objects, - for stdin")
+	recordBulkCmd.Flags().String("mode", "atomic", "insert mode: atomic or best_effort")
+
+	recordUpsertCmd.Flags().StringP("file", "f", "", "JSON file with an array of record objects, - for stdin")
+	recordUpsertCmd.Flags().StringSliceP("key", "k", nil, "key field identifying a record (repeatable)")
+	recordUpsertCmd.Flags().String("mode", "merge", "update mode: merge or replace")
 }
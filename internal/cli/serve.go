@@ -126,12 +126,14 @@ func runServe(cmd *cobra.Command, args []string) error {
 			protected.GET("/databases/:id", handlers.GetDatabase)
 			protected.PUT("/databases/:id", handlers.UpdateDatabase)
 			protected.DELETE("/databases/:id", handlers.DeleteDatabase)
+			protected.GET("/databases/:id/export", handlers.ExportDatabase)
 
 			protected.POST("/tables", handlers.CreateTable)
 			protected.GET("/databases/:id/tables", handlers.ListTables)
 			protected.GET("/tables/:id", handlers.GetTable)
 			protected.PUT("/tables/:id", handlers.UpdateTable)
 			protected.DELETE("/tables/:id", handlers.DeleteTable)
+			protected.POST("/tables/:id/import", handlers.ImportRecords)
 
 			protected.POST("/fields", handlers.CreateField)
 			protected.GET("/tables/:id/fields", handlers.ListFields)
@@ -144,8 +146,13 @@ func runServe(cmd *cobra.Command, args []string) error {
 			protected.GET("/records/export", handlers.ExportRecords)
 			protected.GET("/records/:id", handlers.GetRecord)
 			protected.PUT("/records/:id", handlers.UpdateRecord)
+			protected.PATCH("/records/:id", handlers.PatchRecord)
 			protected.DELETE("/records/:id", handlers.DeleteRecord)
 			protected.POST("/records/batch", handlers.BatchCreateRecords)
+			protected.POST("/records/bulk", handlers.BulkInsertRecords)
+			protected.POST("/records/bulk-update", handlers.BulkUpdateRecords)
+			protected.POST("/records/bulk-delete", handlers.BulkDeleteRecords)
+			protected.POST("/records/upsert", handlers.UpsertRecords)
 
 			protected.POST("/files/upload", handlers.UploadFile)
 			protected.GET("/files/:id", handlers.GetFile)
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 
 	appdb "github.com/jiangfire/cornerstone/internal/db"
@@ -102,15 +103,23 @@ var tableUpdateCmd = &cobra.Command{
 
 		name, _ := cmd.Flags().GetString("name")
 		desc, _ := cmd.Flags().GetString("description")
+		req := dto.TableUpdateRequest{
+			Name:        name,
+			Description: desc,
+		}
+		if uniqueKeys, _ := cmd.Flags().GetString("unique-keys"); uniqueKeys != "" {
+			var keys []dto.UniqueKey
+			if err := json.Unmarshal([]byte(uniqueKeys), &keys); err != nil {
+				return fmt.Errorf("invalid --unique-keys JSON: %w", err)
+			}
+			req.UniqueKeys = &keys
+		}
 		token, err := getAuthTokenID()
 		if err != nil {
 			return err
 		}
 		svc := services.NewTableService(db.DB())
-		table, err := svc.UpdateTable(args[0], dto.TableUpdateRequest{
-			Name:        name,
-			Description: desc,
-		}, token)
+		table, err := svc.UpdateTable(args[0], req, token)
 		if err != nil {
 			return err
 		}
@@ -155,4 +164,5 @@ func init() {
 	tableCreateCmd.Flags().StringP("description", "d", "", "table description")
 	tableUpdateCmd.Flags().StringP("name", "n", "", "new name")
 	tableUpdateCmd.Flags().StringP("description", "d", "", "new description")
+	tableUpdateCmd.Flags().String("unique-keys", "", `composite unique keys as JSON, replacing the current ones, e.g. '[{"name":"sku_per_store","fields":["store","sku"]}]'; '[]' removes all`)
 }
@@ -82,6 +82,8 @@ func Migrate() error {
 		&models.Field{},
 		&models.Record{},
 		&models.RecordFieldIndex{},
+		&models.RecordUniqueKey{},
+		&models.RecordSearchDocument{},
 		&models.File{},
 	); err != nil {
 		return fmt.Errorf("auto migration failed: %w", err)
@@ -98,6 +100,12 @@ func Migrate() error {
 	if err := backfillRecordFieldIndexes(database); err != nil {
 		return fmt.Errorf("failed to backfill record field indexes: %w", err)
 	}
+	if err := backfillRecordLinkIndexes(database); err != nil {
+		return fmt.Errorf("failed to backfill record link indexes: %w", err)
+	}
+	if err := backfillRecordSearchDocuments(database); err != nil {
+		return fmt.Errorf("failed to backfill record search documents: %w", err)
+	}
 
 	masterToken := os.Getenv("MASTER_TOKEN")
 	if masterToken == "" {
@@ -155,6 +163,38 @@ func createIndexes(db *gorm.DB) error {
 		}
 	}
 
+	return createSearchIndexes(db)
+}
+
+// createSearchIndexes indexes the content of record search documents for full-text search.
+// SQLite keeps an external-content FTS5 table in sync through triggers; PostgreSQL uses a GIN
+// index on the tsvector the search queries compute and MySQL a FULLTEXT index.
+func createSearchIndexes(db *gorm.DB) error {
+	switch db.Name() {
+	case "sqlite":
+		for _, statement := range []string{
+			"CREATE VIRTUAL TABLE IF NOT EXISTS record_search_fts USING fts5(content, content='record_search_documents', content_rowid='id')",
+			"CREATE TRIGGER IF NOT EXISTS record_search_documents_ai AFTER INSERT ON record_search_documents BEGIN " +
+				"INSERT INTO record_search_fts(rowid, content) VALUES (new.id, new.content); END",
+			"CREATE TRIGGER IF NOT EXISTS record_search_documents_ad AFTER DELETE ON record_search_documents BEGIN " +
+				"INSERT INTO record_search_fts(record_search_fts, rowid, content) VALUES ('delete', old.id, old.content); END",
+			"CREATE TRIGGER IF NOT EXISTS record_search_documents_au AFTER UPDATE ON record_search_documents BEGIN " +
+				"INSERT INTO record_search_fts(record_search_fts, rowid, content) VALUES ('delete', old.id, old.content); " +
+				"INSERT INTO record_search_fts(rowid, content) VALUES (new.id, new.content); END",
+		} {
+			if err := db.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+	case "postgres":
+		return db.Exec("CREATE INDEX IF NOT EXISTS idx_record_search_documents_content ON record_search_documents USING GIN (to_tsvector('simple', content))").Error
+	case "mysql":
+		exists, err := indexExists(db, "record_search_documents", "idx_record_search_documents_content")
+		if err != nil || exists {
+			return err
+		}
+		return db.Exec("CREATE FULLTEXT INDEX idx_record_search_documents_content ON record_search_documents(content)").Error
+	}
 	return nil
 }
 
@@ -218,6 +258,99 @@ func backfillRecordFieldIndexes(db *gorm.DB) error {
 		}).Error
 }
 
+// backfillRecordLinkIndexes indexes the link values of records written before link fields were
+// indexed, by each record they reference, so the records linking to a deleted record are found.
+func backfillRecordLinkIndexes(db *gorm.DB) error {
+	var fields []models.Field
+	if err := db.Where("deleted_at IS NULL AND type = ?", "link").Find(&fields).Error; err != nil {
+		return err
+	}
+
+	for _, field := range fields {
+		err := db.Model(&models.Record{}).
+			Where("table_id = ? AND deleted_at IS NULL", field.TableID).
+			Where("NOT EXISTS (SELECT 1 FROM record_field_indexes rfi WHERE rfi.record_id = records.id AND rfi.field_id = ? AND rfi.deleted_at IS NULL)", field.ID).
+			FindInBatches(&[]models.Record{}, recordFieldIndexBackfillBatchSize, func(tx *gorm.DB, _ int) error {
+				records, ok := tx.Statement.Dest.(*[]models.Record)
+				if !ok || len(*records) == 0 {
+					return nil
+				}
+
+				var rows []models.RecordFieldIndex
+				for _, record := range *records {
+					payload := make(map[string]interface{})
+					if err := json.Unmarshal([]byte(record.Data), &payload); err != nil {
+						continue
+					}
+					rows = append(rows, buildBackfillLinkIndexRows(record, field, payload[field.Name])...)
+				}
+				if len(rows) == 0 {
+					return nil
+				}
+				return tx.CreateInBatches(&rows, 1000).Error
+			}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillRecordSearchDocuments indexes the text of existing records for full-text search
+// when the search documents are still empty, i.e. on the first migration that creates them.
+func backfillRecordSearchDocuments(db *gorm.DB) error {
+	var documents int64
+	if err := db.Model(&models.RecordSearchDocument{}).Count(&documents).Error; err != nil {
+		return err
+	}
+	if documents > 0 {
+		return nil
+	}
+
+	var fields []models.Field
+	if err := db.Where("deleted_at IS NULL AND type IN ?", []string{"string", "text"}).Find(&fields).Error; err != nil {
+		return err
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	fieldsByTable := make(map[string][]models.Field, len(fields))
+	for _, field := range fields {
+		fieldsByTable[field.TableID] = append(fieldsByTable[field.TableID], field)
+	}
+
+	return db.Model(&models.Record{}).
+		Where("deleted_at IS NULL").
+		FindInBatches(&[]models.Record{}, recordFieldIndexBackfillBatchSize, func(tx *gorm.DB, _ int) error {
+			records, ok := tx.Statement.Dest.(*[]models.Record)
+			if !ok || len(*records) == 0 {
+				return nil
+			}
+
+			var rows []models.RecordSearchDocument
+			for _, record := range *records {
+				payload := make(map[string]interface{})
+				if err := json.Unmarshal([]byte(record.Data), &payload); err != nil {
+					continue
+				}
+				for _, field := range fieldsByTable[record.TableID] {
+					if text, ok := payload[field.Name].(string); ok && strings.TrimSpace(text) != "" {
+						rows = append(rows, models.RecordSearchDocument{
+							TableID:  record.TableID,
+							RecordID: record.ID,
+							FieldID:  field.ID,
+							Content:  text,
+						})
+					}
+				}
+			}
+			if len(rows) == 0 {
+				return nil
+			}
+			return tx.CreateInBatches(&rows, 1000).Error
+		}).Error
+}
+
 func buildBackfillRecordFieldIndexRows(record models.Record, fields []models.Field, payload map[string]interface{}) []models.RecordFieldIndex {
 	rows := make([]models.RecordFieldIndex, 0, len(fields))
 	for _, field := range fields {
@@ -279,6 +412,38 @@ func buildBackfillRecordFieldIndexRow(record models.Record, field models.Field,
 	}
 }
 
+// buildBackfillLinkIndexRows indexes a link value, a record ID or an array of them, by each ID.
+func buildBackfillLinkIndexRows(record models.Record, field models.Field, value interface{}) []models.RecordFieldIndex {
+	var ids []string
+	switch v := value.(type) {
+	case string:
+		ids = []string{v}
+	case []interface{}:
+		for _, item := range v {
+			if id, ok := item.(string); ok {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	rows := make([]models.RecordFieldIndex, 0, len(ids))
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		rows = append(rows, models.RecordFieldIndex{
+			TableID:   record.TableID,
+			RecordID:  record.ID,
+			FieldID:   field.ID,
+			FieldName: field.Name,
+			ValueType: "text",
+			ValueText: id,
+		})
+	}
+	return rows
+}
+
 func backfillRecordFieldIndexNumber(value interface{}) (float64, bool) {
 	switch v := value.(type) {
 	case float64:
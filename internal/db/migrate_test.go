@@ -28,7 +28,7 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	require.NoError(t, err)
 
 	db := pkgdb.DB()
-	require.NoError(t, db.AutoMigrate(&models.Token{}, &models.Database{}, &models.Table{}, &models.Field{}, &models.Record{}, &models.RecordFieldIndex{}, &models.File{}))
+	require.NoError(t, db.AutoMigrate(&models.Token{}, &models.Database{}, &models.Table{}, &models.Field{}, &models.Record{}, &models.RecordFieldIndex{}, &models.RecordSearchDocument{}, &models.File{}))
 
 	// Cleanup function: hard-delete all test data
 	t.Cleanup(func() {
@@ -135,6 +135,7 @@ func TestMigrate(t *testing.T) {
 	assert.True(t, pkgdb.DB().Migrator().HasTable("fields"))
 	assert.True(t, pkgdb.DB().Migrator().HasTable("records"))
 	assert.True(t, pkgdb.DB().Migrator().HasTable("record_field_indexes"))
+	assert.True(t, pkgdb.DB().Migrator().HasTable("record_unique_keys"))
 	assert.True(t, pkgdb.DB().Migrator().HasTable("files"))
 }
 
@@ -199,6 +200,73 @@ func TestBackfillRecordFieldIndexes(t *testing.T) {
 	assert.Equal(t, int64(3), count)
 }
 
+func TestBackfillRecordLinkIndexes(t *testing.T) {
+	db := setupTestDB(t)
+
+	database := &models.Database{Name: "link_backfill_db"}
+	require.NoError(t, db.Create(database).Error)
+	table := &models.Table{DatabaseID: database.ID, Name: "orders"}
+	require.NoError(t, db.Create(table).Error)
+	titleField := &models.Field{TableID: table.ID, Name: "title", Type: "string"}
+	customersField := &models.Field{TableID: table.ID, Name: "customers", Type: "link"}
+	require.NoError(t, db.Create([]*models.Field{titleField, customersField}).Error)
+
+	record := &models.Record{
+		TableID: table.ID,
+		Data:    models.JSONField(`{"title":"order","customers":["rec_a","rec_b"]}`),
+		Version: 1,
+	}
+	require.NoError(t, db.Create(record).Error)
+	// Indexed before link fields were, so only the title has an index row.
+	require.NoError(t, backfillRecordFieldIndexes(db))
+
+	require.NoError(t, backfillRecordLinkIndexes(db))
+	require.NoError(t, backfillRecordLinkIndexes(db))
+
+	var linked []string
+	require.NoError(t, db.Model(&models.RecordFieldIndex{}).
+		Where("record_id = ? AND field_id = ? AND deleted_at IS NULL", record.ID, customersField.ID).
+		Order("value_text").Pluck("value_text", &linked).Error)
+	assert.Equal(t, []string{"rec_a", "rec_b"}, linked)
+}
+
+func TestBackfillRecordSearchDocuments(t *testing.T) {
+	db := setupTestDB(t)
+
+	database := &models.Database{Name: "backfill_db"}
+	require.NoError(t, db.Create(database).Error)
+	table := &models.Table{DatabaseID: database.ID, Name: "backfill_table"}
+	require.NoError(t, db.Create(table).Error)
+	subjectField := &models.Field{TableID: table.ID, Name: "subject", Type: "string"}
+	bodyField := &models.Field{TableID: table.ID, Name: "body", Type: "text"}
+	scoreField := &models.Field{TableID: table.ID, Name: "score", Type: "number"}
+	require.NoError(t, db.Create([]*models.Field{subjectField, bodyField, scoreField}).Error)
+
+	record := &models.Record{
+		TableID: table.ID,
+		Data:    models.JSONField(`{"subject":"Refund","body":"","score":42}`),
+		Version: 1,
+	}
+	require.NoError(t, db.Create(record).Error)
+
+	require.NoError(t, createSearchIndexes(db))
+	require.NoError(t, backfillRecordSearchDocuments(db))
+	require.NoError(t, backfillRecordSearchDocuments(db))
+
+	var documents []models.RecordSearchDocument
+	require.NoError(t, db.Where("record_id = ?", record.ID).Find(&documents).Error)
+	require.Len(t, documents, 1)
+	assert.Equal(t, subjectField.ID, documents[0].FieldID)
+	assert.Equal(t, "Refund", documents[0].Content)
+
+	if pkgdb.IsSQLite() {
+		// The FTS5 table follows its content table through triggers.
+		var matches int64
+		require.NoError(t, db.Raw("SELECT COUNT(*) FROM record_search_fts WHERE record_search_fts MATCH ?", "refund").Scan(&matches).Error)
+		assert.Equal(t, int64(1), matches)
+	}
+}
+
 // BUG-002: Migration should create Master Token record when MASTER_TOKEN is set
 func TestMigrate_CreatesMasterTokenRecord(t *testing.T) {
 	dbType := os.Getenv("DB_TYPE")
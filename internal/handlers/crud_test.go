@@ -1,10 +1,14 @@
 package handlers
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 
@@ -13,6 +17,7 @@ import (
 	"github.com/jiangfire/cornerstone/internal/models"
 	"github.com/jiangfire/cornerstone/internal/testutil"
 	pkgdb "github.com/jiangfire/cornerstone/pkg/db"
+	"github.com/jiangfire/cornerstone/pkg/xlsx"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gorm.io/gorm"
@@ -38,6 +43,7 @@ func setupCRUDTest(t *testing.T) (*gin.Engine, *gorm.DB, *models.Token) {
 	dbSvc.DELETE("/:id", DeleteDatabase)
 	dbSvc.POST("/with-tables", CreateDatabaseWithTables)
 	dbSvc.GET("/:id/tables", ListTables)
+	dbSvc.GET("/:id/export", ExportDatabase)
 
 	tblSvc := router.Group("/api/v1/tables")
 	tblSvc.POST("/", CreateTable)
@@ -45,6 +51,7 @@ func setupCRUDTest(t *testing.T) (*gin.Engine, *gorm.DB, *models.Token) {
 	tblSvc.PUT("/:id", UpdateTable)
 	tblSvc.DELETE("/:id", DeleteTable)
 	tblSvc.GET("/:id/fields", ListFields)
+	tblSvc.POST("/:id/import", ImportRecords)
 
 	fldSvc := router.Group("/api/v1/fields")
 	fldSvc.POST("/", CreateField)
@@ -64,8 +71,13 @@ func setupCRUDTest(t *testing.T) (*gin.Engine, *gorm.DB, *models.Token) {
 	recSvc.GET("/export", ExportRecords)
 	recSvc.GET("/:id", GetRecord)
 	recSvc.PUT("/:id", UpdateRecord)
+	recSvc.PATCH("/:id", PatchRecord)
 	recSvc.DELETE("/:id", DeleteRecord)
 	recSvc.POST("/batch", BatchCreateRecords)
+	recSvc.POST("/bulk", BulkInsertRecords)
+	recSvc.POST("/bulk-update", BulkUpdateRecords)
+	recSvc.POST("/bulk-delete", BulkDeleteRecords)
+	recSvc.POST("/upsert", UpsertRecords)
 
 	return router, db, master
 }
@@ -585,6 +597,81 @@ func TestListRecords_Success(t *testing.T) {
 	assert.Equal(t, 2, len(items))
 }
 
+func TestListRecords_Cursor(t *testing.T) {
+	router, db, master := setupCRUDTest(t)
+	_, tbl, _ := setupRecordPrereqs(t, db)
+
+	for _, title := range []string{"r1", "r2", "r3"} {
+		createRecordDirect(t, db, tbl.ID, map[string]interface{}{"title": title})
+	}
+
+	seen := map[string]bool{}
+	path := fmt.Sprintf("/api/v1/records/?table_id=%s&limit=2", tbl.ID)
+	for pages := 0; pages < 3; pages++ {
+		rec := doJSON(t, router, "GET", path, master.Token, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		data, ok := decodeResp(t, rec)["data"].(map[string]interface{})
+		require.True(t, ok)
+		for _, item := range data["records"].([]interface{}) {
+			seen[item.(map[string]interface{})["id"].(string)] = true
+		}
+		next, _ := data["next_cursor"].(string)
+		if next == "" {
+			break
+		}
+		path = fmt.Sprintf("/api/v1/records/?table_id=%s&limit=2&after=%s", tbl.ID, next)
+	}
+	assert.Len(t, seen, 3)
+
+	rec := doJSON(t, router, "GET", fmt.Sprintf("/api/v1/records/?table_id=%s&limit=2&after=bad", tbl.ID), master.Token, nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestListRecords_Sort(t *testing.T) {
+	router, db, master := setupCRUDTest(t)
+	_, tbl, _ := setupRecordPrereqs(t, db)
+
+	for _, title := range []string{"b", "c", "a"} {
+		createRecordDirect(t, db, tbl.ID, map[string]interface{}{"title": title})
+	}
+
+	rec := doJSON(t, router, "GET", fmt.Sprintf("/api/v1/records/?table_id=%s&limit=10&sort=-title", tbl.ID), master.Token, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	data, ok := decodeResp(t, rec)["data"].(map[string]interface{})
+	require.True(t, ok)
+	var titles []string
+	for _, item := range data["records"].([]interface{}) {
+		titles = append(titles, item.(map[string]interface{})["data"].(map[string]interface{})["title"].(string))
+	}
+	assert.Equal(t, []string{"c", "b", "a"}, titles)
+
+	rec = doJSON(t, router, "GET", fmt.Sprintf("/api/v1/records/?table_id=%s&limit=10&sort=missing", tbl.ID), master.Token, nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestListRecords_Search(t *testing.T) {
+	router, db, master := setupCRUDTest(t)
+	_, tbl, _ := setupRecordPrereqs(t, db)
+
+	for _, title := range []string{"Refund request", "Shipping question"} {
+		body := map[string]interface{}{"table_id": tbl.ID, "data": map[string]interface{}{"title": title}}
+		require.Equal(t, http.StatusOK, doJSON(t, router, "POST", "/api/v1/records/", master.Token, body).Code)
+	}
+
+	rec := doJSON(t, router, "GET", fmt.Sprintf("/api/v1/records/?table_id=%s&limit=10&q=refund", tbl.ID), master.Token, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	data, ok := decodeResp(t, rec)["data"].(map[string]interface{})
+	require.True(t, ok)
+	records := data["records"].([]interface{})
+	require.Len(t, records, 1)
+	record := records[0].(map[string]interface{})
+	assert.Positive(t, record["score"])
+	assert.Equal(t, map[string]interface{}{"title": "<mark>Refund</mark> request"}, record["highlights"])
+
+	rec = doJSON(t, router, "GET", fmt.Sprintf("/api/v1/records/?table_id=%s&limit=10&q=refund&filter=refund", tbl.ID), master.Token, nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
 func TestGetRecord_Success(t *testing.T) {
 	router, db, master := setupCRUDTest(t)
 	_, tbl, _ := setupRecordPrereqs(t, db)
@@ -657,6 +744,116 @@ func TestBatchCreateRecords_Success(t *testing.T) {
 	assert.Equal(t, 5, len(records))
 }
 
+func TestBulkInsertRecords_PerItemResults(t *testing.T) {
+	router, db, master := setupCRUDTest(t)
+	_, tbl, _ := setupRecordPrereqs(t, db)
+
+	body := map[string]interface{}{
+		"table_id": tbl.ID,
+		"mode":     "best_effort",
+		"records":  []map[string]interface{}{{"title": "a"}, {"title": "b"}},
+	}
+	rec := doJSON(t, router, "POST", "/api/v1/records/bulk", master.Token, body)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	resp := decodeResp(t, rec)
+	data, ok := resp["data"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, float64(2), data["inserted"])
+	results, ok := data["results"].([]interface{})
+	require.True(t, ok)
+	require.Len(t, results, 2)
+	assert.Equal(t, "created", results[1].(map[string]interface{})["status"])
+
+	body["mode"] = "partial"
+	rec = doJSON(t, router, "POST", "/api/v1/records/bulk", master.Token, body)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestBulkUpdateAndDeleteRecords(t *testing.T) {
+	router, db, master := setupCRUDTest(t)
+	_, tbl, _ := setupRecordPrereqs(t, db)
+	createRecordDirect(t, db, tbl.ID, map[string]interface{}{"title": "old"})
+	createRecordDirect(t, db, tbl.ID, map[string]interface{}{"title": "keep"})
+
+	where := map[string]interface{}{"and": []map[string]interface{}{{"field": "title", "op": "eq", "value": "old"}}}
+	body := map[string]interface{}{"table_id": tbl.ID, "where": where, "data": map[string]interface{}{"title": "new"}}
+	rec := doJSON(t, router, "POST", "/api/v1/records/bulk-update", master.Token, body)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	data, ok := decodeResp(t, rec)["data"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, float64(1), data["affected"])
+
+	rec = doJSON(t, router, "POST", "/api/v1/records/bulk-update", master.Token, map[string]interface{}{"table_id": tbl.ID, "data": map[string]interface{}{"title": "x"}})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	where["and"] = []map[string]interface{}{{"field": "title", "op": "eq", "value": "new"}}
+	body = map[string]interface{}{"table_id": tbl.ID, "where": where, "dry_run": true}
+	rec = doJSON(t, router, "POST", "/api/v1/records/bulk-delete", master.Token, body)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	data, ok = decodeResp(t, rec)["data"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, float64(1), data["matched"])
+	assert.Equal(t, float64(0), data["affected"])
+}
+
+func TestUpsertRecords_InsertThenUpdate(t *testing.T) {
+	router, db, master := setupCRUDTest(t)
+	_, tbl, _ := setupRecordPrereqs(t, db)
+
+	body := map[string]interface{}{
+		"table_id":   tbl.ID,
+		"key_fields": []string{"title"},
+		"records":    []map[string]interface{}{{"data": map[string]interface{}{"title": "a"}}},
+	}
+	for _, status := range []string{"inserted", "updated"} {
+		rec := doJSON(t, router, "POST", "/api/v1/records/upsert", master.Token, body)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		data, ok := decodeResp(t, rec)["data"].(map[string]interface{})
+		require.True(t, ok)
+		results, ok := data["results"].([]interface{})
+		require.True(t, ok)
+		assert.Equal(t, status, results[0].(map[string]interface{})["status"])
+	}
+
+	body["key_fields"] = []string{"missing"}
+	rec := doJSON(t, router, "POST", "/api/v1/records/upsert", master.Token, body)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestPatchRecord_ContentTypes(t *testing.T) {
+	router, db, master := setupCRUDTest(t)
+	_, tbl, _ := setupRecordPrereqs(t, db)
+	record := createRecordDirect(t, db, tbl.ID, map[string]interface{}{"title": "a"})
+
+	patch := func(contentType, path, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("PATCH", path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+master.Token)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	path := "/api/v1/records/" + record.ID
+
+	rec := patch("application/merge-patch+json", path, `{"title":"b"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	data, ok := decodeResp(t, rec)["data"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "b", data["data"].(map[string]interface{})["title"])
+
+	rec = patch("application/json", path+"?version=2", `[{"op":"replace","path":"/title","value":"c"}]`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	data, ok = decodeResp(t, rec)["data"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, float64(3), data["version"])
+
+	assert.Equal(t, http.StatusBadRequest, patch("application/json-patch+json", path+"?version=2", `[{"op":"remove","path":"/title"}]`).Code)
+	assert.Equal(t, http.StatusBadRequest, patch("application/json", path+"?version=x", `{}`).Code)
+	assert.Equal(t, http.StatusUnsupportedMediaType, patch("text/plain", path, `{}`).Code)
+}
+
 func TestExportRecords_CSV(t *testing.T) {
 	router, db, master := setupCRUDTest(t)
 	_, tbl, _ := setupRecordPrereqs(t, db)
@@ -737,6 +934,121 @@ func TestExportRecords_JSON(t *testing.T) {
 	assert.GreaterOrEqual(t, len(exported), 1)
 }
 
+func TestExportRecords_NDJSON(t *testing.T) {
+	router, db, master := setupCRUDTest(t)
+	_, tbl, _ := setupRecordPrereqs(t, db)
+
+	for _, title := range []string{"b", "a"} {
+		createRecordDirect(t, db, tbl.ID, map[string]interface{}{"title": title})
+	}
+
+	path := fmt.Sprintf("/api/v1/records/export?table_id=%s&format=ndjson&sort=title&fields=title", tbl.ID)
+	req, err := http.NewRequest("GET", path, nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+master.Token)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), ".ndjson")
+
+	var titles []interface{}
+	for _, line := range strings.Split(strings.TrimSpace(w.Body.String()), "\n") {
+		var row map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &row))
+		titles = append(titles, row["data"].(map[string]interface{})["title"])
+	}
+	assert.Equal(t, []interface{}{"a", "b"}, titles)
+}
+
+func TestImportRecords(t *testing.T) {
+	router, db, master := setupCRUDTest(t)
+	_, tbl, _ := setupRecordPrereqs(t, db)
+
+	post := func(path, contentType string, body io.Reader) *httptest.ResponseRecorder {
+		t.Helper()
+		req, err := http.NewRequest("POST", path, body)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+master.Token)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// A raw CSV body, with a column mapped to a field.
+	path := fmt.Sprintf("/api/v1/tables/%s/import?mapping=%s", tbl.ID, url.QueryEscape(`{"Title":"title"}`))
+	w := post(path, "text/csv", strings.NewReader("Title\nfirst\nsecond\n"))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	data := decodeResp(t, w)["data"].(map[string]interface{})
+	assert.Equal(t, "csv", data["format"])
+	assert.Equal(t, float64(2), data["inserted"])
+
+	// A multipart NDJSON file, its format read from the file name; unmatched columns get fields.
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "rows.ndjson")
+	require.NoError(t, err)
+	_, _ = io.WriteString(part, "{\"title\": \"third\", \"score\": 3}\n{\"title\": 4}\n")
+	require.NoError(t, form.Close())
+	w = post(fmt.Sprintf("/api/v1/tables/%s/import?create_fields=true", tbl.ID), form.FormDataContentType(), &body)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	data = decodeResp(t, w)["data"].(map[string]interface{})
+	assert.Equal(t, "ndjson", data["format"])
+	assert.Equal(t, float64(2), data["inserted"])
+	assert.Equal(t, []interface{}{"score"}, data["created_fields"])
+
+	var count int64
+	require.NoError(t, db.Model(&models.Record{}).Where("table_id = ?", tbl.ID).Count(&count).Error)
+	assert.Equal(t, int64(4), count)
+
+	for _, tt := range []struct {
+		query string
+		body  string
+	}{
+		{"", "unknown\nx\n"},
+		{"?create_fields=maybe", "title\nx\n"},
+		{"?mapping=[1]", "title\nx\n"},
+		{"?format=xml", "title\nx\n"},
+	} {
+		w := post("/api/v1/tables/"+tbl.ID+"/import"+tt.query, "text/csv", strings.NewReader(tt.body))
+		assert.Equal(t, http.StatusBadRequest, w.Code, tt.query)
+	}
+}
+
+func TestExportDatabase(t *testing.T) {
+	router, db, master := setupCRUDTest(t)
+	dbModel, tbl, _ := setupRecordPrereqs(t, db)
+	createRecordDirect(t, db, tbl.ID, map[string]interface{}{"title": "hello"})
+
+	rec := doJSON(t, router, "GET", "/api/v1/databases/"+dbModel.ID+"/export", master.Token, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), ".xlsx")
+	workbook, err := xlsx.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"items"}, workbook.SheetNames())
+
+	// The workbook imports back into the table, its format read from the Content-Type.
+	req, err := http.NewRequest("POST", "/api/v1/tables/"+tbl.ID+"/import?mapping="+url.QueryEscape(`{"id":"","version":""}`), bytes.NewReader(rec.Body.Bytes()))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+master.Token)
+	req.Header.Set("Content-Type", rec.Header().Get("Content-Type"))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	data := decodeResp(t, w)["data"].(map[string]interface{})
+	assert.Equal(t, "xlsx", data["format"])
+	assert.Equal(t, float64(1), data["inserted"])
+
+	rec = doJSON(t, router, "GET", "/api/v1/databases/"+dbModel.ID+"/export?format=csv", master.Token, nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = doJSON(t, router, "GET", "/api/v1/databases/nonexistent/export", master.Token, nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
 func TestCreateDatabase_DuplicateName(t *testing.T) {
 	router, db, master := setupCRUDTest(t)
 
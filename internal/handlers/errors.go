@@ -17,6 +17,10 @@ func handleServiceError(c *gin.Context, err error) {
 		dto.Forbidden(c, err.Error())
 		return
 	}
+	if isConflictError(err) {
+		dto.Conflict(c, err.Error())
+		return
+	}
 	dto.InternalServerError(c, err.Error())
 }
 
@@ -25,6 +29,10 @@ func handleCreateServiceError(c *gin.Context, err error) {
 		dto.Forbidden(c, err.Error())
 		return
 	}
+	if isConflictError(err) {
+		dto.Conflict(c, err.Error())
+		return
+	}
 	dto.BadRequest(c, err.Error())
 }
 
@@ -61,3 +69,25 @@ func isNotFoundError(err error) bool {
 	}
 	return false
 }
+
+// isConflictError checks if error conflicts with the current state of related data
+func isConflictError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	conflictKeywords := []string{
+		"still referenced by", "unique constraint",
+	}
+	for _, keyword := range conflictKeywords {
+		if strings.Contains(msg, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// isVersionConflictError checks if error is an optimistic lock failure
+func isVersionConflictError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "modified by another user")
+}
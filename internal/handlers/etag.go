@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiangfire/cornerstone/internal/services"
+	"github.com/jiangfire/cornerstone/pkg/db"
+	"github.com/jiangfire/cornerstone/pkg/dto"
+)
+
+// recordETag is the entity tag of a record read: the record version and a hash of the record
+// as it is returned, which differs by the fields selected and by the fields the token reads and
+// masks. It is weak when formulas reading the clock can change the record between reads.
+func recordETag(record *dto.RecordObject) string {
+	tag := strconv.Quote(strconv.Itoa(record.Version) + "-" + responseHash(record))
+	if record.Volatile {
+		return "W/" + tag
+	}
+	return tag
+}
+
+// recordVersionETag is the entity tag of a record written at a version. Like the tags of reads,
+// it can be used in If-Match.
+func recordVersionETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// recordListETag is a weak entity tag of a record list page, a hash of the page as it is
+// returned.
+func recordListETag(list *dto.RecordListData) string {
+	return `W/"` + responseHash(list) + `"`
+}
+
+// responseHash hashes the JSON of a response payload.
+func responseHash(payload interface{}) string {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		data = []byte(fmt.Sprintf("%#v", payload))
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:32]
+}
+
+// etagVersion returns the record version an entity tag of a record was issued at.
+func etagVersion(tag string) (int, bool) {
+	unquoted, err := strconv.Unquote(strings.TrimPrefix(tag, "W/"))
+	if err != nil {
+		return 0, false
+	}
+	version, _, _ := strings.Cut(unquoted, "-")
+	n, err := strconv.Atoi(version)
+	return n, err == nil
+}
+
+// ifMatchesVersion reports whether an If-Match header lists "*" or a tag issued at version.
+// Writes change the stored record, which its version identifies whatever fields a read
+// returned, so a tag matches by its version; a weak tag too, as only the clock makes it weak.
+func ifMatchesVersion(header string, version int) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if tagVersion, ok := etagVersion(tag); ok && tagVersion == version {
+			return true
+		}
+	}
+	return false
+}
+
+// etagMatches reports whether an If-Match or If-None-Match header lists etag or "*". Weak
+// comparison ignores the W/ prefix; strong comparison never matches weak tags.
+func etagMatches(header, etag string, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if weak {
+			tag = strings.TrimPrefix(tag, "W/")
+			etag = strings.TrimPrefix(etag, "W/")
+		} else if strings.HasPrefix(tag, "W/") {
+			continue
+		}
+		if tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified answers 304 when the If-None-Match header of the request matches etag.
+func notModified(c *gin.Context, etag string) bool {
+	header := c.GetHeader("If-None-Match")
+	if header == "" || !etagMatches(header, etag, true) {
+		return false
+	}
+	c.Status(http.StatusNotModified)
+	return true
+}
+
+// recordIfMatchVersion checks the If-Match header of a write to a record against the current
+// record version. It returns the version the write must be guarded with, 0 without If-Match.
+// When the precondition fails it answers 412 (or the lookup error) and returns false.
+func recordIfMatchVersion(c *gin.Context, recordID, userID string) (int, bool) {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		return 0, true
+	}
+	record, err := services.NewRecordService(db.DB()).GetRecord(recordID, userID, "")
+	if err != nil {
+		handleServiceError(c, err)
+		return 0, false
+	}
+	if !ifMatchesVersion(header, record.Version) {
+		c.Header("ETag", recordETag(record))
+		dto.Error(c, http.StatusPreconditionFailed, "precondition failed: record version does not match If-Match")
+		return 0, false
+	}
+	return record.Version, true
+}
+
+// applyIfMatchVersion guards a record update with the version matched by If-Match. It answers
+// 412 and returns false when If-Match fails or contradicts the version of the request.
+func applyIfMatchVersion(c *gin.Context, recordID, userID string, version *int) bool {
+	matched, ok := recordIfMatchVersion(c, recordID, userID)
+	if !ok {
+		return false
+	}
+	if matched == 0 {
+		return true
+	}
+	if *version > 0 && *version != matched {
+		dto.Error(c, http.StatusPreconditionFailed, fmt.Sprintf("precondition failed: version %d does not match If-Match", *version))
+		return false
+	}
+	*version = matched
+	return true
+}
+
+// handleRecordWriteError maps a record write error; a version conflict on a write guarded by
+// If-Match becomes 412.
+func handleRecordWriteError(c *gin.Context, err error, ifMatch bool) {
+	if ifMatch && isVersionConflictError(err) {
+		dto.Error(c, http.StatusPreconditionFailed, "precondition failed: "+err.Error())
+		return
+	}
+	handleCreateServiceError(c, err)
+}
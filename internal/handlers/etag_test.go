@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jiangfire/cornerstone/pkg/dto"
+)
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		header, etag string
+		weak, want   bool
+	}{
+		{`"3"`, `"3"`, false, true},
+		{`"2", "3"`, `"3"`, false, true},
+		{`*`, `"3"`, false, true},
+		{`"4"`, `"3"`, false, false},
+		{`W/"3"`, `"3"`, false, false},
+		{`W/"3"`, `"3"`, true, true},
+		{`"abc"`, `W/"abc"`, true, true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, etagMatches(tt.header, tt.etag, tt.weak), "%s vs %s", tt.header, tt.etag)
+	}
+}
+
+func TestIfMatchesVersion(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{`"3"`, true},
+		{`"3-0a1b"`, true},
+		{`W/"3-0a1b"`, true},
+		{`"2-0a1b", "3-ffff"`, true},
+		{`*`, true},
+		{`"4-0a1b"`, false},
+		{`"30-0a1b"`, false},
+		{`"abc"`, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ifMatchesVersion(tt.header, 3), tt.header)
+	}
+}
+
+func TestRecordETag_Content(t *testing.T) {
+	record := &dto.RecordObject{ID: "rec_1", Version: 3, Data: map[string]interface{}{"name": "a"}}
+	etag := recordETag(record)
+	assert.True(t, strings.HasPrefix(etag, `"3-`), etag)
+
+	masked := &dto.RecordObject{ID: "rec_1", Version: 3, Data: map[string]interface{}{"name": "*"}}
+	assert.NotEqual(t, etag, recordETag(masked))
+
+	record.Volatile = true
+	assert.Equal(t, "W/"+etag, recordETag(record))
+}
+
+func doWithHeaders(t *testing.T, router *gin.Engine, method, path, token, body string, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	req, err := http.NewRequest(method, path, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRecordETags(t *testing.T) {
+	router, db, master := setupCRUDTest(t)
+	_, tbl, _ := setupRecordPrereqs(t, db)
+	record := createRecordDirect(t, db, tbl.ID, map[string]interface{}{"title": "a"})
+	path := "/api/v1/records/" + record.ID
+
+	rec := doWithHeaders(t, router, "GET", path, master.Token, "", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	etag := rec.Header().Get("ETag")
+	assert.True(t, strings.HasPrefix(etag, `"1-`), etag)
+
+	rec = doWithHeaders(t, router, "GET", path, master.Token, "", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	// Another selection of fields is another response.
+	rec = doWithHeaders(t, router, "GET", path+"?fields=missing", master.Token, "", map[string]string{"If-None-Match": etag})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEqual(t, etag, rec.Header().Get("ETag"))
+
+	listPath := "/api/v1/records/?limit=20&table_id=" + tbl.ID
+	rec = doWithHeaders(t, router, "GET", listPath, master.Token, "", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	listETag := rec.Header().Get("ETag")
+	assert.True(t, strings.HasPrefix(listETag, `W/"`))
+	rec = doWithHeaders(t, router, "GET", listPath, master.Token, "", map[string]string{"If-None-Match": listETag})
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+
+	// Writes succeed at the current ETag and fail with 412 afterwards.
+	rec = doWithHeaders(t, router, "PUT", path, master.Token, `{"data":{"title":"b"}}`, map[string]string{"If-Match": etag})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+
+	rec = doWithHeaders(t, router, "PUT", path, master.Token, `{"data":{"title":"c"}}`, map[string]string{"If-Match": `"1"`})
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	assert.True(t, strings.HasPrefix(rec.Header().Get("ETag"), `"2-`), rec.Header().Get("ETag"))
+
+	rec = doWithHeaders(t, router, "PUT", path, master.Token, `{"data":{"title":"c"},"version":1}`, map[string]string{"If-Match": `"2"`})
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+	rec = doWithHeaders(t, router, "PATCH", path, master.Token, `{"title":"c"}`, map[string]string{"If-Match": `"2"`})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"3"`, rec.Header().Get("ETag"))
+
+	rec = doWithHeaders(t, router, "GET", listPath, master.Token, "", map[string]string{"If-None-Match": listETag})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = doWithHeaders(t, router, "DELETE", path, master.Token, "", map[string]string{"If-Match": `"2"`})
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	rec = doWithHeaders(t, router, "DELETE", path, master.Token, "", map[string]string{"If-Match": `"3"`})
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -119,7 +119,10 @@ func GetField(c *gin.Context) {
 //
 //	Valid field types: string, text, number, boolean, date, datetime, file, json, list.
 //
-//	Changing a field type may affect existing record data. Use with caution.
+//	Changing a field type converts the values of existing records in the same transaction.
+//	conversion.on_failure decides what happens to values that cannot be converted:
+//	abort (default) rejects the update, null clears them and keep leaves them as stored.
+//	With conversion.dry_run nothing is saved and the response is a dto.FieldConversionReport.
 //	The authenticated token must own the parent database or be a Master token.
 //
 // @Tags         fields
@@ -128,7 +131,7 @@ func GetField(c *gin.Context) {
 // @Security     ApiKeyAuth
 // @Param        id    path  string                true  "Field ID"
 // @Param        body  body  dto.FieldUpdateRequest  true  "Field update fields"
-// @Success      200  {object}  dto.APIResponse{data=dto.FieldObject}
+// @Success      200  {object}  dto.APIResponse{data=dto.FieldObject}  "Updated field, or dto.FieldConversionReport for a dry run"
 // @Failure      400  {object}  dto.ErrorResponse  "Validation error - invalid request body or field type"
 // @Failure      401  {object}  dto.ErrorResponse  "Unauthorized - invalid or missing API key"
 // @Failure      403  {object}  dto.ErrorResponse  "Forbidden - no access to this field"
@@ -145,11 +148,15 @@ func UpdateField(c *gin.Context) {
 	}
 
 	fieldService := services.NewFieldService(db.DB())
-	field, err := fieldService.UpdateField(fieldID, req, tokenID)
+	field, report, err := fieldService.UpdateFieldWithReport(fieldID, req, tokenID)
 	if err != nil {
 		handleServiceError(c, err)
 		return
 	}
+	if req.Conversion != nil && req.Conversion.DryRun {
+		dto.Success(c, report)
+		return
+	}
 
 	dto.Success(c, fieldObjectFromModel(field))
 }
@@ -38,6 +38,9 @@ func NewQueryHandler() *QueryHandler {
 //	Supports: from, select, where, order_by, limit, offset, group_by, having,
 //	aggregates, join, and union clauses.
 //
+//	Set cursor to true, or pass the next_cursor or prev_cursor of a result as after
+//	or before, to page with cursors instead of page numbers.
+//
 // @Tags         query
 // @Accept       json
 // @Produce      json
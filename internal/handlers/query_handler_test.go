@@ -510,6 +510,13 @@ func TestIsPermissionError_NotPermission(t *testing.T) {
 	assert.False(t, isPermissionError(fmt.Errorf("syntax error")))
 }
 
+func TestIsConflictError(t *testing.T) {
+	assert.False(t, isConflictError(nil))
+	assert.True(t, isConflictError(fmt.Errorf("record is still referenced by field 'customer' in table tbl_1 (on_delete: restrict)")))
+	assert.True(t, isConflictError(fmt.Errorf("unique constraint violated: field 'email' value already exists in record rec_1")))
+	assert.False(t, isConflictError(fmt.Errorf("record not found")))
+}
+
 func TestContainsSubstring_Match(t *testing.T) {
 	assert.True(t, strings.Contains("abcdef", "cde"))
 }
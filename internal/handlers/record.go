@@ -2,8 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jiangfire/cornerstone/internal/middleware"
@@ -11,6 +14,7 @@ import (
 	"github.com/jiangfire/cornerstone/internal/services"
 	"github.com/jiangfire/cornerstone/pkg/db"
 	"github.com/jiangfire/cornerstone/pkg/dto"
+	"github.com/jiangfire/cornerstone/pkg/query"
 	"go.uber.org/zap"
 )
 
@@ -70,6 +74,7 @@ func recordObjectFromModel(record *models.Record, extraFields map[string]any) dt
 // @Failure      400  {object}  dto.ErrorResponse  "Validation error - invalid request body or field values"
 // @Failure      401  {object}  dto.ErrorResponse  "Unauthorized - invalid or missing API key"
 // @Failure      403  {object}  dto.ErrorResponse  "Forbidden - no access to target table"
+// @Failure      409  {object}  dto.ErrorResponse  "Conflict - unique constraint violated"
 // @Router       /api/v1/records [post]
 func CreateRecord(c *gin.Context) {
 	userID := middleware.GetTokenID(c)
@@ -96,45 +101,161 @@ func CreateRecord(c *gin.Context) {
 
 // ExportRecords exports records
 //
-// @Summary      Export records as CSV or JSON
-// @Description  Export records from a table as a downloadable file.
+// @Summary      Export records as CSV, JSON, NDJSON or XLSX
+// @Description  Export records from a table as a downloadable file, streamed as
 //
-//	Supported formats: csv (default) and json. An optional JSON filter expression
-//	can be provided to export only matching records. The response includes
+//	the records are read, so exports of large tables start at once and take
+//	constant memory. Supported formats: csv (default), json (an array), ndjson
+//	(one JSON object per line) and xlsx (a workbook with a sheet named after the
+//	table, numbers, booleans, dates and datetimes in typed cells). filter, fields and sort work like the record list:
+//	a JSON filter expression or keyword narrows the records, fields selects the
+//	exported fields and sort orders them. The response includes
 //	Content-Disposition header for browser downloads.
 //
 // @Tags         records
-// @Produce      application/octet-stream
+// @Produce      text/csv
+// @Produce      application/json
+// @Produce      application/x-ndjson
+// @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
 // @Security     ApiKeyAuth
 // @Param        table_id  query  string  true   "Table ID"
-// @Param        format    query  string  false  "Export format: csv or json"  default(csv)
-// @Param        filter    query  string  false  "JSON filter expression"
+// @Param        format    query  string  false  "Export format: csv, json, ndjson or xlsx"  default(csv)
+// @Param        filter    query  string  false  "JSON filter expression or keyword"
+// @Param        fields    query  string  false  "Comma-separated field names to export"
+// @Param        sort      query  string  false  "Comma-separated sort fields, e.g. due_date,-priority:nulls_first"
 // @Success      200  {file}  binary
-// @Failure      400  {object}  dto.ErrorResponse  "Validation error - missing table_id or invalid format"
+// @Failure      400  {object}  dto.ErrorResponse  "Validation error - missing table_id, invalid format or invalid sort"
 // @Failure      401  {object}  dto.ErrorResponse  "Unauthorized - invalid or missing API key"
 // @Failure      403  {object}  dto.ErrorResponse  "Forbidden - no access to target table"
 // @Router       /api/v1/records/export [get]
 func ExportRecords(c *gin.Context) {
 	userID := middleware.GetTokenID(c)
-	tableID := c.Query("table_id")
-	if tableID == "" {
+	req := dto.RecordExportRequest{
+		TableID: c.Query("table_id"),
+		Format:  c.DefaultQuery("format", "csv"),
+		Filter:  c.Query("filter"),
+		Fields:  c.Query("fields"),
+		Sort:    c.Query("sort"),
+	}
+	if req.TableID == "" {
 		dto.Error(c, 400, "table ID is required")
 		return
 	}
 
-	format := c.DefaultQuery("format", "csv")
-	filter := c.Query("filter")
-
 	recordService := services.NewRecordService(db.DB())
-	data, contentType, filename, err := recordService.ExportRecords(tableID, userID, format, filter)
+	export, err := recordService.ExportRecords(req, userID)
 	if err != nil {
 		handleCreateServiceError(c, err)
 		return
 	}
 
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.Filename))
 	c.Header("Cache-Control", "no-store")
-	c.Data(http.StatusOK, contentType, data)
+	c.Header("Content-Type", export.ContentType)
+	c.Status(http.StatusOK)
+	if err := export.Stream(c.Writer); err != nil {
+		// The status is sent; the client sees a truncated file.
+		zap.L().Error("record export failed",
+			zap.String("table_id", req.TableID),
+			zap.Error(err),
+		)
+		_ = c.Error(err)
+	}
+}
+
+// ImportRecords imports records from a data file
+//
+// @Summary      Import records from CSV, JSON, NDJSON or XLSX
+// @Description  Create a record per row of a data file sent as the request body or as the
+//
+//	file part of a multipart form. Supported formats: csv (the first line names the
+//	columns), json (an array of objects), ndjson (one object per line) and xlsx (the
+//	first sheet, its first row naming the columns); without format, it is inferred from
+//	the Content-Type or the file name. Columns map to fields by mapping, else by field
+//	name or ID, and values are converted to the field types.
+//	With create_fields, a field is created for each unmatched column with a type inferred
+//	from its values. The file is read and inserted in chunks as it streams in, each row on
+//	its own: the response counts the rows and lists the first 100 that failed, by line.
+//
+// @Tags         records
+// @Accept       text/csv
+// @Accept       application/json
+// @Accept       application/x-ndjson
+// @Accept       application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id             path      string  true   "Table ID"
+// @Param        format         query     string  false  "File format: csv, json, ndjson or xlsx"
+// @Param        mapping        query     string  false  "JSON object mapping source columns to field names, an empty name skips a column"
+// @Param        create_fields  query     bool    false  "Create fields for unmatched columns"
+// @Param        file           formData  file    false  "Data file, for multipart requests"
+// @Success      200  {object}  dto.APIResponse{data=dto.RecordImportData}
+// @Failure      400  {object}  dto.ErrorResponse  "Validation error - invalid format, mapping or header, or unmatched columns"
+// @Failure      401  {object}  dto.ErrorResponse  "Unauthorized - invalid or missing API key"
+// @Failure      403  {object}  dto.ErrorResponse  "Forbidden - no access to target table"
+// @Router       /api/v1/tables/{id}/import [post]
+func ImportRecords(c *gin.Context) {
+	userID := middleware.GetTokenID(c)
+	req := dto.RecordImportRequest{
+		TableID: c.Param("id"),
+		Format:  c.Query("format"),
+	}
+	if raw := c.Query("create_fields"); raw != "" {
+		createFields, err := strconv.ParseBool(raw)
+		if err != nil {
+			dto.Error(c, 400, "create_fields must be true or false")
+			return
+		}
+		req.CreateFields = createFields
+	}
+	if raw := c.Query("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &req.Mapping); err != nil {
+			dto.Error(c, 400, "mapping must be a JSON object of column names to field names")
+			return
+		}
+	}
+
+	body, filename, err := importRequestFile(c)
+	if err != nil {
+		dto.Error(c, 400, err.Error())
+		return
+	}
+	if req.Format == "" {
+		req.Format = services.InferImportFormat(c.ContentType(), filename)
+	}
+
+	recordService := services.NewRecordService(db.DB())
+	result, err := recordService.ImportRecords(req, body, userID)
+	if err != nil {
+		handleCreateServiceError(c, err)
+		return
+	}
+	dto.Success(c, result)
+}
+
+// importRequestFile returns the data file of an import and its name: the file part of a
+// multipart form, read as it arrives rather than buffered, or else the request body.
+func importRequestFile(c *gin.Context) (io.Reader, string, error) {
+	if c.ContentType() != "multipart/form-data" {
+		return c.Request.Body, "", nil
+	}
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid multipart form: %w", err)
+	}
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, "", errors.New("file is required")
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid multipart form: %w", err)
+		}
+		if part.FormName() == "file" {
+			return part, part.FileName(), nil
+		}
+	}
 }
 
 // ListRecords lists records
@@ -142,8 +263,19 @@ func ExportRecords(c *gin.Context) {
 // @Summary      List records in a table
 // @Description  Query records from a table with pagination and optional filtering.
 //
-//	The table_id query parameter is required. Use limit and offset for pagination.
+//	The table_id query parameter is required. Use limit and offset for pagination, or
+//	pass the next_cursor or prev_cursor of a page as after or before to page with cursors,
+//	which stays fast and consistent on large tables.
 //	An optional JSON filter expression can be provided to narrow results.
+//	sort orders the records by comma-separated fields, "-" prefixed for descending
+//	order and ":nulls_first" or ":nulls_last" suffixed to place records without a
+//	value (default last). Numbers and dates compare by value; created_at and
+//	updated_at are accepted too. Cursors of a sorted list hold its sort key values and
+//	only page in the sort order they were made in.
+//	q searches the string and text fields for records containing all of its words,
+//	best matches first unless sorted otherwise; each result carries its relevance
+//	score and highlight snippets. A search combines with a JSON filter and pages
+//	with limit and offset only.
 //
 // @Tags         records
 // @Produce      json
@@ -151,10 +283,17 @@ func ExportRecords(c *gin.Context) {
 // @Param        table_id  query  string  true   "Table ID"
 // @Param        limit     query  int     false  "Page size (1-100)"  default(20)
 // @Param        offset    query  int     false  "Offset for pagination"  default(0)
+// @Param        after     query  string  false  "Cursor of the record the page starts after"
+// @Param        before    query  string  false  "Cursor of the record the page ends before"
 // @Param        filter    query  string  false  "JSON filter expression"
+// @Param        sort      query  string  false  "Comma-separated sort fields, e.g. due_date,-priority:nulls_first"
+// @Param        q         query  string  false  "Full-text search over string and text fields"
 // @Param        fields    query  string  false  "Comma-separated field names to include in data"
+// @Param        If-None-Match  header  string  false  "ETag of a cached page; 304 when unchanged"
 // @Success      200  {object}  dto.APIResponse{data=dto.RecordListData}
-// @Failure      400  {object}  dto.ErrorResponse  "Validation error - missing table_id"
+// @Header       200  {string}  ETag  "Weak entity tag of the page, derived from its content"
+// @Success      304  "Not modified"
+// @Failure      400  {object}  dto.ErrorResponse  "Validation error - missing table_id, invalid cursor, invalid sort or invalid search"
 // @Failure      401  {object}  dto.ErrorResponse  "Unauthorized - invalid or missing API key"
 // @Failure      403  {object}  dto.ErrorResponse  "Forbidden - no access to target table"
 // @Router       /api/v1/records [get]
@@ -170,10 +309,19 @@ func ListRecords(c *gin.Context) {
 	recordService := services.NewRecordService(db.DB())
 	result, err := recordService.ListRecords(req, userID)
 	if err != nil {
+		if errors.Is(err, query.ErrInvalidCursor) || errors.Is(err, services.ErrInvalidSort) || errors.Is(err, services.ErrInvalidSearch) {
+			dto.BadRequest(c, err.Error())
+			return
+		}
 		handleServiceError(c, err)
 		return
 	}
 
+	etag := recordListETag(result)
+	c.Header("ETag", etag)
+	if notModified(c, etag) {
+		return
+	}
 	dto.Success(c, result)
 }
 
@@ -190,7 +338,10 @@ func ListRecords(c *gin.Context) {
 // @Security     ApiKeyAuth
 // @Param        id  path  string  true  "Record ID"
 // @Param        fields  query  string  false  "Comma-separated field names to include in data"
+// @Param        If-None-Match  header  string  false  "ETag of a cached record; 304 when unchanged"
 // @Success      200  {object}  dto.APIResponse{data=dto.RecordObject}
+// @Header       200  {string}  ETag  "Entity tag of the record version and content, weak when formulas read the clock"
+// @Success      304  "Not modified"
 // @Failure      401  {object}  dto.ErrorResponse  "Unauthorized - invalid or missing API key"
 // @Failure      403  {object}  dto.ErrorResponse  "Forbidden - no access to this record"
 // @Failure      404  {object}  dto.ErrorResponse  "Record not found"
@@ -207,6 +358,11 @@ func GetRecord(c *gin.Context) {
 		return
 	}
 
+	etag := recordETag(record)
+	c.Header("ETag", etag)
+	if notModified(c, etag) {
+		return
+	}
 	dto.Success(c, record)
 }
 
@@ -218,18 +374,23 @@ func GetRecord(c *gin.Context) {
 //	If a version is provided, the server will check that it matches the current
 //	record version. If it does not match, a conflict error is returned.
 //	Omit the version field to skip optimistic locking.
+//	Alternatively send the record ETag in If-Match; a mismatch returns 412.
 //
 // @Tags         records
 // @Accept       json
 // @Produce      json
 // @Security     ApiKeyAuth
 // @Param        id    path  string                 true  "Record ID"
+// @Param        If-Match  header  string  false  "Expected record ETag"
 // @Param        body  body  dto.RecordUpdateRequest  true  "Record update with optional version"
 // @Success      200  {object}  dto.APIResponse{data=dto.RecordObject}
+// @Header       200  {string}  ETag  "Entity tag of the updated record version"
 // @Failure      400  {object}  dto.ErrorResponse  "Validation error or version conflict"
 // @Failure      401  {object}  dto.ErrorResponse  "Unauthorized - invalid or missing API key"
 // @Failure      403  {object}  dto.ErrorResponse  "Forbidden - no access to this record"
 // @Failure      404  {object}  dto.ErrorResponse  "Record not found"
+// @Failure      409  {object}  dto.ErrorResponse  "Conflict - unique constraint violated"
+// @Failure      412  {object}  dto.ErrorResponse  "Precondition failed - If-Match does not match the record ETag"
 // @Router       /api/v1/records/{id} [put]
 func UpdateRecord(c *gin.Context) {
 	userID := middleware.GetTokenID(c)
@@ -240,14 +401,104 @@ func UpdateRecord(c *gin.Context) {
 		dto.Error(c, 400, "invalid request: "+err.Error())
 		return
 	}
+	if !applyIfMatchVersion(c, recordID, userID, &req.Version) {
+		return
+	}
 
 	recordService := services.NewRecordService(db.DB())
 	record, err := recordService.UpdateRecord(recordID, req, userID)
 	if err != nil {
-		handleCreateServiceError(c, err)
+		handleRecordWriteError(c, err, c.GetHeader("If-Match") != "")
 		return
 	}
 
+	c.Header("ETag", recordVersionETag(record.Version))
+	dto.Success(c, recordObjectFromModel(record, map[string]any{
+		"id":      record.ID,
+		"version": record.Version,
+	}))
+}
+
+// PatchRecord partially updates a record
+//
+// @Summary      Patch a record
+// @Description  Partially update record data with a JSON Merge Patch (RFC 7396, Content-Type
+//
+//	application/merge-patch+json) or a JSON Patch (RFC 6902, Content-Type application/json-patch+json).
+//	With Content-Type application/json an object body is a merge patch and an array body a JSON Patch.
+//	The patch applies to the data object of the readable fields; attachment fields are arrays of file IDs,
+//	so list and attachment values can be edited element by element, e.g. {"op":"add","path":"/tags/-","value":"new"}.
+//	In a merge patch null removes a field. The optional version query parameter or an If-Match
+//	header with the record ETag enables optimistic locking; an If-Match mismatch returns 412.
+//
+// @Tags         records
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id       path   string  true   "Record ID"
+// @Param        version  query  int     false  "Expected record version"
+// @Param        If-Match  header  string  false  "Expected record ETag"
+// @Param        body     body   object  true   "Merge patch object or JSON Patch array"
+// @Success      200  {object}  dto.APIResponse{data=dto.RecordObject}
+// @Header       200  {string}  ETag  "Entity tag of the patched record version"
+// @Failure      400  {object}  dto.ErrorResponse  "Validation error, failed patch or version conflict"
+// @Failure      401  {object}  dto.ErrorResponse  "Unauthorized - invalid or missing API key"
+// @Failure      403  {object}  dto.ErrorResponse  "Forbidden - no access to this record or a patched field"
+// @Failure      409  {object}  dto.ErrorResponse  "Conflict - unique constraint violated"
+// @Failure      412  {object}  dto.ErrorResponse  "Precondition failed - If-Match does not match the record ETag"
+// @Failure      415  {object}  dto.ErrorResponse  "Unsupported content type"
+// @Router       /api/v1/records/{id} [patch]
+func PatchRecord(c *gin.Context) {
+	userID := middleware.GetTokenID(c)
+	recordID := c.Param("id")
+
+	var req dto.RecordPatchRequest
+	if raw := c.Query("version"); raw != "" {
+		version, err := strconv.Atoi(raw)
+		if err != nil || version < 0 {
+			dto.Error(c, 400, "invalid version: "+raw)
+			return
+		}
+		req.Version = version
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		dto.Error(c, 400, "invalid request: "+err.Error())
+		return
+	}
+	if err := json.Unmarshal(body, &req.Patch); err != nil {
+		dto.Error(c, 400, "invalid request: "+err.Error())
+		return
+	}
+
+	switch c.ContentType() {
+	case "application/merge-patch+json":
+		req.Format = services.RecordPatchMerge
+	case "application/json-patch+json":
+		req.Format = services.RecordPatchJSON
+	case "application/json", "":
+		req.Format = services.RecordPatchMerge
+		if _, ok := req.Patch.([]interface{}); ok {
+			req.Format = services.RecordPatchJSON
+		}
+	default:
+		dto.Error(c, http.StatusUnsupportedMediaType, "unsupported content type: "+c.ContentType())
+		return
+	}
+
+	if !applyIfMatchVersion(c, recordID, userID, &req.Version) {
+		return
+	}
+
+	recordService := services.NewRecordService(db.DB())
+	record, err := recordService.PatchRecord(recordID, req, userID)
+	if err != nil {
+		handleRecordWriteError(c, err, c.GetHeader("If-Match") != "")
+		return
+	}
+
+	c.Header("ETag", recordVersionETag(record.Version))
 	dto.Success(c, recordObjectFromModel(record, map[string]any{
 		"id":      record.ID,
 		"version": record.Version,
@@ -260,23 +511,37 @@ func UpdateRecord(c *gin.Context) {
 // @Description  Delete a record by ID.
 //
 //	This action is irreversible. The authenticated token must have access
-//	to the parent table.
+//	to the parent table. Link fields referencing the record apply their
+//	on_delete behavior: restrict (409), set_null or cascade. With an If-Match
+//	header the record is only deleted at the matching ETag, otherwise 412.
 //
 // @Tags         records
 // @Produce      json
 // @Security     ApiKeyAuth
 // @Param        id  path  string  true  "Record ID"
+// @Param        If-Match  header  string  false  "Expected record ETag"
 // @Success      200  {object}  dto.APIResponse{data=object}
 // @Failure      401  {object}  dto.ErrorResponse  "Unauthorized - invalid or missing API key"
 // @Failure      403  {object}  dto.ErrorResponse  "Forbidden - no access to this record"
 // @Failure      404  {object}  dto.ErrorResponse  "Record not found"
+// @Failure      409  {object}  dto.ErrorResponse  "Conflict - record is still referenced by a restrict link field"
+// @Failure      412  {object}  dto.ErrorResponse  "Precondition failed - If-Match does not match the record ETag"
 // @Router       /api/v1/records/{id} [delete]
 func DeleteRecord(c *gin.Context) {
 	userID := middleware.GetTokenID(c)
 	recordID := c.Param("id")
 
+	version, ok := recordIfMatchVersion(c, recordID, userID)
+	if !ok {
+		return
+	}
+
 	recordService := services.NewRecordService(db.DB())
-	if err := recordService.DeleteRecord(recordID, userID); err != nil {
+	if err := recordService.DeleteRecordVersion(recordID, userID, version); err != nil {
+		if version > 0 && isVersionConflictError(err) {
+			dto.Error(c, http.StatusPreconditionFailed, "precondition failed: "+err.Error())
+			return
+		}
 		handleServiceError(c, err)
 		return
 	}
@@ -303,6 +568,7 @@ func DeleteRecord(c *gin.Context) {
 // @Failure      400  {object}  dto.ErrorResponse  "Validation error - invalid request body or count out of range"
 // @Failure      401  {object}  dto.ErrorResponse  "Unauthorized - invalid or missing API key"
 // @Failure      403  {object}  dto.ErrorResponse  "Forbidden - no access to target table"
+// @Failure      409  {object}  dto.ErrorResponse  "Conflict - unique constraint violated"
 // @Router       /api/v1/records/batch [post]
 func BatchCreateRecords(c *gin.Context) {
 	userID := middleware.GetTokenID(c)
@@ -341,3 +607,160 @@ func BatchCreateRecords(c *gin.Context) {
 		Count:   len(records),
 	})
 }
+
+// BulkInsertRecords creates records from distinct payloads
+//
+// @Summary      Bulk insert records
+// @Description  Create one record per payload in the records array (up to 5000 per request).
+//
+//	Every payload is validated like a single create, then the records are inserted in chunks.
+//	mode "atomic" (default) inserts all records or none: if any record fails, the others are reported as rolled_back.
+//	mode "best_effort" inserts every valid record and reports the failing ones.
+//	The response lists the outcome of each payload by index: created with its record id, failed with an error, or rolled_back.
+//
+// @Tags         records
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        body  body      dto.RecordBulkInsertRequest  true  "Record payloads"
+// @Success      200   {object}  dto.APIResponse{data=dto.RecordBulkInsertData}
+// @Failure      400   {object}  dto.ErrorResponse  "Validation error - invalid request body, mode or record count"
+// @Failure      401   {object}  dto.ErrorResponse  "Unauthorized - invalid or missing API key"
+// @Failure      403   {object}  dto.ErrorResponse  "Forbidden - no access to target table"
+// @Router       /api/v1/records/bulk [post]
+func BulkInsertRecords(c *gin.Context) {
+	userID := middleware.GetTokenID(c)
+
+	var req dto.RecordBulkInsertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		dto.Error(c, 400, "invalid request: "+err.Error())
+		return
+	}
+
+	recordService := services.NewRecordService(db.DB())
+	result, err := recordService.BulkInsertRecords(req, userID)
+	if err != nil {
+		handleCreateServiceError(c, err)
+		return
+	}
+
+	dto.Success(c, result)
+}
+
+// BulkUpdateRecords updates all records matching a filter
+//
+// @Summary      Bulk update records
+// @Description  Apply the data patch to every record of the table matching where and/or filter.
+//
+//	where is a query where clause ({"and":[{"field":"status","op":"eq","value":"open"}]}) whose fields are
+//	readable field names, field IDs or the record columns id, version, created_at and updated_at.
+//	filter is the structured record filter of GET /records ({"status":"open"}). At least one condition is required.
+//	Each matching record is validated after the patch and gets its version bumped; the update is all or nothing.
+//	With dry_run the matches are counted and nothing is changed.
+//
+// @Tags         records
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        body  body      dto.RecordBulkUpdateRequest  true  "Filter and data patch"
+// @Success      200   {object}  dto.APIResponse{data=dto.RecordBulkWriteData}
+// @Failure      400   {object}  dto.ErrorResponse  "Validation error - invalid filter or data"
+// @Failure      401   {object}  dto.ErrorResponse  "Unauthorized - invalid or missing API key"
+// @Failure      403   {object}  dto.ErrorResponse  "Forbidden - no write access to the table or a patched field"
+// @Failure      409   {object}  dto.ErrorResponse  "Conflict - unique constraint violated"
+// @Router       /api/v1/records/bulk-update [post]
+func BulkUpdateRecords(c *gin.Context) {
+	userID := middleware.GetTokenID(c)
+
+	var req dto.RecordBulkUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		dto.Error(c, 400, "invalid request: "+err.Error())
+		return
+	}
+
+	recordService := services.NewRecordService(db.DB())
+	result, err := recordService.BulkUpdateRecords(req, userID)
+	if err != nil {
+		handleCreateServiceError(c, err)
+		return
+	}
+
+	dto.Success(c, result)
+}
+
+// BulkDeleteRecords deletes all records matching a filter
+//
+// @Summary      Bulk delete records
+// @Description  Soft-delete every record of the table matching where and/or filter, with the same
+//
+//	filter syntax as bulk-update. Link fields referencing the records apply their on_delete behavior;
+//	the delete is all or nothing. With dry_run the matches are counted and nothing is deleted.
+//
+// @Tags         records
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        body  body      dto.RecordBulkDeleteRequest  true  "Filter"
+// @Success      200   {object}  dto.APIResponse{data=dto.RecordBulkWriteData}
+// @Failure      400   {object}  dto.ErrorResponse  "Validation error - invalid filter"
+// @Failure      401   {object}  dto.ErrorResponse  "Unauthorized - invalid or missing API key"
+// @Failure      403   {object}  dto.ErrorResponse  "Forbidden - no delete access to the table"
+// @Failure      409   {object}  dto.ErrorResponse  "Conflict - a record is still referenced by a restrict link field"
+// @Router       /api/v1/records/bulk-delete [post]
+func BulkDeleteRecords(c *gin.Context) {
+	userID := middleware.GetTokenID(c)
+
+	var req dto.RecordBulkDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		dto.Error(c, 400, "invalid request: "+err.Error())
+		return
+	}
+
+	recordService := services.NewRecordService(db.DB())
+	result, err := recordService.BulkDeleteRecords(req, userID)
+	if err != nil {
+		handleCreateServiceError(c, err)
+		return
+	}
+
+	dto.Success(c, result)
+}
+
+// UpsertRecords inserts or updates records by key fields
+//
+// @Summary      Upsert records
+// @Description  Insert or update one record per item. The key_fields of an item identify its record: when
+//
+//	a record matches, its data is merged with (mode merge, default) or replaced by (mode replace) the item
+//	data, guarded by the item version if set; otherwise a new record is created. Key fields must be of type
+//	string, text, date, datetime, number or boolean. Each item is written on its own; failures are
+//	reported per item.
+//
+// @Tags         records
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        body  body      dto.RecordUpsertRequest  true  "Key fields and records"
+// @Success      200   {object}  dto.APIResponse{data=dto.RecordUpsertData}
+// @Failure      400   {object}  dto.ErrorResponse  "Validation error - invalid key fields or mode"
+// @Failure      401   {object}  dto.ErrorResponse  "Unauthorized - invalid or missing API key"
+// @Failure      403   {object}  dto.ErrorResponse  "Forbidden - no write access to the table"
+// @Router       /api/v1/records/upsert [post]
+func UpsertRecords(c *gin.Context) {
+	userID := middleware.GetTokenID(c)
+
+	var req dto.RecordUpsertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		dto.Error(c, 400, "invalid request: "+err.Error())
+		return
+	}
+
+	recordService := services.NewRecordService(db.DB())
+	result, err := recordService.UpsertRecords(req, userID)
+	if err != nil {
+		handleCreateServiceError(c, err)
+		return
+	}
+
+	dto.Success(c, result)
+}
@@ -111,7 +111,9 @@ func GetTable(c *gin.Context) {
 // @Description  Update table name and/or description.
 //
 //	The authenticated token must own the parent database or be a Master token.
-//	The name field is required in the request body.
+//	The name field is required in the request body. When unique_keys is present
+//	it replaces the composite unique keys of the table; enabling a key fails if
+//	existing records already hold duplicate values.
 //
 // @Tags         tables
 // @Accept       json
@@ -124,6 +126,7 @@ func GetTable(c *gin.Context) {
 // @Failure      401  {object}  dto.ErrorResponse  "Unauthorized - invalid or missing API key"
 // @Failure      403  {object}  dto.ErrorResponse  "Forbidden - no access to this table"
 // @Failure      404  {object}  dto.ErrorResponse  "Table not found"
+// @Failure      409  {object}  dto.ErrorResponse  "Conflict - existing records violate a unique key"
 // @Router       /api/v1/tables/{id} [put]
 func UpdateTable(c *gin.Context) {
 	userID := middleware.GetTokenID(c)
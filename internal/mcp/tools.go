@@ -56,6 +56,7 @@ func shapeTable(t *models.Table) map[string]interface{} {
 		"database_id": t.DatabaseID,
 		"name":        t.Name,
 		"description": t.Description,
+		"unique_keys": services.TableUniqueKeys(t),
 		"created_at":  t.CreatedAt.Format(time.RFC3339),
 		"updated_at":  t.UpdatedAt.Format(time.RFC3339),
 	}
@@ -88,9 +89,10 @@ func shapeRecord(r *models.Record) map[string]interface{} {
 // --- Tool definitions ---
 
 const (
-	fieldTypeDescription = "Field data type. Determines how values are stored and validated. Supported types: string (short text), text (long text), number (numeric), boolean (true/false), date (YYYY-MM-DD), datetime (ISO 8601), file (attachment reference), json (nested object/array), list (array of strings)."
-	allowedDSLTables     = `["records", "tables", "databases", "fields", "files", "tokens"]`
-	fieldTypeEnum        = `["string", "text", "number", "boolean", "date", "datetime", "file", "json", "list"]`
+	fieldTypeDescription   = "Field data type. Determines how values are stored and validated. Supported types: string (short text), text (long text), number (numeric), boolean (true/false), date (YYYY-MM-DD), datetime (ISO 8601), file (attachment reference), json (nested object/array), list (array of strings), link (record ID or array of record IDs in another table, configured via config.link_table_id), formula (read-only value computed from other fields via config.formula), autonumber (read-only sequential number assigned on insert, formatted via config.prefix, config.padding and config.start)."
+	fieldConfigDescription = `Optional type-specific field configuration. Examples: {"options": ["a", "b"]} for list, {"min": 0, "max": 100} for number, {"link_table_id": "tbl_...", "multiple": false, "on_delete": "restrict"} for link (on_delete: restrict, set_null or cascade), {"formula": "price * quantity"} for formula, {"prefix": "INV-", "padding": 6, "start": 1} for autonumber. Add "unique": true to reject duplicate values of string, text, number, date, datetime and single link fields. Defaults fill fields absent when a record is created: {"default": "new"} for a static value or {"default_func": "now"} for a computed one (now, today, token_name or uuid).`
+	allowedDSLTables       = `["records", "tables", "databases", "fields", "files", "tokens"]`
+	fieldTypeEnum          = `["string", "text", "number", "boolean", "date", "datetime", "file", "json", "list", "link", "formula", "autonumber"]`
 )
 
 func (s *ToolService) ListTools() []ToolDefinition {
@@ -105,13 +107,21 @@ The query body uses the Cornerstone Query DSL with these top-level fields (same
 - "select": Array of field names to return. Omit to return all allowed fields.
   Note: When using JOIN, use qualified names like "records.id" to avoid ambiguous column errors.
 - "where": Filter conditions. Use {"and": [...]} or {"or": [...]} with condition objects {"field": "<name>", "op": "<operator>", "value": <val>}.
-  Supported operators: eq, ne, gt, gte, lt, lte, in, not_in, like, not_like, is_null, is_not_null, between.
+  Supported operators: eq, ne, gt, gte, lt, lte, in, not_in, between, is_null (value true; add "not": true for is not null), like and ilike (case-insensitive like), starts_with, ends_with and contains (case-sensitive, literal), regex, search (full-text), has_any and has_all (a list field holding any or all values of an array value), exists (a JSON path such as "data.meta.color" is set; value false for unset).
+  Any condition takes "not": true to negate it.
+  Subqueries: the value of in/not_in can be a query object selecting one field or aggregate, and {"op": "exists"|"not_exists", "value": {<query>}} (no field) tests whether a query has rows. Inside a subquery, {"field": "<name>", "ref": "<outer field>"} compares with the row of the enclosing query, e.g. customers with an order over 1000: {"op": "exists", "value": {"from": "records", "where": {"and": [{"field": "data.customer", "ref": "data.name"}, {"field": "data.amount", "op": "gt", "value": 1000}]}}}.
   For user record data, use "data.<field_name>" as the field path (e.g. "data.email").
 - "orderBy": Array of {"field": "<name>", "direction": "asc"|"desc"}.
+- Date functions can stand in for a field in "select", "groupBy", "orderBy" and "where": date_trunc(hour|day|week|month|quarter|year, <field>), extract(year|quarter|month|day|hour|dow, <field>), date_diff(second|minute|hour|day|week, <start>, <end>), timezone('<IANA zone>', <field>) and now(). Name a selected one with " as <alias>", e.g. "date_trunc(month, data.ordered_at) as month". Dates return as YYYY-MM-DD, datetimes as YYYY-MM-DDTHH:MM:SSZ.
+- Computed expressions can stand in for a field in "select", aggregate "field", "orderBy", "where" and "having", with fields in braces: arithmetic (+ - * / %), text (&, concat, lower, upper, trim, length, substring(text, start[, length])), comparisons, and/or/not, if(cond, then, else), case(cond, value, ..., else), coalesce, is_blank and the casts to_number, to_text and to_date. Selected ones need an alias, e.g. "{data.price} * {data.qty} as total"; in select, orderBy and having a name in braces can be an aggregate alias, e.g. {"field": "{revenue} / {orders}", "op": "gt", "value": 100}.
+- "window": Array of window functions {"func": "row_number"|"rank"|"dense_rank"|"lag"|"lead"|"sum"|"avg", "field": "<name>", "offset": <n>, "partitionBy": ["<name>"], "orderBy": [{"field": "<name>", "dir": "asc"|"desc"}], "as": "<alias>"} for rankings, running totals (sum/avg along orderBy) and previous/next row values (lag/lead, field required). Ranking functions and lag/lead require orderBy.
+- "qualify": Conditions on window function aliases, same shape as "where", e.g. the top 3 per group: {"and": [{"field": "rn", "op": "lte", "value": 3}]}.
 - "page": Page number (1-based). Default: 1.
 - "size": Page size. Default: 20, max: 100.
+- "cursor": Set to true to page with cursors instead of page numbers; results then carry "next_cursor" and "prev_cursor".
+- "after" / "before": The next_cursor or prev_cursor of a previous result, to fetch the rows after or before it. Faster than deep pages on large tables; not available with groupBy, aggregate or union.
 - "table": (simplified) A table ID like "tbl_xxx" to filter records by table. Shorthand for filtering by table_id.
-- "filter": (simplified) A JSON object of field-value pairs for equality filtering on record data fields.
+- "filter": (simplified) A JSON object of field-value pairs for equality filtering, or of field-operator objects such as {"data.tags": {"has_any": ["urgent"]}}. Used when "where" is absent.
 
 Example: List records in a user table with pagination:
 {"from": "records", "table": "tbl_abc123", "page": 1, "size": 10}
@@ -119,6 +129,12 @@ Example: List records in a user table with pagination:
 Example: Query with conditions:
 {"from": "records", "table": "tbl_abc123", "where": {"and": [{"field": "data.status", "op": "eq", "value": "active"}]}, "orderBy": [{"field": "created_at", "direction": "desc"}]}
 
+Example: Operators in the simplified filter:
+{"from": "records", "table": "tbl_abc123", "filter": {"data.email": {"ends_with": "@example.com"}, "data.tags": {"has_all": ["vip", "active"]}}}
+
+Example: Orders per week:
+{"from": "records", "table": "tbl_abc123", "select": ["date_trunc(week, data.ordered_at) as week"], "groupBy": ["date_trunc(week, data.ordered_at)"], "aggregate": [{"func": "count", "field": "*", "as": "orders"}]}
+
 Example: JOIN query (note the qualified select fields):
 {"from": "records", "select": ["records.id", "records.data"], "join": [{"type": "left", "table": "tables", "as": "t", "on": {"left": "records.table_id", "op": "=", "right": "t.id"}, "select": ["t.name"]}]}`,
 			InputSchema: map[string]interface{}{
@@ -246,7 +262,7 @@ Example: JOIN query (note the qualified select fields):
 											"type": map[string]interface{}{
 												"type":        "string",
 												"description": fieldTypeDescription,
-												"enum":        []string{"string", "text", "number", "boolean", "date", "datetime", "file", "json", "list"},
+												"enum":        []string{"string", "text", "number", "boolean", "date", "datetime", "file", "json", "list", "link", "formula", "autonumber"},
 											},
 											"description": map[string]interface{}{
 												"type":        "string",
@@ -301,7 +317,7 @@ Example: JOIN query (note the qualified select fields):
 								"type": map[string]interface{}{
 									"type":        "string",
 									"description": fieldTypeDescription,
-									"enum":        []string{"string", "text", "number", "boolean", "date", "datetime", "file", "json", "list"},
+									"enum":        []string{"string", "text", "number", "boolean", "date", "datetime", "file", "json", "list", "link", "formula", "autonumber"},
 								},
 								"description": map[string]interface{}{
 									"type":        "string",
@@ -349,7 +365,7 @@ Example: JOIN query (note the qualified select fields):
 		},
 		{
 			Name:        "update_table",
-			Description: `Update a table's name and/or description.`,
+			Description: `Update a table's name, description and/or composite unique keys.`,
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -365,6 +381,25 @@ Example: JOIN query (note the qualified select fields):
 						"type":        "string",
 						"description": "New table description (max 500 characters).",
 					},
+					"unique_keys": map[string]interface{}{
+						"type":        "array",
+						"description": "Optional composite unique keys replacing the current ones; pass [] to remove all. Fails if existing records hold duplicate values.",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"name": map[string]interface{}{
+									"type":        "string",
+									"description": "Key name (letters, digits and underscores).",
+								},
+								"fields": map[string]interface{}{
+									"type":        "array",
+									"description": "Names of the fields whose combined values must be unique.",
+									"items":       map[string]interface{}{"type": "string"},
+								},
+							},
+							"required": []string{"name", "fields"},
+						},
+					},
 				},
 				"required": []string{"table_id", "name"},
 			},
@@ -402,7 +437,7 @@ Example: JOIN query (note the qualified select fields):
 					"type": map[string]interface{}{
 						"type":        "string",
 						"description": fieldTypeDescription,
-						"enum":        []string{"string", "text", "number", "boolean", "date", "datetime", "file", "json", "list"},
+						"enum":        []string{"string", "text", "number", "boolean", "date", "datetime", "file", "json", "list", "link", "formula", "autonumber"},
 					},
 					"description": map[string]interface{}{
 						"type":        "string",
@@ -412,6 +447,10 @@ Example: JOIN query (note the qualified select fields):
 						"type":        "boolean",
 						"description": "Whether this field must have a value when creating records.",
 					},
+					"config": map[string]interface{}{
+						"type":        "object",
+						"description": fieldConfigDescription,
+					},
 				},
 				"required": []string{"table_id", "name", "type"},
 			},
@@ -432,7 +471,7 @@ Example: JOIN query (note the qualified select fields):
 		},
 		{
 			Name:        "update_field",
-			Description: `Update a field's name, type, description, or required status. Changing the type may affect existing record data validation.`,
+			Description: `Update a field's name, type, description, or required status. Changing the type converts the values of existing records; use conversion.dry_run to preview how many would fail.`,
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -447,7 +486,7 @@ Example: JOIN query (note the qualified select fields):
 					"type": map[string]interface{}{
 						"type":        "string",
 						"description": fieldTypeDescription,
-						"enum":        []string{"string", "text", "number", "boolean", "date", "datetime", "file", "json", "list"},
+						"enum":        []string{"string", "text", "number", "boolean", "date", "datetime", "file", "json", "list", "link", "formula", "autonumber"},
 					},
 					"description": map[string]interface{}{
 						"type":        "string",
@@ -457,6 +496,14 @@ Example: JOIN query (note the qualified select fields):
 						"type":        "boolean",
 						"description": "Whether the field is required.",
 					},
+					"config": map[string]interface{}{
+						"type":        "object",
+						"description": fieldConfigDescription,
+					},
+					"conversion": map[string]interface{}{
+						"type":        "object",
+						"description": `Optional conversion of existing values when the type changes: {"dry_run": true} reports without saving, "on_failure" is abort (default), null or keep, "date_format" is a Go time layout for strings becoming dates, e.g. "02/01/2006".`,
+					},
 				},
 				"required": []string{"field_id", "name", "type"},
 			},
@@ -498,7 +545,7 @@ Example: JOIN query (note the qualified select fields):
 		},
 		{
 			Name:        "list_records",
-			Description: `List records in a table with pagination. A simplified alternative to query_data for basic record browsing. Returns records with their data, version numbers, and timestamps.`,
+			Description: `List records in a table with pagination. A simplified alternative to query_data for basic record browsing. Returns records with their data, version numbers, and timestamps. Pages carry next_cursor and prev_cursor; pass them as after or before to page through large tables instead of using offset.`,
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -517,14 +564,59 @@ Example: JOIN query (note the qualified select fields):
 						"description": "Number of records to skip. Default: 0.",
 						"minimum":     0,
 					},
+					"after": map[string]interface{}{
+						"type":        "string",
+						"description": "next_cursor of a previous page; returns the records after it. Cannot be combined with offset or before.",
+					},
+					"before": map[string]interface{}{
+						"type":        "string",
+						"description": "prev_cursor of a previous page; returns the records before it. Cannot be combined with offset or after.",
+					},
 					"filter": map[string]interface{}{
 						"type":        "string",
 						"description": "Optional JSON filter object for equality matching on record data fields. Example: {\"status\":\"active\",\"priority\":\"high\"}",
 					},
+					"sort": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional comma-separated fields to sort by, \"-\" prefixed for descending order; append :nulls_first or :nulls_last to place records without a value (default last). Numbers and dates compare by value. Example: due_date,-priority. Cursors only page in the sort order they were returned for.",
+					},
 				},
 				"required": []string{"table_id"},
 			},
 		},
+		{
+			Name:        "search_records",
+			Description: `Full-text search a table for records whose string and text fields contain all words of q, e.g. every support ticket mentioning "refund". Results are ranked by relevance, best first, and each carries its score and highlights: an HTML snippet per matching field with the matched words in <mark>.`,
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"table_id": map[string]interface{}{
+						"type":        "string",
+						"description": `Table ID (prefixed with "tbl_").`,
+					},
+					"q": map[string]interface{}{
+						"type":        "string",
+						"description": "Words to search for; a record matches when it contains all of them, case-insensitively.",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of records to return (1-100). Default: 20.",
+						"minimum":     1,
+						"maximum":     100,
+					},
+					"offset": map[string]interface{}{
+						"type":        "integer",
+						"description": "Number of records to skip. Default: 0.",
+						"minimum":     0,
+					},
+					"filter": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional JSON filter object for equality matching on record data fields, applied to the matches. Example: {\"status\":\"open\"}",
+					},
+				},
+				"required": []string{"table_id", "q"},
+			},
+		},
 		{
 			Name:        "get_record",
 			Description: `Get a single record by its ID. Returns the record's data, version, and timestamps.`,
@@ -574,7 +666,7 @@ Example: JOIN query (note the qualified select fields):
 		},
 		{
 			Name:        "batch_insert_records",
-			Description: `Insert multiple records into a table at once. Each record is an independent data object. Returns the created records with their generated IDs.`,
+			Description: `Insert multiple records into a table at once (up to 5000). Each record is an independent data object. Returns the outcome of each record by index: "created" with its generated ID, "failed" with an error, or "rolled_back". In "atomic" mode (default) either all records are inserted or none; in "best_effort" mode every valid record is inserted.`,
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -590,10 +682,58 @@ Example: JOIN query (note the qualified select fields):
 							"additionalProperties": true,
 						},
 					},
+					"mode": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"atomic", "best_effort"},
+						"description": `"atomic" (default) inserts all records or none; "best_effort" inserts the valid records and reports the failing ones.`,
+					},
 				},
 				"required": []string{"table_id", "records"},
 			},
 		},
+		{
+			Name:        "upsert_records",
+			Description: `Insert or update records by natural key (up to 5000), e.g. to sync data from another system by its external ID. The key_fields identify the record of each item: a matching record is updated, otherwise a new one is created. Returns the outcome of each item by index: "inserted" or "updated" with the record ID and version, or "failed" with an error. Items are written independently, so one failure does not affect the others.`,
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"table_id": map[string]interface{}{
+						"type":        "string",
+						"description": `Table ID (prefixed with "tbl_").`,
+					},
+					"key_fields": map[string]interface{}{
+						"type":        "array",
+						"description": "Names of the fields whose values identify a record. Must be string, text, date, datetime, number or boolean fields present in every item.",
+						"items":       map[string]interface{}{"type": "string"},
+					},
+					"records": map[string]interface{}{
+						"type":        "array",
+						"description": "Items to upsert.",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"data": map[string]interface{}{
+									"type":                 "object",
+									"description":          "Record data. Keys must match field names on the table and include the key fields.",
+									"additionalProperties": true,
+								},
+								"version": map[string]interface{}{
+									"type":        "integer",
+									"description": "Optional version of the existing record for optimistic locking; the item fails if the record has changed.",
+								},
+							},
+							"required": []string{"data"},
+						},
+					},
+					"mode": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"merge", "replace"},
+						"description": `"merge" (default) sets the given fields of an existing record and keeps the others; "replace" also clears the writable fields not given.`,
+					},
+				},
+				"required": []string{"table_id", "key_fields", "records"},
+			},
+		},
 		{
 			Name:        "generate_test_data",
 			Description: `Generate realistic test data for a table. Automatically creates records with random values matching each field's type. Useful for prototyping and testing. Returns the generated records.`,
@@ -679,6 +819,8 @@ func (s *ToolService) Call(ctx context.Context, name string, args json.RawMessag
 		return s.callInsertRecord(args)
 	case "list_records":
 		return s.callListRecords(args)
+	case "search_records":
+		return s.callSearchRecords(args)
 	case "get_record":
 		return s.callGetRecord(args)
 	case "update_record":
@@ -687,6 +829,8 @@ func (s *ToolService) Call(ctx context.Context, name string, args json.RawMessag
 		return s.callDeleteRecord(args)
 	case "batch_insert_records":
 		return s.callBatchInsertRecords(args)
+	case "upsert_records":
+		return s.callUpsertRecords(args)
 	case "generate_test_data":
 		return s.callGenerateTestData(args)
 	case "get_table_schema":
@@ -1013,9 +1157,10 @@ func (s *ToolService) callGetTable(args json.RawMessage) (*ToolCallResult, error
 
 func (s *ToolService) callUpdateTable(args json.RawMessage) (*ToolCallResult, error) {
 	var req struct {
-		TableID     string `json:"table_id"`
-		Name        string `json:"name"`
-		Description string `json:"description"`
+		TableID     string           `json:"table_id"`
+		Name        string           `json:"name"`
+		Description string           `json:"description"`
+		UniqueKeys  *[]dto.UniqueKey `json:"unique_keys"`
 	}
 	if err := json.Unmarshal(args, &req); err != nil {
 		return nil, fmt.Errorf("invalid update_table arguments: %w", err)
@@ -1025,6 +1170,7 @@ func (s *ToolService) callUpdateTable(args json.RawMessage) (*ToolCallResult, er
 	table, err := tableService.UpdateTable(req.TableID, dto.TableUpdateRequest{
 		Name:        req.Name,
 		Description: req.Description,
+		UniqueKeys:  req.UniqueKeys,
 	}, s.userID)
 	if err != nil {
 		return errorResult("Table update failed.", "UPDATE_ERROR", err.Error()), nil
@@ -1062,11 +1208,12 @@ func (s *ToolService) callDeleteTable(args json.RawMessage) (*ToolCallResult, er
 
 func (s *ToolService) callCreateField(args json.RawMessage) (*ToolCallResult, error) {
 	var req struct {
-		TableID     string `json:"table_id"`
-		Name        string `json:"name"`
-		Type        string `json:"type"`
-		Description string `json:"description"`
-		Required    bool   `json:"required"`
+		TableID     string          `json:"table_id"`
+		Name        string          `json:"name"`
+		Type        string          `json:"type"`
+		Description string          `json:"description"`
+		Required    bool            `json:"required"`
+		Config      dto.FieldConfig `json:"config"`
 	}
 	if err := json.Unmarshal(args, &req); err != nil {
 		return nil, fmt.Errorf("invalid create_field arguments: %w", err)
@@ -1079,6 +1226,7 @@ func (s *ToolService) callCreateField(args json.RawMessage) (*ToolCallResult, er
 		Type:        req.Type,
 		Description: req.Description,
 		Required:    req.Required,
+		Config:      req.Config,
 	}, s.userID)
 	if err != nil {
 		return errorResult("Field creation failed.", "CREATE_ERROR", err.Error()), nil
@@ -1112,26 +1260,37 @@ func (s *ToolService) callListFields(args json.RawMessage) (*ToolCallResult, err
 
 func (s *ToolService) callUpdateField(args json.RawMessage) (*ToolCallResult, error) {
 	var req struct {
-		FieldID     string `json:"field_id"`
-		Name        string `json:"name"`
-		Type        string `json:"type"`
-		Description string `json:"description"`
-		Required    bool   `json:"required"`
+		FieldID     string               `json:"field_id"`
+		Name        string               `json:"name"`
+		Type        string               `json:"type"`
+		Description string               `json:"description"`
+		Required    bool                 `json:"required"`
+		Config      dto.FieldConfig      `json:"config"`
+		Conversion  *dto.FieldConversion `json:"conversion"`
 	}
 	if err := json.Unmarshal(args, &req); err != nil {
 		return nil, fmt.Errorf("invalid update_field arguments: %w", err)
 	}
 
 	fieldService := services.NewFieldService(s.db)
-	field, err := fieldService.UpdateField(req.FieldID, dto.FieldUpdateRequest{
+	field, report, err := fieldService.UpdateFieldWithReport(req.FieldID, dto.FieldUpdateRequest{
 		Name:        req.Name,
 		Type:        req.Type,
 		Description: req.Description,
 		Required:    req.Required,
+		Config:      req.Config,
+		Conversion:  req.Conversion,
 	}, s.userID)
 	if err != nil {
 		return errorResult("Field update failed.", "UPDATE_ERROR", err.Error()), nil
 	}
+	if req.Conversion != nil && req.Conversion.DryRun {
+		return &ToolCallResult{
+			Content: []TextContent{{Type: "text", Text: fmt.Sprintf("Dry run: %d of %d values of field %q would convert to %s, %d would fail.",
+				report.Converted, report.Total, field.Name, report.ToType, report.Failed)}},
+			StructuredContent: report,
+		}, nil
+	}
 
 	return &ToolCallResult{
 		Content:           []TextContent{{Type: "text", Text: fmt.Sprintf("Field %q updated.", field.Name)}},
@@ -1192,7 +1351,10 @@ func (s *ToolService) callListRecords(args json.RawMessage) (*ToolCallResult, er
 		TableID string `json:"table_id"`
 		Limit   int    `json:"limit"`
 		Offset  int    `json:"offset"`
+		After   string `json:"after"`
+		Before  string `json:"before"`
 		Filter  string `json:"filter"`
+		Sort    string `json:"sort"`
 	}
 	if err := json.Unmarshal(args, &req); err != nil {
 		return nil, fmt.Errorf("invalid list_records arguments: %w", err)
@@ -1210,7 +1372,10 @@ func (s *ToolService) callListRecords(args json.RawMessage) (*ToolCallResult, er
 		TableID: req.TableID,
 		Limit:   req.Limit,
 		Offset:  req.Offset,
+		After:   req.After,
+		Before:  req.Before,
 		Filter:  req.Filter,
+		Sort:    req.Sort,
 	}, s.userID)
 	if err != nil {
 		return errorResult("Listing records failed.", "QUERY_ERROR", err.Error()), nil
@@ -1222,6 +1387,46 @@ func (s *ToolService) callListRecords(args json.RawMessage) (*ToolCallResult, er
 	}, nil
 }
 
+func (s *ToolService) callSearchRecords(args json.RawMessage) (*ToolCallResult, error) {
+	var req struct {
+		TableID string `json:"table_id"`
+		Q       string `json:"q"`
+		Limit   int    `json:"limit"`
+		Offset  int    `json:"offset"`
+		Filter  string `json:"filter"`
+	}
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, fmt.Errorf("invalid search_records arguments: %w", err)
+	}
+	if req.Q == "" {
+		return errorResult("Missing search words.", "VALIDATION_ERROR", "Provide the q parameter."), nil
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+	if req.Limit > 100 {
+		req.Limit = 100
+	}
+
+	recordService := services.NewRecordService(s.db)
+	result, err := recordService.ListRecords(dto.RecordListQueryRequest{
+		TableID: req.TableID,
+		Limit:   req.Limit,
+		Offset:  req.Offset,
+		Filter:  req.Filter,
+		Q:       req.Q,
+	}, s.userID)
+	if err != nil {
+		return errorResult("Searching records failed.", "QUERY_ERROR", err.Error()), nil
+	}
+
+	return &ToolCallResult{
+		Content:           []TextContent{{Type: "text", Text: fmt.Sprintf("Found %d matching record(s), total %d.", len(result.Records), result.Total)}},
+		StructuredContent: result,
+	}, nil
+}
+
 func (s *ToolService) callGetRecord(args json.RawMessage) (*ToolCallResult, error) {
 	var req struct {
 		RecordID string `json:"record_id"`
@@ -1288,10 +1493,7 @@ func (s *ToolService) callDeleteRecord(args json.RawMessage) (*ToolCallResult, e
 }
 
 func (s *ToolService) callBatchInsertRecords(args json.RawMessage) (*ToolCallResult, error) {
-	var req struct {
-		TableID string                   `json:"table_id"`
-		Records []map[string]interface{} `json:"records"`
-	}
+	var req dto.RecordBulkInsertRequest
 	if err := json.Unmarshal(args, &req); err != nil {
 		return nil, fmt.Errorf("invalid batch_insert_records arguments: %w", err)
 	}
@@ -1301,36 +1503,40 @@ func (s *ToolService) callBatchInsertRecords(args json.RawMessage) (*ToolCallRes
 	}
 
 	recordService := services.NewRecordService(s.db)
-	var created []map[string]interface{}
-	var errors []map[string]interface{}
+	result, err := recordService.BulkInsertRecords(req, s.userID)
+	if err != nil {
+		return errorResult("Batch insertion failed.", "CREATE_ERROR", err.Error()), nil
+	}
 
-	for i, data := range req.Records {
-		record, err := recordService.CreateRecord(dto.RecordCreateRequest{
-			TableID: req.TableID,
-			Data:    data,
-		}, s.userID)
-		if err != nil {
-			errors = append(errors, map[string]interface{}{
-				"index": i,
-				"error": err.Error(),
-			})
-		} else {
-			created = append(created, shapeRecord(record))
+	summary := fmt.Sprintf("Inserted %d record(s).", result.Inserted)
+	if result.Failed > 0 {
+		summary += fmt.Sprintf(" %d record(s) failed.", result.Failed)
+		if result.Inserted == 0 && result.Mode == "atomic" {
+			summary += " Nothing was inserted because the mode is atomic."
 		}
 	}
 
-	result := map[string]interface{}{
-		"records":  created,
-		"created":  len(created),
-		"table_id": req.TableID,
+	return &ToolCallResult{
+		Content:           []TextContent{{Type: "text", Text: summary}},
+		StructuredContent: result,
+	}, nil
+}
+
+func (s *ToolService) callUpsertRecords(args json.RawMessage) (*ToolCallResult, error) {
+	var req dto.RecordUpsertRequest
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, fmt.Errorf("invalid upsert_records arguments: %w", err)
 	}
-	if len(errors) > 0 {
-		result["errors"] = errors
+
+	recordService := services.NewRecordService(s.db)
+	result, err := recordService.UpsertRecords(req, s.userID)
+	if err != nil {
+		return errorResult("Upsert failed.", "CREATE_ERROR", err.Error()), nil
 	}
 
-	summary := fmt.Sprintf("Inserted %d record(s).", len(created))
-	if len(errors) > 0 {
-		summary += fmt.Sprintf(" %d record(s) failed.", len(errors))
+	summary := fmt.Sprintf("Inserted %d and updated %d record(s).", result.Inserted, result.Updated)
+	if result.Failed > 0 {
+		summary += fmt.Sprintf(" %d record(s) failed.", result.Failed)
 	}
 
 	return &ToolCallResult{
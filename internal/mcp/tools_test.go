@@ -11,6 +11,7 @@ import (
 
 	"github.com/jiangfire/cornerstone/internal/models"
 	"github.com/jiangfire/cornerstone/internal/testutil"
+	"github.com/jiangfire/cornerstone/pkg/dto"
 )
 
 func setupMCPTestDB(t *testing.T) *gorm.DB {
@@ -39,6 +40,7 @@ func TestToolService_ListTools(t *testing.T) {
 		"insert_record",
 		"update_record",
 		"delete_record",
+		"upsert_records",
 		"generate_test_data",
 	}
 
@@ -139,6 +141,111 @@ func TestToolService_Call_InsertRecord(t *testing.T) {
 	assert.Equal(t, int64(1), recordCount)
 }
 
+func TestToolService_Call_UpsertRecords(t *testing.T) {
+	db := setupMCPTestDB(t)
+	svc := NewToolService(db, "test_user")
+
+	database := &models.Database{Name: "TestDB"}
+	db.Create(database)
+
+	table := &models.Table{DatabaseID: database.ID, Name: "users"}
+	db.Create(table)
+
+	db.Create(&models.Field{TableID: table.ID, Name: "email", Type: "string"})
+	db.Create(&models.Field{TableID: table.ID, Name: "age", Type: "number"})
+
+	args, _ := json.Marshal(map[string]any{
+		"table_id":   table.ID,
+		"key_fields": []string{"email"},
+		"records": []map[string]any{
+			{"data": map[string]any{"email": "a@example.com", "age": float64(30)}},
+			{"data": map[string]any{"email": "a@example.com", "age": float64(31)}},
+		},
+	})
+
+	result, err := svc.Call(context.Background(), "upsert_records", args)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, "Inserted 1 and updated 1")
+
+	var recordCount int64
+	db.Table("records").Count(&recordCount)
+	assert.Equal(t, int64(1), recordCount)
+}
+
+func TestToolService_Call_ListRecordsWithCursor(t *testing.T) {
+	db := setupMCPTestDB(t)
+	svc := NewToolService(db, "test_user")
+
+	database := &models.Database{Name: "TestDB"}
+	db.Create(database)
+
+	table := &models.Table{DatabaseID: database.ID, Name: "users"}
+	db.Create(table)
+
+	db.Create(&models.Field{TableID: table.ID, Name: "name", Type: "string"})
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		args, _ := json.Marshal(map[string]any{"table_id": table.ID, "data": map[string]any{"name": name}})
+		result, err := svc.Call(context.Background(), "insert_record", args)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+	}
+
+	seen := map[string]bool{}
+	args, _ := json.Marshal(map[string]any{"table_id": table.ID, "limit": 2})
+	for pages := 0; pages < 3; pages++ {
+		result, err := svc.Call(context.Background(), "list_records", args)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		list := result.StructuredContent.(*dto.RecordListData)
+		for _, record := range list.Records {
+			seen[record.ID] = true
+		}
+		if list.NextCursor == "" {
+			break
+		}
+		args, _ = json.Marshal(map[string]any{"table_id": table.ID, "limit": 2, "after": list.NextCursor})
+	}
+	assert.Len(t, seen, 3)
+
+	args, _ = json.Marshal(map[string]any{"table_id": table.ID, "after": "bad", "offset": 1})
+	result, err := svc.Call(context.Background(), "list_records", args)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
+
+func TestToolService_Call_SearchRecords(t *testing.T) {
+	db := setupMCPTestDB(t)
+	svc := NewToolService(db, "test_user")
+
+	database := &models.Database{Name: "TestDB"}
+	db.Create(database)
+
+	table := &models.Table{DatabaseID: database.ID, Name: "tickets"}
+	db.Create(table)
+
+	db.Create(&models.Field{TableID: table.ID, Name: "subject", Type: "string"})
+	for _, subject := range []string{"Refund for order 42", "Login problem"} {
+		args, _ := json.Marshal(map[string]any{"table_id": table.ID, "data": map[string]any{"subject": subject}})
+		result, err := svc.Call(context.Background(), "insert_record", args)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+	}
+
+	args, _ := json.Marshal(map[string]any{"table_id": table.ID, "q": "refund"})
+	result, err := svc.Call(context.Background(), "search_records", args)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	list := result.StructuredContent.(*dto.RecordListData)
+	require.Len(t, list.Records, 1)
+	assert.Equal(t, "<mark>Refund</mark> for order 42", list.Records[0].Highlights["subject"])
+
+	args, _ = json.Marshal(map[string]any{"table_id": table.ID})
+	result, err = svc.Call(context.Background(), "search_records", args)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
+
 func TestToolService_Call_DeleteRecord(t *testing.T) {
 	db := setupMCPTestDB(t)
 	svc := NewToolService(db, "test_user")
@@ -409,6 +409,16 @@ func (r *Runner) ensureTargetTable(databaseID string, tablePlan PreviewTablePlan
 }
 
 func (r *Runner) importTableData(targetTableID, sourceTable string, schema *source.TableSchema, state *TableState) error {
+	// Target fields without a source column are filled with their defaults.
+	var targetFields []models.Field
+	if err := r.db.Where("table_id = ? AND deleted_at IS NULL", targetTableID).Find(&targetFields).Error; err != nil {
+		return newMigrationError(ErrCodeTableData, "failed to load target fields", err)
+	}
+	defaults, err := services.NewFieldDefaultContext(r.db, r.masterToken)
+	if err != nil {
+		return newMigrationError(ErrCodeTableData, "failed to prepare field defaults", err)
+	}
+
 	strategy := r.pickStrategyWithSource(r.src, sourceTable, schema)
 	cursorColumn := state.CursorColumn
 	offset := state.ProcessedCount
@@ -449,6 +459,7 @@ func (r *Runner) importTableData(targetTableID, sourceTable string, schema *sour
 			}
 
 			payload := r.normalizeRow(schema, row)
+			services.ApplyFieldDefaults(targetFields, payload, defaults)
 			payloadJSON, err := json.Marshal(payload)
 			if err != nil {
 				return newMigrationError(ErrCodeTableData, "failed to serialize migration record", err)
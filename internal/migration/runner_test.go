@@ -577,3 +577,46 @@ func (b *blockingSource) releaseAll() {
 		}
 	}
 }
+
+func TestRunnerRun_FillsFieldDefaults(t *testing.T) {
+	targetDB := testutil.SetupTestDBWithTokens(t, "master")
+	sourcePath := buildSQLiteSourceFixture(t)
+
+	dbModel := &models.Database{Name: "defaults_shop"}
+	require.NoError(t, targetDB.Create(dbModel).Error)
+	tbl := &models.Table{DatabaseID: dbModel.ID, Name: "users"}
+	require.NoError(t, targetDB.Create(tbl).Error)
+	require.NoError(t, targetDB.Create(&models.Field{TableID: tbl.ID, Name: "status", Type: "string", Options: `{"default":"imported"}`}).Error)
+	require.NoError(t, targetDB.Create(&models.Field{TableID: tbl.ID, Name: "imported_by", Type: "string", Options: `{"default_func":"token_name"}`}).Error)
+
+	runner, err := NewRunner(targetDB, "master", Config{
+		Source: SourceConfig{Type: "sqlite", DSN: sourcePath},
+		Target: TargetConfig{DatabaseName: "defaults_shop"},
+		Tables: TablesConfig{Exclude: []string{"audit_logs"}},
+		Data: DataConfig{
+			Enabled:             true,
+			BatchSize:           10,
+			PaginationStrategy:  PaginationCursor,
+			MaxConcurrentTables: 1,
+		},
+		Options: OptionsConfig{
+			CheckpointInterval: 1,
+			RollbackOnFailure:  RollbackTable,
+		},
+	}, RunnerOptions{StateDir: t.TempDir()})
+	require.NoError(t, err)
+	_, err = runner.Run()
+	require.NoError(t, err)
+
+	var token models.Token
+	require.NoError(t, targetDB.First(&token, "id = ?", "master").Error)
+	var records []models.Record
+	require.NoError(t, targetDB.Where("table_id = ?", tbl.ID).Find(&records).Error)
+	require.Len(t, records, 2)
+	for _, rec := range records {
+		payload := map[string]any{}
+		require.NoError(t, json.Unmarshal([]byte(rec.Data), &payload))
+		assert.Equal(t, "imported", payload["status"])
+		assert.Equal(t, token.Name, payload["imported_by"])
+	}
+}
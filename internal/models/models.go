@@ -84,6 +84,7 @@ type Table struct {
 	DatabaseID  string         `gorm:"type:varchar(50);not null;uniqueIndex:uk_table_db_name" json:"database_id"`
 	Name        string         `gorm:"type:varchar(255);not null;uniqueIndex:uk_table_db_name" json:"name"`
 	Description string         `gorm:"type:text" json:"description"`
+	UniqueKeys  string         `gorm:"type:text" json:"-"` // JSON array of composite unique keys over field names
 	CreatedAt   time.Time      `gorm:"type:timestamp;default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt   time.Time      `gorm:"type:timestamp;default:CURRENT_TIMESTAMP" json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"type:timestamp;index" json:"deleted_at"`
@@ -110,6 +111,7 @@ type Field struct {
 	Description string         `gorm:"type:text" json:"description"`
 	Required    bool           `gorm:"type:boolean;default:false" json:"required"`
 	Options     string         `gorm:"type:text" json:"options"`
+	LastNumber  int64          `gorm:"not null;default:0" json:"-"` // Last value assigned by an autonumber field
 	CreatedAt   time.Time      `gorm:"type:timestamp;default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt   time.Time      `gorm:"type:timestamp;default:CURRENT_TIMESTAMP" json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"type:timestamp;index" json:"deleted_at"`
@@ -179,6 +181,45 @@ func (r *RecordFieldIndex) BeforeCreate(tx *gorm.DB) (err error) {
 	return nil
 }
 
+// RecordUniqueKey claims a value of a unique field or composite unique key for one record (ruk_ prefix).
+// The unique index makes concurrent writers of the same value fail on every backend.
+type RecordUniqueKey struct {
+	ID            string    `gorm:"type:varchar(50);primaryKey" json:"id"`
+	TableID       string    `gorm:"type:varchar(50);not null;uniqueIndex:uk_record_unique_value" json:"table_id"`
+	ConstraintKey string    `gorm:"type:varchar(320);not null;uniqueIndex:uk_record_unique_value" json:"constraint_key"`
+	ValueHash     string    `gorm:"type:varchar(64);not null;uniqueIndex:uk_record_unique_value" json:"value_hash"`
+	RecordID      string    `gorm:"type:varchar(50);not null;index" json:"record_id"`
+	CreatedAt     time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP" json:"created_at"`
+	Record        Record    `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:RecordID" json:"-"`
+}
+
+func (RecordUniqueKey) TableName() string {
+	return "record_unique_keys"
+}
+
+func (r *RecordUniqueKey) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == "" {
+		r.ID = GenerateID("ruk")
+	}
+	return nil
+}
+
+// RecordSearchDocument holds the text of one string or text field of a record for full-text search.
+// The integer ID is the rowid the SQLite FTS5 index refers to, which must be stable; the content is
+// indexed natively in each database (see internal/db createSearchIndexes).
+type RecordSearchDocument struct {
+	ID       uint64 `gorm:"primaryKey;autoIncrement" json:"id"`
+	TableID  string `gorm:"type:varchar(50);not null;index" json:"table_id"`
+	RecordID string `gorm:"type:varchar(50);not null;uniqueIndex:uk_record_search_field" json:"record_id"`
+	FieldID  string `gorm:"type:varchar(50);not null;uniqueIndex:uk_record_search_field" json:"field_id"`
+	Content  string `gorm:"type:text;not null" json:"content"`
+	Record   Record `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:RecordID" json:"-"`
+}
+
+func (RecordSearchDocument) TableName() string {
+	return "record_search_documents"
+}
+
 // File file attachment table
 type File struct {
 	ID         string         `gorm:"type:varchar(50);primaryKey" json:"id"`
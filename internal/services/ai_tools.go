@@ -395,6 +395,9 @@ func generateFieldValue(rng *rand.Rand, fieldType string) any {
 			result[i] = items[rng.Intn(len(items))]
 		}
 		return result
+	case "link":
+		// Linked record IDs cannot be invented; leave the link empty
+		return nil
 	default:
 		return "sample_value"
 	}
@@ -181,7 +181,7 @@ func TestExportRecords_DBQueryError(t *testing.T) {
 	SharedFieldCache.Clear()
 	injectQueryErrorOnNthCall(t, db, 5)
 
-	_, _, _, err := svc.ExportRecords(tableID, "user1", "json", "")
+	_, _, _, err := exportRecordsBytes(svc, tableID, "user1", "json", "", "")
 	require.Error(t, err)
 }
 
@@ -107,9 +107,28 @@ func supportsFieldOptions(fieldType string) bool {
 	return fieldType == "list"
 }
 
+func isLinkFieldType(fieldType string) bool {
+	return fieldType == "link"
+}
+
+func isFormulaFieldType(fieldType string) bool {
+	return fieldType == "formula"
+}
+
+func isAutonumberFieldType(fieldType string) bool {
+	return fieldType == "autonumber"
+}
+
+// Link on-delete behaviors, applied when a record referenced by a link field is deleted.
+const (
+	linkOnDeleteRestrict = "restrict"
+	linkOnDeleteSetNull  = "set_null"
+	linkOnDeleteCascade  = "cascade"
+)
+
 // validateFieldType validates field type
 func validateFieldType(fieldType string) error {
-	validTypes := []string{"string", "text", "number", "boolean", "date", "datetime", "file", "json", "list"}
+	validTypes := []string{"string", "text", "number", "boolean", "date", "datetime", "file", "json", "list", "link", "formula", "autonumber"}
 	for _, validType := range validTypes {
 		if fieldType == validType {
 			return nil
@@ -166,6 +185,126 @@ func validateFieldConfig(config dto.FieldConfig) error {
 		return errors.New("max file size must not be negative")
 	}
 
+	switch config.OnDelete {
+	case "", linkOnDeleteRestrict, linkOnDeleteSetNull, linkOnDeleteCascade:
+	default:
+		return fmt.Errorf("invalid on_delete behavior: %s (expected restrict, set_null or cascade)", config.OnDelete)
+	}
+
+	if config.Padding < 0 || config.Padding > maxAutonumberPadding {
+		return fmt.Errorf("padding must be between 0 and %d", maxAutonumberPadding)
+	}
+	if len(config.Prefix) > 50 {
+		return errors.New("prefix must not exceed 50 characters")
+	}
+	if config.Start != nil && *config.Start < 0 {
+		return errors.New("start must not be negative")
+	}
+
+	return nil
+}
+
+// validateAutonumberFieldConfig checks the numbering options of an autonumber field.
+func validateAutonumberFieldConfig(field models.Field, config dto.FieldConfig) error {
+	if !isAutonumberFieldType(field.Type) {
+		if config.Prefix != "" || config.Padding != 0 || config.Start != nil {
+			return errors.New("prefix, padding and start are only supported for autonumber fields")
+		}
+		return nil
+	}
+	if field.Required {
+		return errors.New("autonumber field cannot be required")
+	}
+	return nil
+}
+
+// resolveLinkFieldConfig checks the target table of a link field and stores it by ID.
+// The target must be readable by the user; on_delete defaults to restrict.
+func (s *FieldService) resolveLinkFieldConfig(fieldType string, config dto.FieldConfig, userID string) (dto.FieldConfig, error) {
+	if !isLinkFieldType(fieldType) {
+		return config, nil
+	}
+	if config.LinkTableID == "" {
+		return config, errors.New("link field requires link_table_id")
+	}
+
+	target, err := s.resolveTable(config.LinkTableID)
+	if err != nil {
+		return config, fmt.Errorf("link target: %w", err)
+	}
+	if err := s.checkTableAccess(target.ID, userID, []string{"viewer"}); err != nil {
+		return config, err
+	}
+
+	config.LinkTableID = target.ID
+	if config.OnDelete == "" {
+		config.OnDelete = linkOnDeleteRestrict
+	}
+	return config, nil
+}
+
+// validateFormulaFieldConfig checks the formulas of a table as they would be after saving field.
+// It runs for every field change, since renaming or retyping a field can break formulas that reference it.
+// A formula reveals the fields it reads, so the user must read each of them unmasked.
+func (s *FieldService) validateFormulaFieldConfig(field models.Field, config dto.FieldConfig, userID string) error {
+	if !isFormulaFieldType(field.Type) {
+		if config.Formula != "" {
+			return errors.New("formula is only supported for formula fields")
+		}
+	} else {
+		if config.Formula == "" {
+			return errors.New("formula field requires a formula")
+		}
+		if field.Required {
+			return errors.New("formula field cannot be required")
+		}
+	}
+
+	var fields []models.Field
+	if err := s.db.Where("table_id = ? AND deleted_at IS NULL", field.TableID).Find(&fields).Error; err != nil {
+		return fmt.Errorf("failed to get field definitions: %w", err)
+	}
+	candidate := make([]models.Field, 0, len(fields)+1)
+	for _, existing := range fields {
+		if existing.ID != field.ID || field.ID == "" {
+			candidate = append(candidate, existing)
+		}
+	}
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("config serialization failed: %w", err)
+	}
+	field.Options = string(configJSON)
+	if err := checkTableFormulas(append(candidate, field)); err != nil {
+		return err
+	}
+	if !isFormulaFieldType(field.Type) {
+		return nil
+	}
+
+	byName := make(map[string]models.Field, len(candidate))
+	for _, existing := range candidate {
+		byName[existing.Name] = existing
+	}
+	reads := authz.FormulaReads(append(candidate, field))[field.Name]
+	fieldIDs := make([]string, 0, len(reads))
+	for _, name := range reads {
+		fieldIDs = append(fieldIDs, byName[name].ID)
+	}
+	authorizer, err := authz.NewAuthorizer(s.db, userID)
+	if err != nil {
+		return err
+	}
+	readable := authorizer.CanAccessFields(fieldIDs, authz.ActionRead)
+	masks := authorizer.FieldMasks(field.TableID, candidate)
+	for _, name := range reads {
+		if !readable[byName[name].ID] {
+			return fmt.Errorf("read permission denied for field '%s'", name)
+		}
+		if _, masked := masks[name]; masked {
+			return fmt.Errorf("field '%s' is masked and cannot be read by a formula", name)
+		}
+	}
 	return nil
 }
 
@@ -209,6 +348,12 @@ func sanitizeFieldConfig(config dto.FieldConfig) dto.FieldConfig {
 	}
 	config.AllowedTypes = cleanedAllowedTypes
 
+	config.LinkTableID = strings.TrimSpace(config.LinkTableID)
+	config.OnDelete = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(config.OnDelete)), "-", "_")
+	config.Formula = strings.TrimSpace(config.Formula)
+	config.DefaultFunc = strings.ToLower(strings.TrimSpace(config.DefaultFunc))
+	config.Prefix = strings.TrimSpace(config.Prefix)
+
 	return config
 }
 
@@ -373,6 +518,22 @@ func (s *FieldService) CreateField(req dto.FieldCreateRequest, userID string) (*
 	if err := validateFieldConfig(req.Config); err != nil {
 		return nil, fmt.Errorf("field config validation failed: %w", err)
 	}
+	req.Config, err = s.resolveLinkFieldConfig(req.Type, req.Config, userID)
+	if err != nil {
+		return nil, fmt.Errorf("field config validation failed: %w", err)
+	}
+	if err := s.validateFormulaFieldConfig(models.Field{TableID: req.TableID, Name: req.Name, Type: req.Type, Required: req.Required}, req.Config, userID); err != nil {
+		return nil, fmt.Errorf("field config validation failed: %w", err)
+	}
+	if err := validateAutonumberFieldConfig(models.Field{Type: req.Type, Required: req.Required}, req.Config); err != nil {
+		return nil, fmt.Errorf("field config validation failed: %w", err)
+	}
+	if err := validateUniqueFieldConfig(req.Type, req.Config); err != nil {
+		return nil, fmt.Errorf("field config validation failed: %w", err)
+	}
+	if err := s.validateFieldDefault(models.Field{Name: req.Name, Type: req.Type}, req.Config); err != nil {
+		return nil, fmt.Errorf("field config validation failed: %w", err)
+	}
 
 	// 5. Check for duplicate field name
 	var existingField models.Field
@@ -400,8 +561,25 @@ func (s *FieldService) CreateField(req dto.FieldCreateRequest, userID string) (*
 		Options:     string(configJSON),
 	}
 
-	if err := s.db.Create(&field).Error; err != nil {
-		return nil, fmt.Errorf("failed to create field: %w", err)
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&field).Error; err != nil {
+			return fmt.Errorf("failed to create field: %w", err)
+		}
+		if req.Config.Unique {
+			// Records may still hold values under the name of a deleted field.
+			if err := rebuildUniqueConstraint(tx, field.TableID, fieldUniqueConstraint(field)); err != nil {
+				return err
+			}
+		}
+		if isAutonumberFieldType(field.Type) {
+			return backfillAutonumberValues(tx, field)
+		}
+		if isFormulaFieldType(field.Type) {
+			return backfillFormulaValues(tx, field.TableID)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
 	}
 
 	// Reload to get database-generated timestamps
@@ -515,15 +693,22 @@ func (s *FieldService) GetField(fieldID, userID string) (*dto.FieldObject, error
 
 // UpdateField updates a field
 func (s *FieldService) UpdateField(fieldID string, req dto.FieldUpdateRequest, userID string) (*models.Field, error) {
+	field, _, err := s.UpdateFieldWithReport(fieldID, req, userID)
+	return field, err
+}
+
+// UpdateFieldWithReport updates a field and reports how existing values were converted to its new type.
+// With req.Conversion.DryRun the update is rolled back and the unchanged field is returned with the report.
+func (s *FieldService) UpdateFieldWithReport(fieldID string, req dto.FieldUpdateRequest, userID string) (*models.Field, *dto.FieldConversionReport, error) {
 	// 1. Get field info
 	field, err := s.getActiveField(fieldID)
 	if err != nil {
-		return nil, fmt.Errorf("field not found: %w", err)
+		return nil, nil, fmt.Errorf("field not found: %w", err)
 	}
 
 	// 2. Check table access (only owner, admin, editor can modify)
 	if err := s.checkTableAccess(field.TableID, userID, []string{"owner", "admin", "editor"}); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// 3. Input validation and sanitization
@@ -544,54 +729,126 @@ func (s *FieldService) UpdateField(fieldID string, req dto.FieldUpdateRequest, u
 	req.Config = sanitizeFieldConfig(req.Config)
 
 	if err := validateFieldName(req.Name); err != nil {
-		return nil, fmt.Errorf("field name validation failed: %w", err)
+		return nil, nil, fmt.Errorf("field name validation failed: %w", err)
 	}
 
 	if err := validateFieldType(req.Type); err != nil {
-		return nil, fmt.Errorf("field type validation failed: %w", err)
+		return nil, nil, fmt.Errorf("field type validation failed: %w", err)
 	}
 	if err := validateMutableFieldType(req.Type); err != nil {
-		return nil, fmt.Errorf("field type validation failed: %w", err)
+		return nil, nil, fmt.Errorf("field type validation failed: %w", err)
 	}
 	req.Type = normalizeFieldType(req.Type)
 
 	if err := validateFieldDescription(req.Description); err != nil {
-		return nil, fmt.Errorf("field description validation failed: %w", err)
+		return nil, nil, fmt.Errorf("field description validation failed: %w", err)
 	}
 
 	if err := validateFieldConfig(req.Config); err != nil {
-		return nil, fmt.Errorf("field config validation failed: %w", err)
+		return nil, nil, fmt.Errorf("field config validation failed: %w", err)
+	}
+	req.Config, err = s.resolveLinkFieldConfig(req.Type, req.Config, userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("field config validation failed: %w", err)
+	}
+	if err := s.validateFormulaFieldConfig(models.Field{ID: field.ID, TableID: field.TableID, Name: req.Name, Type: req.Type, Required: req.Required}, req.Config, userID); err != nil {
+		return nil, nil, fmt.Errorf("field config validation failed: %w", err)
+	}
+	if err := validateAutonumberFieldConfig(models.Field{Type: req.Type, Required: req.Required}, req.Config); err != nil {
+		return nil, nil, fmt.Errorf("field config validation failed: %w", err)
+	}
+	if err := validateUniqueFieldConfig(req.Type, req.Config); err != nil {
+		return nil, nil, fmt.Errorf("field config validation failed: %w", err)
+	}
+	if err := s.validateFieldDefault(models.Field{Name: req.Name, Type: req.Type}, req.Config); err != nil {
+		return nil, nil, fmt.Errorf("field config validation failed: %w", err)
+	}
+
+	table, err := s.getActiveTable(field.TableID)
+	if err != nil {
+		return nil, nil, errors.New("table not found")
+	}
+	if err := validateUniqueKeyFieldChange(table, field.Name, req.Type, req.Config); err != nil {
+		return nil, nil, fmt.Errorf("field config validation failed: %w", err)
+	}
+	if err := validateFieldTypeChange(field.Type, req.Type); err != nil {
+		return nil, nil, fmt.Errorf("field type validation failed: %w", err)
+	}
+	conversion, err := normalizeFieldConversion(req.Conversion)
+	if err != nil {
+		return nil, nil, fmt.Errorf("field conversion validation failed: %w", err)
 	}
 
 	// 4. Check for duplicate field name (excluding current field)
 	var existingField models.Field
 	err = s.db.Where("table_id = ? AND name = ? AND id != ? AND deleted_at IS NULL", field.TableID, req.Name, fieldID).First(&existingField).Error
 	if err == nil {
-		return nil, errors.New("a field with this name already exists in this table")
+		return nil, nil, errors.New("a field with this name already exists in this table")
 	}
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, fmt.Errorf("database query failed: %w", err)
+		return nil, nil, fmt.Errorf("database query failed: %w", err)
 	}
 
 	// 5. Serialize config
 	configJSON, err := json.Marshal(req.Config)
 	if err != nil {
-		return nil, fmt.Errorf("config serialization failed: %w", err)
+		return nil, nil, fmt.Errorf("config serialization failed: %w", err)
 	}
 
 	// 6. Update field info
+	previous := *field
 	field.Name = req.Name
 	field.Type = req.Type
 	field.Description = req.Description
 	field.Required = req.Required
 	field.Options = string(configJSON)
 
-	if err := s.db.Save(field).Error; err != nil {
-		return nil, fmt.Errorf("failed to update field: %w", err)
+	var report *dto.FieldConversionReport
+	var scopedTokens []string
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// The autonumber counter is only advanced by record inserts.
+		if err := tx.Omit("LastNumber").Save(field).Error; err != nil {
+			return fmt.Errorf("failed to update field: %w", err)
+		}
+		if err := renameFieldRecords(tx, previous, *field); err != nil {
+			return err
+		}
+		var err error
+		if scopedTokens, err = renameScopedField(tx, previous, *field); err != nil {
+			return err
+		}
+		if report, err = convertFieldRecords(tx, previous, *field, conversion); err != nil {
+			return err
+		}
+		if err := applyFieldUniqueChanges(tx, previous, *field); err != nil {
+			return err
+		}
+		if isAutonumberFieldType(field.Type) {
+			if err := backfillAutonumberValues(tx, *field); err != nil {
+				return err
+			}
+		} else if isFormulaFieldType(field.Type) {
+			if err := backfillFormulaValues(tx, field.TableID); err != nil {
+				return err
+			}
+		}
+		if conversion.DryRun {
+			return errFieldConversionDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, errFieldConversionDryRun) {
+		return &previous, report, nil
+	}
+	if err != nil {
+		return nil, report, err
 	}
 
 	InvalidateFieldCache(field.TableID)
-	return field, nil
+	for _, tokenID := range scopedTokens {
+		authz.InvalidateTokenCache(tokenID)
+	}
+	return field, report, nil
 }
 
 // DeleteField soft-deletes a field
@@ -607,20 +864,44 @@ func (s *FieldService) DeleteField(fieldID, userID string) error {
 		return err
 	}
 
-	// 3. Soft-delete field
+	// 3. Refuse to delete a field that a formula still computes from
+	var tableFields []models.Field
+	if err := s.db.Where("table_id = ? AND deleted_at IS NULL", field.TableID).Find(&tableFields).Error; err != nil {
+		return fmt.Errorf("failed to get field definitions: %w", err)
+	}
+	if formulaField, ok := findFormulaReferencing(tableFields, field.Name); ok {
+		return fmt.Errorf("field '%s' is still referenced by formula field '%s'", field.Name, formulaField.Name)
+	}
+	table, err := s.getActiveTable(field.TableID)
+	if err != nil {
+		return errors.New("table not found")
+	}
+	if key, ok := findUniqueKeyReferencing(TableUniqueKeys(table), field.Name); ok {
+		return fmt.Errorf("field '%s' is still referenced by unique key '%s'", field.Name, key.Name)
+	}
+
+	// 4. Soft-delete field and release its unique values
 	now := time.Now()
-	result := s.db.Model(&models.Field{}).
-		Where("id = ? AND deleted_at IS NULL", fieldID).
-		Updates(map[string]interface{}{
-			"deleted_at": now,
-			"name":       buildDeletedFieldName(field.Name, fieldID),
-			"updated_at": now,
-		})
-	if result.Error != nil {
-		return fmt.Errorf("failed to delete field: %w", result.Error)
-	}
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("field not found: %w", gorm.ErrRecordNotFound)
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Field{}).
+			Where("id = ? AND deleted_at IS NULL", fieldID).
+			Updates(map[string]interface{}{
+				"deleted_at": now,
+				"name":       buildDeletedFieldName(field.Name, fieldID),
+				"updated_at": now,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete field: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("field not found: %w", gorm.ErrRecordNotFound)
+		}
+		if err := tx.Where("field_id = ?", field.ID).Delete(&models.RecordSearchDocument{}).Error; err != nil {
+			return fmt.Errorf("failed to delete field search documents: %w", err)
+		}
+		return dropUniqueConstraint(tx, field.TableID, field.ID)
+	}); err != nil {
+		return err
 	}
 
 	InvalidateFieldCache(field.TableID)
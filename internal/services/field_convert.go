@@ -0,0 +1,280 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/dto"
+	json "github.com/jiangfire/cornerstone/pkg/jsonx"
+	"gorm.io/gorm"
+)
+
+// Policies for values that cannot be converted to a new field type.
+const (
+	conversionAbort = "abort" // roll back the field update
+	conversionNull  = "null"  // store null instead
+	conversionKeep  = "keep"  // keep the raw value
+)
+
+const (
+	conversionBatchSize   = 200
+	maxConversionFailures = 20
+)
+
+// errFieldConversionDryRun rolls back the transaction of a dry-run field update.
+var errFieldConversionDryRun = errors.New("field conversion dry run")
+
+func normalizeFieldConversion(conversion *dto.FieldConversion) (dto.FieldConversion, error) {
+	var normalized dto.FieldConversion
+	if conversion != nil {
+		normalized = *conversion
+	}
+	normalized.OnFailure = strings.ToLower(strings.TrimSpace(normalized.OnFailure))
+	normalized.DateFormat = strings.TrimSpace(normalized.DateFormat)
+	switch normalized.OnFailure {
+	case "":
+		normalized.OnFailure = conversionAbort
+	case conversionAbort, conversionNull, conversionKeep:
+	default:
+		return normalized, fmt.Errorf("invalid on_failure '%s', must be one of: abort, null, keep", normalized.OnFailure)
+	}
+	return normalized, nil
+}
+
+// validateFieldTypeChange rejects type changes whose values cannot be carried over.
+func validateFieldTypeChange(fromType, toType string) error {
+	if fromType == toType {
+		return nil
+	}
+	if isAttachmentFieldType(fromType) || isAttachmentFieldType(toType) {
+		return errors.New("the type of a file field cannot be changed, and other fields cannot become file fields")
+	}
+	if isLinkFieldType(toType) {
+		return errors.New("existing fields cannot become link fields, create a new link field instead")
+	}
+	return nil
+}
+
+// needsValueConversion reports whether stored values are rewritten for a type change.
+// Formula and autonumber fields fill their own values once saved.
+func needsValueConversion(fromType, toType string) bool {
+	return fromType != toType && !isFormulaFieldType(toType) && !isAutonumberFieldType(toType)
+}
+
+// convertFieldValue converts a stored value to a field type.
+// Blank strings become null for non-text types.
+func convertFieldValue(value interface{}, toType, dateFormat string) (interface{}, error) {
+	if text, ok := value.(string); ok && strings.TrimSpace(text) == "" && toType != "string" && toType != "text" {
+		return nil, nil
+	}
+	switch toType {
+	case "string", "text":
+		return convertToText(value)
+	case "number":
+		return convertToNumber(value)
+	case "boolean":
+		return convertToBoolean(value)
+	case "date":
+		parsed, err := convertToTime(value, dateFormat)
+		if err != nil {
+			return nil, err
+		}
+		return parsed.Format("2006-01-02"), nil
+	case "datetime":
+		parsed, err := convertToTime(value, dateFormat)
+		if err != nil {
+			return nil, err
+		}
+		return parsed.UTC().Format(time.RFC3339), nil
+	case "list":
+		return convertToList(value)
+	case "json":
+		if text, ok := value.(string); ok {
+			var parsed interface{}
+			if err := json.Unmarshal([]byte(text), &parsed); err != nil {
+				return nil, errors.New("not valid JSON")
+			}
+			return parsed, nil
+		}
+		return value, nil
+	}
+	return nil, fmt.Errorf("conversion to %s is not supported", toType)
+}
+
+func convertToText(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case []interface{}:
+		if items, err := parseStringListValue(v); err == nil {
+			return strings.Join(items, ", "), nil
+		}
+	}
+	if number, ok := recordFieldIndexNumber(value); ok {
+		return strconv.FormatFloat(number, 'f', -1, 64), nil
+	}
+	encoded, err := json.MarshalString(value)
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode value: %w", err)
+	}
+	return encoded, nil
+}
+
+func convertToNumber(value interface{}) (interface{}, error) {
+	if number, ok := recordFieldIndexNumber(value); ok {
+		return number, nil
+	}
+	switch v := value.(type) {
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil || math.IsInf(number, 0) || math.IsNaN(number) {
+			return nil, errors.New("not a number")
+		}
+		return number, nil
+	case bool:
+		if v {
+			return float64(1), nil
+		}
+		return float64(0), nil
+	}
+	return nil, errors.New("not a number")
+}
+
+func convertToBoolean(value interface{}) (interface{}, error) {
+	if number, ok := recordFieldIndexNumber(value); ok {
+		return number != 0, nil
+	}
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true", "t", "yes", "y", "1":
+			return true, nil
+		case "false", "f", "no", "n", "0":
+			return false, nil
+		}
+	}
+	return nil, errors.New("not a boolean")
+}
+
+func convertToTime(value interface{}, dateFormat string) (time.Time, error) {
+	text, ok := value.(string)
+	if !ok {
+		return time.Time{}, errors.New("not a date")
+	}
+	text = strings.TrimSpace(text)
+	layouts := []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+	if dateFormat != "" {
+		layouts = []string{dateFormat}
+	}
+	for _, layout := range layouts {
+		if parsed, err := time.Parse(layout, text); err == nil {
+			return parsed, nil
+		}
+	}
+	if dateFormat != "" {
+		return time.Time{}, fmt.Errorf("does not match date format %s", dateFormat)
+	}
+	return time.Time{}, errors.New("not a date")
+}
+
+func convertToList(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return splitAndTrim(v, ","), nil
+	case []interface{}:
+		if _, err := parseStringListValue(v); err == nil {
+			return v, nil
+		}
+	}
+	return nil, errors.New("not a list of strings")
+}
+
+// convertFieldRecords rewrites the values of a field after its type changed inside tx and
+// rebuilds the field indexes of the rewritten records. Records must already be keyed by the
+// field's new name. With the abort policy any failure fails the conversion, after every
+// record was checked so the report is complete.
+func convertFieldRecords(tx *gorm.DB, previous, field models.Field, conversion dto.FieldConversion) (*dto.FieldConversionReport, error) {
+	report := &dto.FieldConversionReport{
+		FieldID:   field.ID,
+		FromType:  previous.Type,
+		ToType:    field.Type,
+		DryRun:    conversion.DryRun,
+		OnFailure: conversion.OnFailure,
+	}
+	if !needsValueConversion(previous.Type, field.Type) {
+		return report, nil
+	}
+
+	var fields []models.Field
+	if err := tx.Where("table_id = ? AND deleted_at IS NULL", field.TableID).Order("created_at ASC").Find(&fields).Error; err != nil {
+		return nil, fmt.Errorf("failed to get field definitions: %w", err)
+	}
+	recordService := NewRecordService(tx)
+	now := time.Now()
+
+	var records []models.Record
+	if err := tx.Where("table_id = ? AND deleted_at IS NULL", field.TableID).
+		FindInBatches(&records, conversionBatchSize, func(_ *gorm.DB, _ int) error {
+			for _, record := range records {
+				payload := parseRecordPayload(record.Data)
+				value, exists := payload[field.Name]
+				if !exists || value == nil {
+					continue
+				}
+				report.Total++
+
+				converted, err := convertFieldValue(value, field.Type, conversion.DateFormat)
+				if err != nil {
+					report.Failed++
+					if len(report.Failures) < maxConversionFailures {
+						report.Failures = append(report.Failures, dto.FieldConversionFailure{RecordID: record.ID, Value: value, Error: err.Error()})
+					}
+					// Kept and aborted values are left as stored.
+					if conversion.OnFailure != conversionNull {
+						continue
+					}
+				} else {
+					report.Converted++
+				}
+
+				payload[field.Name] = converted
+				// Formulas may compute from the converted value.
+				applyFormulaValues(fields, payload, now)
+				dataJSON, err := marshalRecordPayload(payload)
+				if err != nil {
+					return err
+				}
+				// A new version keeps writes based on the unconverted value from overwriting it.
+				if err := tx.Model(&models.Record{}).Where("id = ?", record.ID).
+					Updates(map[string]interface{}{
+						"data":       dataJSON,
+						"updated_at": now,
+						"version":    gorm.Expr("version + 1"),
+					}).Error; err != nil {
+					return fmt.Errorf("failed to store converted values: %w", err)
+				}
+				if err := recordService.syncRecordFieldIndexes(tx, record.ID, field.TableID, fields, payload); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error; err != nil {
+		return nil, err
+	}
+
+	if report.Failed > 0 && conversion.OnFailure == conversionAbort && !conversion.DryRun {
+		first := report.Failures[0]
+		return report, fmt.Errorf("cannot convert field '%s' from %s to %s: %d of %d values failed, e.g. record %s: %s (use on_failure null or keep to convert anyway)",
+			previous.Name, previous.Type, field.Type, report.Failed, report.Total, first.RecordID, first.Error)
+	}
+	return report, nil
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/dto"
+)
+
+func TestConvertFieldValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  interface{}
+		toType string
+		format string
+		want   interface{}
+		fails  bool
+	}{
+		{"string to number", " 12.5 ", "number", "", 12.5, false},
+		{"bad number", "n/a", "number", "", nil, true},
+		{"blank to number", "  ", "number", "", nil, false},
+		{"number to string", 3.0, "string", "", "3", false},
+		{"bool to text", true, "text", "", "true", false},
+		{"list to string", []interface{}{"a", "b"}, "string", "", "a, b", false},
+		{"string to list", "a, b,,c", "list", "", []string{"a", "b", "c"}, false},
+		{"string to boolean", "Yes", "boolean", "", true, false},
+		{"number to boolean", 0.0, "boolean", "", false, false},
+		{"string to date", "2026-03-04T10:00:00Z", "date", "", "2026-03-04", false},
+		{"string to date with format", "04/03/2026", "date", "02/01/2006", "2026-03-04", false},
+		{"format mismatch", "2026-03-04", "date", "02/01/2006", nil, true},
+		{"date to datetime", "2026-03-04", "datetime", "", "2026-03-04T00:00:00Z", false},
+		{"text to json", `{"a":1}`, "json", "", map[string]interface{}{"a": float64(1)}, false},
+		{"invalid json", "{", "json", "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := convertFieldValue(tt.value, tt.toType, tt.format)
+			if tt.fails {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func setupConversionTestEnv(t *testing.T) (*FieldService, *RecordService, *models.Table, *models.Token, *models.Field, []*models.Record) {
+	t.Helper()
+	_, table, master, fieldSvc, svc := setupFormulaTestEnv(t)
+	field, err := fieldSvc.CreateField(dto.FieldCreateRequest{TableID: table.ID, Name: "amount", Type: "string"}, master.ID)
+	require.NoError(t, err)
+
+	var records []*models.Record
+	for _, value := range []string{"10", "2.5", "n/a"} {
+		record, err := svc.CreateRecord(dto.RecordCreateRequest{TableID: table.ID, Data: map[string]interface{}{"amount": value}}, master.ID)
+		require.NoError(t, err)
+		records = append(records, record)
+	}
+	return fieldSvc, svc, table, master, field, records
+}
+
+func storedRecordValue(t *testing.T, svc *RecordService, recordID, name string) interface{} {
+	t.Helper()
+	var record models.Record
+	require.NoError(t, svc.db.First(&record, "id = ?", recordID).Error)
+	return parseRecordPayload(record.Data)[name]
+}
+
+func TestUpdateField_ConversionDryRunAndAbort(t *testing.T) {
+	fieldSvc, svc, _, master, field, records := setupConversionTestEnv(t)
+
+	req := dto.FieldUpdateRequest{Name: "amount", Type: "number", Conversion: &dto.FieldConversion{DryRun: true}}
+	updated, report, err := fieldSvc.UpdateFieldWithReport(field.ID, req, master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "string", updated.Type)
+	assert.Equal(t, 3, report.Total)
+	assert.Equal(t, 2, report.Converted)
+	assert.Equal(t, 1, report.Failed)
+	require.Len(t, report.Failures, 1)
+	assert.Equal(t, records[2].ID, report.Failures[0].RecordID)
+	assert.Equal(t, "10", storedRecordValue(t, svc, records[0].ID, "amount"))
+
+	req.Conversion = nil
+	_, err = fieldSvc.UpdateField(field.ID, req, master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot convert field 'amount' from string to number: 1 of 3 values failed")
+
+	stored, err := fieldSvc.getActiveField(field.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "string", stored.Type)
+	assert.Equal(t, "10", storedRecordValue(t, svc, records[0].ID, "amount"))
+}
+
+func TestUpdateField_ConversionPolicies(t *testing.T) {
+	fieldSvc, svc, table, master, field, records := setupConversionTestEnv(t)
+
+	_, report, err := fieldSvc.UpdateFieldWithReport(field.ID, dto.FieldUpdateRequest{
+		Name: "amount", Type: "number", Conversion: &dto.FieldConversion{OnFailure: "null"},
+	}, master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, 10.0, storedRecordValue(t, svc, records[0].ID, "amount"))
+	assert.Nil(t, storedRecordValue(t, svc, records[2].ID, "amount"))
+
+	// Converted records get a new version, so writes based on the old value are rejected.
+	_, err = svc.UpdateRecord(records[0].ID, dto.RecordUpdateRequest{Data: map[string]interface{}{"amount": "11"}, Version: records[0].Version}, master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "modified by another user")
+
+	// Field indexes follow the new type.
+	var index models.RecordFieldIndex
+	require.NoError(t, svc.db.Where("record_id = ? AND field_id = ? AND deleted_at IS NULL", records[1].ID, field.ID).First(&index).Error)
+	assert.Equal(t, "number", index.ValueType)
+	require.NotNil(t, index.ValueNumber)
+	assert.Equal(t, 2.5, *index.ValueNumber)
+
+	// Converting back keeps every value, and keep leaves unconvertible values as stored.
+	_, err = fieldSvc.UpdateField(field.ID, dto.FieldUpdateRequest{Name: "amount", Type: "string"}, master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "10", storedRecordValue(t, svc, records[0].ID, "amount"))
+	_, err = svc.CreateRecord(dto.RecordCreateRequest{TableID: table.ID, Data: map[string]interface{}{"amount": "soon"}}, master.ID)
+	require.NoError(t, err)
+	_, report, err = fieldSvc.UpdateFieldWithReport(field.ID, dto.FieldUpdateRequest{
+		Name: "amount", Type: "date", Conversion: &dto.FieldConversion{OnFailure: "keep"},
+	}, master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Failed)
+	assert.Equal(t, "10", storedRecordValue(t, svc, records[0].ID, "amount"))
+
+	_, err = fieldSvc.UpdateField(field.ID, dto.FieldUpdateRequest{Name: "amount", Type: "file"}, master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot become file fields")
+	_, err = fieldSvc.UpdateField(field.ID, dto.FieldUpdateRequest{
+		Name: "amount", Type: "string", Conversion: &dto.FieldConversion{OnFailure: "skip"},
+	}, master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid on_failure 'skip'")
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/dto"
+	"gorm.io/gorm"
+)
+
+// Dynamic field defaults, evaluated when a record is created.
+const (
+	defaultFuncNow       = "now"        // current time, RFC 3339 in UTC
+	defaultFuncToday     = "today"      // current date, YYYY-MM-DD in UTC
+	defaultFuncTokenName = "token_name" // name of the token creating the record
+	defaultFuncUUID      = "uuid"       // random UUID, unique per record
+)
+
+// defaultFuncFieldTypes lists the field types each dynamic default can fill.
+var defaultFuncFieldTypes = map[string][]string{
+	defaultFuncNow:       {"datetime", "string", "text"},
+	defaultFuncToday:     {"date", "string", "text"},
+	defaultFuncTokenName: {"string", "text"},
+	defaultFuncUUID:      {"string", "text"},
+}
+
+// FieldDefaultContext carries the values dynamic defaults are computed from.
+type FieldDefaultContext struct {
+	TokenName string
+	Now       time.Time
+}
+
+// NewFieldDefaultContext builds the default context for records created by a token.
+func NewFieldDefaultContext(db *gorm.DB, tokenID string) (FieldDefaultContext, error) {
+	ctx := FieldDefaultContext{Now: time.Now()}
+	if tokenID == "" {
+		return ctx, nil
+	}
+	var token models.Token
+	if err := db.Select("id", "name").Where("id = ?", tokenID).Take(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx, nil
+		}
+		return ctx, fmt.Errorf("failed to load token: %w", err)
+	}
+	ctx.TokenName = token.Name
+	return ctx, nil
+}
+
+func hasFieldDefault(config dto.FieldConfig) bool {
+	return config.Default != nil || config.DefaultFunc != ""
+}
+
+func hasFieldDefaults(fields []models.Field) bool {
+	for _, field := range fields {
+		if hasFieldDefault(parseStoredFieldConfig(field.Options)) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldDefaultValue computes the default of a field, reporting false when it has none.
+func fieldDefaultValue(config dto.FieldConfig, ctx FieldDefaultContext) (interface{}, bool) {
+	switch config.DefaultFunc {
+	case "":
+		return config.Default, config.Default != nil
+	case defaultFuncNow:
+		return ctx.Now.UTC().Format(time.RFC3339), true
+	case defaultFuncToday:
+		return ctx.Now.UTC().Format("2006-01-02"), true
+	case defaultFuncTokenName:
+		return ctx.TokenName, true
+	case defaultFuncUUID:
+		return uuid.NewString(), true
+	}
+	return nil, false
+}
+
+// ApplyFieldDefaults fills the fields absent from payload with their defaults and returns the
+// fields it filled. A field present with a null value is left as is.
+func ApplyFieldDefaults(fields []models.Field, payload map[string]interface{}, ctx FieldDefaultContext) []models.Field {
+	var filled []models.Field
+	for _, field := range fields {
+		if _, exists := payload[field.Name]; exists {
+			continue
+		}
+		config := parseStoredFieldConfig(field.Options)
+		if !hasFieldDefault(config) {
+			continue
+		}
+		if value, ok := fieldDefaultValue(config, ctx); ok {
+			payload[field.Name] = value
+			filled = append(filled, field)
+		}
+	}
+	return filled
+}
+
+// applyFieldDefaults fills the absent fields of a record created by userID with their defaults.
+// It runs after the write permission check: defaults also fill fields the user cannot write.
+func (s *RecordService) applyFieldDefaults(fields []models.Field, payload map[string]interface{}, userID string) ([]models.Field, error) {
+	if !hasFieldDefaults(fields) {
+		return nil, nil
+	}
+	ctx, err := NewFieldDefaultContext(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	return ApplyFieldDefaults(fields, payload, ctx), nil
+}
+
+// refreshPerRecordDefaults recomputes the filled defaults that must differ between records.
+func refreshPerRecordDefaults(filled []models.Field, payload map[string]interface{}) {
+	for _, field := range filled {
+		if parseStoredFieldConfig(field.Options).DefaultFunc == defaultFuncUUID {
+			payload[field.Name] = uuid.NewString()
+		}
+	}
+}
+
+func hasPerRecordDefaults(filled []models.Field) bool {
+	for _, field := range filled {
+		if parseStoredFieldConfig(field.Options).DefaultFunc == defaultFuncUUID {
+			return true
+		}
+	}
+	return false
+}
+
+// validateFieldDefault checks the default of a field against its type and configuration.
+func (s *FieldService) validateFieldDefault(field models.Field, config dto.FieldConfig) error {
+	if !hasFieldDefault(config) {
+		return nil
+	}
+	if config.Default != nil && config.DefaultFunc != "" {
+		return errors.New("default and default_func cannot both be set")
+	}
+	switch field.Type {
+	case "formula", "autonumber", "file", "link":
+		return fmt.Errorf("default is not supported for %s fields", field.Type)
+	}
+
+	if config.DefaultFunc != "" {
+		types, ok := defaultFuncFieldTypes[config.DefaultFunc]
+		if !ok {
+			return fmt.Errorf("invalid default_func '%s', must be one of: now, today, token_name, uuid", config.DefaultFunc)
+		}
+		for _, fieldType := range types {
+			if fieldType == field.Type {
+				return nil
+			}
+		}
+		return fmt.Errorf("default_func '%s' is not supported for %s fields", config.DefaultFunc, field.Type)
+	}
+
+	if config.Unique {
+		return errors.New("a static default cannot be used on a unique field")
+	}
+	if err := NewRecordService(s.db).validateFieldValueWithConfig(field, config, config.Default); err != nil {
+		return fmt.Errorf("invalid default: %w", err)
+	}
+	return nil
+}
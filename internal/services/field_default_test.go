@@ -0,0 +1,97 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/dto"
+)
+
+func TestCreateField_DefaultValidation(t *testing.T) {
+	_, table, master, svc, _ := setupFormulaTestEnv(t)
+
+	tests := []struct {
+		name string
+		req  dto.FieldCreateRequest
+		want string
+	}{
+		{"static and func", dto.FieldCreateRequest{Name: "f", Type: "string", Config: dto.FieldConfig{Default: "a", DefaultFunc: "uuid"}}, "cannot both be set"},
+		{"wrong static type", dto.FieldCreateRequest{Name: "f", Type: "number", Config: dto.FieldConfig{Default: "abc"}}, "invalid default: expected number type"},
+		{"unknown func", dto.FieldCreateRequest{Name: "f", Type: "string", Config: dto.FieldConfig{DefaultFunc: "random"}}, "invalid default_func 'random'"},
+		{"func for wrong type", dto.FieldCreateRequest{Name: "f", Type: "number", Config: dto.FieldConfig{DefaultFunc: "now"}}, "default_func 'now' is not supported for number fields"},
+		{"autonumber", dto.FieldCreateRequest{Name: "f", Type: "autonumber", Config: dto.FieldConfig{Default: "1"}}, "default is not supported for autonumber fields"},
+		{"static on unique", dto.FieldCreateRequest{Name: "f", Type: "string", Config: dto.FieldConfig{Default: "a", Unique: true}}, "static default cannot be used on a unique field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.TableID = table.ID
+			_, err := svc.CreateField(tt.req, master.ID)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+
+	field, err := svc.CreateField(dto.FieldCreateRequest{
+		TableID: table.ID, Name: "opened", Type: "date", Config: dto.FieldConfig{DefaultFunc: " TODAY "},
+	}, master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "today", parseStoredFieldConfig(field.Options).DefaultFunc)
+}
+
+func TestCreateRecord_AppliesDefaults(t *testing.T) {
+	db, table, master, fieldSvc, svc := setupFormulaTestEnv(t)
+	for _, req := range []dto.FieldCreateRequest{
+		{Name: "status", Type: "string", Required: true, Config: dto.FieldConfig{Default: "new"}},
+		{Name: "priority", Type: "number", Config: dto.FieldConfig{Default: 3}},
+		{Name: "created_on", Type: "datetime", Config: dto.FieldConfig{DefaultFunc: "now"}},
+		{Name: "owner", Type: "string", Config: dto.FieldConfig{DefaultFunc: "token_name"}},
+		{Name: "ref", Type: "string", Config: dto.FieldConfig{DefaultFunc: "uuid", Unique: true}},
+	} {
+		req.TableID = table.ID
+		_, err := fieldSvc.CreateField(req, master.ID)
+		require.NoError(t, err)
+	}
+	var token models.Token
+	require.NoError(t, db.First(&token, "id = ?", master.ID).Error)
+
+	record, err := svc.CreateRecord(dto.RecordCreateRequest{TableID: table.ID, Data: map[string]interface{}{"name": "a"}}, master.ID)
+	require.NoError(t, err)
+	data := parseRecordPayload(record.Data)
+	assert.Equal(t, "new", data["status"])
+	assert.EqualValues(t, 3, data["priority"])
+	assert.Equal(t, token.Name, data["owner"])
+	_, err = time.Parse(time.RFC3339, data["created_on"].(string))
+	assert.NoError(t, err)
+	_, err = uuid.Parse(data["ref"].(string))
+	assert.NoError(t, err)
+
+	// Provided values, including null, win over defaults; updates do not apply defaults.
+	record, err = svc.CreateRecord(dto.RecordCreateRequest{TableID: table.ID, Data: map[string]interface{}{"status": "open", "priority": nil}}, master.ID)
+	require.NoError(t, err)
+	data = parseRecordPayload(record.Data)
+	assert.Equal(t, "open", data["status"])
+	assert.Nil(t, data["priority"])
+	updated, err := svc.UpdateRecord(record.ID, dto.RecordUpdateRequest{Data: map[string]interface{}{"name": "b"}}, master.ID)
+	require.NoError(t, err)
+	assert.Nil(t, parseRecordPayload(updated.Data)["priority"])
+
+	// Batches share static defaults but get a fresh uuid per record.
+	records, err := svc.BatchCreateRecords(dto.RecordCreateRequest{TableID: table.ID, Data: map[string]interface{}{"name": "x"}}, master.ID, 3)
+	require.NoError(t, err)
+	refs := map[interface{}]bool{}
+	for _, record := range records {
+		data := parseRecordPayload(record.Data)
+		assert.Equal(t, "new", data["status"])
+		refs[data["ref"]] = true
+	}
+	assert.Len(t, refs, 3)
+
+	generated, err := svc.GenerateTestData(table.ID, master.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "new", parseRecordPayload(generated[0].Data)["status"])
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jiangfire/cornerstone/internal/authz"
+	"github.com/jiangfire/cornerstone/internal/models"
+	"gorm.io/gorm"
+)
+
+const renameBatchSize = 500
+
+// renameFieldRecords moves the values of a renamed field to its new key in every record of the
+// table, deleted records included, and renames the field in the record field indexes.
+func renameFieldRecords(tx *gorm.DB, previous, field models.Field) error {
+	if previous.Name == field.Name {
+		return nil
+	}
+
+	var ids []string
+	var batch []models.Record
+	if err := tx.Unscoped().Select("id").Where("table_id = ?", field.TableID).
+		FindInBatches(&batch, renameBatchSize, func(_ *gorm.DB, _ int) error {
+			ids = ids[:0]
+			for _, record := range batch {
+				ids = append(ids, record.ID)
+			}
+			return renameRecordKeys(tx, ids, previous.Name, field.Name)
+		}).Error; err != nil {
+		return fmt.Errorf("failed to rename field in records: %w", err)
+	}
+
+	if err := tx.Model(&models.RecordFieldIndex{}).Where("field_id = ?", field.ID).
+		Update("field_name", field.Name).Error; err != nil {
+		return fmt.Errorf("failed to rename field indexes: %w", err)
+	}
+	return nil
+}
+
+// renameRecordKeys renames a top-level key in the data of the given records, using the JSON
+// functions of the database when the key can be addressed by a JSON path.
+func renameRecordKeys(tx *gorm.DB, ids []string, oldName, newName string) error {
+	records := tx.Unscoped().Model(&models.Record{}).Where("id IN ?", ids)
+	pathSafe := !strings.ContainsAny(oldName+newName, `"\`)
+	switch {
+	case tx.Name() == "postgres":
+		return records.Where("data -> ?::text IS NOT NULL", oldName).
+			UpdateColumn("data", gorm.Expr("(data - ?::text) || jsonb_build_object(?::text, data -> ?::text)", oldName, newName, oldName)).Error
+	case tx.Name() == "mysql" && pathSafe:
+		return records.Where("JSON_CONTAINS_PATH(data, 'one', ?)", jsonKeyPath(oldName)).
+			UpdateColumn("data", gorm.Expr("JSON_SET(JSON_REMOVE(data, ?), ?, JSON_EXTRACT(data, ?))",
+				jsonKeyPath(oldName), jsonKeyPath(newName), jsonKeyPath(oldName))).Error
+	case tx.Name() == "sqlite" && pathSafe:
+		// The -> operator keeps the value as JSON, so objects and booleans are not turned into text.
+		return records.Where("data -> ? IS NOT NULL", jsonKeyPath(oldName)).
+			UpdateColumn("data", gorm.Expr("json_set(json_remove(data, ?), ?, data -> ?)",
+				jsonKeyPath(oldName), jsonKeyPath(newName), jsonKeyPath(oldName))).Error
+	}
+
+	var rows []models.Record
+	if err := records.Select("id", "data").Find(&rows).Error; err != nil {
+		return err
+	}
+	for _, row := range rows {
+		payload := parseRecordPayload(row.Data)
+		value, exists := payload[oldName]
+		if !exists {
+			continue
+		}
+		delete(payload, oldName)
+		payload[newName] = value
+		dataJSON, err := marshalRecordPayload(payload)
+		if err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.Record{}).Where("id = ?", row.ID).UpdateColumn("data", dataJSON).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonKeyPath returns the JSON path of a top-level object key.
+func jsonKeyPath(name string) string {
+	return `$."` + name + `"`
+}
+
+// renameScopedField renames the field in the field scopes of every token that grants it by name.
+// It returns the IDs of the updated tokens, whose cached permissions must be dropped after commit.
+func renameScopedField(tx *gorm.DB, previous, field models.Field) ([]string, error) {
+	if previous.Name == field.Name {
+		return nil, nil
+	}
+	var tokens []models.Token
+	if err := tx.Select("id", "scopes").Where("scopes LIKE ?", "%"+field.TableID+"%").Find(&tokens).Error; err != nil {
+		return nil, fmt.Errorf("failed to load token scopes: %w", err)
+	}
+	var updated []string
+	for _, token := range tokens {
+		scopes, changed, err := authz.RenameScopedField(token.Scopes, field.TableID, previous.Name, field.Name)
+		if err != nil || !changed {
+			// Tokens with unreadable scopes grant nothing, so there is nothing to rename.
+			continue
+		}
+		if err := tx.Model(&models.Token{}).Where("id = ?", token.ID).Update("scopes", scopes).Error; err != nil {
+			return nil, fmt.Errorf("failed to update token scopes: %w", err)
+		}
+		updated = append(updated, token.ID)
+	}
+	return updated, nil
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jiangfire/cornerstone/internal/authz"
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/dto"
+)
+
+func TestUpdateField_RenameRewritesRecords(t *testing.T) {
+	db, table, master, fieldSvc, svc := setupFormulaTestEnv(t)
+	meta, err := fieldSvc.CreateField(dto.FieldCreateRequest{TableID: table.ID, Name: "meta", Type: "json"}, master.ID)
+	require.NoError(t, err)
+	flag, err := fieldSvc.CreateField(dto.FieldCreateRequest{TableID: table.ID, Name: "flag", Type: "boolean"}, master.ID)
+	require.NoError(t, err)
+
+	kept := createUniqueTestRecord(t, svc, table, master, map[string]interface{}{
+		"name": "a", "price": 2, "meta": map[string]interface{}{"k": []interface{}{1.0}}, "flag": true,
+	})
+	deleted := createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"name": "b"})
+	empty := createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"price": 3})
+	require.NoError(t, svc.DeleteRecord(deleted.ID, master.ID))
+
+	name := getTestField(t, db, table, "name")
+	_, err = fieldSvc.UpdateField(name.ID, dto.FieldUpdateRequest{Name: "title", Type: "string"}, master.ID)
+	require.NoError(t, err)
+	_, err = fieldSvc.UpdateField(meta.ID, dto.FieldUpdateRequest{Name: "details", Type: "json"}, master.ID)
+	require.NoError(t, err)
+	_, err = fieldSvc.UpdateField(flag.ID, dto.FieldUpdateRequest{Name: "active", Type: "boolean"}, master.ID)
+	require.NoError(t, err)
+	// A rename combined with a type change converts the values under the new name.
+	price := getTestField(t, db, table, "price")
+	_, err = fieldSvc.UpdateField(price.ID, dto.FieldUpdateRequest{Name: "cost", Type: "string"}, master.ID)
+	require.NoError(t, err)
+
+	var record models.Record
+	require.NoError(t, db.First(&record, "id = ?", kept.ID).Error)
+	assert.Equal(t, map[string]interface{}{
+		"title": "a", "cost": "2", "details": map[string]interface{}{"k": []interface{}{1.0}}, "active": true,
+	}, parseRecordPayload(record.Data))
+	var emptyRecord, deletedRecord models.Record
+	require.NoError(t, db.First(&emptyRecord, "id = ?", empty.ID).Error)
+	assert.Equal(t, map[string]interface{}{"cost": "3"}, parseRecordPayload(emptyRecord.Data))
+	require.NoError(t, db.Unscoped().First(&deletedRecord, "id = ?", deleted.ID).Error)
+	assert.Equal(t, map[string]interface{}{"title": "b"}, parseRecordPayload(deletedRecord.Data))
+
+	var stale int64
+	require.NoError(t, db.Model(&models.RecordFieldIndex{}).Where("field_id = ? AND field_name <> ?", name.ID, "title").Count(&stale).Error)
+	assert.Zero(t, stale)
+	list, err := svc.ListRecords(dto.RecordListQueryRequest{TableID: table.ID, Limit: 10, Filter: `{"title":"a"}`}, master.ID)
+	require.NoError(t, err)
+	assert.Len(t, list.Records, 1)
+}
+
+func TestUpdateField_RenameUpdatesTokenScopes(t *testing.T) {
+	db, table, master, fieldSvc, _ := setupFormulaTestEnv(t)
+	name := getTestField(t, db, table, "name")
+	token := &models.Token{
+		Name:   "reader",
+		Scopes: `{"tables":{"` + table.ID + `":{"role":"","fields":{"name":["read"]}}}}`,
+	}
+	require.NoError(t, db.Create(token).Error)
+	authorizer, err := authz.NewAuthorizer(db, token.ID)
+	require.NoError(t, err)
+	require.True(t, authorizer.CanAccessField(name.ID, authz.ActionRead))
+
+	_, err = fieldSvc.UpdateField(name.ID, dto.FieldUpdateRequest{Name: "title", Type: "string"}, master.ID)
+	require.NoError(t, err)
+
+	require.NoError(t, db.First(token, "id = ?", token.ID).Error)
+	assert.Contains(t, token.Scopes, `"title":["read"]`)
+	assert.NotContains(t, token.Scopes, `"name"`)
+	authorizer, err = authz.NewAuthorizer(db, token.ID)
+	require.NoError(t, err)
+	assert.True(t, authorizer.CanAccessField(name.ID, authz.ActionRead))
+}
@@ -89,7 +89,7 @@ func BenchmarkRecordServiceListRecords(b *testing.B) {
 	if err != nil {
 		b.Fatal(err)
 	}
-	readableFields, _, err := service.getFieldAccessMaps(fields, userID)
+	readableFields, _, _, err := service.getFieldAccessMaps(fields, userID)
 	if err != nil {
 		b.Fatal(err)
 	}
@@ -286,7 +286,7 @@ func benchmarkRecordResponseShaping(
 	for i := 0; i < b.N; i++ {
 		responses := make([]dto.RecordObject, 0, len(records))
 		for _, record := range records {
-			data := service.filterReadableData(fields, readableFields, parseRecordPayload(record.Data))
+			data := service.filterReadableData(fields, readableFields, nil, parseRecordPayload(record.Data))
 			responses = append(responses, dto.RecordObject{
 				ID:      record.ID,
 				TableID: record.TableID,
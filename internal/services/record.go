@@ -2,7 +2,6 @@ package services
 
 import (
 	"bytes"
-	"encoding/csv"
 	"errors"
 	"fmt"
 	"math/rand"
@@ -151,13 +150,20 @@ func (s *RecordService) validateRecordData(tableID string, data map[string]inter
 			continue
 		}
 
+		if isLinkFieldType(field.Type) {
+			if err := s.validateLinkFieldValue(config, value, userID); err != nil {
+				return fmt.Errorf("field '%s' validation failed: %w", field.Name, err)
+			}
+			continue
+		}
+
 		// Validate data based on field type
 		if err := s.validateFieldValueWithConfig(field, config, value); err != nil {
 			return fmt.Errorf("field '%s' validation failed: %w", field.Name, err)
 		}
 	}
 
-	return nil
+	return s.checkRowData(tableID, fields, data, userID)
 }
 
 func (s *RecordService) validateAttachmentFieldValue(field models.Field, config dto.FieldConfig, value interface{}, currentRecordID, userID string) error {
@@ -337,6 +343,12 @@ func (s *RecordService) validateFieldValueWithConfig(field models.Field, config
 		if err != nil {
 			return err
 		}
+	case "link":
+		recordIDs, err := parseLinkValue(value)
+		if err != nil {
+			return err
+		}
+		return validateLinkCardinality(config, recordIDs)
 	case "json":
 		if strValue, ok := value.(string); ok {
 			var dummy interface{}
@@ -396,10 +408,22 @@ func (s *RecordService) normalizeRecordData(fields []models.Field, data map[stri
 			}
 		}
 	}
+	for _, field := range fields {
+		if _, exists := normalized[field.Name]; exists && isFormulaFieldType(field.Type) {
+			return nil, fmt.Errorf("field '%s' is a formula field and cannot be written", field.Name)
+		}
+		if _, exists := normalized[field.Name]; exists && isAutonumberFieldType(field.Type) {
+			return nil, fmt.Errorf("field '%s' is an autonumber field and cannot be written", field.Name)
+		}
+	}
 	return normalized, nil
 }
 
-func (s *RecordService) getFieldAccessMaps(fields []models.Field, userID string) (map[string]models.Field, map[string]models.Field, error) {
+// getFieldAccessMaps returns the fields the user can read and write, by name, and the mask rules
+// of the fields they can only read masked. Masked fields are left out of readableFields, so
+// records cannot be filtered, sorted or searched by them; filterReadableData adds their masked
+// values.
+func (s *RecordService) getFieldAccessMaps(fields []models.Field, userID string) (map[string]models.Field, map[string]models.Field, map[string]string, error) {
 	readableFields := make(map[string]models.Field, len(fields))
 	writableFields := make(map[string]models.Field, len(fields))
 
@@ -411,23 +435,54 @@ func (s *RecordService) getFieldAccessMaps(fields []models.Field, userID string)
 	fieldService := NewFieldService(s.db)
 	readResults, err := fieldService.CheckFieldPermissions(userID, fieldIDs, "read")
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	writeResults, err := fieldService.CheckFieldPermissions(userID, fieldIDs, "write")
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
+	}
+	var rules map[string]string
+	if len(fields) > 0 {
+		authorizer, err := authz.NewAuthorizer(s.db, userID)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		rules = authorizer.FieldMasks(fields[0].TableID, fields)
 	}
 
+	var masks map[string]string
 	for _, field := range fields {
 		if readResults[field.ID] {
-			readableFields[field.Name] = field
+			if rule, ok := rules[field.Name]; ok {
+				if masks == nil {
+					masks = make(map[string]string)
+				}
+				masks[field.Name] = rule
+			} else {
+				readableFields[field.Name] = field
+			}
 		}
 		if writeResults[field.ID] {
 			writableFields[field.Name] = field
 		}
 	}
 
-	return readableFields, writableFields, nil
+	// A formula reveals the fields it reads, so one reading a field the user cannot read is hidden.
+	readByName := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		readByName[field.Name] = readResults[field.ID]
+	}
+	for name, reads := range authz.FormulaReads(fields) {
+		for _, read := range reads {
+			if !readByName[read] {
+				delete(readableFields, name)
+				delete(masks, name)
+				break
+			}
+		}
+	}
+
+	return readableFields, writableFields, masks, nil
 }
 
 func (s *RecordService) ensureWritableFields(data map[string]interface{}, writableFields map[string]models.Field) error {
@@ -477,19 +532,32 @@ func splitAndTrim(s, sep string) []string {
 	return result
 }
 
-func (s *RecordService) filterReadableData(fields []models.Field, readableFields map[string]models.Field, payload map[string]interface{}) map[string]interface{} {
+// filterReadableData returns the values of the readable fields of a record payload, and the
+// masked values of the fields in masks.
+func (s *RecordService) filterReadableData(fields []models.Field, readableFields map[string]models.Field, masks map[string]string, payload map[string]interface{}) map[string]interface{} {
+	// Formulas are recomputed on every read so that values based on today() or now() stay current.
+	if hasFormulaFields(fields) {
+		applyFormulaValues(fields, payload, time.Now())
+	}
+
 	filtered := make(map[string]interface{})
 	for _, field := range fields {
-		if _, ok := readableFields[field.Name]; !ok {
+		_, readable := readableFields[field.Name]
+		rule, masked := masks[field.Name]
+		if !readable && !masked {
 			continue
 		}
-		if value, exists := payload[field.Name]; exists {
-			filtered[field.Name] = value
+		value, exists := payload[field.Name]
+		if !exists {
+			value, exists = payload[field.ID]
+		}
+		if !exists {
 			continue
 		}
-		if value, exists := payload[field.ID]; exists {
-			filtered[field.Name] = value
+		if masked {
+			value = authz.MaskValue(rule, value)
 		}
+		filtered[field.Name] = value
 	}
 	return filtered
 }
@@ -507,6 +575,7 @@ type recordFilterClause struct {
 	sql         string
 	args        []interface{}
 	indexFilter *recordFieldIndexFilter
+	keyset      *recordCursor // the clause pages past a cursor
 }
 
 type recordFieldIndexFilter struct {
@@ -524,6 +593,10 @@ func buildRecordFieldIndexRows(tableID, recordID string, fields []models.Field,
 		if !exists || value == nil {
 			continue
 		}
+		if field.Type == "link" {
+			rows = append(rows, buildLinkFieldIndexRows(tableID, recordID, field, value)...)
+			continue
+		}
 		row, ok, err := buildRecordFieldIndexRow(tableID, recordID, field, value)
 		if err != nil {
 			return nil, err
@@ -544,7 +617,7 @@ func buildRecordFieldIndexRow(tableID, recordID string, field models.Field, valu
 	}
 
 	switch normalizeFieldType(field.Type) {
-	case "string", "text", "date", "datetime":
+	case "string", "text", "date", "datetime", "autonumber":
 		text, ok := value.(string)
 		if !ok || len(text) > maxRecordFieldIndexTextLength {
 			return models.RecordFieldIndex{}, false, nil
@@ -576,6 +649,18 @@ func buildRecordFieldIndexRow(tableID, recordID string, field models.Field, valu
 		row.ValueType = "text"
 		row.ValueText = text
 		return row, true, nil
+	case "formula":
+		// Formula results are indexed by the type of the computed value.
+		computed := field
+		switch value.(type) {
+		case string:
+			computed.Type = "string"
+		case bool:
+			computed.Type = "boolean"
+		default:
+			computed.Type = "number"
+		}
+		return buildRecordFieldIndexRow(tableID, recordID, computed, value)
 	default:
 		return models.RecordFieldIndex{}, false, nil
 	}
@@ -684,18 +769,17 @@ func (s *RecordService) syncRecordFieldIndexes(tx *gorm.DB, recordID, tableID st
 	if err != nil {
 		return err
 	}
-	if len(rows) == 0 {
-		return nil
-	}
-	if err := tx.Create(&rows).Error; err != nil {
-		return fmt.Errorf("failed to write record field indexes: %w", err)
+	if len(rows) > 0 {
+		if err := tx.Create(&rows).Error; err != nil {
+			return fmt.Errorf("failed to write record field indexes: %w", err)
+		}
 	}
-	return nil
+	return syncRecordSearchDocuments(tx, recordID, tableID, fields, data)
 }
 
-func buildMySQLRecordListSQL(req dto.RecordListQueryRequest, clauses []recordFilterClause) (string, []interface{}) {
+func buildMySQLRecordListSQL(req dto.RecordListQueryRequest, clauses []recordFilterClause, sort recordSort) (string, []interface{}) {
 	if filters, ok := collectMySQLRecordFieldIndexFilters(clauses); ok {
-		return buildMySQLRecordFieldIndexListSQL(req, filters)
+		return buildMySQLRecordFieldIndexListSQL(req, filters, recordListCursor(clauses), sort)
 	}
 
 	var b strings.Builder
@@ -710,11 +794,21 @@ func buildMySQLRecordListSQL(req dto.RecordListQueryRequest, clauses []recordFil
 		args = append(args, clause.args...)
 	}
 
-	b.WriteString(" ORDER BY created_at DESC LIMIT ? OFFSET ?")
+	b.WriteString(" ORDER BY " + sort.orderBy("mysql", "", recordListCursor(clauses)) + " LIMIT ? OFFSET ?")
 	args = append(args, req.Limit, req.Offset)
 	return b.String(), args
 }
 
+// recordListCursor returns the cursor of the keyset clause among clauses, nil without one.
+func recordListCursor(clauses []recordFilterClause) *recordCursor {
+	for _, clause := range clauses {
+		if clause.keyset != nil {
+			return clause.keyset
+		}
+	}
+	return nil
+}
+
 func buildMySQLRecordCountSQL(tableID string, clauses []recordFilterClause) (string, []interface{}) {
 	if filters, ok := collectMySQLRecordFieldIndexFilters(clauses); ok {
 		return buildMySQLRecordFieldIndexCountSQL(tableID, filters)
@@ -741,15 +835,18 @@ func collectMySQLRecordFieldIndexFilters(clauses []recordFilterClause) ([]record
 	}
 	filters := make([]recordFieldIndexFilter, 0, len(clauses))
 	for _, clause := range clauses {
+		if clause.keyset != nil {
+			continue
+		}
 		if clause.indexFilter == nil {
 			return nil, false
 		}
 		filters = append(filters, *clause.indexFilter)
 	}
-	return filters, true
+	return filters, len(filters) > 0
 }
 
-func buildMySQLRecordFieldIndexListSQL(req dto.RecordListQueryRequest, filters []recordFieldIndexFilter) (string, []interface{}) {
+func buildMySQLRecordFieldIndexListSQL(req dto.RecordListQueryRequest, filters []recordFieldIndexFilter, cursor *recordCursor, sort recordSort) (string, []interface{}) {
 	subquery, args := buildMySQLRecordFieldIndexMatchedSubquery(req.TableID, filters)
 
 	var b strings.Builder
@@ -758,9 +855,15 @@ func buildMySQLRecordFieldIndexListSQL(req dto.RecordListQueryRequest, filters [
 	b.WriteString(subquery)
 	b.WriteString(") matched JOIN records FORCE INDEX (PRIMARY) ON records.id = matched.record_id ")
 	b.WriteString("WHERE records.table_id = ? AND records.deleted_at IS NULL ")
-	b.WriteString("ORDER BY records.created_at DESC LIMIT ? OFFSET ?")
+	args = append(args, req.TableID)
+	if cursor != nil {
+		keyset := cursor.clause("mysql", "records.")
+		b.WriteString("AND " + keyset.sql + " ")
+		args = append(args, keyset.args...)
+	}
+	b.WriteString("ORDER BY " + sort.orderBy("mysql", "records.", cursor) + " LIMIT ? OFFSET ?")
 
-	args = append(args, req.TableID, req.Limit, req.Offset)
+	args = append(args, req.Limit, req.Offset)
 	return b.String(), args
 }
 
@@ -806,10 +909,10 @@ func buildMySQLRecordFieldIndexMatchedSubquery(tableID string, filters []recordF
 	return b.String(), args
 }
 
-func (s *RecordService) findRecordPage(req dto.RecordListQueryRequest, clauses []recordFilterClause) ([]models.Record, error) {
+func (s *RecordService) findRecordPage(req dto.RecordListQueryRequest, clauses []recordFilterClause, sort recordSort) ([]models.Record, error) {
 	var records []models.Record
 	if s.db.Name() == "mysql" {
-		sql, args := buildMySQLRecordListSQL(req, clauses)
+		sql, args := buildMySQLRecordListSQL(req, clauses, sort)
 		if err := s.db.Raw(sql, args...).Scan(&records).Error; err != nil {
 			return nil, err
 		}
@@ -820,7 +923,7 @@ func (s *RecordService) findRecordPage(req dto.RecordListQueryRequest, clauses [
 	for _, clause := range clauses {
 		query = query.Where(clause.sql, clause.args...)
 	}
-	if err := query.Order("created_at DESC").Limit(req.Limit).Offset(req.Offset).Find(&records).Error; err != nil {
+	if err := query.Order(sort.orderBy(s.db.Name(), "", recordListCursor(clauses))).Limit(req.Limit).Offset(req.Offset).Find(&records).Error; err != nil {
 		return nil, err
 	}
 	return records, nil
@@ -1002,7 +1105,7 @@ func (s *RecordService) filterRecordsByReadablePayload(records []models.Record,
 
 	filtered := make([]models.Record, 0, len(records))
 	for _, record := range records {
-		payload := s.filterReadableData(fields, readableFields, parseRecordPayload(record.Data))
+		payload := s.filterReadableData(fields, readableFields, nil, parseRecordPayload(record.Data))
 		matched, err := s.matchesRecordFilter(fields, readableFields, payload, filter)
 		if err != nil {
 			return nil, err
@@ -1026,7 +1129,7 @@ func (s *RecordService) CreateRecord(req dto.RecordCreateRequest, userID string)
 	if err != nil {
 		return nil, err
 	}
-	readableFields, writableFields, err := s.getFieldAccessMaps(fields, userID)
+	readableFields, writableFields, masks, err := s.getFieldAccessMaps(fields, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -1038,6 +1141,10 @@ func (s *RecordService) CreateRecord(req dto.RecordCreateRequest, userID string)
 	if err := s.ensureWritableFields(normalizedData, writableFields); err != nil {
 		return nil, err
 	}
+	if _, err := s.applyFieldDefaults(fields, normalizedData, userID); err != nil {
+		return nil, err
+	}
+	applyFormulaValues(fields, normalizedData, time.Now())
 
 	// 2. Validate data
 	if err := s.validateRecordData(req.TableID, normalizedData, "", userID); err != nil {
@@ -1058,6 +1165,14 @@ func (s *RecordService) CreateRecord(req dto.RecordCreateRequest, userID string)
 	}
 
 	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		if hasAutonumberFields(fields) {
+			if err := assignAutonumberValues(tx, fields, []map[string]interface{}{normalizedData}); err != nil {
+				return err
+			}
+			if record.Data, err = marshalRecordPayload(normalizedData); err != nil {
+				return err
+			}
+		}
 		if err := tx.Create(&record).Error; err != nil {
 			return fmt.Errorf("failed to create record: %w", err)
 		}
@@ -1067,19 +1182,19 @@ func (s *RecordService) CreateRecord(req dto.RecordCreateRequest, userID string)
 		if err := s.syncRecordFieldIndexes(tx, record.ID, record.TableID, fields, normalizedData); err != nil {
 			return err
 		}
-		return nil
+		return syncRecordUniqueKeys(tx, record.TableID, record.ID, fields, normalizedData)
 	}); err != nil {
 		return nil, err
 	}
 
-	filteredData := s.filterReadableData(fields, readableFields, normalizedData)
+	filteredData := s.filterReadableData(fields, readableFields, masks, normalizedData)
 	record.Data, err = marshalRecordPayload(filteredData)
 	if err != nil {
 		return nil, err
 	}
 
-	// Reload to get database-generated timestamps
-	if err := s.db.First(&record, "id = ?", record.ID).Error; err != nil {
+	// Reload to get database-generated timestamps, keeping the filtered data
+	if err := s.db.Select("created_at", "updated_at").First(&record, "id = ?", record.ID).Error; err != nil {
 		return nil, fmt.Errorf("failed to reload record: %w", err)
 	}
 
@@ -1102,7 +1217,15 @@ func (s *RecordService) ListRecords(req dto.RecordListQueryRequest, userID strin
 	if err != nil {
 		return nil, err
 	}
-	readableFields, _, err := s.getFieldAccessMaps(fields, userID)
+	readableFields, _, masks, err := s.getFieldAccessMaps(fields, userID)
+	if err != nil {
+		return nil, err
+	}
+	sort, err := parseRecordSort(req.Sort, fields, readableFields)
+	if err != nil {
+		return nil, err
+	}
+	rowClauses, noRows, err := s.rowFilterClauses(req.TableID, userID, fields)
 	if err != nil {
 		return nil, err
 	}
@@ -1111,6 +1234,23 @@ func (s *RecordService) ListRecords(req dto.RecordListQueryRequest, userID strin
 	if req.Limit == 0 {
 		req.Limit = 20
 	}
+	cursor, err := parseRecordCursor(req, sort)
+	if err != nil {
+		return nil, err
+	}
+	if noRows {
+		return &dto.RecordListData{Records: []dto.RecordObject{}, Total: 0, HasMore: false}, nil
+	}
+	if strings.TrimSpace(req.Q) != "" {
+		return s.searchRecords(req, fields, readableFields, masks, sort, rowClauses)
+	}
+	// A page past a cursor fetches one more record to tell whether more records follow
+	pageReq := req
+	var keyset []recordFilterClause
+	if cursor != nil {
+		pageReq.Limit++
+		keyset = append(keyset, cursor.clause(s.db.Name(), ""))
+	}
 
 	var records []models.Record
 	var total int64
@@ -1119,11 +1259,11 @@ func (s *RecordService) ListRecords(req dto.RecordListQueryRequest, userID strin
 	switch filter {
 	case "":
 		// 3a. No filter: SQL pagination + COUNT
-		records, err = s.findRecordPage(req, nil)
+		records, err = s.findRecordPage(pageReq, append(keyset, rowClauses...), sort)
 		if err != nil {
 			return nil, fmt.Errorf("failed to query records: %w", err)
 		}
-		total, err = s.countRecords(req.TableID, nil)
+		total, err = s.countRecords(req.TableID, rowClauses)
 		if err != nil {
 			return nil, fmt.Errorf("failed to count records: %w", err)
 		}
@@ -1141,8 +1281,9 @@ func (s *RecordService) ListRecords(req dto.RecordListQueryRequest, userID strin
 				// preventing side-channel detection of hidden field values via 200 vs 400
 				return &dto.RecordListData{Records: []dto.RecordObject{}, Total: 0, HasMore: false}, nil
 			}
+			clauses = append(rowClauses, clauses...)
 
-			records, err = s.findRecordPage(req, clauses)
+			records, err = s.findRecordPage(pageReq, append(keyset, clauses...), sort)
 			if err != nil {
 				return nil, fmt.Errorf("failed to query records: %w", err)
 			}
@@ -1160,21 +1301,36 @@ func (s *RecordService) ListRecords(req dto.RecordListQueryRequest, userID strin
 			} else {
 				likeSQL = "table_id = ? AND deleted_at IS NULL AND data LIKE ?"
 			}
-			narrowQ := s.db.Where(likeSQL, req.TableID, likePattern).
-				Order("created_at DESC").Limit(maxKeywordScanRecords + 1)
-			var narrowed []models.Record
-			if err := narrowQ.Find(&narrowed).Error; err != nil {
-				return nil, fmt.Errorf("failed to query records: %w", err)
-			}
-			if len(narrowed) > maxKeywordScanRecords {
-				return nil, fmt.Errorf("keyword filter matched too many records (>%d), use a more specific filter or the /query endpoint", maxKeywordScanRecords)
+			narrow := func(clauses []recordFilterClause, past *recordCursor) ([]models.Record, error) {
+				narrowQ := s.db.Where(likeSQL, req.TableID, likePattern)
+				for _, clause := range clauses {
+					narrowQ = narrowQ.Where(clause.sql, clause.args...)
+				}
+				narrowQ = narrowQ.Order(sort.orderBy(s.db.Name(), "", past)).Limit(maxKeywordScanRecords + 1)
+				var narrowed []models.Record
+				if err := narrowQ.Find(&narrowed).Error; err != nil {
+					return nil, fmt.Errorf("failed to query records: %w", err)
+				}
+				if len(narrowed) > maxKeywordScanRecords {
+					return nil, fmt.Errorf("keyword filter matched too many records (>%d), use a more specific filter or the /query endpoint", maxKeywordScanRecords)
+				}
+				return s.filterRecordsByReadablePayload(narrowed, fields, readableFields, filter)
 			}
-			filtered, err := s.filterRecordsByReadablePayload(narrowed, fields, readableFields, filter)
+			filtered, err := narrow(rowClauses, nil)
 			if err != nil {
 				return nil, err
 			}
 			total = int64(len(filtered))
-			if req.Offset >= len(filtered) {
+			if cursor != nil {
+				// The records past the cursor are read in fetch order, by the keyset of the order.
+				records, err = narrow(append(keyset, rowClauses...), cursor)
+				if err != nil {
+					return nil, err
+				}
+				if len(records) > pageReq.Limit {
+					records = records[:pageReq.Limit]
+				}
+			} else if req.Offset >= len(filtered) {
 				records = []models.Record{}
 			} else {
 				end := req.Offset + req.Limit
@@ -1186,10 +1342,23 @@ func (s *RecordService) ListRecords(req dto.RecordListQueryRequest, userID strin
 		}
 	}
 
+	listData := &dto.RecordListData{Total: total}
+	if cursor != nil {
+		records = finishCursorPage(records, cursor, req.Limit, listData)
+	} else {
+		listData.HasMore = int64(req.Offset+len(records)) < total
+		if listData.HasMore && len(records) > 0 {
+			listData.NextCursor = encodeRecordCursor(records[len(records)-1], sort)
+		}
+		if req.Offset > 0 && len(records) > 0 {
+			listData.PrevCursor = encodeRecordCursor(records[0], sort)
+		}
+	}
+
 	// 7. Convert to response format
 	result := make([]dto.RecordObject, len(records))
 	for i, r := range records {
-		data := s.filterReadableData(fields, readableFields, parseRecordPayload(r.Data))
+		data := s.filterReadableData(fields, readableFields, masks, parseRecordPayload(r.Data))
 		data = filterDataFields(data, req.Fields)
 
 		result[i] = dto.RecordObject{
@@ -1200,11 +1369,8 @@ func (s *RecordService) ListRecords(req dto.RecordListQueryRequest, userID strin
 		}
 	}
 
-	return &dto.RecordListData{
-		Records: result,
-		Total:   total,
-		HasMore: int64(req.Offset+len(records)) < total,
-	}, nil
+	listData.Records = result
+	return listData, nil
 }
 
 func stringifyExportValue(value interface{}) string {
@@ -1226,105 +1392,6 @@ func stringifyExportValue(value interface{}) string {
 	}
 }
 
-// ExportRecords exports record data
-func (s *RecordService) ExportRecords(tableID, userID, format, filter string) ([]byte, string, string, error) {
-	if err := s.checkTableAccess(tableID, userID, []string{"owner", "admin", "editor", "viewer"}); err != nil {
-		return nil, "", "", err
-	}
-
-	fields, err := s.getTableFields(tableID)
-	if err != nil {
-		return nil, "", "", err
-	}
-	readableFields, _, err := s.getFieldAccessMaps(fields, userID)
-	if err != nil {
-		return nil, "", "", err
-	}
-
-	exportFields := make([]models.Field, 0, len(fields))
-	for _, field := range fields {
-		if _, ok := readableFields[field.Name]; ok {
-			exportFields = append(exportFields, field)
-		}
-	}
-
-	query := s.db.Where("table_id = ? AND deleted_at IS NULL", tableID).Order("created_at DESC")
-	var records []models.Record
-	if err := query.Find(&records).Error; err != nil {
-		return nil, "", "", fmt.Errorf("failed to read records: %w", err)
-	}
-	records, err = s.filterRecordsByReadablePayload(records, fields, readableFields, filter)
-	if err != nil {
-		return nil, "", "", err
-	}
-
-	switch strings.ToLower(format) {
-	case "json":
-		exportRows := make([]map[string]interface{}, 0, len(records))
-		for _, record := range records {
-			row := map[string]interface{}{
-				"id":       record.ID,
-				"table_id": record.TableID,
-				"version":  record.Version,
-			}
-
-			payload := s.filterReadableData(fields, readableFields, parseRecordPayload(record.Data))
-			row["data"] = payload
-			exportRows = append(exportRows, row)
-		}
-
-		data, err := json.MarshalIndent(exportRows, "", "  ")
-		if err != nil {
-			return nil, "", "", fmt.Errorf("failed to export JSON: %w", err)
-		}
-
-		filename := fmt.Sprintf("records_%s_%s.json", tableID, time.Now().Format("20060102150405"))
-		return data, "application/json; charset=utf-8", filename, nil
-
-	case "csv":
-		var buf bytes.Buffer
-		writer := csv.NewWriter(&buf)
-
-		header := []string{"id"}
-		for _, field := range exportFields {
-			header = append(header, field.Name)
-		}
-		header = append(header, "version")
-		if err := writer.Write(header); err != nil {
-			return nil, "", "", fmt.Errorf("failed to write CSV header: %w", err)
-		}
-
-		for _, record := range records {
-			row := []string{record.ID}
-			payload := s.filterReadableData(fields, readableFields, parseRecordPayload(record.Data))
-
-			for _, field := range exportFields {
-				value := payload[field.Name]
-				row = append(row, stringifyExportValue(value))
-			}
-
-			row = append(row,
-				fmt.Sprintf("%d", record.Version),
-			)
-
-			if err := writer.Write(row); err != nil {
-				return nil, "", "", fmt.Errorf("failed to write CSV data: %w", err)
-			}
-		}
-
-		writer.Flush()
-		if err := writer.Error(); err != nil {
-			return nil, "", "", fmt.Errorf("failed to generate CSV: %w", err)
-		}
-
-		filename := fmt.Sprintf("records_%s_%s.csv", tableID, time.Now().Format("20060102150405"))
-		return buf.Bytes(), "text/csv; charset=utf-8", filename, nil
-
-	default:
-		return nil, "", "", errors.New("unsupported export format, only csv/json are supported")
-	}
-}
-
 // GetRecord gets a single record
 func (s *RecordService) GetRecord(recordID, userID, fieldFilter string) (*dto.RecordObject, error) {
 	// 1. Get record
@@ -1343,20 +1410,24 @@ func (s *RecordService) GetRecord(recordID, userID, fieldFilter string) (*dto.Re
 	if err != nil {
 		return nil, err
 	}
-	readableFields, _, err := s.getFieldAccessMaps(fields, userID)
+	if err := s.checkRecordRow(record, fields, userID); err != nil {
+		return nil, err
+	}
+	readableFields, _, masks, err := s.getFieldAccessMaps(fields, userID)
 	if err != nil {
 		return nil, err
 	}
 
 	// 3. Parse data
-	data := s.filterReadableData(fields, readableFields, parseRecordPayload(record.Data))
+	data := s.filterReadableData(fields, readableFields, masks, parseRecordPayload(record.Data))
 	data = filterDataFields(data, fieldFilter)
 
 	return &dto.RecordObject{
-		ID:      record.ID,
-		TableID: record.TableID,
-		Data:    data,
-		Version: record.Version,
+		ID:       record.ID,
+		TableID:  record.TableID,
+		Data:     data,
+		Version:  record.Version,
+		Volatile: hasVolatileFormulas(fields),
 	}, nil
 }
 
@@ -1383,7 +1454,10 @@ func (s *RecordService) UpdateRecord(recordID string, req dto.RecordUpdateReques
 	if err != nil {
 		return nil, err
 	}
-	readableFields, writableFields, err := s.getFieldAccessMaps(fields, userID)
+	if err := s.checkRecordRow(record, fields, userID); err != nil {
+		return nil, err
+	}
+	readableFields, writableFields, masks, err := s.getFieldAccessMaps(fields, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -1400,24 +1474,40 @@ func (s *RecordService) UpdateRecord(recordID string, req dto.RecordUpdateReques
 	for key, value := range normalizedData {
 		currentData[key] = value
 	}
+	applyFormulaValues(fields, currentData, time.Now())
 
 	// 4. Validate data
 	if err := s.validateRecordData(record.TableID, currentData, record.ID, userID); err != nil {
 		return nil, err
 	}
 
-	// 5. Serialize data
-	dataJSON, err := json.MarshalString(currentData)
+	// 5. Save with an atomic update to prevent concurrent overwrites
+	if err := s.saveRecordData(&record, fields, currentData, req.Version); err != nil {
+		return nil, err
+	}
+
+	filteredData := s.filterReadableData(fields, readableFields, masks, currentData)
+	record.Data, err = marshalRecordPayload(filteredData)
 	if err != nil {
-		return nil, fmt.Errorf("data serialization failed: %w", err)
+		return nil, err
 	}
 
-	// 6. Atomic update to prevent concurrent overwrites
-	if err := s.db.Transaction(func(tx *gorm.DB) error {
+	return &record, nil
+}
+
+// saveRecordData stores validated data as the new data of record and reloads it. A version
+// greater than zero makes the update fail if the record changed since that version.
+func (s *RecordService) saveRecordData(record *models.Record, fields []models.Field, data map[string]interface{}, version int) error {
+	dataJSON, err := json.MarshalString(data)
+	if err != nil {
+		return fmt.Errorf("data serialization failed: %w", err)
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
 		updateQuery := tx.Model(&models.Record{}).
-			Where("id = ? AND deleted_at IS NULL", recordID)
-		if req.Version > 0 {
-			updateQuery = updateQuery.Where("version = ?", req.Version)
+			Where("id = ? AND deleted_at IS NULL", record.ID)
+		if version > 0 {
+			updateQuery = updateQuery.Where("version = ?", version)
 		}
 
 		updateResult := updateQuery.Updates(map[string]interface{}{
@@ -1431,33 +1521,32 @@ func (s *RecordService) UpdateRecord(recordID string, req dto.RecordUpdateReques
 			return errors.New("record was modified by another user, please refresh and retry")
 		}
 
-		if err := s.syncAttachmentBindings(tx, recordID, fields, currentData); err != nil {
+		if err := s.syncAttachmentBindings(tx, record.ID, fields, data); err != nil {
+			return err
+		}
+		if err := s.syncRecordFieldIndexes(tx, record.ID, record.TableID, fields, data); err != nil {
 			return err
 		}
-		if err := s.syncRecordFieldIndexes(tx, recordID, record.TableID, fields, currentData); err != nil {
+		if err := syncRecordUniqueKeys(tx, record.TableID, record.ID, fields, data); err != nil {
 			return err
 		}
 
-		if err := tx.Where("id = ?", recordID).First(&record).Error; err != nil {
+		if err := tx.Where("id = ?", record.ID).First(record).Error; err != nil {
 			return fmt.Errorf("failed to read updated record: %w", err)
 		}
 
 		return nil
-	}); err != nil {
-		return nil, err
-	}
-
-	filteredData := s.filterReadableData(fields, readableFields, currentData)
-	record.Data, err = marshalRecordPayload(filteredData)
-	if err != nil {
-		return nil, err
-	}
-
-	return &record, nil
+	})
 }
 
 // DeleteRecord soft-deletes a record
 func (s *RecordService) DeleteRecord(recordID, userID string) error {
+	return s.DeleteRecordVersion(recordID, userID, 0)
+}
+
+// DeleteRecordVersion soft-deletes a record like DeleteRecord. A version greater than zero
+// makes the delete fail if the record changed since that version.
+func (s *RecordService) DeleteRecordVersion(recordID, userID string, version int) error {
 	// 1. Get record
 	var record models.Record
 	err := s.db.Where("id = ? AND deleted_at IS NULL", recordID).First(&record).Error
@@ -1469,29 +1558,33 @@ func (s *RecordService) DeleteRecord(recordID, userID string) error {
 	if err := s.checkTableAccess(record.TableID, userID, []string{"owner", "admin"}); err != nil {
 		return err
 	}
+	fields, err := s.getTableFields(record.TableID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkRecordRow(record, fields, userID); err != nil {
+		return err
+	}
+	if version > 0 && record.Version != version {
+		return fmt.Errorf("record was modified by another user (current version: %d, requested version: %d)", record.Version, version)
+	}
 
-	// 3. Soft-delete record
+	// 3. Soft-delete record and apply on_delete behavior of link fields referencing it
 	now := time.Now()
 	if err := s.db.Transaction(func(tx *gorm.DB) error {
-		result := tx.Model(&models.Record{}).
-			Where("id = ? AND deleted_at IS NULL", recordID).
-			Updates(map[string]interface{}{
-				"deleted_at": now,
-				"updated_at": now,
-				"version":    gorm.Expr("version + 1"),
-			})
-		if result.Error != nil {
-			return fmt.Errorf("failed to delete record: %w", result.Error)
-		}
-		if result.RowsAffected == 0 {
-			return fmt.Errorf("record not found: %w", gorm.ErrRecordNotFound)
-		}
-		if err := tx.Model(&models.RecordFieldIndex{}).
-			Where("record_id = ? AND deleted_at IS NULL", recordID).
-			Update("deleted_at", now).Error; err != nil {
-			return fmt.Errorf("failed to delete record field indexes: %w", err)
+		if version > 0 {
+			// Claim the row at the checked version so a concurrent update cannot slip in.
+			claimed := tx.Model(&models.Record{}).
+				Where("id = ? AND version = ? AND deleted_at IS NULL", record.ID, version).
+				Update("updated_at", now)
+			if claimed.Error != nil {
+				return fmt.Errorf("failed to delete record: %w", claimed.Error)
+			}
+			if claimed.RowsAffected == 0 {
+				return errors.New("record was modified by another user, please refresh and retry")
+			}
 		}
-		return nil
+		return s.deleteRecordTx(tx, record, userID, now, make(map[string]struct{}))
 	}); err != nil {
 		return err
 	}
@@ -1521,7 +1614,7 @@ func (s *RecordService) BatchCreateRecords(req dto.RecordCreateRequest, userID s
 	if err != nil {
 		return nil, err
 	}
-	readableFields, writableFields, err := s.getFieldAccessMaps(fields, userID)
+	readableFields, writableFields, masks, err := s.getFieldAccessMaps(fields, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -1546,6 +1639,11 @@ func (s *RecordService) BatchCreateRecords(req dto.RecordCreateRequest, userID s
 			return nil, errors.New("batch creation does not support file fields")
 		}
 	}
+	defaulted, err := s.applyFieldDefaults(fields, normalizedData, userID)
+	if err != nil {
+		return nil, err
+	}
+	applyFormulaValues(fields, normalizedData, time.Now())
 
 	// 2. Validate data
 	if err := s.validateRecordData(req.TableID, normalizedData, "", userID); err != nil {
@@ -1562,17 +1660,48 @@ func (s *RecordService) BatchCreateRecords(req dto.RecordCreateRequest, userID s
 
 	// 4. Batch-create in a single transaction for atomicity; batching controls per-INSERT size
 	records := make([]*models.Record, 0, count)
+	// Autonumber fields and uuid defaults give every record its own payload; otherwise all records share one.
+	numbered := hasAutonumberFields(fields) || hasPerRecordDefaults(defaulted)
+	var payloads []map[string]interface{}
 	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		constraints, err := loadUniqueConstraints(tx, req.TableID, fields)
+		if err != nil {
+			return err
+		}
 		for i := 0; i < count; i += batchSize {
 			end := i + batchSize
 			if end > count {
 				end = count
 			}
+			batchPayloads := make([]map[string]interface{}, end-i)
+			for j := range batchPayloads {
+				batchPayloads[j] = normalizedData
+			}
+			if numbered {
+				for j := range batchPayloads {
+					batchPayloads[j] = make(map[string]interface{}, len(normalizedData)+1)
+					for key, value := range normalizedData {
+						batchPayloads[j][key] = value
+					}
+					refreshPerRecordDefaults(defaulted, batchPayloads[j])
+				}
+				if err := assignAutonumberValues(tx, fields, batchPayloads); err != nil {
+					return err
+				}
+				payloads = append(payloads, batchPayloads...)
+			}
 			batch := make([]models.Record, 0, end-i)
-			for j := i; j < end; j++ {
+			for j := range batchPayloads {
+				data := models.JSONField(dataJSON)
+				if numbered {
+					var err error
+					if data, err = marshalRecordPayload(batchPayloads[j]); err != nil {
+						return err
+					}
+				}
 				batch = append(batch, models.Record{
 					TableID: req.TableID,
-					Data:    models.JSONField(dataJSON),
+					Data:    data,
 					Version: 1,
 				})
 			}
@@ -1580,32 +1709,47 @@ func (s *RecordService) BatchCreateRecords(req dto.RecordCreateRequest, userID s
 				return fmt.Errorf("batch creation failed: %w", err)
 			}
 			indexRows := make([]models.RecordFieldIndex, 0, len(batch)*len(fields))
+			var searchDocuments []models.RecordSearchDocument
 			for j := range batch {
-				rows, err := buildRecordFieldIndexRows(req.TableID, batch[j].ID, fields, normalizedData)
+				rows, err := buildRecordFieldIndexRows(req.TableID, batch[j].ID, fields, batchPayloads[j])
 				if err != nil {
 					return err
 				}
 				indexRows = append(indexRows, rows...)
+				searchDocuments = append(searchDocuments, buildRecordSearchDocuments(req.TableID, batch[j].ID, fields, batchPayloads[j])...)
 				records = append(records, &batch[j])
+				if err := claimRecordUniqueKeys(tx, req.TableID, batch[j].ID, constraints, batchPayloads[j]); err != nil {
+					return err
+				}
 			}
 			if len(indexRows) > 0 {
 				if err := tx.Create(&indexRows).Error; err != nil {
 					return fmt.Errorf("failed to write record field indexes: %w", err)
 				}
 			}
+			if len(searchDocuments) > 0 {
+				if err := tx.Create(&searchDocuments).Error; err != nil {
+					return fmt.Errorf("failed to write record search documents: %w", err)
+				}
+			}
 		}
 		return nil
 	}); err != nil {
 		return nil, err
 	}
 
-	filteredData := s.filterReadableData(fields, readableFields, normalizedData)
+	filteredData := s.filterReadableData(fields, readableFields, masks, normalizedData)
 	filteredJSON, err := marshalRecordPayload(filteredData)
 	if err != nil {
 		return nil, err
 	}
-	for _, record := range records {
+	for i, record := range records {
 		record.Data = filteredJSON
+		if numbered {
+			if record.Data, err = marshalRecordPayload(s.filterReadableData(fields, readableFields, masks, payloads[i])); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	return records, nil
@@ -1629,6 +1773,13 @@ func (s *RecordService) GenerateTestData(tableID, userID string, count int) ([]*
 	for i := 0; i < count; i++ {
 		data := make(map[string]interface{}, len(fields))
 		for _, field := range fields {
+			if isFormulaFieldType(field.Type) || isAutonumberFieldType(field.Type) {
+				continue
+			}
+			// Fields with a default are left to CreateRecord, which fills them like any other insert.
+			if hasFieldDefault(parseStoredFieldConfig(field.Options)) {
+				continue
+			}
 			data[field.Name] = generateFieldValue(rng, field.Type)
 		}
 		record, err := s.CreateRecord(dto.RecordCreateRequest{
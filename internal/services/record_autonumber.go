@@ -0,0 +1,139 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/dto"
+	"gorm.io/gorm"
+)
+
+// maxAutonumberPadding bounds the zero padding of autonumber values.
+const maxAutonumberPadding = 20
+
+func hasAutonumberFields(fields []models.Field) bool {
+	for _, field := range fields {
+		if isAutonumberFieldType(field.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatAutonumber renders a sequence number with the field's prefix and zero padding, e.g. INV-000123.
+func formatAutonumber(config dto.FieldConfig, number int64) string {
+	return fmt.Sprintf("%s%0*d", config.Prefix, config.Padding, number)
+}
+
+// reserveAutonumbers advances the counter of an autonumber field by count and returns the first reserved number.
+// The counter lives on the field row, so the UPDATE locks it until tx ends: concurrent inserts on
+// Postgres and MySQL wait for each other and SQLite serializes writers, so numbers never collide.
+// A rolled-back insert also rolls back its reservation.
+func reserveAutonumbers(tx *gorm.DB, field models.Field, count int) (int64, error) {
+	start := int64(1)
+	if config := parseStoredFieldConfig(field.Options); config.Start != nil {
+		start = *config.Start
+	}
+
+	// The next number is the larger of last+1 and start, so raising start skips ahead.
+	floor := start - 1
+	result := tx.Model(&models.Field{}).Where("id = ?", field.ID).
+		UpdateColumn("last_number", gorm.Expr("CASE WHEN last_number < ? THEN ? ELSE last_number END + ?", floor, floor, count))
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to reserve autonumber for field '%s': %w", field.Name, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return 0, fmt.Errorf("autonumber field '%s' not found", field.Name)
+	}
+
+	var last int64
+	if err := tx.Model(&models.Field{}).Where("id = ?", field.ID).Select("last_number").Scan(&last).Error; err != nil {
+		return 0, fmt.Errorf("failed to read autonumber for field '%s': %w", field.Name, err)
+	}
+	return last - int64(count) + 1, nil
+}
+
+// assignAutonumberValues numbers each payload in order for every autonumber field,
+// then recomputes formulas that may reference the numbers.
+func assignAutonumberValues(tx *gorm.DB, fields []models.Field, payloads []map[string]interface{}) error {
+	if len(payloads) == 0 {
+		return nil
+	}
+	for _, field := range fields {
+		if !isAutonumberFieldType(field.Type) {
+			continue
+		}
+		first, err := reserveAutonumbers(tx, field, len(payloads))
+		if err != nil {
+			return err
+		}
+		config := parseStoredFieldConfig(field.Options)
+		for i, payload := range payloads {
+			payload[field.Name] = formatAutonumber(config, first+int64(i))
+		}
+	}
+	if hasFormulaFields(fields) {
+		now := time.Now()
+		for _, payload := range payloads {
+			applyFormulaValues(fields, payload, now)
+		}
+	}
+	return nil
+}
+
+// backfillAutonumberValues numbers the records of the field's table that have no value yet,
+// in creation order. Numbered records get a new version, recomputed formulas, and the field
+// indexes and unique keys of their new values.
+func backfillAutonumberValues(tx *gorm.DB, field models.Field) error {
+	const batchSize = 200
+	var fields []models.Field
+	if err := tx.Where("table_id = ? AND deleted_at IS NULL", field.TableID).Order("created_at ASC").Find(&fields).Error; err != nil {
+		return fmt.Errorf("failed to get field definitions: %w", err)
+	}
+
+	recordService := NewRecordService(tx)
+	config := parseStoredFieldConfig(field.Options)
+	now := time.Now()
+	for offset := 0; ; offset += batchSize {
+		var records []models.Record
+		if err := tx.Where("table_id = ? AND deleted_at IS NULL", field.TableID).
+			Order("created_at ASC, id ASC").Limit(batchSize).Offset(offset).
+			Find(&records).Error; err != nil {
+			return fmt.Errorf("failed to load records: %w", err)
+		}
+		for _, record := range records {
+			payload := parseRecordPayload(record.Data)
+			if value, exists := payload[field.Name]; exists && value != nil && value != "" {
+				continue
+			}
+			number, err := reserveAutonumbers(tx, field, 1)
+			if err != nil {
+				return err
+			}
+			payload[field.Name] = formatAutonumber(config, number)
+			applyFormulaValues(fields, payload, now)
+			dataJSON, err := marshalRecordPayload(payload)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Record{}).Where("id = ?", record.ID).
+				Updates(map[string]interface{}{
+					"data":       dataJSON,
+					"updated_at": now,
+					"version":    gorm.Expr("version + 1"),
+				}).Error; err != nil {
+				return fmt.Errorf("failed to store autonumber values: %w", err)
+			}
+			if err := recordService.syncRecordFieldIndexes(tx, record.ID, record.TableID, fields, payload); err != nil {
+				return err
+			}
+			if err := syncRecordUniqueKeys(tx, record.TableID, record.ID, fields, payload); err != nil {
+				return err
+			}
+		}
+		if len(records) < batchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/dto"
+)
+
+func createAutonumberField(t *testing.T, svc *FieldService, table *models.Table, master *models.Token, config dto.FieldConfig) *models.Field {
+	t.Helper()
+	field, err := svc.CreateField(dto.FieldCreateRequest{
+		TableID: table.ID, Name: "number", Type: "autonumber", Config: config,
+	}, master.ID)
+	require.NoError(t, err)
+	return field
+}
+
+func int64Ptr(v int64) *int64 { return &v }
+
+func TestCreateField_AutonumberValidation(t *testing.T) {
+	_, table, master, svc, _ := setupFormulaTestEnv(t)
+
+	tests := []struct {
+		name string
+		req  dto.FieldCreateRequest
+		want string
+	}{
+		{"prefix on plain field", dto.FieldCreateRequest{Name: "f", Type: "string", Config: dto.FieldConfig{Prefix: "INV-"}}, "only supported for autonumber fields"},
+		{"required", dto.FieldCreateRequest{Name: "f", Type: "autonumber", Required: true}, "cannot be required"},
+		{"padding too large", dto.FieldCreateRequest{Name: "f", Type: "autonumber", Config: dto.FieldConfig{Padding: 30}}, "padding must be between 0 and 20"},
+		{"negative start", dto.FieldCreateRequest{Name: "f", Type: "autonumber", Config: dto.FieldConfig{Start: int64Ptr(-1)}}, "start must not be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.TableID = table.ID
+			_, err := svc.CreateField(tt.req, master.ID)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestCreateRecord_AutonumberAssigned(t *testing.T) {
+	_, table, master, fieldSvc, svc := setupFormulaTestEnv(t)
+	createAutonumberField(t, fieldSvc, table, master, dto.FieldConfig{Prefix: "INV-", Padding: 6, Start: int64Ptr(120)})
+	createFormulaField(t, fieldSvc, table, master, "label", "number & ' ' & name")
+
+	var numbers []interface{}
+	for _, name := range []string{"a", "b"} {
+		record, err := svc.CreateRecord(dto.RecordCreateRequest{TableID: table.ID, Data: map[string]interface{}{"name": name}}, master.ID)
+		require.NoError(t, err)
+		data := parseRecordPayload(record.Data)
+		numbers = append(numbers, data["number"])
+		assert.Equal(t, data["number"].(string)+" "+name, data["label"])
+	}
+	assert.Equal(t, []interface{}{"INV-000120", "INV-000121"}, numbers)
+
+	_, err := svc.CreateRecord(dto.RecordCreateRequest{TableID: table.ID, Data: map[string]interface{}{"number": "INV-1"}}, master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "field 'number' is an autonumber field and cannot be written")
+
+	// Updates keep the assigned number.
+	record, err := svc.CreateRecord(dto.RecordCreateRequest{TableID: table.ID, Data: map[string]interface{}{"name": "c"}}, master.ID)
+	require.NoError(t, err)
+	updated, err := svc.UpdateRecord(record.ID, dto.RecordUpdateRequest{Data: map[string]interface{}{"name": "d"}}, master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "INV-000122", parseRecordPayload(updated.Data)["number"])
+}
+
+func TestBatchCreateRecords_AutonumberSequential(t *testing.T) {
+	db, table, master, fieldSvc, svc := setupFormulaTestEnv(t)
+	field := createAutonumberField(t, fieldSvc, table, master, dto.FieldConfig{})
+
+	records, err := svc.BatchCreateRecords(dto.RecordCreateRequest{TableID: table.ID, Data: map[string]interface{}{"name": "x"}}, master.ID, 3)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	for i, record := range records {
+		assert.Equal(t, []string{"1", "2", "3"}[i], parseRecordPayload(record.Data)["number"])
+
+		var stored models.Record
+		require.NoError(t, db.First(&stored, "id = ?", record.ID).Error)
+		assert.Equal(t, parseRecordPayload(record.Data)["number"], parseRecordPayload(stored.Data)["number"])
+	}
+
+	var index models.RecordFieldIndex
+	require.NoError(t, db.Where("record_id = ? AND field_id = ?", records[2].ID, field.ID).First(&index).Error)
+	assert.Equal(t, "3", index.ValueText)
+}
+
+func TestCreateRecord_AutonumberConcurrent(t *testing.T) {
+	_, table, master, fieldSvc, svc := setupFormulaTestEnv(t)
+	createAutonumberField(t, fieldSvc, table, master, dto.FieldConfig{Prefix: "N"})
+
+	const workers = 10
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := make(map[interface{}]bool, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			record, err := svc.CreateRecord(dto.RecordCreateRequest{TableID: table.ID, Data: map[string]interface{}{"name": "x"}}, master.ID)
+			if !assert.NoError(t, err) {
+				return
+			}
+			mu.Lock()
+			seen[parseRecordPayload(record.Data)["number"]] = true
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, seen, workers)
+	for i := 1; i <= workers; i++ {
+		assert.True(t, seen[formatAutonumber(dto.FieldConfig{Prefix: "N"}, int64(i))], "missing N%d", i)
+	}
+}
+
+func TestAutonumberField_BackfillAndStart(t *testing.T) {
+	db, table, master, fieldSvc, svc := setupFormulaTestEnv(t)
+	first, err := svc.CreateRecord(dto.RecordCreateRequest{TableID: table.ID, Data: map[string]interface{}{"name": "first"}}, master.ID)
+	require.NoError(t, err)
+	second, err := svc.CreateRecord(dto.RecordCreateRequest{TableID: table.ID, Data: map[string]interface{}{"name": "second"}}, master.ID)
+	require.NoError(t, err)
+	// Pin creation order, which timestamps of fast inserts may not resolve.
+	require.NoError(t, db.Model(&models.Record{}).Where("id = ?", first.ID).Update("created_at", first.CreatedAt.Add(-1e9)).Error)
+
+	field := createAutonumberField(t, fieldSvc, table, master, dto.FieldConfig{Prefix: "#"})
+	for id, want := range map[string]string{first.ID: "#1", second.ID: "#2"} {
+		var stored models.Record
+		require.NoError(t, db.First(&stored, "id = ?", id).Error)
+		assert.Equal(t, want, parseRecordPayload(stored.Data)["number"])
+		// Backfilled numbers are a change of the record, indexed like written ones.
+		assert.Equal(t, 2, stored.Version)
+
+		var indexed []string
+		require.NoError(t, db.Model(&models.RecordFieldIndex{}).
+			Where("record_id = ? AND field_id = ? AND deleted_at IS NULL", id, field.ID).
+			Pluck("value_text", &indexed).Error)
+		assert.Equal(t, []string{want}, indexed)
+	}
+
+	// Raising start skips ahead; saving the field does not reset the counter.
+	_, err = fieldSvc.UpdateField(field.ID, dto.FieldUpdateRequest{
+		Name: "number", Type: "autonumber", Config: dto.FieldConfig{Prefix: "#", Start: int64Ptr(100)},
+	}, master.ID)
+	require.NoError(t, err)
+	SharedFieldCache.Clear()
+	record, err := svc.CreateRecord(dto.RecordCreateRequest{TableID: table.ID, Data: map[string]interface{}{"name": "third"}}, master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "#100", parseRecordPayload(record.Data)["number"])
+
+	_, err = fieldSvc.UpdateField(field.ID, dto.FieldUpdateRequest{
+		Name: "number", Type: "autonumber", Config: dto.FieldConfig{Prefix: "#", Start: int64Ptr(1)},
+	}, master.ID)
+	require.NoError(t, err)
+	SharedFieldCache.Clear()
+	record, err = svc.CreateRecord(dto.RecordCreateRequest{TableID: table.ID, Data: map[string]interface{}{"name": "fourth"}}, master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "#101", parseRecordPayload(record.Data)["number"])
+}
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/dto"
+	"gorm.io/gorm"
+)
+
+// Modes of a bulk insert.
+const (
+	bulkInsertAtomic     = "atomic"      // insert every record or none
+	bulkInsertBestEffort = "best_effort" // insert the valid records and report the others
+)
+
+// Statuses of a record in a bulk insert result.
+const (
+	bulkStatusCreated    = "created"
+	bulkStatusFailed     = "failed"
+	bulkStatusRolledBack = "rolled_back" // valid, but not inserted because another record failed
+)
+
+const (
+	maxBulkInsertRecords = 5000
+	bulkInsertChunkSize  = 200
+)
+
+// bulkRecord is a validated record waiting to be inserted.
+type bulkRecord struct {
+	index   int
+	payload map[string]interface{}
+	id      string
+}
+
+// bulkItemError ties an insert failure to the record that caused it.
+type bulkItemError struct {
+	index int
+	err   error
+}
+
+func (e *bulkItemError) Error() string {
+	return e.err.Error()
+}
+
+func normalizeBulkInsertMode(mode string) (string, error) {
+	switch normalized := strings.ToLower(strings.TrimSpace(mode)); normalized {
+	case "":
+		return bulkInsertAtomic, nil
+	case bulkInsertAtomic, bulkInsertBestEffort:
+		return normalized, nil
+	}
+	return "", fmt.Errorf("invalid mode '%s', must be one of: atomic, best_effort", mode)
+}
+
+// BulkInsertRecords creates a record for each payload of req and reports the outcome per record.
+// Records are validated first, then inserted in chunks. In atomic mode all records are inserted in
+// one transaction, and any failure inserts none of them. In best_effort mode each chunk commits on
+// its own and a failing record is skipped without affecting the others.
+func (s *RecordService) BulkInsertRecords(req dto.RecordBulkInsertRequest, userID string) (*dto.RecordBulkInsertData, error) {
+	mode, err := normalizeBulkInsertMode(req.Mode)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Records) == 0 {
+		return nil, errors.New("records must contain at least one record")
+	}
+	if len(req.Records) > maxBulkInsertRecords {
+		return nil, fmt.Errorf("too many records: %d, at most %d per request", len(req.Records), maxBulkInsertRecords)
+	}
+
+	if err := s.checkTableAccess(req.TableID, userID, []string{"owner", "admin", "editor"}); err != nil {
+		return nil, err
+	}
+	fields, err := s.getTableFields(req.TableID)
+	if err != nil {
+		return nil, err
+	}
+	_, writableFields, _, err := s.getFieldAccessMaps(fields, userID)
+	if err != nil {
+		return nil, err
+	}
+	var defaults FieldDefaultContext
+	if hasFieldDefaults(fields) {
+		if defaults, err = NewFieldDefaultContext(s.db, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &dto.RecordBulkInsertData{
+		TableID: req.TableID,
+		Mode:    mode,
+		Total:   len(req.Records),
+		Results: make([]dto.RecordBulkInsertResult, len(req.Records)),
+	}
+	pending := make([]*bulkRecord, 0, len(req.Records))
+	now := time.Now()
+	for i, data := range req.Records {
+		result.Results[i].Index = i
+		payload, err := s.prepareBulkRecord(req.TableID, fields, writableFields, data, defaults, now, userID)
+		if err != nil {
+			result.Results[i].Status = bulkStatusFailed
+			result.Results[i].Error = err.Error()
+			continue
+		}
+		pending = append(pending, &bulkRecord{index: i, payload: payload})
+	}
+
+	if mode == bulkInsertAtomic {
+		err = s.insertBulkAtomic(req.TableID, fields, pending, result)
+	} else {
+		err = s.insertBulkBestEffort(req.TableID, fields, pending, result)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range result.Results {
+		switch item.Status {
+		case bulkStatusCreated:
+			result.Inserted++
+		case bulkStatusFailed:
+			result.Failed++
+		}
+	}
+	return result, nil
+}
+
+// prepareBulkRecord normalizes and validates one payload the way CreateRecord does.
+func (s *RecordService) prepareBulkRecord(tableID string, fields []models.Field, writableFields map[string]models.Field, data map[string]interface{}, defaults FieldDefaultContext, now time.Time, userID string) (map[string]interface{}, error) {
+	payload, err := s.normalizeRecordData(fields, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureWritableFields(payload, writableFields); err != nil {
+		return nil, err
+	}
+	for _, field := range fields {
+		if !isAttachmentFieldType(field.Type) {
+			continue
+		}
+		fileIDs, err := attachmentFieldIDsFromData(field, payload)
+		if err != nil {
+			return nil, err
+		}
+		if len(fileIDs) > 0 {
+			return nil, errors.New("bulk insert does not support file fields")
+		}
+	}
+	ApplyFieldDefaults(fields, payload, defaults)
+	applyFormulaValues(fields, payload, now)
+	if err := s.validateRecordData(tableID, payload, "", userID); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// insertBulkAtomic inserts every pending record in one transaction, or none of them when any
+// record failed validation or insertion.
+func (s *RecordService) insertBulkAtomic(tableID string, fields []models.Field, pending []*bulkRecord, result *dto.RecordBulkInsertData) error {
+	if len(pending) < len(result.Results) {
+		markBulkRolledBack(pending, result)
+		return nil
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		constraints, err := loadUniqueConstraints(tx, tableID, fields)
+		if err != nil {
+			return err
+		}
+		for start := 0; start < len(pending); start += bulkInsertChunkSize {
+			end := start + bulkInsertChunkSize
+			if end > len(pending) {
+				end = len(pending)
+			}
+			if err := insertBulkRecords(tx, tableID, fields, constraints, pending[start:end]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	var itemErr *bulkItemError
+	if errors.As(err, &itemErr) {
+		result.Results[itemErr.index].Status = bulkStatusFailed
+		result.Results[itemErr.index].Error = itemErr.Error()
+		markBulkRolledBack(pending, result)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	markBulkCreated(pending, result)
+	return nil
+}
+
+// insertBulkBestEffort inserts the pending records in chunks that commit on their own. Each record
+// is inserted under a savepoint, so a failing record leaves the rest of its chunk intact.
+func (s *RecordService) insertBulkBestEffort(tableID string, fields []models.Field, pending []*bulkRecord, result *dto.RecordBulkInsertData) error {
+	for start := 0; start < len(pending); start += bulkInsertChunkSize {
+		end := start + bulkInsertChunkSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+		chunk := pending[start:end]
+		var inserted []*bulkRecord
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			constraints, err := loadUniqueConstraints(tx, tableID, fields)
+			if err != nil {
+				return err
+			}
+			for _, record := range chunk {
+				err := tx.Transaction(func(tx *gorm.DB) error {
+					return insertBulkRecords(tx, tableID, fields, constraints, []*bulkRecord{record})
+				})
+				if err != nil {
+					result.Results[record.index].Status = bulkStatusFailed
+					result.Results[record.index].Error = err.Error()
+					continue
+				}
+				inserted = append(inserted, record)
+			}
+			return nil
+		})
+		if err != nil {
+			// Nothing of the chunk was committed.
+			for _, record := range chunk {
+				result.Results[record.index].Status = bulkStatusFailed
+				result.Results[record.index].Error = err.Error()
+			}
+			continue
+		}
+		markBulkCreated(inserted, result)
+	}
+	return nil
+}
+
+// insertBulkRecords inserts validated records inside tx with their field indexes and unique values.
+func insertBulkRecords(tx *gorm.DB, tableID string, fields []models.Field, constraints []uniqueConstraint, records []*bulkRecord) error {
+	payloads := make([]map[string]interface{}, len(records))
+	for i, record := range records {
+		payloads[i] = record.payload
+	}
+	if hasAutonumberFields(fields) {
+		if err := assignAutonumberValues(tx, fields, payloads); err != nil {
+			return err
+		}
+	}
+
+	batch := make([]models.Record, len(records))
+	for i, payload := range payloads {
+		data, err := marshalRecordPayload(payload)
+		if err != nil {
+			return &bulkItemError{index: records[i].index, err: err}
+		}
+		batch[i] = models.Record{TableID: tableID, Data: data, Version: 1}
+	}
+	if err := tx.Create(&batch).Error; err != nil {
+		return fmt.Errorf("failed to create records: %w", err)
+	}
+
+	indexRows := make([]models.RecordFieldIndex, 0, len(batch)*len(fields))
+	var searchDocuments []models.RecordSearchDocument
+	for i := range batch {
+		rows, err := buildRecordFieldIndexRows(tableID, batch[i].ID, fields, payloads[i])
+		if err != nil {
+			return &bulkItemError{index: records[i].index, err: err}
+		}
+		indexRows = append(indexRows, rows...)
+		searchDocuments = append(searchDocuments, buildRecordSearchDocuments(tableID, batch[i].ID, fields, payloads[i])...)
+		if err := claimRecordUniqueKeys(tx, tableID, batch[i].ID, constraints, payloads[i]); err != nil {
+			return &bulkItemError{index: records[i].index, err: err}
+		}
+		records[i].id = batch[i].ID
+	}
+	if len(indexRows) > 0 {
+		if err := tx.CreateInBatches(&indexRows, bulkInsertChunkSize).Error; err != nil {
+			return fmt.Errorf("failed to write record field indexes: %w", err)
+		}
+	}
+	if len(searchDocuments) > 0 {
+		if err := tx.CreateInBatches(&searchDocuments, bulkInsertChunkSize).Error; err != nil {
+			return fmt.Errorf("failed to write record search documents: %w", err)
+		}
+	}
+	return nil
+}
+
+func markBulkCreated(records []*bulkRecord, result *dto.RecordBulkInsertData) {
+	for _, record := range records {
+		result.Results[record.index].Status = bulkStatusCreated
+		result.Results[record.index].ID = record.id
+	}
+}
+
+func markBulkRolledBack(records []*bulkRecord, result *dto.RecordBulkInsertData) {
+	for _, record := range records {
+		if result.Results[record.index].Status == "" {
+			result.Results[record.index].Status = bulkStatusRolledBack
+		}
+	}
+}
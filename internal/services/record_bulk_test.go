@@ -0,0 +1,108 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/dto"
+)
+
+func countTableRecords(t *testing.T, svc *RecordService, table *models.Table) int64 {
+	t.Helper()
+	var count int64
+	require.NoError(t, svc.db.Model(&models.Record{}).Where("table_id = ? AND deleted_at IS NULL", table.ID).Count(&count).Error)
+	return count
+}
+
+func TestBulkInsertRecords_Atomic(t *testing.T) {
+	_, table, master, fieldSvc, svc := setupFormulaTestEnv(t)
+	createAutonumberField(t, fieldSvc, table, master, dto.FieldConfig{Prefix: "N-"})
+	createFormulaField(t, fieldSvc, table, master, "total", "price * quantity")
+
+	records := make([]map[string]interface{}, 450)
+	for i := range records {
+		records[i] = map[string]interface{}{"name": fmt.Sprintf("item %d", i), "price": i, "quantity": 2}
+	}
+	result, err := svc.BulkInsertRecords(dto.RecordBulkInsertRequest{TableID: table.ID, Records: records}, master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "atomic", result.Mode)
+	assert.Equal(t, 450, result.Inserted)
+	assert.Zero(t, result.Failed)
+
+	last := result.Results[449]
+	assert.Equal(t, "created", last.Status)
+	var record models.Record
+	require.NoError(t, svc.db.First(&record, "id = ?", last.ID).Error)
+	data := parseRecordPayload(record.Data)
+	assert.Equal(t, "item 449", data["name"])
+	assert.EqualValues(t, 898, data["total"])
+	assert.Equal(t, "N-450", data["number"])
+
+	// One invalid record inserts nothing and reports the others as rolled back.
+	result, err = svc.BulkInsertRecords(dto.RecordBulkInsertRequest{
+		TableID: table.ID,
+		Records: []map[string]interface{}{{"name": "ok"}, {"price": "expensive"}},
+	}, master.ID)
+	require.NoError(t, err)
+	assert.Zero(t, result.Inserted)
+	assert.Equal(t, 1, result.Failed)
+	assert.Equal(t, "rolled_back", result.Results[0].Status)
+	assert.Equal(t, "failed", result.Results[1].Status)
+	assert.Contains(t, result.Results[1].Error, "field 'price' validation failed")
+	assert.EqualValues(t, 450, countTableRecords(t, svc, table))
+}
+
+func TestBulkInsertRecords_UniqueConflicts(t *testing.T) {
+	_, table, master, fieldSvc, svc := setupFormulaTestEnv(t)
+	_, err := fieldSvc.CreateField(dto.FieldCreateRequest{
+		TableID: table.ID, Name: "code", Type: "string", Config: dto.FieldConfig{Unique: true},
+	}, master.ID)
+	require.NoError(t, err)
+	records := []map[string]interface{}{{"code": "a"}, {"code": "b"}, {"code": "a"}, {"code": "c"}}
+
+	result, err := svc.BulkInsertRecords(dto.RecordBulkInsertRequest{TableID: table.ID, Records: records}, master.ID)
+	require.NoError(t, err)
+	assert.Zero(t, result.Inserted)
+	assert.Equal(t, "failed", result.Results[2].Status)
+	assert.Contains(t, result.Results[2].Error, "unique constraint violated")
+	assert.Equal(t, "rolled_back", result.Results[0].Status)
+	assert.Zero(t, countTableRecords(t, svc, table))
+
+	result, err = svc.BulkInsertRecords(dto.RecordBulkInsertRequest{TableID: table.ID, Records: records, Mode: "Best_Effort"}, master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "best_effort", result.Mode)
+	assert.Equal(t, 3, result.Inserted)
+	assert.Equal(t, 1, result.Failed)
+	assert.Equal(t, "failed", result.Results[2].Status)
+	for _, i := range []int{0, 1, 3} {
+		assert.Equal(t, "created", result.Results[i].Status)
+		assert.NotEmpty(t, result.Results[i].ID)
+	}
+	assert.EqualValues(t, 3, countTableRecords(t, svc, table))
+
+	var claims int64
+	require.NoError(t, svc.db.Model(&models.RecordUniqueKey{}).Where("table_id = ?", table.ID).Count(&claims).Error)
+	assert.EqualValues(t, 3, claims)
+}
+
+func TestBulkInsertRecords_RequestValidation(t *testing.T) {
+	_, table, master, _, svc := setupFormulaTestEnv(t)
+
+	_, err := svc.BulkInsertRecords(dto.RecordBulkInsertRequest{TableID: table.ID, Records: []map[string]interface{}{{}}, Mode: "partial"}, master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid mode 'partial'")
+
+	_, err = svc.BulkInsertRecords(dto.RecordBulkInsertRequest{TableID: table.ID}, master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "at least one record")
+
+	_, err = svc.BulkInsertRecords(dto.RecordBulkInsertRequest{
+		TableID: table.ID, Records: make([]map[string]interface{}, maxBulkInsertRecords+1),
+	}, master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "too many records")
+}
@@ -0,0 +1,283 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/dto"
+	json "github.com/jiangfire/cornerstone/pkg/jsonx"
+	"github.com/jiangfire/cornerstone/pkg/query"
+	"gorm.io/gorm"
+)
+
+const bulkWriteBatchSize = 200
+
+// bulkRecordColumns are the record columns a bulk filter may reference besides the table's fields.
+var bulkRecordColumns = map[string]bool{"id": true, "version": true, "created_at": true, "updated_at": true}
+
+// BulkUpdateRecords applies the data patch of req to every live record of the table matching its
+// filter, in batches inside one transaction. Each record is validated as a whole after the patch,
+// its version bumped and its field indexes rebuilt. With DryRun only the matches are counted.
+func (s *RecordService) BulkUpdateRecords(req dto.RecordBulkUpdateRequest, userID string) (*dto.RecordBulkWriteData, error) {
+	if err := s.checkTableAccess(req.TableID, userID, []string{"owner", "admin", "editor"}); err != nil {
+		return nil, err
+	}
+	if len(req.Data) == 0 {
+		return nil, errors.New("data must set at least one field")
+	}
+
+	fields, err := s.getTableFields(req.TableID)
+	if err != nil {
+		return nil, err
+	}
+	readableFields, writableFields, _, err := s.getFieldAccessMaps(fields, userID)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := s.normalizeRecordData(fields, req.Data)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureWritableFields(patch, writableFields); err != nil {
+		return nil, err
+	}
+	for _, field := range fields {
+		if _, exists := patch[field.Name]; exists && isAttachmentFieldType(field.Type) {
+			return nil, errors.New("bulk update does not support file fields")
+		}
+	}
+
+	ids, err := s.findBulkRecordIDs(req.TableID, userID, fields, readableFields, req.Where, req.Filter)
+	if err != nil {
+		return nil, err
+	}
+	result := &dto.RecordBulkWriteData{TableID: req.TableID, DryRun: req.DryRun, Matched: len(ids)}
+	if req.DryRun || len(ids) == 0 {
+		return result, nil
+	}
+
+	now := time.Now()
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		txService := NewRecordService(tx)
+		constraints, err := loadUniqueConstraints(tx, req.TableID, fields)
+		if err != nil {
+			return err
+		}
+		return forEachBulkRecord(tx, ids, func(record models.Record) error {
+			data, _ := s.extractKnownRecordData(fields, parseRecordPayload(record.Data))
+			for key, value := range patch {
+				data[key] = value
+			}
+			applyFormulaValues(fields, data, now)
+			if err := txService.validateRecordData(record.TableID, data, record.ID, userID); err != nil {
+				return fmt.Errorf("record %s: %w", record.ID, err)
+			}
+			dataJSON, err := marshalRecordPayload(data)
+			if err != nil {
+				return err
+			}
+
+			updated := tx.Model(&models.Record{}).
+				Where("id = ? AND version = ? AND deleted_at IS NULL", record.ID, record.Version).
+				Updates(map[string]interface{}{
+					"data":       dataJSON,
+					"version":    gorm.Expr("version + 1"),
+					"updated_at": now,
+				})
+			if updated.Error != nil {
+				return fmt.Errorf("failed to update record: %w", updated.Error)
+			}
+			if updated.RowsAffected == 0 {
+				return fmt.Errorf("record %s was modified by another user, please retry", record.ID)
+			}
+			if err := txService.syncRecordFieldIndexes(tx, record.ID, record.TableID, fields, data); err != nil {
+				return err
+			}
+			if len(constraints) > 0 {
+				if err := releaseRecordUniqueKeys(tx, record.ID); err != nil {
+					return err
+				}
+				if err := claimRecordUniqueKeys(tx, record.TableID, record.ID, constraints, data); err != nil {
+					return fmt.Errorf("record %s: %w", record.ID, err)
+				}
+			}
+			result.Affected++
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// BulkDeleteRecords soft-deletes every live record of the table matching the filter of req,
+// in batches inside one transaction, applying the on_delete behavior of link fields like
+// DeleteRecord. With DryRun only the matches are counted.
+func (s *RecordService) BulkDeleteRecords(req dto.RecordBulkDeleteRequest, userID string) (*dto.RecordBulkWriteData, error) {
+	if err := s.checkTableAccess(req.TableID, userID, []string{"owner", "admin"}); err != nil {
+		return nil, err
+	}
+	fields, err := s.getTableFields(req.TableID)
+	if err != nil {
+		return nil, err
+	}
+	readableFields, _, _, err := s.getFieldAccessMaps(fields, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := s.findBulkRecordIDs(req.TableID, userID, fields, readableFields, req.Where, req.Filter)
+	if err != nil {
+		return nil, err
+	}
+	result := &dto.RecordBulkWriteData{TableID: req.TableID, DryRun: req.DryRun, Matched: len(ids)}
+	if req.DryRun || len(ids) == 0 {
+		return result, nil
+	}
+
+	now := time.Now()
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		txService := NewRecordService(tx)
+		visited := make(map[string]struct{})
+		return forEachBulkRecord(tx, ids, func(record models.Record) error {
+			// A cascade from an earlier record may already have deleted this one.
+			if _, done := visited[record.ID]; done {
+				return nil
+			}
+			if err := txService.deleteRecordTx(tx, record, userID, now, visited); err != nil {
+				return err
+			}
+			result.Affected++
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// forEachBulkRecord loads the live records with the given IDs in batches and calls fn for each.
+func forEachBulkRecord(tx *gorm.DB, ids []string, fn func(record models.Record) error) error {
+	for start := 0; start < len(ids); start += bulkWriteBatchSize {
+		end := start + bulkWriteBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		var records []models.Record
+		if err := tx.Where("id IN ? AND deleted_at IS NULL", ids[start:end]).Order("id").Find(&records).Error; err != nil {
+			return fmt.Errorf("failed to load records: %w", err)
+		}
+		for _, record := range records {
+			if err := fn(record); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// findBulkRecordIDs returns the IDs of the live records of a table matching a bulk filter, given
+// as a query where clause, a structured record filter, or both. Filters may only reference
+// readable fields; at least one condition is required so a bulk write never hits a whole table
+// by accident. Records outside the row filter of the user never match.
+func (s *RecordService) findBulkRecordIDs(tableID, userID string, fields []models.Field, readableFields map[string]models.Field, rawWhere, filter map[string]interface{}) ([]string, error) {
+	where, err := parseBulkWhere(rawWhere)
+	if err != nil {
+		return nil, err
+	}
+	if (where == nil || len(where.And)+len(where.Or) == 0) && len(filter) == 0 {
+		return nil, errors.New("a where or filter condition is required")
+	}
+
+	rowClauses, noRows, err := s.rowFilterClauses(tableID, userID, fields)
+	if err != nil {
+		return nil, err
+	}
+	if noRows {
+		return []string{}, nil
+	}
+	q := s.db.Model(&models.Record{}).Where("table_id = ? AND deleted_at IS NULL", tableID)
+	for _, clause := range rowClauses {
+		q = q.Where(clause.sql, clause.args...)
+	}
+	if len(filter) > 0 {
+		clauses, refsHidden, err := s.buildStructuredFilterClauses(fields, readableFields, filter)
+		if err != nil {
+			return nil, err
+		}
+		if refsHidden {
+			return nil, errors.New("filter references an unknown field")
+		}
+		for _, clause := range clauses {
+			q = q.Where(clause.sql, clause.args...)
+		}
+	}
+	if where != nil {
+		resolved := &query.WhereClause{}
+		if resolved.And, err = resolveBulkConditions(fields, readableFields, where.And); err != nil {
+			return nil, err
+		}
+		if resolved.Or, err = resolveBulkConditions(fields, readableFields, where.Or); err != nil {
+			return nil, err
+		}
+		sql, params, err := query.NewSQLGeneratorWithDBType(s.db.Name()).GenerateWhere(resolved)
+		if err != nil {
+			return nil, fmt.Errorf("invalid where clause: %w", err)
+		}
+		if sql != "" {
+			q = q.Where(sql, params...)
+		}
+	}
+
+	var ids []string
+	if err := q.Order("id").Pluck("id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to find matching records: %w", err)
+	}
+	return ids, nil
+}
+
+// parseBulkWhere decodes the where clause of a bulk request into a query where clause.
+func parseBulkWhere(raw map[string]interface{}) (*query.WhereClause, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid where clause: %w", err)
+	}
+	var where query.WhereClause
+	if err := json.Unmarshal(encoded, &where); err != nil {
+		return nil, fmt.Errorf("invalid where clause: %w", err)
+	}
+	return &where, nil
+}
+
+// resolveBulkConditions maps the field names of where conditions to record data paths.
+// A condition may name a readable field by name, ID or `data.<name>`, or a record column.
+func resolveBulkConditions(fields []models.Field, readableFields map[string]models.Field, conditions []query.Condition) ([]query.Condition, error) {
+	if len(conditions) == 0 {
+		return nil, nil
+	}
+	resolved := make([]query.Condition, len(conditions))
+	for i, condition := range conditions {
+		var err error
+		if condition.And, err = resolveBulkConditions(fields, readableFields, condition.And); err != nil {
+			return nil, err
+		}
+		if condition.Or, err = resolveBulkConditions(fields, readableFields, condition.Or); err != nil {
+			return nil, err
+		}
+		if len(condition.And) == 0 && len(condition.Or) == 0 && !bulkRecordColumns[condition.Field] {
+			field, ok := resolveReadableFilterField(fields, readableFields, strings.TrimPrefix(condition.Field, "data."))
+			if !ok {
+				return nil, fmt.Errorf("where references an unknown field '%s'", condition.Field)
+			}
+			condition.Field = "data." + field.Name
+		}
+		resolved[i] = condition
+	}
+	return resolved, nil
+}
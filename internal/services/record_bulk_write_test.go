@@ -0,0 +1,120 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/dto"
+)
+
+func TestBulkUpdateRecords(t *testing.T) {
+	_, table, master, fieldSvc, svc := setupFormulaTestEnv(t)
+	createFormulaField(t, fieldSvc, table, master, "total", "price * quantity")
+	cheap := createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"name": "cheap", "price": 1, "quantity": 1})
+	mid := createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"name": "mid", "price": 10, "quantity": 1})
+	high := createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"name": "high", "price": 20, "quantity": 1})
+
+	where := map[string]interface{}{"and": []interface{}{map[string]interface{}{"field": "price", "op": "gt", "value": 5}}}
+	result, err := svc.BulkUpdateRecords(dto.RecordBulkUpdateRequest{
+		TableID: table.ID, Where: where, Data: map[string]interface{}{"quantity": 3}, DryRun: true,
+	}, master.ID)
+	require.NoError(t, err)
+	assert.True(t, result.DryRun)
+	assert.Equal(t, 2, result.Matched)
+	assert.Zero(t, result.Affected)
+	assert.EqualValues(t, 1, storedRecordValue(t, svc, mid.ID, "quantity"))
+
+	result, err = svc.BulkUpdateRecords(dto.RecordBulkUpdateRequest{
+		TableID: table.ID, Where: where, Data: map[string]interface{}{"quantity": 3},
+	}, master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Matched)
+	assert.Equal(t, 2, result.Affected)
+
+	for _, record := range []*models.Record{mid, high} {
+		var stored models.Record
+		require.NoError(t, svc.db.First(&stored, "id = ?", record.ID).Error)
+		assert.Equal(t, record.Version+1, stored.Version)
+		data := parseRecordPayload(stored.Data)
+		assert.EqualValues(t, 3, data["quantity"])
+	}
+	assert.EqualValues(t, 60, storedRecordValue(t, svc, high.ID, "total"))
+	assert.EqualValues(t, 1, storedRecordValue(t, svc, cheap.ID, "quantity"))
+
+	var index models.RecordFieldIndex
+	require.NoError(t, svc.db.Where("record_id = ? AND field_name = ?", mid.ID, "quantity").First(&index).Error)
+	require.NotNil(t, index.ValueNumber)
+	assert.EqualValues(t, 3, *index.ValueNumber)
+
+	// The structured filter selects records the same way as GET /records.
+	result, err = svc.BulkUpdateRecords(dto.RecordBulkUpdateRequest{
+		TableID: table.ID, Filter: map[string]interface{}{"name": "cheap"}, Data: map[string]interface{}{"price": 2},
+	}, master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Affected)
+	assert.EqualValues(t, 2, storedRecordValue(t, svc, cheap.ID, "price"))
+}
+
+func TestBulkUpdateRecords_Validation(t *testing.T) {
+	_, table, master, fieldSvc, svc := setupFormulaTestEnv(t)
+	_, err := fieldSvc.CreateField(dto.FieldCreateRequest{
+		TableID: table.ID, Name: "code", Type: "string", Config: dto.FieldConfig{Unique: true},
+	}, master.ID)
+	require.NoError(t, err)
+	first := createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"name": "a", "code": "a"})
+	createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"name": "b", "code": "b"})
+	everything := map[string]interface{}{"and": []interface{}{map[string]interface{}{"field": "id", "op": "ne", "value": ""}}}
+
+	_, err = svc.BulkUpdateRecords(dto.RecordBulkUpdateRequest{TableID: table.ID, Data: map[string]interface{}{"name": "x"}}, master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "a where or filter condition is required")
+
+	_, err = svc.BulkUpdateRecords(dto.RecordBulkUpdateRequest{
+		TableID: table.ID, Where: map[string]interface{}{"and": []interface{}{map[string]interface{}{"field": "missing", "value": 1}}}, Data: map[string]interface{}{"name": "x"},
+	}, master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "where references an unknown field 'missing'")
+
+	_, err = svc.BulkUpdateRecords(dto.RecordBulkUpdateRequest{
+		TableID: table.ID, Where: everything, Data: map[string]interface{}{"price": "expensive"},
+	}, master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "field 'price' validation failed")
+
+	// Setting a unique field to one value on several records fails as a whole.
+	_, err = svc.BulkUpdateRecords(dto.RecordBulkUpdateRequest{
+		TableID: table.ID, Where: everything, Data: map[string]interface{}{"code": "same", "name": "renamed"},
+	}, master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unique constraint violated")
+	assert.Equal(t, "a", storedRecordValue(t, svc, first.ID, "name"))
+	assert.Equal(t, "a", storedRecordValue(t, svc, first.ID, "code"))
+}
+
+func TestBulkDeleteRecords(t *testing.T) {
+	_, table, master, _, svc := setupFormulaTestEnv(t)
+	for _, price := range []int{1, 10, 20} {
+		createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"price": price})
+	}
+	where := map[string]interface{}{"or": []interface{}{
+		map[string]interface{}{"field": "price", "op": "lt", "value": 5},
+		map[string]interface{}{"field": "data.price", "op": "gte", "value": 20},
+	}}
+
+	result, err := svc.BulkDeleteRecords(dto.RecordBulkDeleteRequest{TableID: table.ID, Where: where, DryRun: true}, master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Matched)
+	assert.EqualValues(t, 3, countTableRecords(t, svc, table))
+
+	result, err = svc.BulkDeleteRecords(dto.RecordBulkDeleteRequest{TableID: table.ID, Where: where}, master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Affected)
+	assert.EqualValues(t, 1, countTableRecords(t, svc, table))
+
+	_, err = svc.BulkDeleteRecords(dto.RecordBulkDeleteRequest{TableID: table.ID}, master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "a where or filter condition is required")
+}
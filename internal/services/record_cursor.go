@@ -0,0 +1,216 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/dto"
+	"github.com/jiangfire/cornerstone/pkg/query"
+)
+
+// recordCursorKeys are the sort keys ending the order of every record list, and the whole
+// default order. Records list newest first, ties by ID ascending: the order of
+// idx_records_table_deleted_created in MySQL, where the primary key extends every secondary
+// index.
+var recordCursorKeys = []string{"created_at desc", "id asc"}
+
+// recordCursor is the position of a record in the record list.
+type recordCursor struct {
+	sort      recordSort    // sort order of the list
+	values    []interface{} // values of the sort keys: the stored JSON of a field, the time of a column
+	createdAt time.Time
+	id        string
+	before    bool // the page ends before the record instead of starting after it
+}
+
+// newRecordCursor returns the position of a record in a list in sort order.
+func newRecordCursor(record models.Record, sort recordSort) *recordCursor {
+	cursor := &recordCursor{sort: sort, createdAt: record.CreatedAt, id: record.ID}
+	var payload map[string]interface{}
+	for _, key := range sort {
+		switch key.column {
+		case "created_at":
+			cursor.values = append(cursor.values, record.CreatedAt)
+		case "updated_at":
+			cursor.values = append(cursor.values, record.UpdatedAt)
+		default:
+			if payload == nil {
+				payload = parseRecordPayload(record.Data)
+			}
+			raw, err := json.Marshal(payload[key.field])
+			if err != nil {
+				raw = []byte("null")
+			}
+			cursor.values = append(cursor.values, string(raw))
+		}
+	}
+	return cursor
+}
+
+// parseRecordCursor decodes the after or before cursor of a list request in sort order, nil
+// without one.
+func parseRecordCursor(req dto.RecordListQueryRequest, sort recordSort) (*recordCursor, error) {
+	if req.After == "" && req.Before == "" {
+		return nil, nil
+	}
+	if req.After != "" && req.Before != "" {
+		return nil, fmt.Errorf("%w: after and before cannot be used together", query.ErrInvalidCursor)
+	}
+	if req.Offset > 0 {
+		return nil, fmt.Errorf("%w: offset cannot be used with a cursor", query.ErrInvalidCursor)
+	}
+	if strings.TrimSpace(req.Q) != "" {
+		return nil, fmt.Errorf("%w: q cannot be used with a cursor", query.ErrInvalidCursor)
+	}
+
+	cursor := &recordCursor{sort: sort, before: req.Before != ""}
+	encoded := req.After
+	if cursor.before {
+		encoded = req.Before
+	}
+	values, err := query.DecodeCursor(encoded, sort.cursorKeys())
+	if err != nil {
+		return nil, err
+	}
+	for i, key := range sort {
+		var ok bool
+		if key.column != "" {
+			_, ok = values[i].(time.Time)
+		} else {
+			raw, isString := values[i].(string)
+			ok = isString && json.Valid([]byte(raw))
+		}
+		if !ok {
+			return nil, query.ErrInvalidCursor
+		}
+	}
+	createdAt, ok := values[len(sort)].(time.Time)
+	id, idOK := values[len(sort)+1].(string)
+	if !ok || !idOK {
+		return nil, query.ErrInvalidCursor
+	}
+	cursor.values, cursor.createdAt, cursor.id = values[:len(sort)], createdAt, id
+	return cursor, nil
+}
+
+func encodeRecordCursor(record models.Record, sort recordSort) string {
+	cursor := newRecordCursor(record, sort)
+	return query.EncodeCursor(sort.cursorKeys(), append(cursor.values, cursor.createdAt, cursor.id))
+}
+
+// cursorKeys describes the order as cursor keys, so a cursor only decodes for the order it was
+// made in.
+func (s recordSort) cursorKeys() []string {
+	keys := make([]string, 0, len(s)+len(recordCursorKeys))
+	for _, key := range s {
+		name := key.column
+		if name == "" {
+			name = "data." + key.field
+		}
+		dir := "asc"
+		if key.desc {
+			dir = "desc"
+		}
+		if key.column == "" && key.nullsFirst {
+			dir += " nulls_first"
+		}
+		keys = append(keys, name+" "+dir)
+	}
+	return append(keys, recordCursorKeys...)
+}
+
+// recordListOrder is the ORDER BY of a record list page in the default order; a page before a
+// cursor is fetched in reverse order, nearest record first.
+func recordListOrder(qualifier string, cursor *recordCursor) string {
+	if cursor != nil && cursor.before {
+		return qualifier + "created_at ASC, " + qualifier + "id DESC"
+	}
+	return qualifier + "created_at DESC, " + qualifier + "id ASC"
+}
+
+// clause is the keyset condition of the records past the cursor in the fetch order: the records
+// tying with it on the first keys of the order and coming after it on the next one. The default
+// order ends every order, so it decides between records tying on every sort key.
+func (c *recordCursor) clause(dbName, qualifier string) recordFilterClause {
+	var terms, ties []string
+	var args, tieArgs []interface{}
+	for i, key := range c.sort {
+		if c.before {
+			key = key.reversed()
+		}
+		keyset := key.keyset(dbName, qualifier, c.values[i])
+		if keyset.after != "" {
+			terms = append(terms, conjunction(append(ties[:len(ties):len(ties)], keyset.after)))
+			args = append(append(args, tieArgs...), keyset.afterArgs...)
+		}
+		ties = append(ties, keyset.tie)
+		tieArgs = append(tieArgs, keyset.tieArgs...)
+	}
+
+	createdAt := qualifier + "created_at"
+	id := qualifier + "id"
+	last := "(" + createdAt + " < ? OR (" + createdAt + " = ? AND " + id + " > ?))"
+	if c.before {
+		last = "(" + createdAt + " > ? OR (" + createdAt + " = ? AND " + id + " < ?))"
+	}
+	value := recordCursorTime(dbName, c.createdAt)
+	terms = append(terms, conjunction(append(ties, last)))
+	args = append(append(args, tieArgs...), value, value, c.id)
+
+	sql := terms[0]
+	if len(terms) > 1 {
+		sql = "(" + strings.Join(terms, " OR ") + ")"
+	}
+	return recordFilterClause{sql: sql, args: args, keyset: c}
+}
+
+// conjunction joins conditions with AND.
+func conjunction(conditions []string) string {
+	if len(conditions) == 1 {
+		return conditions[0]
+	}
+	return "(" + strings.Join(conditions, " AND ") + ")"
+}
+
+// recordCursorTime is the cursor time as the database compares it. SQLite compares the text of
+// timestamps, which CURRENT_TIMESTAMP stores in UTC without a zone.
+func recordCursorTime(dbName string, t time.Time) interface{} {
+	if dbName == "sqlite" {
+		return t.UTC().Format("2006-01-02 15:04:05.999999999")
+	}
+	return t
+}
+
+// finishCursorPage trims a page fetched past a cursor to its limit, restores list order and
+// fills the cursors of the result.
+func finishCursorPage(records []models.Record, cursor *recordCursor, limit int, result *dto.RecordListData) []models.Record {
+	more := len(records) > limit
+	if more {
+		records = records[:limit]
+	}
+	if cursor.before {
+		for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+			records[i], records[j] = records[j], records[i]
+		}
+	}
+	if len(records) == 0 {
+		return records
+	}
+	first, last := encodeRecordCursor(records[0], cursor.sort), encodeRecordCursor(records[len(records)-1], cursor.sort)
+	if cursor.before {
+		result.NextCursor = last
+		if more {
+			result.PrevCursor = first
+		}
+	} else {
+		result.PrevCursor = first
+		if more {
+			result.NextCursor = last
+		}
+	}
+	result.HasMore = result.NextCursor != ""
+	return records
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/dto"
+	"github.com/jiangfire/cornerstone/pkg/formula"
+	"github.com/jiangfire/cornerstone/pkg/query"
+)
+
+func recordListNames(list *dto.RecordListData) []string {
+	names := make([]string, len(list.Records))
+	for i, record := range list.Records {
+		names[i], _ = record.Data.(map[string]interface{})["name"].(string)
+	}
+	return names
+}
+
+func TestListRecords_Cursor(t *testing.T) {
+	db, table, master, _, svc := setupFormulaTestEnv(t)
+	// Records 1 and 2 share a timestamp; 6 is the newest.
+	for i, minutes := range []int{9, 8, 7, 7, 5, 4, 1} {
+		record := createUniqueTestRecord(t, svc, table, master, map[string]interface{}{
+			"name": fmt.Sprintf("r%d", i), "price": i % 2,
+		})
+		require.NoError(t, db.Model(&models.Record{}).Where("id = ?", record.ID).
+			Update("created_at", gorm.Expr("datetime('now', ?)", fmt.Sprintf("-%d minutes", minutes))).Error)
+	}
+
+	for _, filter := range []string{"", `{"price":1}`, "r"} {
+		all, err := svc.ListRecords(dto.RecordListQueryRequest{TableID: table.ID, Limit: 100, Filter: filter}, master.ID)
+		require.NoError(t, err)
+		want := recordListNames(all)
+		require.NotEmpty(t, want)
+
+		// Forward from the first page.
+		var names []string
+		req := dto.RecordListQueryRequest{TableID: table.ID, Limit: 2, Filter: filter}
+		var last *dto.RecordListData
+		for pages := 0; pages < 10; pages++ {
+			list, err := svc.ListRecords(req, master.ID)
+			require.NoError(t, err)
+			assert.Equal(t, all.Total, list.Total)
+			names = append(names, recordListNames(list)...)
+			last = list
+			if list.NextCursor == "" {
+				break
+			}
+			assert.True(t, list.HasMore)
+			req.After = list.NextCursor
+		}
+		assert.Equal(t, want, names, filter)
+		assert.False(t, last.HasMore)
+
+		// Backward from the last page.
+		var backward []string
+		for prev := last.PrevCursor; prev != ""; {
+			list, err := svc.ListRecords(dto.RecordListQueryRequest{TableID: table.ID, Limit: 2, Filter: filter, Before: prev}, master.ID)
+			require.NoError(t, err)
+			backward = append(recordListNames(list), backward...)
+			assert.NotEmpty(t, list.NextCursor)
+			prev = list.PrevCursor
+		}
+		assert.Equal(t, want[:len(want)-len(last.Records)], backward, filter)
+	}
+
+	// An offset page hands out cursors too.
+	list, err := svc.ListRecords(dto.RecordListQueryRequest{TableID: table.ID, Limit: 2, Offset: 2}, master.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, list.NextCursor)
+	require.NotEmpty(t, list.PrevCursor)
+	next, err := svc.ListRecords(dto.RecordListQueryRequest{TableID: table.ID, Limit: 2, Offset: 4}, master.ID)
+	require.NoError(t, err)
+	byCursor, err := svc.ListRecords(dto.RecordListQueryRequest{TableID: table.ID, Limit: 2, After: list.NextCursor}, master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, recordListNames(next), recordListNames(byCursor))
+}
+
+func TestListRecords_CursorErrors(t *testing.T) {
+	_, table, master, _, svc := setupFormulaTestEnv(t)
+	record := createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"name": "a"})
+	cursor := encodeRecordCursor(*record, nil)
+
+	tests := []struct {
+		req  dto.RecordListQueryRequest
+		want string
+	}{
+		{dto.RecordListQueryRequest{After: cursor, Before: cursor}, "after and before cannot be used together"},
+		{dto.RecordListQueryRequest{After: cursor, Offset: 1}, "offset cannot be used with a cursor"},
+		{dto.RecordListQueryRequest{After: "bad"}, "invalid cursor"},
+		{dto.RecordListQueryRequest{After: query.EncodeCursor([]string{"id asc"}, []interface{}{"x"})}, "does not match the sort order"},
+	}
+	for _, tt := range tests {
+		tt.req.TableID, tt.req.Limit = table.ID, 10
+		_, err := svc.ListRecords(tt.req, master.ID)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, query.ErrInvalidCursor))
+		assert.Contains(t, err.Error(), tt.want)
+	}
+}
+
+func TestBuildMySQLRecordListSQL_WithCursor(t *testing.T) {
+	record := models.Record{ID: "rec_1"}
+	after := &recordCursor{createdAt: record.CreatedAt, id: record.ID}
+	req := dto.RecordListQueryRequest{TableID: "tbl_1", Limit: 21}
+
+	sql, args := buildMySQLRecordListSQL(req, []recordFilterClause{after.clause("mysql", "")}, nil)
+	assert.Equal(t, "SELECT id, table_id, data, version, created_at, updated_at FROM records FORCE INDEX (idx_records_table_deleted_created) WHERE table_id = ? AND deleted_at IS NULL AND (created_at < ? OR (created_at = ? AND id > ?)) ORDER BY created_at DESC, id ASC LIMIT ? OFFSET ?", sql)
+	assert.Equal(t, []interface{}{"tbl_1", record.CreatedAt, record.CreatedAt, "rec_1", 21, 0}, args)
+
+	before := &recordCursor{createdAt: record.CreatedAt, id: record.ID, before: true}
+	sql, args = buildMySQLRecordListSQL(req, []recordFilterClause{
+		before.clause("mysql", ""),
+		{
+			sql:         "EXISTS (...)",
+			args:        []interface{}{"fld_status", "paid"},
+			indexFilter: &recordFieldIndexFilter{fieldID: "fld_status", valueType: "text", value: "paid"},
+		},
+	}, nil)
+	assert.Contains(t, sql, ") matched JOIN records FORCE INDEX (PRIMARY) ON records.id = matched.record_id ")
+	assert.Contains(t, sql, "WHERE records.table_id = ? AND records.deleted_at IS NULL AND (records.created_at > ? OR (records.created_at = ? AND records.id < ?)) ORDER BY records.created_at ASC, records.id DESC LIMIT ? OFFSET ?")
+	assert.NotContains(t, sql, "EXISTS")
+	assert.Equal(t, []interface{}{"tbl_1", "fld_status", "paid", 1, "tbl_1", record.CreatedAt, record.CreatedAt, "rec_1", 21, 0}, args)
+}
+
+func TestRecordCursorClause_Sorted(t *testing.T) {
+	sort := recordSort{{field: "price", typ: formula.TypeNumber, desc: true}, {column: "updated_at"}}
+	record := models.Record{ID: "rec_1", Data: models.JSONField(`{"price":9.5}`)}
+	after := newRecordCursor(record, sort)
+
+	// Records past the cursor come later on price, or tie on price and come later on updated_at,
+	// or tie on both and come later in the default order.
+	clause := after.clause("sqlite", "")
+	price := `CASE WHEN JSON_TYPE(data, '$."price"') IN ('integer', 'real') THEN JSON_EXTRACT(data, '$."price"') END`
+	cursorPrice := `CASE WHEN JSON_TYPE(?, '$."price"') IN ('integer', 'real') THEN JSON_EXTRACT(?, '$."price"') END`
+	terms := strings.Split(clause.sql, ") OR (")
+	require.Len(t, terms, 3)
+	assert.Contains(t, terms[0], "("+price+" IS NULL OR "+price+" < "+cursorPrice+")")
+	assert.Contains(t, terms[1], "("+price+" = "+cursorPrice+" OR")
+	assert.True(t, strings.HasSuffix(terms[1], "AND julianday(updated_at) > julianday(?)"), terms[1])
+	assert.True(t, strings.HasSuffix(terms[2], "AND julianday(updated_at) = julianday(?) AND (created_at < ? OR (created_at = ? AND id > ?))))"), terms[2])
+	assert.Equal(t, `{"price":9.5}`, clause.args[0])
+
+	for _, dbName := range []string{"sqlite", "postgres", "mysql"} {
+		for _, cursor := range []*recordCursor{after, {sort: sort, values: after.values, id: "rec_1", before: true}} {
+			clause := cursor.clause(dbName, "records.")
+			assert.Equal(t, strings.Count(clause.sql, "?"), len(clause.args), dbName)
+		}
+	}
+	assert.Contains(t, after.clause("postgres", "").sql, "jsonb_typeof(CAST(? AS jsonb)->'price') = 'number'")
+	assert.Contains(t, after.clause("mysql", "").sql, `JSON_VALUE(CAST(? AS JSON), '$."price"' RETURNING DOUBLE)`)
+}
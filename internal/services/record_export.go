@@ -0,0 +1,443 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/dto"
+	json "github.com/jiangfire/cornerstone/pkg/jsonx"
+	"github.com/jiangfire/cornerstone/pkg/xlsx"
+)
+
+// recordExportBatchSize is the number of records an export reads per query.
+// Declared as var for test replacement; production code should not modify.
+var recordExportBatchSize = 500
+
+// xlsxContentType is the content type of an xlsx workbook.
+const xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// RecordExport is an export whose table access and parameters have been checked; Stream writes
+// it. ContentType and Filename describe the file it writes.
+type RecordExport struct {
+	ContentType string
+	Filename    string
+
+	svc            *RecordService
+	req            dto.RecordExportRequest
+	format         string
+	sheetName      string // name of the table, for xlsx
+	fields         []models.Field
+	readableFields map[string]models.Field
+	masks          map[string]string // mask rules of the fields written masked
+	columns        []models.Field    // readable and masked fields written, in column order
+	clauses        []recordFilterClause
+	keyword        string // keyword filter, matched against the readable data of each record
+	sort           recordSort
+	empty          bool // the filter references a field the user cannot read, or no record passes the row filter
+}
+
+// ExportRecords prepares an export of the records of a table as csv, json, ndjson or xlsx, with
+// the filter, field selection and sort of a record list. Records are read in batches while the
+// export is streamed, so the size of a table does not bound the memory it takes.
+func (s *RecordService) ExportRecords(req dto.RecordExportRequest, userID string) (*RecordExport, error) {
+	format := strings.ToLower(strings.TrimSpace(req.Format))
+	if format == "" {
+		format = "csv"
+	}
+	var contentType string
+	switch format {
+	case "csv":
+		contentType = "text/csv; charset=utf-8"
+	case "json":
+		contentType = "application/json; charset=utf-8"
+	case "ndjson":
+		contentType = "application/x-ndjson; charset=utf-8"
+	case "xlsx":
+		contentType = xlsxContentType
+	default:
+		return nil, errors.New("unsupported export format, only csv/json/ndjson/xlsx are supported")
+	}
+
+	if err := s.checkTableAccess(req.TableID, userID, []string{"owner", "admin", "editor", "viewer"}); err != nil {
+		return nil, err
+	}
+	var table models.Table
+	if err := s.db.Select("name").Where("id = ?", req.TableID).First(&table).Error; err != nil {
+		return nil, fmt.Errorf("failed to get table: %w", err)
+	}
+	fields, err := s.getTableFields(req.TableID)
+	if err != nil {
+		return nil, err
+	}
+	readableFields, _, masks, err := s.getFieldAccessMaps(fields, userID)
+	if err != nil {
+		return nil, err
+	}
+	sort, err := parseRecordSort(req.Sort, fields, readableFields)
+	if err != nil {
+		return nil, err
+	}
+	rowClauses, noRows, err := s.rowFilterClauses(req.TableID, userID, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &RecordExport{
+		ContentType:    contentType,
+		Filename:       fmt.Sprintf("records_%s_%s.%s", req.TableID, time.Now().Format("20060102150405"), format),
+		svc:            s,
+		req:            req,
+		format:         format,
+		sheetName:      table.Name,
+		fields:         fields,
+		readableFields: readableFields,
+		masks:          masks,
+		columns:        recordExportColumns(fields, readableFields, masks, req.Fields),
+		sort:           sort,
+		clauses:        rowClauses,
+		empty:          noRows,
+	}
+
+	filter := strings.TrimSpace(req.Filter)
+	if filter == "" {
+		return export, nil
+	}
+	if structured, ok := tryParseStructuredFilter(filter); ok {
+		clauses, refsHidden, err := s.buildStructuredFilterClauses(fields, readableFields, structured)
+		if err != nil {
+			return nil, err
+		}
+		export.clauses = append(export.clauses, clauses...)
+		export.empty = export.empty || refsHidden
+		return export, nil
+	}
+	// Like a record list, a keyword narrows the records in SQL and matches the readable data.
+	likeSQL := "data LIKE ?"
+	if s.db.Name() == "postgres" {
+		likeSQL = "data::text LIKE ?"
+	}
+	export.clauses = append(export.clauses, recordFilterClause{sql: likeSQL, args: []interface{}{"%" + filter + "%"}})
+	export.keyword = filter
+	return export, nil
+}
+
+// recordExportColumns returns the readable and masked fields an export writes: those selected,
+// in the order of the selection, or all of them without one. Unknown names are ignored.
+func recordExportColumns(fields []models.Field, readableFields map[string]models.Field, masks map[string]string, selection string) []models.Field {
+	exported := make(map[string]models.Field, len(readableFields)+len(masks))
+	for _, field := range fields {
+		_, readable := readableFields[field.Name]
+		if _, masked := masks[field.Name]; readable || masked {
+			exported[field.Name] = field
+		}
+	}
+	var columns []models.Field
+	if selection == "" {
+		for _, field := range fields {
+			if _, ok := exported[field.Name]; ok {
+				columns = append(columns, field)
+			}
+		}
+		return columns
+	}
+	seen := make(map[string]bool)
+	for _, name := range splitAndTrim(selection, ",") {
+		if field, ok := exported[name]; ok && !seen[name] {
+			seen[name] = true
+			columns = append(columns, field)
+		}
+	}
+	return columns
+}
+
+// Stream writes the export to w, flushing it after every batch of records when w has a
+// Flush method, like an HTTP response. An error past the first write leaves a truncated file.
+func (e *RecordExport) Stream(w io.Writer) error {
+	flusher, _ := w.(interface{ Flush() })
+	if e.format == "xlsx" {
+		workbook := xlsx.NewWriter(w)
+		if err := e.writeSheet(workbook, flusher); err != nil {
+			return err
+		}
+		return workbook.Close()
+	}
+	var csvWriter *csv.Writer
+
+	switch e.format {
+	case "csv":
+		csvWriter = csv.NewWriter(w)
+		header := make([]string, 0, len(e.columns)+2)
+		header = append(header, "id")
+		for _, field := range e.columns {
+			header = append(header, field.Name)
+		}
+		header = append(header, "version")
+		if err := csvWriter.Write(header); err != nil {
+			return fmt.Errorf("failed to write CSV header: %w", err)
+		}
+	case "json":
+		if _, err := io.WriteString(w, "["); err != nil {
+			return err
+		}
+	}
+
+	written := 0
+	err := e.eachBatch(func(records []models.Record) error {
+		for _, record := range records {
+			data, ok, err := e.recordData(record)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+
+			if csvWriter != nil {
+				row := make([]string, 0, len(e.columns)+2)
+				row = append(row, record.ID)
+				for _, field := range e.columns {
+					row = append(row, stringifyExportValue(data[field.Name]))
+				}
+				row = append(row, strconv.Itoa(record.Version))
+				if err := csvWriter.Write(row); err != nil {
+					return fmt.Errorf("failed to write CSV data: %w", err)
+				}
+				continue
+			}
+
+			data = filterDataFields(data, e.req.Fields)
+			row := map[string]interface{}{
+				"id":       record.ID,
+				"table_id": record.TableID,
+				"data":     data,
+				"version":  record.Version,
+			}
+			var encoded []byte
+			if e.format == "json" {
+				// Indented like a whole-array MarshalIndent would write it.
+				encoded, err = json.MarshalIndent(row, "  ", "  ")
+				prefix := ",\n  "
+				if written == 0 {
+					prefix = "\n  "
+				}
+				encoded = append([]byte(prefix), encoded...)
+			} else {
+				encoded, err = json.Marshal(row)
+				encoded = append(encoded, '\n')
+			}
+			if err != nil {
+				return fmt.Errorf("failed to export JSON: %w", err)
+			}
+			if _, err := w.Write(encoded); err != nil {
+				return err
+			}
+			written++
+		}
+
+		if csvWriter != nil {
+			csvWriter.Flush()
+			if err := csvWriter.Error(); err != nil {
+				return fmt.Errorf("failed to generate CSV: %w", err)
+			}
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	switch e.format {
+	case "csv":
+		csvWriter.Flush()
+		if err := csvWriter.Error(); err != nil {
+			return fmt.Errorf("failed to generate CSV: %w", err)
+		}
+	case "json":
+		end := "\n]"
+		if written == 0 {
+			end = "]"
+		}
+		if _, err := io.WriteString(w, end); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeSheet writes the export as a sheet of workbook named after the table: a header row of
+// id, the field names and version, then a row per record with typed number, boolean, date and
+// datetime cells. Other values are written as text, like in a CSV export.
+func (e *RecordExport) writeSheet(workbook *xlsx.Writer, flusher interface{ Flush() }) error {
+	if err := workbook.AddSheet(e.sheetName); err != nil {
+		return fmt.Errorf("failed to write xlsx sheet: %w", err)
+	}
+	header := make([]interface{}, 0, len(e.columns)+2)
+	header = append(header, "id")
+	for _, field := range e.columns {
+		header = append(header, field.Name)
+	}
+	header = append(header, "version")
+	if err := workbook.WriteRow(header...); err != nil {
+		return fmt.Errorf("failed to write xlsx header: %w", err)
+	}
+
+	return e.eachBatch(func(records []models.Record) error {
+		for _, record := range records {
+			data, ok, err := e.recordData(record)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			row := make([]interface{}, 0, len(e.columns)+2)
+			row = append(row, record.ID)
+			for _, field := range e.columns {
+				row = append(row, xlsxExportValue(field, data[field.Name]))
+			}
+			row = append(row, record.Version)
+			if err := workbook.WriteRow(row...); err != nil {
+				return fmt.Errorf("failed to write xlsx data: %w", err)
+			}
+		}
+		if err := workbook.Flush(); err != nil {
+			return fmt.Errorf("failed to write xlsx data: %w", err)
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+}
+
+// recordData returns the readable data of a record, and false when the keyword filter of the
+// export does not match it.
+func (e *RecordExport) recordData(record models.Record) (map[string]interface{}, bool, error) {
+	data := e.svc.filterReadableData(e.fields, e.readableFields, e.masks, parseRecordPayload(record.Data))
+	if e.keyword == "" {
+		return data, true, nil
+	}
+	matched, err := e.svc.matchesRecordFilter(e.fields, e.readableFields, data, e.keyword)
+	if err != nil || !matched {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// xlsxExportValue returns the cell value of a field value: numbers and booleans keep their
+// type, and the values of date and datetime fields become date cells.
+func xlsxExportValue(field models.Field, value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, float64, bool:
+		return v
+	case string:
+		switch field.Type {
+		case "date":
+			for _, layout := range []string{"2006-01-02", time.RFC3339} {
+				if parsed, err := time.Parse(layout, v); err == nil {
+					return xlsx.Date{Time: parsed}
+				}
+			}
+		case "datetime":
+			for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05"} {
+				if parsed, err := time.Parse(layout, v); err == nil {
+					return parsed
+				}
+			}
+		}
+		return v
+	}
+	return stringifyExportValue(value)
+}
+
+// eachBatch reads the records of the export in order, one batch per query, so no connection
+// is held while a batch is written. Batches page by keyset, so records written meanwhile do not
+// shift them.
+func (e *RecordExport) eachBatch(fn func([]models.Record) error) error {
+	if e.empty {
+		return nil
+	}
+	page := dto.RecordListQueryRequest{TableID: e.req.TableID, Limit: recordExportBatchSize}
+	var cursor *recordCursor
+	for {
+		clauses := e.clauses
+		if cursor != nil {
+			clauses = append([]recordFilterClause{cursor.clause(e.svc.db.Name(), "")}, e.clauses...)
+		}
+		records, err := e.svc.findRecordPage(page, clauses, e.sort)
+		if err != nil {
+			return fmt.Errorf("failed to read records: %w", err)
+		}
+		if len(records) == 0 {
+			return nil
+		}
+		if err := fn(records); err != nil {
+			return err
+		}
+		if len(records) < page.Limit {
+			return nil
+		}
+		cursor = newRecordCursor(records[len(records)-1], e.sort)
+	}
+}
+
+// DatabaseExport is an xlsx export of the tables of a database whose access has been checked;
+// Stream writes it as a workbook with a sheet per table.
+type DatabaseExport struct {
+	ContentType string
+	Filename    string
+
+	tables []*RecordExport
+}
+
+// ExportDatabase prepares an export of every table of a database as one xlsx workbook, each
+// table a sheet of the fields and records the user can read. xlsx is the only format holding
+// several tables; an empty format selects it.
+func (s *RecordService) ExportDatabase(databaseID, format, userID string) (*DatabaseExport, error) {
+	if format = strings.ToLower(strings.TrimSpace(format)); format != "" && format != "xlsx" {
+		return nil, errors.New("unsupported database export format, only xlsx is supported")
+	}
+	database, err := NewDatabaseService(s.db).ResolveDatabase(databaseID)
+	if err != nil {
+		return nil, err
+	}
+	tables, err := NewTableService(s.db).ListTables(database.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &DatabaseExport{
+		ContentType: xlsxContentType,
+		Filename:    fmt.Sprintf("database_%s_%s.xlsx", database.ID, time.Now().Format("20060102150405")),
+	}
+	for _, table := range tables {
+		tableExport, err := s.ExportRecords(dto.RecordExportRequest{TableID: table.ID, Format: "xlsx"}, userID)
+		if err != nil {
+			return nil, err
+		}
+		export.tables = append(export.tables, tableExport)
+	}
+	return export, nil
+}
+
+// Stream writes the workbook to w, one table after the other, flushing it after every batch
+// of records when w has a Flush method. An error past the first write leaves a truncated file.
+func (e *DatabaseExport) Stream(w io.Writer) error {
+	flusher, _ := w.(interface{ Flush() })
+	workbook := xlsx.NewWriter(w)
+	for _, table := range e.tables {
+		if err := table.writeSheet(workbook, flusher); err != nil {
+			return err
+		}
+	}
+	return workbook.Close()
+}
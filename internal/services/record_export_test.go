@@ -0,0 +1,221 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/dto"
+	json "github.com/jiangfire/cornerstone/pkg/jsonx"
+	"github.com/jiangfire/cornerstone/pkg/xlsx"
+)
+
+// exportRecordsBytes runs an export into memory.
+func exportRecordsBytes(s *RecordService, tableID, userID, format, filter, sort string) ([]byte, string, string, error) {
+	export, err := s.ExportRecords(dto.RecordExportRequest{TableID: tableID, Format: format, Filter: filter, Sort: sort}, userID)
+	if err != nil {
+		return nil, "", "", err
+	}
+	var buf bytes.Buffer
+	if err := export.Stream(&buf); err != nil {
+		return nil, "", "", err
+	}
+	return buf.Bytes(), export.ContentType, export.Filename, nil
+}
+
+// flushCounter is a writer that counts how often it is flushed.
+type flushCounter struct {
+	bytes.Buffer
+	flushes int
+}
+
+func (w *flushCounter) Flush() { w.flushes++ }
+
+func TestExportRecords_StreamsInBatches(t *testing.T) {
+	_, table, master, _, svc := setupFormulaTestEnv(t)
+	for i := 0; i < 7; i++ {
+		createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"name": fmt.Sprintf("r%d", i), "price": i})
+	}
+	all, err := svc.ListRecords(dto.RecordListQueryRequest{TableID: table.ID, Limit: 100}, master.ID)
+	require.NoError(t, err)
+	want := recordListNames(all)
+
+	orig := recordExportBatchSize
+	recordExportBatchSize = 3
+	defer func() { recordExportBatchSize = orig }()
+
+	// Pages of 3 read 7 records in 3 batches, keeping the list order.
+	export, err := svc.ExportRecords(dto.RecordExportRequest{TableID: table.ID, Format: "ndjson"}, master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "application/x-ndjson; charset=utf-8", export.ContentType)
+	assert.True(t, strings.HasSuffix(export.Filename, ".ndjson"))
+	var out flushCounter
+	require.NoError(t, export.Stream(&out))
+	assert.Equal(t, 3, out.flushes)
+
+	var names []string
+	for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n") {
+		var row struct {
+			ID   string                 `json:"id"`
+			Data map[string]interface{} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(line), &row), line)
+		assert.NotEmpty(t, row.ID)
+		names = append(names, row.Data["name"].(string))
+	}
+	assert.Equal(t, want, names)
+
+	// A sorted export pages by offset.
+	data, _, _, err := exportRecordsBytes(svc, table.ID, master.ID, "json", "", "-price")
+	require.NoError(t, err)
+	var rows []struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(data, &rows))
+	require.Len(t, rows, 7)
+	for i, row := range rows {
+		assert.Equal(t, fmt.Sprintf("r%d", 6-i), row.Data["name"])
+	}
+}
+
+func TestExportRecords_FiltersAndFields(t *testing.T) {
+	_, table, master, _, svc := setupFormulaTestEnv(t)
+	for _, data := range []map[string]interface{}{
+		{"name": "apple", "price": 1, "quantity": 5},
+		{"name": "apricot", "price": 2},
+		{"name": "banana", "price": 1},
+	} {
+		createUniqueTestRecord(t, svc, table, master, data)
+	}
+
+	export, err := svc.ExportRecords(dto.RecordExportRequest{TableID: table.ID, Filter: `{"price":1}`, Fields: "quantity, name,missing", Sort: "name"}, master.ID)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, export.Stream(&buf))
+	lines, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, lines, 3)
+	assert.Equal(t, []string{"id", "quantity", "name", "version"}, lines[0])
+	assert.Equal(t, []string{"5", "apple"}, lines[1][1:3])
+	assert.Equal(t, []string{"", "banana"}, lines[2][1:3])
+
+	// Keyword filters match like a record list; JSON exports keep the selected fields.
+	export, err = svc.ExportRecords(dto.RecordExportRequest{TableID: table.ID, Format: "JSON", Filter: "ap", Fields: "name", Sort: "name"}, master.ID)
+	require.NoError(t, err)
+	buf.Reset()
+	require.NoError(t, export.Stream(&buf))
+	var rows []struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &rows))
+	require.Len(t, rows, 2)
+	assert.Equal(t, map[string]interface{}{"name": "apple"}, rows[0].Data)
+	assert.Equal(t, map[string]interface{}{"name": "apricot"}, rows[1].Data)
+
+	// A filter on a hidden or unknown field exports nothing.
+	data, _, _, err := exportRecordsBytes(svc, table.ID, master.ID, "ndjson", `{"secret":1}`, "")
+	require.NoError(t, err)
+	assert.Empty(t, data)
+}
+
+// readWorkbook returns the rows of every sheet of a workbook by sheet name, each row mapping
+// the header row's column names to cell values.
+func readWorkbook(t *testing.T, data []byte) map[string][]map[string]interface{} {
+	t.Helper()
+	workbook, err := xlsx.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	sheets := make(map[string][]map[string]interface{})
+	for i, name := range workbook.SheetNames() {
+		rows, err := workbook.Rows(i)
+		require.NoError(t, err)
+		var header []interface{}
+		sheets[name] = []map[string]interface{}{}
+		for {
+			_, cells, err := rows.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+			if header == nil {
+				header = cells
+				continue
+			}
+			row := make(map[string]interface{})
+			for j, cell := range cells {
+				if cell != nil {
+					row[header[j].(string)] = cell
+				}
+			}
+			sheets[name] = append(sheets[name], row)
+		}
+		require.NoError(t, rows.Close())
+	}
+	return sheets
+}
+
+func TestExportRecords_XLSX(t *testing.T) {
+	_, table, master, fieldSvc, svc := setupFormulaTestEnv(t)
+	for name, fieldType := range map[string]string{"paid": "boolean", "due": "date", "seen": "datetime"} {
+		_, err := fieldSvc.CreateField(dto.FieldCreateRequest{TableID: table.ID, Name: name, Type: fieldType}, master.ID)
+		require.NoError(t, err)
+	}
+	createUniqueTestRecord(t, svc, table, master, map[string]interface{}{
+		"name": "apple", "price": 1.5, "paid": true, "due": "2024-03-05", "seen": "2024-03-05T14:30:15Z",
+	})
+	createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"name": "banana"})
+
+	data, contentType, filename, err := exportRecordsBytes(svc, table.ID, master.ID, "XLSX", "", "name")
+	require.NoError(t, err)
+	assert.Equal(t, xlsxContentType, contentType)
+	assert.True(t, strings.HasSuffix(filename, ".xlsx"))
+
+	sheets := readWorkbook(t, data)
+	require.Contains(t, sheets, "gap_table")
+	rows := sheets["gap_table"]
+	require.Len(t, rows, 2)
+	assert.Equal(t, "apple", rows[0]["name"])
+	assert.Equal(t, 1.5, rows[0]["price"])
+	assert.Equal(t, true, rows[0]["paid"])
+	assert.Equal(t, xlsx.Date{Time: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)}, rows[0]["due"])
+	assert.Equal(t, time.Date(2024, 3, 5, 14, 30, 15, 0, time.UTC), rows[0]["seen"])
+	assert.Equal(t, float64(1), rows[0]["version"])
+	assert.Equal(t, map[string]interface{}{"id": rows[1]["id"], "name": "banana", "version": float64(1)}, rows[1])
+}
+
+func TestExportDatabase_SheetPerTable(t *testing.T) {
+	db, table, master, fieldSvc, svc := setupFormulaTestEnv(t)
+	createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"name": "apple"})
+	other := &models.Table{DatabaseID: table.DatabaseID, Name: "other"}
+	require.NoError(t, db.Create(other).Error)
+	_, err := fieldSvc.CreateField(dto.FieldCreateRequest{TableID: other.ID, Name: "label", Type: "string"}, master.ID)
+	require.NoError(t, err)
+	createUniqueTestRecord(t, svc, other, master, map[string]interface{}{"label": "x"})
+
+	export, err := svc.ExportDatabase(table.DatabaseID, "", master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, xlsxContentType, export.ContentType)
+	var buf bytes.Buffer
+	require.NoError(t, export.Stream(&buf))
+	sheets := readWorkbook(t, buf.Bytes())
+	require.Len(t, sheets, 2)
+	require.Len(t, sheets["gap_table"], 1)
+	assert.Equal(t, "apple", sheets["gap_table"][0]["name"])
+	require.Len(t, sheets["other"], 1)
+	assert.Equal(t, "x", sheets["other"][0]["label"])
+
+	_, err = svc.ExportDatabase(table.DatabaseID, "csv", master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "only xlsx is supported")
+	_, err = svc.ExportDatabase("missing", "xlsx", master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+}
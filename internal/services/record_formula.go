@@ -0,0 +1,204 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/formula"
+	"gorm.io/gorm"
+)
+
+// formulaCache holds parsed formulas keyed by their source text.
+var formulaCache sync.Map
+
+func parseFormula(source string) (*formula.Expression, error) {
+	if cached, ok := formulaCache.Load(source); ok {
+		return cached.(*formula.Expression), nil
+	}
+	expr, err := formula.Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	formulaCache.Store(source, expr)
+	return expr, nil
+}
+
+type formulaField struct {
+	field models.Field
+	expr  *formula.Expression
+}
+
+// orderFormulaFields parses the formula fields of a table and orders them so that
+// every formula comes after the formulas it references.
+func orderFormulaFields(fields []models.Field) ([]formulaField, error) {
+	byName := make(map[string]formulaField)
+	var names []string
+	for _, field := range fields {
+		if !isFormulaFieldType(field.Type) {
+			continue
+		}
+		expr, err := parseFormula(parseStoredFieldConfig(field.Options).Formula)
+		if err != nil {
+			return nil, fmt.Errorf("formula field '%s': %w", field.Name, err)
+		}
+		byName[field.Name] = formulaField{field: field, expr: expr}
+		names = append(names, field.Name)
+	}
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int, len(names))
+	ordered := make([]formulaField, 0, len(names))
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("formula field '%s' has a circular reference: %s", path[0], strings.Join(append(path, name), " -> "))
+		}
+		state[name] = visiting
+		current := byName[name]
+		for _, ref := range current.expr.References() {
+			if ref == name {
+				return fmt.Errorf("formula field '%s' cannot reference itself", name)
+			}
+			if _, ok := byName[ref]; ok {
+				if err := visit(ref, append(path, name)); err != nil {
+					return err
+				}
+			}
+		}
+		state[name] = done
+		ordered = append(ordered, current)
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// checkTableFormulas validates every formula field of a table: references must exist,
+// formulas must not be circular and must type-check against the referenced fields.
+func checkTableFormulas(fields []models.Field) error {
+	_, err := tableValueTypes(fields)
+	return err
+}
+
+// tableValueTypes returns the type of the values of every field of a table, formula fields
+// typed by their result. On error, the formula fields not yet checked stay typed as any.
+func tableValueTypes(fields []models.Field) (map[string]formula.Type, error) {
+	types := make(map[string]formula.Type, len(fields))
+	for _, field := range fields {
+		types[field.Name] = formula.TypeOfField(field.Type)
+	}
+	ordered, err := orderFormulaFields(fields)
+	if err != nil {
+		return types, err
+	}
+	for _, f := range ordered {
+		resultType, err := f.expr.Check(types)
+		if err != nil {
+			return types, fmt.Errorf("formula field '%s': %w", f.field.Name, err)
+		}
+		types[f.field.Name] = resultType
+	}
+	return types, nil
+}
+
+// findFormulaReferencing returns a formula field other than the named one that references it.
+func findFormulaReferencing(fields []models.Field, name string) (models.Field, bool) {
+	for _, field := range fields {
+		if !isFormulaFieldType(field.Type) || field.Name == name {
+			continue
+		}
+		expr, err := parseFormula(parseStoredFieldConfig(field.Options).Formula)
+		if err != nil {
+			continue
+		}
+		for _, ref := range expr.References() {
+			if ref == name {
+				return field, true
+			}
+		}
+	}
+	return models.Field{}, false
+}
+
+// applyFormulaValues computes the formula fields of a record into payload.
+// A formula that cannot be evaluated for this record (division by zero, a non-numeric operand, ...) yields null.
+func applyFormulaValues(fields []models.Field, payload map[string]interface{}, now time.Time) {
+	ordered, err := orderFormulaFields(fields)
+	if err != nil {
+		return
+	}
+	for _, f := range ordered {
+		value, err := f.expr.Eval(payload, now)
+		if err != nil {
+			value = nil
+		}
+		payload[f.field.Name] = value
+	}
+}
+
+func hasFormulaFields(fields []models.Field) bool {
+	for _, field := range fields {
+		if isFormulaFieldType(field.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasVolatileFormulas reports whether a formula of a table reads the clock, so the values read
+// from its records change between writes.
+func hasVolatileFormulas(fields []models.Field) bool {
+	for _, field := range fields {
+		if !isFormulaFieldType(field.Type) {
+			continue
+		}
+		if expr, err := parseFormula(parseStoredFieldConfig(field.Options).Formula); err == nil && expr.Volatile() {
+			return true
+		}
+	}
+	return false
+}
+
+// backfillFormulaValues recomputes the stored formula values of every record in a table,
+// so that filters on the stored data and field indexes see a newly created or changed formula.
+func backfillFormulaValues(tx *gorm.DB, tableID string) error {
+	var fields []models.Field
+	if err := tx.Where("table_id = ? AND deleted_at IS NULL", tableID).Order("created_at ASC").Find(&fields).Error; err != nil {
+		return fmt.Errorf("failed to get field definitions: %w", err)
+	}
+
+	recordService := NewRecordService(tx)
+	now := time.Now()
+	var records []models.Record
+	return tx.Where("table_id = ? AND deleted_at IS NULL", tableID).
+		FindInBatches(&records, 200, func(_ *gorm.DB, _ int) error {
+			for _, record := range records {
+				payload := parseRecordPayload(record.Data)
+				applyFormulaValues(fields, payload, now)
+				dataJSON, err := marshalRecordPayload(payload)
+				if err != nil {
+					return err
+				}
+				if err := tx.Model(&models.Record{}).Where("id = ?", record.ID).Update("data", dataJSON).Error; err != nil {
+					return fmt.Errorf("failed to store formula values: %w", err)
+				}
+				if err := recordService.syncRecordFieldIndexes(tx, record.ID, tableID, fields, payload); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
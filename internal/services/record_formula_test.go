@@ -0,0 +1,266 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/dto"
+)
+
+func setupFormulaTestEnv(t *testing.T) (*gorm.DB, *models.Table, *models.Token, *FieldService, *RecordService) {
+	t.Helper()
+	db, _, table, master := setupCrudTestEnv(t)
+	SharedFieldCache.Clear()
+
+	fieldSvc := NewFieldService(db)
+	for name, fieldType := range map[string]string{"price": "number", "quantity": "number", "name": "string"} {
+		_, err := fieldSvc.CreateField(dto.FieldCreateRequest{TableID: table.ID, Name: name, Type: fieldType}, master.ID)
+		require.NoError(t, err)
+	}
+	return db, table, master, fieldSvc, NewRecordService(db)
+}
+
+func createFormulaField(t *testing.T, svc *FieldService, table *models.Table, master *models.Token, name, source string) *models.Field {
+	t.Helper()
+	field, err := svc.CreateField(dto.FieldCreateRequest{
+		TableID: table.ID, Name: name, Type: "formula",
+		Config: dto.FieldConfig{Formula: source},
+	}, master.ID)
+	require.NoError(t, err)
+	return field
+}
+
+func TestCreateField_FormulaValidation(t *testing.T) {
+	_, table, master, svc, _ := setupFormulaTestEnv(t)
+	createFormulaField(t, svc, table, master, "total", "price * quantity")
+
+	tests := []struct {
+		name string
+		req  dto.FieldCreateRequest
+		want string
+	}{
+		{"missing formula", dto.FieldCreateRequest{Name: "f", Type: "formula"}, "formula field requires a formula"},
+		{"syntax error", dto.FieldCreateRequest{Name: "f", Type: "formula", Config: dto.FieldConfig{Formula: "price *"}}, "unexpected"},
+		{"unknown field", dto.FieldCreateRequest{Name: "f", Type: "formula", Config: dto.FieldConfig{Formula: "cost * 2"}}, `unknown field "cost"`},
+		{"type error", dto.FieldCreateRequest{Name: "f", Type: "formula", Config: dto.FieldConfig{Formula: "name * 2"}}, "expects number operands"},
+		{"self reference", dto.FieldCreateRequest{Name: "f", Type: "formula", Config: dto.FieldConfig{Formula: "f + 1"}}, "cannot reference itself"},
+		{"required", dto.FieldCreateRequest{Name: "f", Type: "formula", Required: true, Config: dto.FieldConfig{Formula: "price"}}, "cannot be required"},
+		{"formula on plain field", dto.FieldCreateRequest{Name: "f", Type: "number", Config: dto.FieldConfig{Formula: "price"}}, "only supported for formula fields"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.TableID = table.ID
+			_, err := svc.CreateField(tt.req, master.ID)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestUpdateField_FormulaCycleRejected(t *testing.T) {
+	_, table, master, svc, _ := setupFormulaTestEnv(t)
+	total := createFormulaField(t, svc, table, master, "total", "price * quantity")
+	createFormulaField(t, svc, table, master, "taxed", "total * 1.2")
+
+	_, err := svc.UpdateField(total.ID, dto.FieldUpdateRequest{
+		Name: "total", Type: "formula", Config: dto.FieldConfig{Formula: "taxed / 1.2"},
+	}, master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "circular reference")
+}
+
+func TestUpdateField_RenameReferencedFieldRejected(t *testing.T) {
+	db, table, master, svc, _ := setupFormulaTestEnv(t)
+	createFormulaField(t, svc, table, master, "total", "price * quantity")
+
+	var price models.Field
+	require.NoError(t, db.Where("table_id = ? AND name = ?", table.ID, "price").First(&price).Error)
+	_, err := svc.UpdateField(price.ID, dto.FieldUpdateRequest{Name: "cost", Type: "number"}, master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown field "price"`)
+}
+
+func TestDeleteField_ReferencedByFormula(t *testing.T) {
+	db, table, master, svc, _ := setupFormulaTestEnv(t)
+	total := createFormulaField(t, svc, table, master, "total", "price * quantity")
+
+	var price models.Field
+	require.NoError(t, db.Where("table_id = ? AND name = ?", table.ID, "price").First(&price).Error)
+	err := svc.DeleteField(price.ID, master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "still referenced by formula field 'total'")
+
+	require.NoError(t, svc.DeleteField(total.ID, master.ID))
+	require.NoError(t, svc.DeleteField(price.ID, master.ID))
+}
+
+func TestCreateRecord_FormulaComputedAndStored(t *testing.T) {
+	db, table, master, fieldSvc, svc := setupFormulaTestEnv(t)
+	total := createFormulaField(t, fieldSvc, table, master, "total", "price * quantity")
+	createFormulaField(t, fieldSvc, table, master, "label", "upper(name) & ': ' & total")
+
+	record, err := svc.CreateRecord(dto.RecordCreateRequest{
+		TableID: table.ID,
+		Data:    map[string]interface{}{"price": 2.5, "quantity": 4, "name": "widget"},
+	}, master.ID)
+	require.NoError(t, err)
+
+	got, err := svc.GetRecord(record.ID, master.ID, "")
+	require.NoError(t, err)
+	data := got.Data.(map[string]interface{})
+	assert.Equal(t, 10.0, data["total"])
+	assert.Equal(t, "WIDGET: 10", data["label"])
+	assert.False(t, got.Volatile)
+
+	var stored models.Record
+	require.NoError(t, db.First(&stored, "id = ?", record.ID).Error)
+	assert.Equal(t, 10.0, parseRecordPayload(stored.Data)["total"])
+
+	var index models.RecordFieldIndex
+	require.NoError(t, db.Where("record_id = ? AND field_id = ? AND deleted_at IS NULL", record.ID, total.ID).First(&index).Error)
+	assert.Equal(t, "number", index.ValueType)
+	require.NotNil(t, index.ValueNumber)
+	assert.Equal(t, 10.0, *index.ValueNumber)
+
+	// A formula reading the clock changes the record between reads.
+	createFormulaField(t, fieldSvc, table, master, "checked", "today()")
+	got, err = svc.GetRecord(record.ID, master.ID, "")
+	require.NoError(t, err)
+	assert.True(t, got.Volatile)
+}
+
+func TestWriteFormulaFieldRejected(t *testing.T) {
+	_, table, master, fieldSvc, svc := setupFormulaTestEnv(t)
+	createFormulaField(t, fieldSvc, table, master, "total", "price * quantity")
+
+	_, err := svc.CreateRecord(dto.RecordCreateRequest{
+		TableID: table.ID,
+		Data:    map[string]interface{}{"price": 1, "total": 99},
+	}, master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "field 'total' is a formula field and cannot be written")
+
+	record, err := svc.CreateRecord(dto.RecordCreateRequest{TableID: table.ID, Data: map[string]interface{}{"price": 1}}, master.ID)
+	require.NoError(t, err)
+	_, err = svc.UpdateRecord(record.ID, dto.RecordUpdateRequest{Data: map[string]interface{}{"total": 99}}, master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot be written")
+}
+
+func TestFormulaField_MaskedReferences(t *testing.T) {
+	db, table, master, fieldSvc, svc := setupFormulaTestEnv(t)
+	total := createFormulaField(t, fieldSvc, table, master, "total", "price * quantity")
+	createFormulaField(t, fieldSvc, table, master, "double", "quantity * 2")
+	record, err := svc.CreateRecord(dto.RecordCreateRequest{
+		TableID: table.ID,
+		Data:    map[string]interface{}{"price": 42, "quantity": 2},
+	}, master.ID)
+	require.NoError(t, err)
+
+	token := &models.Token{
+		Name:   "masked_formula_editor",
+		Token:  "cs_masked_formula_editor",
+		Scopes: `{"tables":{"` + table.ID + `":{"role":"editor","masks":{"price":"redact"}}}}`,
+	}
+	require.NoError(t, db.Create(token).Error)
+
+	t.Run("formulas cannot read masked fields", func(t *testing.T) {
+		for _, source := range []string{"price", "price * 2", "total + 1"} {
+			_, err := fieldSvc.CreateField(dto.FieldCreateRequest{
+				TableID: table.ID, Name: "leak", Type: "formula",
+				Config: dto.FieldConfig{Formula: source},
+			}, token.ID)
+			require.Error(t, err, source)
+			assert.Contains(t, err.Error(), "is masked", source)
+		}
+
+		_, err := fieldSvc.UpdateField(total.ID, dto.FieldUpdateRequest{
+			Name: "total", Type: "formula", Config: dto.FieldConfig{Formula: "price"},
+		}, token.ID)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "field 'price' is masked")
+
+		_, err = fieldSvc.CreateField(dto.FieldCreateRequest{
+			TableID: table.ID, Name: "triple", Type: "formula",
+			Config: dto.FieldConfig{Formula: "quantity * 3"},
+		}, token.ID)
+		require.NoError(t, err)
+	})
+
+	t.Run("formulas reading masked fields are read masked", func(t *testing.T) {
+		got, err := svc.GetRecord(record.ID, token.ID, "")
+		require.NoError(t, err)
+		data := got.Data.(map[string]interface{})
+		assert.Equal(t, "****", data["total"])
+		assert.Equal(t, float64(4), data["double"])
+
+		list, err := svc.ListRecords(dto.RecordListQueryRequest{TableID: table.ID, Filter: `{"total":84}`}, token.ID)
+		require.NoError(t, err)
+		assert.Empty(t, list.Records)
+
+		_, err = svc.ListRecords(dto.RecordListQueryRequest{TableID: table.ID, Sort: "total"}, token.ID)
+		assert.ErrorIs(t, err, ErrInvalidSort)
+	})
+}
+
+func TestUpdateRecord_FormulaRecomputed(t *testing.T) {
+	_, table, master, fieldSvc, svc := setupFormulaTestEnv(t)
+	createFormulaField(t, fieldSvc, table, master, "total", "price * quantity")
+
+	record, err := svc.CreateRecord(dto.RecordCreateRequest{
+		TableID: table.ID,
+		Data:    map[string]interface{}{"price": 3, "quantity": 2},
+	}, master.ID)
+	require.NoError(t, err)
+
+	updated, err := svc.UpdateRecord(record.ID, dto.RecordUpdateRequest{Data: map[string]interface{}{"quantity": 5}}, master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 15.0, parseRecordPayload(updated.Data)["total"])
+
+	// Null operands and division by zero yield null rather than an error.
+	updated, err = svc.UpdateRecord(record.ID, dto.RecordUpdateRequest{Data: map[string]interface{}{"quantity": nil}}, master.ID)
+	require.NoError(t, err)
+	assert.Nil(t, parseRecordPayload(updated.Data)["total"])
+}
+
+func TestFormulaField_BackfillsExistingRecords(t *testing.T) {
+	_, table, master, fieldSvc, svc := setupFormulaTestEnv(t)
+	record, err := svc.CreateRecord(dto.RecordCreateRequest{
+		TableID: table.ID,
+		Data:    map[string]interface{}{"price": 4, "quantity": 0},
+	}, master.ID)
+	require.NoError(t, err)
+
+	field := createFormulaField(t, fieldSvc, table, master, "ratio", "price / quantity")
+	list, err := svc.ListRecords(dto.RecordListQueryRequest{TableID: table.ID, Limit: 10}, master.ID)
+	require.NoError(t, err)
+	require.Len(t, list.Records, 1)
+	value, exists := list.Records[0].Data.(map[string]interface{})["ratio"]
+	assert.True(t, exists)
+	assert.Nil(t, value)
+
+	_, err = fieldSvc.UpdateField(field.ID, dto.FieldUpdateRequest{
+		Name: "ratio", Type: "formula", Config: dto.FieldConfig{Formula: "price + quantity"},
+	}, master.ID)
+	require.NoError(t, err)
+	SharedFieldCache.Clear()
+
+	list, err = svc.ListRecords(dto.RecordListQueryRequest{TableID: table.ID, Filter: `{"ratio": 4}`, Limit: 10}, master.ID)
+	require.NoError(t, err)
+	require.Len(t, list.Records, 1)
+	assert.Equal(t, record.ID, list.Records[0].ID)
+}
+
+func TestGenerateTestData_SkipsFormulaFields(t *testing.T) {
+	_, table, master, fieldSvc, svc := setupFormulaTestEnv(t)
+	createFormulaField(t, fieldSvc, table, master, "total", "price * quantity")
+
+	records, err := svc.GenerateTestData(table.ID, master.ID, 2)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Contains(t, parseRecordPayload(records[0].Data), "total")
+}
@@ -516,7 +516,7 @@ func TestExportRecords_CSV(t *testing.T) {
 		TableID: tbl.ID, Data: map[string]any{"name": "bob"},
 	}, "user1")
 
-	data, contentType, filename, err := s.ExportRecords(tbl.ID, "user1", "csv", "")
+	data, contentType, filename, err := exportRecordsBytes(s, tbl.ID, "user1", "csv", "", "")
 	require.NoError(t, err)
 	assert.Contains(t, contentType, "text/csv")
 	assert.Contains(t, filename, ".csv")
@@ -541,7 +541,7 @@ func TestExportRecords_JSON(t *testing.T) {
 		TableID: tbl.ID, Data: map[string]any{"name": "alice"},
 	}, "user1")
 
-	data, contentType, filename, err := s.ExportRecords(tbl.ID, "user1", "json", "")
+	data, contentType, filename, err := exportRecordsBytes(s, tbl.ID, "user1", "json", "", "")
 	require.NoError(t, err)
 	assert.Contains(t, contentType, "application/json")
 	assert.Contains(t, filename, ".json")
@@ -560,7 +560,7 @@ func TestExportRecords_UnsupportedFormat(t *testing.T) {
 		}{"name", "string", false},
 	)
 
-	_, _, _, err := s.ExportRecords(tbl.ID, "user1", "xml", "")
+	_, _, _, err := exportRecordsBytes(s, tbl.ID, "user1", "xml", "", "")
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "unsupported export format")
 }
@@ -584,7 +584,7 @@ func TestExportRecords_WithFilter(t *testing.T) {
 		TableID: tbl.ID, Data: map[string]any{"status": "inactive"},
 	}, "user1")
 
-	data, _, _, err := s.ExportRecords(tbl.ID, "user1", "csv", `{"status":"active"}`)
+	data, _, _, err := exportRecordsBytes(s, tbl.ID, "user1", "csv", `{"status":"active"}`, "")
 	require.NoError(t, err)
 	assert.Contains(t, string(data), "active")
 	assert.NotContains(t, string(data), "inactive")
@@ -594,7 +594,7 @@ func TestExportRecords_TableNotExist(t *testing.T) {
 	db := setupTestDB(t)
 	s := NewRecordService(db)
 
-	_, _, _, err := s.ExportRecords("tbl_nonexistent", "user1", "csv", "")
+	_, _, _, err := exportRecordsBytes(s, "tbl_nonexistent", "user1", "csv", "", "")
 	require.Error(t, err)
 }
 
@@ -762,7 +762,7 @@ func TestFilterReadableData(t *testing.T) {
 	readable := map[string]models.Field{"a": {Name: "a"}}
 	payload := map[string]any{"a": 1, "b": 2}
 
-	filtered := s.filterReadableData(fields, readable, payload)
+	filtered := s.filterReadableData(fields, readable, nil, payload)
 	assert.Equal(t, 1, filtered["a"])
 	_, hasB := filtered["b"]
 	assert.False(t, hasB)
@@ -1269,7 +1269,7 @@ func TestExportRecords_NoRecords(t *testing.T) {
 		}{"name", "string", false},
 	)
 
-	data, contentType, filename, err := s.ExportRecords(tbl.ID, "user1", "csv", "")
+	data, contentType, filename, err := exportRecordsBytes(s, tbl.ID, "user1", "csv", "", "")
 	require.NoError(t, err)
 	assert.Contains(t, contentType, "text/csv")
 	assert.Contains(t, filename, ".csv")
@@ -1410,7 +1410,7 @@ func TestExportRecords_NoRecordsJSON(t *testing.T) {
 		}{"name", "string", false},
 	)
 
-	data, contentType, _, err := s.ExportRecords(tbl.ID, "user1", "json", "")
+	data, contentType, _, err := exportRecordsBytes(s, tbl.ID, "user1", "json", "", "")
 	require.NoError(t, err)
 	assert.Contains(t, contentType, "application/json")
 
@@ -0,0 +1,761 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/dto"
+	json "github.com/jiangfire/cornerstone/pkg/jsonx"
+	"github.com/jiangfire/cornerstone/pkg/xlsx"
+)
+
+const (
+	// maxImportErrors bounds the row errors an import reports; the rest are only counted.
+	maxImportErrors = 100
+	// maxImportStringLength is the longest value a field created by an import stores as
+	// string rather than text, in characters.
+	maxImportStringLength = 255
+)
+
+// importRow is a row read from an import file, with its values keyed by source column.
+type importRow struct {
+	line   int
+	values map[string]interface{}
+	err    error // the row could not be read; values is empty
+}
+
+// importLineError is a read error that ends an import, at the line it was found.
+type importLineError struct {
+	line int
+	err  error
+}
+
+func (e *importLineError) Error() string {
+	return e.err.Error()
+}
+
+// recordImportReader reads the rows of an import file one at a time.
+type recordImportReader interface {
+	// next returns the next row, or io.EOF after the last one. Any other error is an
+	// *importLineError and ends the import.
+	next() (importRow, error)
+}
+
+// recordImport is the state of an import while its rows are read and inserted.
+type recordImport struct {
+	svc      *RecordService
+	req      dto.RecordImportRequest
+	userID   string
+	typed    bool     // values carry their types; CSV cells are all strings
+	header   []string // columns in file order, when the format has a header row
+	fields   []models.Field
+	byName   map[string]models.Field
+	byID     map[string]models.Field
+	writable map[string]models.Field
+	defaults FieldDefaultContext
+	result   *dto.RecordImportData
+}
+
+// ImportRecords reads csv, json (an array of objects), ndjson or xlsx (the first sheet) from r
+// and creates a record per row. Columns map to fields by req.Mapping, else by field name or
+// ID; with CreateFields, a field is created for each unmatched column with a type inferred from its values. Values
+// are converted to the type of their field. Rows are read and inserted in chunks as r is
+// streamed, each row on its own, and the rows that fail are reported by line.
+func (s *RecordService) ImportRecords(req dto.RecordImportRequest, r io.Reader, userID string) (*dto.RecordImportData, error) {
+	format := strings.ToLower(strings.TrimSpace(req.Format))
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" && format != "ndjson" && format != "xlsx" {
+		return nil, errors.New("unsupported import format, only csv/json/ndjson/xlsx are supported")
+	}
+
+	if err := s.checkTableAccess(req.TableID, userID, []string{"owner", "admin", "editor"}); err != nil {
+		return nil, err
+	}
+	imp := &recordImport{
+		svc:    s,
+		req:    req,
+		userID: userID,
+		typed:  format != "csv",
+		result: &dto.RecordImportData{
+			TableID:       req.TableID,
+			Format:        format,
+			CreatedFields: []string{},
+			Errors:        []dto.RecordImportError{},
+		},
+	}
+	if err := imp.loadFields(); err != nil {
+		return nil, err
+	}
+	for column, name := range req.Mapping {
+		if _, ok := imp.byName[name]; name == "" || ok {
+			continue
+		}
+		if !req.CreateFields {
+			return nil, fmt.Errorf("column '%s' is mapped to field '%s', which does not exist", column, name)
+		}
+		if err := validateFieldName(name); err != nil {
+			return nil, fmt.Errorf("column '%s' is mapped to field '%s': %w", column, name, err)
+		}
+	}
+
+	var reader recordImportReader
+	var header []string
+	switch format {
+	case "csv":
+		csvReader, err := newCSVImportReader(r)
+		if err != nil {
+			return nil, err
+		}
+		reader, header = csvReader, csvReader.header
+	case "xlsx":
+		xlsxReader, err := newXLSXImportReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer xlsxReader.close()
+		reader, header = xlsxReader, xlsxReader.header
+	case "json":
+		jsonReader, err := newJSONImportReader(r)
+		if err != nil {
+			return nil, err
+		}
+		reader = jsonReader
+	default:
+		reader = &ndjsonImportReader{r: bufio.NewReader(r)}
+	}
+	imp.header = header
+	// The columns of a CSV file or a sheet are known up front, so a column that cannot be
+	// imported fails the import before any row is read.
+	for _, column := range header {
+		if column == "" {
+			continue
+		}
+		if err := imp.checkColumn(column); err != nil {
+			return nil, err
+		}
+	}
+
+	chunk := make([]importRow, 0, bulkInsertChunkSize)
+	var lineErr *importLineError
+	for {
+		row, err := reader.next()
+		if errors.Is(err, io.EOF) || errors.As(err, &lineErr) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		chunk = append(chunk, row)
+		if len(chunk) == bulkInsertChunkSize {
+			if err := imp.insertChunk(chunk); err != nil {
+				return nil, err
+			}
+			chunk = chunk[:0]
+		}
+	}
+	if err := imp.insertChunk(chunk); err != nil {
+		return nil, err
+	}
+	if lineErr != nil {
+		// The file cannot be read past this line; the rows before it are still imported.
+		imp.result.Total++
+		imp.fail(lineErr.line, lineErr.err)
+	}
+	return imp.result, nil
+}
+
+// InferImportFormat returns the import format of a file from its content type, else from the
+// extension of its name, defaulting to csv.
+func InferImportFormat(contentType, filename string) string {
+	switch contentType {
+	case xlsxContentType:
+		return "xlsx"
+	case "application/json":
+		return "json"
+	case "application/x-ndjson", "application/jsonl":
+		return "ndjson"
+	}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return "json"
+	case ".ndjson", ".jsonl":
+		return "ndjson"
+	case ".xlsx":
+		return "xlsx"
+	}
+	return "csv"
+}
+
+// loadFields reads the fields of the table and what the user may write to them.
+func (imp *recordImport) loadFields() error {
+	fields, err := imp.svc.getTableFields(imp.req.TableID)
+	if err != nil {
+		return err
+	}
+	_, writable, _, err := imp.svc.getFieldAccessMaps(fields, imp.userID)
+	if err != nil {
+		return err
+	}
+	imp.defaults = FieldDefaultContext{}
+	if hasFieldDefaults(fields) {
+		if imp.defaults, err = NewFieldDefaultContext(imp.svc.db, imp.userID); err != nil {
+			return err
+		}
+	}
+	imp.fields, imp.writable = fields, writable
+	imp.byName = make(map[string]models.Field, len(fields))
+	imp.byID = make(map[string]models.Field, len(fields))
+	for _, field := range fields {
+		imp.byName[field.Name] = field
+		imp.byID[field.ID] = field
+	}
+	return nil
+}
+
+// fieldName returns the name of the field a column maps to, "" for a skipped column, and
+// whether that field exists.
+func (imp *recordImport) fieldName(column string) (string, bool) {
+	if name, ok := imp.req.Mapping[column]; ok {
+		_, exists := imp.byName[name]
+		return name, exists || name == ""
+	}
+	if _, ok := imp.byName[column]; ok {
+		return column, true
+	}
+	if field, ok := imp.byID[column]; ok {
+		return field.Name, true
+	}
+	return column, false
+}
+
+// checkColumn rejects a column that matches no field and cannot get one.
+func (imp *recordImport) checkColumn(column string) error {
+	name, ok := imp.fieldName(column)
+	if ok {
+		return nil
+	}
+	if !imp.req.CreateFields {
+		return fmt.Errorf("column '%s' matches no field, map it to a field or create missing fields", column)
+	}
+	if err := validateFieldName(name); err != nil {
+		return fmt.Errorf("column '%s' cannot become a field, map it to a field name: %w", column, err)
+	}
+	return nil
+}
+
+// insertChunk creates the missing fields the rows of a chunk need, then inserts every row
+// that was read, converts and validates. Failures are reported in line order.
+func (imp *recordImport) insertChunk(rows []importRow) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	if imp.req.CreateFields {
+		if err := imp.createMissingFields(rows); err != nil {
+			return err
+		}
+	}
+
+	bulk := &dto.RecordBulkInsertData{Results: make([]dto.RecordBulkInsertResult, len(rows))}
+	pending := make([]*bulkRecord, 0, len(rows))
+	now := time.Now()
+	for i, row := range rows {
+		if row.err != nil {
+			bulk.Results[i] = dto.RecordBulkInsertResult{Status: bulkStatusFailed, Error: row.err.Error()}
+			continue
+		}
+		data, err := imp.rowData(row)
+		if err == nil {
+			data, err = imp.svc.prepareBulkRecord(imp.req.TableID, imp.fields, imp.writable, data, imp.defaults, now, imp.userID)
+		}
+		if err != nil {
+			bulk.Results[i] = dto.RecordBulkInsertResult{Status: bulkStatusFailed, Error: err.Error()}
+			continue
+		}
+		pending = append(pending, &bulkRecord{index: i, payload: data})
+	}
+	if err := imp.svc.insertBulkBestEffort(imp.req.TableID, imp.fields, pending, bulk); err != nil {
+		return err
+	}
+
+	imp.result.Total += len(rows)
+	for i, item := range bulk.Results {
+		if item.Status == bulkStatusCreated {
+			imp.result.Inserted++
+			continue
+		}
+		imp.fail(rows[i].line, errors.New(item.Error))
+	}
+	return nil
+}
+
+// createMissingFields creates a field for each column of rows that matches none, typed by
+// the values of the column in rows. Columns whose names cannot be field names are left to
+// fail the rows that use them.
+func (imp *recordImport) createMissingFields(rows []importRow) error {
+	var columns []string
+	values := make(map[string][]interface{})
+	for _, row := range rows {
+		for _, column := range imp.rowColumns(row) {
+			value := row.values[column]
+			name, ok := imp.fieldName(column)
+			if ok || validateFieldName(name) != nil {
+				continue
+			}
+			if _, seen := values[name]; !seen {
+				columns = append(columns, name)
+			}
+			values[name] = append(values[name], value)
+		}
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+
+	fieldService := NewFieldService(imp.svc.db)
+	for _, name := range columns {
+		req := dto.FieldCreateRequest{TableID: imp.req.TableID, Name: name, Type: inferImportFieldType(values[name], imp.typed)}
+		if _, err := fieldService.CreateField(req, imp.userID); err != nil {
+			return fmt.Errorf("failed to create field '%s': %w", name, err)
+		}
+		imp.result.CreatedFields = append(imp.result.CreatedFields, name)
+	}
+	return imp.loadFields()
+}
+
+// rowColumns returns the columns of a row in header order, or sorted without a header, so
+// fields are created in a stable order.
+func (imp *recordImport) rowColumns(row importRow) []string {
+	if imp.header != nil {
+		columns := make([]string, 0, len(row.values))
+		for _, column := range imp.header {
+			if _, ok := row.values[column]; ok {
+				columns = append(columns, column)
+			}
+		}
+		return columns
+	}
+	columns := make([]string, 0, len(row.values))
+	for column := range row.values {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// rowData maps the values of a row to fields and converts them to the field types. Values of
+// unmatched columns are kept under the column name, so the row fails as writing an unknown field.
+func (imp *recordImport) rowData(row importRow) (map[string]interface{}, error) {
+	data := make(map[string]interface{}, len(row.values))
+	for column, value := range row.values {
+		if name, _ := imp.fieldName(column); name != "" {
+			data[name] = value
+		}
+	}
+	for _, field := range imp.fields {
+		value, ok := data[field.Name]
+		if !ok || value == nil {
+			continue
+		}
+		switch field.Type {
+		case "string", "text", "number", "boolean", "date", "datetime", "list", "json":
+			converted, err := convertFieldValue(value, field.Type, "")
+			if err != nil {
+				return nil, fmt.Errorf("field '%s': %w", field.Name, err)
+			}
+			data[field.Name] = converted
+		}
+	}
+	return data, nil
+}
+
+// fail reports a row that was not imported.
+func (imp *recordImport) fail(line int, err error) {
+	imp.result.Failed++
+	if len(imp.result.Errors) == maxImportErrors {
+		imp.result.ErrorsTruncated = true
+		return
+	}
+	imp.result.Errors = append(imp.result.Errors, dto.RecordImportError{Line: line, Error: err.Error()})
+}
+
+// inferImportFieldType returns the field type that holds every value of a column. Strings
+// that read as dates become date or datetime fields; CSV cells, which are all strings, may
+// also become number or boolean fields. Columns of mixed types become string or, with a
+// value longer than maxImportStringLength, text fields.
+func inferImportFieldType(values []interface{}, typed bool) string {
+	kinds := make(map[string]bool)
+	for _, value := range values {
+		switch v := value.(type) {
+		case nil:
+		case bool:
+			kinds["boolean"] = true
+		case float64:
+			kinds["number"] = true
+		case map[string]interface{}, []interface{}:
+			kinds["json"] = true
+		case string:
+			if kind := inferImportStringType(v, typed); kind != "" {
+				kinds[kind] = true
+			}
+		default:
+			kinds["string"] = true
+		}
+	}
+
+	switch {
+	case len(kinds) == 0:
+		return "string"
+	case len(kinds) == 1:
+		for kind := range kinds {
+			return kind
+		}
+	case len(kinds) == 2 && kinds["date"] && kinds["datetime"]:
+		return "datetime"
+	case kinds["text"]:
+		return "text"
+	}
+	return "string"
+}
+
+// inferImportStringType returns the field type a string value reads as, "" for a blank one.
+func inferImportStringType(value string, typed bool) string {
+	text := strings.TrimSpace(value)
+	if text == "" {
+		return ""
+	}
+	if !typed {
+		if _, err := strconv.ParseFloat(text, 64); err == nil {
+			return "number"
+		}
+		if lower := strings.ToLower(text); lower == "true" || lower == "false" {
+			return "boolean"
+		}
+	}
+	if _, err := time.Parse("2006-01-02", text); err == nil {
+		return "date"
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05"} {
+		if _, err := time.Parse(layout, text); err == nil {
+			return "datetime"
+		}
+	}
+	if utf8.RuneCountInString(value) > maxImportStringLength {
+		return "text"
+	}
+	return "string"
+}
+
+// csvImportReader reads the rows of a CSV file whose first line names the columns. Empty
+// cells are left out of a row, so the field keeps its default.
+type csvImportReader struct {
+	r      *csv.Reader
+	header []string
+	line   int // line of the last row read
+}
+
+func newCSVImportReader(r io.Reader) (*csvImportReader, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("the CSV file has no header row")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+	header[0] = strings.TrimPrefix(header[0], "\ufeff") // byte order mark
+	if header, err = importHeader(header); err != nil {
+		return nil, err
+	}
+	for i, column := range header {
+		if column == "" {
+			return nil, fmt.Errorf("column %d of the header has no name", i+1)
+		}
+	}
+	return &csvImportReader{r: reader, header: header}, nil
+}
+
+// importHeader trims the column names of a header row and rejects a name used twice.
+func importHeader(header []string) ([]string, error) {
+	seen := make(map[string]bool, len(header))
+	for i, column := range header {
+		column = strings.TrimSpace(column)
+		if seen[column] && column != "" {
+			return nil, fmt.Errorf("column '%s' appears twice in the header", column)
+		}
+		seen[column] = true
+		header[i] = column
+	}
+	return header, nil
+}
+
+func (c *csvImportReader) next() (importRow, error) {
+	cells, err := c.r.Read()
+	if errors.Is(err, io.EOF) {
+		return importRow{}, io.EOF
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		if errors.Is(parseErr.Err, csv.ErrFieldCount) {
+			err = fmt.Errorf("row has %d cells, the header has %d", len(cells), len(c.header))
+		} else {
+			err = parseErr.Err
+		}
+		c.line = parseErr.StartLine
+		return importRow{line: parseErr.StartLine, err: err}, nil
+	}
+	if err != nil {
+		return importRow{}, &importLineError{line: c.line + 1, err: fmt.Errorf("failed to read CSV: %w", err)}
+	}
+
+	c.line, _ = c.r.FieldPos(0)
+	values := make(map[string]interface{}, len(cells))
+	for i, cell := range cells {
+		if cell != "" {
+			values[c.header[i]] = cell
+		}
+	}
+	return importRow{line: c.line, values: values}, nil
+}
+
+// xlsxImportReader reads the rows of the first sheet of a workbook whose first row names the
+// columns. Columns without a name are skipped, as long as they hold no values.
+type xlsxImportReader struct {
+	rows    *xlsx.Rows
+	header  []string
+	line    int // row number of the last row read
+	cleanup func()
+}
+
+func newXLSXImportReader(r io.Reader) (*xlsxImportReader, error) {
+	file, size, cleanup, err := importReaderAt(r)
+	if err != nil {
+		return nil, err
+	}
+	reader := &xlsxImportReader{cleanup: cleanup}
+	if err := reader.open(file, size); err != nil {
+		reader.close()
+		return nil, err
+	}
+	return reader, nil
+}
+
+func (x *xlsxImportReader) open(file io.ReaderAt, size int64) error {
+	workbook, err := xlsx.NewReader(file, size)
+	if err != nil {
+		return err
+	}
+	if len(workbook.SheetNames()) == 0 {
+		return errors.New("the workbook has no sheets")
+	}
+	if x.rows, err = workbook.Rows(0); err != nil {
+		return err
+	}
+	number, cells, err := x.rows.Next()
+	if errors.Is(err, io.EOF) {
+		return errors.New("the sheet has no header row")
+	}
+	if err != nil {
+		return err
+	}
+	x.line = number
+	header := make([]string, len(cells))
+	for i, cell := range cells {
+		if cell != nil {
+			header[i] = fmt.Sprint(xlsxImportValue(cell))
+		}
+	}
+	x.header, err = importHeader(header)
+	return err
+}
+
+func (x *xlsxImportReader) next() (importRow, error) {
+	number, cells, err := x.rows.Next()
+	if errors.Is(err, io.EOF) {
+		return importRow{}, io.EOF
+	}
+	if err != nil {
+		return importRow{}, &importLineError{line: x.line + 1, err: err}
+	}
+	x.line = number
+	values := make(map[string]interface{}, len(cells))
+	for i, cell := range cells {
+		if cell == nil {
+			continue
+		}
+		if i >= len(x.header) || x.header[i] == "" {
+			return importRow{line: number, err: fmt.Errorf("cell %s is in a column without a name", xlsx.CellRef(i, number))}, nil
+		}
+		values[x.header[i]] = xlsxImportValue(cell)
+	}
+	return importRow{line: number, values: values}, nil
+}
+
+// close closes the sheet and removes the workbook's temporary file, if any.
+func (x *xlsxImportReader) close() {
+	if x.rows != nil {
+		_ = x.rows.Close()
+	}
+	x.cleanup()
+}
+
+// xlsxImportValue returns a cell value as an import value: dates and times become the strings
+// date and datetime fields store.
+func xlsxImportValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case xlsx.Date:
+		return v.Format("2006-01-02")
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	}
+	return value
+}
+
+// importReaderAt returns r as an io.ReaderAt with its size, which reading a zip archive needs.
+// Unless r is a regular file or in memory, it is copied to a temporary file that cleanup removes.
+func importReaderAt(r io.Reader) (io.ReaderAt, int64, func(), error) {
+	switch v := r.(type) {
+	case interface {
+		io.ReaderAt
+		Size() int64
+	}:
+		return v, v.Size(), func() {}, nil
+	case *os.File:
+		if info, err := v.Stat(); err == nil && info.Mode().IsRegular() {
+			return v, info.Size(), func() {}, nil
+		}
+	}
+	file, err := os.CreateTemp("", "cornerstone-import-*.xlsx")
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to buffer workbook: %w", err)
+	}
+	cleanup := func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}
+	size, err := io.Copy(file, r)
+	if err != nil {
+		cleanup()
+		return nil, 0, nil, fmt.Errorf("failed to read workbook: %w", err)
+	}
+	return file, size, cleanup, nil
+}
+
+// jsonImportReader reads the objects of a JSON array one at a time.
+type jsonImportReader struct {
+	dec   *stdjson.Decoder
+	lines *lineTracker
+	done  bool
+}
+
+func newJSONImportReader(r io.Reader) (*jsonImportReader, error) {
+	lines := &lineTracker{r: r}
+	dec := stdjson.NewDecoder(lines)
+	token, err := dec.Token()
+	if delim, ok := token.(stdjson.Delim); err != nil || !ok || delim != '[' {
+		return nil, errors.New("a JSON import must be an array of objects")
+	}
+	return &jsonImportReader{dec: dec, lines: lines}, nil
+}
+
+func (j *jsonImportReader) next() (importRow, error) {
+	if j.done {
+		return importRow{}, io.EOF
+	}
+	if !j.dec.More() {
+		j.done = true
+		if _, err := j.dec.Token(); err != nil {
+			return importRow{}, &importLineError{line: j.lines.line(j.dec.InputOffset()), err: fmt.Errorf("invalid JSON: %w", err)}
+		}
+		return importRow{}, io.EOF
+	}
+
+	var raw stdjson.RawMessage
+	if err := j.dec.Decode(&raw); err != nil {
+		j.done = true
+		offset := j.dec.InputOffset()
+		var syntaxErr *stdjson.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			offset = syntaxErr.Offset
+		}
+		return importRow{}, &importLineError{line: j.lines.line(offset), err: fmt.Errorf("invalid JSON: %w", err)}
+	}
+	line := j.lines.line(j.dec.InputOffset() - int64(len(raw)))
+	return parseImportObject(line, raw), nil
+}
+
+// ndjsonImportReader reads one JSON object per line, skipping blank lines.
+type ndjsonImportReader struct {
+	r    *bufio.Reader
+	line int
+}
+
+func (n *ndjsonImportReader) next() (importRow, error) {
+	for {
+		raw, err := n.r.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return importRow{}, &importLineError{line: n.line + 1, err: fmt.Errorf("failed to read NDJSON: %w", err)}
+		}
+		if len(raw) == 0 && err != nil {
+			return importRow{}, io.EOF
+		}
+		n.line++
+		if raw = bytes.TrimSpace(raw); len(raw) > 0 {
+			return parseImportObject(n.line, raw), nil
+		}
+	}
+}
+
+// parseImportObject reads a JSON object as the row at line.
+func parseImportObject(line int, raw []byte) importRow {
+	var values map[string]interface{}
+	if err := json.Unmarshal(raw, &values); err != nil || values == nil {
+		return importRow{line: line, err: errors.New("row is not a JSON object")}
+	}
+	return importRow{line: line, values: values}
+}
+
+// lineTracker passes reads through and maps byte offsets of what was read to line numbers.
+// Only the newlines past the last offset asked for are kept.
+type lineTracker struct {
+	r        io.Reader
+	read     int64
+	passed   int     // newlines before the last offset asked for
+	newlines []int64 // offsets of the newlines after it
+}
+
+func (t *lineTracker) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	for i, b := range p[:n] {
+		if b == '\n' {
+			t.newlines = append(t.newlines, t.read+int64(i))
+		}
+	}
+	t.read += int64(n)
+	return n, err
+}
+
+// line returns the 1-based line of a byte offset. Offsets must not decrease between calls.
+func (t *lineTracker) line(offset int64) int {
+	i := 0
+	for i < len(t.newlines) && t.newlines[i] < offset {
+		i++
+	}
+	t.passed += i
+	t.newlines = t.newlines[i:]
+	return t.passed + 1
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/dto"
+	"github.com/jiangfire/cornerstone/pkg/xlsx"
+)
+
+// importedRecords returns the data of the records of a table, by name.
+func importedRecords(t *testing.T, svc *RecordService, tableID, userID string) map[string]map[string]interface{} {
+	t.Helper()
+	list, err := svc.ListRecords(dto.RecordListQueryRequest{TableID: tableID, Limit: 100}, userID)
+	require.NoError(t, err)
+	records := make(map[string]map[string]interface{})
+	for _, record := range list.Records {
+		data := record.Data.(map[string]interface{})
+		name, _ := data["name"].(string)
+		records[name] = data
+	}
+	return records
+}
+
+func TestImportRecords_CSV(t *testing.T) {
+	_, table, master, _, svc := setupFormulaTestEnv(t)
+
+	csvData := "\ufeffname, price ,quantity\n" +
+		"apple,1.5,\n" +
+		"\"banana\nripe\",abc,2\n" +
+		"cherry,2,1,extra\n" +
+		"\n" +
+		",3,4\n"
+	result, err := svc.ImportRecords(dto.RecordImportRequest{TableID: table.ID}, strings.NewReader(csvData), master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "csv", result.Format)
+	assert.Equal(t, 4, result.Total)
+	assert.Equal(t, 2, result.Inserted)
+	assert.Equal(t, 2, result.Failed)
+	assert.Equal(t, []dto.RecordImportError{
+		{Line: 3, Error: "field 'price': not a number"},
+		{Line: 5, Error: "row has 4 cells, the header has 3"},
+	}, result.Errors)
+
+	// Values are converted to the field types; empty cells are left out.
+	records := importedRecords(t, svc, table.ID, master.ID)
+	assert.Equal(t, map[string]interface{}{"name": "apple", "price": 1.5}, records["apple"])
+	assert.Equal(t, map[string]interface{}{"price": float64(3), "quantity": float64(4)}, records[""])
+
+	// A mapping renames or skips columns.
+	result, err = svc.ImportRecords(dto.RecordImportRequest{
+		TableID: table.ID,
+		Mapping: map[string]string{"Product": "name", "Notes": ""},
+	}, strings.NewReader("Product,Notes,price\nplum,ignored,7\n"), master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Inserted)
+	assert.Equal(t, map[string]interface{}{"name": "plum", "price": float64(7)}, importedRecords(t, svc, table.ID, master.ID)["plum"])
+
+	for _, tt := range []struct {
+		req  dto.RecordImportRequest
+		data string
+		want string
+	}{
+		{dto.RecordImportRequest{}, "name,color\nfig,red\n", "column 'color' matches no field"},
+		{dto.RecordImportRequest{CreateFields: true}, "name,Unit Price\nfig,1\n", "column 'Unit Price' cannot become a field"},
+		{dto.RecordImportRequest{Mapping: map[string]string{"a": "missing"}}, "a\n1\n", "mapped to field 'missing', which does not exist"},
+		{dto.RecordImportRequest{}, "name,name\nfig,fig\n", "appears twice"},
+		{dto.RecordImportRequest{}, "", "no header row"},
+		{dto.RecordImportRequest{Format: "xml"}, "", "unsupported import format"},
+	} {
+		tt.req.TableID = table.ID
+		_, err := svc.ImportRecords(tt.req, strings.NewReader(tt.data), master.ID)
+		require.Error(t, err, tt.want)
+		assert.Contains(t, err.Error(), tt.want)
+	}
+	assert.Len(t, importedRecords(t, svc, table.ID, master.ID), 3)
+}
+
+func TestImportRecords_JSONAndNDJSON(t *testing.T) {
+	_, table, master, _, svc := setupFormulaTestEnv(t)
+
+	jsonData := `[
+  {"name": "apple", "price": "2"},
+  {
+    "name": "banana",
+    "price": true
+  },
+  "plain",
+  {"name": "cherry", "color": "red"},
+  {"name": "date", "quantity": null}
+]`
+	result, err := svc.ImportRecords(dto.RecordImportRequest{TableID: table.ID, Format: "JSON"}, strings.NewReader(jsonData), master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.Total)
+	assert.Equal(t, 3, result.Inserted)
+	assert.Equal(t, []dto.RecordImportError{
+		{Line: 7, Error: "row is not a JSON object"},
+		{Line: 8, Error: "field 'color' does not exist"},
+	}, result.Errors)
+	records := importedRecords(t, svc, table.ID, master.ID)
+	assert.Equal(t, float64(2), records["apple"]["price"])
+	assert.Equal(t, float64(1), records["banana"]["price"])
+
+	ndjsonData := "{\"name\": \"elder\"}\n\n{\"name\": \"fig\", \"price\": \"x\"}\n{not json}\n{\"name\": \"grape\"}"
+	result, err = svc.ImportRecords(dto.RecordImportRequest{TableID: table.ID, Format: "ndjson"}, strings.NewReader(ndjsonData), master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, result.Total)
+	assert.Equal(t, 2, result.Inserted)
+	assert.Equal(t, []dto.RecordImportError{
+		{Line: 3, Error: "field 'price': not a number"},
+		{Line: 4, Error: "row is not a JSON object"},
+	}, result.Errors)
+
+	// A syntax error ends a JSON import; the rows before it are imported.
+	result, err = svc.ImportRecords(dto.RecordImportRequest{TableID: table.ID, Format: "json"},
+		strings.NewReader("[\n{\"name\": \"honeydew\"},\n{\"name\": }\n,{\"name\": \"kiwi\"}]"), master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Total)
+	assert.Equal(t, 1, result.Inserted)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, 3, result.Errors[0].Line)
+	assert.Contains(t, result.Errors[0].Error, "invalid JSON")
+
+	_, err = svc.ImportRecords(dto.RecordImportRequest{TableID: table.ID, Format: "json"}, strings.NewReader(`{"name": "x"}`), master.ID)
+	assert.ErrorContains(t, err, "must be an array of objects")
+}
+
+func TestImportRecords_CreateFields(t *testing.T) {
+	_, table, master, _, svc := setupFormulaTestEnv(t)
+	long := strings.Repeat("x", maxImportStringLength+1)
+
+	csvData := "name,in_stock,due,seen,note,code,blank\n" +
+		"a,true,2024-01-02,2024-01-02 10:00:00,short,7,\n" +
+		"b,false,2024-02-03,2024-02-03," + long + ",x1,\n"
+	result, err := svc.ImportRecords(dto.RecordImportRequest{
+		TableID:      table.ID,
+		Mapping:      map[string]string{"code": "sku"},
+		CreateFields: true,
+	}, strings.NewReader(csvData), master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Inserted, result.Errors)
+	assert.Equal(t, []string{"in_stock", "due", "seen", "note", "sku"}, result.CreatedFields)
+
+	// JSON values keep their types; strings are not read as numbers.
+	result, err = svc.ImportRecords(dto.RecordImportRequest{TableID: table.ID, Format: "ndjson", CreateFields: true},
+		strings.NewReader("{\"name\": \"c\", \"meta\": {\"k\": 1}, \"ref\": \"42\", \"score\": 1.5}\n"), master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Inserted, result.Errors)
+
+	fields, err := svc.getTableFields(table.ID)
+	require.NoError(t, err)
+	types := make(map[string]string)
+	for _, field := range fields {
+		types[field.Name] = field.Type
+	}
+	assert.Equal(t, map[string]string{
+		"price": "number", "quantity": "number", "name": "string",
+		"in_stock": "boolean", "due": "date", "seen": "datetime", "note": "text", "sku": "string",
+		"meta": "json", "ref": "string", "score": "number",
+	}, types)
+
+	records := importedRecords(t, svc, table.ID, master.ID)
+	assert.Equal(t, true, records["a"]["in_stock"])
+	assert.Equal(t, "2024-01-02", records["a"]["due"])
+	assert.Equal(t, "2024-01-02T10:00:00Z", records["a"]["seen"])
+	assert.Equal(t, "7", records["a"]["sku"])
+	assert.Equal(t, map[string]interface{}{"k": float64(1)}, records["c"]["meta"])
+}
+
+func TestImportRecords_Chunks(t *testing.T) {
+	db, table, master, _, svc := setupFormulaTestEnv(t)
+
+	// Rows span two chunks; every other one fails, more than the errors reported.
+	var csvData strings.Builder
+	csvData.WriteString("name,price\n")
+	rows := bulkInsertChunkSize + 50
+	for i := 0; i < rows; i++ {
+		price := "bad"
+		if i%2 == 0 {
+			price = fmt.Sprint(i)
+		}
+		fmt.Fprintf(&csvData, "r%d,%s\n", i, price)
+	}
+	result, err := svc.ImportRecords(dto.RecordImportRequest{TableID: table.ID}, strings.NewReader(csvData.String()), master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, rows, result.Total)
+	assert.Equal(t, rows/2, result.Inserted)
+	assert.Equal(t, rows/2, result.Failed)
+	assert.Len(t, result.Errors, maxImportErrors)
+	assert.True(t, result.ErrorsTruncated)
+	assert.Equal(t, 3, result.Errors[0].Line)
+	assert.Equal(t, 2*maxImportErrors+1, result.Errors[maxImportErrors-1].Line)
+
+	var count int64
+	require.NoError(t, db.Model(&models.Record{}).Where("table_id = ?", table.ID).Count(&count).Error)
+	assert.Equal(t, int64(rows/2), count)
+}
+
+func TestImportRecords_XLSX(t *testing.T) {
+	_, table, master, _, svc := setupFormulaTestEnv(t)
+
+	var buf bytes.Buffer
+	workbook := xlsx.NewWriter(&buf)
+	require.NoError(t, workbook.AddSheet("Items"))
+	require.NoError(t, workbook.WriteRow("name", "price", "paid", "due", "seen", nil, "code"))
+	require.NoError(t, workbook.WriteRow("apple", 1.5, true, xlsx.Date{Time: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)},
+		time.Date(2024, 3, 5, 14, 30, 15, 0, time.UTC), nil, 42))
+	require.NoError(t, workbook.WriteRow("banana", "2", false))
+	require.NoError(t, workbook.WriteRow())
+	require.NoError(t, workbook.WriteRow("cherry", "x", nil, nil, nil, "stray"))
+	require.NoError(t, workbook.WriteRow("date"))
+	require.NoError(t, workbook.AddSheet("Ignored"))
+	require.NoError(t, workbook.WriteRow("name"))
+	require.NoError(t, workbook.WriteRow("ignored"))
+	require.NoError(t, workbook.Close())
+
+	// A reader without ReadAt, like a request body, is buffered to a temporary file.
+	result, err := svc.ImportRecords(dto.RecordImportRequest{TableID: table.ID, Format: "xlsx", CreateFields: true},
+		io.MultiReader(bytes.NewReader(buf.Bytes())), master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, result.Total)
+	assert.Equal(t, 3, result.Inserted)
+	assert.Equal(t, []string{"paid", "due", "seen", "code"}, result.CreatedFields)
+	assert.Equal(t, []dto.RecordImportError{{Line: 5, Error: "cell F5 is in a column without a name"}}, result.Errors)
+
+	records := importedRecords(t, svc, table.ID, master.ID)
+	assert.Len(t, records, 3)
+	assert.Equal(t, 1.5, records["apple"]["price"])
+	assert.Equal(t, true, records["apple"]["paid"])
+	assert.Equal(t, "2024-03-05", records["apple"]["due"])
+	assert.Equal(t, "2024-03-05T14:30:15Z", records["apple"]["seen"])
+	assert.Equal(t, float64(42), records["apple"]["code"])
+	assert.Equal(t, float64(2), records["banana"]["price"])
+	assert.Contains(t, records, "date")
+
+	// A record export imports back into the same table.
+	data, _, _, err := exportRecordsBytes(svc, table.ID, master.ID, "xlsx", "", "")
+	require.NoError(t, err)
+	result, err = svc.ImportRecords(dto.RecordImportRequest{TableID: table.ID, Format: "xlsx", Mapping: map[string]string{"id": "", "version": ""}},
+		bytes.NewReader(data), master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Inserted, result.Errors)
+
+	_, err = svc.ImportRecords(dto.RecordImportRequest{TableID: table.ID, Format: "xlsx"}, strings.NewReader("name\nfig\n"), master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not an xlsx workbook")
+}
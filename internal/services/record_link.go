@@ -0,0 +1,315 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/dto"
+	"gorm.io/gorm"
+)
+
+// parseLinkValue normalizes a link field value into the referenced record IDs.
+// A single link is stored as a record ID string, a multiple link as an array of IDs.
+func parseLinkValue(value interface{}) ([]string, error) {
+	switch values := value.(type) {
+	case nil:
+		return []string{}, nil
+	case string:
+		if strings.TrimSpace(values) == "" {
+			return []string{}, nil
+		}
+		return []string{strings.TrimSpace(values)}, nil
+	case []string:
+		items := make([]string, 0, len(values))
+		for _, item := range values {
+			trimmed := strings.TrimSpace(item)
+			if trimmed != "" {
+				items = append(items, trimmed)
+			}
+		}
+		return items, nil
+	case []interface{}:
+		items := make([]string, 0, len(values))
+		for _, item := range values {
+			str, ok := item.(string)
+			if !ok {
+				return nil, errors.New("link value must be a record ID or array of record IDs")
+			}
+			trimmed := strings.TrimSpace(str)
+			if trimmed != "" {
+				items = append(items, trimmed)
+			}
+		}
+		return items, nil
+	default:
+		return nil, errors.New("link value must be a record ID or array of record IDs")
+	}
+}
+
+func validateLinkCardinality(config dto.FieldConfig, recordIDs []string) error {
+	if !config.Multiple && len(recordIDs) > 1 {
+		return errors.New("this link field only allows a single record")
+	}
+
+	seen := make(map[string]struct{}, len(recordIDs))
+	for _, recordID := range recordIDs {
+		if _, exists := seen[recordID]; exists {
+			return fmt.Errorf("duplicate linked record ID: %s", recordID)
+		}
+		seen[recordID] = struct{}{}
+	}
+	return nil
+}
+
+// validateLinkFieldValue checks that every referenced record exists in the target table
+// and that the target table and the records are readable by the user. A record outside
+// their row filter is reported like a missing one.
+func (s *RecordService) validateLinkFieldValue(config dto.FieldConfig, value interface{}, userID string) error {
+	recordIDs, err := parseLinkValue(value)
+	if err != nil {
+		return err
+	}
+	if err := validateLinkCardinality(config, recordIDs); err != nil {
+		return err
+	}
+	if len(recordIDs) == 0 {
+		return nil
+	}
+
+	if err := s.checkTableAccess(config.LinkTableID, userID, []string{"viewer"}); err != nil {
+		return fmt.Errorf("link target: %w", err)
+	}
+
+	var existing []models.Record
+	if err := s.db.Where("table_id = ? AND id IN ? AND deleted_at IS NULL", config.LinkTableID, recordIDs).
+		Find(&existing).Error; err != nil {
+		return fmt.Errorf("failed to query linked records: %w", err)
+	}
+	targetFields, err := s.getTableFields(config.LinkTableID)
+	if err != nil {
+		return err
+	}
+
+	found := make(map[string]struct{}, len(existing))
+	for _, record := range existing {
+		if s.checkRecordRow(record, targetFields, userID) == nil {
+			found[record.ID] = struct{}{}
+		}
+	}
+	for _, recordID := range recordIDs {
+		if _, ok := found[recordID]; !ok {
+			return fmt.Errorf("linked record not found: %s", recordID)
+		}
+	}
+	return nil
+}
+
+// findLinkFieldsTargeting returns the active link fields whose target is tableID.
+func findLinkFieldsTargeting(tx *gorm.DB, tableID string) ([]models.Field, error) {
+	var candidates []models.Field
+	if err := tx.Where("type = ? AND deleted_at IS NULL", "link").Find(&candidates).Error; err != nil {
+		return nil, fmt.Errorf("failed to query link fields: %w", err)
+	}
+
+	fields := make([]models.Field, 0, len(candidates))
+	for _, field := range candidates {
+		if parseStoredFieldConfig(field.Options).LinkTableID == tableID {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+// buildLinkFieldIndexRows indexes a link value by each record it references, so the records
+// linking to a record are found through the index.
+func buildLinkFieldIndexRows(tableID, recordID string, field models.Field, value interface{}) []models.RecordFieldIndex {
+	linked, err := parseLinkValue(value)
+	if err != nil {
+		return nil
+	}
+	rows := make([]models.RecordFieldIndex, 0, len(linked))
+	for _, id := range linked {
+		rows = append(rows, models.RecordFieldIndex{
+			TableID:   tableID,
+			RecordID:  recordID,
+			FieldID:   field.ID,
+			FieldName: field.Name,
+			ValueType: "text",
+			ValueText: id,
+		})
+	}
+	return rows
+}
+
+// findRecordsLinkingTo returns the active records whose link field references recordID, looked
+// up in the field indexes of the link field.
+func findRecordsLinkingTo(tx *gorm.DB, field models.Field, recordID string) ([]models.Record, error) {
+	linking := tx.Model(&models.RecordFieldIndex{}).
+		Select("record_id").
+		Where("table_id = ? AND field_id = ? AND value_text = ? AND deleted_at IS NULL", field.TableID, field.ID, recordID)
+
+	var records []models.Record
+	if err := tx.Where("table_id = ? AND deleted_at IS NULL AND id IN (?)", field.TableID, linking).
+		Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to query linking records: %w", err)
+	}
+	return records, nil
+}
+
+// removeLinkedRecordID drops recordID from a link value, returning nil when nothing is left.
+func removeLinkedRecordID(value interface{}, recordID string) interface{} {
+	linked, err := parseLinkValue(value)
+	if err != nil {
+		return value
+	}
+	remaining := make([]string, 0, len(linked))
+	for _, id := range linked {
+		if id != recordID {
+			remaining = append(remaining, id)
+		}
+	}
+	if len(remaining) == 0 {
+		return nil
+	}
+	if _, single := value.(string); single {
+		return remaining[0]
+	}
+	return remaining
+}
+
+// deleteRecordTx soft-deletes a record and applies the on_delete behavior of link fields referencing it.
+// visited guards against cycles when cascading through self- or mutually-referencing tables.
+func (s *RecordService) deleteRecordTx(tx *gorm.DB, record models.Record, userID string, now time.Time, visited map[string]struct{}) error {
+	visited[record.ID] = struct{}{}
+
+	result := tx.Model(&models.Record{}).
+		Where("id = ? AND deleted_at IS NULL", record.ID).
+		Updates(map[string]interface{}{
+			"deleted_at": now,
+			"updated_at": now,
+			"version":    gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete record: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("record not found: %w", gorm.ErrRecordNotFound)
+	}
+	if err := tx.Model(&models.RecordFieldIndex{}).
+		Where("record_id = ? AND deleted_at IS NULL", record.ID).
+		Update("deleted_at", now).Error; err != nil {
+		return fmt.Errorf("failed to delete record field indexes: %w", err)
+	}
+	if err := tx.Where("record_id = ?", record.ID).Delete(&models.RecordSearchDocument{}).Error; err != nil {
+		return fmt.Errorf("failed to delete record search documents: %w", err)
+	}
+	if err := releaseRecordUniqueKeys(tx, record.ID); err != nil {
+		return err
+	}
+
+	return s.applyLinkOnDelete(tx, record, userID, now, visited)
+}
+
+func (s *RecordService) applyLinkOnDelete(tx *gorm.DB, record models.Record, userID string, now time.Time, visited map[string]struct{}) error {
+	linkFields, err := findLinkFieldsTargeting(tx, record.TableID)
+	if err != nil {
+		return err
+	}
+
+	// Permission and field lookups must run on the transaction: SQLite in-memory has a single connection.
+	txService := NewRecordService(tx)
+	for _, field := range linkFields {
+		referencing, err := findRecordsLinkingTo(tx, field, record.ID)
+		if err != nil {
+			return err
+		}
+		if len(referencing) == 0 {
+			continue
+		}
+
+		switch parseStoredFieldConfig(field.Options).OnDelete {
+		case linkOnDeleteCascade:
+			if err := txService.checkTableAccess(field.TableID, userID, []string{"owner", "admin"}); err != nil {
+				return fmt.Errorf("cascade delete via field '%s': %w", field.Name, err)
+			}
+			tableFields, err := txService.getTableFields(field.TableID)
+			if err != nil {
+				return err
+			}
+			if err := txService.checkReferencingRows(field, tableFields, referencing, userID); err != nil {
+				return err
+			}
+			for _, linked := range referencing {
+				if _, done := visited[linked.ID]; done {
+					continue
+				}
+				if err := s.deleteRecordTx(tx, linked, userID, now, visited); err != nil {
+					return err
+				}
+			}
+
+		case linkOnDeleteSetNull:
+			if err := txService.checkTableAccess(field.TableID, userID, []string{"owner", "admin", "editor"}); err != nil {
+				return fmt.Errorf("clear link via field '%s': %w", field.Name, err)
+			}
+			tableFields, err := txService.getTableFields(field.TableID)
+			if err != nil {
+				return err
+			}
+			if err := txService.checkReferencingRows(field, tableFields, referencing, userID); err != nil {
+				return err
+			}
+			for _, linked := range referencing {
+				if err := txService.clearLinkReference(tx, linked, field, tableFields, record.ID, now); err != nil {
+					return err
+				}
+			}
+
+		default:
+			return fmt.Errorf("record is still referenced by field '%s' in table %s (on_delete: restrict)", field.Name, field.TableID)
+		}
+	}
+
+	return nil
+}
+
+// checkReferencingRows fails a delete as restrict when a record on_delete would change through
+// field is outside the row filter of the user.
+func (s *RecordService) checkReferencingRows(field models.Field, tableFields []models.Field, referencing []models.Record, userID string) error {
+	for _, linked := range referencing {
+		if err := s.checkRecordRow(linked, tableFields, userID); err != nil {
+			return fmt.Errorf("record is still referenced by field '%s' in table %s (on_delete: restrict)", field.Name, field.TableID)
+		}
+	}
+	return nil
+}
+
+func (s *RecordService) clearLinkReference(tx *gorm.DB, record models.Record, field models.Field, tableFields []models.Field, deletedID string, now time.Time) error {
+	payload := parseRecordPayload(record.Data)
+	payload[field.Name] = removeLinkedRecordID(payload[field.Name], deletedID)
+	if field.Required && payload[field.Name] == nil {
+		return fmt.Errorf("record is still referenced by required field '%s' in table %s, cannot clear it", field.Name, field.TableID)
+	}
+	applyFormulaValues(tableFields, payload, now)
+
+	dataJSON, err := marshalRecordPayload(payload)
+	if err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Record{}).
+		Where("id = ? AND deleted_at IS NULL", record.ID).
+		Updates(map[string]interface{}{
+			"data":       dataJSON,
+			"updated_at": now,
+			"version":    gorm.Expr("version + 1"),
+		}).Error; err != nil {
+		return fmt.Errorf("failed to clear link reference: %w", err)
+	}
+	if err := s.syncRecordFieldIndexes(tx, record.ID, record.TableID, tableFields, payload); err != nil {
+		return err
+	}
+	return syncRecordUniqueKeys(tx, record.TableID, record.ID, tableFields, payload)
+}
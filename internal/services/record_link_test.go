@@ -0,0 +1,340 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/dto"
+)
+
+type linkTestEnv struct {
+	db        *gorm.DB
+	records   *RecordService
+	fields    *FieldService
+	master    *models.Token
+	customers *models.Table
+	orders    *models.Table
+	customer  *models.Record
+}
+
+func setupLinkTestEnv(t *testing.T, config dto.FieldConfig) linkTestEnv {
+	t.Helper()
+	db, database, customers, master := setupCrudTestEnv(t)
+	SharedFieldCache.Clear()
+
+	orders := &models.Table{DatabaseID: database.ID, Name: "orders"}
+	require.NoError(t, db.Create(orders).Error)
+
+	fieldSvc := NewFieldService(db)
+	_, err := fieldSvc.CreateField(dto.FieldCreateRequest{TableID: customers.ID, Name: "name", Type: "string"}, master.ID)
+	require.NoError(t, err)
+	_, err = fieldSvc.CreateField(dto.FieldCreateRequest{TableID: orders.ID, Name: "title", Type: "string"}, master.ID)
+	require.NoError(t, err)
+
+	config.LinkTableID = customers.ID
+	_, err = fieldSvc.CreateField(dto.FieldCreateRequest{TableID: orders.ID, Name: "customer", Type: "link", Config: config}, master.ID)
+	require.NoError(t, err)
+
+	recordSvc := NewRecordService(db)
+	customer, err := recordSvc.CreateRecord(dto.RecordCreateRequest{
+		TableID: customers.ID,
+		Data:    map[string]interface{}{"name": "Acme"},
+	}, master.ID)
+	require.NoError(t, err)
+
+	return linkTestEnv{
+		db:        db,
+		records:   recordSvc,
+		fields:    fieldSvc,
+		master:    master,
+		customers: customers,
+		orders:    orders,
+		customer:  customer,
+	}
+}
+
+func (env linkTestEnv) createOrder(t *testing.T, customer interface{}) *models.Record {
+	t.Helper()
+	order, err := env.records.CreateRecord(dto.RecordCreateRequest{
+		TableID: env.orders.ID,
+		Data:    map[string]interface{}{"title": "order", "customer": customer},
+	}, env.master.ID)
+	require.NoError(t, err)
+	return order
+}
+
+func TestCreateField_LinkConfig(t *testing.T) {
+	db, _, table, master := setupCrudTestEnv(t)
+	svc := NewFieldService(db)
+
+	t.Run("requires target table", func(t *testing.T) {
+		_, err := svc.CreateField(dto.FieldCreateRequest{TableID: table.ID, Name: "owner", Type: "link"}, master.ID)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "link field requires link_table_id")
+	})
+
+	t.Run("unknown target table", func(t *testing.T) {
+		_, err := svc.CreateField(dto.FieldCreateRequest{
+			TableID: table.ID, Name: "owner", Type: "link",
+			Config: dto.FieldConfig{LinkTableID: "tbl_missing"},
+		}, master.ID)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "table not found")
+	})
+
+	t.Run("invalid on_delete", func(t *testing.T) {
+		_, err := svc.CreateField(dto.FieldCreateRequest{
+			TableID: table.ID, Name: "owner", Type: "link",
+			Config: dto.FieldConfig{LinkTableID: table.ID, OnDelete: "explode"},
+		}, master.ID)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid on_delete behavior")
+	})
+
+	t.Run("resolves table name and defaults to restrict", func(t *testing.T) {
+		field, err := svc.CreateField(dto.FieldCreateRequest{
+			TableID: table.ID, Name: "parent", Type: "link",
+			Config: dto.FieldConfig{LinkTableID: table.Name},
+		}, master.ID)
+		require.NoError(t, err)
+		config := parseStoredFieldConfig(field.Options)
+		assert.Equal(t, table.ID, config.LinkTableID)
+		assert.Equal(t, linkOnDeleteRestrict, config.OnDelete)
+	})
+
+	t.Run("accepts set-null spelling", func(t *testing.T) {
+		field, err := svc.CreateField(dto.FieldCreateRequest{
+			TableID: table.ID, Name: "sibling", Type: "link",
+			Config: dto.FieldConfig{LinkTableID: table.ID, OnDelete: "set-null"},
+		}, master.ID)
+		require.NoError(t, err)
+		assert.Equal(t, linkOnDeleteSetNull, parseStoredFieldConfig(field.Options).OnDelete)
+	})
+}
+
+func TestCreateRecord_LinkValidation(t *testing.T) {
+	env := setupLinkTestEnv(t, dto.FieldConfig{})
+
+	order := env.createOrder(t, env.customer.ID)
+	assert.Contains(t, string(order.Data), env.customer.ID)
+
+	_, err := env.records.CreateRecord(dto.RecordCreateRequest{
+		TableID: env.orders.ID,
+		Data:    map[string]interface{}{"customer": "rec_missing"},
+	}, env.master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "linked record not found: rec_missing")
+
+	_, err = env.records.CreateRecord(dto.RecordCreateRequest{
+		TableID: env.orders.ID,
+		Data:    map[string]interface{}{"customer": []interface{}{env.customer.ID, env.customer.ID}},
+	}, env.master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "only allows a single record")
+
+	_, err = env.records.CreateRecord(dto.RecordCreateRequest{
+		TableID: env.orders.ID,
+		Data:    map[string]interface{}{"customer": 42},
+	}, env.master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "link value must be a record ID")
+}
+
+func TestCreateRecord_LinkToRecordInOtherTableRejected(t *testing.T) {
+	env := setupLinkTestEnv(t, dto.FieldConfig{})
+	other := env.createOrder(t, nil)
+
+	_, err := env.records.CreateRecord(dto.RecordCreateRequest{
+		TableID: env.orders.ID,
+		Data:    map[string]interface{}{"customer": other.ID},
+	}, env.master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "linked record not found")
+}
+
+func TestCreateRecord_LinkOutsideRowFilterRejected(t *testing.T) {
+	env := setupLinkTestEnv(t, dto.FieldConfig{})
+	other, err := env.records.CreateRecord(dto.RecordCreateRequest{
+		TableID: env.customers.ID,
+		Data:    map[string]interface{}{"name": "Globex"},
+	}, env.master.ID)
+	require.NoError(t, err)
+
+	token := &models.Token{
+		Name:  "acme_editor",
+		Token: "cs_acme_editor",
+		Scopes: `{"subject":"Acme","tables":{"` + env.customers.ID + `":{"role":"viewer","rows":{"name":"$subject"}},"` +
+			env.orders.ID + `":{"role":"editor"}}}`,
+	}
+	require.NoError(t, env.db.Create(token).Error)
+
+	_, err = env.records.CreateRecord(dto.RecordCreateRequest{
+		TableID: env.orders.ID,
+		Data:    map[string]interface{}{"customer": other.ID},
+	}, token.ID)
+	require.Error(t, err)
+	assert.Equal(t, "field 'customer' validation failed: linked record not found: "+other.ID, err.Error())
+
+	_, err = env.records.CreateRecord(dto.RecordCreateRequest{
+		TableID: env.orders.ID,
+		Data:    map[string]interface{}{"customer": env.customer.ID},
+	}, token.ID)
+	require.NoError(t, err)
+}
+
+func TestUpdateRecord_LinkMultiple(t *testing.T) {
+	env := setupLinkTestEnv(t, dto.FieldConfig{Multiple: true})
+	second, err := env.records.CreateRecord(dto.RecordCreateRequest{
+		TableID: env.customers.ID,
+		Data:    map[string]interface{}{"name": "Globex"},
+	}, env.master.ID)
+	require.NoError(t, err)
+
+	order := env.createOrder(t, []interface{}{env.customer.ID})
+	updated, err := env.records.UpdateRecord(order.ID, dto.RecordUpdateRequest{
+		Data: map[string]interface{}{"customer": []interface{}{env.customer.ID, second.ID}},
+	}, env.master.ID)
+	require.NoError(t, err)
+	assert.Contains(t, string(updated.Data), second.ID)
+
+	_, err = env.records.UpdateRecord(order.ID, dto.RecordUpdateRequest{
+		Data: map[string]interface{}{"customer": []interface{}{env.customer.ID, env.customer.ID}},
+	}, env.master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "duplicate linked record ID")
+}
+
+func TestDeleteRecord_LinkRestrict(t *testing.T) {
+	env := setupLinkTestEnv(t, dto.FieldConfig{OnDelete: linkOnDeleteRestrict})
+	env.createOrder(t, env.customer.ID)
+
+	err := env.records.DeleteRecord(env.customer.ID, env.master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "still referenced by field 'customer'")
+
+	var count int64
+	require.NoError(t, env.db.Model(&models.Record{}).Where("id = ? AND deleted_at IS NULL", env.customer.ID).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestDeleteRecord_LinkIgnoresIDInText(t *testing.T) {
+	env := setupLinkTestEnv(t, dto.FieldConfig{OnDelete: linkOnDeleteRestrict})
+	_, err := env.records.CreateRecord(dto.RecordCreateRequest{
+		TableID: env.orders.ID,
+		Data:    map[string]interface{}{"title": "reorder of " + env.customer.ID},
+	}, env.master.ID)
+	require.NoError(t, err)
+
+	require.NoError(t, env.records.DeleteRecord(env.customer.ID, env.master.ID))
+}
+
+func TestDeleteRecord_LinkSetNull(t *testing.T) {
+	env := setupLinkTestEnv(t, dto.FieldConfig{OnDelete: linkOnDeleteSetNull})
+	order := env.createOrder(t, env.customer.ID)
+
+	require.NoError(t, env.records.DeleteRecord(env.customer.ID, env.master.ID))
+
+	got, err := env.records.GetRecord(order.ID, env.master.ID, "")
+	require.NoError(t, err)
+	data := got.Data.(map[string]interface{})
+	assert.Nil(t, data["customer"])
+	assert.Equal(t, "order", data["title"])
+	assert.Equal(t, 2, got.Version)
+}
+
+func TestDeleteRecord_LinkSetNullMultipleKeepsOthers(t *testing.T) {
+	env := setupLinkTestEnv(t, dto.FieldConfig{Multiple: true, OnDelete: linkOnDeleteSetNull})
+	second, err := env.records.CreateRecord(dto.RecordCreateRequest{
+		TableID: env.customers.ID,
+		Data:    map[string]interface{}{"name": "Globex"},
+	}, env.master.ID)
+	require.NoError(t, err)
+	order := env.createOrder(t, []interface{}{env.customer.ID, second.ID})
+
+	require.NoError(t, env.records.DeleteRecord(env.customer.ID, env.master.ID))
+
+	got, err := env.records.GetRecord(order.ID, env.master.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{second.ID}, got.Data.(map[string]interface{})["customer"])
+}
+
+func TestDeleteRecord_LinkCascade(t *testing.T) {
+	env := setupLinkTestEnv(t, dto.FieldConfig{OnDelete: linkOnDeleteCascade})
+	order := env.createOrder(t, env.customer.ID)
+	unrelated := env.createOrder(t, nil)
+
+	require.NoError(t, env.records.DeleteRecord(env.customer.ID, env.master.ID))
+
+	_, err := env.records.GetRecord(order.ID, env.master.ID, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "record not found")
+
+	_, err = env.records.GetRecord(unrelated.ID, env.master.ID, "")
+	require.NoError(t, err)
+}
+
+func TestDeleteRecord_LinkCascadeSelfReferenceTerminates(t *testing.T) {
+	db, _, table, master := setupCrudTestEnv(t)
+	SharedFieldCache.Clear()
+	_, err := NewFieldService(db).CreateField(dto.FieldCreateRequest{
+		TableID: table.ID, Name: "parent", Type: "link",
+		Config: dto.FieldConfig{LinkTableID: table.ID, OnDelete: linkOnDeleteCascade},
+	}, master.ID)
+	require.NoError(t, err)
+
+	svc := NewRecordService(db)
+	root, err := svc.CreateRecord(dto.RecordCreateRequest{TableID: table.ID, Data: map[string]interface{}{}}, master.ID)
+	require.NoError(t, err)
+	child, err := svc.CreateRecord(dto.RecordCreateRequest{TableID: table.ID, Data: map[string]interface{}{"parent": root.ID}}, master.ID)
+	require.NoError(t, err)
+	_, err = svc.UpdateRecord(root.ID, dto.RecordUpdateRequest{Data: map[string]interface{}{"parent": child.ID}}, master.ID)
+	require.NoError(t, err)
+
+	require.NoError(t, svc.DeleteRecord(root.ID, master.ID))
+
+	var count int64
+	require.NoError(t, db.Model(&models.Record{}).Where("table_id = ? AND deleted_at IS NULL", table.ID).Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestDeleteRecord_LinkOnDeleteRowFilter(t *testing.T) {
+	for _, onDelete := range []string{linkOnDeleteCascade, linkOnDeleteSetNull} {
+		t.Run(onDelete, func(t *testing.T) {
+			env := setupLinkTestEnv(t, dto.FieldConfig{OnDelete: onDelete})
+			order, err := env.records.CreateRecord(dto.RecordCreateRequest{
+				TableID: env.orders.ID,
+				Data:    map[string]interface{}{"title": "bob", "customer": env.customer.ID},
+			}, env.master.ID)
+			require.NoError(t, err)
+
+			token := &models.Token{
+				Name:  "row_admin",
+				Token: "cs_row_admin_" + onDelete,
+				Scopes: `{"subject":"alice","tables":{"` + env.customers.ID + `":{"role":"admin"},"` +
+					env.orders.ID + `":{"role":"admin","rows":{"title":"$subject"}}}}`,
+			}
+			require.NoError(t, env.db.Create(token).Error)
+
+			err = env.records.DeleteRecord(env.customer.ID, token.ID)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "on_delete: restrict")
+
+			got, err := env.records.GetRecord(order.ID, env.master.ID, "")
+			require.NoError(t, err)
+			assert.Equal(t, env.customer.ID, got.Data.(map[string]interface{})["customer"])
+			_, err = env.records.GetRecord(env.customer.ID, env.master.ID, "")
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestRemoveLinkedRecordID(t *testing.T) {
+	assert.Nil(t, removeLinkedRecordID("rec_a", "rec_a"))
+	assert.Equal(t, "rec_b", removeLinkedRecordID("rec_b", "rec_a"))
+	assert.Equal(t, []string{"rec_b"}, removeLinkedRecordID([]interface{}{"rec_a", "rec_b"}, "rec_a"))
+	assert.Nil(t, removeLinkedRecordID([]interface{}{"rec_a"}, "rec_a"))
+}
@@ -131,7 +131,7 @@ func TestExportRecords_ListFieldValues(t *testing.T) {
 	}, "user1")
 	require.NoError(t, err)
 
-	data, contentType, filename, err := s.ExportRecords(tbl.ID, "user1", "csv", "")
+	data, contentType, filename, err := exportRecordsBytes(s, tbl.ID, "user1", "csv", "", "")
 	require.NoError(t, err)
 	assert.Contains(t, contentType, "text/csv")
 	assert.Contains(t, filename, ".csv")
@@ -158,7 +158,7 @@ func TestExportRecords_JSONFieldValues(t *testing.T) {
 	}, "user1")
 	require.NoError(t, err)
 
-	data, contentType, _, err := s.ExportRecords(tbl.ID, "user1", "csv", "")
+	data, contentType, _, err := exportRecordsBytes(s, tbl.ID, "user1", "csv", "", "")
 	require.NoError(t, err)
 	assert.Contains(t, contentType, "text/csv")
 	assert.Contains(t, string(data), "key")
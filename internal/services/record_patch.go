@@ -0,0 +1,130 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/dto"
+	"github.com/jiangfire/cornerstone/pkg/jsonpatch"
+)
+
+// Formats of a record patch.
+const (
+	RecordPatchMerge = "merge" // JSON Merge Patch, RFC 7396
+	RecordPatchJSON  = "json"  // JSON Patch, RFC 6902
+)
+
+// PatchRecord applies a JSON Merge Patch or JSON Patch to the data of a record. The patch
+// sees the record data as an object of the readable fields, with attachment fields as arrays
+// of file IDs, so list and attachment values can be edited element by element. Every field the
+// patch changes or removes must be writable, and the patched record is validated as a whole.
+// A version greater than zero must match the record version.
+func (s *RecordService) PatchRecord(recordID string, req dto.RecordPatchRequest, userID string) (*models.Record, error) {
+	var record models.Record
+	if err := s.db.Where("id = ? AND deleted_at IS NULL", recordID).First(&record).Error; err != nil {
+		return nil, fmt.Errorf("record not found: %w", err)
+	}
+	if err := s.checkTableAccess(record.TableID, userID, []string{"owner", "admin", "editor"}); err != nil {
+		return nil, err
+	}
+	if req.Version > 0 && record.Version != req.Version {
+		return nil, fmt.Errorf("record was modified by another user (current version: %d, requested version: %d)", record.Version, req.Version)
+	}
+
+	fields, err := s.getTableFields(record.TableID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkRecordRow(record, fields, userID); err != nil {
+		return nil, err
+	}
+	readableFields, writableFields, masks, err := s.getFieldAccessMaps(fields, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	currentData, _ := s.extractKnownRecordData(fields, parseRecordPayload(record.Data))
+	view := s.filterReadableData(fields, readableFields, masks, currentData)
+	for _, field := range fields {
+		if value, exists := view[field.Name]; exists && isAttachmentFieldType(field.Type) {
+			if fileIDs, err := parseAttachmentValue(value); err == nil {
+				view[field.Name] = jsonpatch.DeepCopy(fileIDs)
+			}
+		}
+	}
+
+	patched, err := applyRecordPatch(view, req)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]interface{})
+	for key, value := range patched {
+		if previous, exists := view[key]; !exists || !jsonpatch.Equal(previous, value) {
+			changes[key] = value
+		}
+	}
+	changes, err = s.normalizeRecordData(fields, changes)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureWritableFields(changes, writableFields); err != nil {
+		return nil, err
+	}
+	for key := range view {
+		if _, kept := patched[key]; kept {
+			continue
+		}
+		if _, ok := writableFields[key]; !ok {
+			return nil, fmt.Errorf("write permission denied for field '%s'", key)
+		}
+		delete(currentData, key)
+	}
+	for key, value := range changes {
+		currentData[key] = value
+	}
+	applyFormulaValues(fields, currentData, time.Now())
+
+	if err := s.validateRecordData(record.TableID, currentData, record.ID, userID); err != nil {
+		return nil, err
+	}
+	if err := s.saveRecordData(&record, fields, currentData, req.Version); err != nil {
+		return nil, err
+	}
+
+	filteredData := s.filterReadableData(fields, readableFields, masks, currentData)
+	if record.Data, err = marshalRecordPayload(filteredData); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// applyRecordPatch applies the patch of req to the record data view, which must stay an object.
+func applyRecordPatch(view map[string]interface{}, req dto.RecordPatchRequest) (map[string]interface{}, error) {
+	var patched interface{}
+	switch req.Format {
+	case RecordPatchMerge:
+		if _, ok := req.Patch.(map[string]interface{}); !ok {
+			return nil, errors.New("merge patch must be a JSON object")
+		}
+		patched = jsonpatch.MergePatch(view, req.Patch)
+	case RecordPatchJSON:
+		ops, err := jsonpatch.ParsePatch(req.Patch)
+		if err != nil {
+			return nil, fmt.Errorf("invalid json patch: %w", err)
+		}
+		if patched, err = jsonpatch.Apply(view, ops); err != nil {
+			return nil, fmt.Errorf("json patch failed: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("invalid patch format '%s', must be one of: merge, json", req.Format)
+	}
+
+	object, ok := patched.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("patched record data must be a JSON object")
+	}
+	return object, nil
+}
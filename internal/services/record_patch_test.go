@@ -0,0 +1,134 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/dto"
+)
+
+func TestPatchRecord_MergePatch(t *testing.T) {
+	_, table, master, fieldSvc, svc := setupFormulaTestEnv(t)
+	createFormulaField(t, fieldSvc, table, master, "total", "price * quantity")
+	_, err := fieldSvc.CreateField(dto.FieldCreateRequest{TableID: table.ID, Name: "meta", Type: "json"}, master.ID)
+	require.NoError(t, err)
+	record := createUniqueTestRecord(t, svc, table, master, map[string]interface{}{
+		"name": "a", "price": 2, "quantity": 3, "meta": map[string]interface{}{"color": "red", "size": "L"},
+	})
+
+	patched, err := svc.PatchRecord(record.ID, dto.RecordPatchRequest{
+		Format: RecordPatchMerge,
+		Patch: map[string]interface{}{
+			"price": 5,
+			"name":  nil,
+			"meta":  map[string]interface{}{"size": nil, "weight": 2},
+		},
+		Version: record.Version,
+	}, master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, record.Version+1, patched.Version)
+
+	data := parseRecordPayload(patched.Data)
+	assert.NotContains(t, data, "name")
+	assert.EqualValues(t, 15, data["total"])
+	assert.Equal(t, map[string]interface{}{"color": "red", "weight": float64(2)}, data["meta"])
+	assert.EqualValues(t, 3, storedRecordValue(t, svc, record.ID, "quantity"))
+
+	var index models.RecordFieldIndex
+	require.NoError(t, svc.db.Where("record_id = ? AND field_name = ?", record.ID, "price").First(&index).Error)
+	assert.EqualValues(t, 5, *index.ValueNumber)
+
+	// A stale version is rejected.
+	_, err = svc.PatchRecord(record.ID, dto.RecordPatchRequest{
+		Format: RecordPatchMerge, Patch: map[string]interface{}{"price": 6}, Version: record.Version,
+	}, master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "modified by another user")
+}
+
+func TestPatchRecord_JSONPatch(t *testing.T) {
+	_, table, master, fieldSvc, svc := setupFormulaTestEnv(t)
+	_, err := fieldSvc.CreateField(dto.FieldCreateRequest{TableID: table.ID, Name: "tags", Type: "list"}, master.ID)
+	require.NoError(t, err)
+	record := createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"name": "a", "tags": []interface{}{"x", "y"}})
+
+	patched, err := svc.PatchRecord(record.ID, dto.RecordPatchRequest{
+		Format: RecordPatchJSON,
+		Patch: []interface{}{
+			map[string]interface{}{"op": "test", "path": "/name", "value": "a"},
+			map[string]interface{}{"op": "add", "path": "/tags/-", "value": "z"},
+			map[string]interface{}{"op": "remove", "path": "/tags/0"},
+			map[string]interface{}{"op": "copy", "from": "/name", "path": "/tags/0"},
+		},
+	}, master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"a", "y", "z"}, parseRecordPayload(patched.Data)["tags"])
+
+	tests := []struct {
+		patch []interface{}
+		want  string
+	}{
+		{[]interface{}{map[string]interface{}{"op": "test", "path": "/name", "value": "b"}}, "test failed"},
+		{[]interface{}{map[string]interface{}{"op": "add", "path": "/tags/-", "value": 1}}, "field 'tags' validation failed"},
+		{[]interface{}{map[string]interface{}{"op": "add", "path": "/missing", "value": 1}}, "field 'missing' does not exist"},
+		{[]interface{}{map[string]interface{}{"op": "replace", "path": "", "value": []interface{}{}}}, "must be a JSON object"},
+	}
+	for _, tt := range tests {
+		_, err := svc.PatchRecord(record.ID, dto.RecordPatchRequest{Format: RecordPatchJSON, Patch: tt.patch}, master.ID)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), tt.want)
+	}
+	assert.Equal(t, []interface{}{"a", "y", "z"}, storedRecordValue(t, svc, record.ID, "tags"))
+}
+
+func TestPatchRecord_Attachments(t *testing.T) {
+	db, table, master, fieldSvc, svc := setupFormulaTestEnv(t)
+	field, err := fieldSvc.CreateField(dto.FieldCreateRequest{
+		TableID: table.ID, Name: "files", Type: "file", Config: dto.FieldConfig{Multiple: true},
+	}, master.ID)
+	require.NoError(t, err)
+	files := make([]*models.File, 2)
+	for i := range files {
+		files[i] = &models.File{FieldID: field.ID, FileName: "a.txt", FileSize: 10, FileType: "text/plain", StorageURL: "./uploads/a.txt"}
+		require.NoError(t, db.Create(files[i]).Error)
+	}
+	record := createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"files": files[0].ID})
+
+	_, err = svc.PatchRecord(record.ID, dto.RecordPatchRequest{
+		Format: RecordPatchJSON,
+		Patch: []interface{}{
+			map[string]interface{}{"op": "add", "path": "/files/-", "value": files[1].ID},
+			map[string]interface{}{"op": "remove", "path": "/files/0"},
+		},
+	}, master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{files[1].ID}, storedRecordValue(t, svc, record.ID, "files"))
+
+	var first, second models.File
+	require.NoError(t, db.First(&first, "id = ?", files[0].ID).Error)
+	require.NoError(t, db.First(&second, "id = ?", files[1].ID).Error)
+	assert.Empty(t, first.RecordID)
+	assert.Equal(t, record.ID, second.RecordID)
+}
+
+func TestPatchRecord_InvalidPatch(t *testing.T) {
+	_, table, master, _, svc := setupFormulaTestEnv(t)
+	record := createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"name": "a"})
+
+	tests := []struct {
+		req  dto.RecordPatchRequest
+		want string
+	}{
+		{dto.RecordPatchRequest{Format: "xml", Patch: map[string]interface{}{}}, "invalid patch format 'xml'"},
+		{dto.RecordPatchRequest{Format: RecordPatchMerge, Patch: []interface{}{}}, "merge patch must be a JSON object"},
+		{dto.RecordPatchRequest{Format: RecordPatchJSON, Patch: map[string]interface{}{}}, "invalid json patch"},
+	}
+	for _, tt := range tests {
+		_, err := svc.PatchRecord(record.ID, tt.req, master.ID)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), tt.want)
+	}
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jiangfire/cornerstone/internal/authz"
+	"github.com/jiangfire/cornerstone/internal/models"
+)
+
+// rowFilter returns the row filter of the user on a table: the records they can access are
+// those whose fields equal its values. It returns nil when they can access every record.
+func (s *RecordService) rowFilter(tableID, userID string) (map[string]interface{}, error) {
+	authorizer, err := authz.NewAuthorizer(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	return authorizer.RowFilter(tableID), nil
+}
+
+// rowFilterClauses returns the clauses limiting a query of the records of a table to those the
+// row filter of the user lets them access. none is true when the filter names a field the table
+// does not have, so no record passes.
+func (s *RecordService) rowFilterClauses(tableID, userID string, fields []models.Field) (clauses []recordFilterClause, none bool, err error) {
+	filter, err := s.rowFilter(tableID, userID)
+	if err != nil || filter == nil {
+		return nil, false, err
+	}
+	// The filter applies whether or not the user can read the fields it compares.
+	allFields := make(map[string]models.Field, len(fields))
+	for _, field := range fields {
+		allFields[field.Name] = field
+	}
+	clauses, none, err = s.buildStructuredFilterClauses(fields, allFields, filter)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid row filter: %w", err)
+	}
+	return clauses, none, nil
+}
+
+// checkRecordRow fails with "record not found" when a record is outside the row filter of the
+// user, so a record they cannot access looks like one that does not exist.
+func (s *RecordService) checkRecordRow(record models.Record, fields []models.Field, userID string) error {
+	filter, err := s.rowFilter(record.TableID, userID)
+	if err != nil || filter == nil {
+		return err
+	}
+	if !authz.RowFilterMatches(filter, fields, parseRecordPayload(record.Data)) {
+		return errors.New("record not found")
+	}
+	return nil
+}
+
+// checkRowData fails when the data a user writes to a record of a table would put it outside
+// their row filter, where they could no longer access it.
+func (s *RecordService) checkRowData(tableID string, fields []models.Field, data map[string]interface{}, userID string) error {
+	filter, err := s.rowFilter(tableID, userID)
+	if err != nil || filter == nil {
+		return err
+	}
+	if !authz.RowFilterMatches(filter, fields, data) {
+		return errors.New("permission denied: record data does not match the row filter of the token")
+	}
+	return nil
+}
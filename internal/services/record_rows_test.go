@@ -0,0 +1,97 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/dto"
+)
+
+func TestRecordService_RowFilter(t *testing.T) {
+	db, table, master, _, svc := setupFormulaTestEnv(t)
+	mine := createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"name": "alice", "price": 1})
+	theirs := createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"name": "bob", "price": 2})
+
+	token := &models.Token{
+		Name:   "row_editor",
+		Token:  "cs_row_editor",
+		Scopes: `{"subject":"alice","tables":{"` + table.ID + `":{"role":"admin","rows":{"name":"$subject"}}}}`,
+	}
+	require.NoError(t, db.Create(token).Error)
+
+	t.Run("list returns matching records only", func(t *testing.T) {
+		for _, req := range []dto.RecordListQueryRequest{
+			{TableID: table.ID},
+			{TableID: table.ID, Filter: `{"price":1}`},
+			{TableID: table.ID, Filter: "a"},
+			{TableID: table.ID, Q: "alice"},
+		} {
+			list, err := svc.ListRecords(req, token.ID)
+			require.NoError(t, err)
+			require.Len(t, list.Records, 1, "request %+v", req)
+			assert.Equal(t, mine.ID, list.Records[0].ID)
+		}
+
+		for _, req := range []dto.RecordListQueryRequest{
+			{TableID: table.ID, Filter: `{"price":2}`},
+			{TableID: table.ID, Q: "bob"},
+		} {
+			list, err := svc.ListRecords(req, token.ID)
+			require.NoError(t, err)
+			assert.Empty(t, list.Records, "request %+v", req)
+		}
+	})
+
+	t.Run("records outside the filter are not found", func(t *testing.T) {
+		_, err := svc.GetRecord(theirs.ID, token.ID, "")
+		assert.ErrorContains(t, err, "record not found")
+		_, err = svc.UpdateRecord(theirs.ID, dto.RecordUpdateRequest{Data: map[string]interface{}{"price": 3}}, token.ID)
+		assert.ErrorContains(t, err, "record not found")
+		assert.ErrorContains(t, svc.DeleteRecord(theirs.ID, token.ID), "record not found")
+
+		record, err := svc.GetRecord(mine.ID, token.ID, "")
+		require.NoError(t, err)
+		assert.Equal(t, mine.ID, record.ID)
+	})
+
+	t.Run("writes must stay inside the filter", func(t *testing.T) {
+		_, err := svc.CreateRecord(dto.RecordCreateRequest{TableID: table.ID, Data: map[string]interface{}{"name": "bob"}}, token.ID)
+		assert.ErrorContains(t, err, "permission denied")
+		_, err = svc.UpdateRecord(mine.ID, dto.RecordUpdateRequest{Data: map[string]interface{}{"name": "bob"}}, token.ID)
+		assert.ErrorContains(t, err, "permission denied")
+
+		created, err := svc.CreateRecord(dto.RecordCreateRequest{TableID: table.ID, Data: map[string]interface{}{"name": "alice", "price": 5}}, token.ID)
+		require.NoError(t, err)
+		require.NoError(t, svc.DeleteRecord(created.ID, token.ID))
+	})
+
+	t.Run("bulk writes skip records outside the filter", func(t *testing.T) {
+		result, err := svc.BulkUpdateRecords(dto.RecordBulkUpdateRequest{
+			TableID: table.ID,
+			Filter:  map[string]interface{}{"price": 2},
+			Data:    map[string]interface{}{"price": 9},
+		}, token.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 0, result.Matched)
+
+		var record models.Record
+		require.NoError(t, db.First(&record, "id = ?", theirs.ID).Error)
+		assert.Equal(t, float64(2), parseRecordPayload(record.Data)["price"])
+	})
+
+	t.Run("exports include matching records only", func(t *testing.T) {
+		body, _, _, err := exportRecordsBytes(svc, table.ID, token.ID, "json", "", "")
+		require.NoError(t, err)
+		assert.Contains(t, string(body), mine.ID)
+		assert.NotContains(t, string(body), theirs.ID)
+	})
+
+	t.Run("master tokens are not filtered", func(t *testing.T) {
+		list, err := svc.ListRecords(dto.RecordListQueryRequest{TableID: table.ID}, master.ID)
+		require.NoError(t, err)
+		assert.Len(t, list.Records, 2)
+	})
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/dto"
+	"github.com/jiangfire/cornerstone/pkg/query"
+)
+
+// ErrInvalidSearch is wrapped by the errors of a search that cannot be run.
+var ErrInvalidSearch = errors.New("invalid search")
+
+const (
+	// searchSnippetLength and searchSnippetLead bound a highlight snippet, in characters: its
+	// length and how much text it keeps before the first match.
+	searchSnippetLength = 160
+	searchSnippetLead   = 40
+)
+
+// isSearchableFieldType reports whether values of a field type are indexed for full-text search.
+func isSearchableFieldType(fieldType string) bool {
+	switch normalizeFieldType(fieldType) {
+	case "string", "text":
+		return true
+	}
+	return false
+}
+
+// buildRecordSearchDocuments returns the search documents of a record: one per string or text
+// field with a non-empty value.
+func buildRecordSearchDocuments(tableID, recordID string, fields []models.Field, data map[string]interface{}) []models.RecordSearchDocument {
+	var documents []models.RecordSearchDocument
+	for _, field := range fields {
+		if !isSearchableFieldType(field.Type) {
+			continue
+		}
+		text, ok := data[field.Name].(string)
+		if !ok || strings.TrimSpace(text) == "" {
+			continue
+		}
+		documents = append(documents, models.RecordSearchDocument{
+			TableID:  tableID,
+			RecordID: recordID,
+			FieldID:  field.ID,
+			Content:  text,
+		})
+	}
+	return documents
+}
+
+// syncRecordSearchDocuments replaces the search documents of a record with those of data.
+func syncRecordSearchDocuments(tx *gorm.DB, recordID, tableID string, fields []models.Field, data map[string]interface{}) error {
+	if err := tx.Where("record_id = ?", recordID).Delete(&models.RecordSearchDocument{}).Error; err != nil {
+		return fmt.Errorf("failed to clear record search documents: %w", err)
+	}
+	documents := buildRecordSearchDocuments(tableID, recordID, fields, data)
+	if len(documents) == 0 {
+		return nil
+	}
+	if err := tx.Create(&documents).Error; err != nil {
+		return fmt.Errorf("failed to write record search documents: %w", err)
+	}
+	return nil
+}
+
+// recordSearchHit is a record matching a search with its relevance score.
+type recordSearchHit struct {
+	models.Record
+	SearchScore float64
+}
+
+// searchRecords lists the records whose readable string and text fields contain every word of
+// req.Q, best matches first unless sort orders them otherwise. A structured filter narrows the
+// matches; a keyword filter is rejected, its words belong in q. Masked fields are not searched.
+func (s *RecordService) searchRecords(req dto.RecordListQueryRequest, fields []models.Field, readableFields map[string]models.Field, masks map[string]string, sort recordSort, rowClauses []recordFilterClause) (*dto.RecordListData, error) {
+	terms := query.SearchTerms(req.Q)
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("%w: q has no words to search for", ErrInvalidSearch)
+	}
+	if len(terms) > query.MaxSearchTerms {
+		return nil, fmt.Errorf("%w: q has more than %d words", ErrInvalidSearch, query.MaxSearchTerms)
+	}
+
+	empty := &dto.RecordListData{Records: []dto.RecordObject{}}
+	clauses := rowClauses
+	if filter := strings.TrimSpace(req.Filter); filter != "" {
+		structured, ok := tryParseStructuredFilter(filter)
+		if !ok {
+			return nil, fmt.Errorf("%w: q cannot be combined with a keyword filter", ErrInvalidSearch)
+		}
+		filterClauses, refsHidden, err := s.buildStructuredFilterClauses(fields, readableFields, structured)
+		if err != nil {
+			return nil, err
+		}
+		if refsHidden {
+			return empty, nil
+		}
+		clauses = append(clauses, filterClauses...)
+	}
+
+	// Only readable fields are searched, so a match never reveals a hidden value.
+	var searchable []models.Field
+	fieldIDs := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if _, ok := readableFields[field.Name]; ok && isSearchableFieldType(field.Type) {
+			searchable = append(searchable, field)
+			fieldIDs = append(fieldIDs, field.ID)
+		}
+	}
+	if len(fieldIDs) == 0 {
+		return empty, nil
+	}
+
+	searchSQL, args, err := query.SearchSQL(s.db.Name(), terms, "d.table_id = ? AND d.field_id IN ?", []interface{}{req.TableID, fieldIDs})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSearch, err)
+	}
+	var from strings.Builder
+	from.WriteString(" FROM (" + searchSQL + ") matched JOIN records ON records.id = matched.record_id")
+	from.WriteString(" WHERE records.table_id = ? AND records.deleted_at IS NULL")
+	args = append(args, req.TableID)
+	for _, clause := range clauses {
+		from.WriteString(" AND " + clause.sql)
+		args = append(args, clause.args...)
+	}
+
+	var total int64
+	if err := s.db.Raw("SELECT COUNT(*)"+from.String(), args...).Scan(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count records: %w", err)
+	}
+
+	order := "matched.score DESC, " + recordListOrder("records.", nil)
+	if len(sort) > 0 {
+		order = sort.orderBy(s.db.Name(), "records.", nil)
+	}
+	listSQL := "SELECT records.id, records.table_id, records.data, records.version, records.created_at, records.updated_at, matched.score AS search_score" +
+		from.String() + " ORDER BY " + order + " LIMIT ? OFFSET ?"
+	var hits []recordSearchHit
+	if err := s.db.Raw(listSQL, append(args, req.Limit, req.Offset)...).Scan(&hits).Error; err != nil {
+		return nil, fmt.Errorf("failed to query records: %w", err)
+	}
+
+	result := make([]dto.RecordObject, len(hits))
+	for i, hit := range hits {
+		data := s.filterReadableData(fields, readableFields, masks, parseRecordPayload(hit.Data))
+		score := hit.SearchScore
+		result[i] = dto.RecordObject{
+			ID:         hit.ID,
+			TableID:    hit.TableID,
+			Data:       filterDataFields(data, req.Fields),
+			Version:    hit.Version,
+			Score:      &score,
+			Highlights: recordSearchHighlights(searchable, data, terms),
+		}
+	}
+	return &dto.RecordListData{
+		Records: result,
+		Total:   total,
+		HasMore: int64(req.Offset+len(hits)) < total,
+	}, nil
+}
+
+// recordSearchHighlights returns a highlight snippet for each searchable field of data that
+// contains a search term, keyed by field name.
+func recordSearchHighlights(searchable []models.Field, data map[string]interface{}, terms []string) map[string]string {
+	highlights := make(map[string]string)
+	for _, field := range searchable {
+		text, ok := data[field.Name].(string)
+		if !ok {
+			continue
+		}
+		if snippet, ok := searchSnippet(text, terms); ok {
+			highlights[field.Name] = snippet
+		}
+	}
+	if len(highlights) == 0 {
+		return nil
+	}
+	return highlights
+}
+
+// searchSnippet returns an HTML excerpt of text around its first search term, with every term
+// it shows wrapped in <mark> and the rest escaped. Words are split like query.SearchTerms does,
+// so the excerpt marks what the search matched. It reports false when text has no term.
+func searchSnippet(text string, terms []string) (string, bool) {
+	isTerm := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		isTerm[term] = true
+	}
+	isWord := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+
+	runes := []rune(text)
+	type span struct{ start, end int }
+	var matches []span
+	for i := 0; i < len(runes); {
+		if !isWord(runes[i]) {
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && isWord(runes[j]) {
+			j++
+		}
+		if isTerm[strings.ToLower(string(runes[i:j]))] {
+			matches = append(matches, span{i, j})
+		}
+		i = j
+	}
+	if len(matches) == 0 {
+		return "", false
+	}
+
+	// Cut at word boundaries, never through the first match.
+	first := matches[0]
+	start := max(first.start-searchSnippetLead, 0)
+	for start > 0 && start < first.start && isWord(runes[start-1]) {
+		start++
+	}
+	end := min(max(start+searchSnippetLength, first.end), len(runes))
+	for end < len(runes) && end > first.end && isWord(runes[end]) {
+		end--
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for _, match := range matches {
+		if match.start < start {
+			continue
+		}
+		if match.end > end {
+			break
+		}
+		b.WriteString(html.EscapeString(string(runes[pos:match.start])))
+		b.WriteString("<mark>" + html.EscapeString(string(runes[match.start:match.end])) + "</mark>")
+		pos = match.end
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:end])))
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String(), true
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/dto"
+	"github.com/jiangfire/cornerstone/pkg/query"
+)
+
+func countSearchDocuments(t *testing.T, db *gorm.DB, recordID string) int64 {
+	t.Helper()
+	var count int64
+	require.NoError(t, db.Model(&models.RecordSearchDocument{}).Where("record_id = ?", recordID).Count(&count).Error)
+	return count
+}
+
+func TestRecordSearchDocuments_Sync(t *testing.T) {
+	db, table, master, fieldSvc, svc := setupFormulaTestEnv(t)
+	notes, err := fieldSvc.CreateField(dto.FieldCreateRequest{TableID: table.ID, Name: "notes", Type: "text"}, master.ID)
+	require.NoError(t, err)
+
+	// Numbers and blank text are not indexed.
+	record := createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"name": "refund", "notes": " ", "price": 3})
+	assert.Equal(t, int64(1), countSearchDocuments(t, db, record.ID))
+
+	_, err = svc.UpdateRecord(record.ID, dto.RecordUpdateRequest{Data: map[string]interface{}{"notes": "customer asked twice"}}, master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), countSearchDocuments(t, db, record.ID))
+
+	require.NoError(t, fieldSvc.DeleteField(notes.ID, master.ID))
+	assert.Equal(t, int64(1), countSearchDocuments(t, db, record.ID))
+
+	require.NoError(t, svc.DeleteRecord(record.ID, master.ID))
+	assert.Equal(t, int64(0), countSearchDocuments(t, db, record.ID))
+
+	// Batch and bulk inserts index their records too.
+	batch, err := svc.BatchCreateRecords(dto.RecordCreateRequest{TableID: table.ID, Data: map[string]interface{}{"name": "batch"}}, master.ID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), countSearchDocuments(t, db, batch[1].ID))
+	bulk, err := svc.BulkInsertRecords(dto.RecordBulkInsertRequest{TableID: table.ID, Records: []map[string]interface{}{{"name": "bulk"}}}, master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), countSearchDocuments(t, db, bulk.Results[0].ID))
+}
+
+func TestListRecords_Search(t *testing.T) {
+	_, table, master, fieldSvc, svc := setupFormulaTestEnv(t)
+	_, err := fieldSvc.CreateField(dto.FieldCreateRequest{TableID: table.ID, Name: "notes", Type: "text"}, master.ID)
+	require.NoError(t, err)
+
+	for _, data := range []map[string]interface{}{
+		{"name": "a", "notes": "Customer wants a refund for a damaged order, shipped late and packed badly", "price": 1},
+		{"name": "b", "notes": "Refund", "price": 2},
+		{"name": "c", "notes": "Order shipped", "price": 3},
+		{"name": "refund now", "notes": "Damaged <box>", "price": 4},
+	} {
+		createUniqueTestRecord(t, svc, table, master, data)
+	}
+
+	search := func(req dto.RecordListQueryRequest) *dto.RecordListData {
+		t.Helper()
+		req.TableID, req.Limit = table.ID, 10
+		list, err := svc.ListRecords(req, master.ID)
+		require.NoError(t, err)
+		return list
+	}
+
+	// Shorter documents rank higher; a term may match in any field.
+	list := search(dto.RecordListQueryRequest{Q: "REFUND"})
+	assert.Equal(t, int64(3), list.Total)
+	assert.Equal(t, []string{"b", "refund now", "a"}, recordListNames(list))
+	for _, record := range list.Records {
+		require.NotNil(t, record.Score)
+		assert.Positive(t, *record.Score)
+	}
+	assert.Equal(t, map[string]string{"notes": "<mark>Refund</mark>"}, list.Records[0].Highlights)
+
+	// Every word must match, not necessarily in the same field.
+	list = search(dto.RecordListQueryRequest{Q: "damaged refund"})
+	assert.ElementsMatch(t, []string{"a", "refund now"}, recordListNames(list))
+	for i, name := range recordListNames(list) {
+		if name == "refund now" {
+			assert.Equal(t, map[string]string{"name": "<mark>refund</mark> now", "notes": "<mark>Damaged</mark> &lt;box&gt;"}, list.Records[i].Highlights)
+		}
+	}
+
+	// Filters, sorts and pages apply to the matches.
+	list = search(dto.RecordListQueryRequest{Q: "refund", Filter: `{"price":1}`})
+	assert.Equal(t, []string{"a"}, recordListNames(list))
+	list = search(dto.RecordListQueryRequest{Q: "refund", Sort: "-price"})
+	assert.Equal(t, []string{"refund now", "b", "a"}, recordListNames(list))
+	list, err = svc.ListRecords(dto.RecordListQueryRequest{TableID: table.ID, Limit: 2, Offset: 1, Q: "refund", Sort: "price"}, master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "refund now"}, recordListNames(list))
+	assert.False(t, list.HasMore)
+	assert.Equal(t, int64(3), list.Total)
+
+	assert.Empty(t, search(dto.RecordListQueryRequest{Q: "missing"}).Records)
+	assert.Empty(t, search(dto.RecordListQueryRequest{Q: "refund", Filter: `{"secret":1}`}).Records)
+
+	for _, req := range []dto.RecordListQueryRequest{{Q: "?!"}, {Q: "refund", Filter: "order"}, {Q: "a b c d e f g h i"}} {
+		req.TableID, req.Limit = table.ID, 10
+		_, err := svc.ListRecords(req, master.ID)
+		assert.True(t, errors.Is(err, ErrInvalidSearch), req.Q)
+	}
+	_, err = svc.ListRecords(dto.RecordListQueryRequest{TableID: table.ID, Limit: 10, Q: "refund", After: "x"}, master.ID)
+	assert.True(t, errors.Is(err, query.ErrInvalidCursor))
+}
+
+func TestSearchRecords_HiddenFields(t *testing.T) {
+	_, table, master, _, svc := setupFormulaTestEnv(t)
+	createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"name": "refund"})
+
+	fields, err := svc.getTableFields(table.ID)
+	require.NoError(t, err)
+	readable := make(map[string]models.Field)
+	for _, field := range fields {
+		if field.Name != "name" {
+			readable[field.Name] = field
+		}
+	}
+
+	// A word only in a hidden field does not match.
+	list, err := svc.searchRecords(dto.RecordListQueryRequest{TableID: table.ID, Limit: 10, Q: "refund"}, fields, readable, nil, nil, nil)
+	require.NoError(t, err)
+	assert.Empty(t, list.Records)
+	assert.Equal(t, int64(0), list.Total)
+}
+
+func TestSearchSnippet(t *testing.T) {
+	terms := []string{"refund", "late"}
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Refund, please", "<mark>Refund</mark>, please"},
+		{"refunded later", ""},
+		{"a <b>late</b> refund & more", "a &lt;b&gt;<mark>late</mark>&lt;/b&gt; <mark>refund</mark> &amp; more"},
+		{
+			"The first part of this ticket rambles on for a while before the customer finally asks for a refund of the order, which arrived late",
+			"…before the customer finally asks for a <mark>refund</mark> of the order, which arrived <mark>late</mark>",
+		},
+	}
+	for _, tt := range tests {
+		got, ok := searchSnippet(tt.text, terms)
+		assert.Equal(t, tt.want != "", ok, tt.text)
+		assert.Equal(t, tt.want, got, tt.text)
+	}
+}
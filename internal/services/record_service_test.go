@@ -699,7 +699,7 @@ func TestBuildMySQLRecordListSQL_WithRecordFieldIndexFilters(t *testing.T) {
 				value:     "beta",
 			},
 		},
-	})
+	}, nil)
 
 	assert.Contains(t, sql, "FROM (SELECT record_id FROM (SELECT record_id, field_id FROM record_field_indexes")
 	assert.Contains(t, sql, "UNION ALL")
@@ -768,9 +768,9 @@ func TestBuildMySQLRecordListSQL_NoFilter(t *testing.T) {
 		TableID: "tbl_1",
 		Limit:   50,
 		Offset:  10,
-	}, nil)
+	}, nil, nil)
 
-	assert.Equal(t, "SELECT id, table_id, data, version, created_at, updated_at FROM records FORCE INDEX (idx_records_table_deleted_created) WHERE table_id = ? AND deleted_at IS NULL ORDER BY created_at DESC LIMIT ? OFFSET ?", sql)
+	assert.Equal(t, "SELECT id, table_id, data, version, created_at, updated_at FROM records FORCE INDEX (idx_records_table_deleted_created) WHERE table_id = ? AND deleted_at IS NULL ORDER BY created_at DESC, id ASC LIMIT ? OFFSET ?", sql)
 	assert.Equal(t, []interface{}{"tbl_1", 50, 10}, args)
 }
 
@@ -782,9 +782,9 @@ func TestBuildMySQLRecordListSQL_WithStructuredClauses(t *testing.T) {
 	}, []recordFilterClause{
 		{sql: "JSON_EXTRACT(data, ?) = ?", args: []interface{}{"$.status", "paid"}},
 		{sql: "JSON_EXTRACT(data, ?) = ?", args: []interface{}{"$.category", "beta"}},
-	})
+	}, nil)
 
-	assert.Equal(t, "SELECT id, table_id, data, version, created_at, updated_at FROM records FORCE INDEX (idx_records_table_deleted_created) WHERE table_id = ? AND deleted_at IS NULL AND JSON_EXTRACT(data, ?) = ? AND JSON_EXTRACT(data, ?) = ? ORDER BY created_at DESC LIMIT ? OFFSET ?", sql)
+	assert.Equal(t, "SELECT id, table_id, data, version, created_at, updated_at FROM records FORCE INDEX (idx_records_table_deleted_created) WHERE table_id = ? AND deleted_at IS NULL AND JSON_EXTRACT(data, ?) = ? AND JSON_EXTRACT(data, ?) = ? ORDER BY created_at DESC, id ASC LIMIT ? OFFSET ?", sql)
 	assert.Equal(t, []interface{}{"tbl_1", "$.status", "paid", "$.category", "beta", 20, 0}, args)
 }
 
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/formula"
+)
+
+// ErrInvalidSort is wrapped by the errors of a sort parameter that cannot be applied.
+var ErrInvalidSort = errors.New("invalid sort")
+
+// recordSortColumns are the record columns a list can be sorted by besides its fields.
+var recordSortColumns = map[string]bool{"created_at": true, "updated_at": true}
+
+// recordSortKey is one key of a record list sort order.
+type recordSortKey struct {
+	column     string       // record column, empty for a field
+	field      string       // field name
+	typ        formula.Type // type of the field values
+	desc       bool
+	nullsFirst bool
+}
+
+// recordSort is the sort order a record list was asked for; empty keeps the default order,
+// newest first.
+type recordSort []recordSortKey
+
+// parseRecordSort parses a sort parameter: comma-separated field names or IDs, prefixed with
+// "-" for descending order and suffixed with ":nulls_first" or ":nulls_last". Records without
+// a value sort last by default. A field the user cannot read is rejected like an unknown one,
+// so the error does not tell them apart.
+func parseRecordSort(sort string, fields []models.Field, readableFields map[string]models.Field) (recordSort, error) {
+	sort = strings.TrimSpace(sort)
+	if sort == "" {
+		return nil, nil
+	}
+	// A broken formula still sorts, as text.
+	types, _ := tableValueTypes(fields)
+
+	var keys recordSort
+	for _, part := range strings.Split(sort, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		var key recordSortKey
+		name, nulls, hasNulls := strings.Cut(part, ":")
+		if hasNulls {
+			switch strings.ToLower(strings.TrimSpace(nulls)) {
+			case "nulls_first":
+				key.nullsFirst = true
+			case "nulls_last":
+			default:
+				return nil, fmt.Errorf("%w: unknown null ordering '%s', expected nulls_first or nulls_last", ErrInvalidSort, nulls)
+			}
+		}
+		name = strings.TrimSpace(name)
+		if strings.HasPrefix(name, "-") {
+			key.desc = true
+			name = name[1:]
+		} else {
+			name = strings.TrimPrefix(name, "+")
+		}
+
+		if field, ok := resolveReadableFilterField(fields, readableFields, name); ok {
+			// Sort expressions embed the field name; valid names are letters, digits and underscores.
+			if validateFieldName(field.Name) != nil {
+				return nil, fmt.Errorf("%w: field '%s' cannot be sorted", ErrInvalidSort, name)
+			}
+			key.field, key.typ = field.Name, types[field.Name]
+		} else if recordSortColumns[name] {
+			key.column = name
+		} else {
+			return nil, fmt.Errorf("%w: unknown field '%s'", ErrInvalidSort, name)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// orderBy is the ORDER BY of a record list page. Records that tie on every sort key keep the
+// default list order, which also makes the order total. A page before a cursor is fetched in
+// reverse order.
+func (s recordSort) orderBy(dbName, qualifier string, cursor *recordCursor) string {
+	if len(s) == 0 {
+		return recordListOrder(qualifier, cursor)
+	}
+	terms := make([]string, 0, 2*len(s)+1)
+	for _, key := range s {
+		if cursor != nil && cursor.before {
+			key = key.reversed()
+		}
+		expr := key.expression(dbName, qualifier)
+		dir := "ASC"
+		if key.desc {
+			dir = "DESC"
+		}
+		if key.column == "" {
+			// Databases disagree on where NULL sorts, so it is ordered explicitly.
+			nulls := "ASC"
+			if key.nullsFirst {
+				nulls = "DESC"
+			}
+			terms = append(terms, "("+expr+" IS NULL) "+nulls)
+		}
+		terms = append(terms, expr+" "+dir)
+	}
+	terms = append(terms, recordListOrder(qualifier, cursor))
+	return strings.Join(terms, ", ")
+}
+
+// reversed is the key in reverse order, records without a value included.
+func (k recordSortKey) reversed() recordSortKey {
+	k.desc = !k.desc
+	k.nullsFirst = !k.nullsFirst
+	return k
+}
+
+// jsonParameter is a parameter read as a JSON document.
+func jsonParameter(dbName string) string {
+	switch dbName {
+	case "postgres":
+		return "CAST(? AS jsonb)"
+	case "mysql":
+		return "CAST(? AS JSON)"
+	}
+	return "?"
+}
+
+// recordKeyset is the part of one sort key in the keyset condition of a cursor.
+type recordKeyset struct {
+	after     string // records after the cursor on the key, empty when none can be
+	afterArgs []interface{}
+	tie       string // records tying with the cursor on the key
+	tieArgs   []interface{}
+}
+
+// keyset compares the key of records with its value at a cursor. The cursor value of a field
+// is extracted from its stored JSON by the expression extracting it from records, so both
+// compare alike.
+func (k recordSortKey) keyset(dbName, qualifier string, value interface{}) recordKeyset {
+	expr := k.expression(dbName, qualifier)
+	op := " > "
+	if k.desc {
+		op = " < "
+	}
+	if k.column != "" {
+		t, _ := value.(time.Time)
+		cursor, arg := "?", interface{}(t)
+		if dbName == "sqlite" {
+			cursor, arg = "julianday(?)", t.UTC().Format(time.RFC3339Nano)
+		}
+		return recordKeyset{
+			after: expr + op + cursor, afterArgs: []interface{}{arg},
+			tie: expr + " = " + cursor, tieArgs: []interface{}{arg},
+		}
+	}
+
+	raw, _ := value.(string)
+	doc, _ := json.Marshal(map[string]json.RawMessage{k.field: json.RawMessage(raw)})
+	cursor := k.valueExpression(dbName, jsonParameter(dbName))
+	cursorArgs := make([]interface{}, strings.Count(cursor, "?"))
+	for i := range cursorArgs {
+		cursorArgs[i] = string(doc)
+	}
+	twice := append(append([]interface{}{}, cursorArgs...), cursorArgs...)
+
+	keyset := recordKeyset{
+		tie:     "(" + expr + " = " + cursor + " OR (" + expr + " IS NULL AND " + cursor + " IS NULL))",
+		tieArgs: twice,
+	}
+	if k.nullsFirst {
+		keyset.after = "(" + expr + " IS NOT NULL AND (" + cursor + " IS NULL OR " + expr + op + cursor + "))"
+	} else {
+		keyset.after = "(" + cursor + " IS NOT NULL AND (" + expr + " IS NULL OR " + expr + op + cursor + "))"
+	}
+	keyset.afterArgs = twice
+	return keyset
+}
+
+// expression extracts the sort key from a record as a SQL value that compares by the type of
+// the field: numbers as numbers, dates and datetimes as points in time, booleans false first
+// and everything else as text. Values of another type and JSON null extract as NULL where the
+// database allows it. Record columns compare as points in time too: SQLite stores them as text,
+// with or without a zone.
+func (k recordSortKey) expression(dbName, qualifier string) string {
+	if k.column != "" {
+		if dbName == "sqlite" {
+			return "julianday(" + qualifier + k.column + ")"
+		}
+		return qualifier + k.column
+	}
+	return k.valueExpression(dbName, qualifier+"data")
+}
+
+// valueExpression extracts the value of a field key from the JSON document data, a column or
+// the value of a cursor.
+func (k recordSortKey) valueExpression(dbName, data string) string {
+	switch dbName {
+	case "postgres":
+		value := fmt.Sprintf("%s->'%s'", data, k.field)
+		text := fmt.Sprintf("%s->>'%s'", data, k.field)
+		switch k.typ {
+		case formula.TypeNumber:
+			return fmt.Sprintf("CASE WHEN jsonb_typeof(%s) = 'number' THEN CAST(%s AS numeric) END", value, text)
+		case formula.TypeBool:
+			return fmt.Sprintf("CASE WHEN jsonb_typeof(%s) = 'boolean' THEN CAST(%s AS boolean) END", value, text)
+		case formula.TypeDate, formula.TypeDatetime:
+			return "CAST(" + text + " AS timestamptz)"
+		}
+		return text
+	case "mysql":
+		path := fmt.Sprintf(`'$."%s"'`, k.field)
+		// JSON null unquotes to the string 'null'.
+		text := fmt.Sprintf("NULLIF(JSON_UNQUOTE(JSON_EXTRACT(%s, %s)), 'null')", data, path)
+		switch k.typ {
+		case formula.TypeNumber:
+			return fmt.Sprintf("JSON_VALUE(%s, %s RETURNING DOUBLE)", data, path)
+		case formula.TypeBool:
+			return fmt.Sprintf("CASE JSON_UNQUOTE(JSON_EXTRACT(%s, %s)) WHEN 'true' THEN 1 WHEN 'false' THEN 0 END", data, path)
+		case formula.TypeDate, formula.TypeDatetime:
+			// MySQL reads UTC offsets but not the Z of RFC 3339.
+			return "CAST(REPLACE(" + text + ", 'Z', '+00:00') AS DATETIME(6))"
+		}
+		return text
+	default:
+		path := fmt.Sprintf(`'$."%s"'`, k.field)
+		extract := fmt.Sprintf("JSON_EXTRACT(%s, %s)", data, path)
+		switch k.typ {
+		case formula.TypeNumber:
+			return fmt.Sprintf("CASE WHEN JSON_TYPE(%s, %s) IN ('integer', 'real') THEN %s END", data, path, extract)
+		case formula.TypeDate, formula.TypeDatetime:
+			// julianday converts UTC offsets, so datetimes in different zones compare correctly.
+			return "julianday(" + extract + ")"
+		}
+		// Booleans extract as 0 and 1.
+		return extract
+	}
+}
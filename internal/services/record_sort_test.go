@@ -0,0 +1,197 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/dto"
+	"github.com/jiangfire/cornerstone/pkg/formula"
+	"github.com/jiangfire/cornerstone/pkg/query"
+)
+
+func TestParseRecordSort(t *testing.T) {
+	fields := []models.Field{
+		{ID: "fld_due", Name: "due", Type: "date"},
+		{ID: "fld_price", Name: "price", Type: "number"},
+		{ID: "fld_secret", Name: "secret", Type: "string"},
+	}
+	readable := map[string]models.Field{"due": fields[0], "price": fields[1]}
+
+	sort, err := parseRecordSort(" due, -fld_price:nulls_first ,+created_at:NULLS_LAST", fields, readable)
+	require.NoError(t, err)
+	assert.Equal(t, recordSort{
+		{field: "due", typ: formula.TypeDate},
+		{field: "price", typ: formula.TypeNumber, desc: true, nullsFirst: true},
+		{column: "created_at"},
+	}, sort)
+
+	sort, err = parseRecordSort("", fields, readable)
+	require.NoError(t, err)
+	assert.Nil(t, sort)
+
+	// A hidden field is as unknown as a missing one.
+	for _, input := range []string{"secret", "fld_secret", "missing", "price:nulls", "-id"} {
+		_, err := parseRecordSort(input, fields, readable)
+		require.Error(t, err, input)
+		assert.True(t, errors.Is(err, ErrInvalidSort), input)
+	}
+	_, err = parseRecordSort("secret", fields, readable)
+	assert.Equal(t, "invalid sort: unknown field 'secret'", err.Error())
+}
+
+func TestRecordSortOrderBy(t *testing.T) {
+	sort := recordSort{
+		{field: "due", typ: formula.TypeDatetime},
+		{field: "price", typ: formula.TypeNumber, desc: true, nullsFirst: true},
+		{field: "name", typ: formula.TypeString},
+		{column: "updated_at", desc: true},
+	}
+	assert.Equal(t, "(julianday(JSON_EXTRACT(data, '$.\"due\"')) IS NULL) ASC, julianday(JSON_EXTRACT(data, '$.\"due\"')) ASC, "+
+		"(CASE WHEN JSON_TYPE(data, '$.\"price\"') IN ('integer', 'real') THEN JSON_EXTRACT(data, '$.\"price\"') END IS NULL) DESC, "+
+		"CASE WHEN JSON_TYPE(data, '$.\"price\"') IN ('integer', 'real') THEN JSON_EXTRACT(data, '$.\"price\"') END DESC, "+
+		"(JSON_EXTRACT(data, '$.\"name\"') IS NULL) ASC, JSON_EXTRACT(data, '$.\"name\"') ASC, "+
+		"julianday(updated_at) DESC, created_at DESC, id ASC", sort.orderBy("sqlite", "", nil))
+
+	assert.Equal(t, "(CAST(data->>'due' AS timestamptz) IS NULL) ASC, CAST(data->>'due' AS timestamptz) ASC, "+
+		"(CASE WHEN jsonb_typeof(data->'price') = 'number' THEN CAST(data->>'price' AS numeric) END IS NULL) DESC, "+
+		"CASE WHEN jsonb_typeof(data->'price') = 'number' THEN CAST(data->>'price' AS numeric) END DESC, "+
+		"(data->>'name' IS NULL) ASC, data->>'name' ASC, updated_at DESC, created_at DESC, id ASC", sort.orderBy("postgres", "", nil))
+
+	mysql := sort.orderBy("mysql", "records.", nil)
+	assert.True(t, strings.HasPrefix(mysql, "(CAST(REPLACE(NULLIF(JSON_UNQUOTE(JSON_EXTRACT(records.data, '$.\"due\"')), 'null'), 'Z', '+00:00') AS DATETIME(6)) IS NULL) ASC, "), mysql)
+	assert.Contains(t, mysql, "JSON_VALUE(records.data, '$.\"price\"' RETURNING DOUBLE) DESC, ")
+	assert.True(t, strings.HasSuffix(mysql, "records.updated_at DESC, records.created_at DESC, records.id ASC"), mysql)
+
+	assert.Equal(t, "created_at ASC, id DESC", recordSort(nil).orderBy("sqlite", "", &recordCursor{before: true}))
+}
+
+func TestListRecords_Sort(t *testing.T) {
+	_, table, master, fieldSvc, svc := setupFormulaTestEnv(t)
+	for name, fieldType := range map[string]string{"due": "datetime", "done": "boolean"} {
+		_, err := fieldSvc.CreateField(dto.FieldCreateRequest{TableID: table.ID, Name: name, Type: fieldType}, master.ID)
+		require.NoError(t, err)
+	}
+	createFormulaField(t, fieldSvc, table, master, "total", "price * quantity")
+
+	// Text order differs from numeric and time order: 9 < 10 and 09:00+08:00 is 01:00 UTC.
+	for _, data := range []map[string]interface{}{
+		{"name": "a", "price": 10, "quantity": 1, "due": "2026-01-02T09:00:00+08:00", "done": true},
+		{"name": "b", "price": 9, "quantity": 3, "due": "2026-01-02T02:00:00Z", "done": false},
+		{"name": "c", "quantity": 2, "due": "2026-01-01 23:00:00"},
+		{"name": "d", "price": 100, "quantity": 1, "done": true},
+	} {
+		createUniqueTestRecord(t, svc, table, master, data)
+	}
+
+	tests := []struct {
+		sort string
+		want []string
+	}{
+		{"price", []string{"b", "a", "d", "c"}},
+		{"-price", []string{"d", "a", "b", "c"}},
+		{"price:nulls_first", []string{"c", "b", "a", "d"}},
+		{"-price:nulls_first", []string{"c", "d", "a", "b"}},
+		{"due", []string{"c", "a", "b", "d"}},
+		{"-due", []string{"b", "a", "c", "d"}},
+		{"done,name", []string{"b", "a", "d", "c"}},
+		{"-total", []string{"d", "b", "a", "c"}},
+		{"-name", []string{"d", "c", "b", "a"}},
+	}
+	for _, tt := range tests {
+		list, err := svc.ListRecords(dto.RecordListQueryRequest{TableID: table.ID, Limit: 10, Sort: tt.sort}, master.ID)
+		require.NoError(t, err, tt.sort)
+		assert.Equal(t, tt.want, recordListNames(list), tt.sort)
+		assert.Empty(t, list.NextCursor)
+	}
+
+	// Filters and offsets apply to the sorted list.
+	list, err := svc.ListRecords(dto.RecordListQueryRequest{TableID: table.ID, Limit: 1, Offset: 1, Sort: "-price", Filter: `{"done":true}`}, master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, recordListNames(list))
+	assert.False(t, list.HasMore)
+	list, err = svc.ListRecords(dto.RecordListQueryRequest{TableID: table.ID, Limit: 2, Sort: "price", Filter: "2026"}, master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "a"}, recordListNames(list))
+	assert.True(t, list.HasMore)
+	list, err = svc.ListRecords(dto.RecordListQueryRequest{TableID: table.ID, Limit: 2, Sort: "price", Filter: "2026", After: list.NextCursor}, master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c"}, recordListNames(list))
+	assert.False(t, list.HasMore)
+
+	_, err = svc.ListRecords(dto.RecordListQueryRequest{TableID: table.ID, Limit: 10, Sort: "cost"}, master.ID)
+	assert.True(t, errors.Is(err, ErrInvalidSort))
+	_, err = svc.ListRecords(dto.RecordListQueryRequest{TableID: table.ID, Limit: 10, Sort: "price", After: "x"}, master.ID)
+	assert.True(t, errors.Is(err, query.ErrInvalidCursor))
+	_, err = svc.ListRecords(dto.RecordListQueryRequest{TableID: table.ID, Limit: 10, Sort: "-price", After: list.PrevCursor}, master.ID)
+	assert.ErrorContains(t, err, "does not match the sort order")
+
+	// Cursors page through the sorted list, both ways.
+	tests = append(tests,
+		struct {
+			sort string
+			want []string
+		}{"updated_at,name", []string{"a", "b", "c", "d"}},
+		struct {
+			sort string
+			want []string
+		}{"-done:nulls_first,name", []string{"c", "a", "d", "b"}},
+	)
+	for _, tt := range tests {
+		for filter, matches := range map[string]string{"": "abcd", `{"done":true}`: "ad", "2026": "abc"} {
+			var want []string
+			for _, name := range tt.want {
+				if strings.Contains(matches, name) {
+					want = append(want, name)
+				}
+			}
+			var forward []string
+			req := dto.RecordListQueryRequest{TableID: table.ID, Limit: 1, Sort: tt.sort, Filter: filter}
+			var last *dto.RecordListData
+			for pages := 0; pages < 10; pages++ {
+				list, err := svc.ListRecords(req, master.ID)
+				require.NoError(t, err, tt.sort)
+				forward = append(forward, recordListNames(list)...)
+				last = list
+				if list.NextCursor == "" {
+					break
+				}
+				req.After = list.NextCursor
+			}
+			assert.Equal(t, want, forward, "%s %s", tt.sort, filter)
+
+			backward := recordListNames(last)
+			for prev := last.PrevCursor; prev != ""; {
+				list, err := svc.ListRecords(dto.RecordListQueryRequest{TableID: table.ID, Limit: 1, Sort: tt.sort, Filter: filter, Before: prev}, master.ID)
+				require.NoError(t, err, tt.sort)
+				backward = append(recordListNames(list), backward...)
+				prev = list.PrevCursor
+			}
+			assert.Equal(t, want, backward, "%s %s", tt.sort, filter)
+		}
+	}
+}
+
+func TestExportRecords_Sort(t *testing.T) {
+	_, table, master, _, svc := setupFormulaTestEnv(t)
+	for _, price := range []int{2, 10, 1} {
+		createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"price": price})
+	}
+
+	data, _, _, err := exportRecordsBytes(svc, table.ID, master.ID, "json", "", "-price")
+	require.NoError(t, err)
+	var rows []struct {
+		Data map[string]float64 `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(data, &rows))
+	require.Len(t, rows, 3)
+	assert.Equal(t, []float64{10, 2, 1}, []float64{rows[0].Data["price"], rows[1].Data["price"], rows[2].Data["price"]})
+
+	_, _, _, err = exportRecordsBytes(svc, table.ID, master.ID, "json", "", "cost")
+	assert.True(t, errors.Is(err, ErrInvalidSort))
+}
@@ -0,0 +1,377 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/dto"
+	"gorm.io/gorm"
+)
+
+const (
+	maxTableUniqueKeys  = 20
+	maxUniqueKeyFields  = 8
+	uniqueKeyBatchSize  = 200
+	uniqueKeyKeyPrefix  = "key:"
+	uniqueKeyNameMaxLen = 64
+)
+
+var uniqueKeyNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// isUniqueFieldType reports whether values of a field type can be constrained to be unique.
+func isUniqueFieldType(fieldType string) bool {
+	switch fieldType {
+	case "string", "text", "number", "date", "datetime", "link":
+		return true
+	}
+	return false
+}
+
+// validateUniqueFieldConfig checks the unique flag of a field.
+func validateUniqueFieldConfig(fieldType string, config dto.FieldConfig) error {
+	if !config.Unique {
+		return nil
+	}
+	if !isUniqueFieldType(fieldType) {
+		return fmt.Errorf("unique is not supported for %s fields", fieldType)
+	}
+	if isLinkFieldType(fieldType) && config.Multiple {
+		return errors.New("unique is not supported for multiple link fields")
+	}
+	return nil
+}
+
+// uniqueConstraint is a unique field or a composite unique key of a table.
+type uniqueConstraint struct {
+	key    string // constraint key in record_unique_keys: the field ID, or "key:<name>" for a composite key
+	label  string // used in error messages
+	fields []models.Field
+}
+
+func parseTableUniqueKeys(raw string) []dto.UniqueKey {
+	if raw == "" {
+		return nil
+	}
+	var keys []dto.UniqueKey
+	if err := json.Unmarshal([]byte(raw), &keys); err != nil {
+		return nil
+	}
+	return keys
+}
+
+// TableUniqueKeys returns the composite unique keys of a table.
+func TableUniqueKeys(table *models.Table) []dto.UniqueKey {
+	return parseTableUniqueKeys(table.UniqueKeys)
+}
+
+func compositeUniqueConstraint(key dto.UniqueKey, fields []models.Field) (uniqueConstraint, bool) {
+	byName := make(map[string]models.Field, len(fields))
+	for _, field := range fields {
+		byName[field.Name] = field
+	}
+	constraint := uniqueConstraint{
+		key:   uniqueKeyKeyPrefix + key.Name,
+		label: fmt.Sprintf("unique key '%s' (%s)", key.Name, strings.Join(key.Fields, ", ")),
+	}
+	for _, name := range key.Fields {
+		field, ok := byName[name]
+		if !ok {
+			return uniqueConstraint{}, false
+		}
+		constraint.fields = append(constraint.fields, field)
+	}
+	return constraint, true
+}
+
+func fieldUniqueConstraint(field models.Field) uniqueConstraint {
+	return uniqueConstraint{key: field.ID, label: fmt.Sprintf("field '%s'", field.Name), fields: []models.Field{field}}
+}
+
+// loadUniqueConstraints returns the unique fields and composite unique keys of a table.
+func loadUniqueConstraints(tx *gorm.DB, tableID string, fields []models.Field) ([]uniqueConstraint, error) {
+	var constraints []uniqueConstraint
+	for _, field := range fields {
+		if parseStoredFieldConfig(field.Options).Unique {
+			constraints = append(constraints, fieldUniqueConstraint(field))
+		}
+	}
+
+	var table models.Table
+	if err := tx.Select("id", "unique_keys").Where("id = ?", tableID).Take(&table).Error; err != nil {
+		return nil, fmt.Errorf("failed to load unique keys: %w", err)
+	}
+	for _, key := range parseTableUniqueKeys(table.UniqueKeys) {
+		if constraint, ok := compositeUniqueConstraint(key, fields); ok {
+			constraints = append(constraints, constraint)
+		}
+	}
+	return constraints, nil
+}
+
+// uniqueValueHash hashes the values a record holds for a constraint.
+// Records with an empty value in any of the fields are not constrained.
+func uniqueValueHash(constraint uniqueConstraint, payload map[string]interface{}) (string, bool) {
+	parts := make([]interface{}, len(constraint.fields))
+	for i, field := range constraint.fields {
+		value := payload[field.Name]
+		if value == nil || value == "" {
+			return "", false
+		}
+		if number, ok := recordFieldIndexNumber(value); ok {
+			value = number
+		}
+		parts[i] = value
+	}
+	encoded, err := json.Marshal(parts)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:]), true
+}
+
+// syncRecordUniqueKeys claims the unique values of a record inside tx, replacing its previous claims.
+// A value held by another record fails with a unique constraint error.
+func syncRecordUniqueKeys(tx *gorm.DB, tableID, recordID string, fields []models.Field, payload map[string]interface{}) error {
+	constraints, err := loadUniqueConstraints(tx, tableID, fields)
+	if err != nil || len(constraints) == 0 {
+		return err
+	}
+	if err := releaseRecordUniqueKeys(tx, recordID); err != nil {
+		return err
+	}
+	return claimRecordUniqueKeys(tx, tableID, recordID, constraints, payload)
+}
+
+// claimRecordUniqueKeys claims the unique values of a record that holds no claims yet.
+func claimRecordUniqueKeys(tx *gorm.DB, tableID, recordID string, constraints []uniqueConstraint, payload map[string]interface{}) error {
+	for _, constraint := range constraints {
+		hash, ok := uniqueValueHash(constraint, payload)
+		if !ok {
+			continue
+		}
+		var holders []models.RecordUniqueKey
+		if err := tx.Where("table_id = ? AND constraint_key = ? AND value_hash = ?", tableID, constraint.key, hash).
+			Limit(1).Find(&holders).Error; err != nil {
+			return fmt.Errorf("failed to check unique values: %w", err)
+		}
+		if len(holders) > 0 {
+			return fmt.Errorf("unique constraint violated: %s value already exists in record %s", constraint.label, holders[0].RecordID)
+		}
+		claim := models.RecordUniqueKey{TableID: tableID, ConstraintKey: constraint.key, ValueHash: hash, RecordID: recordID}
+		if err := tx.Create(&claim).Error; err != nil {
+			// A concurrent writer claimed the value between the check and the insert.
+			return fmt.Errorf("unique constraint violated: %s value already exists: %w", constraint.label, err)
+		}
+	}
+	return nil
+}
+
+func releaseRecordUniqueKeys(tx *gorm.DB, recordID string) error {
+	if err := tx.Where("record_id = ?", recordID).Delete(&models.RecordUniqueKey{}).Error; err != nil {
+		return fmt.Errorf("failed to release unique values: %w", err)
+	}
+	return nil
+}
+
+func dropUniqueConstraint(tx *gorm.DB, tableID, key string) error {
+	if err := tx.Where("table_id = ? AND constraint_key = ?", tableID, key).Delete(&models.RecordUniqueKey{}).Error; err != nil {
+		return fmt.Errorf("failed to drop unique values: %w", err)
+	}
+	return nil
+}
+
+// rebuildUniqueConstraint claims the values of every record for a constraint.
+// It fails when existing records already hold duplicate values.
+func rebuildUniqueConstraint(tx *gorm.DB, tableID string, constraint uniqueConstraint) error {
+	if err := dropUniqueConstraint(tx, tableID, constraint.key); err != nil {
+		return err
+	}
+
+	holders := make(map[string]string)
+	var claims []models.RecordUniqueKey
+	var records []models.Record
+	if err := tx.Where("table_id = ? AND deleted_at IS NULL", tableID).
+		FindInBatches(&records, uniqueKeyBatchSize, func(_ *gorm.DB, _ int) error {
+			for _, record := range records {
+				hash, ok := uniqueValueHash(constraint, parseRecordPayload(record.Data))
+				if !ok {
+					continue
+				}
+				if holder, exists := holders[hash]; exists {
+					return fmt.Errorf("cannot enable unique constraint on %s: records %s and %s have the same value", constraint.label, holder, record.ID)
+				}
+				holders[hash] = record.ID
+				claims = append(claims, models.RecordUniqueKey{TableID: tableID, ConstraintKey: constraint.key, ValueHash: hash, RecordID: record.ID})
+			}
+			return nil
+		}).Error; err != nil {
+		return err
+	}
+
+	if len(claims) == 0 {
+		return nil
+	}
+	if err := tx.CreateInBatches(&claims, uniqueKeyBatchSize).Error; err != nil {
+		return fmt.Errorf("failed to store unique values: %w", err)
+	}
+	return nil
+}
+
+func sanitizeTableUniqueKeys(keys []dto.UniqueKey) []dto.UniqueKey {
+	sanitized := make([]dto.UniqueKey, len(keys))
+	for i, key := range keys {
+		sanitized[i] = dto.UniqueKey{Name: strings.TrimSpace(key.Name), Fields: make([]string, len(key.Fields))}
+		for j, name := range key.Fields {
+			sanitized[i].Fields[j] = strings.TrimSpace(name)
+		}
+	}
+	return sanitized
+}
+
+// validateTableUniqueKeys checks composite unique keys against the fields of a table.
+func validateTableUniqueKeys(keys []dto.UniqueKey, fields []models.Field) error {
+	if len(keys) > maxTableUniqueKeys {
+		return fmt.Errorf("a table can have at most %d unique keys", maxTableUniqueKeys)
+	}
+	byName := make(map[string]models.Field, len(fields))
+	for _, field := range fields {
+		byName[field.Name] = field
+	}
+
+	names := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if len(key.Name) > uniqueKeyNameMaxLen || !uniqueKeyNamePattern.MatchString(key.Name) {
+			return fmt.Errorf("unique key name '%s' must start with a letter or underscore, contain only letters, digits and underscores, and be at most %d characters", key.Name, uniqueKeyNameMaxLen)
+		}
+		if names[key.Name] {
+			return fmt.Errorf("duplicate unique key name '%s'", key.Name)
+		}
+		names[key.Name] = true
+
+		if len(key.Fields) == 0 || len(key.Fields) > maxUniqueKeyFields {
+			return fmt.Errorf("unique key '%s' must have between 1 and %d fields", key.Name, maxUniqueKeyFields)
+		}
+		seen := make(map[string]bool, len(key.Fields))
+		for _, name := range key.Fields {
+			field, ok := byName[name]
+			if !ok {
+				return fmt.Errorf("unique key '%s': unknown field '%s'", key.Name, name)
+			}
+			if seen[name] {
+				return fmt.Errorf("unique key '%s': field '%s' is listed more than once", key.Name, name)
+			}
+			seen[name] = true
+			if err := validateUniqueFieldConfig(field.Type, dto.FieldConfig{Unique: true, Multiple: parseStoredFieldConfig(field.Options).Multiple}); err != nil {
+				return fmt.Errorf("unique key '%s': field '%s': %w", key.Name, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// findUniqueKeyReferencing returns a composite unique key of the table that includes the named field.
+func findUniqueKeyReferencing(keys []dto.UniqueKey, name string) (dto.UniqueKey, bool) {
+	for _, key := range keys {
+		for _, fieldName := range key.Fields {
+			if fieldName == name {
+				return key, true
+			}
+		}
+	}
+	return dto.UniqueKey{}, false
+}
+
+func marshalTableUniqueKeys(keys []dto.UniqueKey) (string, error) {
+	if len(keys) == 0 {
+		return "", nil
+	}
+	encoded, err := json.Marshal(keys)
+	if err != nil {
+		return "", fmt.Errorf("unique keys serialization failed: %w", err)
+	}
+	return string(encoded), nil
+}
+
+// validateUniqueKeyFieldChange checks that the composite unique keys using a field still accept its new type.
+func validateUniqueKeyFieldChange(table *models.Table, fieldName, fieldType string, config dto.FieldConfig) error {
+	key, ok := findUniqueKeyReferencing(TableUniqueKeys(table), fieldName)
+	if !ok {
+		return nil
+	}
+	if err := validateUniqueFieldConfig(fieldType, dto.FieldConfig{Unique: true, Multiple: config.Multiple}); err != nil {
+		return fmt.Errorf("field '%s' is used by unique key '%s': %w", fieldName, key.Name, err)
+	}
+	return nil
+}
+
+// applyFieldUniqueChanges updates the unique values of a table after a field is saved inside tx:
+// it claims or drops the values of the field's own constraint, renames the field in composite
+// unique keys and re-checks the keys whose values may compare differently under a new type.
+func applyFieldUniqueChanges(tx *gorm.DB, previous, field models.Field) error {
+	wasUnique := parseStoredFieldConfig(previous.Options).Unique
+	isUnique := parseStoredFieldConfig(field.Options).Unique
+	switch {
+	case isUnique && (!wasUnique || previous.Type != field.Type):
+		if err := rebuildUniqueConstraint(tx, field.TableID, fieldUniqueConstraint(field)); err != nil {
+			return err
+		}
+	case wasUnique && !isUnique:
+		if err := dropUniqueConstraint(tx, field.TableID, field.ID); err != nil {
+			return err
+		}
+	}
+	if previous.Name == field.Name && previous.Type == field.Type {
+		return nil
+	}
+
+	var table models.Table
+	if err := tx.Select("id", "unique_keys").Where("id = ?", field.TableID).Take(&table).Error; err != nil {
+		return fmt.Errorf("failed to load unique keys: %w", err)
+	}
+	keys := parseTableUniqueKeys(table.UniqueKeys)
+	var affected []dto.UniqueKey
+	for i := range keys {
+		for j, name := range keys[i].Fields {
+			if name == previous.Name {
+				keys[i].Fields[j] = field.Name
+				affected = append(affected, keys[i])
+			}
+		}
+	}
+	if len(affected) == 0 {
+		return nil
+	}
+
+	if previous.Name != field.Name {
+		encoded, err := marshalTableUniqueKeys(keys)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Table{}).Where("id = ?", field.TableID).Update("unique_keys", encoded).Error; err != nil {
+			return fmt.Errorf("failed to update unique keys: %w", err)
+		}
+	}
+	if previous.Type == field.Type {
+		return nil
+	}
+
+	var fields []models.Field
+	if err := tx.Where("table_id = ? AND deleted_at IS NULL", field.TableID).Find(&fields).Error; err != nil {
+		return fmt.Errorf("failed to get field definitions: %w", err)
+	}
+	for _, key := range affected {
+		if constraint, ok := compositeUniqueConstraint(key, fields); ok {
+			if err := rebuildUniqueConstraint(tx, field.TableID, constraint); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
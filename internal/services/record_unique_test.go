@@ -0,0 +1,154 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/dto"
+)
+
+func getTestField(t *testing.T, db *gorm.DB, table *models.Table, name string) *models.Field {
+	t.Helper()
+	var field models.Field
+	require.NoError(t, db.Where("table_id = ? AND name = ? AND deleted_at IS NULL", table.ID, name).First(&field).Error)
+	return &field
+}
+
+func createUniqueTestRecord(t *testing.T, svc *RecordService, table *models.Table, master *models.Token, data map[string]interface{}) *models.Record {
+	t.Helper()
+	record, err := svc.CreateRecord(dto.RecordCreateRequest{TableID: table.ID, Data: data}, master.ID)
+	require.NoError(t, err)
+	return record
+}
+
+func TestCreateField_UniqueValidation(t *testing.T) {
+	_, table, master, svc, _ := setupFormulaTestEnv(t)
+
+	_, err := svc.CreateField(dto.FieldCreateRequest{
+		TableID: table.ID, Name: "flag", Type: "boolean", Config: dto.FieldConfig{Unique: true},
+	}, master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unique is not supported for boolean fields")
+}
+
+func TestUniqueField_RejectsDuplicates(t *testing.T) {
+	db, table, master, fieldSvc, svc := setupFormulaTestEnv(t)
+	_, err := fieldSvc.CreateField(dto.FieldCreateRequest{
+		TableID: table.ID, Name: "email", Type: "string", Config: dto.FieldConfig{Unique: true},
+	}, master.ID)
+	require.NoError(t, err)
+
+	first := createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"email": "a@example.com"})
+	_, err = svc.CreateRecord(dto.RecordCreateRequest{TableID: table.ID, Data: map[string]interface{}{"email": "a@example.com"}}, master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unique constraint violated: field 'email' value already exists in record "+first.ID)
+
+	// Empty values are not constrained.
+	createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"name": "x"})
+	createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"name": "y"})
+
+	second := createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"email": "b@example.com"})
+	_, err = svc.UpdateRecord(second.ID, dto.RecordUpdateRequest{Data: map[string]interface{}{"email": "a@example.com"}}, master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unique constraint violated")
+
+	// A record may keep its own value, and a deleted record frees it.
+	_, err = svc.UpdateRecord(first.ID, dto.RecordUpdateRequest{Data: map[string]interface{}{"email": "a@example.com", "name": "renamed"}}, master.ID)
+	require.NoError(t, err)
+	require.NoError(t, svc.DeleteRecord(first.ID, master.ID))
+	_, err = svc.UpdateRecord(second.ID, dto.RecordUpdateRequest{Data: map[string]interface{}{"email": "a@example.com"}}, master.ID)
+	require.NoError(t, err)
+
+	var claims int64
+	require.NoError(t, db.Model(&models.RecordUniqueKey{}).Where("table_id = ?", table.ID).Count(&claims).Error)
+	assert.EqualValues(t, 1, claims)
+}
+
+func TestUniqueField_BatchCreateRejectsDuplicates(t *testing.T) {
+	db, table, master, fieldSvc, svc := setupFormulaTestEnv(t)
+	_, err := fieldSvc.CreateField(dto.FieldCreateRequest{
+		TableID: table.ID, Name: "code", Type: "string", Config: dto.FieldConfig{Unique: true},
+	}, master.ID)
+	require.NoError(t, err)
+
+	_, err = svc.BatchCreateRecords(dto.RecordCreateRequest{TableID: table.ID, Data: map[string]interface{}{"code": "same"}}, master.ID, 2)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unique constraint violated")
+
+	var count int64
+	require.NoError(t, db.Model(&models.Record{}).Where("table_id = ?", table.ID).Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func TestUniqueField_EnableOnDuplicateData(t *testing.T) {
+	db, table, master, fieldSvc, svc := setupFormulaTestEnv(t)
+	createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"price": 5})
+	createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"price": 5.0})
+
+	price := getTestField(t, db, table, "price")
+	_, err := fieldSvc.UpdateField(price.ID, dto.FieldUpdateRequest{
+		Name: "price", Type: "number", Config: dto.FieldConfig{Unique: true},
+	}, master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot enable unique constraint on field 'price'")
+
+	var stored models.Field
+	require.NoError(t, db.First(&stored, "id = ?", price.ID).Error)
+	assert.False(t, parseStoredFieldConfig(stored.Options).Unique)
+}
+
+func TestTableUniqueKeys_Composite(t *testing.T) {
+	db, table, master, fieldSvc, svc := setupFormulaTestEnv(t)
+	tableSvc := NewTableService(db)
+	createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"name": "a", "price": 1})
+	createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"name": "a", "price": 2})
+
+	keys := []dto.UniqueKey{{Name: "name_price", Fields: []string{"name", "price"}}}
+	updated, err := tableSvc.UpdateTable(table.ID, dto.TableUpdateRequest{Name: table.Name, UniqueKeys: &keys}, master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, keys, TableUniqueKeys(updated))
+
+	_, err = svc.CreateRecord(dto.RecordCreateRequest{TableID: table.ID, Data: map[string]interface{}{"name": "a", "price": 2}}, master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unique constraint violated: unique key 'name_price' (name, price)")
+	createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"name": "b", "price": 2})
+
+	// Keys are validated, and saving the table without unique_keys keeps them.
+	invalid := []dto.UniqueKey{{Name: "bad", Fields: []string{"missing"}}}
+	_, err = tableSvc.UpdateTable(table.ID, dto.TableUpdateRequest{Name: table.Name, UniqueKeys: &invalid}, master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown field 'missing'")
+	updated, err = tableSvc.UpdateTable(table.ID, dto.TableUpdateRequest{Name: table.Name, Description: "d"}, master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, keys, TableUniqueKeys(updated))
+
+	// Fields in a key cannot be deleted, and renaming them rewrites the key.
+	price := getTestField(t, db, table, "price")
+	err = fieldSvc.DeleteField(price.ID, master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "field 'price' is still referenced by unique key 'name_price'")
+	_, err = fieldSvc.UpdateField(price.ID, dto.FieldUpdateRequest{Name: "amount", Type: "number"}, master.ID)
+	require.NoError(t, err)
+	reloaded, err := tableSvc.GetTable(table.ID, master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"name", "amount"}, reloaded.UniqueKeys[0].Fields)
+
+	// Duplicates block enabling a key; removing all keys releases their values.
+	createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"name": "dup"})
+	createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"name": "dup"})
+	byName := []dto.UniqueKey{{Name: "by_name", Fields: []string{"name"}}}
+	_, err = tableSvc.UpdateTable(table.ID, dto.TableUpdateRequest{Name: table.Name, UniqueKeys: &byName}, master.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot enable unique constraint on unique key 'by_name'")
+
+	none := []dto.UniqueKey{}
+	_, err = tableSvc.UpdateTable(table.ID, dto.TableUpdateRequest{Name: table.Name, UniqueKeys: &none}, master.ID)
+	require.NoError(t, err)
+	var claims int64
+	require.NoError(t, db.Model(&models.RecordUniqueKey{}).Where("table_id = ?", table.ID).Count(&claims).Error)
+	assert.Zero(t, claims)
+}
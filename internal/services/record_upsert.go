@@ -0,0 +1,280 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/dto"
+	"gorm.io/gorm"
+)
+
+// Modes of an upsert, deciding what happens to the data of an existing record.
+const (
+	upsertMerge   = "merge"   // set the given fields and keep the others
+	upsertReplace = "replace" // set the given fields and clear the other writable ones
+)
+
+// Statuses of a record in an upsert result, besides bulkStatusFailed.
+const (
+	upsertStatusInserted = "inserted"
+	upsertStatusUpdated  = "updated"
+)
+
+const maxUpsertRecords = maxBulkInsertRecords
+
+// upsertKeyFieldTypes are the field types that can identify a record in an upsert.
+var upsertKeyFieldTypes = map[string]bool{
+	"string": true, "text": true, "date": true, "datetime": true, "number": true, "boolean": true,
+}
+
+func normalizeUpsertMode(mode string) (string, error) {
+	switch normalized := strings.ToLower(strings.TrimSpace(mode)); normalized {
+	case "":
+		return upsertMerge, nil
+	case upsertMerge, upsertReplace:
+		return normalized, nil
+	}
+	return "", fmt.Errorf("invalid mode '%s', must be one of: merge, replace", mode)
+}
+
+// UpsertRecords inserts or updates a record for each item of req and reports the outcome per item.
+// The key fields of an item identify its record through the record field indexes: when a record
+// matches, its data is merged with or replaced by the item data, guarded by the item version if set;
+// otherwise a new record is created. Items are written one by one in their own transaction, so a
+// failing item does not affect the others.
+func (s *RecordService) UpsertRecords(req dto.RecordUpsertRequest, userID string) (*dto.RecordUpsertData, error) {
+	mode, err := normalizeUpsertMode(req.Mode)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Records) == 0 {
+		return nil, errors.New("records must contain at least one record")
+	}
+	if len(req.Records) > maxUpsertRecords {
+		return nil, fmt.Errorf("too many records: %d, at most %d per request", len(req.Records), maxUpsertRecords)
+	}
+
+	if err := s.checkTableAccess(req.TableID, userID, []string{"owner", "admin", "editor"}); err != nil {
+		return nil, err
+	}
+	fields, err := s.getTableFields(req.TableID)
+	if err != nil {
+		return nil, err
+	}
+	readableFields, writableFields, _, err := s.getFieldAccessMaps(fields, userID)
+	if err != nil {
+		return nil, err
+	}
+	keyFields, err := resolveUpsertKeyFields(req.KeyFields, readableFields)
+	if err != nil {
+		return nil, err
+	}
+	var defaults FieldDefaultContext
+	if hasFieldDefaults(fields) {
+		if defaults, err = NewFieldDefaultContext(s.db, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &dto.RecordUpsertData{
+		TableID: req.TableID,
+		Mode:    mode,
+		Total:   len(req.Records),
+		Results: make([]dto.RecordUpsertResult, len(req.Records)),
+	}
+	for _, field := range keyFields {
+		result.KeyFields = append(result.KeyFields, field.Name)
+	}
+	for i := range req.Records {
+		item := &result.Results[i]
+		item.Index = i
+		record, status, err := s.upsertRecord(req.TableID, fields, writableFields, keyFields, mode, req.Records[i], defaults, userID)
+		if err != nil {
+			item.Status = bulkStatusFailed
+			item.Error = err.Error()
+			result.Failed++
+			continue
+		}
+		item.Status = status
+		item.ID = record.ID
+		item.Version = record.Version
+		if status == upsertStatusInserted {
+			result.Inserted++
+		} else {
+			result.Updated++
+		}
+	}
+	return result, nil
+}
+
+// resolveUpsertKeyFields returns the fields named as upsert keys. Keys must be readable and of a
+// type kept in the record field indexes.
+func resolveUpsertKeyFields(names []string, readableFields map[string]models.Field) ([]models.Field, error) {
+	if len(names) == 0 {
+		return nil, errors.New("key_fields must name at least one field")
+	}
+	keyFields := make([]models.Field, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		field, ok := readableFields[name]
+		if !ok {
+			return nil, fmt.Errorf("key field '%s' does not exist", name)
+		}
+		if !upsertKeyFieldTypes[normalizeFieldType(field.Type)] {
+			return nil, fmt.Errorf("field '%s' of type %s cannot be an upsert key", name, field.Type)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("key field '%s' is listed twice", name)
+		}
+		seen[name] = true
+		keyFields = append(keyFields, field)
+	}
+	return keyFields, nil
+}
+
+// upsertRecord writes one upsert item and returns the written record and whether it was inserted
+// or updated.
+func (s *RecordService) upsertRecord(tableID string, fields []models.Field, writableFields map[string]models.Field, keyFields []models.Field, mode string, item dto.RecordUpsertItem, defaults FieldDefaultContext, userID string) (*models.Record, string, error) {
+	data, err := s.normalizeRecordData(fields, item.Data)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := s.ensureWritableFields(data, writableFields); err != nil {
+		return nil, "", err
+	}
+	keyClauses := make([]recordFilterClause, 0, len(keyFields))
+	for _, field := range keyFields {
+		value, exists := data[field.Name]
+		if !exists || value == nil {
+			return nil, "", fmt.Errorf("key field '%s' is missing", field.Name)
+		}
+		clause, ok, err := mysqlRecordFieldIndexClause(field, value)
+		if err != nil {
+			return nil, "", err
+		}
+		if !ok {
+			return nil, "", fmt.Errorf("key field '%s' has a value that cannot be matched", field.Name)
+		}
+		keyClauses = append(keyClauses, clause)
+	}
+
+	var record models.Record
+	status := upsertStatusUpdated
+	now := time.Now()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		txService := NewRecordService(tx)
+		q := tx.Model(&models.Record{}).Where("records.table_id = ? AND records.deleted_at IS NULL", tableID)
+		for _, clause := range keyClauses {
+			q = q.Where(clause.sql, clause.args...)
+		}
+		var matches []models.Record
+		if err := q.Limit(2).Find(&matches).Error; err != nil {
+			return fmt.Errorf("failed to find record by key: %w", err)
+		}
+
+		switch len(matches) {
+		case 0:
+			if item.Version > 0 {
+				return fmt.Errorf("no record matches the key, cannot check version %d", item.Version)
+			}
+			status = upsertStatusInserted
+			return txService.insertUpsertRecord(tx, tableID, fields, data, defaults, now, userID, &record)
+		case 1:
+			record = matches[0]
+			if err := txService.checkRecordRow(record, fields, userID); err != nil {
+				return errors.New("permission denied: key matches a record outside the row filter of the token")
+			}
+			return txService.updateUpsertRecord(tx, fields, writableFields, mode, data, item.Version, now, userID, &record)
+		default:
+			return errors.New("key matches more than one record")
+		}
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return &record, status, nil
+}
+
+// insertUpsertRecord creates a record for an upsert item that matched no record.
+func (s *RecordService) insertUpsertRecord(tx *gorm.DB, tableID string, fields []models.Field, data map[string]interface{}, defaults FieldDefaultContext, now time.Time, userID string, record *models.Record) error {
+	ApplyFieldDefaults(fields, data, defaults)
+	applyFormulaValues(fields, data, now)
+	if err := s.validateRecordData(tableID, data, "", userID); err != nil {
+		return err
+	}
+	if hasAutonumberFields(fields) {
+		if err := assignAutonumberValues(tx, fields, []map[string]interface{}{data}); err != nil {
+			return err
+		}
+	}
+	dataJSON, err := marshalRecordPayload(data)
+	if err != nil {
+		return err
+	}
+
+	*record = models.Record{TableID: tableID, Data: dataJSON, Version: 1}
+	if err := tx.Create(record).Error; err != nil {
+		return fmt.Errorf("failed to create record: %w", err)
+	}
+	if err := s.syncAttachmentBindings(tx, record.ID, fields, data); err != nil {
+		return err
+	}
+	if err := s.syncRecordFieldIndexes(tx, record.ID, tableID, fields, data); err != nil {
+		return err
+	}
+	return syncRecordUniqueKeys(tx, tableID, record.ID, fields, data)
+}
+
+// updateUpsertRecord writes an upsert item to the record its key matched. In replace mode the
+// writable fields missing from the item are cleared; fields the caller cannot write are kept.
+func (s *RecordService) updateUpsertRecord(tx *gorm.DB, fields []models.Field, writableFields map[string]models.Field, mode string, data map[string]interface{}, version int, now time.Time, userID string, record *models.Record) error {
+	if version > 0 && record.Version != version {
+		return fmt.Errorf("record was modified by another user (current version: %d, requested version: %d)", record.Version, version)
+	}
+
+	current, _ := s.extractKnownRecordData(fields, parseRecordPayload(record.Data))
+	if mode == upsertReplace {
+		for name := range writableFields {
+			delete(current, name)
+		}
+	}
+	for key, value := range data {
+		current[key] = value
+	}
+	applyFormulaValues(fields, current, now)
+	if err := s.validateRecordData(record.TableID, current, record.ID, userID); err != nil {
+		return err
+	}
+	dataJSON, err := marshalRecordPayload(current)
+	if err != nil {
+		return err
+	}
+
+	updated := tx.Model(&models.Record{}).
+		Where("id = ? AND version = ? AND deleted_at IS NULL", record.ID, record.Version).
+		Updates(map[string]interface{}{
+			"data":    dataJSON,
+			"version": gorm.Expr("version + 1"),
+		})
+	if updated.Error != nil {
+		return fmt.Errorf("failed to update record: %w", updated.Error)
+	}
+	if updated.RowsAffected == 0 {
+		return errors.New("record was modified by another user, please refresh and retry")
+	}
+	if err := s.syncAttachmentBindings(tx, record.ID, fields, current); err != nil {
+		return err
+	}
+	if err := s.syncRecordFieldIndexes(tx, record.ID, record.TableID, fields, current); err != nil {
+		return err
+	}
+	if err := syncRecordUniqueKeys(tx, record.TableID, record.ID, fields, current); err != nil {
+		return err
+	}
+	record.Data = dataJSON
+	record.Version++
+	return nil
+}
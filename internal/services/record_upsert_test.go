@@ -0,0 +1,112 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/dto"
+)
+
+func TestUpsertRecords(t *testing.T) {
+	_, table, master, fieldSvc, svc := setupFormulaTestEnv(t)
+	createFormulaField(t, fieldSvc, table, master, "total", "price * quantity")
+	existing := createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"name": "a", "price": 1, "quantity": 2})
+
+	result, err := svc.UpsertRecords(dto.RecordUpsertRequest{
+		TableID:   table.ID,
+		KeyFields: []string{"name"},
+		Records: []dto.RecordUpsertItem{
+			{Data: map[string]interface{}{"name": "a", "price": 5}},
+			{Data: map[string]interface{}{"name": "b", "price": 3, "quantity": 1}},
+			{Data: map[string]interface{}{"price": 3}},
+		},
+	}, master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "merge", result.Mode)
+	assert.Equal(t, []string{"name"}, result.KeyFields)
+	assert.Equal(t, 1, result.Inserted)
+	assert.Equal(t, 1, result.Updated)
+	assert.Equal(t, 1, result.Failed)
+
+	assert.Equal(t, "updated", result.Results[0].Status)
+	assert.Equal(t, existing.ID, result.Results[0].ID)
+	assert.Equal(t, existing.Version+1, result.Results[0].Version)
+	assert.EqualValues(t, 5, storedRecordValue(t, svc, existing.ID, "price"))
+	assert.EqualValues(t, 2, storedRecordValue(t, svc, existing.ID, "quantity"))
+	assert.EqualValues(t, 10, storedRecordValue(t, svc, existing.ID, "total"))
+
+	assert.Equal(t, "inserted", result.Results[1].Status)
+	assert.Equal(t, 1, result.Results[1].Version)
+	assert.EqualValues(t, 3, storedRecordValue(t, svc, result.Results[1].ID, "total"))
+
+	assert.Equal(t, "failed", result.Results[2].Status)
+	assert.Contains(t, result.Results[2].Error, "key field 'name' is missing")
+	assert.EqualValues(t, 2, countTableRecords(t, svc, table))
+
+	// The inserted record is found again through its field index.
+	result, err = svc.UpsertRecords(dto.RecordUpsertRequest{
+		TableID:   table.ID,
+		KeyFields: []string{"name"},
+		Mode:      "replace",
+		Records:   []dto.RecordUpsertItem{{Data: map[string]interface{}{"name": "b", "price": 4}}},
+	}, master.ID)
+	require.NoError(t, err)
+	require.Equal(t, "updated", result.Results[0].Status)
+	id := result.Results[0].ID
+	assert.EqualValues(t, 4, storedRecordValue(t, svc, id, "price"))
+	assert.Nil(t, storedRecordValue(t, svc, id, "quantity"))
+	assert.EqualValues(t, 2, countTableRecords(t, svc, table))
+}
+
+func TestUpsertRecords_Version(t *testing.T) {
+	_, table, master, _, svc := setupFormulaTestEnv(t)
+	existing := createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"name": "a", "price": 1})
+
+	result, err := svc.UpsertRecords(dto.RecordUpsertRequest{
+		TableID:   table.ID,
+		KeyFields: []string{"name", "price"},
+		Records: []dto.RecordUpsertItem{
+			{Data: map[string]interface{}{"name": "a", "price": 1, "quantity": 3}, Version: existing.Version + 1},
+			{Data: map[string]interface{}{"name": "a", "price": 1, "quantity": 4}, Version: existing.Version},
+			{Data: map[string]interface{}{"name": "z", "price": 1}, Version: 1},
+		},
+	}, master.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "failed", result.Results[0].Status)
+	assert.Contains(t, result.Results[0].Error, "modified by another user")
+	assert.Equal(t, "updated", result.Results[1].Status)
+	assert.EqualValues(t, 4, storedRecordValue(t, svc, existing.ID, "quantity"))
+	assert.Equal(t, "failed", result.Results[2].Status)
+	assert.Contains(t, result.Results[2].Error, "no record matches the key")
+	assert.EqualValues(t, 1, countTableRecords(t, svc, table))
+
+	var stored models.Record
+	require.NoError(t, svc.db.First(&stored, "id = ?", existing.ID).Error)
+	assert.Equal(t, existing.Version+1, stored.Version)
+}
+
+func TestUpsertRecords_RequestValidation(t *testing.T) {
+	_, table, master, fieldSvc, svc := setupFormulaTestEnv(t)
+	createFormulaField(t, fieldSvc, table, master, "total", "price * quantity")
+	items := []dto.RecordUpsertItem{{Data: map[string]interface{}{"name": "a"}}}
+
+	cases := []struct {
+		req     dto.RecordUpsertRequest
+		message string
+	}{
+		{dto.RecordUpsertRequest{TableID: table.ID, KeyFields: []string{"name"}, Records: items, Mode: "patch"}, "invalid mode 'patch'"},
+		{dto.RecordUpsertRequest{TableID: table.ID, KeyFields: []string{"name"}}, "at least one record"},
+		{dto.RecordUpsertRequest{TableID: table.ID, Records: items}, "key_fields must name at least one field"},
+		{dto.RecordUpsertRequest{TableID: table.ID, KeyFields: []string{"missing"}, Records: items}, "key field 'missing' does not exist"},
+		{dto.RecordUpsertRequest{TableID: table.ID, KeyFields: []string{"total"}, Records: items}, "cannot be an upsert key"},
+		{dto.RecordUpsertRequest{TableID: table.ID, KeyFields: []string{"name", "name"}, Records: items}, "listed twice"},
+	}
+	for _, tc := range cases {
+		_, err := svc.UpsertRecords(tc.req, master.ID)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), tc.message)
+	}
+}
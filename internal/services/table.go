@@ -211,6 +211,7 @@ func (s *TableService) ListTables(dbID, userID string) ([]dto.TableObject, error
 			DatabaseID:  t.DatabaseID,
 			Name:        t.Name,
 			Description: t.Description,
+			UniqueKeys:  TableUniqueKeys(&t),
 		}
 	}
 
@@ -237,6 +238,7 @@ func (s *TableService) GetTable(tableID, userID string) (*dto.TableObject, error
 		DatabaseID:  table.DatabaseID,
 		Name:        table.Name,
 		Description: table.Description,
+		UniqueKeys:  TableUniqueKeys(table),
 	}, nil
 }
 
@@ -273,8 +275,45 @@ func (s *TableService) UpdateTable(tableID string, req dto.TableUpdateRequest, u
 	table.Name = req.Name
 	table.Description = req.Description
 
-	if err := s.db.Save(table).Error; err != nil {
-		return nil, fmt.Errorf("failed to update table: %w", err)
+	if req.UniqueKeys == nil {
+		if err := s.db.Save(table).Error; err != nil {
+			return nil, fmt.Errorf("failed to update table: %w", err)
+		}
+		return table, nil
+	}
+
+	keys := sanitizeTableUniqueKeys(*req.UniqueKeys)
+	var fields []models.Field
+	if err := s.db.Where("table_id = ? AND deleted_at IS NULL", table.ID).Find(&fields).Error; err != nil {
+		return nil, fmt.Errorf("failed to get field definitions: %w", err)
+	}
+	if err := validateTableUniqueKeys(keys, fields); err != nil {
+		return nil, fmt.Errorf("unique key validation failed: %w", err)
+	}
+	previousKeys := TableUniqueKeys(table)
+	table.UniqueKeys, err = marshalTableUniqueKeys(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(table).Error; err != nil {
+			return fmt.Errorf("failed to update table: %w", err)
+		}
+		for _, key := range previousKeys {
+			if err := dropUniqueConstraint(tx, table.ID, uniqueKeyKeyPrefix+key.Name); err != nil {
+				return err
+			}
+		}
+		for _, key := range keys {
+			constraint, _ := compositeUniqueConstraint(key, fields)
+			if err := rebuildUniqueConstraint(tx, table.ID, constraint); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
 	}
 
 	return table, nil
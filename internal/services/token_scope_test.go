@@ -1,6 +1,7 @@
 package services
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/jiangfire/cornerstone/internal/authz"
 	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/dto"
 )
 
 func TestDatabaseService_ListDatabases_DBLevelScope(t *testing.T) {
@@ -228,3 +230,72 @@ func TestAuthorizer_EditorVsViewer(t *testing.T) {
 		assert.True(t, auth.CanAccessDatabase(database.ID, authz.ActionManage))
 	})
 }
+
+func TestRecordService_FieldMasks(t *testing.T) {
+	db, table, master, _, svc := setupFormulaTestEnv(t)
+	record := createUniqueTestRecord(t, svc, table, master, map[string]interface{}{"name": "4111-1111-1111-1234", "price": 42})
+
+	token := &models.Token{
+		Name:   "masked_viewer",
+		Token:  "cs_masked_viewer",
+		Scopes: `{"tables":{"` + table.ID + `":{"role":"viewer","masks":{"name":"last4","price":"redact"}}}}`,
+	}
+	require.NoError(t, db.Create(token).Error)
+	masked := map[string]interface{}{"name": "****1234", "price": "****"}
+
+	t.Run("reads return masked values", func(t *testing.T) {
+		got, err := svc.GetRecord(record.ID, token.ID, "")
+		require.NoError(t, err)
+		assert.Equal(t, masked, got.Data)
+
+		list, err := svc.ListRecords(dto.RecordListQueryRequest{TableID: table.ID}, token.ID)
+		require.NoError(t, err)
+		require.Len(t, list.Records, 1)
+		assert.Equal(t, masked, list.Records[0].Data)
+
+		export, err := svc.ExportRecords(dto.RecordExportRequest{TableID: table.ID, Format: "json"}, token.ID)
+		require.NoError(t, err)
+		var buf bytes.Buffer
+		require.NoError(t, export.Stream(&buf))
+		assert.Contains(t, buf.String(), "****1234")
+		assert.NotContains(t, buf.String(), "4111")
+	})
+
+	t.Run("created records are returned masked", func(t *testing.T) {
+		editor := &models.Token{
+			Name:   "masked_editor",
+			Token:  "cs_masked_editor",
+			Scopes: `{"tables":{"` + table.ID + `":{"role":"editor","masks":{"name":"last4","price":"redact"}}}}`,
+		}
+		require.NoError(t, db.Create(editor).Error)
+
+		created, err := svc.CreateRecord(dto.RecordCreateRequest{
+			TableID: table.ID,
+			Data:    map[string]interface{}{"name": "4111-1111-1111-5678", "price": 7},
+		}, editor.ID)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"name":"****5678","price":"****"}`, string(created.Data))
+		assert.False(t, created.CreatedAt.IsZero())
+	})
+
+	t.Run("masked fields do not filter, sort or search", func(t *testing.T) {
+		for _, req := range []dto.RecordListQueryRequest{
+			{TableID: table.ID, Filter: `{"price":42}`},
+			{TableID: table.ID, Filter: "4111"},
+			{TableID: table.ID, Q: "4111"},
+		} {
+			list, err := svc.ListRecords(req, token.ID)
+			require.NoError(t, err)
+			assert.Empty(t, list.Records, "request %+v", req)
+		}
+
+		_, err := svc.ListRecords(dto.RecordListQueryRequest{TableID: table.ID, Sort: "price"}, token.ID)
+		assert.ErrorIs(t, err, ErrInvalidSort)
+	})
+
+	t.Run("master tokens read unmasked values", func(t *testing.T) {
+		got, err := svc.GetRecord(record.ID, master.ID, "")
+		require.NoError(t, err)
+		assert.Equal(t, "4111-1111-1111-1234", got.Data.(map[string]interface{})["name"])
+	})
+}
@@ -575,6 +575,71 @@ const docTemplate = `{
                 }
             }
         },
+        "/api/v1/databases/{id}/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Export the records of every table of a database as one xlsx workbook, a sheet",
+                "produces": [
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "databases"
+                ],
+                "summary": "Export a database as an XLSX workbook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Database ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "xlsx",
+                        "description": "Export format: xlsx",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation error - invalid format",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - no access to this database",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Database not found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/databases/{id}/tables": {
             "get": {
                 "security": [
@@ -802,7 +867,7 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Updated field, or dto.FieldConversionReport for a dry run",
                         "schema": {
                             "allOf": [
                                 {
@@ -1742,17 +1807,47 @@ const docTemplate = `{
                         "name": "offset",
                         "in": "query"
                     },
+                    {
+                        "type": "string",
+                        "description": "Cursor of the record the page starts after",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of the record the page ends before",
+                        "name": "before",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
                         "description": "JSON filter expression",
                         "name": "filter",
                         "in": "query"
                     },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated sort fields, e.g. due_date,-priority:nulls_first",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Full-text search over string and text fields",
+                        "name": "q",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
                         "description": "Comma-separated field names to include in data",
                         "name": "fields",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached page; 304 when unchanged",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -1772,10 +1867,19 @@ const docTemplate = `{
                                     }
                                 }
                             ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Weak entity tag of the page, derived from record versions"
+                            }
                         }
                     },
+                    "304": {
+                        "description": "Not modified"
+                    },
                     "400": {
-                        "description": "Validation error - missing table_id",
+                        "description": "Validation error - missing table_id, invalid cursor, invalid sort or invalid search",
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
@@ -1858,6 +1962,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
+                    },
+                    "409": {
+                        "description": "Conflict - unique constraint violated",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
                     }
                 }
             }
@@ -1935,56 +2045,66 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
+                    },
+                    "409": {
+                        "description": "Conflict - unique constraint violated",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/api/v1/records/export": {
-            "get": {
+        "/api/v1/records/bulk": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Export records from a table as a downloadable file.",
+                "description": "Create one record per payload in the records array (up to 5000 per request).",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
-                    "application/octet-stream"
+                    "application/json"
                 ],
                 "tags": [
                     "records"
                 ],
-                "summary": "Export records as CSV or JSON",
+                "summary": "Bulk insert records",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Table ID",
-                        "name": "table_id",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "default": "csv",
-                        "description": "Export format: csv or json",
-                        "name": "format",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "JSON filter expression",
-                        "name": "filter",
-                        "in": "query"
+                        "description": "Record payloads",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.RecordBulkInsertRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "file"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.RecordBulkInsertData"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
-                        "description": "Validation error - missing table_id or invalid format",
+                        "description": "Validation error - invalid request body, mode or record count",
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
@@ -2004,34 +2124,33 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/records/{id}": {
-            "get": {
+        "/api/v1/records/bulk-delete": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieve a single record by its ID.",
+                "description": "Soft-delete every record of the table matching where and/or filter, with the same",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "records"
                 ],
-                "summary": "Get a record by ID",
+                "summary": "Bulk delete records",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Record ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Comma-separated field names to include in data",
-                        "name": "fields",
-                        "in": "query"
+                        "description": "Filter",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.RecordBulkDeleteRequest"
+                        }
                     }
                 ],
                 "responses": {
@@ -2046,13 +2165,19 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/dto.RecordObject"
+                                            "$ref": "#/definitions/dto.RecordBulkWriteData"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
+                    "400": {
+                        "description": "Validation error - invalid filter",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
                     "401": {
                         "description": "Unauthorized - invalid or missing API key",
                         "schema": {
@@ -2060,26 +2185,28 @@ const docTemplate = `{
                         }
                     },
                     "403": {
-                        "description": "Forbidden - no access to this record",
+                        "description": "Forbidden - no delete access to the table",
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Record not found",
+                    "409": {
+                        "description": "Conflict - a record is still referenced by a restrict link field",
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/api/v1/records/bulk-update": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Update record data. Supports optimistic locking via the version field.",
+                "description": "Apply the data patch to every record of the table matching where and/or filter.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2089,22 +2216,15 @@ const docTemplate = `{
                 "tags": [
                     "records"
                 ],
-                "summary": "Update a record",
+                "summary": "Bulk update records",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Record ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Record update with optional version",
+                        "description": "Filter and data patch",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.RecordUpdateRequest"
+                            "$ref": "#/definitions/dto.RecordBulkUpdateRequest"
                         }
                     }
                 ],
@@ -2120,7 +2240,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/dto.RecordObject"
+                                            "$ref": "#/definitions/dto.RecordBulkWriteData"
                                         }
                                     }
                                 }
@@ -2128,7 +2248,7 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Validation error or version conflict",
+                        "description": "Validation error - invalid filter or data",
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
@@ -2140,75 +2260,93 @@ const docTemplate = `{
                         }
                     },
                     "403": {
-                        "description": "Forbidden - no access to this record",
+                        "description": "Forbidden - no write access to the table or a patched field",
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Record not found",
+                    "409": {
+                        "description": "Conflict - unique constraint violated",
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/api/v1/records/export": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Delete a record by ID.",
+                "description": "Export records from a table as a downloadable file, streamed as",
                 "produces": [
-                    "application/json"
+                    "text/csv",
+                    "application/json",
+                    "application/x-ndjson",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
                 ],
                 "tags": [
                     "records"
                 ],
-                "summary": "Delete a record",
+                "summary": "Export records as CSV, JSON, NDJSON or XLSX",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Record ID",
-                        "name": "id",
-                        "in": "path",
+                        "description": "Table ID",
+                        "name": "table_id",
+                        "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "csv",
+                        "description": "Export format: csv, json, ndjson or xlsx",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON filter expression or keyword",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated field names to export",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated sort fields, e.g. due_date,-priority:nulls_first",
+                        "name": "sort",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/dto.APIResponse"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "object"
-                                        }
-                                    }
-                                }
-                            ]
+                            "type": "file"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized - invalid or missing API key",
+                    "400": {
+                        "description": "Validation error - missing table_id, invalid format or invalid sort",
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden - no access to this record",
+                    "401": {
+                        "description": "Unauthorized - invalid or missing API key",
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Record not found",
+                    "403": {
+                        "description": "Forbidden - no access to target table",
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
@@ -2216,90 +2354,32 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/records/{id}/files": {
-            "get": {
+        "/api/v1/records/upsert": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Returns all files attached to a record.",
+                "description": "Insert or update one record per item. The key_fields of an item identify its record: when",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "files"
+                    "records"
                 ],
-                "summary": "List files for a record",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Record ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/dto.APIResponse"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/dto.FileListData"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "401": {
-                        "description": "Unauthorized - invalid or missing API key",
-                        "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
-                        }
-                    },
-                    "403": {
-                        "description": "Forbidden - no access to this record",
-                        "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
-                        }
-                    }
-                }
-            }
-        },
-        "/api/v1/tables": {
-            "post": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Create a new table inside a database.",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "tables"
-                ],
-                "summary": "Create a table",
+                "summary": "Upsert records",
                 "parameters": [
                     {
-                        "description": "Table to create",
+                        "description": "Key fields and records",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.TableCreateRequest"
+                            "$ref": "#/definitions/dto.RecordUpsertRequest"
                         }
                     }
                 ],
@@ -2315,7 +2395,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/dto.TableObject"
+                                            "$ref": "#/definitions/dto.RecordUpsertData"
                                         }
                                     }
                                 }
@@ -2323,7 +2403,7 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Validation error - invalid request body",
+                        "description": "Validation error - invalid key fields or mode",
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
@@ -2335,7 +2415,7 @@ const docTemplate = `{
                         }
                     },
                     "403": {
-                        "description": "Forbidden - no access to parent database",
+                        "description": "Forbidden - no write access to the table",
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
@@ -2343,28 +2423,40 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/tables/{id}": {
+        "/api/v1/records/{id}": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieve full details of a single table by its ID.",
+                "description": "Retrieve a single record by its ID.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "tables"
+                    "records"
                 ],
-                "summary": "Get a table by ID",
+                "summary": "Get a record by ID",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Table ID",
+                        "description": "Record ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated field names to include in data",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached record; 304 when unchanged",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -2379,13 +2471,22 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/dto.TableObject"
+                                            "$ref": "#/definitions/dto.RecordObject"
                                         }
                                     }
                                 }
                             ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Entity tag of the record version"
+                            }
                         }
                     },
+                    "304": {
+                        "description": "Not modified"
+                    },
                     "401": {
                         "description": "Unauthorized - invalid or missing API key",
                         "schema": {
@@ -2393,13 +2494,13 @@ const docTemplate = `{
                         }
                     },
                     "403": {
-                        "description": "Forbidden - no access to this table",
+                        "description": "Forbidden - no access to this record",
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Table not found",
+                        "description": "Record not found",
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
@@ -2412,7 +2513,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Update table name and/or description.",
+                "description": "Update record data. Supports optimistic locking via the version field.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2420,24 +2521,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "tables"
+                    "records"
                 ],
-                "summary": "Update a table",
+                "summary": "Update a record",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Table ID",
+                        "description": "Record ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Table update fields",
+                        "type": "string",
+                        "description": "Expected record ETag",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Record update with optional version",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.TableUpdateRequest"
+                            "$ref": "#/definitions/dto.RecordUpdateRequest"
                         }
                     }
                 ],
@@ -2453,15 +2560,21 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/dto.TableObject"
+                                            "$ref": "#/definitions/dto.RecordObject"
                                         }
                                     }
                                 }
                             ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Entity tag of the updated record version"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Validation error - invalid request body",
+                        "description": "Validation error or version conflict",
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
@@ -2473,13 +2586,25 @@ const docTemplate = `{
                         }
                     },
                     "403": {
-                        "description": "Forbidden - no access to this table",
+                        "description": "Forbidden - no access to this record",
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Table not found",
+                        "description": "Record not found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict - unique constraint violated",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition failed - If-Match does not match the record ETag",
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
@@ -2492,21 +2617,27 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Delete a table and all of its associated fields and records.",
+                "description": "Delete a record by ID.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "tables"
+                    "records"
                 ],
-                "summary": "Delete a table",
+                "summary": "Delete a record",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Table ID",
+                        "description": "Record ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Expected record ETag",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -2535,42 +2666,76 @@ const docTemplate = `{
                         }
                     },
                     "403": {
-                        "description": "Forbidden - no access to this table",
+                        "description": "Forbidden - no access to this record",
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Table not found",
+                        "description": "Record not found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict - record is still referenced by a restrict link field",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition failed - If-Match does not match the record ETag",
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/tables/{id}/fields": {
-            "get": {
+            },
+            "patch": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Returns all fields in the specified table.",
+                "description": "Partially update record data with a JSON Merge Patch (RFC 7396, Content-Type",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "fields"
+                    "records"
                 ],
-                "summary": "List fields in a table",
+                "summary": "Patch a record",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Table ID",
+                        "description": "Record ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Expected record version",
+                        "name": "version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Expected record ETag",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Merge patch object or JSON Patch array",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
                     }
                 ],
                 "responses": {
@@ -2585,11 +2750,23 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/dto.FieldListData"
+                                            "$ref": "#/definitions/dto.RecordObject"
                                         }
                                     }
                                 }
                             ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Entity tag of the patched record version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Validation error, failed patch or version conflict",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
                         }
                     },
                     "401": {
@@ -2599,7 +2776,25 @@ const docTemplate = `{
                         }
                     },
                     "403": {
-                        "description": "Forbidden - no access to this table",
+                        "description": "Forbidden - no access to this record or a patched field",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict - unique constraint violated",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition failed - If-Match does not match the record ETag",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported content type",
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
@@ -2607,21 +2802,30 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/tokens": {
+        "/api/v1/records/{id}/files": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Returns all tokens visible to the current token.",
+                "description": "Returns all files attached to a record.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "tokens"
+                    "files"
+                ],
+                "summary": "List files for a record",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Record ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "List all tokens",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -2634,7 +2838,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/dto.TokenListData"
+                                            "$ref": "#/definitions/dto.FileListData"
                                         }
                                     }
                                 }
@@ -2647,21 +2851,23 @@ const docTemplate = `{
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden - no access to this record",
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/api/v1/tables": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Create a new API token. Requires Master Token.",
+                "description": "Create a new table inside a database.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2669,17 +2875,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "tokens"
+                    "tables"
                 ],
-                "summary": "Create a new token",
+                "summary": "Create a table",
                 "parameters": [
                     {
-                        "description": "Token to create",
+                        "description": "Table to create",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.TokenCreateRequest"
+                            "$ref": "#/definitions/dto.TableCreateRequest"
                         }
                     }
                 ],
@@ -2695,7 +2901,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/dto.TokenCreateData"
+                                            "$ref": "#/definitions/dto.TableObject"
                                         }
                                     }
                                 }
@@ -2715,13 +2921,7 @@ const docTemplate = `{
                         }
                     },
                     "403": {
-                        "description": "Forbidden - requires Master Token",
-                        "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
+                        "description": "Forbidden - no access to parent database",
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
@@ -2729,22 +2929,509 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/tokens/{id}": {
-            "put": {
+        "/api/v1/tables/{id}": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Update token scopes and/or expiration date. Requires Master Token.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Retrieve full details of a single table by its ID.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "tokens"
+                    "tables"
+                ],
+                "summary": "Get a table by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Table ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.TableObject"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - no access to this table",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Table not found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update table name and/or description.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tables"
+                ],
+                "summary": "Update a table",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Table ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Table update fields",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.TableUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.TableObject"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Validation error - invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - no access to this table",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Table not found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict - existing records violate a unique key",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete a table and all of its associated fields and records.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tables"
+                ],
+                "summary": "Delete a table",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Table ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "object"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - no access to this table",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Table not found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/tables/{id}/fields": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns all fields in the specified table.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "fields"
+                ],
+                "summary": "List fields in a table",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Table ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.FieldListData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - no access to this table",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/tables/{id}/import": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create a record per row of a data file sent as the request body or as the",
+                "consumes": [
+                    "text/csv",
+                    "application/json",
+                    "application/x-ndjson",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "records"
+                ],
+                "summary": "Import records from CSV, JSON, NDJSON or XLSX",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Table ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "File format: csv, json, ndjson or xlsx",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON object mapping source columns to field names, an empty name skips a column",
+                        "name": "mapping",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Create fields for unmatched columns",
+                        "name": "create_fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "file",
+                        "description": "Data file, for multipart requests",
+                        "name": "file",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.RecordImportData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Validation error - invalid format, mapping or header, or unmatched columns",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - no access to target table",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/tokens": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns all tokens visible to the current token.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tokens"
+                ],
+                "summary": "List all tokens",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.TokenListData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create a new API token. Requires Master Token.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tokens"
+                ],
+                "summary": "Create a new token",
+                "parameters": [
+                    {
+                        "description": "Token to create",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.TokenCreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.TokenCreateData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Validation error - invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - requires Master Token",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/tokens/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update token scopes and/or expiration date. Requires Master Token.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tokens"
                 ],
                 "summary": "Update a token",
                 "parameters": [
@@ -3265,10 +3952,26 @@ const docTemplate = `{
                         ".pdf"
                     ]
                 },
+                "default": {
+                    "type": "string",
+                    "example": "new"
+                },
+                "default_func": {
+                    "type": "string",
+                    "example": "now"
+                },
                 "format": {
                     "type": "string",
                     "example": "2006-01-02"
                 },
+                "formula": {
+                    "type": "string",
+                    "example": "price * quantity"
+                },
+                "link_table_id": {
+                    "type": "string",
+                    "example": "tbl_xyz789"
+                },
                 "max": {
                     "type": "number",
                     "example": 100
@@ -3289,6 +3992,10 @@ const docTemplate = `{
                     "type": "boolean",
                     "example": false
                 },
+                "on_delete": {
+                    "type": "string",
+                    "example": "restrict"
+                },
                 "options": {
                     "type": "array",
                     "items": {
@@ -3299,16 +4006,52 @@ const docTemplate = `{
                         "option2"
                     ]
                 },
+                "padding": {
+                    "type": "integer",
+                    "example": 6
+                },
+                "prefix": {
+                    "type": "string",
+                    "example": "INV-"
+                },
                 "required": {
                     "type": "boolean",
                     "example": false
                 },
+                "start": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "unique": {
+                    "type": "boolean",
+                    "example": false
+                },
                 "validation": {
                     "type": "string",
                     "example": "^[a-z]+$"
                 }
             }
         },
+        "dto.FieldConversion": {
+            "type": "object",
+            "properties": {
+                "date_format": {
+                    "description": "DateFormat is a Go time layout for parsing strings into date or datetime values.",
+                    "type": "string",
+                    "example": "02/01/2006"
+                },
+                "dry_run": {
+                    "description": "DryRun reports the outcome of the conversion without saving anything.",
+                    "type": "boolean",
+                    "example": false
+                },
+                "on_failure": {
+                    "description": "OnFailure is applied to values that cannot be converted: abort (default), null or keep.",
+                    "type": "string",
+                    "example": "abort"
+                }
+            }
+        },
         "dto.FieldCreateRequest": {
             "type": "object",
             "required": [
@@ -3414,6 +4157,9 @@ const docTemplate = `{
                 "config": {
                     "$ref": "#/definitions/dto.FieldConfig"
                 },
+                "conversion": {
+                    "$ref": "#/definitions/dto.FieldConversion"
+                },
                 "description": {
                     "type": "string",
                     "maxLength": 1000,
@@ -3450,71 +4196,193 @@ const docTemplate = `{
                 }
             }
         },
-        "dto.FileObject": {
+        "dto.FileObject": {
+            "type": "object",
+            "properties": {
+                "field_id": {
+                    "type": "string",
+                    "example": "fld_def456"
+                },
+                "file_name": {
+                    "type": "string",
+                    "example": "report.pdf"
+                },
+                "file_size": {
+                    "type": "integer",
+                    "example": 204800
+                },
+                "file_type": {
+                    "type": "string",
+                    "example": "application/pdf"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "fil_mno678"
+                },
+                "record_id": {
+                    "type": "string",
+                    "example": "rec_ghi012"
+                },
+                "storage_url": {
+                    "type": "string",
+                    "example": "./uploads/file_report.pdf"
+                }
+            }
+        },
+        "dto.QueryDSLRequest": {
+            "type": "object"
+        },
+        "dto.QueryResult": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "type": "object",
+                        "additionalProperties": true
+                    }
+                },
+                "has_more": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "prev_cursor": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 100
+                }
+            }
+        },
+        "dto.RecordBatchCreateRequest": {
+            "type": "object",
+            "required": [
+                "data",
+                "table_id"
+            ],
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "table_id": {
+                    "type": "string",
+                    "example": "tbl_xyz789"
+                }
+            }
+        },
+        "dto.RecordBulkDeleteRequest": {
+            "type": "object",
+            "required": [
+                "table_id"
+            ],
+            "properties": {
+                "dry_run": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "filter": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "table_id": {
+                    "type": "string",
+                    "example": "tbl_xyz789"
+                },
+                "where": {
+                    "type": "object",
+                    "additionalProperties": true
+                }
+            }
+        },
+        "dto.RecordBulkInsertData": {
             "type": "object",
             "properties": {
-                "field_id": {
-                    "type": "string",
-                    "example": "fld_def456"
-                },
-                "file_name": {
-                    "type": "string",
-                    "example": "report.pdf"
+                "failed": {
+                    "type": "integer",
+                    "example": 0
                 },
-                "file_size": {
+                "inserted": {
                     "type": "integer",
-                    "example": 204800
+                    "example": 3
                 },
-                "file_type": {
+                "mode": {
                     "type": "string",
-                    "example": "application/pdf"
+                    "example": "atomic"
                 },
-                "id": {
-                    "type": "string",
-                    "example": "fil_mno678"
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.RecordBulkInsertResult"
+                    }
                 },
-                "record_id": {
+                "table_id": {
                     "type": "string",
-                    "example": "rec_ghi012"
+                    "example": "tbl_xyz789"
                 },
-                "storage_url": {
-                    "type": "string",
-                    "example": "./uploads/file_report.pdf"
+                "total": {
+                    "type": "integer",
+                    "example": 3
                 }
             }
         },
-        "dto.QueryDSLRequest": {
-            "type": "object"
-        },
-        "dto.QueryResult": {
+        "dto.RecordBulkInsertRequest": {
             "type": "object",
+            "required": [
+                "records",
+                "table_id"
+            ],
             "properties": {
-                "data": {
+                "mode": {
+                    "type": "string",
+                    "example": "atomic"
+                },
+                "records": {
                     "type": "array",
                     "items": {
                         "type": "object",
                         "additionalProperties": true
                     }
                 },
-                "has_more": {
-                    "type": "boolean",
-                    "example": true
+                "table_id": {
+                    "type": "string",
+                    "example": "tbl_xyz789"
+                }
+            }
+        },
+        "dto.RecordBulkInsertResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
                 },
-                "page": {
-                    "type": "integer",
-                    "example": 1
+                "id": {
+                    "type": "string",
+                    "example": "rec_abc123"
                 },
-                "size": {
+                "index": {
                     "type": "integer",
-                    "example": 20
+                    "example": 0
                 },
-                "total": {
-                    "type": "integer",
-                    "example": 100
+                "status": {
+                    "type": "string",
+                    "example": "created"
                 }
             }
         },
-        "dto.RecordBatchCreateRequest": {
+        "dto.RecordBulkUpdateRequest": {
             "type": "object",
             "required": [
                 "data",
@@ -3525,6 +4393,39 @@ const docTemplate = `{
                     "type": "object",
                     "additionalProperties": true
                 },
+                "dry_run": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "filter": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "table_id": {
+                    "type": "string",
+                    "example": "tbl_xyz789"
+                },
+                "where": {
+                    "type": "object",
+                    "additionalProperties": true
+                }
+            }
+        },
+        "dto.RecordBulkWriteData": {
+            "type": "object",
+            "properties": {
+                "affected": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "dry_run": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "matched": {
+                    "type": "integer",
+                    "example": 12
+                },
                 "table_id": {
                     "type": "string",
                     "example": "tbl_xyz789"
@@ -3548,6 +4449,59 @@ const docTemplate = `{
                 }
             }
         },
+        "dto.RecordImportData": {
+            "type": "object",
+            "properties": {
+                "created_fields": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.RecordImportError"
+                    }
+                },
+                "errors_truncated": {
+                    "type": "boolean"
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "format": {
+                    "type": "string",
+                    "example": "csv"
+                },
+                "inserted": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "table_id": {
+                    "type": "string",
+                    "example": "tbl_xyz789"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "dto.RecordImportError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "field 'price': not a number"
+                },
+                "line": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
         "dto.RecordListData": {
             "type": "object",
             "properties": {
@@ -3555,6 +4509,13 @@ const docTemplate = `{
                     "type": "boolean",
                     "example": true
                 },
+                "next_cursor": {
+                    "description": "Cursors of the records after and before the page, for the after and before parameters",
+                    "type": "string"
+                },
+                "prev_cursor": {
+                    "type": "string"
+                },
                 "records": {
                     "type": "array",
                     "items": {
@@ -3571,10 +4532,21 @@ const docTemplate = `{
             "type": "object",
             "properties": {
                 "data": {},
+                "highlights": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
                 "id": {
                     "type": "string",
                     "example": "rec_ghi012"
                 },
+                "score": {
+                    "description": "Score and Highlights are set on full-text search results: the relevance of the record\nand an HTML snippet of each matching field, with the matched words in \u003cmark\u003e.",
+                    "type": "number",
+                    "example": 1.5
+                },
                 "table_id": {
                     "type": "string",
                     "example": "tbl_xyz789"
@@ -3601,6 +4573,123 @@ const docTemplate = `{
                 }
             }
         },
+        "dto.RecordUpsertData": {
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "inserted": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "key_fields": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "external_id"
+                    ]
+                },
+                "mode": {
+                    "type": "string",
+                    "example": "merge"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.RecordUpsertResult"
+                    }
+                },
+                "table_id": {
+                    "type": "string",
+                    "example": "tbl_xyz789"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "updated": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "dto.RecordUpsertItem": {
+            "type": "object",
+            "required": [
+                "data"
+            ],
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "dto.RecordUpsertRequest": {
+            "type": "object",
+            "required": [
+                "key_fields",
+                "records",
+                "table_id"
+            ],
+            "properties": {
+                "key_fields": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "external_id"
+                    ]
+                },
+                "mode": {
+                    "type": "string",
+                    "example": "merge"
+                },
+                "records": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.RecordUpsertItem"
+                    }
+                },
+                "table_id": {
+                    "type": "string",
+                    "example": "tbl_xyz789"
+                }
+            }
+        },
+        "dto.RecordUpsertResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "rec_ghi012"
+                },
+                "index": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "status": {
+                    "type": "string",
+                    "example": "updated"
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 4
+                }
+            }
+        },
         "dto.TableCreateRequest": {
             "type": "object",
             "required": [
@@ -3658,6 +4747,12 @@ const docTemplate = `{
                 "name": {
                     "type": "string",
                     "example": "orders"
+                },
+                "unique_keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.UniqueKey"
+                    }
                 }
             }
         },
@@ -3677,6 +4772,13 @@ const docTemplate = `{
                     "maxLength": 255,
                     "minLength": 2,
                     "example": "orders_v2"
+                },
+                "unique_keys": {
+                    "description": "Replaces the composite unique keys when set",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.UniqueKey"
+                    }
                 }
             }
         },
@@ -3779,6 +4881,25 @@ const docTemplate = `{
                     "example": "read"
                 }
             }
+        },
+        "dto.UniqueKey": {
+            "type": "object",
+            "properties": {
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "customer",
+                        "order_no"
+                    ]
+                },
+                "name": {
+                    "type": "string",
+                    "example": "uk_customer_order"
+                }
+            }
         }
     },
     "securityDefinitions": {
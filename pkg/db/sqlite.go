@@ -0,0 +1,100 @@
+package db
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+	_ "time/tzdata" // the SQLite timezone function needs a zone database on every host
+
+	sqlite "github.com/glebarez/go-sqlite"
+)
+
+// maxCachedRegexps bounds the compiled patterns kept by the SQLite regexp function.
+const maxCachedRegexps = 64
+
+var (
+	regexpCacheMu sync.Mutex
+	regexpCache   = make(map[string]*regexp.Regexp)
+)
+
+// SQLite has the REGEXP operator but no function behind it: `x REGEXP y` calls regexp(y, x),
+// which is registered here for every SQLite connection, with Go regular expression syntax.
+// SQLite has no time zones either: timezone(zone, x) converts a UTC datetime to local time of an
+// IANA zone, like the PostgreSQL function of the same name.
+func init() {
+	sqlite.MustRegisterDeterministicScalarFunction("regexp", 2, sqliteRegexp)
+	sqlite.MustRegisterDeterministicScalarFunction("timezone", 2, sqliteTimezone)
+}
+
+func sqliteRegexp(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	if args[0] == nil || args[1] == nil {
+		return nil, nil
+	}
+	pattern, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("regexp pattern must be text")
+	}
+	re, err := cachedRegexp(pattern)
+	if err != nil {
+		return nil, err
+	}
+	var value string
+	switch v := args[1].(type) {
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		value = fmt.Sprint(v)
+	}
+	if re.MatchString(value) {
+		return int64(1), nil
+	}
+	return int64(0), nil
+}
+
+func cachedRegexp(pattern string) (*regexp.Regexp, error) {
+	regexpCacheMu.Lock()
+	defer regexpCacheMu.Unlock()
+	if re, ok := regexpCache[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(regexpCache) >= maxCachedRegexps {
+		clear(regexpCache)
+	}
+	regexpCache[pattern] = re
+	return re, nil
+}
+
+// sqliteDatetimeLayout is the format of SQLite date and time functions.
+const sqliteDatetimeLayout = "2006-01-02 15:04:05"
+
+func sqliteTimezone(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	if args[0] == nil || args[1] == nil {
+		return nil, nil
+	}
+	zone, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("timezone zone must be text")
+	}
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", zone)
+	}
+	value, ok := args[1].(string)
+	if !ok {
+		return nil, nil
+	}
+	for _, layout := range []string{sqliteDatetimeLayout, time.RFC3339Nano, "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.UTC); err == nil {
+			return t.In(loc).Format(sqliteDatetimeLayout), nil
+		}
+	}
+	return nil, nil
+}
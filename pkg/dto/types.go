@@ -86,18 +86,27 @@ type TableCreateRequest struct {
 	Description string `json:"description" binding:"max=500" example:"Order records"`
 }
 
+// UniqueKey is a table-level unique constraint over a combination of fields.
+// Records where any of the fields is empty are not constrained.
+type UniqueKey struct {
+	Name   string   `json:"name" example:"uk_customer_order"`
+	Fields []string `json:"fields" example:"customer,order_no"`
+}
+
 // TableUpdateRequest body for PUT /api/tables/{id}
 type TableUpdateRequest struct {
-	Name        string `json:"name" binding:"required,min=2,max=255" example:"orders_v2"`
-	Description string `json:"description" binding:"max=500" example:"Updated orders"`
+	Name        string       `json:"name" binding:"required,min=2,max=255" example:"orders_v2"`
+	Description string       `json:"description" binding:"max=500" example:"Updated orders"`
+	UniqueKeys  *[]UniqueKey `json:"unique_keys,omitempty"` // Replaces the composite unique keys when set
 }
 
 // TableObject represents a single table in responses.
 type TableObject struct {
-	ID          string `json:"id" example:"tbl_xyz789"`
-	DatabaseID  string `json:"database_id" example:"db_abc123"`
-	Name        string `json:"name" example:"orders"`
-	Description string `json:"description" example:"Order records"`
+	ID          string      `json:"id" example:"tbl_xyz789"`
+	DatabaseID  string      `json:"database_id" example:"db_abc123"`
+	Name        string      `json:"name" example:"orders"`
+	Description string      `json:"description" example:"Order records"`
+	UniqueKeys  []UniqueKey `json:"unique_keys,omitempty"`
 }
 
 // TableListData is the data payload for GET /api/databases/{id}/tables.
@@ -110,16 +119,25 @@ type TableListData struct {
 
 // FieldConfig describes the configuration for list, number, file and other typed fields.
 type FieldConfig struct {
-	Options       []string `json:"options,omitempty" example:"option1,option2"`
-	Required      bool     `json:"required,omitempty" example:"false"`
-	Min           *float64 `json:"min,omitempty" example:"0"`
-	Max           *float64 `json:"max,omitempty" example:"100"`
-	Format        string   `json:"format,omitempty" example:"2006-01-02"`
-	MaxLength     *int     `json:"max_length,omitempty" example:"255"`
-	Validation    string   `json:"validation,omitempty" example:"^[a-z]+$"`
-	AllowedTypes  []string `json:"allowed_types,omitempty" example:"image/*,.pdf"`
-	MaxFileSizeMB int      `json:"max_file_size_mb,omitempty" example:"10"`
-	Multiple      bool     `json:"multiple,omitempty" example:"false"`
+	Options       []string    `json:"options,omitempty" example:"option1,option2"`
+	Required      bool        `json:"required,omitempty" example:"false"`
+	Min           *float64    `json:"min,omitempty" example:"0"`
+	Max           *float64    `json:"max,omitempty" example:"100"`
+	Format        string      `json:"format,omitempty" example:"2006-01-02"`
+	MaxLength     *int        `json:"max_length,omitempty" example:"255"`
+	Validation    string      `json:"validation,omitempty" example:"^[a-z]+$"`
+	AllowedTypes  []string    `json:"allowed_types,omitempty" example:"image/*,.pdf"`
+	MaxFileSizeMB int         `json:"max_file_size_mb,omitempty" example:"10"`
+	Multiple      bool        `json:"multiple,omitempty" example:"false"`
+	LinkTableID   string      `json:"link_table_id,omitempty" example:"tbl_xyz789"`
+	OnDelete      string      `json:"on_delete,omitempty" example:"restrict"`
+	Formula       string      `json:"formula,omitempty" example:"price * quantity"`
+	Prefix        string      `json:"prefix,omitempty" example:"INV-"`
+	Padding       int         `json:"padding,omitempty" example:"6"`
+	Start         *int64      `json:"start,omitempty" example:"1"`
+	Unique        bool        `json:"unique,omitempty" example:"false"`
+	Default       interface{} `json:"default,omitempty" swaggertype:"string" example:"new"`
+	DefaultFunc   string      `json:"default_func,omitempty" example:"now"`
 }
 
 // FieldCreateRequest body for POST /api/fields
@@ -135,12 +153,43 @@ type FieldCreateRequest struct {
 
 // FieldUpdateRequest body for PUT /api/fields/{id}
 type FieldUpdateRequest struct {
-	Name        string      `json:"name" binding:"required,min=1,max=255" example:"status"`
-	Type        string      `json:"type" binding:"required" example:"string"`
-	Description string      `json:"description" binding:"max=1000" example:"Current status"`
-	Required    bool        `json:"required" example:"true"`
-	Options     string      `json:"options" example:"active,inactive"`
-	Config      FieldConfig `json:"config"`
+	Name        string           `json:"name" binding:"required,min=1,max=255" example:"status"`
+	Type        string           `json:"type" binding:"required" example:"string"`
+	Description string           `json:"description" binding:"max=1000" example:"Current status"`
+	Required    bool             `json:"required" example:"true"`
+	Options     string           `json:"options" example:"active,inactive"`
+	Config      FieldConfig      `json:"config"`
+	Conversion  *FieldConversion `json:"conversion,omitempty"`
+}
+
+// FieldConversion controls how existing record values are converted when a field changes type.
+type FieldConversion struct {
+	// DryRun reports the outcome of the conversion without saving anything.
+	DryRun bool `json:"dry_run,omitempty" example:"false"`
+	// OnFailure is applied to values that cannot be converted: abort (default), null or keep.
+	OnFailure string `json:"on_failure,omitempty" example:"abort"`
+	// DateFormat is a Go time layout for parsing strings into date or datetime values.
+	DateFormat string `json:"date_format,omitempty" example:"02/01/2006"`
+}
+
+// FieldConversionFailure describes a value that could not be converted.
+type FieldConversionFailure struct {
+	RecordID string      `json:"record_id" example:"rec_abc123"`
+	Value    interface{} `json:"value" swaggertype:"string" example:"n/a"`
+	Error    string      `json:"error" example:"not a number"`
+}
+
+// FieldConversionReport summarizes the conversion of existing values to a new field type.
+type FieldConversionReport struct {
+	FieldID   string                   `json:"field_id" example:"fld_def456"`
+	FromType  string                   `json:"from_type" example:"string"`
+	ToType    string                   `json:"to_type" example:"number"`
+	DryRun    bool                     `json:"dry_run" example:"true"`
+	OnFailure string                   `json:"on_failure" example:"abort"`
+	Total     int                      `json:"total" example:"120"`
+	Converted int                      `json:"converted" example:"118"`
+	Failed    int                      `json:"failed" example:"2"`
+	Failures  []FieldConversionFailure `json:"failures,omitempty"`
 }
 
 // FieldObject represents a single field in responses.
@@ -176,12 +225,27 @@ type RecordUpdateRequest struct {
 	Version int                    `json:"version" example:"3"`
 }
 
+// RecordPatchRequest is a patch of a record's data for PATCH /api/records/{id}: a JSON Merge
+// Patch (RFC 7396) object with format merge, or a JSON Patch (RFC 6902) array with format json.
+type RecordPatchRequest struct {
+	Format  string      `json:"format" example:"merge"`
+	Patch   interface{} `json:"patch"`
+	Version int         `json:"version" example:"3"`
+}
+
 // RecordObject represents a single record in responses.
 type RecordObject struct {
 	ID      string `json:"id" example:"rec_ghi012"`
 	TableID string `json:"table_id" example:"tbl_xyz789"`
 	Data    any    `json:"data"`
 	Version int    `json:"version" example:"1"`
+	// Score and Highlights are set on full-text search results: the relevance of the record
+	// and an HTML snippet of each matching field, with the matched words in <mark>.
+	Score      *float64          `json:"score,omitempty" example:"1.5"`
+	Highlights map[string]string `json:"highlights,omitempty"`
+	// Volatile is set when the data holds formulas reading today() or now(), whose values
+	// change between reads of the same version.
+	Volatile bool `json:"-"`
 }
 
 // RecordListData is the data payload for GET /api/records.
@@ -189,6 +253,10 @@ type RecordListData struct {
 	Records []RecordObject `json:"records"`
 	Total   int64          `json:"total" example:"42"`
 	HasMore bool           `json:"has_more" example:"true"`
+
+	// Cursors of the records after and before the page, for the after and before parameters
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
 }
 
 // RecordListQueryRequest is the simplified list query for GET /api/records.
@@ -196,10 +264,51 @@ type RecordListQueryRequest struct {
 	TableID string `json:"table_id" form:"table_id" binding:"required"`
 	Limit   int    `json:"limit" form:"limit" binding:"min=1,max=100"`
 	Offset  int    `json:"offset" form:"offset" binding:"min=0"`
+	After   string `json:"after" form:"after"`
+	Before  string `json:"before" form:"before"`
+	Filter  string `json:"filter" form:"filter"`
+	Sort    string `json:"sort" form:"sort"`
+	Q       string `json:"q" form:"q"`
+	Fields  string `json:"fields" form:"fields"`
+}
+
+// RecordExportRequest is the export query for GET /api/records/export.
+type RecordExportRequest struct {
+	TableID string `json:"table_id" form:"table_id" binding:"required"`
+	Format  string `json:"format" form:"format"`
 	Filter  string `json:"filter" form:"filter"`
+	Sort    string `json:"sort" form:"sort"`
 	Fields  string `json:"fields" form:"fields"`
 }
 
+// RecordImportRequest describes an import for POST /api/v1/tables/{id}/import. Mapping maps
+// source columns to field names; an empty name skips the column.
+type RecordImportRequest struct {
+	TableID      string            `json:"table_id" example:"tbl_xyz789"`
+	Format       string            `json:"format" example:"csv"`
+	Mapping      map[string]string `json:"mapping,omitempty"`
+	CreateFields bool              `json:"create_fields"`
+}
+
+// RecordImportError is a row of an import that was not imported.
+type RecordImportError struct {
+	Line  int    `json:"line" example:"3"`
+	Error string `json:"error" example:"field 'price': not a number"`
+}
+
+// RecordImportData is the data payload for a record import. Errors holds the first 100 row
+// errors; ErrorsTruncated reports that there were more.
+type RecordImportData struct {
+	TableID         string              `json:"table_id" example:"tbl_xyz789"`
+	Format          string              `json:"format" example:"csv"`
+	Total           int                 `json:"total" example:"3"`
+	Inserted        int                 `json:"inserted" example:"2"`
+	Failed          int                 `json:"failed" example:"1"`
+	CreatedFields   []string            `json:"created_fields"`
+	Errors          []RecordImportError `json:"errors"`
+	ErrorsTruncated bool                `json:"errors_truncated,omitempty"`
+}
+
 // BatchQueryData is the data payload for POST /api/query/batch.
 type BatchQueryData struct {
 	Results map[string]QueryResult `json:"results"`
@@ -217,6 +326,91 @@ type RecordBatchCreateData struct {
 	Count   int            `json:"count"`
 }
 
+// RecordBulkInsertRequest body for POST /api/records/bulk
+type RecordBulkInsertRequest struct {
+	TableID string                   `json:"table_id" binding:"required" example:"tbl_xyz789"`
+	Records []map[string]interface{} `json:"records" binding:"required"`
+	Mode    string                   `json:"mode" example:"atomic"`
+}
+
+// RecordBulkInsertResult is the outcome of one record of a bulk insert.
+type RecordBulkInsertResult struct {
+	Index  int    `json:"index" example:"0"`
+	Status string `json:"status" example:"created"`
+	ID     string `json:"id,omitempty" example:"rec_abc123"`
+	Error  string `json:"error,omitempty"`
+}
+
+// RecordBulkInsertData is the data payload for bulk record insertion.
+type RecordBulkInsertData struct {
+	TableID  string                   `json:"table_id" example:"tbl_xyz789"`
+	Mode     string                   `json:"mode" example:"atomic"`
+	Total    int                      `json:"total" example:"3"`
+	Inserted int                      `json:"inserted" example:"3"`
+	Failed   int                      `json:"failed" example:"0"`
+	Results  []RecordBulkInsertResult `json:"results"`
+}
+
+// RecordUpsertRequest body for POST /api/records/upsert
+type RecordUpsertRequest struct {
+	TableID   string             `json:"table_id" binding:"required" example:"tbl_xyz789"`
+	KeyFields []string           `json:"key_fields" binding:"required" example:"external_id"`
+	Records   []RecordUpsertItem `json:"records" binding:"required"`
+	Mode      string             `json:"mode" example:"merge"`
+}
+
+// RecordUpsertItem is one record of an upsert. Version, when set, must match the existing record.
+type RecordUpsertItem struct {
+	Data    map[string]interface{} `json:"data" binding:"required"`
+	Version int                    `json:"version" example:"3"`
+}
+
+// RecordUpsertResult is the outcome of one record of an upsert.
+type RecordUpsertResult struct {
+	Index   int    `json:"index" example:"0"`
+	Status  string `json:"status" example:"updated"`
+	ID      string `json:"id,omitempty" example:"rec_ghi012"`
+	Version int    `json:"version,omitempty" example:"4"`
+	Error   string `json:"error,omitempty"`
+}
+
+// RecordUpsertData is the data payload for record upserts.
+type RecordUpsertData struct {
+	TableID   string               `json:"table_id" example:"tbl_xyz789"`
+	KeyFields []string             `json:"key_fields" example:"external_id"`
+	Mode      string               `json:"mode" example:"merge"`
+	Total     int                  `json:"total" example:"2"`
+	Inserted  int                  `json:"inserted" example:"1"`
+	Updated   int                  `json:"updated" example:"1"`
+	Failed    int                  `json:"failed" example:"0"`
+	Results   []RecordUpsertResult `json:"results"`
+}
+
+// RecordBulkUpdateRequest body for POST /api/records/bulk-update
+type RecordBulkUpdateRequest struct {
+	TableID string                 `json:"table_id" binding:"required" example:"tbl_xyz789"`
+	Where   map[string]interface{} `json:"where"`
+	Filter  map[string]interface{} `json:"filter"`
+	Data    map[string]interface{} `json:"data" binding:"required"`
+	DryRun  bool                   `json:"dry_run" example:"false"`
+}
+
+// RecordBulkDeleteRequest body for POST /api/records/bulk-delete
+type RecordBulkDeleteRequest struct {
+	TableID string                 `json:"table_id" binding:"required" example:"tbl_xyz789"`
+	Where   map[string]interface{} `json:"where"`
+	Filter  map[string]interface{} `json:"filter"`
+	DryRun  bool                   `json:"dry_run" example:"false"`
+}
+
+// RecordBulkWriteData is the data payload for bulk record updates and deletes.
+type RecordBulkWriteData struct {
+	TableID  string `json:"table_id" example:"tbl_xyz789"`
+	DryRun   bool   `json:"dry_run" example:"false"`
+	Matched  int    `json:"matched" example:"12"`
+	Affected int    `json:"affected" example:"12"`
+}
+
 // --- Token ---
 
 // TokenCreateRequest body for POST /api/tokens
@@ -293,6 +487,9 @@ type QueryDSLRequest struct {
 	OrderBy   []OrderByClause   `json:"orderBy"`
 	Page      int               `json:"page" example:"1"`
 	Size      int               `json:"size" example:"20"`
+	Cursor    bool              `json:"cursor,omitempty" example:"true"`
+	After     string            `json:"after,omitempty"`
+	Before    string            `json:"before,omitempty"`
 	Union     []QueryDSLRequest `json:"union,omitempty"`
 	Table     string            `json:"table" example:"records"`
 	Filter    map[string]any    `json:"filter"`
@@ -351,6 +548,9 @@ type QueryResult struct {
 	Page    int                      `json:"page" example:"1"`
 	Size    int                      `json:"size" example:"20"`
 	HasMore bool                     `json:"has_more" example:"true"`
+
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
 }
 
 // BatchQueryRequest body for POST /api/query/batch
@@ -0,0 +1,504 @@
+package formula
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrDivisionByZero is returned when a formula divides by zero.
+var ErrDivisionByZero = errors.New("division by zero")
+
+const (
+	dateLayout     = "2006-01-02"
+	datetimeLayout = time.RFC3339
+)
+
+// Eval evaluates the formula against a record payload.
+// Null operands propagate (SQL semantics): arithmetic and comparisons involving null yield nil.
+// now is the reference time for today() and now().
+func (e *Expression) Eval(values map[string]interface{}, now time.Time) (interface{}, error) {
+	ev := evaluator{values: values, now: now.UTC()}
+	return ev.eval(e.root)
+}
+
+type evaluator struct {
+	values map[string]interface{}
+	now    time.Time
+}
+
+func (ev evaluator) eval(n node) (interface{}, error) {
+	switch n := n.(type) {
+	case *literal:
+		return n.value, nil
+
+	case *fieldRef:
+		return normalizeValue(ev.values[n.name]), nil
+
+	case *unary:
+		operand, err := ev.eval(n.operand)
+		if err != nil || operand == nil {
+			return nil, err
+		}
+		if n.op == "not" {
+			b, err := toBool(operand)
+			if err != nil {
+				return nil, err
+			}
+			return !b, nil
+		}
+		num, err := toNumber(operand)
+		if err != nil {
+			return nil, err
+		}
+		return -num, nil
+
+	case *binary:
+		return ev.evalBinary(n)
+
+	case *call:
+		return ev.evalCall(n)
+	}
+	return nil, fmt.Errorf("unsupported formula node %T", n)
+}
+
+func (ev evaluator) evalBinary(n *binary) (interface{}, error) {
+	left, err := ev.eval(n.left)
+	if err != nil {
+		return nil, err
+	}
+
+	// and / or use three-valued logic and short-circuit like SQL.
+	if n.op == "and" || n.op == "or" {
+		return ev.evalLogical(n, left)
+	}
+
+	right, err := ev.eval(n.right)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "&" {
+		return toText(left) + toText(right), nil
+	}
+	if left == nil || right == nil {
+		return nil, nil
+	}
+
+	switch n.op {
+	case "+", "-", "*", "/", "%":
+		a, err := toNumber(left)
+		if err != nil {
+			return nil, err
+		}
+		b, err := toNumber(right)
+		if err != nil {
+			return nil, err
+		}
+		switch n.op {
+		case "+":
+			return a + b, nil
+		case "-":
+			return a - b, nil
+		case "*":
+			return a * b, nil
+		case "/":
+			if b == 0 {
+				return nil, ErrDivisionByZero
+			}
+			return a / b, nil
+		default:
+			if b == 0 {
+				return nil, ErrDivisionByZero
+			}
+			return math.Mod(a, b), nil
+		}
+	}
+
+	cmp, err := compare(left, right)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "=":
+		return cmp == 0, nil
+	case "!=":
+		return cmp != 0, nil
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+func (ev evaluator) evalLogical(n *binary, left interface{}) (interface{}, error) {
+	var l *bool
+	if left != nil {
+		b, err := toBool(left)
+		if err != nil {
+			return nil, err
+		}
+		l = &b
+	}
+	if l != nil && (n.op == "and" && !*l || n.op == "or" && *l) {
+		return *l, nil
+	}
+
+	right, err := ev.eval(n.right)
+	if err != nil {
+		return nil, err
+	}
+	if right == nil {
+		return nil, nil
+	}
+	r, err := toBool(right)
+	if err != nil {
+		return nil, err
+	}
+	if l == nil {
+		if n.op == "and" && !r || n.op == "or" && r {
+			return r, nil
+		}
+		return nil, nil
+	}
+	return r, nil
+}
+
+func (ev evaluator) evalCall(n *call) (interface{}, error) {
+	switch n.name {
+	case "today":
+		return ev.now.Format(dateLayout), nil
+	case "now":
+		return ev.now.Format(datetimeLayout), nil
+	case "if":
+		cond, err := ev.eval(n.args[0])
+		if err != nil {
+			return nil, err
+		}
+		branch := n.args[2]
+		if cond != nil {
+			b, err := toBool(cond)
+			if err != nil {
+				return nil, err
+			}
+			if b {
+				branch = n.args[1]
+			}
+		}
+		return ev.eval(branch)
+	case "case":
+		for i := 0; i+1 < len(n.args); i += 2 {
+			cond, err := ev.eval(n.args[i])
+			if err != nil {
+				return nil, err
+			}
+			if cond == nil {
+				continue
+			}
+			b, err := toBool(cond)
+			if err != nil {
+				return nil, err
+			}
+			if b {
+				return ev.eval(n.args[i+1])
+			}
+		}
+		if len(n.args)%2 == 1 {
+			return ev.eval(n.args[len(n.args)-1])
+		}
+		return nil, nil
+	case "coalesce":
+		for _, arg := range n.args {
+			value, err := ev.eval(arg)
+			if err != nil {
+				return nil, err
+			}
+			if value != nil {
+				return value, nil
+			}
+		}
+		return nil, nil
+	}
+
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		value, err := ev.eval(arg)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+
+	switch n.name {
+	case "concat":
+		var sb strings.Builder
+		for _, arg := range args {
+			sb.WriteString(toText(arg))
+		}
+		return sb.String(), nil
+	case "is_blank":
+		return args[0] == nil || args[0] == "", nil
+	}
+
+	for _, arg := range args {
+		if arg == nil {
+			return nil, nil
+		}
+	}
+
+	switch n.name {
+	case "abs", "floor", "ceil", "round":
+		x, err := toNumber(args[0])
+		if err != nil {
+			return nil, err
+		}
+		switch n.name {
+		case "abs":
+			return math.Abs(x), nil
+		case "floor":
+			return math.Floor(x), nil
+		case "ceil":
+			return math.Ceil(x), nil
+		}
+		places := 0.0
+		if len(args) > 1 {
+			if places, err = toNumber(args[1]); err != nil {
+				return nil, err
+			}
+		}
+		scale := math.Pow(10, math.Trunc(places))
+		return math.Round(x*scale) / scale, nil
+
+	case "min", "max":
+		result, err := toNumber(args[0])
+		if err != nil {
+			return nil, err
+		}
+		for _, arg := range args[1:] {
+			x, err := toNumber(arg)
+			if err != nil {
+				return nil, err
+			}
+			if n.name == "min" && x < result || n.name == "max" && x > result {
+				result = x
+			}
+		}
+		return result, nil
+
+	case "upper":
+		return strings.ToUpper(toText(args[0])), nil
+	case "lower":
+		return strings.ToLower(toText(args[0])), nil
+	case "trim":
+		return strings.TrimSpace(toText(args[0])), nil
+	case "length":
+		return float64(len([]rune(toText(args[0])))), nil
+	case "substring":
+		return substring(args)
+	case "to_number":
+		return toNumber(args[0])
+	case "to_text":
+		return toText(args[0]), nil
+	case "to_date":
+		t, err := toDate(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return t.Format(dateLayout), nil
+
+	case "year", "month", "day":
+		t, err := toDate(args[0])
+		if err != nil {
+			return nil, err
+		}
+		switch n.name {
+		case "year":
+			return float64(t.Year()), nil
+		case "month":
+			return float64(t.Month()), nil
+		}
+		return float64(t.Day()), nil
+
+	case "days_between":
+		from, err := toDate(args[0])
+		if err != nil {
+			return nil, err
+		}
+		to, err := toDate(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return math.Round(to.Sub(from).Hours() / 24), nil
+
+	case "add_days":
+		t, err := toDate(args[0])
+		if err != nil {
+			return nil, err
+		}
+		days, err := toNumber(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return t.AddDate(0, 0, int(days)).Format(dateLayout), nil
+	}
+	return nil, fmt.Errorf("unsupported function %s", n.name)
+}
+
+// substring returns the characters of text from a 1-based start, up to an optional length,
+// counting positions before the first character like SQL.
+func substring(args []interface{}) (interface{}, error) {
+	text := []rune(toText(args[0]))
+	start, err := toNumber(args[1])
+	if err != nil {
+		return nil, err
+	}
+	from, to := int(start), len(text)+1
+	if len(args) > 2 {
+		length, err := toNumber(args[2])
+		if err != nil {
+			return nil, err
+		}
+		if length < 0 {
+			return nil, fmt.Errorf("substring length cannot be negative")
+		}
+		to = min(from+int(length), to)
+	}
+	from = max(from, 1)
+	if from >= to {
+		return "", nil
+	}
+	return string(text[from-1 : to-1]), nil
+}
+
+// normalizeValue converts decoded JSON and Go numeric values to the evaluator's value set.
+func normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	}
+	return value
+}
+
+func toNumber(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("cannot use %q as a number", v)
+		}
+		return f, nil
+	}
+	return 0, fmt.Errorf("cannot use %T as a number", value)
+}
+
+func toBool(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case float64:
+		return v != 0, nil
+	case string:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return false, fmt.Errorf("cannot use %q as a boolean", v)
+		}
+		return b, nil
+	}
+	return false, fmt.Errorf("cannot use %T as a boolean", value)
+}
+
+func toText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(value)
+}
+
+// toDate parses the date part of an ISO date or datetime string.
+func toDate(value interface{}) (time.Time, error) {
+	s, ok := value.(string)
+	if !ok || len(s) < len(dateLayout) {
+		return time.Time{}, fmt.Errorf("cannot use %v as a date", value)
+	}
+	t, err := time.Parse(dateLayout, s[:len(dateLayout)])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot use %q as a date", s)
+	}
+	return t, nil
+}
+
+// compare orders two non-null values: numbers numerically, booleans false < true,
+// everything else (including ISO dates) as strings.
+func compare(left, right interface{}) (int, error) {
+	switch l := left.(type) {
+	case float64:
+		r, err := toNumber(right)
+		if err != nil {
+			return 0, err
+		}
+		return compareOrdered(l, r), nil
+	case bool:
+		r, err := toBool(right)
+		if err != nil {
+			return 0, err
+		}
+		return compareOrdered(boolRank(l), boolRank(r)), nil
+	}
+	if r, ok := right.(float64); ok {
+		l, err := toNumber(left)
+		if err != nil {
+			return 0, err
+		}
+		return compareOrdered(l, r), nil
+	}
+	return strings.Compare(toText(left), toText(right)), nil
+}
+
+func compareOrdered(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func boolRank(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
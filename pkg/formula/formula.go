@@ -0,0 +1,693 @@
+// Package formula implements the expression language of computed (formula) fields.
+//
+// A formula references other fields of the same table by name and combines them with
+// arithmetic, comparison, logical and string operators and a small whitelist of functions:
+//
+//	price * quantity
+//	if(stock <= 0, 'sold out', 'available')
+//	days_between({Start Date}, today())
+//
+// Bare identifiers reference fields; names that are not plain identifiers are wrapped
+// in braces. The language has no side effects, loops or user-defined functions, and
+// the same parsed Expression can be evaluated in Go (Eval) or compiled to SQL (SQL).
+package formula
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Parser limits keep evaluation and generated SQL bounded.
+const (
+	MaxLength = 1000
+	MaxDepth  = 32
+)
+
+// Type is the static type of an expression.
+type Type string
+
+// Expression types. TypeAny is used for fields whose values carry no usable type (json, list, ...).
+const (
+	TypeAny      Type = "any"
+	TypeNumber   Type = "number"
+	TypeString   Type = "string"
+	TypeBool     Type = "boolean"
+	TypeDate     Type = "date"
+	TypeDatetime Type = "datetime"
+)
+
+// TypeOfField maps a table field type to the formula type of its values.
+func TypeOfField(fieldType string) Type {
+	switch fieldType {
+	case "number":
+		return TypeNumber
+	case "string", "text", "link", "autonumber":
+		return TypeString
+	case "boolean":
+		return TypeBool
+	case "date":
+		return TypeDate
+	case "datetime":
+		return TypeDatetime
+	default:
+		return TypeAny
+	}
+}
+
+// Expression is a parsed formula.
+type Expression struct {
+	source   string
+	root     node
+	refs     []string
+	volatile bool // calls today() or now()
+}
+
+// Parse parses a formula expression.
+func Parse(source string) (*Expression, error) {
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return nil, fmt.Errorf("formula cannot be empty")
+	}
+	if len(source) > MaxLength {
+		return nil, fmt.Errorf("formula is too long (max %d characters)", MaxLength)
+	}
+
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseExpression(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+
+	expr := &Expression{source: source, root: root}
+	seen := make(map[string]struct{})
+	walk(root, func(n node) {
+		if ref, ok := n.(*fieldRef); ok {
+			if _, exists := seen[ref.name]; !exists {
+				seen[ref.name] = struct{}{}
+				expr.refs = append(expr.refs, ref.name)
+			}
+		}
+		if fn, ok := n.(*call); ok && (fn.name == "today" || fn.name == "now") {
+			expr.volatile = true
+		}
+	})
+	return expr, nil
+}
+
+// String returns the formula source.
+func (e *Expression) String() string {
+	return e.source
+}
+
+// References returns the field names referenced by the formula, in order of first use.
+func (e *Expression) References() []string {
+	return append([]string(nil), e.refs...)
+}
+
+// Volatile reports whether the formula reads the clock through today() or now(), so its value
+// changes while its fields stay the same.
+func (e *Expression) Volatile() bool {
+	return e.volatile
+}
+
+// Check type-checks the formula against the types of the referenced fields and returns its result type.
+func (e *Expression) Check(fieldTypes map[string]Type) (Type, error) {
+	return check(e.root, fieldTypes)
+}
+
+// ---- AST ----
+
+type node interface{}
+
+type literal struct {
+	value interface{} // nil, float64, string or bool
+}
+
+type fieldRef struct {
+	name string
+}
+
+type unary struct {
+	op      string // "-" or "not"
+	operand node
+}
+
+type binary struct {
+	op          string
+	left, right node
+}
+
+type call struct {
+	name string
+	args []node
+}
+
+func walk(n node, visit func(node)) {
+	visit(n)
+	switch n := n.(type) {
+	case *unary:
+		walk(n.operand, visit)
+	case *binary:
+		walk(n.left, visit)
+		walk(n.right, visit)
+	case *call:
+		for _, arg := range n.args {
+			walk(arg, visit)
+		}
+	}
+}
+
+// ---- Lexer ----
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenField // {field name}
+	tokenOp
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+var operators = []string{"==", "!=", "<>", "<=", ">=", "&&", "||", "+", "-", "*", "/", "%", "&", "=", "<", ">", "!"}
+
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		r, size := utf8.DecodeRuneInString(src[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+
+		case r >= '0' && r <= '9' || r == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			start := i
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.') {
+				i++
+			}
+			if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+				i++
+				if i < len(src) && (src[i] == '+' || src[i] == '-') {
+					i++
+				}
+				for i < len(src) && src[i] >= '0' && src[i] <= '9' {
+					i++
+				}
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: src[start:i], pos: start})
+
+		case r == '\'' || r == '"':
+			start := i
+			text, next, err := scanString(src, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, text: text, pos: start})
+			i = next
+
+		case r == '{':
+			end := strings.IndexByte(src[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated field reference at position %d", i)
+			}
+			name := strings.TrimSpace(src[i+1 : i+end])
+			if name == "" {
+				return nil, fmt.Errorf("empty field reference at position %d", i)
+			}
+			tokens = append(tokens, token{kind: tokenField, text: name, pos: i})
+			i += end + 1
+
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(src) {
+				r, size := utf8.DecodeRuneInString(src[i:])
+				if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+					break
+				}
+				i += size
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: src[start:i], pos: start})
+
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", pos: i})
+			i++
+
+		default:
+			matched := ""
+			for _, op := range operators {
+				if strings.HasPrefix(src[i:], op) {
+					matched = op
+					break
+				}
+			}
+			if matched == "" {
+				return nil, fmt.Errorf("unexpected character %q at position %d", r, i)
+			}
+			tokens = append(tokens, token{kind: tokenOp, text: matched, pos: i})
+			i += len(matched)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, text: "end of formula", pos: len(src)}), nil
+}
+
+func scanString(src string, start int) (string, int, error) {
+	quote := src[start]
+	var sb strings.Builder
+	for i := start + 1; i < len(src); i++ {
+		ch := src[i]
+		switch {
+		case ch == '\\' && i+1 < len(src):
+			i++
+			switch src[i] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			default:
+				sb.WriteByte(src[i])
+			}
+		case ch == quote:
+			return sb.String(), i + 1, nil
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string at position %d", start)
+}
+
+// ---- Parser ----
+
+type parser struct {
+	tokens []token
+	pos    int
+	depth  int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// binaryOperator normalizes an operator token and returns its precedence (0 when it is not binary).
+func binaryOperator(tok token) (string, int) {
+	text := tok.text
+	if tok.kind == tokenIdent {
+		text = strings.ToLower(text)
+	} else if tok.kind != tokenOp {
+		return "", 0
+	}
+	switch text {
+	case "or", "||":
+		return "or", 1
+	case "and", "&&":
+		return "and", 2
+	case "=", "==":
+		return "=", 4
+	case "!=", "<>":
+		return "!=", 4
+	case "<", "<=", ">", ">=":
+		return text, 4
+	case "&":
+		return "&", 5
+	case "+", "-":
+		return text, 6
+	case "*", "/", "%":
+		return text, 7
+	}
+	return "", 0
+}
+
+const notPrecedence = 3
+
+func (p *parser) parseExpression(minPrec int) (node, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > MaxDepth {
+		return nil, fmt.Errorf("formula is nested too deeply (max depth %d)", MaxDepth)
+	}
+
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, prec := binaryOperator(p.peek())
+		if prec == 0 || prec <= minPrec {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseExpression(prec)
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	tok := p.peek()
+	if tok.kind == tokenOp && (tok.text == "-" || tok.text == "+") {
+		p.next()
+		operand, err := p.parseExpression(7)
+		if err != nil {
+			return nil, err
+		}
+		if tok.text == "+" {
+			return operand, nil
+		}
+		return &unary{op: "-", operand: operand}, nil
+	}
+	if tok.kind == tokenOp && tok.text == "!" || tok.kind == tokenIdent && strings.EqualFold(tok.text, "not") {
+		p.next()
+		operand, err := p.parseExpression(notPrecedence)
+		if err != nil {
+			return nil, err
+		}
+		return &unary{op: "not", operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNumber:
+		value, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at position %d", tok.text, tok.pos)
+		}
+		return &literal{value: value}, nil
+
+	case tokenString:
+		return &literal{value: tok.text}, nil
+
+	case tokenField:
+		return &fieldRef{name: tok.text}, nil
+
+	case tokenIdent:
+		switch strings.ToLower(tok.text) {
+		case "true":
+			return &literal{value: true}, nil
+		case "false":
+			return &literal{value: false}, nil
+		case "null":
+			return &literal{value: nil}, nil
+		}
+		if p.peek().kind == tokenLParen {
+			return p.parseCall(tok)
+		}
+		return &fieldRef{name: tok.text}, nil
+
+	case tokenLParen:
+		inner, err := p.parseExpression(0)
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, fmt.Errorf("expected ')' at position %d", closing.pos)
+		}
+		return inner, nil
+	}
+	return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	fn, ok := functions[strings.ToLower(name.text)]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q at position %d", name.text, name.pos)
+	}
+	p.next() // (
+
+	var args []node
+	if p.peek().kind == tokenRParen {
+		p.next()
+	} else {
+		for {
+			arg, err := p.parseExpression(0)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+
+			tok := p.next()
+			if tok.kind == tokenRParen {
+				break
+			}
+			if tok.kind != tokenComma {
+				return nil, fmt.Errorf("expected ',' or ')' at position %d", tok.pos)
+			}
+		}
+	}
+
+	if len(args) < fn.minArgs || fn.maxArgs >= 0 && len(args) > fn.maxArgs {
+		return nil, fmt.Errorf("function %s expects %s, got %d", fn.name, fn.arity(), len(args))
+	}
+	return &call{name: fn.name, args: args}, nil
+}
+
+// ---- Functions ----
+
+type function struct {
+	name    string
+	minArgs int
+	maxArgs int    // -1 for variadic
+	args    []Type // expected argument types; the last entry repeats for variadic functions
+	result  Type   // TypeAny means the result type follows the arguments
+}
+
+func (f function) arity() string {
+	switch {
+	case f.minArgs == f.maxArgs && f.minArgs == 1:
+		return "1 argument"
+	case f.minArgs == f.maxArgs:
+		return fmt.Sprintf("%d arguments", f.minArgs)
+	case f.maxArgs < 0:
+		return fmt.Sprintf("at least %d arguments", f.minArgs)
+	default:
+		return fmt.Sprintf("%d to %d arguments", f.minArgs, f.maxArgs)
+	}
+}
+
+func (f function) argType(i int) Type {
+	if len(f.args) == 0 {
+		return TypeAny
+	}
+	if i >= len(f.args) {
+		return f.args[len(f.args)-1]
+	}
+	return f.args[i]
+}
+
+var functions = map[string]function{
+	"abs":          {name: "abs", minArgs: 1, maxArgs: 1, args: []Type{TypeNumber}, result: TypeNumber},
+	"round":        {name: "round", minArgs: 1, maxArgs: 2, args: []Type{TypeNumber, TypeNumber}, result: TypeNumber},
+	"floor":        {name: "floor", minArgs: 1, maxArgs: 1, args: []Type{TypeNumber}, result: TypeNumber},
+	"ceil":         {name: "ceil", minArgs: 1, maxArgs: 1, args: []Type{TypeNumber}, result: TypeNumber},
+	"min":          {name: "min", minArgs: 2, maxArgs: -1, args: []Type{TypeNumber}, result: TypeNumber},
+	"max":          {name: "max", minArgs: 2, maxArgs: -1, args: []Type{TypeNumber}, result: TypeNumber},
+	"concat":       {name: "concat", minArgs: 1, maxArgs: -1, result: TypeString},
+	"upper":        {name: "upper", minArgs: 1, maxArgs: 1, args: []Type{TypeString}, result: TypeString},
+	"lower":        {name: "lower", minArgs: 1, maxArgs: 1, args: []Type{TypeString}, result: TypeString},
+	"trim":         {name: "trim", minArgs: 1, maxArgs: 1, args: []Type{TypeString}, result: TypeString},
+	"length":       {name: "length", minArgs: 1, maxArgs: 1, args: []Type{TypeString}, result: TypeNumber},
+	"substring":    {name: "substring", minArgs: 2, maxArgs: 3, args: []Type{TypeString, TypeNumber, TypeNumber}, result: TypeString},
+	"today":        {name: "today", result: TypeDate},
+	"now":          {name: "now", result: TypeDatetime},
+	"year":         {name: "year", minArgs: 1, maxArgs: 1, args: []Type{TypeDate}, result: TypeNumber},
+	"month":        {name: "month", minArgs: 1, maxArgs: 1, args: []Type{TypeDate}, result: TypeNumber},
+	"day":          {name: "day", minArgs: 1, maxArgs: 1, args: []Type{TypeDate}, result: TypeNumber},
+	"days_between": {name: "days_between", minArgs: 2, maxArgs: 2, args: []Type{TypeDate, TypeDate}, result: TypeNumber},
+	"add_days":     {name: "add_days", minArgs: 2, maxArgs: 2, args: []Type{TypeDate, TypeNumber}, result: TypeDate},
+	"if":           {name: "if", minArgs: 3, maxArgs: 3, args: []Type{TypeBool, TypeAny, TypeAny}, result: TypeAny},
+	"case":         {name: "case", minArgs: 2, maxArgs: -1, result: TypeAny},
+	"coalesce":     {name: "coalesce", minArgs: 1, maxArgs: -1, result: TypeAny},
+	"is_blank":     {name: "is_blank", minArgs: 1, maxArgs: 1, result: TypeBool},
+	"to_number":    {name: "to_number", minArgs: 1, maxArgs: 1, result: TypeNumber},
+	"to_text":      {name: "to_text", minArgs: 1, maxArgs: 1, result: TypeString},
+	"to_date":      {name: "to_date", minArgs: 1, maxArgs: 1, args: []Type{TypeDate}, result: TypeDate},
+}
+
+// caseCondition reports whether argument i of a case call of n arguments is a condition:
+// case(condition, value, condition, value, ..., else) pairs conditions with values, the else is optional.
+func caseCondition(i, n int) bool {
+	return i%2 == 0 && i+1 < n
+}
+
+// ---- Type checking ----
+
+// assignable reports whether a value of type actual can be used where want is expected.
+// Dates and datetimes are ISO strings, so string literals are accepted as dates.
+func assignable(actual, want Type) bool {
+	if actual == TypeAny || want == TypeAny || actual == want {
+		return true
+	}
+	switch want {
+	case TypeDate:
+		return actual == TypeDatetime || actual == TypeString
+	case TypeDatetime:
+		return actual == TypeDate || actual == TypeString
+	}
+	return false
+}
+
+func isTemporal(t Type) bool {
+	return t == TypeDate || t == TypeDatetime
+}
+
+func check(n node, fieldTypes map[string]Type) (Type, error) {
+	switch n := n.(type) {
+	case *literal:
+		switch n.value.(type) {
+		case float64:
+			return TypeNumber, nil
+		case string:
+			return TypeString, nil
+		case bool:
+			return TypeBool, nil
+		}
+		return TypeAny, nil
+
+	case *fieldRef:
+		t, ok := fieldTypes[n.name]
+		if !ok {
+			return "", fmt.Errorf("unknown field %q", n.name)
+		}
+		if t == "" {
+			return TypeAny, nil
+		}
+		return t, nil
+
+	case *unary:
+		operand, err := check(n.operand, fieldTypes)
+		if err != nil {
+			return "", err
+		}
+		want := TypeNumber
+		if n.op == "not" {
+			want = TypeBool
+		}
+		if !assignable(operand, want) {
+			return "", fmt.Errorf("operator %s expects a %s operand, got %s", n.op, want, operand)
+		}
+		return want, nil
+
+	case *binary:
+		left, err := check(n.left, fieldTypes)
+		if err != nil {
+			return "", err
+		}
+		right, err := check(n.right, fieldTypes)
+		if err != nil {
+			return "", err
+		}
+		switch n.op {
+		case "and", "or":
+			if !assignable(left, TypeBool) || !assignable(right, TypeBool) {
+				return "", fmt.Errorf("operator %s expects boolean operands, got %s and %s", n.op, left, right)
+			}
+			return TypeBool, nil
+		case "&":
+			return TypeString, nil
+		case "+", "-", "*", "/", "%":
+			if !assignable(left, TypeNumber) || !assignable(right, TypeNumber) {
+				return "", fmt.Errorf("operator %s expects number operands, got %s and %s", n.op, left, right)
+			}
+			return TypeNumber, nil
+		default:
+			if !comparable(left, right) {
+				return "", fmt.Errorf("cannot compare %s with %s", left, right)
+			}
+			return TypeBool, nil
+		}
+
+	case *call:
+		fn := functions[n.name]
+		argTypes := make([]Type, len(n.args))
+		for i, arg := range n.args {
+			t, err := check(arg, fieldTypes)
+			if err != nil {
+				return "", err
+			}
+			want := fn.argType(i)
+			if fn.name == "case" && caseCondition(i, len(n.args)) {
+				want = TypeBool
+			}
+			if !assignable(t, want) {
+				return "", fmt.Errorf("function %s argument %d expects %s, got %s", fn.name, i+1, want, t)
+			}
+			argTypes[i] = t
+		}
+		return callResultType(fn, argTypes), nil
+	}
+	return "", fmt.Errorf("unsupported formula node %T", n)
+}
+
+func comparable(left, right Type) bool {
+	if left == TypeAny || right == TypeAny || left == right {
+		return true
+	}
+	if isTemporal(left) || isTemporal(right) {
+		return assignable(left, TypeDatetime) && assignable(right, TypeDatetime)
+	}
+	return false
+}
+
+func callResultType(fn function, argTypes []Type) Type {
+	if fn.result != TypeAny {
+		return fn.result
+	}
+	branches := argTypes
+	switch fn.name {
+	case "if":
+		branches = argTypes[1:]
+	case "case":
+		branches = nil
+		for i, t := range argTypes {
+			if !caseCondition(i, len(argTypes)) {
+				branches = append(branches, t)
+			}
+		}
+	}
+	result := TypeAny
+	for _, t := range branches {
+		switch {
+		case result == TypeAny:
+			result = t
+		case t != TypeAny && t != result:
+			return TypeAny
+		}
+	}
+	return result
+}
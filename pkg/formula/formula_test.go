@@ -0,0 +1,327 @@
+package formula
+
+import (
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+var testNow = time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC)
+
+func mustParse(t *testing.T, source string) *Expression {
+	t.Helper()
+	expr, err := Parse(source)
+	require.NoError(t, err)
+	return expr
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"", "cannot be empty"},
+		{"price *", "unexpected"},
+		{"(price", "expected ')'"},
+		{"'open", "unterminated string"},
+		{"{name", "unterminated field reference"},
+		{"explode(1)", "unknown function"},
+		{"round()", "expects 1 to 2 arguments"},
+		{"if(a, b)", "expects 3 arguments"},
+		{"case(a)", "expects at least 2 arguments"},
+		{"price ; drop", "unexpected character"},
+		{"a b", "unexpected"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			_, err := Parse(tt.source)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestParse_Limits(t *testing.T) {
+	long := make([]byte, MaxLength+1)
+	for i := range long {
+		long[i] = '1'
+	}
+	_, err := Parse(string(long))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "too long")
+
+	deep := ""
+	for i := 0; i < MaxDepth+1; i++ {
+		deep += "("
+	}
+	_, err = Parse(deep + "1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "nested too deeply")
+}
+
+func TestParse_References(t *testing.T) {
+	expr := mustParse(t, "price * qty + {Unit Price} - price + 单价")
+	assert.Equal(t, []string{"price", "qty", "Unit Price", "单价"}, expr.References())
+	assert.Equal(t, "price * qty + {Unit Price} - price + 单价", expr.String())
+}
+
+func TestExpression_Volatile(t *testing.T) {
+	assert.False(t, mustParse(t, "price * qty").Volatile())
+	assert.True(t, mustParse(t, "days_between(start, TODAY())").Volatile())
+	assert.True(t, mustParse(t, "if(done, 'yes', to_text(now()))").Volatile())
+}
+
+func TestCheck(t *testing.T) {
+	types := map[string]Type{
+		"price": TypeNumber,
+		"name":  TypeString,
+		"done":  TypeBool,
+		"due":   TypeDate,
+		"meta":  TypeAny,
+	}
+	tests := []struct {
+		source string
+		want   Type
+		err    string
+	}{
+		{"price * 2", TypeNumber, ""},
+		{"name & price", TypeString, ""},
+		{"price > 10 and not done", TypeBool, ""},
+		{"due < '2024-01-01'", TypeBool, ""},
+		{"days_between(due, today())", TypeNumber, ""},
+		{"add_days(due, 7)", TypeDate, ""},
+		{"if(done, 'yes', 'no')", TypeString, ""},
+		{"if(done, 1, 'no')", TypeAny, ""},
+		{"coalesce(price, 0)", TypeNumber, ""},
+		{"case(price > 10, 'high', price > 5, 'mid', 'low')", TypeString, ""},
+		{"case(done, 1)", TypeNumber, ""},
+		{"substring(name, 1, 3)", TypeString, ""},
+		{"to_number(name) + 1", TypeNumber, ""},
+		{"to_text(price) & name", TypeString, ""},
+		{"to_date(due)", TypeDate, ""},
+		{"case(price, 'a', 'b')", "", "argument 1 expects boolean"},
+		{"substring(name, 'a')", "", "argument 2 expects number"},
+		{"meta + 1", TypeNumber, ""},
+		{"name * 2", "", "expects number operands"},
+		{"price and done", "", "expects boolean operands"},
+		{"price = name", "", "cannot compare"},
+		{"upper(price)", "", "argument 1 expects string"},
+		{"missing + 1", "", `unknown field "missing"`},
+		{"-name", "", "expects a number operand"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			got, err := mustParse(t, tt.source).Check(types)
+			if tt.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEval(t *testing.T) {
+	values := map[string]interface{}{
+		"price":     12.5,
+		"qty":       4,
+		"name":      "  Widget ",
+		"done":      true,
+		"start":     "2024-03-01",
+		"Unit Cost": 2.0,
+		"empty":     nil,
+	}
+	tests := []struct {
+		source string
+		want   interface{}
+	}{
+		{"price * qty", 50.0},
+		{"price + qty * 2 - 1", 19.5},
+		{"(price + qty) * 2", 33.0},
+		{"-price", -12.5},
+		{"10 % 4", 2.0},
+		{"price * {Unit Cost}", 25.0},
+		{"round(price / 3, 2)", 4.17},
+		{"floor(price)", 12.0},
+		{"ceil(price)", 13.0},
+		{"abs(-3)", 3.0},
+		{"min(price, qty, 7)", 4.0},
+		{"max(price, qty, 7)", 12.5},
+		{"trim(name) & '-' & qty", "Widget-4"},
+		{"concat(upper(trim(name)), empty, '!')", "WIDGET!"},
+		{"lower('ABC')", "abc"},
+		{"length(trim(name))", 6.0},
+		{"price > 10 and done", true},
+		{"price > 100 || !done", false},
+		{"qty == 4 && price != 12", true},
+		{"name <> 'x'", true},
+		{"if(qty >= 5, 'bulk', 'single')", "single"},
+		{"coalesce(empty, price)", 12.5},
+		{"is_blank(empty)", true},
+		{"is_blank(name)", false},
+		{"today()", "2024-03-15"},
+		{"now()", "2024-03-15T10:30:00Z"},
+		{"year(start) * 100 + month(start)", 202403.0},
+		{"day(now())", 15.0},
+		{"days_between(start, today())", 14.0},
+		{"add_days(start, -1)", "2024-02-29"},
+		{"start < '2024-03-02'", true},
+		{"empty + 1", nil},
+		{"empty = 1", nil},
+		{"empty and false", false},
+		{"empty or true", true},
+		{"empty and true", nil},
+		{"if(empty, 'yes', 'no')", "no"},
+		{"upper(empty)", nil},
+		{"case(qty > 5, 'bulk', qty > 1, 'few', 'single')", "few"},
+		{"case(qty > 5, 'bulk')", nil},
+		{"case(empty, 'yes', 'no')", "no"},
+		{"substring(trim(name), 2, 3)", "idg"},
+		{"substring(trim(name), 4)", "get"},
+		{"substring(trim(name), 0, 2)", "W"},
+		{"to_number('4.5') * 2", 9.0},
+		{"to_text(qty) & '!'", "4!"},
+		{"to_date('2024-03-01T10:00:00Z')", "2024-03-01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			got, err := mustParse(t, tt.source).Eval(values, testNow)
+			require.NoError(t, err)
+			if want, ok := tt.want.(float64); ok {
+				assert.InDelta(t, want, got, 1e-9)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEval_TypeErrors(t *testing.T) {
+	_, err := mustParse(t, "name * 2").Eval(map[string]interface{}{"name": "abc"}, testNow)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "as a number")
+
+	_, err = mustParse(t, "price / zero").Eval(map[string]interface{}{"price": 1.0, "zero": 0}, testNow)
+	assert.ErrorIs(t, err, ErrDivisionByZero)
+
+	got, err := mustParse(t, "code * 2").Eval(map[string]interface{}{"code": "21"}, testNow)
+	require.NoError(t, err)
+	assert.Equal(t, 42.0, got)
+}
+
+func sqliteResolver(types map[string]Type) Resolver {
+	return func(name string) (string, []interface{}, Type, error) {
+		return "JSON_EXTRACT(data, '$." + name + "')", nil, types[name], nil
+	}
+}
+
+func TestSQL_Dialects(t *testing.T) {
+	expr := mustParse(t, "if(price / qty > 2, 'high', label)")
+	types := map[string]Type{"price": TypeNumber, "qty": TypeNumber, "label": TypeString}
+
+	sql, params, err := expr.SQL("sqlite", sqliteResolver(types))
+	require.NoError(t, err)
+	assert.Equal(t, "CASE WHEN ((CAST(JSON_EXTRACT(data, '$.price') AS REAL) / NULLIF(JSON_EXTRACT(data, '$.qty'), 0)) > ?) THEN ? ELSE JSON_EXTRACT(data, '$.label') END", sql)
+	assert.Equal(t, []interface{}{2.0, "high"}, params)
+
+	sql, params, err = expr.SQL("postgres", func(name string) (string, []interface{}, Type, error) {
+		return "data->>'" + name + "'", nil, TypeAny, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "CASE WHEN ((CAST(data->>'price' AS numeric) / NULLIF(CAST(data->>'qty' AS numeric), 0)) > CAST(? AS numeric)) THEN CAST(? AS text) ELSE data->>'label' END", sql)
+	assert.Equal(t, []interface{}{2.0, "high"}, params)
+
+	sql, _, err = mustParse(t, "a & b").SQL("mysql", sqliteResolver(types))
+	require.NoError(t, err)
+	assert.Equal(t, "CONCAT_WS('', JSON_EXTRACT(data, '$.a'), JSON_EXTRACT(data, '$.b'))", sql)
+
+	sql, params, err = mustParse(t, "case(qty > 5, substring(label, 1, 3), to_text(price))").SQL("postgres", func(name string) (string, []interface{}, Type, error) {
+		return "data->>'" + name + "'", nil, types[name], nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "CASE WHEN (data->>'qty' > CAST(? AS numeric)) THEN SUBSTR(data->>'label', CAST(CAST(? AS numeric) AS integer), CAST(CAST(? AS numeric) AS integer)) ELSE CAST(data->>'price' AS text) END", sql)
+	assert.Equal(t, []interface{}{5.0, 1.0, 3.0}, params)
+}
+
+func TestSQL_ParamOrderFollowsText(t *testing.T) {
+	sql, params, err := mustParse(t, "days_between(add_days(d, 1), add_days(d, 2))").SQL("sqlite", sqliteResolver(nil))
+	require.NoError(t, err)
+	assert.Equal(t, "CAST(julianday(date(date(JSON_EXTRACT(data, '$.d'), printf('%+d days', ?)))) - julianday(date(date(JSON_EXTRACT(data, '$.d'), printf('%+d days', ?)))) AS INTEGER)", sql)
+	assert.Equal(t, []interface{}{2.0, 1.0}, params)
+}
+
+// TestSQL_MatchesEvalOnSQLite runs compiled formulas on SQLite and compares them with Eval.
+func TestSQL_MatchesEvalOnSQLite(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	data := `{"price": 12.5, "qty": 4, "name": " Widget ", "done": true, "start": "2024-03-01", "empty": null}`
+	values := map[string]interface{}{"price": 12.5, "qty": 4.0, "name": " Widget ", "done": true, "start": "2024-03-01", "empty": nil}
+	types := map[string]Type{"price": TypeNumber, "qty": TypeNumber, "name": TypeString, "done": TypeBool, "start": TypeDate, "empty": TypeAny}
+
+	sources := []string{
+		"price * qty",
+		"price / qty",
+		"price / (qty - 4)",
+		"round(price / 3, 2)",
+		"floor(price) + ceil(price)",
+		"min(price, qty) + max(price, qty)",
+		"upper(trim(name)) & '-' & empty",
+		"length(name)",
+		"if(price > 10 and done, 'big', 'small')",
+		"coalesce(empty, qty)",
+		"is_blank(empty)",
+		"year(start) + month(start) + day(start)",
+		"days_between(start, '2024-04-01')",
+		"add_days(start, 30)",
+		"case(qty > 5, 'bulk', qty > 1, 'few', 'single')",
+		"case(price < 1, 'cheap')",
+		"substring(name, 2, 3) & substring(name, 5)",
+		"to_number('4.5') + to_number(qty)",
+		"to_text(qty) & to_text(price)",
+		"to_date(start)",
+		"start < '2024-03-02'",
+		"empty + 1",
+	}
+	for _, source := range sources {
+		t.Run(source, func(t *testing.T) {
+			expr := mustParse(t, source)
+			want, evalErr := expr.Eval(values, testNow)
+			if evalErr != nil {
+				want = nil
+			}
+
+			sql, params, err := expr.SQL("sqlite", sqliteResolver(types))
+			require.NoError(t, err)
+			var got interface{}
+			require.NoError(t, db.Raw("SELECT "+sql+" FROM (SELECT ? AS data)", append(params, data)...).Row().Scan(&got))
+
+			switch w := want.(type) {
+			case float64:
+				assert.InDelta(t, w, toFloat(t, got), 1e-9)
+			case bool:
+				assert.Equal(t, w, toFloat(t, got) != 0)
+			default:
+				assert.Equal(t, want, got)
+			}
+		})
+	}
+}
+
+func toFloat(t *testing.T, value interface{}) float64 {
+	t.Helper()
+	switch v := value.(type) {
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	}
+	t.Fatalf("expected a number, got %T", value)
+	return 0
+}
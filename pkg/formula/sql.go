@@ -0,0 +1,368 @@
+package formula
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Resolver returns the SQL for a referenced field together with its bind parameters and type.
+type Resolver func(name string) (sql string, params []interface{}, typ Type, err error)
+
+// SQL compiles the formula into a SQL expression for the given dialect ("sqlite", "postgres" or "mysql").
+// Literals are emitted as ? placeholders; the returned params are in placeholder order.
+// Division and modulo by zero yield NULL, matching Eval.
+func (e *Expression) SQL(dialect string, resolve Resolver) (string, []interface{}, error) {
+	c := compiler{dialect: dialect, resolve: resolve}
+	sql, params, _, err := c.compile(e.root)
+	return sql, params, err
+}
+
+type compiler struct {
+	dialect string
+	resolve Resolver
+}
+
+type fragment struct {
+	sql    string
+	params []interface{}
+	typ    Type
+}
+
+func (c compiler) compile(n node) (string, []interface{}, Type, error) {
+	switch n := n.(type) {
+	case *literal:
+		switch v := n.value.(type) {
+		case nil:
+			return "NULL", nil, TypeAny, nil
+		case bool:
+			if v {
+				return "TRUE", nil, TypeBool, nil
+			}
+			return "FALSE", nil, TypeBool, nil
+		case float64:
+			return c.cast("?", "numeric"), []interface{}{v}, TypeNumber, nil
+		default:
+			return c.cast("?", "text"), []interface{}{v}, TypeString, nil
+		}
+
+	case *fieldRef:
+		sql, params, typ, err := c.resolve(n.name)
+		if err != nil {
+			return "", nil, "", err
+		}
+		return sql, params, typ, nil
+
+	case *unary:
+		operand, err := c.fragment(n.operand)
+		if err != nil {
+			return "", nil, "", err
+		}
+		if n.op == "not" {
+			return "(NOT " + operand.sql + ")", operand.params, TypeBool, nil
+		}
+		return "(-" + c.asNumber(operand) + ")", operand.params, TypeNumber, nil
+
+	case *binary:
+		return c.compileBinary(n)
+
+	case *call:
+		return c.compileCall(n)
+	}
+	return "", nil, "", fmt.Errorf("unsupported formula node %T", n)
+}
+
+func (c compiler) fragment(n node) (fragment, error) {
+	sql, params, typ, err := c.compile(n)
+	return fragment{sql: sql, params: params, typ: typ}, err
+}
+
+func (c compiler) fragments(nodes []node) ([]fragment, []interface{}, error) {
+	frags := make([]fragment, len(nodes))
+	var params []interface{}
+	for i, n := range nodes {
+		frag, err := c.fragment(n)
+		if err != nil {
+			return nil, nil, err
+		}
+		frags[i] = frag
+		params = append(params, frag.params...)
+	}
+	return frags, params, nil
+}
+
+func (c compiler) compileBinary(n *binary) (string, []interface{}, Type, error) {
+	frags, params, err := c.fragments([]node{n.left, n.right})
+	if err != nil {
+		return "", nil, "", err
+	}
+	left, right := frags[0], frags[1]
+
+	switch n.op {
+	case "and", "or":
+		return "(" + left.sql + " " + strings.ToUpper(n.op) + " " + right.sql + ")", params, TypeBool, nil
+	case "&":
+		return c.concat(frags), params, TypeString, nil
+	case "+", "-", "*":
+		return "(" + c.asNumber(left) + " " + n.op + " " + c.asNumber(right) + ")", params, TypeNumber, nil
+	case "/":
+		dividend := c.asNumber(left)
+		if c.dialect == "sqlite" {
+			// SQLite truncates integer division.
+			dividend = "CAST(" + dividend + " AS REAL)"
+		}
+		return "(" + dividend + " / NULLIF(" + c.asNumber(right) + ", 0))", params, TypeNumber, nil
+	case "%":
+		return "(" + c.asNumber(left) + " % NULLIF(" + c.asNumber(right) + ", 0))", params, TypeNumber, nil
+	}
+
+	op := n.op
+	if op == "!=" {
+		op = "<>"
+	}
+	l, r := c.alignComparison(left, right)
+	return "(" + l + " " + op + " " + r + ")", params, TypeBool, nil
+}
+
+// alignComparison casts operands so that PostgreSQL compares them with matching types.
+func (c compiler) alignComparison(left, right fragment) (string, string) {
+	if c.dialect != "postgres" || left.typ == right.typ {
+		return left.sql, right.sql
+	}
+	switch {
+	case left.typ == TypeNumber || right.typ == TypeNumber:
+		return c.asNumber(left), c.asNumber(right)
+	case isTemporal(left.typ) || isTemporal(right.typ):
+		return c.asTemporal(left), c.asTemporal(right)
+	}
+	return c.asText(left), c.asText(right)
+}
+
+func (c compiler) compileCall(n *call) (string, []interface{}, Type, error) {
+	args, params, err := c.fragments(n.args)
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	switch n.name {
+	case "abs", "floor", "ceil":
+		return strings.ToUpper(n.name) + "(" + c.asNumber(args[0]) + ")", params, TypeNumber, nil
+	case "round":
+		if len(args) == 1 {
+			return "ROUND(" + c.asNumber(args[0]) + ")", params, TypeNumber, nil
+		}
+		places := args[1].sql
+		if c.dialect == "postgres" {
+			places = "CAST(" + places + " AS integer)"
+		}
+		return "ROUND(" + c.asNumber(args[0]) + ", " + places + ")", params, TypeNumber, nil
+	case "min", "max":
+		fn := map[string]string{"min": "LEAST", "max": "GREATEST"}[n.name]
+		if c.dialect == "sqlite" {
+			fn = strings.ToUpper(n.name)
+		}
+		values := make([]string, len(args))
+		for i, arg := range args {
+			values[i] = c.asNumber(arg)
+		}
+		return fn + "(" + strings.Join(values, ", ") + ")", params, TypeNumber, nil
+
+	case "concat":
+		return c.concat(args), params, TypeString, nil
+	case "upper", "lower", "trim":
+		return strings.ToUpper(n.name) + "(" + c.asText(args[0]) + ")", params, TypeString, nil
+	case "length":
+		fn := "LENGTH"
+		if c.dialect == "mysql" {
+			fn = "CHAR_LENGTH"
+		}
+		return fn + "(" + c.asText(args[0]) + ")", params, TypeNumber, nil
+	case "substring":
+		bounds := make([]string, len(args)-1)
+		for i, arg := range args[1:] {
+			bounds[i] = arg.sql
+			if c.dialect == "postgres" {
+				bounds[i] = "CAST(" + arg.sql + " AS integer)"
+			}
+		}
+		return "SUBSTR(" + c.asText(args[0]) + ", " + strings.Join(bounds, ", ") + ")", params, TypeString, nil
+
+	case "today":
+		switch c.dialect {
+		case "sqlite":
+			return "date('now')", nil, TypeDate, nil
+		case "mysql":
+			return "UTC_DATE()", nil, TypeDate, nil
+		}
+		return "CAST(NOW() AT TIME ZONE 'UTC' AS date)", nil, TypeDate, nil
+	case "now":
+		switch c.dialect {
+		case "sqlite":
+			return "strftime('%Y-%m-%dT%H:%M:%SZ', 'now')", nil, TypeDatetime, nil
+		case "mysql":
+			return "UTC_TIMESTAMP()", nil, TypeDatetime, nil
+		}
+		return "NOW()", nil, TypeDatetime, nil
+	case "year", "month", "day":
+		switch c.dialect {
+		case "sqlite":
+			format := map[string]string{"year": "%Y", "month": "%m", "day": "%d"}[n.name]
+			return "CAST(strftime('" + format + "', " + args[0].sql + ") AS INTEGER)", params, TypeNumber, nil
+		case "mysql":
+			return strings.ToUpper(n.name) + "(" + args[0].sql + ")", params, TypeNumber, nil
+		}
+		return "EXTRACT(" + strings.ToUpper(n.name) + " FROM " + c.asDate(args[0]) + ")", params, TypeNumber, nil
+	case "days_between":
+		// The end date comes first in every dialect, so its params lead.
+		params = append(append([]interface{}(nil), args[1].params...), args[0].params...)
+		switch c.dialect {
+		case "sqlite":
+			return "CAST(julianday(date(" + args[1].sql + ")) - julianday(date(" + args[0].sql + ")) AS INTEGER)", params, TypeNumber, nil
+		case "mysql":
+			return "DATEDIFF(" + args[1].sql + ", " + args[0].sql + ")", params, TypeNumber, nil
+		}
+		return "(" + c.asDate(args[1]) + " - " + c.asDate(args[0]) + ")", params, TypeNumber, nil
+	case "add_days":
+		switch c.dialect {
+		case "sqlite":
+			return "date(" + args[0].sql + ", printf('%+d days', " + args[1].sql + "))", params, TypeDate, nil
+		case "mysql":
+			return "DATE_ADD(" + c.asDate(args[0]) + ", INTERVAL " + args[1].sql + " DAY)", params, TypeDate, nil
+		}
+		return "(" + c.asDate(args[0]) + " + CAST(" + args[1].sql + " AS integer))", params, TypeDate, nil
+
+	case "if":
+		branches := c.alignBranches(args[1:])
+		typ := callResultType(functions["if"], []Type{args[0].typ, args[1].typ, args[2].typ})
+		return "CASE WHEN " + args[0].sql + " THEN " + branches[0] + " ELSE " + branches[1] + " END", params, typ, nil
+	case "case":
+		var values []fragment
+		argTypes := make([]Type, len(args))
+		for i, arg := range args {
+			if !caseCondition(i, len(args)) {
+				values = append(values, arg)
+			}
+			argTypes[i] = arg.typ
+		}
+		branches := c.alignBranches(values)
+		var sb strings.Builder
+		sb.WriteString("CASE")
+		for i := 0; i+1 < len(args); i += 2 {
+			sb.WriteString(" WHEN " + args[i].sql + " THEN " + branches[i/2])
+		}
+		if len(args)%2 == 1 {
+			sb.WriteString(" ELSE " + branches[len(branches)-1])
+		}
+		sb.WriteString(" END")
+		return sb.String(), params, callResultType(functions["case"], argTypes), nil
+	case "coalesce":
+		values := c.alignBranches(args)
+		argTypes := make([]Type, len(args))
+		for i, arg := range args {
+			argTypes[i] = arg.typ
+		}
+		return "COALESCE(" + strings.Join(values, ", ") + ")", params, callResultType(functions["coalesce"], argTypes), nil
+	case "is_blank":
+		return "(" + args[0].sql + " IS NULL OR " + c.asText(args[0]) + " = '')", append(append([]interface{}(nil), args[0].params...), args[0].params...), TypeBool, nil
+
+	case "to_number":
+		if args[0].typ == TypeNumber {
+			return args[0].sql, params, TypeNumber, nil
+		}
+		switch c.dialect {
+		case "sqlite":
+			return "CAST(" + args[0].sql + " AS REAL)", params, TypeNumber, nil
+		case "mysql":
+			return "CAST(" + args[0].sql + " AS DOUBLE)", params, TypeNumber, nil
+		}
+		return "CAST(" + args[0].sql + " AS numeric)", params, TypeNumber, nil
+	case "to_text":
+		return c.asText(args[0]), params, TypeString, nil
+	case "to_date":
+		return c.asDate(args[0]), params, TypeDate, nil
+	}
+	return "", nil, "", fmt.Errorf("unsupported function %s", n.name)
+}
+
+// alignBranches casts result branches of if/coalesce to text on PostgreSQL when their types differ.
+func (c compiler) alignBranches(args []fragment) []string {
+	values := make([]string, len(args))
+	mixed := false
+	for i, arg := range args {
+		values[i] = arg.sql
+		if arg.typ != TypeAny && arg.typ != args[0].typ {
+			mixed = true
+		}
+	}
+	if c.dialect == "postgres" && mixed {
+		for i, arg := range args {
+			values[i] = c.asText(arg)
+		}
+	}
+	return values
+}
+
+func (c compiler) concat(args []fragment) string {
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		parts[i] = arg.sql
+	}
+	switch c.dialect {
+	case "sqlite":
+		for i, arg := range args {
+			parts[i] = "COALESCE(" + c.asText(arg) + ", '')"
+		}
+		return "(" + strings.Join(parts, " || ") + ")"
+	case "mysql":
+		// CONCAT returns NULL when any argument is NULL; CONCAT_WS skips them.
+		return "CONCAT_WS('', " + strings.Join(parts, ", ") + ")"
+	}
+	return "CONCAT(" + strings.Join(parts, ", ") + ")"
+}
+
+// cast wraps a placeholder with a type for PostgreSQL, which cannot infer parameter types in arithmetic.
+func (c compiler) cast(sql, pgType string) string {
+	if c.dialect != "postgres" {
+		return sql
+	}
+	return "CAST(" + sql + " AS " + pgType + ")"
+}
+
+func (c compiler) asNumber(f fragment) string {
+	if f.typ == TypeNumber || c.dialect != "postgres" {
+		return f.sql
+	}
+	return "CAST(" + f.sql + " AS numeric)"
+}
+
+func (c compiler) asText(f fragment) string {
+	if f.typ == TypeString {
+		return f.sql
+	}
+	switch c.dialect {
+	case "sqlite":
+		return "CAST(" + f.sql + " AS TEXT)"
+	case "mysql":
+		return "CAST(" + f.sql + " AS CHAR)"
+	}
+	return "CAST(" + f.sql + " AS text)"
+}
+
+func (c compiler) asDate(f fragment) string {
+	if f.typ == TypeDate {
+		return f.sql
+	}
+	switch c.dialect {
+	case "sqlite":
+		return "date(" + f.sql + ")"
+	case "mysql":
+		return "CAST(" + f.sql + " AS DATE)"
+	}
+	return "CAST(" + f.sql + " AS date)"
+}
+
+func (c compiler) asTemporal(f fragment) string {
+	if f.typ == TypeDate {
+		return f.sql
+	}
+	return "CAST(" + f.sql + " AS timestamptz)"
+}
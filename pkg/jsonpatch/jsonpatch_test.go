@@ -0,0 +1,114 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decode(t *testing.T, source string) interface{} {
+	t.Helper()
+	var value interface{}
+	require.NoError(t, json.Unmarshal([]byte(source), &value))
+	return value
+}
+
+func TestMergePatch(t *testing.T) {
+	// Test cases from RFC 7396 appendix A.
+	tests := []struct {
+		target, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		target := decode(t, tt.target)
+		got := MergePatch(target, decode(t, tt.patch))
+		assert.True(t, Equal(decode(t, tt.want), got), "%s + %s = %v", tt.target, tt.patch, got)
+		assert.True(t, Equal(decode(t, tt.target), target), "target must not change")
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name, doc, patch, want string
+	}{
+		{"add member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"foo":"bar","baz":"qux"}`},
+		{"add array element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"append", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc"]}]`, `{"foo":["bar",["abc"]]}`},
+		{"add null", `{}`, `[{"op":"add","path":"/foo","value":null}]`, `{"foo":null}`},
+		{"remove member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"remove element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"replace", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"move member", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"move element", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{"copy", `{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"}]`, `{"a":{"b":1},"c":{"b":1}}`},
+		{"test", `{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`, `{"baz":"qux","foo":["a",2,"c"]}`},
+		{"escaped path", `{"a/b":1,"m~n":2}`, `[{"op":"replace","path":"/a~1b","value":3},{"op":"remove","path":"/m~0n"}]`, `{"a/b":3}`},
+		{"whole document", `{"a":1}`, `[{"op":"replace","path":"","value":{"b":2}}]`, `{"b":2}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops, err := ParsePatch(decode(t, tt.patch))
+			require.NoError(t, err)
+			doc := decode(t, tt.doc)
+			got, err := Apply(doc, ops)
+			require.NoError(t, err)
+			assert.True(t, Equal(decode(t, tt.want), got), "got %v", got)
+			assert.True(t, Equal(decode(t, tt.doc), doc), "target must not change")
+		})
+	}
+}
+
+func TestApply_Errors(t *testing.T) {
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, "member 'baz' does not exist"},
+		{`{"foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, "member 'baz' does not exist"},
+		{`{"foo":"bar"}`, `[{"op":"replace","path":"/baz","value":1}]`, "member 'baz' does not exist"},
+		{`{"foo":["a"]}`, `[{"op":"add","path":"/foo/2","value":"b"}]`, "out of range"},
+		{`{"foo":["a"]}`, `[{"op":"remove","path":"/foo/01"}]`, "invalid array index"},
+		{`{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, "test failed"},
+		{`{"a":{"b":1}}`, `[{"op":"move","from":"/a","path":"/a/c"}]`, "into itself"},
+		{`{"a":1}`, `[{"op":"add","path":"a","value":1}]`, "must be empty or start with /"},
+	}
+	for _, tt := range tests {
+		ops, err := ParsePatch(decode(t, tt.patch))
+		require.NoError(t, err)
+		_, err = Apply(decode(t, tt.doc), ops)
+		require.Error(t, err, tt.patch)
+		assert.Contains(t, err.Error(), tt.want)
+	}
+}
+
+func TestParsePatch_Errors(t *testing.T) {
+	tests := []struct {
+		patch, want string
+	}{
+		{`{"op":"add"}`, "must be an array"},
+		{`["add"]`, "must be an object"},
+		{`[{"op":"add","value":1}]`, "path is required"},
+		{`[{"op":"add","path":"/a"}]`, "requires a value"},
+		{`[{"op":"copy","path":"/a"}]`, "requires from"},
+		{`[{"op":"patch","path":"/a"}]`, "unknown op 'patch'"},
+	}
+	for _, tt := range tests {
+		_, err := ParsePatch(decode(t, tt.patch))
+		require.Error(t, err, tt.patch)
+		assert.Contains(t, err.Error(), tt.want)
+	}
+}
@@ -0,0 +1,279 @@
+package query
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor is wrapped by the errors of a cursor that cannot be decoded or used.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursorColumnPrefix prefixes the columns a cursor page selects for the sort keys of its rows;
+// they are removed from the result rows.
+const cursorColumnPrefix = "__cursor_"
+
+// cursorPayload is the content of an encoded cursor.
+type cursorPayload struct {
+	Keys   []string          `json:"k"` // sort keys, "field dir"
+	Values []json.RawMessage `json:"v"` // sort key values of the row
+}
+
+// cursorTime tags a time value, so it decodes as a time.Time again and reaches the database
+// driver in the same form it was read.
+type cursorTime struct {
+	Time string `json:"$time"`
+}
+
+// EncodeCursor encodes the sort key values of a row as an opaque cursor. keys describe the
+// sort order as "field dir" pairs, e.g. "created_at desc"; the cursor only decodes for the same
+// keys.
+func EncodeCursor(keys []string, values []interface{}) string {
+	payload := cursorPayload{Keys: keys, Values: make([]json.RawMessage, len(values))}
+	for i, value := range values {
+		if t, ok := value.(time.Time); ok {
+			value = cursorTime{Time: t.Format(time.RFC3339Nano)}
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			raw, _ = json.Marshal(fmt.Sprintf("%v", value))
+		}
+		payload.Values[i] = raw
+	}
+	data, _ := json.Marshal(payload)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor decodes a cursor encoded by EncodeCursor for the same sort keys. Numbers decode
+// as int64 when they are integral and as float64 otherwise.
+func DecodeCursor(cursor string, keys []string) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if strings.Join(payload.Keys, ",") != strings.Join(keys, ",") || len(payload.Values) != len(keys) {
+		return nil, fmt.Errorf("%w: cursor does not match the sort order", ErrInvalidCursor)
+	}
+
+	values := make([]interface{}, len(payload.Values))
+	for i, raw := range payload.Values {
+		var tagged cursorTime
+		if json.Unmarshal(raw, &tagged) == nil && tagged.Time != "" {
+			t, err := time.Parse(time.RFC3339Nano, tagged.Time)
+			if err != nil {
+				return nil, ErrInvalidCursor
+			}
+			values[i] = t
+			continue
+		}
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil {
+			return nil, ErrInvalidCursor
+		}
+		if number, ok := value.(json.Number); ok {
+			if n, err := number.Int64(); err == nil {
+				value = n
+			} else if value, err = number.Float64(); err != nil {
+				return nil, ErrInvalidCursor
+			}
+		}
+		if _, ok := value.(map[string]interface{}); ok {
+			return nil, ErrInvalidCursor
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// cursorPage is the keyset pagination of a query that pages with cursors.
+type cursorPage struct {
+	orderBy []OrderByClause // sort order including the id tie-breaker
+	keys    []string        // orderBy as cursor keys
+	values  []interface{}   // sort key values of the cursor row, nil on the first page
+	before  bool            // page backwards from the cursor row
+	size    int
+}
+
+// newCursorPage prepares the cursor pagination of a normalized request, nil when the request
+// pages with page numbers. The sort order is completed with the id of the primary table, so
+// every row has a distinct position.
+func newCursorPage(req *QueryRequest) (*cursorPage, error) {
+	if !req.Cursor && req.After == "" && req.Before == "" {
+		return nil, nil
+	}
+	switch {
+	case req.After != "" && req.Before != "":
+		return nil, fmt.Errorf("%w: after and before cannot be used together", ErrInvalidCursor)
+	case req.Page > 1:
+		return nil, fmt.Errorf("%w: page cannot be used with a cursor", ErrInvalidCursor)
+	case len(req.Union) > 0 || len(req.Intersect) > 0:
+		return nil, fmt.Errorf("%w: union and intersect queries cannot page with a cursor", ErrInvalidCursor)
+	case len(req.GroupBy) > 0 || len(req.Aggregate) > 0 || req.Having != nil:
+		return nil, fmt.Errorf("%w: aggregate queries cannot page with a cursor", ErrInvalidCursor)
+	case len(req.Window) > 0:
+		// Resuming after the cursor row would leave the rows before it out of the windows.
+		return nil, fmt.Errorf("%w: window queries cannot page with a cursor", ErrInvalidCursor)
+	}
+
+	idField := "id"
+	if len(req.Join) > 0 {
+		idField = req.From + ".id"
+	}
+	page := &cursorPage{before: req.Before != "", size: req.Size}
+	hasID := false
+	for _, order := range req.OrderBy {
+		dir := strings.ToLower(order.Dir)
+		if dir != "desc" {
+			dir = "asc"
+		}
+		page.orderBy = append(page.orderBy, OrderByClause{Field: order.Field, Dir: dir})
+		hasID = hasID || order.Field == idField
+	}
+	if !hasID {
+		page.orderBy = append(page.orderBy, OrderByClause{Field: idField, Dir: "asc"})
+	}
+	for _, order := range page.orderBy {
+		page.keys = append(page.keys, order.Field+" "+order.Dir)
+	}
+
+	cursor := req.After
+	if page.before {
+		cursor = req.Before
+	}
+	if cursor != "" {
+		values, err := DecodeCursor(cursor, page.keys)
+		if err != nil {
+			return nil, err
+		}
+		page.values = values
+	}
+	return page, nil
+}
+
+// request returns the request of the page: it selects the sort keys as hidden columns, fetches
+// one extra row to tell whether more rows follow, and resumes after the cursor row. A backward
+// page reverses the sort order; finish restores it.
+func (p *cursorPage) request(req *QueryRequest, nullsLast bool) *QueryRequest {
+	pageReq := cloneQueryRequest(req)
+	pageReq.Page = 1
+	pageReq.Size = p.size + 1
+	pageReq.OrderBy = make([]OrderByClause, len(p.orderBy))
+	pageReq.cursorKeys = make([]string, len(p.orderBy))
+	for i, order := range p.orderBy {
+		if p.before {
+			order.Dir = map[string]string{"asc": "desc", "desc": "asc"}[order.Dir]
+		}
+		pageReq.OrderBy[i] = order
+		pageReq.cursorKeys[i] = order.Field
+	}
+	if p.values == nil {
+		return pageReq
+	}
+
+	where := &WhereClause{}
+	if req.Where != nil {
+		where.And = append(where.And, req.Where.And...)
+		if len(req.Where.Or) > 0 {
+			where.And = append(where.And, Condition{Or: req.Where.Or})
+		}
+	}
+	where.And = append(where.And, p.keysetCondition(pageReq.OrderBy, nullsLast))
+	pageReq.Where = where
+	return pageReq
+}
+
+// keysetCondition matches the rows that sort after the cursor row in the order of orderBy:
+// rows that tie with it on the first keys and sort after it on the next one. nullsLast tells
+// whether the database sorts NULL after every value in ascending order, as Postgres does;
+// SQLite and MySQL sort it first.
+func (p *cursorPage) keysetCondition(orderBy []OrderByClause, nullsLast bool) Condition {
+	var terms []Condition
+	var ties []Condition
+	for i, order := range orderBy {
+		value := p.values[i]
+		if after, ok := keyAfter(order, value, nullsLast); ok {
+			terms = append(terms, Condition{And: append(append([]Condition(nil), ties...), after)})
+		}
+		if value == nil {
+			ties = append(ties, Condition{Field: order.Field, Op: "is_null", Value: true})
+		} else {
+			ties = append(ties, Condition{Field: order.Field, Op: "eq", Value: value})
+		}
+	}
+	return Condition{Or: terms}
+}
+
+// keyAfter matches the values of one sort key that sort after value; false when none does.
+func keyAfter(order OrderByClause, value interface{}, nullsLast bool) (Condition, bool) {
+	// Descending order reverses where NULL sorts.
+	nullsAfter := nullsLast == (order.Dir == "asc")
+	op := "gt"
+	if order.Dir == "desc" {
+		op = "lt"
+	}
+	if value == nil {
+		if nullsAfter {
+			return Condition{}, false
+		}
+		return Condition{Field: order.Field, Op: "is_null", Not: true}, true
+	}
+	after := Condition{Field: order.Field, Op: op, Value: value}
+	if nullsAfter {
+		after = Condition{Or: []Condition{after, {Field: order.Field, Op: "is_null", Value: true}}}
+	}
+	return after, true
+}
+
+// finish trims the rows of the page to its size, removes the hidden sort key columns and fills
+// the cursors of the result.
+func (p *cursorPage) finish(rows []map[string]interface{}, result *QueryResult) {
+	more := len(rows) > p.size
+	if more {
+		rows = rows[:p.size]
+	}
+	if p.before {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	cursors := make([]string, len(rows))
+	for i, row := range rows {
+		values := make([]interface{}, len(p.keys))
+		for k := range p.keys {
+			column := fmt.Sprintf("%s%d", cursorColumnPrefix, k)
+			values[k] = row[column]
+			delete(row, column)
+		}
+		cursors[i] = EncodeCursor(p.keys, values)
+	}
+
+	result.Data = rows
+	if len(rows) == 0 {
+		return
+	}
+	if p.before {
+		result.NextCursor = cursors[len(cursors)-1]
+		if more {
+			result.PrevCursor = cursors[0]
+		}
+	} else {
+		if more {
+			result.NextCursor = cursors[len(cursors)-1]
+		}
+		if p.values != nil {
+			result.PrevCursor = cursors[0]
+		}
+	}
+	result.HasMore = result.NextCursor != ""
+}
@@ -0,0 +1,165 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/jiangfire/cornerstone/internal/authz"
+	"github.com/jiangfire/cornerstone/internal/models"
+)
+
+func TestEncodeDecodeCursor(t *testing.T) {
+	keys := []string{"created_at desc", "score asc", "note asc", "id asc"}
+	created := time.Date(2026, 3, 4, 5, 6, 7, 890, time.FixedZone("", 8*3600))
+	cursor := EncodeCursor(keys, []interface{}{created, 1.5, nil, "db_1"})
+
+	values, err := DecodeCursor(cursor, keys)
+	require.NoError(t, err)
+	require.Len(t, values, 4)
+	assert.True(t, created.Equal(values[0].(time.Time)))
+	assert.Equal(t, created.Format(time.RFC3339Nano), values[0].(time.Time).Format(time.RFC3339Nano))
+	assert.Equal(t, 1.5, values[1])
+	assert.Nil(t, values[2])
+	assert.Equal(t, "db_1", values[3])
+
+	values, err = DecodeCursor(EncodeCursor([]string{"n asc"}, []interface{}{int64(9007199254740993)}), []string{"n asc"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(9007199254740993), values[0])
+
+	_, err = DecodeCursor(cursor, []string{"id asc"})
+	assert.True(t, errors.Is(err, ErrInvalidCursor))
+	assert.Contains(t, err.Error(), "does not match the sort order")
+	_, err = DecodeCursor("not a cursor!", keys)
+	assert.True(t, errors.Is(err, ErrInvalidCursor))
+}
+
+// collectCursorPages pages through the databases in the order of req, forward from the first
+// page, and returns the names and the cursor of the last page.
+func collectCursorPages(t *testing.T, executor *Executor, req QueryRequest) ([]string, string) {
+	t.Helper()
+	var names []string
+	var last string
+	req.Cursor = true
+	for pages := 0; pages < 20; pages++ {
+		result, err := executor.Execute(context.Background(), &req, "user1")
+		require.NoError(t, err)
+		for _, row := range result.Data {
+			assert.NotContains(t, row, cursorColumnPrefix+"0")
+			names = append(names, row["name"].(string))
+		}
+		last = result.PrevCursor
+		if result.NextCursor == "" {
+			assert.False(t, result.HasMore)
+			return names, last
+		}
+		assert.True(t, result.HasMore)
+		req.After = result.NextCursor
+	}
+	t.Fatal("cursor pagination did not end")
+	return nil, ""
+}
+
+func TestExecute_CursorPagination(t *testing.T) {
+	db := setupQueryTestDB(t)
+	descriptions := []interface{}{"b", nil, "a", "b", nil, "c", "a"}
+	for i, description := range descriptions {
+		database := &models.Database{Name: fmt.Sprintf("cursor_%d", i)}
+		require.NoError(t, db.Create(database).Error)
+		value := description
+		if value == nil {
+			value = gorm.Expr("NULL")
+		}
+		require.NoError(t, db.Model(database).Update("description", value).Error)
+	}
+	authz.ClearTokenCache()
+	executor := NewExecutor(db)
+
+	for _, dir := range []string{"asc", "desc"} {
+		orderBy := []OrderByClause{{Field: "description", Dir: dir}}
+		all, err := executor.Execute(context.Background(), &QueryRequest{
+			From: "databases", Select: []string{"name"}, Page: 1, Size: 100,
+			OrderBy: append(orderBy, OrderByClause{Field: "id", Dir: "asc"}),
+		}, "user1")
+		require.NoError(t, err)
+		var want []string
+		for _, row := range all.Data {
+			want = append(want, row["name"].(string))
+		}
+		require.Len(t, want, len(descriptions))
+
+		req := QueryRequest{From: "databases", Select: []string{"name"}, Size: 2, OrderBy: orderBy}
+		names, prev := collectCursorPages(t, executor, req)
+		assert.Equal(t, want, names, dir)
+
+		// Page backwards from the last page.
+		var backward []string
+		for prev != "" {
+			req.Before = prev
+			result, err := executor.Execute(context.Background(), &req, "user1")
+			require.NoError(t, err)
+			page := make([]string, 0, len(result.Data))
+			for _, row := range result.Data {
+				page = append(page, row["name"].(string))
+			}
+			backward = append(page, backward...)
+			assert.NotEmpty(t, result.NextCursor)
+			prev = result.PrevCursor
+		}
+		assert.Equal(t, want[:len(want)-1], backward, dir)
+	}
+}
+
+func TestExecute_CursorPaginationByTime(t *testing.T) {
+	db := setupQueryTestDB(t)
+	ids := make([]string, 5)
+	for i := range ids {
+		database := &models.Database{Name: fmt.Sprintf("time_%d", i)}
+		require.NoError(t, db.Create(database).Error)
+		// time_1 and time_2 share a timestamp and are ordered by ID.
+		minutes := []int{4, 3, 3, 1, 0}[i]
+		require.NoError(t, db.Model(database).Update("created_at", gorm.Expr("datetime('now', ?)", fmt.Sprintf("-%d minutes", minutes))).Error)
+		ids[i] = database.ID
+	}
+	authz.ClearTokenCache()
+	executor := NewExecutor(db)
+
+	want := []string{"time_4", "time_3", "time_1", "time_2", "time_0"}
+	if ids[2] < ids[1] {
+		want[2], want[3] = want[3], want[2]
+	}
+	names, _ := collectCursorPages(t, executor, QueryRequest{
+		From: "databases", Select: []string{"name"}, Size: 2, Sort: "-created_at",
+	})
+	assert.Equal(t, want, names)
+}
+
+func TestExecute_CursorPaginationErrors(t *testing.T) {
+	db := setupQueryTestDB(t)
+	createTestData(t, db)
+	authz.ClearTokenCache()
+	executor := NewExecutor(db)
+
+	tests := []struct {
+		req  QueryRequest
+		want string
+	}{
+		{QueryRequest{From: "databases", After: "a", Before: "b"}, "after and before cannot be used together"},
+		{QueryRequest{From: "databases", Cursor: true, Page: 2}, "page cannot be used with a cursor"},
+		{QueryRequest{From: "databases", Cursor: true, GroupBy: []string{"name"}, Select: []string{"name"}}, "aggregate queries"},
+		{QueryRequest{From: "databases", After: "bad"}, "invalid cursor"},
+		{QueryRequest{From: "databases", After: EncodeCursor([]string{"id asc"}, []interface{}{"x"}), Sort: "name"}, "does not match the sort order"},
+	}
+	for _, tt := range tests {
+		_, err := executor.Execute(context.Background(), &tt.req, "user1")
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrInvalidCursor))
+		assert.Contains(t, err.Error(), tt.want)
+	}
+}
@@ -0,0 +1,426 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	_ "time/tzdata" // timezone() accepts IANA zones on hosts without a zone database
+)
+
+// Date functions can stand in for a field in select, groupBy, orderBy and where, such as
+// `date_trunc(month, data.ordered_at)`. A selected date function is named by its function name,
+// or by an alias: `date_trunc(month, data.ordered_at) as month`.
+//
+//	date_trunc(unit, value)      start of the hour, day, week (Monday), month, quarter or year
+//	extract(part, value)         year, quarter, month, day, hour or dow (0 for Sunday) as a number
+//	date_diff(unit, start, end)  whole seconds, minutes, hours, days or weeks from start to end
+//	timezone(zone, value)        a UTC value as local time of an IANA zone, such as Asia/Shanghai
+//	now()                        the current time
+//
+// Values are fields holding dates or ISO 8601 datetimes, or other date functions. Dates are
+// returned as YYYY-MM-DD, datetimes as YYYY-MM-DDTHH:MM:SS, with a Z unless converted by
+// timezone(). Units, parts and zones are inlined in the SQL, so the same expression can repeat in
+// SELECT and GROUP BY; they are checked against fixed lists and the zone database first.
+
+var (
+	dateTruncUnits = map[string]bool{"hour": true, "day": true, "week": true, "month": true, "quarter": true, "year": true}
+	extractParts   = map[string]bool{"year": true, "quarter": true, "month": true, "day": true, "hour": true, "dow": true}
+	dateDiffUnits  = map[string]int{"second": 1, "minute": 60, "hour": 3600, "day": 86400, "week": 604800}
+)
+
+var timeZonePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_+\-]*(/[A-Za-z0-9_+\-]+)*$`)
+
+// dateCall is a parsed date function.
+type dateCall struct {
+	name   string // date_trunc, extract, date_diff, timezone or now
+	option string // the unit, part or zone
+	args   []dateArg
+}
+
+// dateArg is a value of a date function: a field or a nested date function.
+type dateArg struct {
+	field string
+	call  *dateCall
+}
+
+// dateKind is the type of the value of a date function.
+type dateKind int
+
+const (
+	dateKindDatetime dateKind = iota
+	dateKindDate
+	dateKindNumber
+)
+
+// parseDateFunction parses a date function. ok is false when field is not a date function call.
+func parseDateFunction(field string) (call *dateCall, ok bool, err error) {
+	field = strings.TrimSpace(field)
+	open := strings.IndexByte(field, '(')
+	if open < 0 {
+		return nil, false, nil
+	}
+	name := strings.ToLower(strings.TrimSpace(field[:open]))
+	switch name {
+	case "date_trunc", "extract", "date_diff", "timezone", "now":
+	default:
+		return nil, false, nil
+	}
+	if !strings.HasSuffix(field, ")") {
+		return nil, true, fmt.Errorf("invalid date function %q: missing closing parenthesis", field)
+	}
+	args, err := splitFunctionArgs(field[open+1 : len(field)-1])
+	if err != nil {
+		return nil, true, fmt.Errorf("invalid date function %q: %w", field, err)
+	}
+
+	call = &dateCall{name: name}
+	want := map[string]int{"date_trunc": 2, "extract": 2, "date_diff": 3, "timezone": 2, "now": 0}[name]
+	if len(args) != want {
+		return nil, true, fmt.Errorf("%s() takes %d arguments, got %d", name, want, len(args))
+	}
+	if want == 0 {
+		return call, true, nil
+	}
+
+	call.option = strings.Trim(args[0], "'")
+	switch name {
+	case "date_trunc":
+		call.option = strings.ToLower(call.option)
+		if !dateTruncUnits[call.option] {
+			return nil, true, fmt.Errorf("date_trunc() unit must be hour, day, week, month, quarter or year, got %q", args[0])
+		}
+	case "extract":
+		call.option = strings.ToLower(call.option)
+		if !extractParts[call.option] {
+			return nil, true, fmt.Errorf("extract() part must be year, quarter, month, day, hour or dow, got %q", args[0])
+		}
+	case "date_diff":
+		call.option = strings.ToLower(call.option)
+		if _, ok := dateDiffUnits[call.option]; !ok {
+			return nil, true, fmt.Errorf("date_diff() unit must be second, minute, hour, day or week, got %q", args[0])
+		}
+	case "timezone":
+		if err := validateTimeZone(call.option); err != nil {
+			return nil, true, err
+		}
+	}
+
+	for _, arg := range args[1:] {
+		nested, ok, err := parseDateFunction(arg)
+		if err != nil {
+			return nil, true, err
+		}
+		if ok {
+			if nested.kind() == dateKindNumber {
+				return nil, true, fmt.Errorf("%s() cannot take the number of %s() as a date", name, nested.name)
+			}
+			call.args = append(call.args, dateArg{call: nested})
+			continue
+		}
+		if err := validateFieldExpression(arg); err != nil {
+			return nil, true, fmt.Errorf("%s() %w", name, err)
+		}
+		call.args = append(call.args, dateArg{field: strings.TrimSpace(arg)})
+	}
+	return call, true, nil
+}
+
+// splitFunctionArgs splits the arguments of a function call on the commas outside of nested
+// calls and quotes.
+func splitFunctionArgs(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var args []string
+	depth, start := 0, 0
+	quoted := false
+	for i, r := range s {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+		case quoted:
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+			if depth < 0 {
+				return nil, errors.New("unbalanced parentheses")
+			}
+		case r == ',' && depth == 0:
+			args = append(args, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if depth != 0 || quoted {
+		return nil, errors.New("unbalanced parentheses or quotes")
+	}
+	args = append(args, strings.TrimSpace(s[start:]))
+	for _, arg := range args {
+		if arg == "" {
+			return nil, errors.New("empty argument")
+		}
+	}
+	return args, nil
+}
+
+func validateTimeZone(zone string) error {
+	if zone == "Local" || !timeZonePattern.MatchString(zone) {
+		return fmt.Errorf("timezone() zone must be an IANA time zone such as Asia/Shanghai, got %q", zone)
+	}
+	if _, err := time.LoadLocation(zone); err != nil {
+		return fmt.Errorf("timezone() zone %q is unknown", zone)
+	}
+	return nil
+}
+
+// splitSelectAlias splits a selected date function or computed expression from its alias, as in
+// `date_trunc(month, data.ordered_at) as month`. Other selections are returned as they are, and
+// so is an " as " inside a quoted literal.
+func splitSelectAlias(field string) (expr, alias string) {
+	field = strings.TrimSpace(field)
+	idx := strings.LastIndex(strings.ToLower(field), " as ")
+	if idx < 0 {
+		return field, ""
+	}
+	expr = strings.TrimSpace(field[:idx])
+	alias = strings.TrimSpace(field[idx+len(" as "):])
+	if !strings.ContainsAny(expr, "({") || strings.ContainsAny(alias, `'"()`) {
+		return field, ""
+	}
+	return expr, alias
+}
+
+// dateFunctionOf returns the date function of a field or selection, if it is a valid one.
+func dateFunctionOf(field string) (*dateCall, bool) {
+	expr, _ := splitSelectAlias(field)
+	call, ok, err := parseDateFunction(expr)
+	if !ok || err != nil {
+		return nil, false
+	}
+	return call, true
+}
+
+// fields returns the fields a date function reads.
+func (c *dateCall) fields() []string {
+	var fields []string
+	for _, arg := range c.args {
+		if arg.call != nil {
+			fields = append(fields, arg.call.fields()...)
+		} else {
+			fields = append(fields, arg.field)
+		}
+	}
+	return fields
+}
+
+func (c *dateCall) kind() dateKind {
+	switch c.name {
+	case "extract", "date_diff":
+		return dateKindNumber
+	case "date_trunc":
+		if c.option == "hour" {
+			return dateKindDatetime
+		}
+		return dateKindDate
+	}
+	return dateKindDatetime
+}
+
+// local reports whether a date function returns local time of a zone rather than UTC.
+func (c *dateCall) local() bool {
+	switch c.name {
+	case "timezone":
+		return true
+	case "date_trunc":
+		return c.args[0].call != nil && c.args[0].call.local()
+	}
+	return false
+}
+
+// dateFunctionExpression generates SQL for a date function, formatting dates and datetimes as
+// ISO 8601 text.
+func (g *SQLGenerator) dateFunctionExpression(call *dateCall) (string, []interface{}, error) {
+	sql, params, err := g.dateCallSQL(call)
+	if err != nil {
+		return "", nil, err
+	}
+	switch call.kind() {
+	case dateKindDate:
+		switch g.dbType {
+		case "postgres":
+			sql = "to_char(" + sql + ", 'YYYY-MM-DD')"
+		case "mysql":
+			sql = "DATE_FORMAT(" + sql + ", '%Y-%m-%d')"
+		}
+		// SQLite dates are YYYY-MM-DD text already.
+	case dateKindDatetime:
+		suffix := "Z"
+		if call.local() {
+			suffix = ""
+		}
+		switch g.dbType {
+		case "postgres":
+			sql = "to_char(" + sql + `, 'YYYY-MM-DD"T"HH24:MI:SS` + suffix + "')"
+		case "mysql":
+			sql = "DATE_FORMAT(" + sql + ", '%Y-%m-%dT%H:%i:%s" + suffix + "')"
+		default:
+			sql = "strftime('%Y-%m-%dT%H:%M:%S" + suffix + "', " + sql + ")"
+		}
+	}
+	return sql, params, nil
+}
+
+// dateCallSQL generates SQL for a date function, with dates and datetimes in the native
+// representation of the database: TEXT in SQLite, timestamp in PostgreSQL and DATETIME in MySQL,
+// in UTC unless converted by timezone().
+func (g *SQLGenerator) dateCallSQL(call *dateCall) (string, []interface{}, error) {
+	if call.name == "now" {
+		switch g.dbType {
+		case "postgres":
+			return "(CURRENT_TIMESTAMP AT TIME ZONE 'UTC')", nil, nil
+		case "mysql":
+			return "UTC_TIMESTAMP()", nil, nil
+		default:
+			return "datetime('now')", nil, nil
+		}
+	}
+
+	values := make([]string, len(call.args))
+	valueParams := make([][]interface{}, len(call.args))
+	for i, arg := range call.args {
+		var err error
+		if arg.call != nil {
+			values[i], valueParams[i], err = g.dateCallSQL(arg.call)
+		} else {
+			values[i], valueParams[i], err = g.dateValue(arg.field)
+		}
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	if call.name == "date_diff" {
+		start, end := values[0], values[1]
+		switch g.dbType {
+		case "postgres":
+			return fmt.Sprintf("CAST(TRUNC(EXTRACT(EPOCH FROM (%s - %s)) / %d) AS bigint)", end, start, dateDiffUnits[call.option]),
+				append(append([]interface{}{}, valueParams[1]...), valueParams[0]...), nil
+		case "mysql":
+			return fmt.Sprintf("TIMESTAMPDIFF(%s, %s, %s)", strings.ToUpper(call.option), start, end),
+				append(append([]interface{}{}, valueParams[0]...), valueParams[1]...), nil
+		default:
+			sql := fmt.Sprintf("(CAST(strftime('%%s', %s) AS INTEGER) - CAST(strftime('%%s', %s) AS INTEGER))", end, start)
+			if seconds := dateDiffUnits[call.option]; seconds > 1 {
+				sql = fmt.Sprintf("(%s / %d)", sql, seconds)
+			}
+			return sql, append(append([]interface{}{}, valueParams[1]...), valueParams[0]...), nil
+		}
+	}
+
+	var template string
+	switch call.name {
+	case "timezone":
+		switch g.dbType {
+		case "postgres":
+			template = "(({v} AT TIME ZONE 'UTC') AT TIME ZONE '" + call.option + "')"
+		case "mysql":
+			template = "CONVERT_TZ({v}, '+00:00', '" + call.option + "')"
+		default:
+			// timezone() is registered on SQLite connections by pkg/db
+			template = "timezone('" + call.option + "', {v})"
+		}
+	case "date_trunc":
+		template = g.dateTruncTemplate(call.option)
+	case "extract":
+		template = g.extractTemplate(call.option)
+	}
+	n := strings.Count(template, "{v}")
+	return strings.ReplaceAll(template, "{v}", values[0]), repeatParams(valueParams[0], n), nil
+}
+
+// dateValue converts a field holding a date or an ISO 8601 datetime to the native datetime of
+// the database, in UTC.
+func (g *SQLGenerator) dateValue(field string) (string, []interface{}, error) {
+	expr, params, err := g.fieldExpression(field)
+	if err != nil {
+		return "", nil, err
+	}
+	switch g.dbType {
+	case "postgres":
+		return "(CAST(" + expr + " AS timestamptz) AT TIME ZONE 'UTC')", params, nil
+	case "mysql":
+		if strings.HasPrefix(expr, "JSON_EXTRACT(") {
+			expr = "JSON_UNQUOTE(" + expr + ")"
+		}
+		return "CAST(" + expr + " AS DATETIME)", params, nil
+	default:
+		return "datetime(" + expr + ")", params, nil
+	}
+}
+
+// dateTruncTemplate returns the SQL of date_trunc, with {v} for its value.
+func (g *SQLGenerator) dateTruncTemplate(unit string) string {
+	switch g.dbType {
+	case "postgres":
+		return "date_trunc('" + unit + "', {v})"
+	case "mysql":
+		switch unit {
+		case "hour":
+			return "CAST(DATE_FORMAT({v}, '%Y-%m-%d %H:00:00') AS DATETIME)"
+		case "day":
+			return "DATE({v})"
+		case "week":
+			return "DATE_SUB(DATE({v}), INTERVAL WEEKDAY({v}) DAY)"
+		case "month":
+			return "DATE_SUB(DATE({v}), INTERVAL DAYOFMONTH({v}) - 1 DAY)"
+		case "quarter":
+			return "DATE_ADD(MAKEDATE(YEAR({v}), 1), INTERVAL QUARTER({v}) - 1 QUARTER)"
+		default:
+			return "MAKEDATE(YEAR({v}), 1)"
+		}
+	default:
+		switch unit {
+		case "hour":
+			return "strftime('%Y-%m-%d %H:00:00', {v})"
+		case "day":
+			return "date({v})"
+		case "week":
+			// 'weekday 0' moves to the next Sunday, unless it is one
+			return "date({v}, 'weekday 0', '-6 days')"
+		case "month":
+			return "date({v}, 'start of month')"
+		case "quarter":
+			return "date({v}, 'start of month', '-' || ((CAST(strftime('%m', {v}) AS INTEGER) - 1) % 3) || ' months')"
+		default:
+			return "date({v}, 'start of year')"
+		}
+	}
+}
+
+// extractTemplate returns the SQL of extract, with {v} for its value.
+func (g *SQLGenerator) extractTemplate(part string) string {
+	switch g.dbType {
+	case "postgres":
+		return "CAST(EXTRACT(" + strings.ToUpper(part) + " FROM {v}) AS integer)"
+	case "mysql":
+		switch part {
+		case "dow":
+			return "(DAYOFWEEK({v}) - 1)"
+		case "day":
+			return "DAYOFMONTH({v})"
+		default:
+			return strings.ToUpper(part) + "({v})"
+		}
+	default:
+		switch part {
+		case "quarter":
+			return "((CAST(strftime('%m', {v}) AS INTEGER) + 2) / 3)"
+		case "dow":
+			return "CAST(strftime('%w', {v}) AS INTEGER)"
+		}
+		format := map[string]string{"year": "%Y", "month": "%m", "day": "%d", "hour": "%H"}[part]
+		return "CAST(strftime('" + format + "', {v}) AS INTEGER)"
+	}
+}
@@ -0,0 +1,209 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+)
+
+func TestParseDateFunction(t *testing.T) {
+	call, ok, err := parseDateFunction("date_trunc(Month, timezone('Asia/Shanghai', data.ordered_at))")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "month", call.option)
+	assert.Equal(t, []string{"data.ordered_at"}, call.fields())
+	assert.True(t, call.local())
+
+	call, ok, err = parseDateFunction("date_diff(day, created_at, now())")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, []string{"created_at"}, call.fields())
+
+	_, ok, err = parseDateFunction("data.ordered_at")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	for _, field := range []string{
+		"date_trunc(fortnight, created_at)",
+		"date_trunc(month)",
+		"extract(week, created_at)",
+		"date_diff(day, created_at)",
+		"timezone('Mars/Olympus', created_at)",
+		"timezone('UTC'' OR 1=1', created_at)",
+		"date_trunc(month, extract(year, created_at))",
+		"date_trunc(month, created_at",
+		"date_trunc(month, data.x'; DROP TABLE records)",
+		"now(created_at)",
+	} {
+		_, ok, err := parseDateFunction(field)
+		assert.True(t, ok, field)
+		assert.Error(t, err, field)
+	}
+}
+
+func TestSplitSelectAlias(t *testing.T) {
+	expr, alias := splitSelectAlias("date_trunc(month, data.ordered_at) AS month")
+	assert.Equal(t, "date_trunc(month, data.ordered_at)", expr)
+	assert.Equal(t, "month", alias)
+
+	expr, alias = splitSelectAlias("data.status")
+	assert.Equal(t, "data.status", expr)
+	assert.Empty(t, alias)
+}
+
+func TestSQLGenerator_DateFunctions(t *testing.T) {
+	tests := []struct {
+		name   string
+		dbType string
+		field  string
+		sql    string
+	}{
+		{"trunc month sqlite", "sqlite", "date_trunc(month, data.ordered_at)",
+			`date(datetime(JSON_EXTRACT("data", '$.ordered_at')), 'start of month')`},
+		{"trunc hour sqlite", "sqlite", "date_trunc(hour, created_at)",
+			`strftime('%Y-%m-%dT%H:%M:%SZ', strftime('%Y-%m-%d %H:00:00', datetime("created_at")))`},
+		{"trunc week postgres", "postgres", "date_trunc(week, data.ordered_at)",
+			`to_char(date_trunc('week', (CAST("data"->>'ordered_at' AS timestamptz) AT TIME ZONE 'UTC')), 'YYYY-MM-DD')`},
+		{"trunc quarter mysql", "mysql", "date_trunc(quarter, created_at)",
+			"DATE_FORMAT(DATE_ADD(MAKEDATE(YEAR(CAST(`created_at` AS DATETIME)), 1), INTERVAL QUARTER(CAST(`created_at` AS DATETIME)) - 1 QUARTER), '%Y-%m-%d')"},
+		{"extract dow sqlite", "sqlite", "extract(dow, created_at)",
+			`CAST(strftime('%w', datetime("created_at")) AS INTEGER)`},
+		{"extract year postgres", "postgres", "extract(year, created_at)",
+			`CAST(EXTRACT(YEAR FROM (CAST("created_at" AS timestamptz) AT TIME ZONE 'UTC')) AS integer)`},
+		{"extract dow mysql", "mysql", "extract(dow, data.ordered_at)",
+			"(DAYOFWEEK(CAST(JSON_UNQUOTE(JSON_EXTRACT(`data`, '$.ordered_at')) AS DATETIME)) - 1)"},
+		{"diff days sqlite", "sqlite", "date_diff(day, created_at, now())",
+			`((CAST(strftime('%s', datetime('now')) AS INTEGER) - CAST(strftime('%s', datetime("created_at")) AS INTEGER)) / 86400)`},
+		{"diff hours postgres", "postgres", "date_diff(hour, created_at, updated_at)",
+			`CAST(TRUNC(EXTRACT(EPOCH FROM ((CAST("updated_at" AS timestamptz) AT TIME ZONE 'UTC') - (CAST("created_at" AS timestamptz) AT TIME ZONE 'UTC'))) / 3600) AS bigint)`},
+		{"diff days mysql", "mysql", "date_diff(day, created_at, updated_at)",
+			"TIMESTAMPDIFF(DAY, CAST(`created_at` AS DATETIME), CAST(`updated_at` AS DATETIME))"},
+		{"timezone sqlite", "sqlite", "timezone('Asia/Shanghai', created_at)",
+			`strftime('%Y-%m-%dT%H:%M:%S', timezone('Asia/Shanghai', datetime("created_at")))`},
+		{"timezone postgres", "postgres", "timezone(Asia/Shanghai, created_at)",
+			`to_char((((CAST("created_at" AS timestamptz) AT TIME ZONE 'UTC') AT TIME ZONE 'UTC') AT TIME ZONE 'Asia/Shanghai'), 'YYYY-MM-DD"T"HH24:MI:SS')`},
+		{"timezone mysql", "mysql", "timezone('Asia/Shanghai', created_at)",
+			"DATE_FORMAT(CONVERT_TZ(CAST(`created_at` AS DATETIME), '+00:00', 'Asia/Shanghai'), '%Y-%m-%dT%H:%i:%s')"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, params, err := NewSQLGeneratorWithDBType(tt.dbType).fieldExpression(tt.field)
+			require.NoError(t, err)
+			assert.Equal(t, tt.sql, sql)
+			assert.Empty(t, params)
+		})
+	}
+}
+
+func TestSQLGenerator_DateFunctionSelect(t *testing.T) {
+	g := NewSQLGeneratorWithDBType("postgres")
+	q, err := g.Generate(&QueryRequest{
+		From:      "records",
+		Select:    []string{"date_trunc(month, data.ordered_at) as month"},
+		GroupBy:   []string{"date_trunc(month, data.ordered_at)"},
+		OrderBy:   []OrderByClause{{Field: "date_trunc(month, data.ordered_at)", Dir: "ASC"}},
+		Aggregate: []AggregateFunc{{Func: "count", Field: "*", As: "orders"}},
+	})
+	require.NoError(t, err)
+	month := `to_char(date_trunc('month', (CAST("data"->>'ordered_at' AS timestamptz) AT TIME ZONE 'UTC')), 'YYYY-MM-DD')`
+	assert.Contains(t, q.SQL, "SELECT "+month+` AS "month", COUNT(*) AS "orders"`)
+	assert.Contains(t, q.SQL, "GROUP BY "+month)
+	assert.Contains(t, q.SQL, "ORDER BY "+month+" ASC")
+
+	q, err = g.Generate(&QueryRequest{From: "records", Select: []string{"extract(year, created_at)"}})
+	require.NoError(t, err)
+	assert.Contains(t, q.SQL, `AS "extract"`)
+
+	_, err = g.Generate(&QueryRequest{From: "records", Select: []string{`now() as "x"`}})
+	assert.Error(t, err)
+}
+
+func TestExecutor_DateFunctions(t *testing.T) {
+	db := setupQueryTestDB(t)
+	_, tbl := createTestData(t, db)
+	for _, orderedAt := range []string{
+		"2024-01-15T23:30:00Z",      // Monday, Tuesday 2024-01-16 07:30 in Shanghai
+		"2024-01-31",                // Wednesday
+		"2024-02-03T10:00:00+08:00", // Saturday, 02:00 UTC
+		"2024-05-20T08:00:00Z",      // Monday
+	} {
+		require.NoError(t, db.Create(&models.Record{TableID: tbl.ID, Data: models.JSONField(`{"ordered_at":"` + orderedAt + `"}`)}).Error)
+	}
+	executor := NewExecutor(db)
+	run := func(req *QueryRequest) []map[string]interface{} {
+		t.Helper()
+		req.From, req.Page, req.Size = "records", 1, 20
+		result, err := executor.Execute(context.Background(), req, "user1")
+		require.NoError(t, err)
+		return result.Data
+	}
+
+	t.Run("group by month", func(t *testing.T) {
+		rows := run(&QueryRequest{
+			Select:    []string{"date_trunc(month, data.ordered_at) as month"},
+			GroupBy:   []string{"date_trunc(month, data.ordered_at)"},
+			OrderBy:   []OrderByClause{{Field: "date_trunc(month, data.ordered_at)", Dir: "asc"}},
+			Aggregate: []AggregateFunc{{Func: "count", Field: "*", As: "orders"}},
+		})
+		require.Len(t, rows, 3)
+		assert.Equal(t, "2024-01-01", rows[0]["month"])
+		assert.EqualValues(t, 2, rows[0]["orders"])
+		assert.Equal(t, "2024-02-01", rows[1]["month"])
+		assert.Equal(t, "2024-05-01", rows[2]["month"])
+	})
+
+	t.Run("week, quarter and hour", func(t *testing.T) {
+		rows := run(&QueryRequest{
+			Select: []string{
+				"date_trunc(week, data.ordered_at) as week",
+				"date_trunc(quarter, data.ordered_at) as quarter",
+				"date_trunc(hour, data.ordered_at) as hour",
+			},
+			OrderBy: []OrderByClause{{Field: "data.ordered_at", Dir: "asc"}},
+		})
+		require.Len(t, rows, 4)
+		assert.Equal(t, "2024-01-15", rows[0]["week"])
+		assert.Equal(t, "2024-01-01", rows[0]["quarter"])
+		assert.Equal(t, "2024-01-15T23:00:00Z", rows[0]["hour"])
+		assert.Equal(t, "2024-01-29", rows[1]["week"])
+		assert.Equal(t, "2024-02-03T02:00:00Z", rows[2]["hour"])
+		assert.Equal(t, "2024-04-01", rows[3]["quarter"])
+	})
+
+	t.Run("timezone", func(t *testing.T) {
+		rows := run(&QueryRequest{
+			Select: []string{
+				"timezone('Asia/Shanghai', data.ordered_at) as local",
+				"date_trunc(day, timezone('Asia/Shanghai', data.ordered_at)) as local_day",
+			},
+			Where: &WhereClause{And: []Condition{{Field: "data.ordered_at", Value: "2024-01-15T23:30:00Z"}}},
+		})
+		require.Len(t, rows, 1)
+		assert.Equal(t, "2024-01-16T07:30:00", rows[0]["local"])
+		assert.Equal(t, "2024-01-16", rows[0]["local_day"])
+	})
+
+	t.Run("extract and date_diff in where", func(t *testing.T) {
+		rows := run(&QueryRequest{
+			Select: []string{"data.ordered_at"},
+			Where: &WhereClause{And: []Condition{
+				{Field: "extract(month, data.ordered_at)", Op: "lte", Value: 2},
+				{Field: "extract(dow, data.ordered_at)", Op: "in", Value: []interface{}{1.0, 6.0}},
+			}},
+		})
+		assert.Len(t, rows, 2)
+
+		rows = run(&QueryRequest{
+			Select:  []string{"date_diff(day, data.ordered_at, created_at) as days"},
+			Where:   &WhereClause{And: []Condition{{Field: "date_diff(day, data.ordered_at, now())", Op: "gt", Value: 30}}},
+			OrderBy: []OrderByClause{{Field: "data.ordered_at", Dir: "asc"}},
+		})
+		require.Len(t, rows, 4)
+		assert.Greater(t, rows[0]["days"], rows[3]["days"])
+	})
+}
@@ -55,18 +55,33 @@ func (e *Executor) Execute(ctx context.Context, req *QueryRequest, userID string
 	req = cloneQueryRequest(req)
 
 	// 1. Normalize and validate request
-	if err := e.Prepare(ctx, req, userID); err != nil {
+	scope, err := e.prepare(ctx, req, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	generator, err := e.generatorFor(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := newCursorPage(req)
+	if err != nil {
 		return nil, err
 	}
+	pageReq := req
+	if page != nil {
+		pageReq = page.request(req, generator.dbType == "postgres")
+	}
 
 	// 2. generate query SQL
-	query, err := e.generator.Generate(req)
+	query, err := generator.Generate(pageReq)
 	if err != nil {
 		return nil, fmt.Errorf("SQL generation failed: %w", err)
 	}
 
 	// 3. generate COUNT SQL
-	countQuery, err := e.generator.GenerateCount(req)
+	countQuery, err := generator.GenerateCount(req)
 	if err != nil {
 		return nil, fmt.Errorf("COUNT SQL generation failed: %w", err)
 	}
@@ -76,6 +91,14 @@ func (e *Executor) Execute(ctx context.Context, req *QueryRequest, userID string
 	if err != nil {
 		return nil, fmt.Errorf("query execution failed: %w", err)
 	}
+	if req.Qualify != nil {
+		stripWindowOrderColumns(data)
+	}
+	masks, err := e.validator.recordMasks(scope)
+	if err != nil {
+		return nil, err
+	}
+	e.validator.maskRows(data, masks)
 
 	// 5. get total count
 	total, err := e.executeCount(ctx, countQuery)
@@ -91,32 +114,44 @@ func (e *Executor) Execute(ctx context.Context, req *QueryRequest, userID string
 		Size:    req.Size,
 		HasMore: int64((req.Page-1)*req.Size+len(data)) < total,
 	}
+	if page != nil {
+		page.finish(data, result)
+	}
 
 	return result, nil
 }
 
 // Prepare normalizes, authorizes, and injects permission filters.
 func (e *Executor) Prepare(ctx context.Context, req *QueryRequest, userID string) error {
+	_, err := e.prepare(ctx, req, userID)
+	return err
+}
+
+// prepare is Prepare, returning the access scope of the user for masking the results.
+func (e *Executor) prepare(ctx context.Context, req *QueryRequest, userID string) (*validatorAccessScope, error) {
 	if err := e.normalize(req); err != nil {
-		return err
+		return nil, err
+	}
+	if err := e.parser.validateSubqueries(req); err != nil {
+		return nil, err
 	}
 
 	scope, err := e.validator.newAccessScope(userID)
 	if err != nil {
-		return fmt.Errorf("permission check failed: %w", err)
+		return nil, fmt.Errorf("permission check failed: %w", err)
 	}
 
 	if err := e.validator.validateRequestWithScope(ctx, req, userID, scope); err != nil {
-		return fmt.Errorf("permission check failed: %w", err)
+		return nil, fmt.Errorf("permission check failed: %w", err)
 	}
 
 	if err := e.validator.autoFilterByPermissionWithScope(req, scope); err != nil {
-		return err
+		return nil, err
 	}
 
 	e.expandWildcardSelections(req)
 
-	return nil
+	return scope, nil
 }
 
 // ExecuteRaw executes a raw JSON query.
@@ -300,7 +335,11 @@ func (e *Executor) ExplainAuthorized(ctx context.Context, req *QueryRequest, use
 	if err := e.Prepare(ctx, req, userID); err != nil {
 		return nil, err
 	}
-	return e.generator.Generate(req)
+	generator, err := e.generatorFor(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return generator.Generate(req)
 }
 
 // GetParser returns the parser.
@@ -395,7 +434,7 @@ func (e *Executor) normalize(req *QueryRequest) error {
 		req.Select = []string{"*"}
 	}
 
-	return nil
+	return normalizeSubqueries(req, e.normalize)
 }
 
 func (e *Executor) expandWildcardSelections(req *QueryRequest) {
@@ -420,12 +459,15 @@ func cloneQueryRequest(req *QueryRequest) *QueryRequest {
 	cloned.GroupBy = append([]string(nil), req.GroupBy...)
 	cloned.Aggregate = append([]AggregateFunc(nil), req.Aggregate...)
 	cloned.OrderBy = append([]OrderByClause(nil), req.OrderBy...)
+	cloned.Window = cloneWindowFuncs(req.Window)
+	cloned.Qualify = cloneWhereClause(req.Qualify)
 	cloned.Join = cloneJoinClauses(req.Join)
 	cloned.Where = cloneWhereClause(req.Where)
 	cloned.Having = cloneWhereClause(req.Having)
 	cloned.Union = cloneQueryRequestSlice(req.Union)
 	cloned.Intersect = cloneQueryRequestSlice(req.Intersect)
 	cloned.Filter = cloneStringAnyMap(req.Filter)
+	cloned.cursorKeys = append([]string(nil), req.cursorKeys...)
 	return &cloned
 }
 
@@ -453,6 +495,19 @@ func cloneJoinClauses(items []JoinClause) []JoinClause {
 	return cloned
 }
 
+func cloneWindowFuncs(items []WindowFunc) []WindowFunc {
+	if len(items) == 0 {
+		return nil
+	}
+	cloned := make([]WindowFunc, len(items))
+	for i := range items {
+		cloned[i] = items[i]
+		cloned[i].PartitionBy = append([]string(nil), items[i].PartitionBy...)
+		cloned[i].OrderBy = append([]OrderByClause(nil), items[i].OrderBy...)
+	}
+	return cloned
+}
+
 func cloneWhereClause(where *WhereClause) *WhereClause {
 	if where == nil {
 		return nil
@@ -474,6 +529,12 @@ func cloneConditions(items []Condition) []Condition {
 		cloned[i] = items[i]
 		cloned[i].And = cloneConditions(items[i].And)
 		cloned[i].Or = cloneConditions(items[i].Or)
+		switch sub := items[i].Value.(type) {
+		case *QueryRequest:
+			cloned[i].Value = cloneQueryRequest(sub)
+		case QueryRequest:
+			cloned[i].Value = cloneQueryRequest(&sub)
+		}
 	}
 	return cloned
 }
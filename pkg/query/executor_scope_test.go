@@ -201,3 +201,104 @@ func TestExecutor_QueryTablesWithScope(t *testing.T) {
 	require.Len(t, result.Data, 1)
 	assert.Equal(t, allowedTable.ID, result.Data[0]["id"])
 }
+
+func TestExecutor_RowFilterScope(t *testing.T) {
+	db := setupScopedExecutorTestDB(t)
+
+	database := &models.Database{Name: "testdb"}
+	require.NoError(t, db.Create(database).Error)
+	ordersTable := &models.Table{DatabaseID: database.ID, Name: "orders"}
+	usersTable := &models.Table{DatabaseID: database.ID, Name: "users"}
+	require.NoError(t, db.Create(ordersTable).Error)
+	require.NoError(t, db.Create(usersTable).Error)
+	require.NoError(t, db.Create(&models.Field{TableID: ordersTable.ID, Name: "owner", Type: "string"}).Error)
+
+	mine := &models.Record{TableID: ordersTable.ID, Data: `{"owner":"alice"}`}
+	require.NoError(t, db.Create(mine).Error)
+	require.NoError(t, db.Create(&models.Record{TableID: ordersTable.ID, Data: `{"owner":"bob"}`}).Error)
+	user := &models.Record{TableID: usersTable.ID, Data: `{"name":"carol"}`}
+	require.NoError(t, db.Create(user).Error)
+
+	token := &models.Token{
+		Name:   "row_scope",
+		Token:  "cs_row_scope",
+		Scopes: `{"subject":"alice","databases":{"` + database.ID + `":"viewer"},"tables":{"` + ordersTable.ID + `":{"role":"viewer","rows":{"owner":"$subject"}}}}`,
+	}
+	require.NoError(t, db.Create(token).Error)
+
+	executor := NewExecutor(db)
+	recordIDs := func(req *QueryRequest) []string {
+		t.Helper()
+		result, err := executor.Execute(context.Background(), req, token.ID)
+		require.NoError(t, err)
+		ids := make([]string, 0, len(result.Data))
+		for _, row := range result.Data {
+			ids = append(ids, row["id"].(string))
+		}
+		return ids
+	}
+
+	t.Run("records outside the filter are hidden", func(t *testing.T) {
+		ids := recordIDs(&QueryRequest{From: "records", Select: []string{"id"}, Page: 1, Size: 20})
+		assert.ElementsMatch(t, []string{mine.ID, user.ID}, ids)
+	})
+
+	t.Run("union queries are filtered too", func(t *testing.T) {
+		req := &QueryRequest{
+			From:   "records",
+			Select: []string{"id"},
+			Union:  []QueryRequest{{From: "records", Select: []string{"id"}}},
+		}
+		require.NoError(t, NewValidator(db).AutoFilterByPermission(req, token.ID))
+		require.NotNil(t, req.Union[0].Where)
+		require.NotEmpty(t, req.Union[0].Where.And)
+		assert.Equal(t, req.Where.And[0], req.Union[0].Where.And[0])
+		require.Len(t, req.Union[0].Where.And[0].Or, 2)
+		assert.Equal(t, []Condition{
+			{Field: "records.table_id", Op: "eq", Value: ordersTable.ID},
+			{Field: "records.data.owner", Op: "eq", Value: "alice"},
+		}, req.Union[0].Where.And[0].Or[1].And)
+	})
+
+	t.Run("joined records are filtered too", func(t *testing.T) {
+		result, err := executor.Execute(context.Background(), &QueryRequest{
+			From:   "tables",
+			Select: []string{"r.id", "r.data"},
+			Join: []JoinClause{{
+				Type:  "inner",
+				Table: "records",
+				As:    "r",
+				On:    JoinCondition{Left: "tables.id", Op: "=", Right: "r.table_id"},
+			}},
+			Where: &WhereClause{And: []Condition{{Field: "tables.id", Value: ordersTable.ID}}},
+			Page:  1,
+			Size:  20,
+		}, token.ID)
+		require.NoError(t, err)
+		require.Len(t, result.Data, 1)
+		assert.Equal(t, mine.ID, result.Data[0]["id"])
+	})
+}
+
+func TestRowFilterCondition_Postgres(t *testing.T) {
+	g := NewSQLGeneratorWithDBType("postgres")
+	tests := []struct {
+		value interface{}
+		param interface{}
+	}{
+		{1e21, "1000000000000000000000"},
+		{123456789.125, "123456789.125"},
+		{0.0000001, "0.0000001"},
+		{true, "true"},
+		{"alice", "alice"},
+	}
+	for _, tt := range tests {
+		sql, params, err := g.generateCondition(rowFilterCondition("postgres", "records", "amount", tt.value))
+		require.NoError(t, err)
+		assert.Equal(t, `"records"."data"->>'amount' = ?`, sql)
+		assert.Equal(t, []interface{}{tt.param}, params, "%v", tt.value)
+	}
+
+	// Other databases compare the JSON values themselves.
+	assert.Equal(t, 1e21, rowFilterCondition("sqlite", "records", "amount", 1e21).Value)
+}
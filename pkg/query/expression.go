@@ -0,0 +1,142 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jiangfire/cornerstone/pkg/formula"
+)
+
+// A computed expression can stand in for a field in select, aggregates, orderBy and conditions,
+// such as `{data.price} * {data.qty}`. Expressions are written in the formula language of formula
+// fields (package formula), with fields in braces; a bare name reads a column. A selected
+// expression is named by an alias: `{data.price} * {data.qty} as total`. In select, orderBy and
+// having, a name can also be the alias of an aggregate of the query: `{revenue} / {orders}`.
+//
+//	arithmetic  + - * / %, where dividing by zero gives null
+//	text        a & b, concat, lower, upper, trim, length, substring(text, start[, length])
+//	logic       = != < <= > >=, and, or, not, if(cond, then, else),
+//	            case(cond, value, cond, value, ..., else)
+//	nulls       coalesce(value, ...), is_blank(value)
+//	casts       to_number, to_text, to_date
+//
+// Literals are bound as parameters, and fields are generated like any field, so an expression
+// reads nothing its fields could not.
+
+// parseExpression parses a computed expression. ok is false when field is a field path or a date
+// function.
+func parseExpression(field string) (expr *formula.Expression, ok bool, err error) {
+	field = strings.TrimSpace(field)
+	if !strings.ContainsAny(field, "{(") {
+		return nil, false, nil
+	}
+	if _, isDate, _ := parseDateFunction(field); isDate {
+		return nil, false, nil
+	}
+	expr, err = formula.Parse(field)
+	if err != nil {
+		return nil, true, fmt.Errorf("invalid expression %q: %w", field, err)
+	}
+	return expr, true, nil
+}
+
+// expressionOf returns the computed expression of a field or selection, if it is a valid one.
+func expressionOf(field string) (*formula.Expression, bool) {
+	expr, _ := splitSelectAlias(field)
+	parsed, ok, err := parseExpression(expr)
+	if !ok || err != nil {
+		return nil, false
+	}
+	return parsed, true
+}
+
+// validateExpression validates the fields of a computed expression.
+func validateExpression(expr *formula.Expression) error {
+	for _, ref := range expr.References() {
+		if err := validateFieldExpression(ref); err != nil {
+			return fmt.Errorf("in expression %w", err)
+		}
+	}
+	return nil
+}
+
+// checkAggregateExpression rejects an aggregate of an expression naming an aggregate of the query,
+// which databases cannot nest.
+func checkAggregateExpression(req *QueryRequest, agg AggregateFunc) error {
+	expr, ok := expressionOf(agg.Field)
+	if !ok {
+		return nil
+	}
+	for _, ref := range expr.References() {
+		if req.aggregateAlias(ref) != nil {
+			return fmt.Errorf("aggregate %s cannot aggregate the aggregate '%s'", agg.As, ref)
+		}
+	}
+	return nil
+}
+
+// checkOutputFieldWithScope checks a field of select, orderBy or having, where a computed
+// expression can name the aggregates of the query.
+func (v *Validator) checkOutputFieldWithScope(ctx context.Context, req *QueryRequest, field string, scope *validatorAccessScope) error {
+	expr, ok := expressionOf(field)
+	if !ok {
+		return v.checkFieldReferenceWithScope(ctx, req.From, req.Join, field, scope)
+	}
+	for _, ref := range expr.References() {
+		if req.aggregateAlias(ref) != nil {
+			continue
+		}
+		if err := v.checkFieldReferenceWithScope(ctx, req.From, req.Join, ref, scope); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expressionSQL generates SQL for a computed expression. A name of an aggregate of the query is
+// generated as the aggregate, which every database accepts where it would reject its alias.
+func (g *SQLGenerator) expressionSQL(expr *formula.Expression) (string, []interface{}, error) {
+	return expr.SQL(g.dbType, func(ref string) (string, []interface{}, formula.Type, error) {
+		for _, agg := range g.aggregates {
+			if agg.As != ref {
+				continue
+			}
+			inner := *g
+			inner.aggregates = nil
+			sql, params, err := inner.generateAggregate(agg)
+			if err != nil {
+				return "", nil, "", err
+			}
+			return strings.TrimSuffix(sql, " AS "+g.quoteIdentifier(agg.As)), params, formula.TypeAny, nil
+		}
+		sql, params, err := g.fieldExpression(ref)
+		if err != nil {
+			return "", nil, "", err
+		}
+		// Stored values carry no type in SQL; the expression casts them where PostgreSQL needs it.
+		return sql, params, formula.TypeAny, nil
+	})
+}
+
+// selectedExpression generates a selected computed expression with its alias. ok is false for
+// other selections.
+func (g *SQLGenerator) selectedExpression(field string) (string, []interface{}, bool, error) {
+	exprText, alias := splitSelectAlias(field)
+	expr, ok, err := parseExpression(exprText)
+	if !ok || err != nil {
+		return "", nil, ok, err
+	}
+	if alias == "" {
+		return "", nil, true, errors.New("selected expression requires an alias (as)")
+	}
+	if err := ValidateIdentifier(alias); err != nil {
+		return "", nil, true, fmt.Errorf("select alias %w", err)
+	}
+	sql, params, err := g.expressionSQL(expr)
+	if err != nil {
+		return "", nil, true, err
+	}
+	return sql + " AS " + g.quoteIdentifier(alias), params, true, nil
+}
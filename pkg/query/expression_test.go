@@ -0,0 +1,208 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+)
+
+func TestSplitSelectAlias_Expressions(t *testing.T) {
+	tests := []struct {
+		field string
+		expr  string
+		alias string
+	}{
+		{"{data.price} * {data.qty} as total", "{data.price} * {data.qty}", "total"},
+		{"upper({data.name}) AS name", "upper({data.name})", "name"},
+		{"concat({data.name}, ' as x')", "concat({data.name}, ' as x')", ""},
+		{"data.name as n", "data.name as n", ""},
+	}
+	for _, tt := range tests {
+		expr, alias := splitSelectAlias(tt.field)
+		assert.Equal(t, tt.expr, expr, tt.field)
+		assert.Equal(t, tt.alias, alias, tt.field)
+	}
+}
+
+func TestParser_Expressions(t *testing.T) {
+	p := NewParser()
+	require.NoError(t, p.validate(&QueryRequest{
+		From:      "records",
+		Select:    []string{"data.region", "case({data.qty} >= 10, 'bulk', 'single') as size", "{revenue} / {orders} as mean"},
+		Where:     &WhereClause{And: []Condition{{Field: "lower(trim({data.region}))", Value: "east"}}},
+		GroupBy:   []string{"data.region"},
+		Aggregate: []AggregateFunc{{Func: "sum", Field: "{data.price} * {data.qty}", As: "revenue"}, {Func: "count", As: "orders"}},
+		Having:    &WhereClause{And: []Condition{{Field: "{revenue} / {orders}", Op: "gt", Value: 100}}},
+		OrderBy:   []OrderByClause{{Field: "{revenue} / {orders}", Dir: "desc"}},
+	}))
+
+	for name, req := range map[string]*QueryRequest{
+		"missing alias":        {Select: []string{"{data.price} * 2"}},
+		"invalid alias":        {Select: []string{"{data.price} * 2 as a-b"}},
+		"syntax error":         {Select: []string{"{data.price} * as total"}},
+		"unknown function":     {Select: []string{"explode({data.price}) as x"}},
+		"invalid field":        {OrderBy: []OrderByClause{{Field: "upper({data.x'})"}}},
+		"invalid where field":  {Where: &WhereClause{And: []Condition{{Field: "{data.a b} + 1", Value: 2}}}},
+		"nested aggregate":     {Aggregate: []AggregateFunc{{Func: "sum", Field: "data.amount", As: "total"}, {Func: "max", Field: "{total} * 2", As: "twice"}}},
+		"invalid having field": {Having: &WhereClause{And: []Condition{{Field: "{data.x;} * 2", Op: "gt", Value: 1}}}},
+	} {
+		req.From = "records"
+		assert.Error(t, p.validate(req), name)
+	}
+}
+
+func TestSQLGenerator_Expressions(t *testing.T) {
+	req := func() *QueryRequest {
+		return &QueryRequest{
+			From:      "records",
+			Select:    []string{"data.region", "case({data.qty} >= 10, 'bulk', 'single') as size"},
+			GroupBy:   []string{"data.region"},
+			Aggregate: []AggregateFunc{{Func: "sum", Field: "{data.price} * {data.qty}", As: "revenue"}, {Func: "count", As: "orders"}},
+			Having:    &WhereClause{And: []Condition{{Field: "{revenue} / {orders}", Op: "gt", Value: 100}}},
+			OrderBy:   []OrderByClause{{Field: "{revenue} / {orders}", Dir: "desc"}},
+			Page:      1,
+			Size:      10,
+		}
+	}
+	tests := []struct {
+		dbType string
+		sql    string
+	}{
+		{"sqlite", `SELECT JSON_EXTRACT("data", '$.region'), CASE WHEN (JSON_EXTRACT("data", '$.qty') >= ?) THEN ? ELSE ? END AS "size", ` +
+			`SUM((JSON_EXTRACT("data", '$.price') * JSON_EXTRACT("data", '$.qty'))) AS "revenue", COUNT(*) AS "orders" FROM "records" ` +
+			`GROUP BY JSON_EXTRACT("data", '$.region') ` +
+			`HAVING (CAST(SUM((JSON_EXTRACT("data", '$.price') * JSON_EXTRACT("data", '$.qty'))) AS REAL) / NULLIF(COUNT(*), 0)) > ? ` +
+			`ORDER BY (CAST(SUM((JSON_EXTRACT("data", '$.price') * JSON_EXTRACT("data", '$.qty'))) AS REAL) / NULLIF(COUNT(*), 0)) DESC LIMIT ? OFFSET ?`},
+		{"postgres", `SELECT "data"->>'region', CASE WHEN (CAST("data"->>'qty' AS numeric) >= CAST(? AS numeric)) THEN CAST(? AS text) ELSE CAST(? AS text) END AS "size", ` +
+			`SUM((CAST("data"->>'price' AS numeric) * CAST("data"->>'qty' AS numeric))) AS "revenue", COUNT(*) AS "orders" FROM "records" ` +
+			`GROUP BY "data"->>'region' ` +
+			`HAVING (CAST(SUM((CAST("data"->>'price' AS numeric) * CAST("data"->>'qty' AS numeric))) AS numeric) / NULLIF(CAST(COUNT(*) AS numeric), 0)) > ? ` +
+			`ORDER BY (CAST(SUM((CAST("data"->>'price' AS numeric) * CAST("data"->>'qty' AS numeric))) AS numeric) / NULLIF(CAST(COUNT(*) AS numeric), 0)) DESC LIMIT ? OFFSET ?`},
+		{"mysql", "SELECT JSON_EXTRACT(`data`, '$.region'), CASE WHEN (JSON_EXTRACT(`data`, '$.qty') >= ?) THEN ? ELSE ? END AS `size`, " +
+			"SUM((JSON_EXTRACT(`data`, '$.price') * JSON_EXTRACT(`data`, '$.qty'))) AS `revenue`, COUNT(*) AS `orders` FROM `records` " +
+			"GROUP BY JSON_EXTRACT(`data`, '$.region') " +
+			"HAVING (SUM((JSON_EXTRACT(`data`, '$.price') * JSON_EXTRACT(`data`, '$.qty'))) / NULLIF(COUNT(*), 0)) > ? " +
+			"ORDER BY (SUM((JSON_EXTRACT(`data`, '$.price') * JSON_EXTRACT(`data`, '$.qty'))) / NULLIF(COUNT(*), 0)) DESC LIMIT ? OFFSET ?"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dbType, func(t *testing.T) {
+			q, err := NewSQLGeneratorWithDBType(tt.dbType).Generate(req())
+			require.NoError(t, err)
+			assert.Equal(t, tt.sql, q.SQL)
+			assert.Equal(t, []interface{}{10.0, "bulk", "single", 100, 10, 0}, q.Params)
+		})
+	}
+
+	t.Run("literals are parameters", func(t *testing.T) {
+		q, err := NewSQLGeneratorWithDBType("sqlite").Generate(&QueryRequest{
+			From:   "records",
+			Select: []string{`concat({data.name}, '\'); DROP TABLE records; --') as label`},
+			Size:   -1,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, `SELECT (COALESCE(CAST(JSON_EXTRACT("data", '$.name') AS TEXT), '') || COALESCE(?, '')) AS "label" FROM "records"`, q.SQL)
+		assert.Equal(t, []interface{}{"'); DROP TABLE records; --"}, q.Params)
+	})
+
+	t.Run("selected expression without alias", func(t *testing.T) {
+		_, err := NewSQLGeneratorWithDBType("sqlite").Generate(&QueryRequest{From: "records", Select: []string{"{data.price} * 2"}})
+		assert.ErrorContains(t, err, "requires an alias")
+	})
+}
+
+func TestExecutor_Expressions(t *testing.T) {
+	db := setupQueryTestDB(t)
+	_, tbl := createTestData(t, db)
+	for _, data := range []string{
+		`{"region":"east","product":"widget","price":2.5,"qty":4}`,
+		`{"region":"east","product":"gadget","price":10,"qty":12}`,
+		`{"region":"west","product":"gizmo","price":3,"qty":5}`,
+		`{"region":"west","product":"doohickey","qty":2}`,
+	} {
+		require.NoError(t, db.Create(&models.Record{TableID: tbl.ID, Data: models.JSONField(data)}).Error)
+	}
+	executor := NewExecutor(db)
+	run := func(req *QueryRequest) []map[string]interface{} {
+		t.Helper()
+		req.From, req.Page, req.Size = "records", 1, 20
+		req.Where = &WhereClause{And: append([]Condition{{Field: "table_id", Value: tbl.ID}}, conditionsOf(req.Where)...)}
+		result, err := executor.Execute(context.Background(), req, "user1")
+		require.NoError(t, err)
+		return result.Data
+	}
+
+	t.Run("select and sort", func(t *testing.T) {
+		rows := run(&QueryRequest{
+			Select: []string{
+				"upper(substring({data.product}, 1, 3)) as code",
+				"coalesce({data.price}, 0) * {data.qty} as total",
+				"case({data.qty} >= 10, 'bulk', {data.qty} >= 4, 'box', 'single') as size",
+				"to_text({data.qty}) & ' pcs' as label",
+			},
+			OrderBy: []OrderByClause{{Field: "coalesce({data.price}, 0) * {data.qty}", Dir: "desc"}},
+		})
+		require.Len(t, rows, 4)
+		var codes, sizes, labels []interface{}
+		var totals []float64
+		for _, row := range rows {
+			codes = append(codes, row["code"])
+			sizes = append(sizes, row["size"])
+			labels = append(labels, row["label"])
+			totals = append(totals, toFloat64(row["total"]))
+		}
+		assert.Equal(t, []interface{}{"GAD", "GIZ", "WID", "DOO"}, codes)
+		assert.Equal(t, []float64{120, 15, 10, 0}, totals)
+		assert.Equal(t, []interface{}{"bulk", "box", "box", "single"}, sizes)
+		assert.Equal(t, []interface{}{"12 pcs", "5 pcs", "4 pcs", "2 pcs"}, labels)
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		rows := run(&QueryRequest{
+			Select: []string{"data.product"},
+			Where:  &WhereClause{And: []Condition{{Field: "{data.price} * {data.qty}", Op: "gte", Value: 15}}},
+		})
+		assert.Len(t, rows, 2)
+	})
+
+	t.Run("aggregate, having and sort", func(t *testing.T) {
+		rows := run(&QueryRequest{
+			Select:    []string{"data.region", "round({revenue} / {orders}, 1) as mean"},
+			GroupBy:   []string{"data.region"},
+			Aggregate: []AggregateFunc{{Func: "sum", Field: "{data.price} * {data.qty}", As: "revenue"}, {Func: "count", As: "orders"}},
+			Having:    &WhereClause{And: []Condition{{Field: "{revenue} / {orders}", Op: "gt", Value: 5}}},
+			OrderBy:   []OrderByClause{{Field: "{revenue} / {orders}", Dir: "desc"}},
+		})
+		require.Len(t, rows, 2)
+		assert.Equal(t, []float64{130, 15}, []float64{toFloat64(rows[0]["revenue"]), toFloat64(rows[1]["revenue"])})
+		assert.Equal(t, []float64{65, 7.5}, []float64{toFloat64(rows[0]["mean"]), toFloat64(rows[1]["mean"])})
+	})
+
+	t.Run("fields are checked", func(t *testing.T) {
+		_, err := executor.Execute(context.Background(), &QueryRequest{
+			From:   "records",
+			Select: []string{"upper({password}) as p"},
+		}, "user1")
+		assert.Error(t, err)
+	})
+}
+
+func conditionsOf(where *WhereClause) []Condition {
+	if where == nil {
+		return nil
+	}
+	return where.And
+}
+
+func toFloat64(value interface{}) float64 {
+	switch v := value.(type) {
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return 0
+}
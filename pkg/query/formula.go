@@ -0,0 +1,306 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jiangfire/cornerstone/pkg/formula"
+)
+
+// FormulaTable describes the formula fields of one table.
+// A `data.<name>` reference to one of its formula fields is computed in SQL for the table's
+// records instead of reading the value stored at write time, so filters and sorts on
+// volatile formulas (today(), now()) stay correct.
+type FormulaTable struct {
+	TableID    string
+	FieldTypes map[string]string              // field name -> field type
+	Formulas   map[string]*formula.Expression // formula field name -> parsed formula
+}
+
+// WithFormulas returns a copy of the generator that computes the given formula fields.
+func (g *SQLGenerator) WithFormulas(tables []FormulaTable) *SQLGenerator {
+	cloned := *g
+	cloned.formulas = tables
+	return &cloned
+}
+
+// forQuery binds the generator to the records table (or alias) of a single query,
+// which unqualified `data.<name>` references resolve against, and to its aggregates.
+func (g *SQLGenerator) forQuery(req *QueryRequest) *SQLGenerator {
+	cloned := *g
+	cloned.aggregates = req.Aggregate
+	if len(g.formulas) == 0 {
+		return &cloned
+	}
+	cloned.recordsQualifier = ""
+	if req.From == "records" {
+		cloned.recordsQualifier = "records"
+	} else {
+		for _, join := range req.Join {
+			if join.Table == "records" {
+				cloned.recordsQualifier = join.Table
+				if join.As != "" {
+					cloned.recordsQualifier = join.As
+				}
+				break
+			}
+		}
+	}
+	return &cloned
+}
+
+// fieldExpression generates a field expression, computing date functions, computed expressions,
+// and formula fields when configured.
+func (g *SQLGenerator) fieldExpression(field string) (string, []interface{}, error) {
+	if call, ok, err := parseDateFunction(field); ok || err != nil {
+		if err != nil {
+			return "", nil, err
+		}
+		return g.dateFunctionExpression(call)
+	}
+	if expr, ok, err := parseExpression(field); ok || err != nil {
+		if err != nil {
+			return "", nil, err
+		}
+		return g.expressionSQL(expr)
+	}
+	if sql, params, ok, err := g.formulaFieldExpression(field); ok || err != nil {
+		return sql, params, err
+	}
+	sql, err := g.generateFieldExpression(field)
+	return sql, nil, err
+}
+
+// recordDataKey splits `data.key`, `alias.data.key` and `data->>key` into the records qualifier and key.
+func recordDataKey(field string) (string, string, bool) {
+	field = strings.TrimSpace(field)
+	if strings.Contains(field, "->") {
+		parts := strings.SplitN(field, "->", 2)
+		base := strings.TrimSpace(parts[0])
+		key := strings.Trim(strings.TrimPrefix(strings.TrimSpace(parts[1]), ">"), "'\"")
+		switch {
+		case base == "data":
+			return "", key, !strings.Contains(key, ".")
+		case strings.HasSuffix(base, ".data") && strings.Count(base, ".") == 1:
+			return strings.TrimSuffix(base, ".data"), key, !strings.Contains(key, ".")
+		}
+		return "", "", false
+	}
+
+	parts := strings.Split(field, ".")
+	switch {
+	case len(parts) == 2 && parts[0] == "data":
+		return "", parts[1], true
+	case len(parts) == 3 && parts[1] == "data":
+		return parts[0], parts[2], true
+	}
+	return "", "", false
+}
+
+func (g *SQLGenerator) formulaFieldExpression(field string) (string, []interface{}, bool, error) {
+	if len(g.formulas) == 0 {
+		return "", nil, false, nil
+	}
+	qualifier, key, ok := recordDataKey(field)
+	if !ok {
+		return "", nil, false, nil
+	}
+	if qualifier == "" {
+		qualifier = g.recordsQualifier
+	}
+	if qualifier == "" || ValidateIdentifier(qualifier) != nil || ValidateJSONPathSegment(key) != nil {
+		return "", nil, false, nil
+	}
+
+	var branches []string
+	var params []interface{}
+	for _, table := range g.formulas {
+		if _, isFormula := table.Formulas[key]; !isFormula {
+			continue
+		}
+		// Table IDs are generated identifiers; validating them keeps the CASE free of bind
+		// parameters, so the same expression can repeat in SELECT and GROUP BY.
+		if err := ValidateIdentifier(table.TableID); err != nil {
+			return "", nil, true, fmt.Errorf("formula table %w", err)
+		}
+		sql, exprParams, _, err := g.compileFormula(table, qualifier, key, map[string]bool{})
+		if err != nil {
+			return "", nil, true, fmt.Errorf("formula field '%s': %w", key, err)
+		}
+		branches = append(branches, fmt.Sprintf("WHEN %s = '%s' THEN %s", g.quoteQualifiedIdentifier(qualifier+".table_id"), table.TableID, g.storedValueExpression(sql)))
+		params = append(params, exprParams...)
+	}
+	if len(branches) == 0 {
+		return "", nil, false, nil
+	}
+
+	stored, err := g.generateJSONFieldExpression(qualifier+".data", key)
+	if err != nil {
+		return "", nil, true, err
+	}
+	return "CASE " + strings.Join(branches, " ") + " ELSE " + stored + " END", params, true, nil
+}
+
+// storedValueExpression converts a computed value to the representation of stored JSON values,
+// so that computed and stored branches of the CASE compare the same way.
+func (g *SQLGenerator) storedValueExpression(sql string) string {
+	switch g.dbType {
+	case "sqlite":
+		return sql
+	case "mysql":
+		return "JSON_EXTRACT(JSON_ARRAY(" + sql + "), '$[0]')"
+	default:
+		// data->>'key' is text on PostgreSQL.
+		return "CAST(" + sql + " AS text)"
+	}
+}
+
+func (g *SQLGenerator) compileFormula(table FormulaTable, qualifier, name string, visiting map[string]bool) (string, []interface{}, formula.Type, error) {
+	expr := table.Formulas[name]
+	if visiting[name] {
+		return "", nil, "", fmt.Errorf("circular reference through '%s'", name)
+	}
+	visiting[name] = true
+	defer delete(visiting, name)
+
+	sql, params, err := expr.SQL(g.dbType, func(ref string) (string, []interface{}, formula.Type, error) {
+		if _, isFormula := table.Formulas[ref]; isFormula {
+			return g.compileFormula(table, qualifier, ref, visiting)
+		}
+		if err := ValidateJSONPathSegment(ref); err != nil {
+			return "", nil, "", fmt.Errorf("field '%s' cannot be referenced in SQL: %w", ref, err)
+		}
+		typ := formula.TypeOfField(table.FieldTypes[ref])
+		return g.typedDataField(qualifier, ref, typ), nil, typ, nil
+	})
+	if err != nil {
+		return "", nil, "", err
+	}
+	// Nested formulas are typed as any: their result type is only known from a full check.
+	return "(" + sql + ")", params, formula.TypeAny, nil
+}
+
+// typedDataField extracts a stored field value as a SQL value of the given type.
+// The caller validates qualifier and key.
+func (g *SQLGenerator) typedDataField(qualifier, key string, typ formula.Type) string {
+	column := g.quoteQualifiedIdentifier(qualifier + ".data")
+	switch g.dbType {
+	case "sqlite":
+		return fmt.Sprintf("JSON_EXTRACT(%s, '$.%s')", column, key)
+	case "mysql":
+		switch typ {
+		case formula.TypeNumber:
+			return fmt.Sprintf("JSON_VALUE(%s, '$.%s' RETURNING DOUBLE)", column, key)
+		case formula.TypeDate:
+			return fmt.Sprintf("JSON_VALUE(%s, '$.%s' RETURNING DATE)", column, key)
+		case formula.TypeDatetime:
+			return fmt.Sprintf("JSON_VALUE(%s, '$.%s' RETURNING DATETIME)", column, key)
+		case formula.TypeBool:
+			return fmt.Sprintf("(JSON_UNQUOTE(JSON_EXTRACT(%s, '$.%s')) = 'true')", column, key)
+		}
+		// JSON null unquotes to the string 'null'.
+		return fmt.Sprintf("NULLIF(JSON_UNQUOTE(JSON_EXTRACT(%s, '$.%s')), 'null')", column, key)
+	default:
+		text := fmt.Sprintf("%s->>'%s'", column, key)
+		switch typ {
+		case formula.TypeNumber:
+			return "CAST(" + text + " AS numeric)"
+		case formula.TypeBool:
+			return "CAST(" + text + " AS boolean)"
+		case formula.TypeDate:
+			return "CAST(" + text + " AS date)"
+		case formula.TypeDatetime:
+			return "CAST(" + text + " AS timestamptz)"
+		}
+		return text
+	}
+}
+
+// queryUsesRecords reports whether any part of the request reads the records table.
+func queryUsesRecords(req *QueryRequest) bool {
+	if req.From == "records" || req.Table == "records" {
+		return true
+	}
+	for _, join := range req.Join {
+		if join.Table == "records" {
+			return true
+		}
+	}
+	for i := range req.Union {
+		if queryUsesRecords(&req.Union[i]) {
+			return true
+		}
+	}
+	for i := range req.Intersect {
+		if queryUsesRecords(&req.Intersect[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadFormulaTables loads the formula fields of all tables that have any.
+func (e *Executor) loadFormulaTables(ctx context.Context) ([]FormulaTable, error) {
+	type fieldRow struct {
+		TableID string
+		Name    string
+		Type    string
+		Options string
+	}
+	var rows []fieldRow
+	if err := e.db.WithContext(ctx).Table("fields").
+		Select("table_id, name, type, options").
+		Where("deleted_at IS NULL AND table_id IN (?)",
+			e.db.Table("fields").Select("table_id").Where("type = ? AND deleted_at IS NULL", "formula")).
+		Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load formula fields: %w", err)
+	}
+
+	byTable := make(map[string]*FormulaTable)
+	var order []string
+	for _, row := range rows {
+		table, ok := byTable[row.TableID]
+		if !ok {
+			table = &FormulaTable{TableID: row.TableID, FieldTypes: map[string]string{}, Formulas: map[string]*formula.Expression{}}
+			byTable[row.TableID] = table
+			order = append(order, row.TableID)
+		}
+		table.FieldTypes[row.Name] = row.Type
+		if row.Type != "formula" {
+			continue
+		}
+		var config struct {
+			Formula string `json:"formula"`
+		}
+		_ = json.Unmarshal([]byte(row.Options), &config)
+		expr, err := formula.Parse(config.Formula)
+		if err != nil {
+			// Stored formulas are validated on save; an unparsable one falls back to its stored value.
+			continue
+		}
+		table.Formulas[row.Name] = expr
+	}
+
+	tables := make([]FormulaTable, 0, len(order))
+	for _, id := range order {
+		tables = append(tables, *byTable[id])
+	}
+	return tables, nil
+}
+
+// generatorFor returns the generator for a prepared request, computing formula fields when the query reads records.
+func (e *Executor) generatorFor(ctx context.Context, req *QueryRequest) (*SQLGenerator, error) {
+	if e.db == nil || !queryUsesRecords(req) {
+		return e.generator, nil
+	}
+	tables, err := e.loadFormulaTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(tables) == 0 {
+		return e.generator, nil
+	}
+	return e.generator.WithFormulas(tables), nil
+}
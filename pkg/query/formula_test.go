@@ -0,0 +1,91 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/pkg/formula"
+)
+
+func testFormulaTables(t *testing.T) []FormulaTable {
+	t.Helper()
+	expr, err := formula.Parse("price * 2")
+	require.NoError(t, err)
+	return []FormulaTable{{
+		TableID:    "tbl_abc",
+		FieldTypes: map[string]string{"price": "number", "total": "formula"},
+		Formulas:   map[string]*formula.Expression{"total": expr},
+	}}
+}
+
+func TestSQLGenerator_FormulaFieldComputed(t *testing.T) {
+	g := NewSQLGenerator(true).WithFormulas(testFormulaTables(t))
+
+	query, err := g.Generate(&QueryRequest{
+		From:    "records",
+		Select:  []string{"id", "data.total"},
+		Where:   &WhereClause{And: []Condition{{Field: "data.total", Op: "gt", Value: 10}}},
+		OrderBy: []OrderByClause{{Field: "data.total", Dir: "desc"}},
+		Page:    1,
+		Size:    5,
+	})
+	require.NoError(t, err)
+
+	computed := `CASE WHEN "records"."table_id" = 'tbl_abc' THEN ((JSON_EXTRACT("records"."data", '$.price') * ?)) ELSE JSON_EXTRACT("records"."data", '$.total') END`
+	assert.Equal(t, `SELECT "id", `+computed+` AS "total" FROM "records" WHERE `+computed+` > ? ORDER BY `+computed+` DESC LIMIT ? OFFSET ?`, query.SQL)
+	assert.Equal(t, []interface{}{2.0, 2.0, 10, 2.0, 5, 0}, query.Params)
+}
+
+func TestSQLGenerator_FormulaFieldNeedsRecordsTable(t *testing.T) {
+	g := NewSQLGenerator(true).WithFormulas(testFormulaTables(t))
+
+	expr, _, err := g.forQuery(&QueryRequest{From: "tables"}).fieldExpression("data.total")
+	require.NoError(t, err)
+	assert.NotContains(t, expr, "CASE")
+
+	expr, _, err = g.forQuery(&QueryRequest{From: "records"}).fieldExpression("data.price")
+	require.NoError(t, err)
+	assert.NotContains(t, expr, "CASE")
+}
+
+func TestExecutor_FiltersAndSortsByFormulaField(t *testing.T) {
+	db := setupScopedExecutorTestDB(t)
+
+	database := &models.Database{Name: "shop"}
+	require.NoError(t, db.Create(database).Error)
+	table := &models.Table{DatabaseID: database.ID, Name: "orders"}
+	require.NoError(t, db.Create(table).Error)
+	require.NoError(t, db.Create(&models.Field{TableID: table.ID, Name: "price", Type: "number"}).Error)
+	require.NoError(t, db.Create(&models.Field{TableID: table.ID, Name: "quantity", Type: "number"}).Error)
+	require.NoError(t, db.Create(&models.Field{TableID: table.ID, Name: "total", Type: "formula", Options: `{"formula":"price * quantity"}`}).Error)
+
+	// Stored totals are stale; queries must use the computed values.
+	require.NoError(t, db.Create(&models.Record{TableID: table.ID, Data: `{"price":2,"quantity":3,"total":0}`}).Error)
+	require.NoError(t, db.Create(&models.Record{TableID: table.ID, Data: `{"price":5,"quantity":4,"total":0}`}).Error)
+	require.NoError(t, db.Create(&models.Record{TableID: table.ID, Data: `{"price":1,"quantity":1,"total":99}`}).Error)
+
+	token := &models.Token{
+		Name:   "viewer",
+		Token:  "cs_viewer_formula",
+		Scopes: `{"databases":{"` + database.ID + `":"viewer"}}`,
+	}
+	require.NoError(t, db.Create(token).Error)
+
+	result, err := NewExecutor(db).Execute(context.Background(), &QueryRequest{
+		From:    "records",
+		Select:  []string{"id", "data.total"},
+		Where:   &WhereClause{And: []Condition{{Field: "data.total", Op: "gte", Value: 6}}},
+		OrderBy: []OrderByClause{{Field: "data.total", Dir: "desc"}},
+		Page:    1,
+		Size:    20,
+	}, token.ID)
+	require.NoError(t, err)
+	require.Len(t, result.Data, 2)
+	assert.EqualValues(t, 2, result.Total)
+	assert.EqualValues(t, 20, result.Data[0]["total"])
+	assert.EqualValues(t, 6, result.Data[1]["total"])
+}
@@ -0,0 +1,291 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jiangfire/cornerstone/internal/authz"
+	"github.com/jiangfire/cornerstone/internal/models"
+)
+
+// recordMasks holds the mask rules of a token on the fields of records, by field name.
+type recordMasks struct {
+	byTable map[string]map[string]string // rules of each table, by table ID
+	any     map[string]string            // rules of any table, for rows without a table_id
+}
+
+// recordMasks returns the mask rules of the token of scope, or nil when it reads every record
+// field unmasked. Formula fields reading a masked field are masked too, so a `data.<formula>`
+// reference, which expands to SQL over the fields the formula reads, is checked like them.
+func (v *Validator) recordMasks(scope *validatorAccessScope) (*recordMasks, error) {
+	if scope.masksLoaded {
+		return scope.masks, nil
+	}
+	tableIDs := scope.authorizer.MaskedTableIDs()
+	if len(tableIDs) > 0 {
+		var fields []models.Field
+		if err := v.db.Where("table_id IN ? AND deleted_at IS NULL", tableIDs).Find(&fields).Error; err != nil {
+			return nil, fmt.Errorf("failed to load masked fields: %w", err)
+		}
+		fieldsByTable := make(map[string][]models.Field)
+		for _, field := range fields {
+			fieldsByTable[field.TableID] = append(fieldsByTable[field.TableID], field)
+		}
+		masks := &recordMasks{byTable: make(map[string]map[string]string), any: make(map[string]string)}
+		for _, tableID := range tableIDs {
+			rules := scope.authorizer.FieldMasks(tableID, fieldsByTable[tableID])
+			if len(rules) == 0 {
+				continue
+			}
+			masks.byTable[tableID] = rules
+			for name, rule := range rules {
+				// A field masked in two tables by different rules is redacted when the table is unknown.
+				if existing, ok := masks.any[name]; ok && existing != rule {
+					rule = authz.MaskRedact
+				}
+				masks.any[name] = rule
+			}
+		}
+		if len(masks.any) > 0 {
+			scope.masks = masks
+		}
+	}
+	scope.masksLoaded = true
+	return scope.masks, nil
+}
+
+// checkMaskedFields rejects the references of a query to masked record fields that masking the
+// results cannot reach: selecting a masked field by key, and filtering, sorting, grouping,
+// aggregating or joining by a masked field or by the whole data column. A token reads masked
+// fields through the data column, whose values are masked.
+func (v *Validator) checkMaskedFields(req *QueryRequest, scope *validatorAccessScope) error {
+	if !queryReadsRecords(req) {
+		return nil
+	}
+	masks, err := v.recordMasks(scope)
+	if err != nil || masks == nil {
+		return err
+	}
+
+	check := masks.check
+	var checkConditions func(conditions []Condition) error
+	checkConditions = func(conditions []Condition) error {
+		for _, cond := range conditions {
+			if cond.Field != "" {
+				if err := check(cond.Field, false); err != nil {
+					return err
+				}
+			}
+			if err := checkConditions(cond.And); err != nil {
+				return err
+			}
+			if err := checkConditions(cond.Or); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, field := range req.Select {
+		if err := check(field, true); err != nil {
+			return err
+		}
+	}
+	for _, join := range req.Join {
+		for _, field := range join.Select {
+			if err := check(field, true); err != nil {
+				return err
+			}
+		}
+		for _, field := range []string{join.On.Left, join.On.Right} {
+			if err := check(field, false); err != nil {
+				return err
+			}
+		}
+	}
+	for _, where := range []*WhereClause{req.Where, req.Having} {
+		if where == nil {
+			continue
+		}
+		if err := checkConditions(where.And); err != nil {
+			return err
+		}
+		if err := checkConditions(where.Or); err != nil {
+			return err
+		}
+	}
+	for _, order := range req.OrderBy {
+		if err := check(order.Field, false); err != nil {
+			return err
+		}
+	}
+	for _, w := range req.Window {
+		fields := append([]string{w.Field}, w.PartitionBy...)
+		for _, order := range w.OrderBy {
+			fields = append(fields, order.Field)
+		}
+		for _, field := range fields {
+			if field == "" {
+				continue
+			}
+			if err := check(field, false); err != nil {
+				return err
+			}
+		}
+	}
+	for _, group := range req.GroupBy {
+		if err := check(group, false); err != nil {
+			return err
+		}
+	}
+	for _, agg := range req.Aggregate {
+		if err := check(agg.Field, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkMaskedSubquery rejects the masked record fields a subquery compares with the rows of its
+// enclosing query outer: the column of an in subquery, and the fields its refs read.
+func (v *Validator) checkMaskedSubquery(outer *QueryRequest, cond Condition, sub *QueryRequest, refs []string, scope *validatorAccessScope) error {
+	masks, err := v.recordMasks(scope)
+	if err != nil || masks == nil {
+		return err
+	}
+	if (cond.Op == "in" || cond.Op == "not_in") && queryReadsRecords(sub) {
+		fields := subquerySelect(sub)
+		for _, agg := range sub.Aggregate {
+			fields = append(fields, agg.Field)
+		}
+		for _, field := range fields {
+			if err := masks.check(field, false); err != nil {
+				return err
+			}
+		}
+	}
+	if queryReadsRecords(outer) {
+		for _, ref := range refs {
+			if err := masks.check(ref, false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// check rejects a reference to a masked record field, or to the whole data column unless it is
+// selected, whose values are masked.
+func (m *recordMasks) check(field string, selected bool) error {
+	if call, ok := dateFunctionOf(field); ok {
+		for _, f := range call.fields() {
+			if err := m.check(f, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if expr, ok := expressionOf(field); ok {
+		for _, ref := range expr.References() {
+			if err := m.check(ref, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	key, whole := recordDataReference(field)
+	if whole && !selected {
+		return fmt.Errorf("field '%s' cannot be queried, the token reads masked fields", field)
+	}
+	if _, ok := m.any[key]; ok && !whole {
+		return fmt.Errorf("field '%s' is masked, select data to read its masked value", field)
+	}
+	return nil
+}
+
+// queryReadsRecords reports whether a query reads the records table, directly or by a join.
+func queryReadsRecords(req *QueryRequest) bool {
+	if req.From == "records" {
+		return true
+	}
+	for _, join := range req.Join {
+		if join.Table == "records" {
+			return true
+		}
+	}
+	return false
+}
+
+// recordDataReference returns the record field a field reference reads, such as status for
+// data.status, records.data->>'status' or r.data.status.address. whole is true for a reference
+// to the data column itself.
+func recordDataReference(field string) (key string, whole bool) {
+	field = strings.TrimSpace(field)
+	if base, path, ok := strings.Cut(field, "->"); ok {
+		base = strings.TrimSpace(base)
+		if base != "data" && !strings.HasSuffix(base, ".data") {
+			return "", false
+		}
+		path = strings.Trim(strings.TrimPrefix(strings.TrimSpace(path), ">"), "'\"")
+		key, _, _ = strings.Cut(path, ".")
+		return key, false
+	}
+	parts := strings.Split(field, ".")
+	for i, part := range parts {
+		if part != "data" || i > 1 {
+			continue
+		}
+		if i == len(parts)-1 {
+			return "", true
+		}
+		return parts[i+1], false
+	}
+	return "", false
+}
+
+// maskRows masks the values of masked fields in the data columns of query results, by the rules
+// of the table of each row when it has a table_id, and of any table otherwise.
+func (v *Validator) maskRows(rows []map[string]interface{}, masks *recordMasks) {
+	if masks == nil {
+		return
+	}
+	for _, row := range rows {
+		data, ok := row["data"]
+		if !ok || data == nil {
+			continue
+		}
+		rules := masks.any
+		if tableID, ok := row["table_id"].(string); ok {
+			rules = masks.byTable[tableID]
+		}
+		if len(rules) == 0 {
+			continue
+		}
+		row["data"] = maskRecordData(data, rules)
+	}
+}
+
+// maskRecordData masks the values of a data column, decoded or as JSON text.
+func maskRecordData(data interface{}, rules map[string]string) interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for name, rule := range rules {
+			if value, ok := v[name]; ok {
+				v[name] = authz.MaskValue(rule, value)
+			}
+		}
+		return v
+	case string:
+		var decoded map[string]interface{}
+		if err := json.Unmarshal([]byte(v), &decoded); err != nil {
+			return v
+		}
+		encoded, err := json.Marshal(maskRecordData(decoded, rules))
+		if err != nil {
+			return v
+		}
+		return string(encoded)
+	}
+	return data
+}
@@ -0,0 +1,118 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+	"github.com/jiangfire/cornerstone/internal/testutil"
+)
+
+func TestRecordDataReference(t *testing.T) {
+	tests := []struct {
+		field string
+		key   string
+		whole bool
+	}{
+		{"data.card", "card", false},
+		{"records.data.card", "card", false},
+		{"data.address.city", "address", false},
+		{"data->>'card'", "card", false},
+		{"r.data->'card'", "card", false},
+		{"data", "", true},
+		{"records.data", "", true},
+		{"table_id", "", false},
+		{"tables.name", "", false},
+	}
+	for _, tt := range tests {
+		key, whole := recordDataReference(tt.field)
+		assert.Equal(t, tt.key, key, tt.field)
+		assert.Equal(t, tt.whole, whole, tt.field)
+	}
+}
+
+func TestExecutor_MaskedFields(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	database := &models.Database{Name: "testdb"}
+	require.NoError(t, db.Create(database).Error)
+	table := &models.Table{DatabaseID: database.ID, Name: "customers"}
+	require.NoError(t, db.Create(table).Error)
+	require.NoError(t, db.Create(&models.Field{TableID: table.ID, Name: "card", Type: "string"}).Error)
+	require.NoError(t, db.Create(&models.Field{TableID: table.ID, Name: "name", Type: "string"}).Error)
+	require.NoError(t, db.Create(&models.Field{TableID: table.ID, Name: "digits", Type: "formula", Options: `{"formula":"card"}`}).Error)
+	require.NoError(t, db.Create(&models.Field{TableID: table.ID, Name: "prefix", Type: "formula", Options: `{"formula":"digits & name"}`}).Error)
+	require.NoError(t, db.Create(&models.Record{TableID: table.ID, Data: `{"card":"4111111111111234","name":"alice","digits":"4111111111111234","prefix":"4111111111111234alice"}`}).Error)
+
+	token := &models.Token{
+		Name:   "masked",
+		Token:  "cs_masked_scope",
+		Scopes: `{"databases":{"` + database.ID + `":"viewer"},"tables":{"` + table.ID + `":{"role":"viewer","masks":{"card":"last4"}}}}`,
+	}
+	require.NoError(t, db.Create(token).Error)
+	executor := NewExecutor(db)
+
+	t.Run("data is returned masked", func(t *testing.T) {
+		for _, selection := range [][]string{{"id", "data"}, {"id", "table_id", "data"}, {"*"}} {
+			result, err := executor.Execute(context.Background(), &QueryRequest{From: "records", Select: selection, Page: 1, Size: 20}, token.ID)
+			require.NoError(t, err)
+			require.Len(t, result.Data, 1)
+			assert.Contains(t, result.Data[0]["data"], "****1234", "select %v", selection)
+			assert.NotContains(t, result.Data[0]["data"], "4111", "select %v", selection)
+			assert.Contains(t, result.Data[0]["data"], `"prefix":"****"`, "select %v", selection)
+		}
+	})
+
+	t.Run("masked fields cannot be queried", func(t *testing.T) {
+		for _, req := range []*QueryRequest{
+			{From: "records", Select: []string{"data.card"}},
+			{From: "records", Select: []string{"id"}, Where: &WhereClause{And: []Condition{{Field: "data.card", Op: "like", Value: "4111%"}}}},
+			{From: "records", Select: []string{"id"}, Where: &WhereClause{And: []Condition{{Field: "data", Op: "like", Value: "%4111%"}}}},
+			{From: "records", Select: []string{"id"}, OrderBy: []OrderByClause{{Field: "data.card"}}},
+			{From: "records", Select: []string{"extract(year, data.card) as year"}},
+			{From: "records", Select: []string{"substring({data.card}, 1, 4) as prefix"}},
+			{From: "records", Select: []string{"id"}, OrderBy: []OrderByClause{{Field: "to_number({data.card}) % 10"}}},
+			{From: "records", Select: []string{"id"}, Union: []QueryRequest{{From: "records", Select: []string{"data.card"}}}},
+			{From: "records", Select: []string{"id"}, Where: &WhereClause{And: []Condition{{Field: "data.digits", Op: "like", Value: "4111%"}}}},
+			{From: "records", Select: []string{"id"}, OrderBy: []OrderByClause{{Field: "data.prefix"}}},
+			{From: "records", Select: []string{"id"}, Where: &WhereClause{And: []Condition{{Field: "{data.prefix} = 'x'", Op: "eq", Value: true}}}},
+		} {
+			req.Page, req.Size = 1, 20
+			_, err := executor.Execute(context.Background(), req, token.ID)
+			assert.Error(t, err)
+		}
+	})
+
+	t.Run("unmasked fields can be queried", func(t *testing.T) {
+		result, err := executor.Execute(context.Background(), &QueryRequest{
+			From:   "records",
+			Select: []string{"id"},
+			Where:  &WhereClause{And: []Condition{{Field: "data.name", Op: "eq", Value: "alice"}}},
+			Page:   1,
+			Size:   20,
+		}, token.ID)
+		require.NoError(t, err)
+		assert.Len(t, result.Data, 1)
+	})
+}
+
+func TestFilterFieldsByPermission_MasksRecordData(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	table := &models.Table{DatabaseID: "db_1", Name: "customers"}
+	require.NoError(t, db.Create(table).Error)
+	require.NoError(t, db.Create(&models.Field{TableID: table.ID, Name: "email", Type: "string"}).Error)
+	token := &models.Token{
+		Name:   "masked",
+		Token:  "cs_masked_filter",
+		Scopes: `{"tables":{"` + table.ID + `":{"role":"viewer","masks":{"email":"redact"}}}}`,
+	}
+	require.NoError(t, db.Create(token).Error)
+
+	rows := []map[string]interface{}{{"id": "rec_1", "table_id": table.ID, "data": map[string]interface{}{"email": "a@example.com"}}}
+	filtered, err := NewValidator(db).FilterFieldsByPermission(context.Background(), rows, "records", token.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"email": "****"}, filtered[0]["data"])
+}
@@ -21,6 +21,15 @@ type QueryRequest struct {
 	Page      int             `json:"page"`      // Page number
 	Size      int             `json:"size"`      // Page size
 
+	// Window functions, see window.go
+	Window  []WindowFunc `json:"window,omitempty"`  // Window functions
+	Qualify *WhereClause `json:"qualify,omitempty"` // Conditions on window functions, such as the top N per group
+
+	// Cursor pagination, see cursor.go
+	Cursor bool   `json:"cursor,omitempty"` // Page with cursors instead of page numbers
+	After  string `json:"after,omitempty"`  // Cursor of the row the page starts after
+	Before string `json:"before,omitempty"` // Cursor of the row the page ends before
+
 	// Set operations
 	Union     []QueryRequest `json:"union,omitempty"`     // UNION queries
 	Intersect []QueryRequest `json:"intersect,omitempty"` // INTERSECT queries
@@ -29,6 +38,8 @@ type QueryRequest struct {
 	Table  string                 `json:"table"`  // Primary table (shorthand)
 	Filter map[string]interface{} `json:"filter"` // Filter conditions (shorthand)
 	Sort   string                 `json:"sort"`   // Sort (shorthand, e.g. "-created_at")
+
+	cursorKeys []string // sort keys a cursor page selects as hidden columns
 }
 
 // WhereClause is a WHERE condition.
@@ -42,7 +53,8 @@ type WhereClause struct {
 type Condition struct {
 	Field string      `json:"field"`         // Field name
 	Op    string      `json:"op,omitempty"`  // Operator; defaults to eq when omitted
-	Value interface{} `json:"value"`         // Value
+	Value interface{} `json:"value"`         // Value, or a subquery for in, not_in, exists and not_exists
+	Ref   string      `json:"ref,omitempty"` // Field of the enclosing query to compare with, in a subquery
 	Not   bool        `json:"not,omitempty"` // Negation
 	And   []Condition `json:"and,omitempty"` // Nested AND
 	Or    []Condition `json:"or,omitempty"`  // Nested OR
@@ -100,6 +112,16 @@ type AggregateFunc struct {
 	As    string `json:"as"`              // Alias
 }
 
+// WindowFunc is a window function, computed for every row over the rows of its partition.
+type WindowFunc struct {
+	Func        string          `json:"func"`                  // row_number, rank, dense_rank, lag, lead, sum, avg
+	Field       string          `json:"field,omitempty"`       // Field or aggregate alias, for lag, lead, sum and avg
+	Offset      int             `json:"offset,omitempty"`      // Rows back or ahead for lag and lead (default 1)
+	PartitionBy []string        `json:"partitionBy,omitempty"` // Partition fields
+	OrderBy     []OrderByClause `json:"orderBy,omitempty"`     // Order within the partition; sum and avg run along it
+	As          string          `json:"as"`                    // Alias
+}
+
 // OrderByClause is an ORDER BY clause.
 type OrderByClause struct {
 	Field string `json:"field"`         // Field name
@@ -113,6 +135,9 @@ type QueryResult struct {
 	Page    int                      `json:"page"`     // Current page
 	Size    int                      `json:"size"`     // Page size
 	HasMore bool                     `json:"has_more"` // Whether more pages exist
+
+	NextCursor string `json:"next_cursor,omitempty"` // Cursor of the next page
+	PrevCursor string `json:"prev_cursor,omitempty"` // Cursor of the previous page
 }
 
 // BatchQueryRequest is a batch query request.
@@ -0,0 +1,231 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// generateStringCondition generates SQL for the string operators ilike, starts_with, ends_with,
+// contains and regex. starts_with, ends_with and contains match their value literally and case
+// sensitively in every database; ilike is like without case.
+func (g *SQLGenerator) generateStringCondition(op, fieldExpr string, value interface{}, not bool) (string, []interface{}, error) {
+	str, ok := value.(string)
+	if !ok {
+		return "", nil, fmt.Errorf("'%s' operator requires a string value", op)
+	}
+	if g.dbType == "mysql" && strings.HasPrefix(fieldExpr, "JSON_EXTRACT(") {
+		// JSON_EXTRACT returns JSON strings quoted in MySQL
+		fieldExpr = "JSON_UNQUOTE(" + fieldExpr + ")"
+	}
+
+	var sql string
+	switch op {
+	case "ilike":
+		if !strings.Contains(str, "%") {
+			str = "%" + str + "%"
+		}
+		if g.dbType == "postgres" {
+			sql = fieldExpr + " ILIKE ?"
+		} else {
+			sql = "LOWER(" + fieldExpr + ") LIKE LOWER(?)"
+		}
+	case "regex":
+		if _, err := regexp.Compile(str); err != nil {
+			return "", nil, fmt.Errorf("'regex' operator requires a valid regular expression: %w", err)
+		}
+		switch g.dbType {
+		case "postgres":
+			sql = fieldExpr + " ~ ?"
+		case "mysql":
+			sql = "REGEXP_LIKE(" + fieldExpr + ", ?, 'c')"
+		default:
+			// regexp() is registered on SQLite connections by pkg/db
+			sql = fieldExpr + " REGEXP ?"
+		}
+	default:
+		prefix, suffix := "", ""
+		if op != "starts_with" {
+			prefix = "%"
+		}
+		if op != "ends_with" {
+			suffix = "%"
+		}
+		switch g.dbType {
+		case "postgres":
+			sql = fieldExpr + " LIKE ? ESCAPE '!'"
+			str = prefix + escapeLike(str) + suffix
+		case "mysql":
+			sql = "CAST(" + fieldExpr + " AS BINARY) LIKE ? ESCAPE '!'"
+			str = prefix + escapeLike(str) + suffix
+		default:
+			// LIKE ignores case in SQLite, GLOB does not
+			sql = fieldExpr + " GLOB ?"
+			str = strings.ReplaceAll(prefix, "%", "*") + escapeGlob(str) + strings.ReplaceAll(suffix, "%", "*")
+		}
+	}
+	if not {
+		sql = "NOT " + sql
+	}
+	return sql, []interface{}{str}, nil
+}
+
+var (
+	likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+	globEscaper = strings.NewReplacer("[", "[[]", "*", "[*]", "?", "[?]")
+)
+
+// escapeLike escapes the wildcards of a LIKE pattern with ESCAPE '!'.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// escapeGlob escapes the wildcards of a SQLite GLOB pattern.
+func escapeGlob(s string) string {
+	return globEscaper.Replace(s)
+}
+
+// generateListCondition generates SQL for has_any and has_all, which match a JSON array at a
+// JSON path, such as a list field at data.tags, holding any or all of the values of an array.
+func (g *SQLGenerator) generateListCondition(op, field string, value interface{}, not bool) (string, []interface{}, error) {
+	column, path, err := g.jsonPathField(op, field)
+	if err != nil {
+		return "", nil, err
+	}
+	values, ok := value.([]interface{})
+	if !ok {
+		return "", nil, fmt.Errorf("'%s' operator requires an array value", op)
+	}
+	if len(values) == 0 {
+		return "", nil, fmt.Errorf("'%s' operator array cannot be empty", op)
+	}
+	// Duplicates would break the count of has_all in SQLite.
+	var distinct []interface{}
+	seen := make(map[string]bool)
+	for _, v := range values {
+		switch v.(type) {
+		case string, float64, int, int64, bool:
+		default:
+			return "", nil, fmt.Errorf("'%s' operator accepts only string, number and boolean values", op)
+		}
+		key := fmt.Sprintf("%T:%v", v, v)
+		if !seen[key] {
+			seen[key] = true
+			distinct = append(distinct, v)
+		}
+	}
+
+	var sql string
+	var params []interface{}
+	switch g.dbType {
+	case "postgres", "mysql":
+		var contains func(string) string
+		if g.dbType == "postgres" {
+			contains = func(placeholder string) string {
+				return fmt.Sprintf("CAST(%s #> '%s' AS jsonb) @> CAST(%s AS jsonb)", column, postgresJSONPath(path), placeholder)
+			}
+		} else {
+			contains = func(placeholder string) string {
+				return fmt.Sprintf("JSON_CONTAINS(JSON_EXTRACT(%s, '$.%s'), CAST(%s AS JSON))", column, path, placeholder)
+			}
+		}
+		if op == "has_all" {
+			encoded, err := json.Marshal(distinct)
+			if err != nil {
+				return "", nil, err
+			}
+			sql = contains("?")
+			params = append(params, string(encoded))
+			break
+		}
+		branches := make([]string, len(distinct))
+		for i, v := range distinct {
+			encoded, err := json.Marshal([]interface{}{v})
+			if err != nil {
+				return "", nil, err
+			}
+			branches[i] = contains("?")
+			params = append(params, string(encoded))
+		}
+		sql = "(" + strings.Join(branches, " OR ") + ")"
+	default:
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(distinct)), ", ")
+		params = append(params, distinct...)
+		if op == "has_all" {
+			sql = fmt.Sprintf("(SELECT COUNT(DISTINCT je.value) FROM json_each(%s, '$.%s') AS je WHERE je.value IN (%s)) = %d",
+				column, path, placeholders, len(distinct))
+		} else {
+			sql = fmt.Sprintf("EXISTS (SELECT 1 FROM json_each(%s, '$.%s') AS je WHERE je.value IN (%s))", column, path, placeholders)
+		}
+	}
+	if not {
+		sql = "NOT " + sql
+	}
+	return sql, params, nil
+}
+
+// generateExistsCondition generates SQL for exists, which matches a JSON path that is set, even
+// to null. A false value matches a path that is not set.
+func (g *SQLGenerator) generateExistsCondition(field string, value interface{}, not bool) (string, []interface{}, error) {
+	column, path, err := g.jsonPathField("exists", field)
+	if err != nil {
+		return "", nil, err
+	}
+	if value != nil {
+		exists, ok := value.(bool)
+		if !ok {
+			return "", nil, fmt.Errorf("'exists' operator requires a boolean value")
+		}
+		if !exists {
+			not = !not
+		}
+	}
+
+	var sql string
+	switch g.dbType {
+	case "postgres":
+		sql = fmt.Sprintf("%s #> '%s' IS NOT NULL", column, postgresJSONPath(path))
+	case "mysql":
+		sql = fmt.Sprintf("JSON_CONTAINS_PATH(%s, 'one', '$.%s') = 1", column, path)
+	default:
+		sql = fmt.Sprintf("JSON_TYPE(%s, '$.%s') IS NOT NULL", column, path)
+	}
+	if not {
+		sql = "NOT (" + sql + ")"
+	}
+	return sql, nil, nil
+}
+
+// jsonPathField splits a field such as data.tags, r.data.tags or data->>'tags' into its quoted
+// JSON column and path, for the operators that need a JSON path rather than its value.
+func (g *SQLGenerator) jsonPathField(op, field string) (string, string, error) {
+	field = strings.TrimSpace(field)
+	var column, path string
+	if base, rest, ok := strings.Cut(field, "->"); ok {
+		column = strings.TrimSpace(base)
+		path = strings.Trim(strings.TrimPrefix(strings.TrimSpace(rest), ">"), "'\"")
+	} else {
+		parts := strings.Split(field, ".")
+		switch {
+		case len(parts) >= 2 && isJSONColumnCandidate(parts[0]):
+			column, path = parts[0], strings.Join(parts[1:], ".")
+		case len(parts) >= 3 && isJSONColumnCandidate(parts[1]):
+			column, path = parts[0]+"."+parts[1], strings.Join(parts[2:], ".")
+		default:
+			return "", "", fmt.Errorf("'%s' operator requires a JSON path field such as data.tags", op)
+		}
+	}
+	if err := ValidateIdentifier(column); err != nil {
+		return "", "", err
+	}
+	if err := ValidateJSONPath(path); err != nil {
+		return "", "", err
+	}
+	return g.quoteQualifiedIdentifier(column), path, nil
+}
+
+// postgresJSONPath returns a validated JSON path as a PostgreSQL text array, such as {a,b} for a.b.
+func postgresJSONPath(path string) string {
+	return "{" + strings.ReplaceAll(path, ".", ",") + "}"
+}
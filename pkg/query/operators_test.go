@@ -0,0 +1,144 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+)
+
+func TestSQLGenerator_Operators(t *testing.T) {
+	tests := []struct {
+		name   string
+		dbType string
+		cond   Condition
+		sql    string
+		params []interface{}
+	}{
+		{"not_in", "sqlite", Condition{Field: "name", Op: "not_in", Value: []interface{}{"a", "b"}},
+			`"name" NOT IN (?, ?)`, []interface{}{"a", "b"}},
+		{"ilike postgres", "postgres", Condition{Field: "data.name", Op: "ilike", Value: "ali"},
+			`"data"->>'name' ILIKE ?`, []interface{}{"%ali%"}},
+		{"ilike sqlite", "sqlite", Condition{Field: "data.name", Op: "ilike", Value: "ali%"},
+			`LOWER(JSON_EXTRACT("data", '$.name')) LIKE LOWER(?)`, []interface{}{"ali%"}},
+		{"starts_with sqlite", "sqlite", Condition{Field: "name", Op: "starts_with", Value: "a*b"},
+			`"name" GLOB ?`, []interface{}{"a[*]b*"}},
+		{"ends_with postgres", "postgres", Condition{Field: "name", Op: "ends_with", Value: "100%"},
+			`"name" LIKE ? ESCAPE '!'`, []interface{}{"%100!%"}},
+		{"contains mysql", "mysql", Condition{Field: "data.name", Op: "contains", Value: "a_b"},
+			"CAST(JSON_UNQUOTE(JSON_EXTRACT(`data`, '$.name')) AS BINARY) LIKE ? ESCAPE '!'", []interface{}{"%a!_b%"}},
+		{"not contains sqlite", "sqlite", Condition{Field: "name", Op: "contains", Value: "x", Not: true},
+			`NOT "name" GLOB ?`, []interface{}{"*x*"}},
+		{"regex sqlite", "sqlite", Condition{Field: "name", Op: "regex", Value: "^a.c$"},
+			`"name" REGEXP ?`, []interface{}{"^a.c$"}},
+		{"regex postgres", "postgres", Condition{Field: "name", Op: "regex", Value: "^a"},
+			`"name" ~ ?`, []interface{}{"^a"}},
+		{"regex mysql", "mysql", Condition{Field: "name", Op: "regex", Value: "^a"},
+			"REGEXP_LIKE(`name`, ?, 'c')", []interface{}{"^a"}},
+		{"has_any sqlite", "sqlite", Condition{Field: "data.tags", Op: "has_any", Value: []interface{}{"a", "b", "a"}},
+			`EXISTS (SELECT 1 FROM json_each("data", '$.tags') AS je WHERE je.value IN (?, ?))`, []interface{}{"a", "b"}},
+		{"has_all sqlite", "sqlite", Condition{Field: "r.data.tags", Op: "has_all", Value: []interface{}{"a", "b"}},
+			`(SELECT COUNT(DISTINCT je.value) FROM json_each("r"."data", '$.tags') AS je WHERE je.value IN (?, ?)) = 2`, []interface{}{"a", "b"}},
+		{"has_all postgres", "postgres", Condition{Field: "data.meta.tags", Op: "has_all", Value: []interface{}{"a", 1.0}},
+			`CAST("data" #> '{meta,tags}' AS jsonb) @> CAST(? AS jsonb)`, []interface{}{`["a",1]`}},
+		{"has_any postgres", "postgres", Condition{Field: "data.tags", Op: "has_any", Value: []interface{}{"a", "b"}},
+			`(CAST("data" #> '{tags}' AS jsonb) @> CAST(? AS jsonb) OR CAST("data" #> '{tags}' AS jsonb) @> CAST(? AS jsonb))`, []interface{}{`["a"]`, `["b"]`}},
+		{"has_any mysql", "mysql", Condition{Field: "data.tags", Op: "has_any", Value: []interface{}{"a"}},
+			"(JSON_CONTAINS(JSON_EXTRACT(`data`, '$.tags'), CAST(? AS JSON)))", []interface{}{`["a"]`}},
+		{"exists sqlite", "sqlite", Condition{Field: "data.meta.color", Op: "exists"},
+			`JSON_TYPE("data", '$.meta.color') IS NOT NULL`, nil},
+		{"exists false postgres", "postgres", Condition{Field: "data->>'color'", Op: "exists", Value: false},
+			`NOT ("data" #> '{color}' IS NOT NULL)`, nil},
+		{"exists mysql", "mysql", Condition{Field: "data.color", Op: "exists", Value: true},
+			"JSON_CONTAINS_PATH(`data`, 'one', '$.color') = 1", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewSQLGeneratorWithDBType(tt.dbType)
+			sql, params, err := g.generateCondition(tt.cond)
+			require.NoError(t, err)
+			assert.Equal(t, tt.sql, sql)
+			assert.Equal(t, tt.params, params)
+		})
+	}
+}
+
+func TestSQLGenerator_OperatorErrors(t *testing.T) {
+	g := NewSQLGeneratorWithDBType("sqlite")
+	for _, cond := range []Condition{
+		{Field: "name", Op: "not_in", Value: []interface{}{}},
+		{Field: "name", Op: "contains", Value: 1},
+		{Field: "name", Op: "regex", Value: "(unclosed"},
+		{Field: "name", Op: "has_any", Value: []interface{}{"a"}},
+		{Field: "data.tags", Op: "has_all", Value: "a"},
+		{Field: "data.tags", Op: "has_any", Value: []interface{}{map[string]interface{}{"a": 1}}},
+		{Field: "data.tags", Op: "exists", Value: "yes"},
+		{Field: "data.tags'", Op: "exists"},
+	} {
+		_, _, err := g.generateCondition(cond)
+		assert.Error(t, err, "%s %v", cond.Op, cond.Value)
+	}
+}
+
+func TestParser_SimplifiedFilterOperators(t *testing.T) {
+	p := NewParser()
+	where, err := p.parseSimplifiedFilter(map[string]interface{}{
+		"data.tags": map[string]interface{}{"has_any": []interface{}{"red"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, where.And, 1)
+	assert.Equal(t, Condition{Field: "data.tags", Op: "has_any", Value: []interface{}{"red"}}, where.And[0])
+
+	_, err = p.parseSimplifiedFilter(map[string]interface{}{"name": map[string]interface{}{"matches": "x"}})
+	assert.Error(t, err)
+}
+
+func TestExecutor_Operators(t *testing.T) {
+	db := setupQueryTestDB(t)
+	_, tbl := createTestData(t, db)
+	for _, data := range []string{
+		`{"name":"Alice","tags":["red","blue"],"meta":{"color":null}}`,
+		`{"name":"alicia_b","tags":["blue"]}`,
+		`{"name":"Bob*","tags":["green","red"],"meta":{}}`,
+	} {
+		require.NoError(t, db.Create(&models.Record{TableID: tbl.ID, Data: models.JSONField(data)}).Error)
+	}
+	executor := NewExecutor(db)
+
+	names := func(cond Condition) []string {
+		t.Helper()
+		result, err := executor.Execute(context.Background(), &QueryRequest{
+			From:    "records",
+			Select:  []string{"data.name"},
+			Where:   &WhereClause{And: []Condition{cond}},
+			OrderBy: []OrderByClause{{Field: "data.name"}},
+			Page:    1,
+			Size:    20,
+		}, "user1")
+		require.NoError(t, err, "%s %v", cond.Op, cond.Value)
+		var names []string
+		for _, row := range result.Data {
+			for _, value := range row {
+				names = append(names, value.(string))
+			}
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"Alice", "alicia_b"}, names(Condition{Field: "data.name", Op: "ilike", Value: "ALI"}))
+	assert.Equal(t, []string{"Alice"}, names(Condition{Field: "data.name", Op: "starts_with", Value: "Ali"}))
+	assert.Equal(t, []string{"Bob*"}, names(Condition{Field: "data.name", Op: "ends_with", Value: "*"}))
+	assert.Equal(t, []string{"alicia_b"}, names(Condition{Field: "data.name", Op: "contains", Value: "_"}))
+	assert.Equal(t, []string{"Alice", "Bob*"}, names(Condition{Field: "data.name", Op: "regex", Value: "^[A-Z]"}))
+	assert.Equal(t, []string{"Bob*"}, names(Condition{Field: "data.name", Op: "not_in", Value: []interface{}{"Alice", "alicia_b"}}))
+	assert.Equal(t, []string{"Alice", "Bob*"}, names(Condition{Field: "data.tags", Op: "has_any", Value: []interface{}{"red"}}))
+	assert.Equal(t, []string{"Alice"}, names(Condition{Field: "data.tags", Op: "has_all", Value: []interface{}{"red", "blue"}}))
+	assert.Equal(t, []string{"alicia_b"}, names(Condition{Field: "data.tags", Op: "has_any", Value: []interface{}{"red"}, Not: true}))
+	assert.Equal(t, []string{"Alice", "Bob*"}, names(Condition{Field: "data.meta", Op: "exists"}))
+	assert.Equal(t, []string{"Alice"}, names(Condition{Field: "data.meta.color", Op: "exists"}))
+	assert.Equal(t, []string{"Bob*", "alicia_b"}, names(Condition{Field: "data.meta.color", Op: "exists", Value: false}))
+}
@@ -107,7 +107,7 @@ func (p *Parser) normalize(req *QueryRequest) error {
 		}
 	}
 
-	return nil
+	return normalizeSubqueries(req, p.normalize)
 }
 
 // parseSimplifiedFilter parses simplified filter syntax.
@@ -133,22 +133,12 @@ func (p *Parser) parseFilterField(field string, value interface{}) (Condition, e
 	if obj, ok := value.(map[string]interface{}); ok {
 		// Support {"field": {"op": "value"}} or {"field": {"in": ["a", "b"]}}
 		for op, val := range obj {
-			switch op {
-			case "eq", "ne", "gt", "gte", "lt", "lte", "like", "in", "between", "is_null":
+			if isOperator(op) {
 				return Condition{
 					Field: field,
 					Op:    op,
 					Value: val,
 				}, nil
-			default:
-				// Possibly shorthand {"status": {"in": ["a", "b"]}}
-				if isOperator(op) {
-					return Condition{
-						Field: field,
-						Op:    op,
-						Value: val,
-					}, nil
-				}
 			}
 		}
 
@@ -231,6 +221,9 @@ func (p *Parser) validate(req *QueryRequest) error {
 			return err
 		}
 	}
+	if hasSubquery(req.Having) || hasSubquery(req.Qualify) {
+		return errors.New("subqueries are only supported in where")
+	}
 
 	// Validate UNION queries
 	for i, unionReq := range req.Union {
@@ -254,6 +247,10 @@ func (p *Parser) validate(req *QueryRequest) error {
 		}
 	}
 
+	if err := p.validateWindows(req); err != nil {
+		return err
+	}
+
 	// Validate JOIN type and ON condition structure
 	for i, join := range req.Join {
 		if !isValidJoinType(join.Type) {
@@ -290,7 +287,7 @@ func (p *Parser) validate(req *QueryRequest) error {
 		if field == "*" {
 			continue
 		}
-		if err := validateFieldExpression(field); err != nil {
+		if err := validateSelectExpression(field); err != nil {
 			return fmt.Errorf("select[%d] %w", i, err)
 		}
 	}
@@ -311,6 +308,9 @@ func (p *Parser) validate(req *QueryRequest) error {
 		if err := validateFieldExpression(agg.Field); err != nil {
 			return fmt.Errorf("aggregate[%d].field %w", i, err)
 		}
+		if err := checkAggregateExpression(req, agg); err != nil {
+			return err
+		}
 		if err := ValidateIdentifier(agg.As); err != nil {
 			return fmt.Errorf("aggregate[%d].as %w", i, err)
 		}
@@ -320,7 +320,7 @@ func (p *Parser) validate(req *QueryRequest) error {
 			if field == "*" {
 				continue
 			}
-			if err := validateFieldExpression(field); err != nil {
+			if err := validateSelectExpression(field); err != nil {
 				return fmt.Errorf("join[%s].select[%d] %w", join.Table, i, err)
 			}
 		}
@@ -344,13 +344,39 @@ func (p *Parser) validate(req *QueryRequest) error {
 	return nil
 }
 
-// validateFieldExpression validates field names like `id`, `tables.id`, `data.status`, or `data->>name`.
+// validateSelectExpression validates a selected field, a date function with an optional alias, or
+// a computed expression with an alias.
+func validateSelectExpression(field string) error {
+	expr, alias := splitSelectAlias(field)
+	if alias != "" {
+		if err := ValidateIdentifier(alias); err != nil {
+			return fmt.Errorf("alias %w", err)
+		}
+	}
+	if _, ok, err := parseExpression(expr); ok && err == nil && alias == "" {
+		return errors.New("selected expression requires an alias (as)")
+	}
+	return validateFieldExpression(expr)
+}
+
+// validateFieldExpression validates field names like `id`, `tables.id`, `data.status`, or `data->>name`,
+// date functions of them like `date_trunc(month, data.ordered_at)`, and computed expressions like
+// `{data.price} * {data.qty}`.
 // Rejects nested `->`, and names containing `[`, `*`, `'`, `"`, or spaces.
 func validateFieldExpression(field string) error {
 	field = strings.TrimSpace(field)
 	if field == "" {
 		return errors.New("field name cannot be empty")
 	}
+	if _, ok, err := parseDateFunction(field); ok {
+		return err
+	}
+	if expr, ok, err := parseExpression(field); ok {
+		if err != nil {
+			return err
+		}
+		return validateExpression(expr)
+	}
 	// Postgres JSON arrow syntax `data->>key` or `data->key`: split and validate
 	if strings.Contains(field, "->") {
 		// Disallow nested `->`, e.g. `data->>a->>b`; delegate to sql_generator JSON path expression instead
@@ -394,7 +420,8 @@ func validateConditionFieldName(cond Condition) error {
 	// may have an empty Field because they are purely for nesting; leaf nodes must have
 	// a valid field, otherwise SQL generation will fail with an empty field and could
 	// become a validation bypass vector.
-	isGroup := len(cond.And) > 0 || len(cond.Or) > 0
+	// Conditions on a subquery are checked by validateSubquery; exists has no field.
+	isGroup := len(cond.And) > 0 || len(cond.Or) > 0 || conditionSubquery(cond) != nil
 	if !isGroup {
 		if err := validateFieldExpression(cond.Field); err != nil {
 			return fmt.Errorf("where %w", err)
@@ -404,6 +431,14 @@ func validateConditionFieldName(cond Condition) error {
 			return fmt.Errorf("where %w", err)
 		}
 	}
+	if cond.Ref != "" {
+		if _, ok, _ := parseDateFunction(cond.Ref); ok {
+			return fmt.Errorf("where ref '%s' must be a field", cond.Ref)
+		}
+		if err := validateFieldExpression(cond.Ref); err != nil {
+			return fmt.Errorf("where ref %w", err)
+		}
+	}
 	for _, nested := range cond.And {
 		if err := validateConditionFieldName(nested); err != nil {
 			return err
@@ -449,6 +484,27 @@ func (p *Parser) validateConditionDepth(cond Condition, depth int) error {
 		return fmt.Errorf("invalid operator: %s", cond.Op)
 	}
 
+	// Validate subqueries, whose conditions count towards the depth, and refs to their enclosing query
+	if sub := conditionSubquery(cond); sub != nil {
+		if err := p.validateSubquery(cond, sub, depth); err != nil {
+			return err
+		}
+	} else if cond.Op == "not_exists" {
+		return errors.New("'not_exists' operator requires a subquery value")
+	}
+	if cond.Ref != "" {
+		op := cond.Op
+		if op == "" {
+			op = "eq"
+		}
+		if _, ok := refOperators[op]; !ok {
+			return fmt.Errorf("'%s' operator cannot compare with a ref", op)
+		}
+		if cond.Value != nil {
+			return errors.New("condition cannot have both a value and a ref")
+		}
+	}
+
 	// Recursively validate nested conditions
 	for _, nested := range cond.And {
 		if err := p.validateConditionDepth(nested, depth+1); err != nil {
@@ -472,7 +528,10 @@ func isOperator(s string) bool {
 
 // isValidOperator checks whether an operator is valid.
 func isValidOperator(op string) bool {
-	validOps := []string{"eq", "ne", "gt", "gte", "lt", "lte", "like", "in", "between", "is_null"}
+	validOps := []string{
+		"eq", "ne", "gt", "gte", "lt", "lte", "like", "in", "not_in", "between", "is_null", "search",
+		"ilike", "starts_with", "ends_with", "contains", "regex", "has_any", "has_all", "exists", "not_exists",
+	}
 	for _, valid := range validOps {
 		if op == valid {
 			return true
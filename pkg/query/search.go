@@ -0,0 +1,133 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// MaxSearchTerms bounds the terms of a full-text search, each of which adds a branch to the
+// search query.
+const MaxSearchTerms = 8
+
+// SearchDocumentsTable holds the text of the string and text fields of records, one row per
+// record field, indexed for full-text search by every database: through the FTS5 table
+// SearchFTSTable in SQLite, a GIN index on its tsvector in PostgreSQL and a FULLTEXT index in
+// MySQL.
+const (
+	SearchDocumentsTable = "record_search_documents"
+	SearchFTSTable       = "record_search_fts"
+)
+
+// SearchTerms splits a search text into the terms a full-text search matches: the runs of
+// letters and digits, lower-cased, without duplicates.
+func SearchTerms(text string) []string {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, term := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// SearchSQL returns a query of the records matching every search term, selecting record_id and
+// a relevance score, higher for better matches. A term matches a record when one of its
+// documents contains it; restrict is an extra condition on the documents, aliased d, with its
+// params. The terms must come from SearchTerms.
+func SearchSQL(dbType string, terms []string, restrict string, restrictParams []interface{}) (string, []interface{}, error) {
+	if len(terms) == 0 {
+		return "", nil, fmt.Errorf("search requires at least one term")
+	}
+	if len(terms) > MaxSearchTerms {
+		return "", nil, fmt.Errorf("search accepts at most %d terms", MaxSearchTerms)
+	}
+	if restrict != "" {
+		restrict = " AND " + restrict
+	}
+
+	var b strings.Builder
+	var params []interface{}
+	b.WriteString("SELECT record_id, SUM(score) AS score FROM (")
+	for i, term := range terms {
+		if i > 0 {
+			b.WriteString(" UNION ALL ")
+		}
+		// Terms are letters and digits only; quoting keeps them literal in every match syntax.
+		quoted := `"` + term + `"`
+		switch dbType {
+		case "postgres":
+			b.WriteString(fmt.Sprintf("SELECT d.record_id, %d AS term, MAX(ts_rank(to_tsvector('simple', d.content), plainto_tsquery('simple', ?))) AS score "+
+				"FROM %s d WHERE to_tsvector('simple', d.content) @@ plainto_tsquery('simple', ?)%s GROUP BY d.record_id", i, SearchDocumentsTable, restrict))
+			params = append(params, term, term)
+		case "mysql":
+			b.WriteString(fmt.Sprintf("SELECT d.record_id, %d AS term, MAX(MATCH(d.content) AGAINST (? IN BOOLEAN MODE)) AS score "+
+				"FROM %s d WHERE MATCH(d.content) AGAINST (? IN BOOLEAN MODE)%s GROUP BY d.record_id", i, SearchDocumentsTable, restrict))
+			params = append(params, quoted, quoted)
+		default:
+			// bm25 is negative, lower for better matches, and only available in a full-text query;
+			// LIMIT -1 keeps SQLite from flattening that query into the aggregate around it.
+			b.WriteString(fmt.Sprintf("SELECT record_id, %d AS term, MAX(score) AS score FROM ("+
+				"SELECT d.record_id, -bm25(%s) AS score FROM %s JOIN %s d ON d.id = %s.rowid WHERE %s MATCH ?%s LIMIT -1"+
+				") ranked GROUP BY record_id", i, SearchFTSTable, SearchFTSTable, SearchDocumentsTable, SearchFTSTable, SearchFTSTable, restrict))
+			params = append(params, quoted)
+		}
+		params = append(params, restrictParams...)
+	}
+	b.WriteString(") search_terms GROUP BY record_id HAVING COUNT(*) = ?")
+	params = append(params, len(terms))
+	return b.String(), params, nil
+}
+
+// generateSearchCondition matches the records whose indexed text contains every term of the
+// condition value: `data` searches all string and text fields of a record, `data.<name>` one
+// field.
+func (g *SQLGenerator) generateSearchCondition(cond Condition) (string, []interface{}, error) {
+	text, ok := cond.Value.(string)
+	if !ok {
+		return "", nil, fmt.Errorf("'search' operator requires a string value")
+	}
+	terms := SearchTerms(text)
+	if len(terms) == 0 {
+		return "", nil, fmt.Errorf("'search' operator requires at least one word")
+	}
+
+	field := strings.TrimSpace(cond.Field)
+	var qualifier, key string
+	switch {
+	case field == "data":
+	case strings.HasSuffix(field, ".data") && strings.Count(field, ".") == 1:
+		qualifier = strings.TrimSuffix(field, ".data")
+	default:
+		if qualifier, key, ok = recordDataKey(field); !ok {
+			return "", nil, fmt.Errorf("'search' operator applies to record data or a record data field, got '%s'", field)
+		}
+	}
+	idColumn := "id"
+	if qualifier != "" {
+		if err := ValidateIdentifier(qualifier); err != nil {
+			return "", nil, err
+		}
+		idColumn = qualifier + ".id"
+	}
+
+	var restrict string
+	var restrictParams []interface{}
+	if key != "" {
+		restrict = "d.field_id IN (SELECT id FROM fields WHERE name = ? AND deleted_at IS NULL)"
+		restrictParams = []interface{}{key}
+	}
+	sql, params, err := SearchSQL(g.dbType, terms, restrict, restrictParams)
+	if err != nil {
+		return "", nil, err
+	}
+	notPrefix := ""
+	if cond.Not {
+		notPrefix = "NOT "
+	}
+	return notPrefix + g.quoteQualifiedIdentifier(idColumn) + " IN (SELECT record_id FROM (" + sql + ") search_matches)", params, nil
+}
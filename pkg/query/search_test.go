@@ -0,0 +1,90 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+)
+
+func TestSearchTerms(t *testing.T) {
+	assert.Equal(t, []string{"refund", "order", "42", "café"}, SearchTerms("Refund: order #42, refund!  CAFÉ"))
+	assert.Nil(t, SearchTerms(" ?! "))
+}
+
+func TestSQLGenerator_SearchCondition(t *testing.T) {
+	g := NewSQLGenerator(true)
+
+	sql, params, err := g.generateCondition(Condition{Field: "r.data.notes", Op: "search", Value: "late refund", Not: true})
+	require.NoError(t, err)
+	assert.Contains(t, sql, `NOT "r"."id" IN (SELECT record_id FROM (`)
+	assert.Contains(t, sql, "record_search_fts MATCH ? AND d.field_id IN (SELECT id FROM fields WHERE name = ? AND deleted_at IS NULL)")
+	assert.Equal(t, []interface{}{`"late"`, "notes", `"refund"`, "notes", 2}, params)
+
+	g.dbType = "postgres"
+	sql, params, err = g.generateCondition(Condition{Field: "data", Op: "search", Value: "refund"})
+	require.NoError(t, err)
+	assert.Contains(t, sql, "to_tsvector('simple', d.content) @@ plainto_tsquery('simple', ?)")
+	assert.Equal(t, []interface{}{"refund", "refund", 1}, params)
+
+	for _, cond := range []Condition{
+		{Field: "data", Op: "search", Value: 1},
+		{Field: "data", Op: "search", Value: "?!"},
+		{Field: "name", Op: "search", Value: "refund"},
+	} {
+		_, _, err := g.generateCondition(cond)
+		assert.Error(t, err, cond)
+	}
+}
+
+func TestExecutor_SearchOperator(t *testing.T) {
+	db := setupQueryTestDB(t)
+	_, table := createTestData(t, db)
+	notes := &models.Field{TableID: table.ID, Name: "notes", Type: "text"}
+	require.NoError(t, db.Create(notes).Error)
+	title := &models.Field{TableID: table.ID, Name: "title", Type: "string"}
+	require.NoError(t, db.Create(title).Error)
+
+	notesByID := make(map[string]string)
+	for _, doc := range []struct{ title, notes string }{
+		{"Refund", "Order arrived late"},
+		{"Question", "Asks about a refund"},
+		{"Question", "Order arrived"},
+	} {
+		record := &models.Record{TableID: table.ID, Data: models.JSONField(`{"title":"` + doc.title + `","notes":"` + doc.notes + `"}`)}
+		require.NoError(t, db.Create(record).Error)
+		notesByID[record.ID] = doc.notes
+		require.NoError(t, db.Create(&[]models.RecordSearchDocument{
+			{TableID: table.ID, RecordID: record.ID, FieldID: title.ID, Content: doc.title},
+			{TableID: table.ID, RecordID: record.ID, FieldID: notes.ID, Content: doc.notes},
+		}).Error)
+	}
+
+	search := func(where Condition) []string {
+		t.Helper()
+		result, err := NewExecutor(db).Execute(context.Background(), &QueryRequest{
+			From:   "records",
+			Select: []string{"id"},
+			Where:  &WhereClause{And: []Condition{where}},
+			Page:   1,
+			Size:   20,
+		}, "user1")
+		require.NoError(t, err)
+		notes := make([]string, len(result.Data))
+		for i, row := range result.Data {
+			notes[i] = notesByID[fmt.Sprint(row["id"])]
+		}
+		sort.Strings(notes)
+		return notes
+	}
+
+	assert.Equal(t, []string{"Asks about a refund", "Order arrived late"}, search(Condition{Field: "data", Op: "search", Value: "REFUND"}))
+	assert.Equal(t, []string{"Order arrived late"}, search(Condition{Field: "data", Op: "search", Value: "refund late"}))
+	assert.Equal(t, []string{"Asks about a refund"}, search(Condition{Field: "data.notes", Op: "search", Value: "refund"}))
+	assert.Equal(t, []string{"Order arrived"}, search(Condition{Field: "data", Op: "search", Value: "refund", Not: true}))
+}
@@ -9,6 +9,11 @@ import (
 type SQLGenerator struct {
 	dbType  string // "sqlite", "postgres", "mysql"
 	maxRows int64
+
+	formulas         []FormulaTable  // formula fields computed in SQL, see WithFormulas
+	recordsQualifier string          // records table or alias of the query being generated
+	subquery         *subqueryScope  // subquery being generated, see subquery.go
+	aggregates       []AggregateFunc // aggregates of the query being generated, see expression.go
 }
 
 // NewSQLGenerator creates a SQL generator (legacy compat, takes isSQLite bool).
@@ -81,10 +86,14 @@ func (g *SQLGenerator) combineQueries(req *QueryRequest, mainQuery *SQLQuery) (*
 	}
 
 	if len(req.OrderBy) > 0 || req.Size > 0 {
-		orderByClause, err := g.generateOrderBy(req)
+		// The combined result has no records table to compute formula fields against.
+		outer := *g
+		outer.formulas = nil
+		orderByClause, orderParams, err := outer.generateOrderBy(req)
 		if err != nil {
 			return nil, err
 		}
+		allParams = append(allParams, orderParams...)
 		limitClause, limitParams := g.generateLimit(req)
 		allParams = append(allParams, limitParams...)
 
@@ -100,15 +109,17 @@ func (g *SQLGenerator) combineQueries(req *QueryRequest, mainQuery *SQLQuery) (*
 
 // generateSingleQuery generates a single query (without UNION).
 func (g *SQLGenerator) generateSingleQuery(req *QueryRequest) (*SQLQuery, error) {
+	g = g.forQuery(req)
 	query := &SQLQuery{
 		Params: make([]interface{}, 0),
 	}
 
 	// 1. Generate SELECT clause
-	selectClause, err := g.generateSelect(req)
+	selectClause, selectParams, err := g.generateSelect(req)
 	if err != nil {
 		return nil, err
 	}
+	query.Params = append(query.Params, selectParams...)
 
 	// 2. Generate FROM clause
 	fromClause := g.generateFrom(req)
@@ -127,10 +138,11 @@ func (g *SQLGenerator) generateSingleQuery(req *QueryRequest) (*SQLQuery, error)
 	query.Params = append(query.Params, whereParams...)
 
 	// 5. Generate GROUP BY clause
-	groupByClause, err := g.generateGroupBy(req)
+	groupByClause, groupByParams, err := g.generateGroupBy(req)
 	if err != nil {
 		return nil, err
 	}
+	query.Params = append(query.Params, groupByParams...)
 
 	// 6. Generate HAVING clause
 	havingClause, havingParams, err := g.generateWhere(req.Having)
@@ -139,11 +151,32 @@ func (g *SQLGenerator) generateSingleQuery(req *QueryRequest) (*SQLQuery, error)
 	}
 	query.Params = append(query.Params, havingParams...)
 
+	if req.Qualify != nil {
+		sql := selectClause + fromClause + joinClause
+		if whereClause != "" {
+			sql += " WHERE " + whereClause
+		}
+		if groupByClause != "" {
+			sql += " GROUP BY " + groupByClause
+		}
+		if havingClause != "" {
+			sql += " HAVING " + havingClause
+		}
+		qualified, qualifiedParams, err := g.generateQualified(req, sql)
+		if err != nil {
+			return nil, err
+		}
+		query.SQL = qualified
+		query.Params = append(query.Params, qualifiedParams...)
+		return query, nil
+	}
+
 	// 7. Generate ORDER BY clause
-	orderByClause, err := g.generateOrderBy(req)
+	orderByClause, orderByParams, err := g.generateOrderBy(req)
 	if err != nil {
 		return nil, err
 	}
+	query.Params = append(query.Params, orderByParams...)
 
 	// 8. Generate pagination clause
 	limitClause, limitParams := g.generateLimit(req)
@@ -202,13 +235,14 @@ func (g *SQLGenerator) canUseDirectCount(req *QueryRequest) bool {
 	if len(req.Union) > 0 || len(req.Intersect) > 0 {
 		return false
 	}
-	if len(req.GroupBy) > 0 || len(req.Aggregate) > 0 || req.Having != nil {
+	if len(req.GroupBy) > 0 || len(req.Aggregate) > 0 || req.Having != nil || req.Qualify != nil {
 		return false
 	}
 	return true
 }
 
 func (g *SQLGenerator) generateDirectCount(req *QueryRequest) (*SQLQuery, error) {
+	g = g.forQuery(req)
 	query := &SQLQuery{
 		Params: make([]interface{}, 0),
 	}
@@ -234,8 +268,9 @@ func (g *SQLGenerator) generateDirectCount(req *QueryRequest) (*SQLQuery, error)
 }
 
 // generateSelect generates the SELECT clause.
-func (g *SQLGenerator) generateSelect(req *QueryRequest) (string, error) {
+func (g *SQLGenerator) generateSelect(req *QueryRequest) (string, []interface{}, error) {
 	var fields []string
+	var params []interface{}
 
 	// Handle aggregate queries
 	if len(req.Aggregate) > 0 {
@@ -243,29 +278,32 @@ func (g *SQLGenerator) generateSelect(req *QueryRequest) (string, error) {
 
 		// Add regular fields
 		for _, f := range req.Select {
-			expr, err := g.generateFieldExpression(f)
+			expr, exprParams, err := g.selectExpression(f)
 			if err != nil {
-				return "", err
+				return "", nil, err
 			}
 			fields = append(fields, expr)
+			params = append(params, exprParams...)
 		}
 
 		// Add aggregate functions
 		for _, agg := range req.Aggregate {
-			aggSQL, err := g.generateAggregate(agg)
+			aggSQL, aggParams, err := g.generateAggregate(agg)
 			if err != nil {
-				return "", err
+				return "", nil, err
 			}
 			fields = append(fields, aggSQL)
+			params = append(params, aggParams...)
 		}
 	} else {
 		fields = make([]string, 0, len(req.Select))
 		for _, f := range req.Select {
-			expr, err := g.generateFieldExpression(f)
+			expr, exprParams, err := g.selectExpression(f)
 			if err != nil {
-				return "", err
+				return "", nil, err
 			}
 			fields = append(fields, expr)
+			params = append(params, exprParams...)
 		}
 	}
 
@@ -273,87 +311,177 @@ func (g *SQLGenerator) generateSelect(req *QueryRequest) (string, error) {
 		fields = []string{"*"}
 	}
 
-	return "SELECT " + strings.Join(fields, ", "), nil
+	for _, w := range req.Window {
+		windowSQL, windowParams, err := g.generateWindow(req, w)
+		if err != nil {
+			return "", nil, err
+		}
+		fields = append(fields, windowSQL)
+		params = append(params, windowParams...)
+	}
+
+	// Sort keys of a qualified query, which sorts outside of it.
+	if req.Qualify != nil {
+		for i, order := range req.OrderBy {
+			if req.isOutputAlias(order.Field) {
+				continue
+			}
+			expr, exprParams, err := g.fieldExpression(order.Field)
+			if err != nil {
+				return "", nil, err
+			}
+			fields = append(fields, expr+" AS "+g.quoteIdentifier(fmt.Sprintf("%s%d", windowOrderColumnPrefix, i)))
+			params = append(params, exprParams...)
+		}
+	}
+
+	// Sort keys of a cursor page. SQLite compares timestamps as the text it stores; the no-op
+	// unary plus keeps the driver from parsing that text into a time.Time.
+	for i, f := range req.cursorKeys {
+		expr, exprParams, err := g.fieldExpression(f)
+		if err != nil {
+			return "", nil, err
+		}
+		if g.dbType == "sqlite" {
+			expr = "+" + expr
+		}
+		fields = append(fields, expr+" AS "+g.quoteIdentifier(fmt.Sprintf("%s%d", cursorColumnPrefix, i)))
+		params = append(params, exprParams...)
+	}
+
+	return "SELECT " + strings.Join(fields, ", "), params, nil
+}
+
+// selectExpression generates a selected field; computed formula fields keep their key as column name,
+// date functions their alias or function name, and computed expressions their alias.
+func (g *SQLGenerator) selectExpression(field string) (string, []interface{}, error) {
+	if sql, params, ok, err := g.selectedExpression(field); ok || err != nil {
+		return sql, params, err
+	}
+	if dateExpr, alias := splitSelectAlias(field); strings.HasSuffix(dateExpr, ")") {
+		call, ok, err := parseDateFunction(dateExpr)
+		if err != nil {
+			return "", nil, err
+		}
+		if ok {
+			if alias == "" {
+				alias = call.name
+			}
+			if err := ValidateIdentifier(alias); err != nil {
+				return "", nil, fmt.Errorf("select alias %w", err)
+			}
+			sql, params, err := g.dateFunctionExpression(call)
+			if err != nil {
+				return "", nil, err
+			}
+			return sql + " AS " + g.quoteIdentifier(alias), params, nil
+		}
+	}
+	expr, params, computed, err := g.formulaFieldExpression(field)
+	if err != nil {
+		return "", nil, err
+	}
+	if computed {
+		_, key, _ := recordDataKey(field)
+		return expr + " AS " + g.quoteIdentifier(key), params, nil
+	}
+	expr, err = g.generateFieldExpression(field)
+	return expr, nil, err
 }
 
 // generateAggregate generates aggregate function SQL.
-func (g *SQLGenerator) generateAggregate(agg AggregateFunc) (string, error) {
+func (g *SQLGenerator) generateAggregate(agg AggregateFunc) (string, []interface{}, error) {
 	funcName := strings.ToUpper(agg.Func)
 	field := agg.Field
 
 	if err := ValidateIdentifier(agg.As); err != nil {
-		return "", fmt.Errorf("aggregate.as %w", err)
+		return "", nil, fmt.Errorf("aggregate.as %w", err)
 	}
 
 	// Handle special aggregate functions
 	switch funcName {
 	case "COUNT_DISTINCT":
 		if field == "" || field == "*" {
-			return "", fmt.Errorf("count_distinct requires a field")
+			return "", nil, fmt.Errorf("count_distinct requires a field")
 		}
-		fieldExpr, err := g.generateFieldExpression(field)
+		fieldExpr, params, err := g.fieldExpression(field)
 		if err != nil {
-			return "", fmt.Errorf("aggregate.field %w", err)
+			return "", nil, fmt.Errorf("aggregate.field %w", err)
 		}
-		return fmt.Sprintf("COUNT(DISTINCT %s) AS %s", fieldExpr, g.quoteIdentifier(agg.As)), nil
+		return fmt.Sprintf("COUNT(DISTINCT %s) AS %s", fieldExpr, g.quoteIdentifier(agg.As)), params, nil
 
 	case "STDDEV", "STDDEV_POP", "STDDEV_SAMP":
 		if field == "" || field == "*" {
-			return "", fmt.Errorf("%s requires a numeric field", funcName)
+			return "", nil, fmt.Errorf("%s requires a numeric field", funcName)
 		}
-		fieldExpr, err := g.generateFieldExpression(field)
+		fieldExpr, params, err := g.fieldExpression(field)
 		if err != nil {
-			return "", fmt.Errorf("aggregate.field %w", err)
+			return "", nil, fmt.Errorf("aggregate.field %w", err)
 		}
 		if g.dbType == "sqlite" {
 			// SQLite lacks native STDDEV; compute via formula
 			// stddev = sqrt(avg(x^2) - avg(x)^2)
-			return fmt.Sprintf("SQRT(AVG(%s * %s) - AVG(%s) * AVG(%s)) AS %s", fieldExpr, fieldExpr, fieldExpr, fieldExpr, g.quoteIdentifier(agg.As)), nil
+			return fmt.Sprintf("SQRT(AVG(%s * %s) - AVG(%s) * AVG(%s)) AS %s", fieldExpr, fieldExpr, fieldExpr, fieldExpr, g.quoteIdentifier(agg.As)), repeatParams(params, 4), nil
 		}
 		// MySQL 8.0+ does not support STDDEV (Oracle compat alias); map to STDDEV_SAMP
 		mysqlFunc := funcName
 		if g.dbType == "mysql" && funcName == "STDDEV" {
 			mysqlFunc = "STDDEV_SAMP"
 		}
-		return fmt.Sprintf("%s(%s) AS %s", mysqlFunc, fieldExpr, g.quoteIdentifier(agg.As)), nil
+		return fmt.Sprintf("%s(%s) AS %s", mysqlFunc, fieldExpr, g.quoteIdentifier(agg.As)), params, nil
 
 	case "VARIANCE", "VAR_POP", "VAR_SAMP":
 		if field == "" || field == "*" {
-			return "", fmt.Errorf("%s requires a numeric field", funcName)
+			return "", nil, fmt.Errorf("%s requires a numeric field", funcName)
 		}
-		fieldExpr, err := g.generateFieldExpression(field)
+		fieldExpr, params, err := g.fieldExpression(field)
 		if err != nil {
-			return "", fmt.Errorf("aggregate.field %w", err)
+			return "", nil, fmt.Errorf("aggregate.field %w", err)
 		}
 		if g.dbType == "sqlite" {
 			// SQLite lacks native VARIANCE; compute via formula
 			// variance = avg(x^2) - avg(x)^2
-			return fmt.Sprintf("(AVG(%s * %s) - AVG(%s) * AVG(%s)) AS %s", fieldExpr, fieldExpr, fieldExpr, fieldExpr, g.quoteIdentifier(agg.As)), nil
+			return fmt.Sprintf("(AVG(%s * %s) - AVG(%s) * AVG(%s)) AS %s", fieldExpr, fieldExpr, fieldExpr, fieldExpr, g.quoteIdentifier(agg.As)), repeatParams(params, 4), nil
 		}
 		// MySQL 8.0+ does not support VARIANCE (Oracle compat alias); map to VAR_SAMP
 		mysqlFunc := funcName
 		if g.dbType == "mysql" && funcName == "VARIANCE" {
 			mysqlFunc = "VAR_SAMP"
 		}
-		return fmt.Sprintf("%s(%s) AS %s", mysqlFunc, fieldExpr, g.quoteIdentifier(agg.As)), nil
+		return fmt.Sprintf("%s(%s) AS %s", mysqlFunc, fieldExpr, g.quoteIdentifier(agg.As)), params, nil
 
 	default:
 		// Standard aggregate functions: COUNT, SUM, AVG, MIN, MAX
 		if field == "" || field == "*" {
-			return fmt.Sprintf("%s(*) AS %s", funcName, g.quoteIdentifier(agg.As)), nil
+			return fmt.Sprintf("%s(*) AS %s", funcName, g.quoteIdentifier(agg.As)), nil, nil
 		}
 
 		// Handle JSON field path
-		fieldExpr, err := g.generateFieldExpression(field)
+		fieldExpr, params, err := g.fieldExpression(field)
 		if err != nil {
-			return "", fmt.Errorf("aggregate.field %w", err)
+			return "", nil, fmt.Errorf("aggregate.field %w", err)
 		}
-		return fmt.Sprintf("%s(%s) AS %s", funcName, fieldExpr, g.quoteIdentifier(agg.As)), nil
+		return fmt.Sprintf("%s(%s) AS %s", funcName, fieldExpr, g.quoteIdentifier(agg.As)), params, nil
+	}
+}
+
+// repeatParams repeats the params of an expression that occurs n times in the SQL text.
+func repeatParams(params []interface{}, n int) []interface{} {
+	if len(params) == 0 {
+		return nil
+	}
+	repeated := make([]interface{}, 0, len(params)*n)
+	for i := 0; i < n; i++ {
+		repeated = append(repeated, params...)
 	}
+	return repeated
 }
 
 // generateFrom generates the FROM clause.
 func (g *SQLGenerator) generateFrom(req *QueryRequest) string {
+	if g.subquery != nil {
+		return " FROM " + g.quoteIdentifier(req.From) + " AS " + g.quoteIdentifier(g.subquery.alias)
+	}
 	return " FROM " + g.quoteIdentifier(req.From)
 }
 
@@ -408,6 +536,12 @@ func (g *SQLGenerator) generateJoins(req *QueryRequest) (string, error) {
 	return strings.Join(joins, ""), nil
 }
 
+// GenerateWhere generates the parameterized condition of where, without the WHERE keyword,
+// for callers that filter their own queries. It returns an empty string for an empty clause.
+func (g *SQLGenerator) GenerateWhere(where *WhereClause) (string, []interface{}, error) {
+	return g.generateWhere(where)
+}
+
 // generateWhere generates the WHERE clause.
 func (g *SQLGenerator) generateWhere(where *WhereClause) (string, []interface{}, error) {
 	if where == nil {
@@ -490,11 +624,22 @@ func (g *SQLGenerator) generateCondition(cond Condition) (string, []interface{},
 		return "(" + strings.Join(nestedConditions, " OR ") + ")", params, nil
 	}
 
+	if cond.Op == "search" {
+		return g.generateSearchCondition(cond)
+	}
+	if sub := conditionSubquery(cond); sub != nil {
+		return g.generateSubqueryCondition(cond, sub)
+	}
+	if cond.Op == "not_exists" {
+		return "", nil, fmt.Errorf("'not_exists' operator requires a subquery value")
+	}
+
 	// Handle field expression
-	fieldExpr, err := g.generateFieldExpression(cond.Field)
+	fieldExpr, fieldParams, err := g.fieldExpression(cond.Field)
 	if err != nil {
 		return "", nil, err
 	}
+	params = append(params, fieldParams...)
 
 	// Generate SQL based on operator
 	op := cond.Op
@@ -502,6 +647,14 @@ func (g *SQLGenerator) generateCondition(cond Condition) (string, []interface{},
 		op = "eq"
 	}
 
+	if cond.Ref != "" {
+		sql, refParams, err := g.generateRefCondition(op, fieldExpr, cond)
+		if err != nil {
+			return "", nil, err
+		}
+		return sql, append(params, refParams...), nil
+	}
+
 	// Handle NOT
 	notPrefix := ""
 	if cond.Not {
@@ -510,17 +663,17 @@ func (g *SQLGenerator) generateCondition(cond Condition) (string, []interface{},
 
 	switch op {
 	case "eq":
-		return notPrefix + fieldExpr + " = ?", []interface{}{cond.Value}, nil
+		return notPrefix + fieldExpr + " = ?", append(params, cond.Value), nil
 	case "ne":
-		return notPrefix + fieldExpr + " != ?", []interface{}{cond.Value}, nil
+		return notPrefix + fieldExpr + " != ?", append(params, cond.Value), nil
 	case "gt":
-		return notPrefix + fieldExpr + " > ?", []interface{}{cond.Value}, nil
+		return notPrefix + fieldExpr + " > ?", append(params, cond.Value), nil
 	case "gte":
-		return notPrefix + fieldExpr + " >= ?", []interface{}{cond.Value}, nil
+		return notPrefix + fieldExpr + " >= ?", append(params, cond.Value), nil
 	case "lt":
-		return notPrefix + fieldExpr + " < ?", []interface{}{cond.Value}, nil
+		return notPrefix + fieldExpr + " < ?", append(params, cond.Value), nil
 	case "lte":
-		return notPrefix + fieldExpr + " <= ?", []interface{}{cond.Value}, nil
+		return notPrefix + fieldExpr + " <= ?", append(params, cond.Value), nil
 	case "like":
 		value := cond.Value
 		if str, ok := value.(string); ok {
@@ -529,32 +682,46 @@ func (g *SQLGenerator) generateCondition(cond Condition) (string, []interface{},
 				value = "%" + str + "%"
 			}
 		}
-		return notPrefix + fieldExpr + " LIKE ?", []interface{}{value}, nil
-	case "in":
+		return notPrefix + fieldExpr + " LIKE ?", append(params, value), nil
+	case "in", "not_in":
 		values, ok := cond.Value.([]interface{})
 		if !ok {
-			return "", nil, fmt.Errorf("'in' operator requires an array value")
+			return "", nil, fmt.Errorf("'%s' operator requires an array value", op)
 		}
 		if len(values) == 0 {
-			return "", nil, fmt.Errorf("'in' operator array cannot be empty")
+			return "", nil, fmt.Errorf("'%s' operator array cannot be empty", op)
 		}
 		placeholders := make([]string, len(values))
 		for i := range values {
 			placeholders[i] = "?"
 			params = append(params, values[i])
 		}
-		return notPrefix + fieldExpr + " IN (" + strings.Join(placeholders, ", ") + ")", params, nil
+		in := " IN ("
+		if op == "not_in" {
+			in = " NOT IN ("
+		}
+		return notPrefix + fieldExpr + in + strings.Join(placeholders, ", ") + ")", params, nil
 	case "between":
 		values, ok := cond.Value.([]interface{})
 		if !ok || len(values) != 2 {
 			return "", nil, fmt.Errorf("'between' operator requires an array with two values")
 		}
-		return notPrefix + fieldExpr + " BETWEEN ? AND ?", []interface{}{values[0], values[1]}, nil
+		return notPrefix + fieldExpr + " BETWEEN ? AND ?", append(params, values[0], values[1]), nil
 	case "is_null":
 		if value, ok := cond.Value.(bool); ok && value {
-			return fieldExpr + " IS " + notPrefix + "NULL", nil, nil
+			return fieldExpr + " IS " + notPrefix + "NULL", params, nil
 		}
-		return fieldExpr + " IS " + notPrefix + "NULL", nil, nil
+		return fieldExpr + " IS " + notPrefix + "NULL", params, nil
+	case "ilike", "starts_with", "ends_with", "contains", "regex":
+		sql, opParams, err := g.generateStringCondition(op, fieldExpr, cond.Value, cond.Not)
+		if err != nil {
+			return "", nil, err
+		}
+		return sql, append(params, opParams...), nil
+	case "has_any", "has_all":
+		return g.generateListCondition(op, cond.Field, cond.Value, cond.Not)
+	case "exists":
+		return g.generateExistsCondition(cond.Field, cond.Value, cond.Not)
 	default:
 		return "", nil, fmt.Errorf("unknown operator: %s", op)
 	}
@@ -647,36 +814,40 @@ func isJSONColumnCandidate(field string) bool {
 }
 
 // generateGroupBy generates the GROUP BY clause.
-func (g *SQLGenerator) generateGroupBy(req *QueryRequest) (string, error) {
+func (g *SQLGenerator) generateGroupBy(req *QueryRequest) (string, []interface{}, error) {
 	if len(req.GroupBy) == 0 {
-		return "", nil
+		return "", nil, nil
 	}
 
 	fields := make([]string, len(req.GroupBy))
+	var params []interface{}
 	for i, f := range req.GroupBy {
-		expr, err := g.generateFieldExpression(f)
+		expr, exprParams, err := g.fieldExpression(f)
 		if err != nil {
-			return "", err
+			return "", nil, err
 		}
 		fields[i] = expr
+		params = append(params, exprParams...)
 	}
 
-	return strings.Join(fields, ", "), nil
+	return strings.Join(fields, ", "), params, nil
 }
 
 // generateOrderBy generates the ORDER BY clause.
-func (g *SQLGenerator) generateOrderBy(req *QueryRequest) (string, error) {
+func (g *SQLGenerator) generateOrderBy(req *QueryRequest) (string, []interface{}, error) {
 	if len(req.OrderBy) == 0 {
 		// Default sort by created_at descending
-		return "", nil
+		return "", nil, nil
 	}
 
 	orders := make([]string, len(req.OrderBy))
+	var params []interface{}
 	for i, o := range req.OrderBy {
-		fieldExpr, err := g.generateFieldExpression(o.Field)
+		fieldExpr, fieldParams, err := g.fieldExpression(o.Field)
 		if err != nil {
-			return "", err
+			return "", nil, err
 		}
+		params = append(params, fieldParams...)
 		dir := strings.ToUpper(o.Dir)
 		if dir != "ASC" && dir != "DESC" {
 			dir = "ASC"
@@ -684,7 +855,7 @@ func (g *SQLGenerator) generateOrderBy(req *QueryRequest) (string, error) {
 		orders[i] = fieldExpr + " " + dir
 	}
 
-	return strings.Join(orders, ", "), nil
+	return strings.Join(orders, ", "), params, nil
 }
 
 // generateLimit generates the pagination clause.
@@ -722,6 +893,10 @@ func (g *SQLGenerator) quoteIdentifier(name string) string {
 
 func (g *SQLGenerator) quoteQualifiedIdentifier(name string) string {
 	parts := strings.Split(name, ".")
+	if g.subquery != nil && len(parts) > 1 && parts[0] == g.subquery.table {
+		// The table of a subquery is aliased, see subquery.go.
+		parts[0] = g.subquery.alias
+	}
 	quoted := make([]string, 0, len(parts))
 	for _, part := range parts {
 		quoted = append(quoted, g.quoteIdentifier(part))
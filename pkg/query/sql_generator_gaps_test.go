@@ -414,7 +414,7 @@ func TestGenerateCondition_IsNull_NilValue(t *testing.T) {
 
 func TestGenerateCondition_UnknownOp(t *testing.T) {
 	g := NewSQLGenerator(true)
-	_, _, err := g.generateCondition(Condition{Field: "name", Op: "regex", Value: "test"})
+	_, _, err := g.generateCondition(Condition{Field: "name", Op: "soundex", Value: "test"})
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "unknown operator")
 }
@@ -774,122 +774,122 @@ func TestGenerateJSONFieldExpression_InvalidPath(t *testing.T) {
 
 func TestGenerateAggregate_StandardWithField(t *testing.T) {
 	g := NewSQLGenerator(true)
-	sql, err := g.generateAggregate(AggregateFunc{Func: "sum", Field: "amount", As: "total"})
+	sql, _, err := g.generateAggregate(AggregateFunc{Func: "sum", Field: "amount", As: "total"})
 	require.NoError(t, err)
 	assert.Equal(t, `SUM("amount") AS "total"`, sql)
 }
 
 func TestGenerateAggregate_StandardWithoutField(t *testing.T) {
 	g := NewSQLGenerator(true)
-	sql, err := g.generateAggregate(AggregateFunc{Func: "count", As: "cnt"})
+	sql, _, err := g.generateAggregate(AggregateFunc{Func: "count", As: "cnt"})
 	require.NoError(t, err)
 	assert.Equal(t, `COUNT(*) AS "cnt"`, sql)
 }
 
 func TestGenerateAggregate_StandardWithStar(t *testing.T) {
 	g := NewSQLGenerator(true)
-	sql, err := g.generateAggregate(AggregateFunc{Func: "count", Field: "*", As: "cnt"})
+	sql, _, err := g.generateAggregate(AggregateFunc{Func: "count", Field: "*", As: "cnt"})
 	require.NoError(t, err)
 	assert.Equal(t, `COUNT(*) AS "cnt"`, sql)
 }
 
 func TestGenerateAggregate_InvalidAlias(t *testing.T) {
 	g := NewSQLGenerator(true)
-	_, err := g.generateAggregate(AggregateFunc{Func: "count", As: "bad alias!"})
+	_, _, err := g.generateAggregate(AggregateFunc{Func: "count", As: "bad alias!"})
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "aggregate.as")
 }
 
 func TestGenerateAggregate_StdDevNonSQLite(t *testing.T) {
 	g := NewSQLGenerator(false)
-	sql, err := g.generateAggregate(AggregateFunc{Func: "stddev", Field: "amount", As: "std_amount"})
+	sql, _, err := g.generateAggregate(AggregateFunc{Func: "stddev", Field: "amount", As: "std_amount"})
 	require.NoError(t, err)
 	assert.Equal(t, `STDDEV("amount") AS "std_amount"`, sql)
 }
 
 func TestGenerateAggregate_StdDevPopNonSQLite(t *testing.T) {
 	g := NewSQLGenerator(false)
-	sql, err := g.generateAggregate(AggregateFunc{Func: "stddev_pop", Field: "amount", As: "std_pop"})
+	sql, _, err := g.generateAggregate(AggregateFunc{Func: "stddev_pop", Field: "amount", As: "std_pop"})
 	require.NoError(t, err)
 	assert.Equal(t, `STDDEV_POP("amount") AS "std_pop"`, sql)
 }
 
 func TestGenerateAggregate_StdDevSampNonSQLite(t *testing.T) {
 	g := NewSQLGenerator(false)
-	sql, err := g.generateAggregate(AggregateFunc{Func: "stddev_samp", Field: "amount", As: "std_samp"})
+	sql, _, err := g.generateAggregate(AggregateFunc{Func: "stddev_samp", Field: "amount", As: "std_samp"})
 	require.NoError(t, err)
 	assert.Equal(t, `STDDEV_SAMP("amount") AS "std_samp"`, sql)
 }
 
 func TestGenerateAggregate_StdDevWithoutField(t *testing.T) {
 	g := NewSQLGenerator(false)
-	_, err := g.generateAggregate(AggregateFunc{Func: "stddev", As: "std"})
+	_, _, err := g.generateAggregate(AggregateFunc{Func: "stddev", As: "std"})
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "STDDEV")
 }
 
 func TestGenerateAggregate_VarianceNonSQLite(t *testing.T) {
 	g := NewSQLGenerator(false)
-	sql, err := g.generateAggregate(AggregateFunc{Func: "variance", Field: "price", As: "var_price"})
+	sql, _, err := g.generateAggregate(AggregateFunc{Func: "variance", Field: "price", As: "var_price"})
 	require.NoError(t, err)
 	assert.Equal(t, `VARIANCE("price") AS "var_price"`, sql)
 }
 
 func TestGenerateAggregate_VarPopNonSQLite(t *testing.T) {
 	g := NewSQLGenerator(false)
-	sql, err := g.generateAggregate(AggregateFunc{Func: "var_pop", Field: "price", As: "var_pop"})
+	sql, _, err := g.generateAggregate(AggregateFunc{Func: "var_pop", Field: "price", As: "var_pop"})
 	require.NoError(t, err)
 	assert.Equal(t, `VAR_POP("price") AS "var_pop"`, sql)
 }
 
 func TestGenerateAggregate_VarSampNonSQLite(t *testing.T) {
 	g := NewSQLGenerator(false)
-	sql, err := g.generateAggregate(AggregateFunc{Func: "var_samp", Field: "price", As: "var_samp"})
+	sql, _, err := g.generateAggregate(AggregateFunc{Func: "var_samp", Field: "price", As: "var_samp"})
 	require.NoError(t, err)
 	assert.Equal(t, `VAR_SAMP("price") AS "var_samp"`, sql)
 }
 
 func TestGenerateAggregate_VarianceWithoutField(t *testing.T) {
 	g := NewSQLGenerator(false)
-	_, err := g.generateAggregate(AggregateFunc{Func: "variance", As: "var"})
+	_, _, err := g.generateAggregate(AggregateFunc{Func: "variance", As: "var"})
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "VARIANCE")
 }
 
 func TestGenerateAggregate_DefaultFuncWithField(t *testing.T) {
 	g := NewSQLGenerator(true)
-	sql, err := g.generateAggregate(AggregateFunc{Func: "avg", Field: "score", As: "avg_score"})
+	sql, _, err := g.generateAggregate(AggregateFunc{Func: "avg", Field: "score", As: "avg_score"})
 	require.NoError(t, err)
 	assert.Equal(t, `AVG("score") AS "avg_score"`, sql)
 }
 
 func TestGenerateAggregate_DefaultFuncInvalidField(t *testing.T) {
 	g := NewSQLGenerator(true)
-	_, err := g.generateAggregate(AggregateFunc{Func: "sum", Field: "bad field!", As: "total"})
+	_, _, err := g.generateAggregate(AggregateFunc{Func: "sum", Field: "bad field!", As: "total"})
 	assert.Error(t, err)
 }
 
 func TestGenerateAggregate_StdDevInvalidField(t *testing.T) {
 	g := NewSQLGenerator(true)
-	_, err := g.generateAggregate(AggregateFunc{Func: "stddev", Field: "bad field!", As: "std"})
+	_, _, err := g.generateAggregate(AggregateFunc{Func: "stddev", Field: "bad field!", As: "std"})
 	assert.Error(t, err)
 }
 
 func TestGenerateAggregate_VarianceInvalidField(t *testing.T) {
 	g := NewSQLGenerator(true)
-	_, err := g.generateAggregate(AggregateFunc{Func: "variance", Field: "bad field!", As: "var"})
+	_, _, err := g.generateAggregate(AggregateFunc{Func: "variance", Field: "bad field!", As: "var"})
 	assert.Error(t, err)
 }
 
 func TestGenerateAggregate_CountDistinctInvalidField(t *testing.T) {
 	g := NewSQLGenerator(true)
-	_, err := g.generateAggregate(AggregateFunc{Func: "count_distinct", Field: "bad field!", As: "cnt"})
+	_, _, err := g.generateAggregate(AggregateFunc{Func: "count_distinct", Field: "bad field!", As: "cnt"})
 	assert.Error(t, err)
 }
 
 func TestGenerateAggregate_WithJSONField(t *testing.T) {
 	g := NewSQLGenerator(true)
-	sql, err := g.generateAggregate(AggregateFunc{Func: "sum", Field: "data.amount", As: "total"})
+	sql, _, err := g.generateAggregate(AggregateFunc{Func: "sum", Field: "data.amount", As: "total"})
 	require.NoError(t, err)
 	assert.Contains(t, sql, "JSON_EXTRACT")
 	assert.Contains(t, sql, `$.amount`)
@@ -897,7 +897,7 @@ func TestGenerateAggregate_WithJSONField(t *testing.T) {
 
 func TestGenerateAggregate_CountDistinctWithField(t *testing.T) {
 	g := NewSQLGenerator(false)
-	sql, err := g.generateAggregate(AggregateFunc{Func: "count_distinct", Field: "user_id", As: "unique_users"})
+	sql, _, err := g.generateAggregate(AggregateFunc{Func: "count_distinct", Field: "user_id", As: "unique_users"})
 	require.NoError(t, err)
 	assert.Equal(t, `COUNT(DISTINCT "user_id") AS "unique_users"`, sql)
 }
@@ -68,7 +68,7 @@ func TestSQLGenerator_StdDevSQLite(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := g.generateAggregate(tt.agg)
+			result, _, err := g.generateAggregate(tt.agg)
 			if tt.hasError {
 				assert.Error(t, err)
 			} else {
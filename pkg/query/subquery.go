@@ -0,0 +1,351 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// A condition can test the rows of another query, a subquery, given as its value: in and not_in
+// compare a field with the column the subquery selects, exists and not_exists test whether it has
+// rows. A subquery is checked against the permissions of the token and filtered by them like any
+// query. Its conditions can compare a field with a field of the row of the enclosing query, named
+// by ref, which makes it a correlated subquery:
+//
+//	{"op": "exists", "value": {"from": "records", "where": {"and": [
+//		{"field": "data.customer", "ref": "data.name"},
+//		{"field": "data.amount", "op": "gt", "value": 1000}]}}}
+//
+// The table of a subquery is aliased subquery_<depth>, so that a ref to an enclosing query of the
+// same table, such as records in records, reads the enclosing row.
+
+// subqueryAliasPrefix prefixes the alias of the table of a subquery.
+const subqueryAliasPrefix = "subquery_"
+
+// subqueryScope is the subquery a generator generates.
+type subqueryScope struct {
+	table string        // table of the subquery, which its qualified fields name
+	alias string        // alias of the table in SQL
+	depth int           // nesting depth, 1 for a subquery of a top-level query
+	outer *SQLGenerator // generator of the enclosing query, which generates refs
+}
+
+// refOperators are the SQL operators of the operators that compare a field with a ref.
+var refOperators = map[string]string{
+	"eq":  "=",
+	"ne":  "!=",
+	"gt":  ">",
+	"gte": ">=",
+	"lt":  "<",
+	"lte": "<=",
+}
+
+// takesSubquery reports whether an operator can take a subquery as its value.
+func takesSubquery(op string) bool {
+	switch op {
+	case "in", "not_in", "exists", "not_exists":
+		return true
+	}
+	return false
+}
+
+// conditionSubquery returns the subquery of a condition, or nil.
+func conditionSubquery(cond Condition) *QueryRequest {
+	if !takesSubquery(cond.Op) {
+		return nil
+	}
+	sub, _ := cond.Value.(*QueryRequest)
+	return sub
+}
+
+// decodeSubquery returns a subquery value as a query request; a request decoded from JSON is a
+// map. ok is false for other values.
+func decodeSubquery(value interface{}) (*QueryRequest, bool, error) {
+	switch v := value.(type) {
+	case *QueryRequest:
+		return v, v != nil, nil
+	case QueryRequest:
+		return &v, true, nil
+	case map[string]interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid subquery: %w", err)
+		}
+		var sub QueryRequest
+		if err := json.Unmarshal(data, &sub); err != nil {
+			return nil, false, fmt.Errorf("invalid subquery: %w", err)
+		}
+		return &sub, true, nil
+	}
+	return nil, false, nil
+}
+
+// walkConditions calls fn for the conditions of a clause and the conditions nested in them, but
+// not for the conditions of their subqueries.
+func walkConditions(where *WhereClause, fn func(cond *Condition) error) error {
+	if where == nil {
+		return nil
+	}
+	var walk func(conditions []Condition) error
+	walk = func(conditions []Condition) error {
+		for i := range conditions {
+			if err := fn(&conditions[i]); err != nil {
+				return err
+			}
+			if err := walk(conditions[i].And); err != nil {
+				return err
+			}
+			if err := walk(conditions[i].Or); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(where.And); err != nil {
+		return err
+	}
+	return walk(where.Or)
+}
+
+// hasSubquery reports whether a clause has a subquery.
+func hasSubquery(where *WhereClause) bool {
+	errFound := errors.New("found")
+	return walkConditions(where, func(cond *Condition) error {
+		if conditionSubquery(*cond) != nil {
+			return errFound
+		}
+		return nil
+	}) != nil
+}
+
+// subqueryRefs returns the refs of the conditions of a subquery to its enclosing query.
+func subqueryRefs(sub *QueryRequest) []string {
+	var refs []string
+	_ = walkConditions(sub.Where, func(cond *Condition) error {
+		if cond.Ref != "" {
+			refs = append(refs, cond.Ref)
+		}
+		return nil
+	})
+	return refs
+}
+
+// normalizeSubqueries decodes the subqueries of the conditions of req and normalizes them with
+// normalize, which normalizes their own subqueries in turn. Their refs are qualified with the
+// table of req. Subqueries outside of where are decoded to be rejected by validation.
+func normalizeSubqueries(req *QueryRequest, normalize func(*QueryRequest) error) error {
+	for _, where := range []*WhereClause{req.Where, req.Having, req.Qualify} {
+		if err := walkConditions(where, func(cond *Condition) error {
+			return normalizeSubquery(req, cond, normalize)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// normalizeSubquery normalizes the subquery of a condition of req, if it has one.
+func normalizeSubquery(req *QueryRequest, cond *Condition, normalize func(*QueryRequest) error) error {
+	if !takesSubquery(cond.Op) {
+		return nil
+	}
+	sub, ok, err := decodeSubquery(cond.Value)
+	if err != nil || !ok {
+		return err
+	}
+	if err := normalize(sub); err != nil {
+		return fmt.Errorf("subquery: %w", err)
+	}
+	_ = walkConditions(sub.Where, func(c *Condition) error {
+		if c.Ref != "" {
+			c.Ref = qualifyRef(req, c.Ref)
+		}
+		return nil
+	})
+	cond.Value = sub
+	return nil
+}
+
+// qualifyRef qualifies a field of the enclosing query req with its table, unless it names the
+// table or one of its joins already, so that it cannot read a column of the subquery.
+func qualifyRef(req *QueryRequest, ref string) string {
+	ref = strings.TrimSpace(ref)
+	first, _, _ := strings.Cut(ref, ".")
+	first, _, _ = strings.Cut(first, "->")
+	first = strings.TrimSpace(first)
+	if first == req.From {
+		return ref
+	}
+	for _, join := range req.Join {
+		if first == join.Table || (join.As != "" && first == join.As) {
+			return ref
+		}
+	}
+	return req.From + "." + ref
+}
+
+// subquerySelect returns the fields an in subquery selects, without the default * of a subquery
+// that selects an aggregate.
+func subquerySelect(sub *QueryRequest) []string {
+	var fields []string
+	for _, field := range sub.Select {
+		if field != "*" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// checkSubquery checks that a subquery fits its condition: it has no order, windows or combined
+// queries, and an in subquery selects one column to compare the field of the condition with.
+func checkSubquery(cond Condition, sub *QueryRequest) error {
+	if len(sub.Union) > 0 || len(sub.Intersect) > 0 {
+		return errors.New("subquery cannot have union or intersect")
+	}
+	if len(sub.Window) > 0 || sub.Qualify != nil {
+		return errors.New("subquery cannot have window functions")
+	}
+	if len(sub.OrderBy) > 0 || sub.Cursor {
+		return errors.New("subquery cannot have orderBy or a cursor")
+	}
+	if cond.Ref != "" {
+		return errors.New("condition with a subquery cannot have a ref")
+	}
+	switch cond.Op {
+	case "in", "not_in":
+		if strings.TrimSpace(cond.Field) == "" {
+			return fmt.Errorf("'%s' subquery condition requires a field", cond.Op)
+		}
+		if len(subquerySelect(sub))+len(sub.Aggregate) != 1 {
+			return fmt.Errorf("'%s' subquery must select exactly one field or aggregate", cond.Op)
+		}
+	default:
+		if cond.Field != "" {
+			return fmt.Errorf("'%s' subquery condition does not take a field", cond.Op)
+		}
+	}
+	return nil
+}
+
+// validateSubquery validates the subquery of a condition at depth: it is a query of its own, and
+// its conditions nest in the where clause of the enclosing query.
+func (p *Parser) validateSubquery(cond Condition, sub *QueryRequest, depth int) error {
+	if err := checkSubquery(cond, sub); err != nil {
+		return err
+	}
+	if err := p.validate(sub); err != nil {
+		return fmt.Errorf("subquery: %w", err)
+	}
+	if sub.Where == nil {
+		return nil
+	}
+	return p.validateWhereDepth(sub.Where, depth)
+}
+
+// validateSubqueries validates a where clause with subqueries, for requests run without parsing.
+func (p *Parser) validateSubqueries(req *QueryRequest) error {
+	if !hasSubquery(req.Where) {
+		return nil
+	}
+	if err := p.validateWhereDepth(req.Where, 0); err != nil {
+		return err
+	}
+	return validateWhereFieldNames(req.Where)
+}
+
+// validateSubqueryWithScope checks a subquery like a query, and its refs against the table and
+// joins of the enclosing query.
+func (v *Validator) validateSubqueryWithScope(ctx context.Context, table string, joins []JoinClause, cond Condition, sub *QueryRequest, scope *validatorAccessScope) error {
+	if err := checkSubquery(cond, sub); err != nil {
+		return err
+	}
+	if err := v.validateRequestWithScope(ctx, sub, "", scope); err != nil {
+		return fmt.Errorf("subquery: %w", err)
+	}
+	refs := subqueryRefs(sub)
+	for _, ref := range refs {
+		if err := v.checkFieldReferenceWithScope(ctx, table, joins, ref, scope); err != nil {
+			return err
+		}
+	}
+	return v.checkMaskedSubquery(&QueryRequest{From: table, Join: joins}, cond, sub, refs, scope)
+}
+
+// generateSubqueryCondition generates SQL for a condition on a subquery.
+func (g *SQLGenerator) generateSubqueryCondition(cond Condition, sub *QueryRequest) (string, []interface{}, error) {
+	if err := checkSubquery(cond, sub); err != nil {
+		return "", nil, err
+	}
+	depth := 1
+	if g.subquery != nil {
+		depth = g.subquery.depth + 1
+	}
+	inner := *g
+	inner.subquery = &subqueryScope{
+		table: sub.From,
+		alias: fmt.Sprintf("%s%d", subqueryAliasPrefix, depth),
+		depth: depth,
+		outer: g,
+	}
+
+	req := *sub
+	req.Page, req.Size = 0, -1
+	if cond.Op == "exists" || cond.Op == "not_exists" {
+		// Any column tells that a row exists; grouped fields are valid in every database.
+		req.Select = req.GroupBy
+	} else {
+		req.Select = subquerySelect(sub)
+	}
+	query, err := inner.generateSingleQuery(&req)
+	if err != nil {
+		return "", nil, fmt.Errorf("subquery: %w", err)
+	}
+
+	if cond.Op == "exists" || cond.Op == "not_exists" {
+		sql := "EXISTS (" + query.SQL + ")"
+		if (cond.Op == "not_exists") != cond.Not {
+			sql = "NOT " + sql
+		}
+		return sql, query.Params, nil
+	}
+
+	fieldExpr, params, err := g.fieldExpression(cond.Field)
+	if err != nil {
+		return "", nil, err
+	}
+	notPrefix := ""
+	if cond.Not {
+		notPrefix = "NOT "
+	}
+	in := " IN ("
+	if cond.Op == "not_in" {
+		in = " NOT IN ("
+	}
+	return notPrefix + fieldExpr + in + query.SQL + ")", append(params, query.Params...), nil
+}
+
+// generateRefCondition generates SQL comparing a field with a field of the row of the enclosing
+// query.
+func (g *SQLGenerator) generateRefCondition(op, fieldExpr string, cond Condition) (string, []interface{}, error) {
+	if g.subquery == nil {
+		return "", nil, fmt.Errorf("ref '%s' is only allowed in the conditions of a subquery", cond.Ref)
+	}
+	operator, ok := refOperators[op]
+	if !ok {
+		return "", nil, fmt.Errorf("'%s' operator cannot compare with a ref", op)
+	}
+	if cond.Value != nil {
+		return "", nil, errors.New("condition cannot have both a value and a ref")
+	}
+	refExpr, params, err := g.subquery.outer.fieldExpression(cond.Ref)
+	if err != nil {
+		return "", nil, fmt.Errorf("ref %w", err)
+	}
+	notPrefix := ""
+	if cond.Not {
+		notPrefix = "NOT "
+	}
+	return notPrefix + fieldExpr + " " + operator + " " + refExpr, params, nil
+}
@@ -0,0 +1,257 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+)
+
+func TestParser_Subqueries(t *testing.T) {
+	p := NewParser()
+	req, err := p.Parse([]byte(`{
+		"from": "records",
+		"where": {"and": [
+			{"field": "data.name", "op": "in", "value": {"from": "records", "select": ["data.customer"]}},
+			{"op": "exists", "value": {"from": "records", "where": {"and": [
+				{"field": "data.customer", "ref": "data.name"},
+				{"field": "data.amount", "op": "gt", "value": 1000}
+			]}}}
+		]}
+	}`))
+	require.NoError(t, err)
+	in, ok := req.Where.And[0].Value.(*QueryRequest)
+	require.True(t, ok)
+	assert.Equal(t, []string{"data.customer"}, in.Select)
+	exists, ok := req.Where.And[1].Value.(*QueryRequest)
+	require.True(t, ok)
+	assert.Equal(t, "records.data.name", exists.Where.And[0].Ref, "refs are qualified with the enclosing table")
+
+	for name, body := range map[string]string{
+		"in selecting two fields": `{"from": "records", "where": {"and": [{"field": "id", "op": "in", "value": {"from": "records", "select": ["id", "table_id"]}}]}}`,
+		"in selecting nothing":    `{"from": "records", "where": {"and": [{"field": "id", "op": "in", "value": {"from": "records"}}]}}`,
+		"exists with a field":     `{"from": "records", "where": {"and": [{"field": "id", "op": "exists", "value": {"from": "records"}}]}}`,
+		"not_exists literal":      `{"from": "records", "where": {"and": [{"op": "not_exists", "value": true}]}}`,
+		"ordered subquery":        `{"from": "records", "where": {"and": [{"op": "exists", "value": {"from": "records", "orderBy": [{"field": "id"}]}}]}}`,
+		"ref with like":           `{"from": "records", "where": {"and": [{"op": "exists", "value": {"from": "records", "where": {"and": [{"field": "id", "op": "like", "ref": "id"}]}}}]}}`,
+		"ref and value":           `{"from": "records", "where": {"and": [{"op": "exists", "value": {"from": "records", "where": {"and": [{"field": "id", "ref": "id", "value": 1}]}}}]}}`,
+		"ref to a date function":  `{"from": "records", "where": {"and": [{"op": "exists", "value": {"from": "records", "where": {"and": [{"field": "id", "ref": "extract(year, created_at)"}]}}}]}}`,
+		"invalid subquery field":  `{"from": "records", "where": {"and": [{"op": "exists", "value": {"from": "records", "where": {"and": [{"field": "data.x'", "value": 1}]}}}]}}`,
+		"subquery in having":      `{"from": "records", "groupBy": ["table_id"], "aggregate": [{"func": "count", "as": "n"}], "having": {"and": [{"field": "n", "op": "exists", "value": {"from": "records"}}]}}`,
+	} {
+		_, err := p.Parse([]byte(body))
+		assert.Error(t, err, name)
+	}
+
+	nested := `{"from": "records", "where": {"and": [{"op": "exists", "value": {"from": "records", "where": {"and": [
+		{"op": "exists", "value": {"from": "records", "where": {"and": [{"field": "id", "ref": "id"}]}}}
+	]}}}]}}`
+	_, err = NewParserWithLimits(QueryLimits{MaxJoins: 3, MaxPageSize: 100, MaxDepth: 2, MaxFields: 10}).Parse([]byte(nested))
+	assert.Error(t, err, "subqueries count towards the nesting depth")
+	_, err = NewParserWithLimits(QueryLimits{MaxJoins: 3, MaxPageSize: 100, MaxDepth: 3, MaxFields: 10}).Parse([]byte(nested))
+	assert.NoError(t, err)
+}
+
+func TestSQLGenerator_Subqueries(t *testing.T) {
+	order := &QueryRequest{
+		From: "records",
+		Where: &WhereClause{And: []Condition{
+			{Field: "data.customer", Op: "eq", Ref: "records.data.name"},
+			{Field: "data.amount", Op: "gt", Value: 1000},
+		}},
+	}
+
+	q, err := NewSQLGeneratorWithDBType("sqlite").Generate(&QueryRequest{
+		From:   "records",
+		Select: []string{"id"},
+		Where: &WhereClause{And: []Condition{
+			{Field: "table_id", Value: "customers"},
+			{Op: "exists", Value: order},
+		}},
+		Size: -1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT "id" FROM "records" WHERE "table_id" = ? AND EXISTS (SELECT * FROM "records" AS "subquery_1" `+
+		`WHERE JSON_EXTRACT("data", '$.customer') = JSON_EXTRACT("records"."data", '$.name') AND JSON_EXTRACT("data", '$.amount') > ?)`, q.SQL)
+	assert.Equal(t, []interface{}{"customers", 1000}, q.Params)
+
+	q, err = NewSQLGeneratorWithDBType("postgres").Generate(&QueryRequest{
+		From:   "records",
+		Select: []string{"id"},
+		Where: &WhereClause{And: []Condition{
+			{Field: "data.name", Op: "not_in", Value: &QueryRequest{
+				From:      "records",
+				Select:    []string{"data.customer"},
+				Where:     &WhereClause{And: []Condition{{Field: "records.table_id", Value: "orders"}}},
+				GroupBy:   []string{"data.customer"},
+				Having:    &WhereClause{And: []Condition{{Field: "orders", Op: "gte", Value: 3}}},
+				Aggregate: nil,
+				Page:      2,
+				Size:      5,
+			}},
+			{Op: "not_exists", Value: &QueryRequest{From: "records", Where: &WhereClause{And: []Condition{
+				{Op: "exists", Value: &QueryRequest{From: "records", Where: &WhereClause{And: []Condition{
+					{Field: "id", Ref: "subquery_1.id"},
+				}}}},
+			}}}},
+		}},
+		Size: -1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT "id" FROM "records" WHERE "data"->>'name' NOT IN (SELECT "data"->>'customer' FROM "records" AS "subquery_1" `+
+		`WHERE "subquery_1"."table_id" = ? GROUP BY "data"->>'customer' HAVING "orders" >= ?) `+
+		`AND NOT EXISTS (SELECT * FROM "records" AS "subquery_1" WHERE EXISTS (SELECT * FROM "records" AS "subquery_2" WHERE "id" = "subquery_1"."id"))`, q.SQL)
+	assert.Equal(t, []interface{}{"orders", 3}, q.Params)
+
+	q, err = NewSQLGeneratorWithDBType("mysql").Generate(&QueryRequest{
+		From:   "tables",
+		Select: []string{"name"},
+		Where: &WhereClause{And: []Condition{{Field: "id", Op: "in", Value: &QueryRequest{
+			From:      "records",
+			Select:    []string{"*"},
+			Aggregate: []AggregateFunc{{Func: "max", Field: "table_id", As: "last"}},
+		}}}},
+		Size: -1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT `name` FROM `tables` WHERE `id` IN (SELECT MAX(`table_id`) AS `last` FROM `records` AS `subquery_1`)", q.SQL)
+
+	_, err = NewSQLGeneratorWithDBType("sqlite").Generate(&QueryRequest{
+		From:  "records",
+		Where: &WhereClause{And: []Condition{{Field: "id", Ref: "records.id"}}},
+	})
+	assert.ErrorContains(t, err, "only allowed in the conditions of a subquery")
+}
+
+func TestExecutor_Subqueries(t *testing.T) {
+	db := setupQueryTestDB(t)
+	dbModel, customers := createTestData(t, db)
+	orders := &models.Table{DatabaseID: dbModel.ID, Name: "orders"}
+	require.NoError(t, db.Create(orders).Error)
+	for _, name := range []string{"ada", "bob", "cy"} {
+		require.NoError(t, db.Create(&models.Record{TableID: customers.ID, Data: models.JSONField(`{"name":"` + name + `"}`)}).Error)
+	}
+	for _, data := range []string{
+		`{"customer":"ada","amount":1500}`,
+		`{"customer":"ada","amount":20}`,
+		`{"customer":"bob","amount":300}`,
+	} {
+		require.NoError(t, db.Create(&models.Record{TableID: orders.ID, Data: models.JSONField(data)}).Error)
+	}
+	executor := NewExecutor(db)
+	names := func(req *QueryRequest) []interface{} {
+		t.Helper()
+		req.From, req.Select, req.OrderBy, req.Page, req.Size = "records", []string{"data.name"}, []OrderByClause{{Field: "data.name"}}, 1, 20
+		req.Where.And = append([]Condition{{Field: "table_id", Value: customers.ID}}, req.Where.And...)
+		result, err := executor.Execute(context.Background(), req, "user1")
+		require.NoError(t, err)
+		values := make([]interface{}, len(result.Data))
+		for i, row := range result.Data {
+			for _, value := range row {
+				values[i] = value
+			}
+		}
+		return values
+	}
+	bigOrder := func() *QueryRequest {
+		return &QueryRequest{From: "records", Where: &WhereClause{And: []Condition{
+			{Field: "table_id", Value: orders.ID},
+			{Field: "data.customer", Ref: "data.name"},
+			{Field: "data.amount", Op: "gt", Value: 1000},
+		}}}
+	}
+
+	t.Run("exists", func(t *testing.T) {
+		assert.Equal(t, []interface{}{"ada"}, names(&QueryRequest{Where: &WhereClause{And: []Condition{
+			{Op: "exists", Value: bigOrder()},
+		}}}))
+		assert.Equal(t, []interface{}{"bob", "cy"}, names(&QueryRequest{Where: &WhereClause{And: []Condition{
+			{Op: "not_exists", Value: bigOrder()},
+		}}}))
+	})
+
+	t.Run("in", func(t *testing.T) {
+		customersWithOrders := map[string]interface{}{
+			"from":   "records",
+			"select": []interface{}{"data.customer"},
+			"where":  map[string]interface{}{"and": []interface{}{map[string]interface{}{"field": "table_id", "value": orders.ID}}},
+		}
+		assert.Equal(t, []interface{}{"ada", "bob"}, names(&QueryRequest{Where: &WhereClause{And: []Condition{
+			{Field: "data.name", Op: "in", Value: customersWithOrders},
+		}}}))
+		assert.Equal(t, []interface{}{"cy"}, names(&QueryRequest{Where: &WhereClause{And: []Condition{
+			{Field: "data.name", Op: "not_in", Value: customersWithOrders},
+		}}}))
+	})
+
+	t.Run("request is not modified", func(t *testing.T) {
+		sub := bigOrder()
+		req := &QueryRequest{From: "records", Where: &WhereClause{And: []Condition{{Op: "exists", Value: sub}}}}
+		_, err := executor.Execute(context.Background(), req, "user1")
+		require.NoError(t, err)
+		assert.Len(t, sub.Where.And, 3)
+		assert.Equal(t, "data.name", sub.Where.And[1].Ref)
+	})
+
+	t.Run("depth limit", func(t *testing.T) {
+		limited := NewExecutorWithConfig(db, QueryLimits{MaxJoins: 3, MaxPageSize: 100, MaxDepth: 1, MaxRows: 100, MaxFields: 10}, DefaultAllowedTables)
+		_, err := limited.Execute(context.Background(), &QueryRequest{From: "records", Where: &WhereClause{And: []Condition{
+			{Op: "exists", Value: bigOrder()},
+		}}}, "user1")
+		assert.ErrorContains(t, err, "nesting depth")
+	})
+}
+
+func TestExecutor_SubqueryPermissions(t *testing.T) {
+	db := setupScopedExecutorTestDB(t)
+	allowedDB := &models.Database{Name: "allowed"}
+	blockedDB := &models.Database{Name: "blocked"}
+	require.NoError(t, db.Create(allowedDB).Error)
+	require.NoError(t, db.Create(blockedDB).Error)
+	allowedTable := &models.Table{DatabaseID: allowedDB.ID, Name: "customers"}
+	blockedTable := &models.Table{DatabaseID: blockedDB.ID, Name: "secrets"}
+	require.NoError(t, db.Create(allowedTable).Error)
+	require.NoError(t, db.Create(blockedTable).Error)
+	require.NoError(t, db.Create(&models.Record{TableID: allowedTable.ID, Data: `{"name":"ada"}`}).Error)
+	require.NoError(t, db.Create(&models.Record{TableID: blockedTable.ID, Data: `{"name":"ada","secret":"x"}`}).Error)
+	token := &models.Token{
+		Name:   "viewer",
+		Token:  "cs_viewer_subquery",
+		Scopes: `{"databases":{"` + allowedDB.ID + `":"viewer"}}`,
+	}
+	require.NoError(t, db.Create(token).Error)
+	executor := NewExecutor(db)
+
+	result, err := executor.Execute(context.Background(), &QueryRequest{
+		From:   "records",
+		Select: []string{"id"},
+		Where: &WhereClause{And: []Condition{{Op: "exists", Value: &QueryRequest{
+			From:  "records",
+			Where: &WhereClause{And: []Condition{{Field: "data.secret", Value: "x"}}},
+		}}}},
+	}, token.ID)
+	require.NoError(t, err)
+	assert.Empty(t, result.Data, "subqueries only read the records the token can read")
+
+	_, err = executor.Execute(context.Background(), &QueryRequest{
+		From: "records",
+		Where: &WhereClause{And: []Condition{{Field: "id", Op: "in", Value: &QueryRequest{
+			From:   "tokens",
+			Select: []string{"id"},
+		}}}},
+	}, token.ID)
+	assert.ErrorContains(t, err, "access to tokens denied")
+
+	_, err = executor.Execute(context.Background(), &QueryRequest{
+		From: "records",
+		Where: &WhereClause{And: []Condition{{Field: "id", Op: "in", Value: &QueryRequest{
+			From:   "records",
+			Select: []string{"version"},
+			Where:  &WhereClause{And: []Condition{{Field: "deleted_at", Ref: "id"}}},
+		}}}},
+	}, token.ID)
+	assert.ErrorContains(t, err, "not in the allowed list")
+}
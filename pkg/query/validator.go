@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/jiangfire/cornerstone/internal/authz"
@@ -26,6 +28,9 @@ type validatorAccessScope struct {
 
 	recordIDs       []string
 	recordIDsLoaded bool
+
+	masks       *recordMasks
+	masksLoaded bool
 }
 
 func NewValidator(db *gorm.DB) *Validator {
@@ -58,12 +63,18 @@ func (v *Validator) validateRequestWithScope(ctx context.Context, req *QueryRequ
 	if err := v.checkTableAccessWithScope(ctx, req.From, scope); err != nil {
 		return err
 	}
+	if err := v.checkMaskedFields(req, scope); err != nil {
+		return err
+	}
+	if hasSubquery(req.Having) || hasSubquery(req.Qualify) {
+		return errors.New("subqueries are only supported in where")
+	}
 
 	for _, field := range req.Select {
 		if field == "*" {
 			continue
 		}
-		if err := v.checkFieldReferenceWithScope(ctx, req.From, req.Join, field, scope); err != nil {
+		if err := v.checkOutputFieldWithScope(ctx, req, field, scope); err != nil {
 			return err
 		}
 	}
@@ -89,10 +100,36 @@ func (v *Validator) validateRequestWithScope(ctx context.Context, req *QueryRequ
 	}
 
 	for _, order := range req.OrderBy {
-		if err := v.checkFieldReferenceWithScope(ctx, req.From, req.Join, order.Field, scope); err != nil {
+		if req.isOutputAlias(order.Field) {
+			continue
+		}
+		if err := v.checkOutputFieldWithScope(ctx, req, order.Field, scope); err != nil {
 			return err
 		}
 	}
+	if err := walkConditions(req.Having, func(cond *Condition) error {
+		if req.isOutputAlias(cond.Field) {
+			return nil
+		}
+		return v.checkOutputFieldWithScope(ctx, req, cond.Field, scope)
+	}); err != nil {
+		return err
+	}
+
+	for _, w := range req.Window {
+		fields := append([]string{w.Field}, w.PartitionBy...)
+		for _, order := range w.OrderBy {
+			fields = append(fields, order.Field)
+		}
+		for _, field := range fields {
+			if field == "" || req.aggregateAlias(field) != nil {
+				continue
+			}
+			if err := v.checkFieldReferenceWithScope(ctx, req.From, req.Join, field, scope); err != nil {
+				return err
+			}
+		}
+	}
 
 	for _, group := range req.GroupBy {
 		if err := v.checkFieldReferenceWithScope(ctx, req.From, req.Join, group, scope); err != nil {
@@ -102,12 +139,26 @@ func (v *Validator) validateRequestWithScope(ctx context.Context, req *QueryRequ
 
 	for _, agg := range req.Aggregate {
 		if agg.Field != "" && agg.Field != "*" {
+			if err := checkAggregateExpression(req, agg); err != nil {
+				return err
+			}
 			if err := v.checkFieldReferenceWithScope(ctx, req.From, req.Join, agg.Field, scope); err != nil {
 				return err
 			}
 		}
 	}
 
+	for i := range req.Union {
+		if err := v.validateRequestWithScope(ctx, &req.Union[i], userID, scope); err != nil {
+			return fmt.Errorf("union[%d]: %w", i, err)
+		}
+	}
+	for i := range req.Intersect {
+		if err := v.validateRequestWithScope(ctx, &req.Intersect[i], userID, scope); err != nil {
+			return fmt.Errorf("intersect[%d]: %w", i, err)
+		}
+	}
+
 	return nil
 }
 
@@ -131,6 +182,11 @@ func (v *Validator) validateConditionFieldsWithScope(ctx context.Context, table
 			return err
 		}
 	}
+	if sub := conditionSubquery(cond); sub != nil {
+		if err := v.validateSubqueryWithScope(ctx, table, joins, cond, sub, scope); err != nil {
+			return err
+		}
+	}
 
 	for _, nested := range cond.And {
 		if err := v.validateConditionFieldsWithScope(ctx, table, joins, nested, scope); err != nil {
@@ -178,6 +234,22 @@ func (v *Validator) checkFieldReferenceWithScope(ctx context.Context, baseTable
 	if field == "" || field == "*" {
 		return nil
 	}
+	if call, ok := dateFunctionOf(field); ok {
+		for _, f := range call.fields() {
+			if err := v.checkFieldReferenceWithScope(ctx, baseTable, joins, f, scope); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if expr, ok := expressionOf(field); ok {
+		for _, ref := range expr.References() {
+			if err := v.checkFieldReferenceWithScope(ctx, baseTable, joins, ref, scope); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
 
 	parts := strings.Split(field, ".")
 	if len(parts) >= 2 {
@@ -298,6 +370,40 @@ func (v *Validator) autoFilterByPermissionWithScope(req *QueryRequest, scope *va
 	if req.Where == nil {
 		req.Where = &WhereClause{}
 	}
+	// The queries combined with this one read their tables as much as it does.
+	for i := range req.Union {
+		if err := v.autoFilterByPermissionWithScope(&req.Union[i], scope); err != nil {
+			return err
+		}
+	}
+	for i := range req.Intersect {
+		if err := v.autoFilterByPermissionWithScope(&req.Intersect[i], scope); err != nil {
+			return err
+		}
+	}
+	// So do its subqueries.
+	if err := walkConditions(req.Where, func(cond *Condition) error {
+		if sub := conditionSubquery(*cond); sub != nil {
+			return v.autoFilterByPermissionWithScope(sub, scope)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	// Joined records are filtered like records queried directly, by the name the query gives them.
+	for _, join := range req.Join {
+		if join.Table != "records" {
+			continue
+		}
+		qualifier := join.Table
+		if join.As != "" {
+			qualifier = join.As
+		}
+		if err := v.filterRecordsWithScope(req, qualifier, scope); err != nil {
+			return err
+		}
+	}
 
 	switch req.From {
 	case "databases":
@@ -310,14 +416,7 @@ func (v *Validator) autoFilterByPermissionWithScope(req *QueryRequest, scope *va
 		}
 		appendInCondition(req.Where, qualifyBaseField(req.From, "id"), dbIDs)
 	case "records":
-		tableIDs, err := scope.accessibleTableIDs()
-		if err != nil {
-			return err
-		}
-		if len(tableIDs) == 0 {
-			return errors.New("you do not have access to any tables")
-		}
-		appendInCondition(req.Where, qualifyBaseField(req.From, "table_id"), tableIDs)
+		return v.filterRecordsWithScope(req, req.From, scope)
 	case "tables":
 		dbIDs, err := scope.accessibleDatabaseIDs()
 		if err != nil {
@@ -355,6 +454,106 @@ func (v *Validator) autoFilterByPermissionWithScope(req *QueryRequest, scope *va
 	return nil
 }
 
+// filterRecordsWithScope limits the records a query reads as qualifier, the records table or the
+// alias of a join to it, to the tables and rows the token can read.
+func (v *Validator) filterRecordsWithScope(req *QueryRequest, qualifier string, scope *validatorAccessScope) error {
+	tableIDs, err := scope.accessibleTableIDs()
+	if err != nil {
+		return err
+	}
+	if len(tableIDs) == 0 {
+		return errors.New("you do not have access to any tables")
+	}
+	return v.appendRecordAccessCondition(req, qualifier, tableIDs, scope)
+}
+
+// appendRecordAccessCondition limits the records of a query to the accessible tables and, on the
+// tables the token has a row filter on, to the records passing it.
+func (v *Validator) appendRecordAccessCondition(req *QueryRequest, qualifier string, tableIDs []string, scope *validatorAccessScope) error {
+	tableField := qualifyBaseField(qualifier, "table_id")
+	var unfiltered []string
+	var filtered []Condition
+	for _, tableID := range tableIDs {
+		filter := scope.authorizer.RowFilter(tableID)
+		if filter == nil {
+			unfiltered = append(unfiltered, tableID)
+			continue
+		}
+		conditions, ok, err := v.rowFilterConditions(qualifier, tableID, filter)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		conditions = append([]Condition{{Field: tableField, Op: "eq", Value: tableID}}, conditions...)
+		filtered = append(filtered, Condition{And: conditions})
+	}
+
+	if len(filtered) == 0 {
+		if len(unfiltered) == 0 {
+			unfiltered = []string{"__no_accessible_table__"}
+		}
+		appendInCondition(req.Where, tableField, unfiltered)
+		return nil
+	}
+	var anyOf []Condition
+	if len(unfiltered) > 0 {
+		values := make([]interface{}, len(unfiltered))
+		for i, tableID := range unfiltered {
+			values[i] = tableID
+		}
+		anyOf = append(anyOf, Condition{Field: tableField, Op: "in", Value: values})
+	}
+	anyOf = append(anyOf, filtered...)
+	req.Where.And = append([]Condition{{Or: anyOf}}, req.Where.And...)
+	return nil
+}
+
+// rowFilterConditions returns the conditions on the data of the records of a table that a row
+// filter makes, its keys resolved from field names or IDs. ok is false when a key names no
+// field of the table, so no record passes.
+func (v *Validator) rowFilterConditions(from, tableID string, filter map[string]interface{}) ([]Condition, bool, error) {
+	keys := make([]string, 0, len(filter))
+	for key := range filter {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	conditions := make([]Condition, 0, len(keys))
+	for _, key := range keys {
+		var names []string
+		if err := v.db.Table("fields").
+			Where("table_id = ? AND (name = ? OR id = ?) AND deleted_at IS NULL", tableID, key, key).
+			Limit(1).Pluck("name", &names).Error; err != nil {
+			return nil, false, fmt.Errorf("failed to resolve row filter field: %w", err)
+		}
+		if len(names) == 0 {
+			return nil, false, nil
+		}
+		if err := ValidateJSONPath(names[0]); err != nil {
+			return nil, false, fmt.Errorf("row filter field '%s' cannot be queried: %w", names[0], err)
+		}
+		conditions = append(conditions, rowFilterCondition(v.db.Name(), from, names[0], filter[key]))
+	}
+	return conditions, true, nil
+}
+
+// rowFilterCondition returns the condition a row filter value makes on a field of the data of
+// from. On PostgreSQL data->>'field' is text, so numbers and booleans compare as the text jsonb
+// prints for them: numbers in full, never with an exponent.
+func rowFilterCondition(dbType, from, name string, value interface{}) Condition {
+	if dbType == "postgres" {
+		switch typed := value.(type) {
+		case float64:
+			value = strconv.FormatFloat(typed, 'f', -1, 64)
+		case bool:
+			value = strconv.FormatBool(typed)
+		}
+	}
+	return Condition{Field: from + ".data." + name, Op: "eq", Value: value}
+}
+
 func (v *Validator) newAccessScope(userID string) (*validatorAccessScope, error) {
 	authorizer, err := authz.NewAuthorizer(v.db, userID)
 	if err != nil {
@@ -445,6 +644,18 @@ func (v *Validator) FilterFieldsByPermission(ctx context.Context, data []map[str
 		filtered[i] = filteredItem
 	}
 
+	if table == "records" {
+		scope, err := v.newAccessScope(userID)
+		if err != nil {
+			return nil, err
+		}
+		masks, err := v.recordMasks(scope)
+		if err != nil {
+			return nil, err
+		}
+		v.maskRows(filtered, masks)
+	}
+
 	return filtered, nil
 }
 
@@ -128,6 +128,30 @@ func TestValidateRequest_ValidatesAggregateFields(t *testing.T) {
 	assert.Contains(t, err.Error(), "invalid_col")
 }
 
+func TestValidateRequest_ValidatesWindowFields(t *testing.T) {
+	db := setupQueryTestDB(t)
+	createTestData(t, db)
+	v := NewValidator(db)
+	for _, w := range []WindowFunc{
+		{Func: "sum", Field: "invalid_col", As: "total"},
+		{Func: "sum", Field: "id", PartitionBy: []string{"invalid_col"}, As: "total"},
+		{Func: "rank", OrderBy: []OrderByClause{{Field: "invalid_col"}}, As: "place"},
+	} {
+		req := &QueryRequest{From: "databases", Select: []string{"id"}, Window: []WindowFunc{w}}
+		err := v.ValidateRequest(context.Background(), req, "user1")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid_col")
+	}
+
+	req := &QueryRequest{
+		From:    "databases",
+		Select:  []string{"id"},
+		Window:  []WindowFunc{{Func: "rank", OrderBy: []OrderByClause{{Field: "name"}}, As: "place"}},
+		OrderBy: []OrderByClause{{Field: "place"}},
+	}
+	assert.NoError(t, v.ValidateRequest(context.Background(), req, "user1"))
+}
+
 func TestValidateRequest_ValidatesJoinFields(t *testing.T) {
 	db := setupQueryTestDB(t)
 	createTestData(t, db)
@@ -0,0 +1,292 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// A query with window functions selects each of them under its alias, next to the selected fields
+// and aggregates. The windows see every row matching where, after grouping when the query has
+// aggregates, and before paging. qualify filters the rows by the values of the windows, such as
+// `{"field": "rn", "op": "lte", "value": 3}` on a row_number for the top 3 per group: the query
+// is then wrapped in an outer query, which sorts by the sort keys selected as hidden columns.
+
+// windowOrderColumnPrefix prefixes the columns a qualified query selects for its sort keys; they
+// are removed from the result rows.
+const windowOrderColumnPrefix = "__order_"
+
+// isValidWindowFunc checks whether a window function is valid.
+func isValidWindowFunc(fn string) bool {
+	switch fn {
+	case "row_number", "rank", "dense_rank", "lag", "lead", "sum", "avg":
+		return true
+	}
+	return false
+}
+
+// windowFuncTakesField reports whether a window function computes over a field.
+func windowFuncTakesField(fn string) bool {
+	switch fn {
+	case "lag", "lead", "sum", "avg":
+		return true
+	}
+	return false
+}
+
+// validateWindows validates the window functions and qualify conditions of a request.
+func (p *Parser) validateWindows(req *QueryRequest) error {
+	if len(req.Window) > p.limits.MaxFields {
+		return fmt.Errorf("window function count cannot exceed %d", p.limits.MaxFields)
+	}
+	aliases := make(map[string]bool)
+	for _, agg := range req.Aggregate {
+		aliases[agg.As] = true
+	}
+	validateField := func(field string) error {
+		if req.aggregateAlias(field) != nil {
+			return nil
+		}
+		return validateFieldExpression(field)
+	}
+
+	for i, w := range req.Window {
+		if !isValidWindowFunc(w.Func) {
+			return fmt.Errorf("invalid window function: %s", w.Func)
+		}
+		if w.As == "" {
+			return fmt.Errorf("window function %s must specify an alias (as)", w.Func)
+		}
+		// A dotted alias could stand for a field path in orderBy and qualify.
+		if err := validateIdentifierSegment(w.As); err != nil {
+			return fmt.Errorf("window[%d].as %w", i, err)
+		}
+		if aliases[w.As] {
+			return fmt.Errorf("window[%d].as '%s' is already used", i, w.As)
+		}
+		aliases[w.As] = true
+
+		if windowFuncTakesField(w.Func) {
+			if w.Field == "" || w.Field == "*" {
+				return fmt.Errorf("window function %s requires a field", w.Func)
+			}
+			if err := validateField(w.Field); err != nil {
+				return fmt.Errorf("window[%d].field %w", i, err)
+			}
+		} else if w.Field != "" {
+			return fmt.Errorf("window function %s does not take a field", w.Func)
+		}
+		if w.Offset < 0 || (w.Offset > 0 && w.Func != "lag" && w.Func != "lead") {
+			return fmt.Errorf("window[%d].offset must be a positive number of rows for lag and lead", i)
+		}
+		if len(w.OrderBy) == 0 && w.Func != "sum" && w.Func != "avg" {
+			return fmt.Errorf("window function %s requires orderBy", w.Func)
+		}
+		for j, field := range w.PartitionBy {
+			if err := validateField(field); err != nil {
+				return fmt.Errorf("window[%d].partitionBy[%d] %w", i, j, err)
+			}
+		}
+		for j, order := range w.OrderBy {
+			if err := validateField(order.Field); err != nil {
+				return fmt.Errorf("window[%d].orderBy[%d] %w", i, j, err)
+			}
+			switch strings.ToLower(order.Dir) {
+			case "", "asc", "desc":
+			default:
+				return fmt.Errorf("window[%d].orderBy[%d] invalid direction: %s", i, j, order.Dir)
+			}
+		}
+	}
+
+	if req.Qualify == nil {
+		return nil
+	}
+	if len(req.Window) == 0 {
+		return errors.New("qualify requires window functions")
+	}
+	if err := p.validateWhereDepth(req.Qualify, 0); err != nil {
+		return err
+	}
+	var checkConditions func(conditions []Condition) error
+	checkConditions = func(conditions []Condition) error {
+		for _, cond := range conditions {
+			if len(cond.And) > 0 || len(cond.Or) > 0 {
+				if err := checkConditions(cond.And); err != nil {
+					return err
+				}
+				if err := checkConditions(cond.Or); err != nil {
+					return err
+				}
+				continue
+			}
+			if req.windowFunc(cond.Field) == nil {
+				return fmt.Errorf("qualify field '%s' is not a window function alias", cond.Field)
+			}
+			if cond.Op == "search" {
+				return errors.New("qualify does not support the search operator")
+			}
+		}
+		return nil
+	}
+	if err := checkConditions(req.Qualify.And); err != nil {
+		return err
+	}
+	return checkConditions(req.Qualify.Or)
+}
+
+// windowFunc returns the window function of an alias, or nil.
+func (req *QueryRequest) windowFunc(alias string) *WindowFunc {
+	for i := range req.Window {
+		if req.Window[i].As == alias {
+			return &req.Window[i]
+		}
+	}
+	return nil
+}
+
+// aggregateAlias returns the aggregate of an alias, or nil. Dotted names are field paths.
+func (req *QueryRequest) aggregateAlias(alias string) *AggregateFunc {
+	if strings.Contains(alias, ".") {
+		return nil
+	}
+	for i := range req.Aggregate {
+		if req.Aggregate[i].As == alias {
+			return &req.Aggregate[i]
+		}
+	}
+	return nil
+}
+
+// isOutputAlias reports whether a sort field names a window function or aggregate of the query
+// rather than a field.
+func (req *QueryRequest) isOutputAlias(field string) bool {
+	return req.windowFunc(field) != nil || req.aggregateAlias(field) != nil
+}
+
+// generateWindow generates SQL for a window function.
+func (g *SQLGenerator) generateWindow(req *QueryRequest, w WindowFunc) (string, []interface{}, error) {
+	if err := validateIdentifierSegment(w.As); err != nil {
+		return "", nil, fmt.Errorf("window.as %w", err)
+	}
+	var params []interface{}
+
+	call := strings.ToUpper(w.Func) + "()"
+	if windowFuncTakesField(w.Func) {
+		expr, exprParams, err := g.windowOperand(req, w.Field)
+		if err != nil {
+			return "", nil, fmt.Errorf("window.field %w", err)
+		}
+		params = append(params, exprParams...)
+		switch w.Func {
+		case "lag", "lead":
+			offset := w.Offset
+			if offset == 0 {
+				offset = 1
+			}
+			call = fmt.Sprintf("%s(%s, %d)", strings.ToUpper(w.Func), expr, offset)
+		default:
+			if g.dbType == "postgres" && req.aggregateAlias(w.Field) == nil {
+				// data->>'key' is text on PostgreSQL.
+				expr = "CAST(" + expr + " AS numeric)"
+			}
+			call = fmt.Sprintf("%s(%s)", strings.ToUpper(w.Func), expr)
+		}
+	}
+
+	var over []string
+	if len(w.PartitionBy) > 0 {
+		fields := make([]string, len(w.PartitionBy))
+		for i, field := range w.PartitionBy {
+			expr, exprParams, err := g.windowOperand(req, field)
+			if err != nil {
+				return "", nil, fmt.Errorf("window.partitionBy %w", err)
+			}
+			fields[i] = expr
+			params = append(params, exprParams...)
+		}
+		over = append(over, "PARTITION BY "+strings.Join(fields, ", "))
+	}
+	if len(w.OrderBy) > 0 {
+		orders := make([]string, len(w.OrderBy))
+		for i, order := range w.OrderBy {
+			expr, exprParams, err := g.windowOperand(req, order.Field)
+			if err != nil {
+				return "", nil, fmt.Errorf("window.orderBy %w", err)
+			}
+			dir := "ASC"
+			if strings.EqualFold(order.Dir, "desc") {
+				dir = "DESC"
+			}
+			orders[i] = expr + " " + dir
+			params = append(params, exprParams...)
+		}
+		over = append(over, "ORDER BY "+strings.Join(orders, ", "))
+		if w.Func == "sum" || w.Func == "avg" {
+			// Running totals add one row at a time, also across rows that tie on the order.
+			over = append(over, "ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW")
+		}
+	}
+
+	return fmt.Sprintf("%s OVER (%s) AS %s", call, strings.Join(over, " "), g.quoteIdentifier(w.As)), params, nil
+}
+
+// windowOperand generates the expression of a field a window function reads, or of an aggregate
+// of the query named by its alias.
+func (g *SQLGenerator) windowOperand(req *QueryRequest, field string) (string, []interface{}, error) {
+	if agg := req.aggregateAlias(field); agg != nil {
+		sql, params, err := g.generateAggregate(*agg)
+		if err != nil {
+			return "", nil, err
+		}
+		return strings.TrimSuffix(sql, " AS "+g.quoteIdentifier(agg.As)), params, nil
+	}
+	return g.fieldExpression(field)
+}
+
+// generateQualified wraps a query with window functions in an outer query that filters its rows
+// by qualify, then sorts and pages them. The query selects the sort keys that are not aliases of
+// its windows and aggregates as hidden columns.
+func (g *SQLGenerator) generateQualified(req *QueryRequest, inner string) (string, []interface{}, error) {
+	outer := *g
+	outer.formulas = nil
+	outer.aggregates = nil
+	where, params, err := outer.generateWhere(req.Qualify)
+	if err != nil {
+		return "", nil, err
+	}
+	sql := "SELECT * FROM (" + inner + ") AS windowed"
+	if where != "" {
+		sql += " WHERE " + where
+	}
+
+	if len(req.OrderBy) > 0 {
+		orders := make([]string, len(req.OrderBy))
+		for i, order := range req.OrderBy {
+			column := fmt.Sprintf("%s%d", windowOrderColumnPrefix, i)
+			if req.isOutputAlias(order.Field) {
+				column = order.Field
+			}
+			dir := strings.ToUpper(order.Dir)
+			if dir != "ASC" && dir != "DESC" {
+				dir = "ASC"
+			}
+			orders[i] = g.quoteIdentifier(column) + " " + dir
+		}
+		sql += " ORDER BY " + strings.Join(orders, ", ")
+	}
+
+	limitClause, limitParams := g.generateLimit(req)
+	return sql + limitClause, append(params, limitParams...), nil
+}
+
+// stripWindowOrderColumns removes the hidden sort key columns of a qualified query from its rows.
+func stripWindowOrderColumns(rows []map[string]interface{}) {
+	for _, row := range rows {
+		for column := range row {
+			if strings.HasPrefix(column, windowOrderColumnPrefix) {
+				delete(row, column)
+			}
+		}
+	}
+}
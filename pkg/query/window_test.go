@@ -0,0 +1,216 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jiangfire/cornerstone/internal/models"
+)
+
+func TestParser_WindowValidation(t *testing.T) {
+	p := NewParser()
+	rank := WindowFunc{Func: "row_number", OrderBy: []OrderByClause{{Field: "data.amount", Dir: "desc"}}, As: "rn"}
+
+	require.NoError(t, p.validate(&QueryRequest{
+		From:    "records",
+		Window:  []WindowFunc{rank, {Func: "sum", Field: "data.amount", PartitionBy: []string{"data.region"}, As: "running"}},
+		Qualify: &WhereClause{And: []Condition{{Field: "rn", Op: "lte", Value: 3}}},
+	}))
+	require.NoError(t, p.validate(&QueryRequest{
+		From:      "records",
+		GroupBy:   []string{"data.region"},
+		Aggregate: []AggregateFunc{{Func: "sum", Field: "data.amount", As: "revenue"}},
+		Window:    []WindowFunc{{Func: "rank", OrderBy: []OrderByClause{{Field: "revenue", Dir: "desc"}}, As: "place"}},
+	}))
+
+	for name, req := range map[string]*QueryRequest{
+		"unknown function":   {Window: []WindowFunc{{Func: "ntile", OrderBy: rank.OrderBy, As: "n"}}},
+		"missing alias":      {Window: []WindowFunc{{Func: "rank", OrderBy: rank.OrderBy}}},
+		"dotted alias":       {Window: []WindowFunc{{Func: "rank", OrderBy: rank.OrderBy, As: "data.secret"}}},
+		"duplicate alias":    {Window: []WindowFunc{rank, rank}},
+		"missing field":      {Window: []WindowFunc{{Func: "lag", OrderBy: rank.OrderBy, As: "prev"}}},
+		"unexpected field":   {Window: []WindowFunc{{Func: "rank", Field: "data.amount", OrderBy: rank.OrderBy, As: "r"}}},
+		"offset on sum":      {Window: []WindowFunc{{Func: "sum", Field: "data.amount", Offset: 2, As: "s"}}},
+		"negative offset":    {Window: []WindowFunc{{Func: "lead", Field: "data.amount", Offset: -1, OrderBy: rank.OrderBy, As: "next"}}},
+		"ranking unordered":  {Window: []WindowFunc{{Func: "row_number", As: "rn"}}},
+		"invalid partition":  {Window: []WindowFunc{{Func: "sum", Field: "data.amount", PartitionBy: []string{"data.x'"}, As: "s"}}},
+		"invalid direction":  {Window: []WindowFunc{{Func: "rank", OrderBy: []OrderByClause{{Field: "id", Dir: "sideways"}}, As: "r"}}},
+		"qualify no window":  {Qualify: &WhereClause{And: []Condition{{Field: "rn", Value: 1}}}},
+		"qualify on a field": {Window: []WindowFunc{rank}, Qualify: &WhereClause{And: []Condition{{Field: "data.amount", Op: "gt", Value: 1}}}},
+	} {
+		req.From = "records"
+		assert.Error(t, p.validate(req), name)
+	}
+}
+
+func TestSQLGenerator_Window(t *testing.T) {
+	tests := []struct {
+		name   string
+		dbType string
+		window WindowFunc
+		sql    string
+	}{
+		{"row_number sqlite", "sqlite",
+			WindowFunc{Func: "row_number", PartitionBy: []string{"data.region"}, OrderBy: []OrderByClause{{Field: "data.amount", Dir: "desc"}}, As: "rn"},
+			`ROW_NUMBER() OVER (PARTITION BY JSON_EXTRACT("data", '$.region') ORDER BY JSON_EXTRACT("data", '$.amount') DESC) AS "rn"`},
+		{"dense_rank mysql", "mysql",
+			WindowFunc{Func: "dense_rank", OrderBy: []OrderByClause{{Field: "created_at"}}, As: "place"},
+			"DENSE_RANK() OVER (ORDER BY `created_at` ASC) AS `place`"},
+		{"lag postgres", "postgres",
+			WindowFunc{Func: "lag", Field: "data.amount", Offset: 2, OrderBy: []OrderByClause{{Field: "created_at"}}, As: "prev"},
+			`LAG("data"->>'amount', 2) OVER (ORDER BY "created_at" ASC) AS "prev"`},
+		{"lead sqlite", "sqlite",
+			WindowFunc{Func: "lead", Field: "id", OrderBy: []OrderByClause{{Field: "created_at"}}, As: "next"},
+			`LEAD("id", 1) OVER (ORDER BY "created_at" ASC) AS "next"`},
+		{"running sum postgres", "postgres",
+			WindowFunc{Func: "sum", Field: "data.amount", OrderBy: []OrderByClause{{Field: "created_at"}}, As: "running"},
+			`SUM(CAST("data"->>'amount' AS numeric)) OVER (ORDER BY "created_at" ASC ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW) AS "running"`},
+		{"partition avg mysql", "mysql",
+			WindowFunc{Func: "avg", Field: "data.amount", PartitionBy: []string{"table_id"}, As: "mean"},
+			"AVG(JSON_EXTRACT(`data`, '$.amount')) OVER (PARTITION BY `table_id`) AS `mean`"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, params, err := NewSQLGeneratorWithDBType(tt.dbType).generateWindow(&QueryRequest{}, tt.window)
+			require.NoError(t, err)
+			assert.Equal(t, tt.sql, sql)
+			assert.Empty(t, params)
+		})
+	}
+}
+
+func TestSQLGenerator_Qualify(t *testing.T) {
+	g := NewSQLGeneratorWithDBType("sqlite")
+	q, err := g.Generate(&QueryRequest{
+		From:    "records",
+		Select:  []string{"id"},
+		Window:  []WindowFunc{{Func: "row_number", OrderBy: []OrderByClause{{Field: "data.amount", Dir: "desc"}}, As: "rn"}},
+		Qualify: &WhereClause{And: []Condition{{Field: "rn", Op: "lte", Value: 3}}},
+		OrderBy: []OrderByClause{{Field: "data.region"}, {Field: "rn"}},
+		Page:    1,
+		Size:    10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT * FROM (SELECT "id", ROW_NUMBER() OVER (ORDER BY JSON_EXTRACT("data", '$.amount') DESC) AS "rn", `+
+		`JSON_EXTRACT("data", '$.region') AS "__order_0" FROM "records") AS windowed WHERE "rn" <= ? `+
+		`ORDER BY "__order_0" ASC, "rn" ASC LIMIT ? OFFSET ?`, q.SQL)
+	assert.Equal(t, []interface{}{3, 10, 0}, q.Params)
+}
+
+func TestExecutor_Window(t *testing.T) {
+	db := setupQueryTestDB(t)
+	_, tbl := createTestData(t, db)
+	for _, data := range []string{
+		`{"region":"east","day":"2024-01-01","amount":10}`,
+		`{"region":"east","day":"2024-01-02","amount":30}`,
+		`{"region":"east","day":"2024-01-03","amount":20}`,
+		`{"region":"west","day":"2024-01-01","amount":5}`,
+		`{"region":"west","day":"2024-01-02","amount":50}`,
+	} {
+		require.NoError(t, db.Create(&models.Record{TableID: tbl.ID, Data: models.JSONField(data)}).Error)
+	}
+	executor := NewExecutor(db)
+	run := func(req *QueryRequest) []map[string]interface{} {
+		t.Helper()
+		req.From, req.Page, req.Size = "records", 1, 20
+		result, err := executor.Execute(context.Background(), req, "user1")
+		require.NoError(t, err)
+		return result.Data
+	}
+	amounts := func(rows []map[string]interface{}, column string) []float64 {
+		values := make([]float64, len(rows))
+		for i, row := range rows {
+			switch v := row[column].(type) {
+			case int64:
+				values[i] = float64(v)
+			case float64:
+				values[i] = v
+			}
+		}
+		return values
+	}
+
+	t.Run("top N per group", func(t *testing.T) {
+		rows := run(&QueryRequest{
+			Select: []string{"data.amount"},
+			Window: []WindowFunc{{
+				Func:        "row_number",
+				PartitionBy: []string{"data.region"},
+				OrderBy:     []OrderByClause{{Field: "data.amount", Dir: "desc"}},
+				As:          "rn",
+			}},
+			Qualify: &WhereClause{And: []Condition{{Field: "rn", Op: "eq", Value: 1}}},
+			OrderBy: []OrderByClause{{Field: "data.region"}},
+		})
+		require.Len(t, rows, 2)
+		var top []map[string]interface{}
+		for _, row := range rows {
+			assert.Len(t, row, 2, "hidden sort columns are removed")
+			assert.EqualValues(t, 1, row["rn"])
+			for column, value := range row {
+				if column != "rn" {
+					top = append(top, map[string]interface{}{"amount": value})
+				}
+			}
+		}
+		assert.Equal(t, []float64{30, 50}, amounts(top, "amount"))
+	})
+
+	t.Run("running sum and previous row", func(t *testing.T) {
+		rows := run(&QueryRequest{
+			Select: []string{"data.day"},
+			Where:  &WhereClause{And: []Condition{{Field: "data.region", Value: "east"}}},
+			Window: []WindowFunc{
+				{Func: "sum", Field: "data.amount", OrderBy: []OrderByClause{{Field: "data.day"}}, As: "running"},
+				{Func: "lag", Field: "data.amount", OrderBy: []OrderByClause{{Field: "data.day"}}, As: "prev"},
+				{Func: "lead", Field: "data.amount", OrderBy: []OrderByClause{{Field: "data.day"}}, As: "next"},
+			},
+			OrderBy: []OrderByClause{{Field: "data.day"}},
+		})
+		require.Len(t, rows, 3)
+		assert.Equal(t, []float64{10, 40, 60}, amounts(rows, "running"))
+		assert.Nil(t, rows[0]["prev"])
+		assert.Equal(t, []float64{10, 30}, amounts(rows[1:], "prev"))
+		assert.Equal(t, []float64{30, 20}, amounts(rows[:2], "next"))
+	})
+
+	t.Run("rank of aggregates", func(t *testing.T) {
+		rows := run(&QueryRequest{
+			Select:    []string{"data.region"},
+			GroupBy:   []string{"data.region"},
+			Aggregate: []AggregateFunc{{Func: "sum", Field: "data.amount", As: "revenue"}},
+			Window:    []WindowFunc{{Func: "rank", OrderBy: []OrderByClause{{Field: "revenue", Dir: "desc"}}, As: "place"}},
+			OrderBy:   []OrderByClause{{Field: "place"}},
+		})
+		require.Len(t, rows, 2)
+		assert.Equal(t, []float64{60, 55}, amounts(rows, "revenue"))
+		assert.Equal(t, []float64{1, 2}, amounts(rows, "place"))
+	})
+
+	t.Run("qualified count", func(t *testing.T) {
+		result, err := executor.Execute(context.Background(), &QueryRequest{
+			From:    "records",
+			Select:  []string{"id"},
+			Window:  []WindowFunc{{Func: "dense_rank", OrderBy: []OrderByClause{{Field: "data.region"}}, As: "region_rank"}},
+			Qualify: &WhereClause{And: []Condition{{Field: "region_rank", Value: 2}}},
+			Page:    1,
+			Size:    1,
+		}, "user1")
+		require.NoError(t, err)
+		assert.Len(t, result.Data, 1)
+		assert.EqualValues(t, 2, result.Total)
+		assert.True(t, result.HasMore)
+	})
+
+	t.Run("cursor pages are rejected", func(t *testing.T) {
+		_, err := executor.Execute(context.Background(), &QueryRequest{
+			From:   "records",
+			Window: []WindowFunc{{Func: "sum", Field: "data.amount", As: "total"}},
+			Cursor: true,
+		}, "user1")
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+}
@@ -0,0 +1,385 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kinds of number formats, by what a formatted serial number shows.
+const (
+	formatNumber = iota
+	formatDate
+	formatDateTime
+)
+
+// Reader reads the sheets of a workbook. The shared strings and styles are read when it is
+// opened; the rows of a sheet are streamed by Rows.
+type Reader struct {
+	zr       *zip.Reader
+	sheets   []sheetEntry
+	strings  []string
+	formats  []int // number format kind of each cell style
+	date1904 bool
+}
+
+type sheetEntry struct {
+	name string
+	path string
+}
+
+type xmlWorkbook struct {
+	Properties struct {
+		Date1904 string `xml:"date1904,attr"`
+	} `xml:"workbookPr"`
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xmlRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Type   string `xml:"Type,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xmlText is rich or plain text: a string item, or the inline string of a cell.
+type xmlText struct {
+	T    *string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xmlText) String() string {
+	if t.T != nil {
+		return *t.T
+	}
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.T)
+	}
+	return b.String()
+}
+
+type xmlStyles struct {
+	NumFmts []struct {
+		ID   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	CellXfs []struct {
+		NumFmtID int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+type xmlCell struct {
+	Ref    string  `xml:"r,attr"`
+	Type   string  `xml:"t,attr"`
+	Style  int     `xml:"s,attr"`
+	Value  string  `xml:"v"`
+	Inline xmlText `xml:"is"`
+}
+
+// NewReader opens the workbook of size bytes read from r.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("not an xlsx workbook: %w", err)
+	}
+	reader := &Reader{zr: zr}
+
+	var workbook xmlWorkbook
+	if err := reader.decodePart("xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	var rels xmlRelationships
+	if err := reader.decodePart("xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string, len(rels.Relationships))
+	sharedStrings, styles := "xl/sharedStrings.xml", "xl/styles.xml"
+	for _, rel := range rels.Relationships {
+		target := partPath(rel.Target)
+		targets[rel.ID] = target
+		switch {
+		case strings.HasSuffix(rel.Type, "/sharedStrings"):
+			sharedStrings = target
+		case strings.HasSuffix(rel.Type, "/styles"):
+			styles = target
+		}
+	}
+	reader.date1904, _ = strconv.ParseBool(workbook.Properties.Date1904)
+	for _, sheet := range workbook.Sheets {
+		target, ok := targets[sheet.RID]
+		if !ok {
+			return nil, fmt.Errorf("sheet '%s' has no part in the workbook", sheet.Name)
+		}
+		reader.sheets = append(reader.sheets, sheetEntry{name: sheet.Name, path: target})
+	}
+
+	if reader.hasPart(sharedStrings) {
+		var shared struct {
+			Items []xmlText `xml:"si"`
+		}
+		if err := reader.decodePart(sharedStrings, &shared); err != nil {
+			return nil, err
+		}
+		reader.strings = make([]string, len(shared.Items))
+		for i, item := range shared.Items {
+			reader.strings[i] = item.String()
+		}
+	}
+	if reader.hasPart(styles) {
+		var parsed xmlStyles
+		if err := reader.decodePart(styles, &parsed); err != nil {
+			return nil, err
+		}
+		codes := make(map[int]string, len(parsed.NumFmts))
+		for _, format := range parsed.NumFmts {
+			codes[format.ID] = format.Code
+		}
+		reader.formats = make([]int, len(parsed.CellXfs))
+		for i, xf := range parsed.CellXfs {
+			reader.formats[i] = numberFormatKind(xf.NumFmtID, codes[xf.NumFmtID])
+		}
+	}
+	return reader, nil
+}
+
+// partPath returns the path in the package of a target relative to xl/.
+func partPath(target string) string {
+	if strings.HasPrefix(target, "/") {
+		return strings.TrimPrefix(target, "/")
+	}
+	return path.Join("xl", target)
+}
+
+func (r *Reader) hasPart(name string) bool {
+	for _, f := range r.zr.File {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reader) openPart(name string) (io.ReadCloser, error) {
+	for _, f := range r.zr.File {
+		if f.Name == name {
+			return f.Open()
+		}
+	}
+	return nil, fmt.Errorf("the workbook has no %s", name)
+}
+
+func (r *Reader) decodePart(name string, v interface{}) error {
+	part, err := r.openPart(name)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = part.Close() }()
+	if err := xml.NewDecoder(part).Decode(v); err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return nil
+}
+
+// SheetNames returns the names of the sheets, in workbook order.
+func (r *Reader) SheetNames() []string {
+	names := make([]string, len(r.sheets))
+	for i, sheet := range r.sheets {
+		names[i] = sheet.name
+	}
+	return names
+}
+
+// Rows returns the rows of the sheet at index.
+func (r *Reader) Rows(index int) (*Rows, error) {
+	if index < 0 || index >= len(r.sheets) {
+		return nil, fmt.Errorf("the workbook has no sheet %d", index+1)
+	}
+	part, err := r.openPart(r.sheets[index].path)
+	if err != nil {
+		return nil, err
+	}
+	return &Rows{reader: r, part: part, dec: xml.NewDecoder(part)}, nil
+}
+
+// Rows streams the rows of a sheet.
+type Rows struct {
+	reader *Reader
+	part   io.ReadCloser
+	dec    *xml.Decoder
+	number int
+}
+
+// Next returns the 1-based number and the cells of the next row with a value, or io.EOF
+// after the last one. Cells are indexed by column, nil when empty, and hold strings, float64
+// numbers, booleans, Date for numbers formatted as dates and time.Time for those formatted
+// with a time of day. Error cells hold their error text, like "#N/A".
+func (rows *Rows) Next() (int, []interface{}, error) {
+	var cells []interface{}
+	inRow := false
+	for {
+		token, err := rows.dec.Token()
+		if errors.Is(err, io.EOF) {
+			return 0, nil, io.EOF
+		}
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid sheet: %w", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "row":
+				inRow, cells = true, nil
+				rows.number++
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "r" {
+						if number, err := strconv.Atoi(attr.Value); err == nil {
+							rows.number = number
+						}
+					}
+				}
+			case "c":
+				if !inRow {
+					continue
+				}
+				var cell xmlCell
+				if err := rows.dec.DecodeElement(&cell, &t); err != nil {
+					return 0, nil, fmt.Errorf("invalid cell in row %d: %w", rows.number, err)
+				}
+				column := columnIndex(cell.Ref)
+				if column < 0 {
+					column = len(cells)
+				}
+				value, err := rows.reader.cellValue(cell)
+				if err != nil {
+					return 0, nil, fmt.Errorf("cell %s: %w", CellRef(column, rows.number), err)
+				}
+				if value == nil {
+					continue
+				}
+				for len(cells) <= column {
+					cells = append(cells, nil)
+				}
+				cells[column] = value
+			}
+		case xml.EndElement:
+			if t.Name.Local == "row" {
+				inRow = false
+				if len(cells) > 0 {
+					return rows.number, cells, nil
+				}
+			}
+		}
+	}
+}
+
+// Close closes the sheet.
+func (rows *Rows) Close() error {
+	return rows.part.Close()
+}
+
+// cellValue returns the value of a cell, nil when it is empty.
+func (r *Reader) cellValue(cell xmlCell) (interface{}, error) {
+	switch cell.Type {
+	case "s":
+		if cell.Value == "" {
+			return nil, nil
+		}
+		index, err := strconv.Atoi(strings.TrimSpace(cell.Value))
+		if err != nil || index < 0 || index >= len(r.strings) {
+			return nil, fmt.Errorf("invalid shared string %q", cell.Value)
+		}
+		return nilIfEmpty(r.strings[index]), nil
+	case "inlineStr":
+		return nilIfEmpty(cell.Inline.String()), nil
+	case "str", "e":
+		return nilIfEmpty(cell.Value), nil
+	case "b":
+		return strings.TrimSpace(cell.Value) == "1", nil
+	case "d":
+		if t, err := time.Parse(time.RFC3339Nano, cell.Value); err == nil {
+			return t, nil
+		}
+		return nilIfEmpty(cell.Value), nil
+	}
+
+	text := strings.TrimSpace(cell.Value)
+	if text == "" {
+		return nil, nil
+	}
+	number, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number %q", cell.Value)
+	}
+	kind := formatNumber
+	if cell.Style >= 0 && cell.Style < len(r.formats) {
+		kind = r.formats[cell.Style]
+	}
+	switch kind {
+	case formatDate:
+		return Date{serialTime(number, r.date1904)}, nil
+	case formatDateTime:
+		return serialTime(number, r.date1904), nil
+	}
+	return number, nil
+}
+
+func nilIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// numberFormatKind returns what a number format shows: a built-in format by its ID, a
+// custom one by its date and time codes outside literal text.
+func numberFormatKind(id int, code string) int {
+	switch {
+	case id >= 14 && id <= 17, id >= 27 && id <= 31, id >= 34 && id <= 36, id >= 50 && id <= 58:
+		return formatDate
+	case id >= 18 && id <= 22, id == 32, id == 33, id >= 45 && id <= 47:
+		return formatDateTime
+	case id < 164:
+		return formatNumber
+	}
+
+	var b strings.Builder
+	inQuote, inBracket := false, false
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		switch {
+		case inQuote:
+			inQuote = c != '"'
+		case inBracket:
+			inBracket = c != ']'
+		case c == '"':
+			inQuote = true
+		case c == '[':
+			inBracket = true
+		case c == '\\' || c == '_' || c == '*':
+			i++ // the next character is literal, or padding
+		default:
+			b.WriteByte(c)
+		}
+	}
+	plain := strings.ToLower(b.String())
+	switch {
+	case strings.ContainsAny(plain, "hs"):
+		return formatDateTime
+	case strings.ContainsAny(plain, "ymd"):
+		return formatDate
+	}
+	return formatNumber
+}
@@ -0,0 +1,217 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Styles of written cells, indexes into the cellXfs of styles.xml.
+const (
+	styleDate     = 1
+	styleDateTime = 2
+)
+
+const stylesXML = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="2"><numFmt numFmtId="164" formatCode="yyyy-mm-dd"/><numFmt numFmtId="165" formatCode="yyyy-mm-dd hh:mm:ss"/></numFmts>` +
+	`<fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="3"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs>` +
+	`</styleSheet>`
+
+// Writer streams rows into the sheets of a workbook. Each sheet is written to the underlying
+// writer as its rows come, with strings inline rather than in a shared table, so memory does
+// not grow with the data; the workbook parts that list the sheets follow on Close.
+type Writer struct {
+	zw     *zip.Writer
+	sheets []string
+	sheet  io.Writer // the sheet being written
+	row    int       // rows written to it
+	buf    bytes.Buffer
+}
+
+// NewWriter returns a Writer writing a workbook to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{zw: zip.NewWriter(w)}
+}
+
+// AddSheet ends the current sheet and starts a new one. The name is made valid with
+// SheetName and unique by a numbered suffix.
+func (w *Writer) AddSheet(name string) error {
+	if err := w.endSheet(); err != nil {
+		return err
+	}
+	name = w.uniqueSheetName(SheetName(name))
+	w.sheets = append(w.sheets, name)
+	sheet, err := w.zw.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", len(w.sheets)))
+	if err != nil {
+		return err
+	}
+	w.sheet, w.row = sheet, 0
+	_, err = io.WriteString(sheet, xml.Header+`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return err
+}
+
+func (w *Writer) uniqueSheetName(name string) string {
+	taken := func(name string) bool {
+		for _, sheet := range w.sheets {
+			if strings.EqualFold(sheet, name) {
+				return true
+			}
+		}
+		return false
+	}
+	unique := name
+	for n := 2; taken(unique); n++ {
+		suffix := fmt.Sprintf(" (%d)", n)
+		runes := []rune(name)
+		if len(runes)+len(suffix) > maxSheetNameLength {
+			runes = runes[:maxSheetNameLength-len(suffix)]
+		}
+		unique = string(runes) + suffix
+	}
+	return unique
+}
+
+// WriteRow writes a row of cells to the current sheet, starting one named "Sheet1" if there
+// is none. Values may be nil (an empty cell), strings, booleans, integers, floats, Date and
+// time.Time; other values are written as their fmt.Sprint string.
+func (w *Writer) WriteRow(values ...interface{}) error {
+	if w.sheet == nil {
+		if err := w.AddSheet("Sheet1"); err != nil {
+			return err
+		}
+	}
+	w.row++
+	w.buf.Reset()
+	fmt.Fprintf(&w.buf, `<row r="%d">`, w.row)
+	for i, value := range values {
+		ref := CellRef(i, w.row)
+		switch v := value.(type) {
+		case nil:
+		case string:
+			w.writeString(ref, v)
+		case bool:
+			digit := "0"
+			if v {
+				digit = "1"
+			}
+			fmt.Fprintf(&w.buf, `<c r="%s" t="b"><v>%s</v></c>`, ref, digit)
+		case int:
+			fmt.Fprintf(&w.buf, `<c r="%s"><v>%d</v></c>`, ref, v)
+		case int64:
+			fmt.Fprintf(&w.buf, `<c r="%s"><v>%d</v></c>`, ref, v)
+		case float64:
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				w.writeString(ref, strconv.FormatFloat(v, 'g', -1, 64))
+				continue
+			}
+			fmt.Fprintf(&w.buf, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(v, 'g', -1, 64))
+		case Date:
+			day := time.Date(v.Year(), v.Month(), v.Day(), 0, 0, 0, 0, time.UTC)
+			fmt.Fprintf(&w.buf, `<c r="%s" s="%d"><v>%s</v></c>`, ref, styleDate, strconv.FormatFloat(dateSerial(day), 'f', -1, 64))
+		case time.Time:
+			fmt.Fprintf(&w.buf, `<c r="%s" s="%d"><v>%s</v></c>`, ref, styleDateTime, strconv.FormatFloat(dateSerial(v.UTC()), 'f', -1, 64))
+		default:
+			w.writeString(ref, fmt.Sprint(v))
+		}
+	}
+	w.buf.WriteString("</row>")
+	_, err := w.sheet.Write(w.buf.Bytes())
+	return err
+}
+
+func (w *Writer) writeString(ref, text string) {
+	if text == "" {
+		return
+	}
+	fmt.Fprintf(&w.buf, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+	_ = xml.EscapeText(&w.buf, []byte(text))
+	w.buf.WriteString(`</t></is></c>`)
+}
+
+// Flush flushes what has been written to the underlying writer.
+func (w *Writer) Flush() error {
+	return w.zw.Flush()
+}
+
+func (w *Writer) endSheet() error {
+	if w.sheet == nil {
+		return nil
+	}
+	_, err := io.WriteString(w.sheet, `</sheetData></worksheet>`)
+	w.sheet = nil
+	return err
+}
+
+// Close ends the current sheet and writes the parts describing the workbook. A workbook
+// without sheets gets an empty one. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if len(w.sheets) == 0 {
+		if err := w.AddSheet("Sheet1"); err != nil {
+			return err
+		}
+	}
+	if err := w.endSheet(); err != nil {
+		return err
+	}
+
+	var contentTypes, workbook, workbookRels strings.Builder
+	contentTypes.WriteString(xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	workbook.WriteString(xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	workbookRels.WriteString(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rIdStyles" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`)
+	for i, name := range w.sheets {
+		n := i + 1
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escapeAttr(name), n, n)
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, n, n)
+	}
+	contentTypes.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets></workbook>`)
+	workbookRels.WriteString(`</Relationships>`)
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", contentTypes.String()},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", workbook.String()},
+		{"xl/_rels/workbook.xml.rels", workbookRels.String()},
+		{"xl/styles.xml", stylesXML},
+	}
+	for _, part := range parts {
+		f, err := w.zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return err
+		}
+	}
+	if err := w.zw.Close(); err != nil {
+		return fmt.Errorf("failed to finish workbook: %w", err)
+	}
+	return nil
+}
+
+func escapeAttr(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,104 @@
+// Package xlsx reads and writes the cell values of Office Open XML workbooks (.xlsx) with
+// archive/zip and encoding/xml alone.
+//
+// It covers what tabular data needs and nothing of presentation: a Writer streams rows of
+// strings, numbers, booleans, dates and times into one or more sheets, and a Reader streams
+// the rows of a sheet back as the same value types. Formulas, merged cells, fonts and the
+// like are not written, and are read as their cached values or ignored.
+package xlsx
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Date is a cell value shown as a calendar date, without a time of day. A time.Time value
+// is shown with its time of day.
+type Date struct {
+	time.Time
+}
+
+const (
+	// maxSheetNameLength is the longest sheet name Excel accepts, in characters.
+	maxSheetNameLength = 31
+	secondsPerDay      = 24 * 60 * 60
+)
+
+// Epochs of the serial numbers workbooks store dates as: days since the epoch, the fraction
+// being the time of day.
+var (
+	epoch1900 = time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC)
+	epoch1904 = time.Date(1904, time.January, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// dateSerial returns the serial number of t in the 1900 date system, the writer's.
+func dateSerial(t time.Time) float64 {
+	seconds := float64(t.Unix()-epoch1900.Unix()) + float64(t.Nanosecond())/1e9
+	return seconds / secondsPerDay
+}
+
+// serialTime returns the time of a serial number, to the millisecond.
+func serialTime(serial float64, date1904 bool) time.Time {
+	epoch := epoch1900
+	if date1904 {
+		epoch = epoch1904
+	}
+	millis := math.Round(serial * secondsPerDay * 1000)
+	return epoch.Add(time.Duration(millis) * time.Millisecond)
+}
+
+// CellRef returns the A1-style reference of a cell, from a 0-based column and 1-based row.
+func CellRef(column, row int) string {
+	return columnName(column) + strconv.Itoa(row)
+}
+
+// columnName returns the letters of a 0-based column: A to Z, then AA, AB and so on.
+func columnName(column int) string {
+	var name []byte
+	for column++; column > 0; column = (column - 1) / 26 {
+		name = append([]byte{byte('A' + (column-1)%26)}, name...)
+	}
+	return string(name)
+}
+
+// columnIndex returns the 0-based column of an A1-style cell reference, or -1 when it has
+// no column letters.
+func columnIndex(ref string) int {
+	column := 0
+	i := 0
+	for ; i < len(ref); i++ {
+		c := ref[i]
+		if c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		column = column*26 + int(c-'A') + 1
+	}
+	if i == 0 {
+		return -1
+	}
+	return column - 1
+}
+
+// SheetName returns name as a valid sheet name: characters Excel rejects are replaced, and
+// it is cut to 31 characters. An empty name becomes "Sheet".
+func SheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.Trim(strings.TrimSpace(name), "'")
+	if runes := []rune(name); len(runes) > maxSheetNameLength {
+		name = string(runes[:maxSheetNameLength])
+	}
+	if name == "" {
+		return "Sheet"
+	}
+	return name
+}
@@ -0,0 +1,129 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readAll returns the rows of a sheet by row number.
+func readAll(t *testing.T, r *Reader, sheet int) map[int][]interface{} {
+	t.Helper()
+	rows, err := r.Rows(sheet)
+	require.NoError(t, err)
+	defer func() { _ = rows.Close() }()
+	all := make(map[int][]interface{})
+	for {
+		number, cells, err := rows.Next()
+		if err == io.EOF {
+			return all
+		}
+		require.NoError(t, err)
+		all[number] = cells
+	}
+}
+
+func TestCellRef(t *testing.T) {
+	for column, want := range map[int]string{0: "A1", 25: "Z1", 26: "AA1", 51: "AZ1", 52: "BA1", 701: "ZZ1", 702: "AAA1"} {
+		assert.Equal(t, want, CellRef(column, 1))
+		assert.Equal(t, column, columnIndex(want))
+	}
+	assert.Equal(t, -1, columnIndex("12"))
+}
+
+func TestSheetName(t *testing.T) {
+	assert.Equal(t, "a_b_c", SheetName("a/b:c"))
+	assert.Equal(t, "Sheet", SheetName(" '' "))
+	assert.Equal(t, 31, len(SheetName("abcdefghijklmnopqrstuvwxyz0123456789")))
+}
+
+func TestWriterReader_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	require.NoError(t, w.AddSheet("Orders"))
+	require.NoError(t, w.WriteRow("name", "count", "paid", "due", "at"))
+	at := time.Date(2024, 3, 5, 14, 30, 15, 0, time.UTC)
+	require.NoError(t, w.WriteRow("a <&> \"b\"\nc", 1.5, true, Date{time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)}, at))
+	require.NoError(t, w.WriteRow(nil, 42, false, "", nil))
+	require.NoError(t, w.AddSheet("orders"))
+	require.NoError(t, w.WriteRow("second"))
+	require.NoError(t, w.Close())
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Orders", "orders (2)"}, r.SheetNames())
+
+	rows := readAll(t, r, 0)
+	assert.Equal(t, []interface{}{"name", "count", "paid", "due", "at"}, rows[1])
+	assert.Equal(t, []interface{}{"a <&> \"b\"\nc", 1.5, true, Date{time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)}, at}, rows[2])
+	assert.Equal(t, []interface{}{nil, float64(42), false}, rows[3])
+	assert.Equal(t, map[int][]interface{}{1: {"second"}}, readAll(t, r, 1))
+
+	_, err = r.Rows(2)
+	assert.Error(t, err)
+}
+
+// writeZip builds a package from part names and contents.
+func writeZip(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range parts {
+		f, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = io.WriteString(f, content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestReader_SpreadsheetFeatures(t *testing.T) {
+	// Shared and rich strings, styles, the 1904 date system and rows with gaps, the way
+	// spreadsheet applications write them.
+	data := writeZip(t, map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+			<workbookPr date1904="1"/><sheets><sheet name="Data" sheetId="1" r:id="rId7"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+			<Relationship Id="rId7" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="/xl/worksheets/data.xml"/>
+			<Relationship Id="rId8" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/sharedStrings" Target="strings.xml"/>
+			<Relationship Id="rId9" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`,
+		"xl/strings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+			<si><t>name</t></si><si><r><t>ri</t></r><r><rPr><b/></rPr><t>ch</t></r><rPh><t>x</t></rPh></si></sst>`,
+		"xl/styles.xml": `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+			<numFmts><numFmt numFmtId="170" formatCode="[$-409]d\-mmm\-yy;@"/><numFmt numFmtId="171" formatCode="0.0&quot; hrs&quot;"/></numFmts>
+			<cellXfs><xf numFmtId="0"/><xf numFmtId="170"/><xf numFmtId="171"/><xf numFmtId="22"/></cellXfs></styleSheet>`,
+		"xl/worksheets/data.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+			<row r="2"><c r="A2" t="s"><v>0</v></c><c r="C2" t="s"><v>1</v></c></row>
+			<row r="3"><c r="A3" s="1"><v>0</v></c><c r="B3" s="2"><v>7.5</v></c><c r="C3" s="3"><v>1.5</v></c></row>
+			<row r="4"><c r="A4" t="e"><v>#N/A</v></c><c r="B4" t="str"><f>A1</f><v>x</v></c><c r="D4" t="inlineStr"><is><t>in</t></is></c></row>
+			<row r="5"><c r="A5"/></row>
+		</sheetData></worksheet>`,
+	})
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Data"}, r.SheetNames())
+
+	assert.Equal(t, map[int][]interface{}{
+		2: {"name", nil, "rich"},
+		3: {Date{time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)}, 7.5, time.Date(1904, 1, 2, 12, 0, 0, 0, time.UTC)},
+		4: {"#N/A", "x", nil, "in"},
+	}, readAll(t, r, 0))
+
+	_, err = NewReader(bytes.NewReader([]byte("not a zip")), 9)
+	assert.Error(t, err)
+}
+
+func TestNumberFormatKind(t *testing.T) {
+	assert.Equal(t, formatDate, numberFormatKind(14, ""))
+	assert.Equal(t, formatDateTime, numberFormatKind(22, ""))
+	assert.Equal(t, formatNumber, numberFormatKind(2, ""))
+	assert.Equal(t, formatDate, numberFormatKind(164, "yyyy/mm/dd"))
+	assert.Equal(t, formatDateTime, numberFormatKind(164, "m/d/yy h:mm"))
+	assert.Equal(t, formatNumber, numberFormatKind(164, `#,##0.00 "days";[Red]-0`))
+}